@@ -0,0 +1,263 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package actions implements a client for the Solana Actions specification:
+// it fetches action metadata, obtains transactions from action endpoints,
+// validates them, and submits them once signed.
+package actions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ActionScheme is the URL scheme of action links.
+const ActionScheme = "solana-action:"
+
+// MaxResponseSize is the maximum size of a response read from an action endpoint.
+var MaxResponseSize int64 = 1 << 20
+
+var DefaultTimeout = 30 * time.Second
+
+// ActionGetResponse is the metadata of an action, returned by a GET request.
+type ActionGetResponse struct {
+	Type        string         `json:"type,omitempty"`
+	Icon        string         `json:"icon"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Label       string         `json:"label"`
+	Disabled    bool           `json:"disabled,omitempty"`
+	Links       *ActionLinks   `json:"links,omitempty"`
+	Error       *ActionMessage `json:"error,omitempty"`
+}
+
+type ActionLinks struct {
+	Actions []LinkedAction `json:"actions"`
+}
+
+// LinkedAction is a related action; its Href may be relative to the action URL,
+// and may contain {name} placeholders for its parameters.
+type LinkedAction struct {
+	Href       string            `json:"href"`
+	Label      string            `json:"label"`
+	Parameters []ActionParameter `json:"parameters,omitempty"`
+}
+
+type ActionParameter struct {
+	Name     string `json:"name"`
+	Label    string `json:"label,omitempty"`
+	Required bool   `json:"required,omitempty"`
+}
+
+type ActionMessage struct {
+	Message string `json:"message"`
+}
+
+// ActionPostRequest is the body of a POST request to an action endpoint.
+type ActionPostRequest struct {
+	Account string `json:"account"`
+}
+
+// ActionPostResponse is returned by a POST request to an action endpoint.
+type ActionPostResponse struct {
+	// Base64-encoded transaction.
+	Transaction string `json:"transaction"`
+	Message     string `json:"message,omitempty"`
+}
+
+// ActionError is returned when an action endpoint responds with a non-2xx status.
+type ActionError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *ActionError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("action endpoint responded with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("action endpoint responded with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Client talks to action endpoints over HTTP.
+type Client struct {
+	httpClient *http.Client
+}
+
+// NewClient creates a new Client. If httpClient is nil,
+// a client with DefaultTimeout is used.
+func NewClient(httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+	return &Client{httpClient: httpClient}
+}
+
+// ResolveURL returns the action endpoint URL of an action link,
+// which may be a "solana-action:" URL, a blink URL carrying the action
+// in its "action" query parameter, or a plain https URL.
+func ResolveURL(link string) (string, error) {
+	if strings.HasPrefix(link, ActionScheme) {
+		decoded, err := url.QueryUnescape(strings.TrimPrefix(link, ActionScheme))
+		if err != nil {
+			return "", fmt.Errorf("invalid action link: %w", err)
+		}
+		link = decoded
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("invalid action link: %w", err)
+	}
+	if action := u.Query().Get("action"); action != "" {
+		return ResolveURL(action)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return "", fmt.Errorf("invalid action link: unsupported scheme %q", u.Scheme)
+	}
+	return u.String(), nil
+}
+
+// LinkedActionURL resolves the href of a linked action against the URL
+// of the action that returned it, replacing its {name} placeholders
+// with the provided parameter values.
+func LinkedActionURL(actionURL string, linked LinkedAction, params map[string]string) (string, error) {
+	href := linked.Href
+	for _, param := range linked.Parameters {
+		value, ok := params[param.Name]
+		if !ok && param.Required {
+			return "", fmt.Errorf("missing required parameter %q", param.Name)
+		}
+		href = strings.ReplaceAll(href, "{"+param.Name+"}", url.QueryEscape(value))
+	}
+	base, err := url.Parse(actionURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid action URL: %w", err)
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("invalid linked action href: %w", err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// GetAction fetches the metadata of an action.
+func (c *Client) GetAction(ctx context.Context, actionURL string) (*ActionGetResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, actionURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	var out ActionGetResponse
+	if err := c.do(req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// PostAction requests the transaction of an action for the provided account.
+func (c *Client) PostAction(ctx context.Context, actionURL string, account solana.PublicKey) (*ActionPostResponse, error) {
+	body, err := json.Marshal(ActionPostRequest{Account: account.String()})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, actionURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var out ActionPostResponse
+	if err := c.do(req, &out); err != nil {
+		return nil, err
+	}
+	if out.Transaction == "" {
+		return nil, fmt.Errorf("action response has no transaction")
+	}
+	return &out, nil
+}
+
+// FetchTransaction requests the transaction of an action for the provided account,
+// decodes it and validates it (see ValidateTransaction).
+func (c *Client) FetchTransaction(
+	ctx context.Context,
+	actionURL string,
+	account solana.PublicKey,
+	opts *ValidateOpts, // optional
+) (*solana.Transaction, *ActionPostResponse, error) {
+	resp, err := c.PostAction(ctx, actionURL, account)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, err := solana.TransactionFromBase64(resp.Transaction)
+	if err != nil {
+		return nil, resp, fmt.Errorf("unable to decode action transaction: %w", err)
+	}
+	if err := ValidateTransaction(tx, account, opts); err != nil {
+		return nil, resp, err
+	}
+	return tx, resp, nil
+}
+
+// Execute fetches and validates the transaction of an action for the signer,
+// signs it and submits it with the provided RPC client.
+func (c *Client) Execute(
+	ctx context.Context,
+	rpcClient *rpc.Client,
+	actionURL string,
+	signer solana.PrivateKey,
+	opts *ValidateOpts, // optional
+) (solana.Signature, *ActionPostResponse, error) {
+	tx, resp, err := c.FetchTransaction(ctx, actionURL, signer.PublicKey(), opts)
+	if err != nil {
+		return solana.Signature{}, resp, err
+	}
+	if err := SignTransaction(tx, signer); err != nil {
+		return solana.Signature{}, resp, err
+	}
+	sig, err := rpcClient.SendTransaction(ctx, tx)
+	if err != nil {
+		return solana.Signature{}, resp, err
+	}
+	return sig, resp, nil
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseSize))
+	if err != nil {
+		return fmt.Errorf("unable to read action response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var msg ActionMessage
+		json.Unmarshal(body, &msg)
+		return &ActionError{StatusCode: resp.StatusCode, Message: msg.Message}
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unable to decode action response: %w", err)
+	}
+	return nil
+}
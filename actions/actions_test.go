@@ -0,0 +1,144 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// newActionTransaction builds a transfer from account, paid and
+// partially signed by feePayer.
+func newActionTransaction(t *testing.T, feePayer solana.PrivateKey, account solana.PublicKey) *solana.Transaction {
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1, account, solana.NewWallet().PublicKey()).Build(),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(feePayer.PublicKey()),
+	)
+	require.NoError(t, err)
+	require.NoError(t, SignTransaction(tx, feePayer))
+	return tx
+}
+
+func TestClient_Execute(t *testing.T) {
+	feePayer := solana.NewWallet().PrivateKey
+	user := solana.NewWallet().PrivateKey
+
+	actionServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			fmt.Fprint(rw, `{"icon":"https://example.com/icon.png","title":"Donate","description":"Donate 1 lamport","label":"Donate","links":{"actions":[{"href":"/donate?amount={amount}","label":"Donate","parameters":[{"name":"amount","required":true}]}]}}`)
+		case http.MethodPost:
+			var in ActionPostRequest
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&in))
+			if in.Account != user.PublicKey().String() {
+				rw.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(rw, `{"message":"unknown account"}`)
+				return
+			}
+			raw, err := newActionTransaction(t, feePayer, user.PublicKey()).MarshalBinary()
+			require.NoError(t, err)
+			fmt.Fprintf(rw, `{"transaction":%q,"message":"thanks"}`, base64.StdEncoding.EncodeToString(raw))
+		}
+	}))
+	defer actionServer.Close()
+
+	var sent *solana.Transaction
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var in struct {
+			ID     any      `json:"id"`
+			Params []string `json:"params"`
+		}
+		json.NewDecoder(req.Body).Decode(&in)
+		sent, _ = solana.TransactionFromBase64(in.Params[0])
+		fmt.Fprintf(rw, `{"jsonrpc":"2.0","result":%q,"id":%q}`, sent.Signatures[0], fmt.Sprint(in.ID))
+	}))
+	defer rpcServer.Close()
+
+	ctx := context.Background()
+	client := NewClient(nil)
+
+	action, err := client.GetAction(ctx, actionServer.URL+"/donate")
+	require.NoError(t, err)
+	require.Equal(t, "Donate", action.Title)
+	require.Len(t, action.Links.Actions, 1)
+
+	_, err = LinkedActionURL(actionServer.URL+"/donate", action.Links.Actions[0], nil)
+	require.Error(t, err)
+	linked, err := LinkedActionURL(actionServer.URL+"/donate", action.Links.Actions[0], map[string]string{"amount": "1"})
+	require.NoError(t, err)
+	require.Equal(t, actionServer.URL+"/donate?amount=1", linked)
+
+	sig, resp, err := client.Execute(ctx, rpc.New(rpcServer.URL), linked, user, &ValidateOpts{
+		AllowedPrograms: []solana.PublicKey{solana.SystemProgramID},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "thanks", resp.Message)
+	require.Equal(t, sent.Signatures[0], sig)
+	require.NoError(t, sent.VerifySignatures())
+
+	_, _, err = client.FetchTransaction(ctx, linked, solana.NewWallet().PublicKey(), nil)
+	var actionErr *ActionError
+	require.True(t, errors.As(err, &actionErr))
+	require.Equal(t, http.StatusBadRequest, actionErr.StatusCode)
+	require.Equal(t, "unknown account", actionErr.Message)
+}
+
+func TestValidateTransaction(t *testing.T) {
+	feePayer := solana.NewWallet().PrivateKey
+	user := solana.NewWallet().PublicKey()
+
+	tx := newActionTransaction(t, feePayer, user)
+	require.NoError(t, ValidateTransaction(tx, user, nil))
+	require.ErrorIs(t, ValidateTransaction(tx, solana.NewWallet().PublicKey(), nil), ErrAccountNotSigner)
+	require.ErrorIs(t,
+		ValidateTransaction(tx, user, &ValidateOpts{AllowedPrograms: []solana.PublicKey{solana.TokenProgramID}}),
+		ErrProgramNotAllowed,
+	)
+
+	tx.Signatures[0] = solana.Signature{}
+	require.ErrorIs(t, ValidateTransaction(tx, user, nil), ErrMissingSignature)
+
+	tx.Signatures[0] = solana.Signature{1}
+	require.Error(t, ValidateTransaction(tx, user, nil))
+}
+
+func TestResolveURL(t *testing.T) {
+	for link, expected := range map[string]string{
+		"https://example.com/api/donate":                                        "https://example.com/api/donate",
+		"solana-action:https://example.com/api/donate":                          "https://example.com/api/donate",
+		"solana-action:https%3A%2F%2Fexample.com%2Fapi%2Fdonate%3Famount%3D1":   "https://example.com/api/donate?amount=1",
+		"https://dial.to/?action=solana-action%3Ahttps%3A%2F%2Fexample.com%2Fx": "https://example.com/x",
+	} {
+		got, err := ResolveURL(link)
+		require.NoError(t, err, link)
+		require.Equal(t, expected, got, link)
+	}
+	_, err := ResolveURL("ftp://example.com")
+	require.Error(t, err)
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+var (
+	ErrAccountNotSigner    = errors.New("account is not a signer of the transaction")
+	ErrMissingSignature    = errors.New("transaction is missing a signature")
+	ErrMissingBlockhash    = errors.New("transaction has no recent blockhash")
+	ErrProgramNotAllowed   = errors.New("transaction invokes a program that is not allowed")
+	ErrUnexpectedSignature = errors.New("transaction has an unexpected number of signatures")
+)
+
+type ValidateOpts struct {
+	// AllowedPrograms, if not empty, restricts the programs
+	// the transaction may invoke.
+	AllowedPrograms []solana.PublicKey
+}
+
+// ValidateTransaction checks that a transaction returned by an action endpoint
+// is safe to sign for the account:
+//   - the account is a required signer;
+//   - the transaction has a recent blockhash;
+//   - every required signature other than the account's is present and valid;
+//   - all the invoked programs are allowed (see ValidateOpts.AllowedPrograms).
+func ValidateTransaction(tx *solana.Transaction, account solana.PublicKey, opts *ValidateOpts) error {
+	if tx.Message.RecentBlockhash.IsZero() {
+		return ErrMissingBlockhash
+	}
+
+	signers := tx.Message.Signers()
+	if !signers.Has(account) {
+		return ErrAccountNotSigner
+	}
+	if len(tx.Signatures) != len(signers) {
+		return fmt.Errorf("%w: got %d signatures for %d signers", ErrUnexpectedSignature, len(tx.Signatures), len(signers))
+	}
+
+	msg, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("unable to encode transaction message: %w", err)
+	}
+	for i, signer := range signers {
+		if signer.Equals(account) {
+			continue
+		}
+		if tx.Signatures[i].IsZero() {
+			return fmt.Errorf("%w: %s", ErrMissingSignature, signer)
+		}
+		if !tx.Signatures[i].Verify(signer, msg) {
+			return fmt.Errorf("invalid signature by %s", signer)
+		}
+	}
+	if opts != nil && len(opts.AllowedPrograms) > 0 {
+		allowed := solana.PublicKeySlice(opts.AllowedPrograms)
+		programIDs, err := tx.GetProgramIDsWithError()
+		if err != nil {
+			return err
+		}
+		for _, programID := range programIDs {
+			if !allowed.Has(programID) {
+				return fmt.Errorf("%w: %s", ErrProgramNotAllowed, programID)
+			}
+		}
+	}
+	return nil
+}
+
+// SignTransaction signs the transaction with the key, placing the signature
+// in the slot of the key and leaving the other signatures untouched.
+func SignTransaction(tx *solana.Transaction, key solana.PrivateKey) error {
+	msg, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("unable to encode transaction message: %w", err)
+	}
+	signers := tx.Message.Signers()
+	if len(tx.Signatures) < len(signers) {
+		signatures := make([]solana.Signature, len(signers))
+		copy(signatures, tx.Signatures)
+		tx.Signatures = signatures
+	}
+	for i, signer := range signers {
+		if signer.Equals(key.PublicKey()) {
+			sig, err := key.Sign(msg)
+			if err != nil {
+				return fmt.Errorf("unable to sign transaction: %w", err)
+			}
+			tx.Signatures[i] = sig
+			return nil
+		}
+	}
+	return ErrAccountNotSigner
+}
@@ -0,0 +1,232 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"fmt"
+)
+
+// The base58 codec of the package works on limbs of five base58 digits
+// (encoding) or of 32 bits (decoding) instead of single digits and bytes,
+// cutting the number of multiplications of the quadratic conversion
+// by about twenty; it is equivalent to github.com/mr-tron/base58.
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Limb is the base of the limbs of the encoder: 58^5 < 2^30.
+const base58Limb = 58 * 58 * 58 * 58 * 58
+
+// base58Pow holds the powers of 58 up to 58^5.
+var base58Pow = [6]uint64{1, 58, 58 * 58, 58 * 58 * 58, 58 * 58 * 58 * 58, base58Limb}
+
+var base58Digits = func() (digits [256]int8) {
+	for i := range digits {
+		digits[i] = -1
+	}
+	for i := 0; i < len(base58Alphabet); i++ {
+		digits[base58Alphabet[i]] = int8(i)
+	}
+	return
+}()
+
+// limbsOnStack is the number of limbs that fit in the arrays allocated
+// on the stack, enough for signatures (64 bytes, 88 digits).
+const limbsOnStack = 24
+
+// encodeBase58 returns the base58 encoding of src.
+func encodeBase58(src []byte) string {
+	var buf [128]byte
+	return string(appendBase58(buf[:0], src))
+}
+
+// appendBase58 appends the base58 encoding of src to dst;
+// it doesn't allocate if dst has enough capacity, and src
+// is at most 88 bytes long.
+func appendBase58(dst []byte, src []byte) []byte {
+	zeros := 0
+	for zeros < len(src) && src[zeros] == 0 {
+		zeros++
+	}
+	rest := src[zeros:]
+
+	// Little-endian limbs of 5 digits; each limb holds at least 29 bits.
+	var stack [limbsOnStack]uint32
+	limbs := stack[:]
+	if n := len(rest)*8/29 + 1; n > len(limbs) {
+		limbs = make([]uint32, n)
+	}
+	used := 0
+	for len(rest) > 0 {
+		// Consume the input 32 bits at a time, the first word
+		// taking the bytes that are not a multiple of 4.
+		n := len(rest) % 4
+		if n == 0 {
+			n = 4
+		}
+		var word uint32
+		for _, b := range rest[:n] {
+			word = word<<8 | uint32(b)
+		}
+		rest = rest[n:]
+
+		shift := uint64(1) << (8 * n)
+		carry := uint64(word)
+		for i := 0; i < used; i++ {
+			carry += uint64(limbs[i]) * shift
+			limbs[i] = uint32(carry % base58Limb)
+			carry /= base58Limb
+		}
+		for carry > 0 {
+			limbs[used] = uint32(carry % base58Limb)
+			carry /= base58Limb
+			used++
+		}
+	}
+
+	for i := 0; i < zeros; i++ {
+		dst = append(dst, base58Alphabet[0])
+	}
+	if used == 0 {
+		return dst
+	}
+	// The most significant limb is written without its leading zeros.
+	var digits [5]byte
+	k := len(digits)
+	for v := limbs[used-1]; v > 0; v /= 58 {
+		k--
+		digits[k] = base58Alphabet[v%58]
+	}
+	dst = append(dst, digits[k:]...)
+	for i := used - 2; i >= 0; i-- {
+		v := limbs[i]
+		for j := len(digits) - 1; j >= 0; j-- {
+			digits[j] = base58Alphabet[v%58]
+			v /= 58
+		}
+		dst = append(dst, digits[:]...)
+	}
+	return dst
+}
+
+// decodeBase58 returns the bytes encoded in base58 by s.
+func decodeBase58(s string) ([]byte, error) {
+	var stack [limbsOnStack]uint32
+	limbs, zeros, err := base58Limbs(stack[:], s)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, zeros+limbsLen(limbs))
+	putLimbs(out[zeros:], limbs)
+	return out, nil
+}
+
+// decodeBase58Into decodes s into dst, without allocating if s decodes
+// into at most 96 bytes. It returns the length of the decoded bytes,
+// and writes them only if it is exactly len(dst).
+func decodeBase58Into(dst []byte, s string) (int, error) {
+	var stack [limbsOnStack]uint32
+	limbs, zeros, err := base58Limbs(stack[:], s)
+	if err != nil {
+		return 0, err
+	}
+	n := zeros + limbsLen(limbs)
+	if n != len(dst) {
+		return n, nil
+	}
+	for i := 0; i < zeros; i++ {
+		dst[i] = 0
+	}
+	putLimbs(dst[zeros:], limbs)
+	return n, nil
+}
+
+// base58Limbs returns the value encoded by s, after its leading zeros,
+// as little-endian 32-bit limbs without leading zero limbs,
+// using buf if it is large enough; and the number of leading zeros.
+func base58Limbs(buf []uint32, s string) (limbs []uint32, zeros int, err error) {
+	if len(s) == 0 {
+		return nil, 0, fmt.Errorf("zero length string")
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c > 127 {
+			return nil, 0, fmt.Errorf("high-bit set on invalid digit")
+		}
+		if base58Digits[c] == -1 {
+			return nil, 0, fmt.Errorf("invalid base58 digit (%q)", rune(c))
+		}
+	}
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	rest := s[zeros:]
+
+	// Each digit holds less than 6 bits.
+	if n := len(rest)*6/32 + 1; n > len(buf) {
+		buf = make([]uint32, n)
+	}
+	used := 0
+	for len(rest) > 0 {
+		// Consume the digits 5 at a time, the first chunk
+		// taking the digits that are not a multiple of 5.
+		n := len(rest) % 5
+		if n == 0 {
+			n = 5
+		}
+		var chunk uint64
+		for i := 0; i < n; i++ {
+			chunk = chunk*58 + uint64(base58Digits[rest[i]])
+		}
+		rest = rest[n:]
+
+		mul := base58Pow[n]
+		carry := chunk
+		for i := 0; i < used; i++ {
+			carry += uint64(buf[i]) * mul
+			buf[i] = uint32(carry)
+			carry >>= 32
+		}
+		for carry > 0 {
+			buf[used] = uint32(carry)
+			carry >>= 32
+			used++
+		}
+	}
+	return buf[:used], zeros, nil
+}
+
+// limbsLen returns the number of bytes of the value of the limbs.
+func limbsLen(limbs []uint32) int {
+	if len(limbs) == 0 {
+		return 0
+	}
+	n := (len(limbs) - 1) * 4
+	for top := limbs[len(limbs)-1]; top > 0; top >>= 8 {
+		n++
+	}
+	return n
+}
+
+// putLimbs writes the value of the limbs, big-endian, into dst of limbsLen bytes.
+func putLimbs(dst []byte, limbs []uint32) {
+	i := len(dst) - 1
+	for _, limb := range limbs {
+		for j := 0; j < 4 && i >= 0; j++ {
+			dst[i] = byte(limb)
+			limb >>= 8
+			i--
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBase58Equivalence(t *testing.T) {
+	inputs := [][]byte{
+		nil,
+		{0},
+		{0, 0, 0},
+		{0, 0, 1},
+		{0xff},
+		{0xff, 0xff, 0xff, 0xff, 0xff},
+		bytes.Repeat([]byte{0xff}, 64),
+		bytes.Repeat([]byte{0}, 32),
+	}
+	for i := 0; i < 200; i++ {
+		buf := make([]byte, i%100)
+		_, err := rand.Read(buf)
+		require.NoError(t, err)
+		inputs = append(inputs, buf)
+	}
+
+	for _, in := range inputs {
+		expected := base58.Encode(in)
+		require.Equal(t, expected, encodeBase58(in), "%x", in)
+
+		if len(in) == 0 {
+			continue
+		}
+		got, err := decodeBase58(expected)
+		require.NoError(t, err)
+		require.Equal(t, in, got)
+	}
+}
+
+func TestBase58DecodeErrors(t *testing.T) {
+	for _, in := range []string{"", "0", "O", "I", "l", "abc+", "é", "1\xff"} {
+		_, expected := base58.Decode(in)
+		_, err := decodeBase58(in)
+		require.Error(t, err, "%q", in)
+		assert.Equal(t, expected.Error(), err.Error(), "%q", in)
+	}
+}
+
+func TestDecodeBase58Into(t *testing.T) {
+	pk := newUniqueTestPubkey(t)
+
+	var out PublicKey
+	n, err := decodeBase58Into(out[:], pk.String())
+	require.NoError(t, err)
+	assert.Equal(t, PublicKeyLength, n)
+	assert.Equal(t, pk, out)
+
+	// Wrong lengths are reported without writing to dst.
+	var short [16]byte
+	n, err = decodeBase58Into(short[:], pk.String())
+	require.NoError(t, err)
+	assert.Equal(t, PublicKeyLength, n)
+	assert.Equal(t, [16]byte{}, short)
+
+	_, err = PublicKeyFromBase58(base58.Encode(make([]byte, 31)))
+	assert.EqualError(t, err, "invalid length, expected 32, got 31")
+}
+
+func TestAppendBase58NoAllocs(t *testing.T) {
+	pk := newUniqueTestPubkey(t)
+	var sig Signature
+	_, err := rand.Read(sig[:])
+	require.NoError(t, err)
+
+	var buf [SignatureBase58MaxLength]byte
+	assert.Equal(t, base58.Encode(pk[:]), string(pk.AppendBase58(buf[:0])))
+	assert.Equal(t, base58.Encode(sig[:]), string(sig.AppendBase58(buf[:0])))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		pk.AppendBase58(buf[:0])
+		Hash(pk).AppendBase58(buf[:0])
+		sig.AppendBase58(buf[:0])
+	})
+	assert.Zero(t, allocs)
+
+	allocs = testing.AllocsPerRun(100, func() {
+		_, _ = PublicKeyFromBase58("9B5XszUGdMaxCZ7uSQhPzdks5ZQSmWxrmzCSvtJ6Ns6g")
+	})
+	assert.Zero(t, allocs)
+}
+
+func newUniqueTestPubkey(t *testing.T) PublicKey {
+	var pk PublicKey
+	_, err := rand.Read(pk[:])
+	require.NoError(t, err)
+	return pk
+}
+
+func FuzzBase58Encode(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0, 0, 1, 2, 3})
+	f.Add(bytes.Repeat([]byte{0xff}, 64))
+	f.Fuzz(func(t *testing.T, in []byte) {
+		expected := base58.Encode(in)
+		if got := encodeBase58(in); got != expected {
+			t.Fatalf("encode %x: got %q, expected %q", in, got, expected)
+		}
+	})
+}
+
+func FuzzBase58Decode(f *testing.F) {
+	f.Add("")
+	f.Add("1112")
+	f.Add("9B5XszUGdMaxCZ7uSQhPzdks5ZQSmWxrmzCSvtJ6Ns6g")
+	f.Add("0OIl")
+	f.Fuzz(func(t *testing.T, in string) {
+		expected, expectedErr := base58.Decode(in)
+		got, err := decodeBase58(in)
+		if (err != nil) != (expectedErr != nil) {
+			t.Fatalf("decode %q: got error %v, expected %v", in, err, expectedErr)
+		}
+		if err == nil && !bytes.Equal(got, expected) {
+			t.Fatalf("decode %q: got %x, expected %x", in, got, expected)
+		}
+	})
+}
+
+func BenchmarkBase58EncodePublicKey(b *testing.B) {
+	pk := MustPublicKeyFromBase58("9B5XszUGdMaxCZ7uSQhPzdks5ZQSmWxrmzCSvtJ6Ns6g")
+	b.Run("mr-tron", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = base58.Encode(pk[:])
+		}
+	})
+	b.Run("String", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = pk.String()
+		}
+	})
+	b.Run("AppendBase58", func(b *testing.B) {
+		b.ReportAllocs()
+		var buf [PublicKeyBase58MaxLength]byte
+		for i := 0; i < b.N; i++ {
+			_ = pk.AppendBase58(buf[:0])
+		}
+	})
+}
+
+func BenchmarkBase58DecodeSignature(b *testing.B) {
+	sig := "5j7s6NiJS3JAkvgkoc18WVAsiSaci2pxB2A6ueCJP4tprA2TFg9wSyTLeYouxPBJEMzJinENTkpA52YStRW5Dia7"
+	b.Run("mr-tron", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = base58.Decode(sig)
+		}
+	})
+	b.Run("SignatureFromBase58", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = SignatureFromBase58(sig)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HardenedKeyStart is the index of the first hardened child key.
+const HardenedKeyStart uint32 = 0x80000000
+
+// SolanaCoinType is the SLIP-44 coin type of Solana.
+const SolanaCoinType uint32 = 501
+
+// DerivationPath is a BIP-32 derivation path, as a list of child indexes.
+// Hardened indexes include HardenedKeyStart.
+type DerivationPath []uint32
+
+// SolanaDerivationPath returns the derivation path m/44'/501'/account'/0'
+// used by Phantom, Solflare and Ledger for the provided account index.
+func SolanaDerivationPath(account uint32) DerivationPath {
+	return DerivationPath{
+		HardenedKeyStart + 44,
+		HardenedKeyStart + SolanaCoinType,
+		HardenedKeyStart + account,
+		HardenedKeyStart + 0,
+	}
+}
+
+// ParseDerivationPath parses a derivation path such as m/44'/501'/0'/0'.
+// Hardened indexes are marked with a trailing ' or h.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q: must start with m", path)
+	}
+	out := make(DerivationPath, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= HardenedKeyStart {
+			return nil, fmt.Errorf("invalid derivation path %q: invalid index %q", path, part)
+		}
+		if hardened {
+			index += uint64(HardenedKeyStart)
+		}
+		out = append(out, uint32(index))
+	}
+	return out, nil
+}
+
+func (p DerivationPath) String() string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, index := range p {
+		b.WriteString("/")
+		if index >= HardenedKeyStart {
+			b.WriteString(strconv.FormatUint(uint64(index-HardenedKeyStart), 10))
+			b.WriteString("'")
+		} else {
+			b.WriteString(strconv.FormatUint(uint64(index), 10))
+		}
+	}
+	return b.String()
+}
+
+// PrivateKeyFromSeed derives the private key at the provided path from
+// a BIP-39 seed, following SLIP-0010 for ed25519. Since ed25519 only supports
+// hardened derivation, all the indexes of the path must be hardened.
+func PrivateKeyFromSeed(seed []byte, path DerivationPath) (PrivateKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length %d: must be between 16 and 64 bytes", len(seed))
+	}
+	key, chainCode := slip10Derive([]byte("ed25519 seed"), seed)
+	for _, index := range path {
+		if index < HardenedKeyStart {
+			return nil, errors.New("ed25519 derivation only supports hardened indexes")
+		}
+		data := make([]byte, 0, 1+len(key)+4)
+		data = append(data, 0x00)
+		data = append(data, key...)
+		data = binary.BigEndian.AppendUint32(data, index)
+		key, chainCode = slip10Derive(chainCode, data)
+	}
+	return PrivateKey(ed25519.NewKeyFromSeed(key)), nil
+}
+
+func slip10Derive(key []byte, data []byte) (privateKey []byte, chainCode []byte) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
+// NewWalletFromMnemonic derives the wallet of the provided account index
+// from a BIP-39 mnemonic, at the path m/44'/501'/account'/0'
+// (see SolanaDerivationPath), like Phantom, Solflare and Ledger do.
+func NewWalletFromMnemonic(mnemonic string, account uint32) (*Wallet, error) {
+	return NewWalletFromMnemonicWithPath(mnemonic, "", SolanaDerivationPath(account))
+}
+
+// NewWalletFromMnemonicWithPath derives the wallet at the provided path
+// from a BIP-39 mnemonic and (optional) passphrase.
+func NewWalletFromMnemonicWithPath(mnemonic string, passphrase string, path DerivationPath) (*Wallet, error) {
+	seed, err := SeedFromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("wallet from mnemonic: %w", err)
+	}
+	k, err := PrivateKeyFromSeed(seed, path)
+	if err != nil {
+		return nil, fmt.Errorf("wallet from mnemonic: %w", err)
+	}
+	return &Wallet{
+		PrivateKey: k,
+	}, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestMnemonic(t *testing.T) {
+	mnemonic, err := MnemonicFromEntropy(make([]byte, 16))
+	require.NoError(t, err)
+	require.Equal(t, testMnemonic, mnemonic)
+
+	entropy, err := hex.DecodeString("7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f")
+	require.NoError(t, err)
+	mnemonic, err = MnemonicFromEntropy(entropy)
+	require.NoError(t, err)
+	require.Equal(t, "legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth title", mnemonic)
+
+	decoded, err := EntropyFromMnemonic(mnemonic)
+	require.NoError(t, err)
+	require.Equal(t, entropy, decoded)
+
+	seed, err := SeedFromMnemonic(testMnemonic, "TREZOR")
+	require.NoError(t, err)
+	require.Equal(t, "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04", hex.EncodeToString(seed))
+
+	for _, bitSize := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err := NewMnemonic(bitSize)
+		require.NoError(t, err)
+		require.Len(t, strings.Fields(mnemonic), bitSize*3/32)
+		require.True(t, IsValidMnemonic(mnemonic))
+	}
+	_, err = NewMnemonic(100)
+	require.Error(t, err)
+
+	require.False(t, IsValidMnemonic(strings.Replace(testMnemonic, "about", "abandon", 1)))
+	require.False(t, IsValidMnemonic("abandon abandon"))
+	_, err = SeedFromMnemonic(strings.Replace(testMnemonic, "about", "solana", 1), "")
+	require.ErrorIs(t, err, ErrInvalidMnemonic)
+}
+
+func TestDerivationPath(t *testing.T) {
+	path, err := ParseDerivationPath("m/44'/501'/3'/0'")
+	require.NoError(t, err)
+	require.Equal(t, SolanaDerivationPath(3), path)
+	require.Equal(t, "m/44'/501'/3'/0'", path.String())
+
+	path, err = ParseDerivationPath("m/44h/501h/0")
+	require.NoError(t, err)
+	require.Equal(t, "m/44'/501'/0", path.String())
+
+	for _, invalid := range []string{"44'/501'", "m/x'", "m/2147483648"} {
+		_, err := ParseDerivationPath(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestPrivateKeyFromSeed(t *testing.T) {
+	// SLIP-0010 test vector 1 for ed25519.
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+
+	key, err := PrivateKeyFromSeed(seed, DerivationPath{})
+	require.NoError(t, err)
+	require.Equal(t, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7", hex.EncodeToString(key[:32]))
+
+	path, err := ParseDerivationPath("m/0'")
+	require.NoError(t, err)
+	key, err = PrivateKeyFromSeed(seed, path)
+	require.NoError(t, err)
+	require.Equal(t, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3", hex.EncodeToString(key[:32]))
+
+	_, err = PrivateKeyFromSeed(seed, DerivationPath{0})
+	require.Error(t, err)
+}
+
+func TestNewWalletFromMnemonic(t *testing.T) {
+	wallet, err := NewWalletFromMnemonic(testMnemonic, 0)
+	require.NoError(t, err)
+	require.Equal(t, MustPublicKeyFromBase58("HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk"), wallet.PublicKey())
+
+	other, err := NewWalletFromMnemonic(testMnemonic, 1)
+	require.NoError(t, err)
+	require.NotEqual(t, wallet.PublicKey(), other.PublicKey())
+
+	_, err = NewWalletFromMnemonic("not a mnemonic", 0)
+	require.ErrorIs(t, err, ErrInvalidMnemonic)
+}
@@ -30,7 +30,6 @@ import (
 	"sort"
 
 	"filippo.io/edwards25519"
-	"github.com/mr-tron/base58"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
 )
@@ -46,30 +45,26 @@ func MustPrivateKeyFromBase58(in string) PrivateKey {
 }
 
 func PrivateKeyFromBase58(privkey string) (PrivateKey, error) {
-	res, err := base58.Decode(privkey)
+	res, err := decodeBase58(privkey)
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 }
 
+// PrivateKeyFromSolanaKeygenFile reads a private key from a file
+// in the format of the Solana CLI (see PrivateKeyFromSolanaKeygenBytes).
 func PrivateKeyFromSolanaKeygenFile(file string) (PrivateKey, error) {
 	content, err := ioutil.ReadFile(file)
 	if err != nil {
 		return nil, fmt.Errorf("read keygen file: %w", err)
 	}
 
-	var values []byte
-	err = json.Unmarshal(content, &values)
-	if err != nil {
-		return nil, fmt.Errorf("decode keygen file: %w", err)
-	}
-
-	return PrivateKey([]byte(values)), nil
+	return PrivateKeyFromSolanaKeygenBytes(content)
 }
 
 func (k PrivateKey) String() string {
-	return base58.Encode(k)
+	return encodeBase58(k)
 }
 
 func NewRandomPrivateKey() (PrivateKey, error) {
@@ -144,21 +139,19 @@ func MustPublicKeyFromBase58(in string) PublicKey {
 }
 
 func PublicKeyFromBase58(in string) (out PublicKey, err error) {
-	val, err := base58.Decode(in)
+	n, err := decodeBase58Into(out[:], in)
 	if err != nil {
 		return out, fmt.Errorf("decode: %w", err)
 	}
 
-	if len(val) != PublicKeyLength {
-		return out, fmt.Errorf("invalid length, expected %v, got %d", PublicKeyLength, len(val))
+	if n != PublicKeyLength {
+		return out, fmt.Errorf("invalid length, expected %v, got %d", PublicKeyLength, n)
 	}
-
-	copy(out[:], val)
 	return
 }
 
 func (p PublicKey) MarshalText() ([]byte, error) {
-	return []byte(base58.Encode(p[:])), nil
+	return p.AppendBase58(make([]byte, 0, PublicKeyBase58MaxLength)), nil
 }
 
 func (p *PublicKey) UnmarshalText(data []byte) error {
@@ -166,7 +159,9 @@ func (p *PublicKey) UnmarshalText(data []byte) error {
 }
 
 func (p PublicKey) MarshalJSON() ([]byte, error) {
-	return json.Marshal(base58.Encode(p[:]))
+	// The base58 alphabet needs no escaping.
+	buf := append(make([]byte, 0, PublicKeyBase58MaxLength+2), '"')
+	return append(p.AppendBase58(buf), '"'), nil
 }
 
 func (p *PublicKey) UnmarshalJSON(data []byte) (err error) {
@@ -265,7 +260,17 @@ func (p *PublicKey) Set(s string) (err error) {
 }
 
 func (p PublicKey) String() string {
-	return base58.Encode(p[:])
+	var buf [PublicKeyBase58MaxLength]byte
+	return string(p.AppendBase58(buf[:0]))
+}
+
+// PublicKeyBase58MaxLength is the maximum length of the base58 encoding of a public key.
+const PublicKeyBase58MaxLength = 44
+
+// AppendBase58 appends the base58 encoding of the public key to dst,
+// without allocating if dst has a capacity of PublicKeyBase58MaxLength left.
+func (p PublicKey) AppendBase58(dst []byte) []byte {
+	return appendBase58(dst, p[:])
 }
 
 // Short returns a shortened pubkey string,
@@ -641,13 +646,27 @@ func FindProgramAddress(seed [][]byte, programID PublicKey) (PublicKey, uint8, e
 	return PublicKey{}, bumpSeed, errors.New("unable to find a valid program address")
 }
 
+// FindAssociatedTokenAddress returns the associated token account address
+// of wallet for a mint of the SPL Token program.
 func FindAssociatedTokenAddress(
 	wallet PublicKey,
 	mint PublicKey,
+) (PublicKey, uint8, error) {
+	return FindAssociatedTokenAddressWithProgramID(wallet, mint, TokenProgramID)
+}
+
+// FindAssociatedTokenAddressWithProgramID returns the associated token account
+// address of wallet for a mint owned by tokenProgramID
+// (i.e. TokenProgramID or Token2022ProgramID).
+func FindAssociatedTokenAddressWithProgramID(
+	wallet PublicKey,
+	mint PublicKey,
+	tokenProgramID PublicKey,
 ) (PublicKey, uint8, error) {
 	return findAssociatedTokenAddressAndBumpSeed(
 		wallet,
 		mint,
+		tokenProgramID,
 		SPLAssociatedTokenAccountProgramID,
 	)
 }
@@ -655,11 +674,12 @@ func FindAssociatedTokenAddress(
 func findAssociatedTokenAddressAndBumpSeed(
 	walletAddress PublicKey,
 	splTokenMintAddress PublicKey,
+	tokenProgramID PublicKey,
 	programID PublicKey,
 ) (PublicKey, uint8, error) {
 	return FindProgramAddress([][]byte{
 		walletAddress[:],
-		TokenProgramID[:],
+		tokenProgramID[:],
 		splTokenMintAddress[:],
 	},
 		programID,
@@ -618,3 +618,29 @@ func TestFindTokenMetadataAddress(t *testing.T) {
 	assert.Equal(t, metadataPDA, MustPublicKeyFromBase58("GfihrEYCPrvUyrMyMQPdhGEStxa9nKEK2Wfn9iK4AZq2"))
 	assert.Equal(t, bumpSeed, uint8(0xfd))
 }
+
+func TestFindAssociatedTokenAddressWithProgramID(t *testing.T) {
+	wallet := MustPublicKeyFromBase58("7HZaCWazgTuuFuajxaaxGYbGnyVKwxvsJKue1W4Nvyro")
+	mint := MustPublicKeyFromBase58("So11111111111111111111111111111111111111112")
+
+	ata, bump, err := FindAssociatedTokenAddress(wallet, mint)
+	require.NoError(t, err)
+	{
+		got, gotBump, err := FindAssociatedTokenAddressWithProgramID(wallet, mint, TokenProgramID)
+		require.NoError(t, err)
+		require.Equal(t, ata, got)
+		require.Equal(t, bump, gotBump)
+	}
+	{
+		got, _, err := FindAssociatedTokenAddressWithProgramID(wallet, mint, Token2022ProgramID)
+		require.NoError(t, err)
+		require.NotEqual(t, ata, got)
+
+		expected, _, err := FindProgramAddress(
+			[][]byte{wallet[:], Token2022ProgramID[:], mint[:]},
+			SPLAssociatedTokenAccountProgramID,
+		)
+		require.NoError(t, err)
+		require.Equal(t, expected, got)
+	}
+}
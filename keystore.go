@@ -0,0 +1,330 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	crypto_rand "crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ErrInvalidPassphrase is returned when a keystore cannot be decrypted
+// with the provided passphrase (or was tampered with).
+var ErrInvalidPassphrase = errors.New("invalid keystore passphrase")
+
+// PrivateKeyFromSolanaKeygenBytes decodes a private key from the JSON array
+// of 64 bytes used by the Solana CLI (e.g. ~/.config/solana/id.json),
+// and checks that its public half matches its seed.
+func PrivateKeyFromSolanaKeygenBytes(data []byte) (PrivateKey, error) {
+	var values []byte
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("decode keygen file: %w", err)
+	}
+	key := PrivateKey(values)
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("decode keygen file: %w", err)
+	}
+	return key, nil
+}
+
+// SolanaKeygenBytes encodes the private key as the JSON array
+// of 64 bytes used by the Solana CLI.
+func (k PrivateKey) SolanaKeygenBytes() []byte {
+	buf := make([]byte, 0, len(k)*4+2)
+	buf = append(buf, '[')
+	for i, b := range k {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = strconv.AppendUint(buf, uint64(b), 10)
+	}
+	return append(buf, ']')
+}
+
+// SaveSolanaKeygenFile writes the private key to the provided file in the
+// format of the Solana CLI, readable only by the owner.
+// It fails if the file already exists.
+func (k PrivateKey) SaveSolanaKeygenFile(file string) error {
+	if err := k.Validate(); err != nil {
+		return fmt.Errorf("save keygen file: %w", err)
+	}
+	if err := writeNewFile(file, k.SolanaKeygenBytes()); err != nil {
+		return fmt.Errorf("save keygen file: %w", err)
+	}
+	return nil
+}
+
+// PrivateKeyFromEd25519Seed returns the private key of the provided
+// raw 32-byte ed25519 seed (as opposed to PrivateKeyFromSeed,
+// which derives a key from a BIP-39 seed).
+func PrivateKeyFromEd25519Seed(seed []byte) (PrivateKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid ed25519 seed length: expected %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+	return PrivateKey(ed25519.NewKeyFromSeed(seed)), nil
+}
+
+// Ed25519Seed returns the raw 32-byte ed25519 seed of the private key.
+func (k PrivateKey) Ed25519Seed() []byte {
+	return ed25519.PrivateKey(k).Seed()
+}
+
+// Validate checks that the private key is 64 bytes long,
+// and that its public half matches its seed.
+func (k PrivateKey) Validate() error {
+	if len(k) != ed25519.PrivateKeySize {
+		return fmt.Errorf("invalid private key length: expected %d bytes, got %d", ed25519.PrivateKeySize, len(k))
+	}
+	expected := ed25519.NewKeyFromSeed(k[:ed25519.SeedSize])
+	if !bytes.Equal(expected[ed25519.SeedSize:], k[ed25519.SeedSize:]) {
+		return errors.New("private key does not match its public key")
+	}
+	return nil
+}
+
+// KeystoreKDF is the function deriving the encryption key of a keystore
+// from its passphrase.
+type KeystoreKDF string
+
+const (
+	KeystoreKDFArgon2id KeystoreKDF = "argon2id"
+	KeystoreKDFScrypt   KeystoreKDF = "scrypt"
+)
+
+const (
+	keystoreVersion = 1
+	keystoreCipher  = "aes-256-gcm"
+	keystoreKeySize = 32
+	keystoreSalt    = 16
+)
+
+// KeystoreOpts configures the key derivation of an encrypted keystore.
+// Zero values are replaced by the defaults.
+type KeystoreOpts struct {
+	// KDF defaults to KeystoreKDFArgon2id.
+	KDF KeystoreKDF
+
+	// Argon2id parameters; default to 3 passes over 64 MiB with 4 threads.
+	Argon2Time    uint32
+	Argon2Memory  uint32 // in KiB
+	Argon2Threads uint8
+
+	// Scrypt parameters; default to N=2^15, r=8, p=1.
+	ScryptN int
+	ScryptR int
+	ScryptP int
+}
+
+// Keystore is the JSON format of a private key encrypted with a passphrase.
+// The public key is stored in clear, and authenticated by the cipher.
+type Keystore struct {
+	Version    int             `json:"version"`
+	PublicKey  PublicKey       `json:"publicKey"`
+	KDF        KeystoreKDFJSON `json:"kdf"`
+	Cipher     string          `json:"cipher"`
+	Nonce      []byte          `json:"nonce"`
+	Ciphertext []byte          `json:"ciphertext"`
+}
+
+// KeystoreKDFJSON holds the name and parameters of the key derivation function
+// of a keystore; only the parameters of the named function are set.
+type KeystoreKDFJSON struct {
+	Name    KeystoreKDF `json:"name"`
+	Salt    []byte      `json:"salt"`
+	Time    uint32      `json:"time,omitempty"`
+	Memory  uint32      `json:"memory,omitempty"`
+	Threads uint8       `json:"threads,omitempty"`
+	N       int         `json:"n,omitempty"`
+	R       int         `json:"r,omitempty"`
+	P       int         `json:"p,omitempty"`
+}
+
+func (o *KeystoreOpts) kdf() KeystoreKDFJSON {
+	var opts KeystoreOpts
+	if o != nil {
+		opts = *o
+	}
+	if opts.KDF == "" {
+		opts.KDF = KeystoreKDFArgon2id
+	}
+	kdf := KeystoreKDFJSON{Name: opts.KDF}
+	switch opts.KDF {
+	case KeystoreKDFArgon2id:
+		kdf.Time, kdf.Memory, kdf.Threads = opts.Argon2Time, opts.Argon2Memory, opts.Argon2Threads
+		if kdf.Time == 0 {
+			kdf.Time = 3
+		}
+		if kdf.Memory == 0 {
+			kdf.Memory = 64 * 1024
+		}
+		if kdf.Threads == 0 {
+			kdf.Threads = 4
+		}
+	case KeystoreKDFScrypt:
+		kdf.N, kdf.R, kdf.P = opts.ScryptN, opts.ScryptR, opts.ScryptP
+		if kdf.N == 0 {
+			kdf.N = 1 << 15
+		}
+		if kdf.R == 0 {
+			kdf.R = 8
+		}
+		if kdf.P == 0 {
+			kdf.P = 1
+		}
+	}
+	return kdf
+}
+
+func (kdf KeystoreKDFJSON) deriveKey(passphrase string) ([]byte, error) {
+	if len(kdf.Salt) == 0 {
+		return nil, errors.New("missing kdf salt")
+	}
+	switch kdf.Name {
+	case KeystoreKDFArgon2id:
+		if kdf.Time == 0 || kdf.Memory == 0 || kdf.Threads == 0 {
+			return nil, errors.New("invalid argon2id parameters")
+		}
+		return argon2.IDKey([]byte(passphrase), kdf.Salt, kdf.Time, kdf.Memory, kdf.Threads, keystoreKeySize), nil
+	case KeystoreKDFScrypt:
+		return scrypt.Key([]byte(passphrase), kdf.Salt, kdf.N, kdf.R, kdf.P, keystoreKeySize)
+	default:
+		return nil, fmt.Errorf("unsupported kdf %q", kdf.Name)
+	}
+}
+
+// EncryptPrivateKey encrypts the private key with a key derived from
+// the passphrase, and returns the JSON encoded Keystore.
+func EncryptPrivateKey(key PrivateKey, passphrase string, opts *KeystoreOpts) ([]byte, error) {
+	if err := key.Validate(); err != nil {
+		return nil, fmt.Errorf("encrypt private key: %w", err)
+	}
+	ks := Keystore{
+		Version:   keystoreVersion,
+		PublicKey: key.PublicKey(),
+		KDF:       opts.kdf(),
+		Cipher:    keystoreCipher,
+	}
+	ks.KDF.Salt = make([]byte, keystoreSalt)
+	if _, err := io.ReadFull(crypto_rand.Reader, ks.KDF.Salt); err != nil {
+		return nil, fmt.Errorf("encrypt private key: %w", err)
+	}
+	secret, err := ks.KDF.deriveKey(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt private key: %w", err)
+	}
+	aead, err := newKeystoreAEAD(secret)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt private key: %w", err)
+	}
+	ks.Nonce = make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(crypto_rand.Reader, ks.Nonce); err != nil {
+		return nil, fmt.Errorf("encrypt private key: %w", err)
+	}
+	ks.Ciphertext = aead.Seal(nil, ks.Nonce, key.Ed25519Seed(), ks.PublicKey[:])
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// DecryptPrivateKey decrypts the JSON encoded Keystore with the passphrase.
+// It returns ErrInvalidPassphrase if the passphrase is wrong.
+func DecryptPrivateKey(data []byte, passphrase string) (PrivateKey, error) {
+	var ks Keystore
+	if err := json.Unmarshal(data, &ks); err != nil {
+		return nil, fmt.Errorf("decrypt private key: decode keystore: %w", err)
+	}
+	if ks.Version != keystoreVersion {
+		return nil, fmt.Errorf("decrypt private key: unsupported keystore version %d", ks.Version)
+	}
+	if ks.Cipher != keystoreCipher {
+		return nil, fmt.Errorf("decrypt private key: unsupported cipher %q", ks.Cipher)
+	}
+	secret, err := ks.KDF.deriveKey(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt private key: %w", err)
+	}
+	aead, err := newKeystoreAEAD(secret)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt private key: %w", err)
+	}
+	if len(ks.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("decrypt private key: invalid nonce length %d", len(ks.Nonce))
+	}
+	seed, err := aead.Open(nil, ks.Nonce, ks.Ciphertext, ks.PublicKey[:])
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+	key, err := PrivateKeyFromEd25519Seed(seed)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt private key: %w", err)
+	}
+	if !key.PublicKey().Equals(ks.PublicKey) {
+		return nil, errors.New("decrypt private key: public key mismatch")
+	}
+	return key, nil
+}
+
+func newKeystoreAEAD(secret []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// PrivateKeyFromKeystoreFile reads and decrypts a keystore file
+// written by SaveKeystoreFile.
+func PrivateKeyFromKeystoreFile(file string, passphrase string) (PrivateKey, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read keystore file: %w", err)
+	}
+	return DecryptPrivateKey(content, passphrase)
+}
+
+// SaveKeystoreFile encrypts the private key with the passphrase and writes
+// the keystore to the provided file, readable only by the owner.
+// It fails if the file already exists.
+func (k PrivateKey) SaveKeystoreFile(file string, passphrase string, opts *KeystoreOpts) error {
+	data, err := EncryptPrivateKey(k, passphrase, opts)
+	if err != nil {
+		return err
+	}
+	if err := writeNewFile(file, data); err != nil {
+		return fmt.Errorf("save keystore file: %w", err)
+	}
+	return nil
+}
+
+// writeNewFile writes data to a file that must not exist, with 0600 permissions.
+func writeNewFile(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolanaKeygenFile_RoundTrip(t *testing.T) {
+	key, err := PrivateKeyFromSolanaKeygenFile("testdata/standard.solana-keygen.json")
+	require.NoError(t, err)
+
+	file := filepath.Join(t.TempDir(), "id.json")
+	require.NoError(t, key.SaveSolanaKeygenFile(file))
+
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	loaded, err := PrivateKeyFromSolanaKeygenFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, key, loaded)
+
+	// Existing files are not overwritten.
+	assert.Error(t, key.SaveSolanaKeygenFile(file))
+}
+
+func TestPrivateKeyFromSolanaKeygenBytes_Invalid(t *testing.T) {
+	key, err := NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	_, err = PrivateKeyFromSolanaKeygenBytes([]byte("[1,2,3]"))
+	assert.Error(t, err)
+
+	tampered := append(PrivateKey{}, key...)
+	tampered[63] ^= 0xff
+	_, err = PrivateKeyFromSolanaKeygenBytes(tampered.SolanaKeygenBytes())
+	assert.Error(t, err)
+}
+
+func TestPrivateKeyFromEd25519Seed(t *testing.T) {
+	key, err := NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	fromSeed, err := PrivateKeyFromEd25519Seed(key.Ed25519Seed())
+	require.NoError(t, err)
+	assert.Equal(t, key, fromSeed)
+
+	_, err = PrivateKeyFromEd25519Seed(make([]byte, 31))
+	assert.Error(t, err)
+}
+
+func TestKeystore(t *testing.T) {
+	key, err := NewRandomPrivateKey()
+	require.NoError(t, err)
+
+	for _, opts := range []*KeystoreOpts{
+		{KDF: KeystoreKDFArgon2id, Argon2Time: 1, Argon2Memory: 1024, Argon2Threads: 1},
+		{KDF: KeystoreKDFScrypt, ScryptN: 1 << 10},
+	} {
+		t.Run(string(opts.KDF), func(t *testing.T) {
+			data, err := EncryptPrivateKey(key, "correct horse", opts)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), key.PublicKey().String())
+			assert.NotContains(t, string(data), key.String())
+
+			decrypted, err := DecryptPrivateKey(data, "correct horse")
+			require.NoError(t, err)
+			assert.Equal(t, key, decrypted)
+
+			_, err = DecryptPrivateKey(data, "battery staple")
+			assert.Equal(t, ErrInvalidPassphrase, err)
+		})
+	}
+
+	t.Run("file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "key.json")
+		opts := &KeystoreOpts{Argon2Time: 1, Argon2Memory: 1024}
+		require.NoError(t, key.SaveKeystoreFile(file, "pass", opts))
+
+		loaded, err := PrivateKeyFromKeystoreFile(file, "pass")
+		require.NoError(t, err)
+		assert.Equal(t, key, loaded)
+	})
+
+	t.Run("tampered public key", func(t *testing.T) {
+		other, err := NewRandomPrivateKey()
+		require.NoError(t, err)
+		data, err := EncryptPrivateKey(key, "pass", &KeystoreOpts{KDF: KeystoreKDFScrypt, ScryptN: 1 << 10})
+		require.NoError(t, err)
+
+		var ks Keystore
+		require.NoError(t, json.Unmarshal(data, &ks))
+		ks.PublicKey = other.PublicKey()
+		data, err = json.Marshal(ks)
+		require.NoError(t, err)
+
+		_, err = DecryptPrivateKey(data, "pass")
+		assert.Equal(t, ErrInvalidPassphrase, err)
+	})
+}
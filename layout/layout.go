@@ -0,0 +1,577 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package layout decodes and encodes the binary layouts of Rust programs
+// (accounts and instruction data) declared with struct tags, so that
+// account layouts don't need a hand-written UnmarshalWithDecoder.
+//
+// Fields are read in order, little-endian, with the Borsh conventions
+// for variable-size values. The `layout` struct tag accepts the
+// following space-separated options:
+//
+//	option    Option<T>: a one-byte flag followed by the value if present;
+//	          the field must be a pointer, nil when absent.
+//	coption   COption<T> as packed by the SPL programs: a four-byte flag
+//	          followed by the value, zero-filled when absent; the field
+//	          must be a pointer, nil when absent.
+//	enum      on the first field of a struct, makes the struct a Rust enum
+//	          with data: the field holds the variant index, and the variant
+//	          with index i is the field i+1 (usually a pointer, nil when
+//	          not selected; use *struct{} for the variants without data).
+//	len=N     the length prefix of a slice or string: u8, u16, u32
+//	          (the default), u64 or compact (compact-u16); fixed-size arrays
+//	          have no prefix.
+//	big       big-endian integer.
+//	skip, -   the field is not part of the layout.
+//
+// Fields whose type implements bin.BinaryUnmarshaler and bin.BinaryMarshaler
+// (e.g. bin.Uint128) are decoded and encoded with their own methods.
+package layout
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+// Unmarshal decodes data into v, which must be a non-nil pointer.
+func Unmarshal(data []byte, v interface{}) error {
+	return Decode(bin.NewBinDecoder(data), v)
+}
+
+// Marshal returns the encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := Encode(bin.NewBinEncoder(buf), v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes the next value of dec into v, which must be a non-nil pointer.
+// The methods of v itself are ignored, so that Decode can implement them:
+//
+//	func (a *MyAccount) UnmarshalWithDecoder(dec *bin.Decoder) error {
+//		return layout.Decode(dec, a)
+//	}
+func Decode(dec *bin.Decoder, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("layout: decode: non-nil pointer required, got %T", v)
+	}
+	return decodeValue(dec, rv.Elem(), &fieldTag{}, false)
+}
+
+// Encode encodes v into enc. Like Decode, the methods of v itself are ignored.
+func Encode(enc *bin.Encoder, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("layout: encode: nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	return encodeValue(enc, rv, &fieldTag{}, false)
+}
+
+type lengthPrefix int
+
+const (
+	lengthU32 lengthPrefix = iota
+	lengthU8
+	lengthU16
+	lengthU64
+	lengthCompact
+)
+
+type fieldTag struct {
+	skip    bool
+	option  bool
+	coption bool
+	enum    bool
+	big     bool
+	length  lengthPrefix
+}
+
+func parseFieldTag(field reflect.StructField) (*fieldTag, error) {
+	tag := &fieldTag{}
+	for _, opt := range strings.Fields(field.Tag.Get("layout")) {
+		switch {
+		case opt == "skip" || opt == "-":
+			tag.skip = true
+		case opt == "option":
+			tag.option = true
+		case opt == "coption":
+			tag.coption = true
+		case opt == "enum":
+			tag.enum = true
+		case opt == "big":
+			tag.big = true
+		case strings.HasPrefix(opt, "len="):
+			switch strings.TrimPrefix(opt, "len=") {
+			case "u8":
+				tag.length = lengthU8
+			case "u16":
+				tag.length = lengthU16
+			case "u32":
+				tag.length = lengthU32
+			case "u64":
+				tag.length = lengthU64
+			case "compact":
+				tag.length = lengthCompact
+			default:
+				return nil, fmt.Errorf("unknown length prefix %q", opt)
+			}
+		default:
+			return nil, fmt.Errorf("unknown tag option %q", opt)
+		}
+	}
+	if (tag.option || tag.coption) && field.Type.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("option and coption require a pointer field, got %s", field.Type)
+	}
+	return tag, nil
+}
+
+func (t *fieldTag) order() binary.ByteOrder {
+	if t.big {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+var (
+	unmarshalerType = reflect.TypeOf((*bin.BinaryUnmarshaler)(nil)).Elem()
+	marshalerType   = reflect.TypeOf((*bin.BinaryMarshaler)(nil)).Elem()
+	int128Type      = reflect.TypeOf(bin.Int128{})
+)
+
+func decodeValue(dec *bin.Decoder, rv reflect.Value, tag *fieldTag, useMethods bool) error {
+	if tag.option || tag.coption {
+		var present bool
+		if tag.option {
+			flag, err := dec.ReadUint8()
+			if err != nil {
+				return err
+			}
+			present = flag != 0
+		} else {
+			flag, err := dec.ReadUint32(binary.LittleEndian)
+			if err != nil {
+				return err
+			}
+			present = flag != 0
+		}
+		value := reflect.New(rv.Type().Elem())
+		if present || tag.coption {
+			// The value of an absent COption is zero-filled, but still there.
+			if err := decodeValue(dec, value.Elem(), &fieldTag{big: tag.big, length: tag.length}, true); err != nil {
+				return err
+			}
+		}
+		if present {
+			rv.Set(value)
+		} else {
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+		return nil
+	}
+
+	if useMethods && reflect.PtrTo(rv.Type()).Implements(unmarshalerType) {
+		return rv.Addr().Interface().(bin.BinaryUnmarshaler).UnmarshalWithDecoder(dec)
+	}
+
+	order := tag.order()
+	switch rv.Kind() {
+	case reflect.Bool:
+		v, err := dec.ReadBool()
+		rv.SetBool(v)
+		return err
+	case reflect.Uint8:
+		v, err := dec.ReadUint8()
+		rv.SetUint(uint64(v))
+		return err
+	case reflect.Int8:
+		v, err := dec.ReadInt8()
+		rv.SetInt(int64(v))
+		return err
+	case reflect.Uint16:
+		v, err := dec.ReadUint16(order)
+		rv.SetUint(uint64(v))
+		return err
+	case reflect.Int16:
+		v, err := dec.ReadInt16(order)
+		rv.SetInt(int64(v))
+		return err
+	case reflect.Uint32:
+		v, err := dec.ReadUint32(order)
+		rv.SetUint(uint64(v))
+		return err
+	case reflect.Int32:
+		v, err := dec.ReadInt32(order)
+		rv.SetInt(int64(v))
+		return err
+	case reflect.Uint64:
+		v, err := dec.ReadUint64(order)
+		rv.SetUint(v)
+		return err
+	case reflect.Int64:
+		v, err := dec.ReadInt64(order)
+		rv.SetInt(v)
+		return err
+	case reflect.Float32:
+		v, err := dec.ReadFloat32(order)
+		rv.SetFloat(float64(v))
+		return err
+	case reflect.Float64:
+		v, err := dec.ReadFloat64(order)
+		rv.SetFloat(v)
+		return err
+	case reflect.String:
+		n, err := readLength(dec, tag.length)
+		if err != nil {
+			return err
+		}
+		data, err := dec.ReadNBytes(n)
+		if err != nil {
+			return err
+		}
+		rv.SetString(string(data))
+		return nil
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			data, err := dec.ReadNBytes(rv.Len())
+			if err != nil {
+				return err
+			}
+			reflect.Copy(rv, reflect.ValueOf(data))
+			return nil
+		}
+		return decodeElements(dec, rv, tag)
+	case reflect.Slice:
+		n, err := readLength(dec, tag.length)
+		if err != nil {
+			return err
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			data, err := dec.ReadNBytes(n)
+			if err != nil {
+				return err
+			}
+			rv.SetBytes(append([]byte(nil), data...))
+			return nil
+		}
+		if n == 0 {
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		rv.Set(reflect.MakeSlice(rv.Type(), n, n))
+		return decodeElements(dec, rv, tag)
+	case reflect.Ptr:
+		value := reflect.New(rv.Type().Elem())
+		if err := decodeValue(dec, value.Elem(), tag, true); err != nil {
+			return err
+		}
+		rv.Set(value)
+		return nil
+	case reflect.Struct:
+		if rv.Type() == int128Type {
+			v, err := dec.ReadInt128(order)
+			rv.Set(reflect.ValueOf(v))
+			return err
+		}
+		return decodeStruct(dec, rv)
+	default:
+		return fmt.Errorf("unsupported type %s", rv.Type())
+	}
+}
+
+func decodeElements(dec *bin.Decoder, rv reflect.Value, tag *fieldTag) error {
+	elemTag := &fieldTag{big: tag.big}
+	for i := 0; i < rv.Len(); i++ {
+		if err := decodeValue(dec, rv.Index(i), elemTag, true); err != nil {
+			return fmt.Errorf("index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func decodeStruct(dec *bin.Decoder, rv reflect.Value) error {
+	typ := rv.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, err := parseFieldTag(field)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", typ.Name(), field.Name, err)
+		}
+		if tag.skip || !field.IsExported() {
+			continue
+		}
+		if tag.enum {
+			if i != 0 {
+				return fmt.Errorf("%s.%s: the enum tag must be on the first field", typ.Name(), field.Name)
+			}
+			return decodeEnum(dec, rv, tag)
+		}
+		if err := decodeValue(dec, rv.Field(i), tag, true); err != nil {
+			return fmt.Errorf("%s.%s: %w", typ.Name(), field.Name, err)
+		}
+	}
+	return nil
+}
+
+func decodeEnum(dec *bin.Decoder, rv reflect.Value, tag *fieldTag) error {
+	typ := rv.Type()
+	if err := decodeValue(dec, rv.Field(0), tag, false); err != nil {
+		return fmt.Errorf("%s: variant: %w", typ.Name(), err)
+	}
+	index, err := variantIndex(rv)
+	if err != nil {
+		return err
+	}
+	for i := 1; i < typ.NumField(); i++ {
+		rv.Field(i).Set(reflect.Zero(typ.Field(i).Type))
+	}
+	variant := typ.Field(index)
+	variantTag, err := parseFieldTag(variant)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", typ.Name(), variant.Name, err)
+	}
+	if err := decodeValue(dec, rv.Field(index), variantTag, true); err != nil {
+		return fmt.Errorf("%s.%s: %w", typ.Name(), variant.Name, err)
+	}
+	return nil
+}
+
+// variantIndex returns the index of the field holding the variant
+// selected by the first field of the enum struct rv.
+func variantIndex(rv reflect.Value) (int, error) {
+	var variant uint64
+	switch discriminant := rv.Field(0); discriminant.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		variant = discriminant.Uint()
+	default:
+		return 0, fmt.Errorf("%s: the enum field must be an unsigned integer, got %s", rv.Type().Name(), discriminant.Type())
+	}
+	if variant >= uint64(rv.NumField()-1) {
+		return 0, fmt.Errorf("%s: unknown variant %d", rv.Type().Name(), variant)
+	}
+	return int(variant) + 1, nil
+}
+
+func readLength(dec *bin.Decoder, prefix lengthPrefix) (int, error) {
+	var n uint64
+	switch prefix {
+	case lengthU8:
+		v, err := dec.ReadUint8()
+		if err != nil {
+			return 0, err
+		}
+		n = uint64(v)
+	case lengthU16:
+		v, err := dec.ReadUint16(binary.LittleEndian)
+		if err != nil {
+			return 0, err
+		}
+		n = uint64(v)
+	case lengthU64:
+		v, err := dec.ReadUint64(binary.LittleEndian)
+		if err != nil {
+			return 0, err
+		}
+		n = v
+	case lengthCompact:
+		v, err := dec.ReadCompactU16()
+		if err != nil {
+			return 0, err
+		}
+		n = uint64(v)
+	default:
+		v, err := dec.ReadUint32(binary.LittleEndian)
+		if err != nil {
+			return 0, err
+		}
+		n = uint64(v)
+	}
+	if n > uint64(dec.Remaining()) {
+		return 0, fmt.Errorf("length %d exceeds the %d remaining bytes", n, dec.Remaining())
+	}
+	return int(n), nil
+}
+
+func encodeValue(enc *bin.Encoder, rv reflect.Value, tag *fieldTag, useMethods bool) error {
+	if tag.option || tag.coption {
+		present := !rv.IsNil()
+		var err error
+		if tag.option {
+			err = enc.WriteBool(present)
+		} else {
+			err = enc.WriteUint32(boolToUint32(present), binary.LittleEndian)
+		}
+		if err != nil {
+			return err
+		}
+		valueTag := &fieldTag{big: tag.big, length: tag.length}
+		if present {
+			return encodeValue(enc, rv.Elem(), valueTag, true)
+		}
+		if tag.coption {
+			return encodeValue(enc, reflect.Zero(rv.Type().Elem()), valueTag, true)
+		}
+		return nil
+	}
+
+	if useMethods {
+		if rv.Type().Implements(marshalerType) {
+			return rv.Interface().(bin.BinaryMarshaler).MarshalWithEncoder(enc)
+		}
+		if rv.CanAddr() && reflect.PtrTo(rv.Type()).Implements(marshalerType) {
+			return rv.Addr().Interface().(bin.BinaryMarshaler).MarshalWithEncoder(enc)
+		}
+	}
+
+	order := tag.order()
+	switch rv.Kind() {
+	case reflect.Bool:
+		return enc.WriteBool(rv.Bool())
+	case reflect.Uint8:
+		return enc.WriteUint8(uint8(rv.Uint()))
+	case reflect.Int8:
+		return enc.WriteInt8(int8(rv.Int()))
+	case reflect.Uint16:
+		return enc.WriteUint16(uint16(rv.Uint()), order)
+	case reflect.Int16:
+		return enc.WriteInt16(int16(rv.Int()), order)
+	case reflect.Uint32:
+		return enc.WriteUint32(uint32(rv.Uint()), order)
+	case reflect.Int32:
+		return enc.WriteInt32(int32(rv.Int()), order)
+	case reflect.Uint64:
+		return enc.WriteUint64(rv.Uint(), order)
+	case reflect.Int64:
+		return enc.WriteInt64(rv.Int(), order)
+	case reflect.Float32:
+		return enc.WriteFloat32(float32(rv.Float()), order)
+	case reflect.Float64:
+		return enc.WriteFloat64(rv.Float(), order)
+	case reflect.String:
+		if err := writeLength(enc, tag.length, rv.Len()); err != nil {
+			return err
+		}
+		return enc.WriteBytes([]byte(rv.String()), false)
+	case reflect.Array:
+		return encodeElements(enc, rv, tag)
+	case reflect.Slice:
+		if err := writeLength(enc, tag.length, rv.Len()); err != nil {
+			return err
+		}
+		return encodeElements(enc, rv, tag)
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return encodeValue(enc, reflect.Zero(rv.Type().Elem()), tag, true)
+		}
+		return encodeValue(enc, rv.Elem(), tag, true)
+	case reflect.Struct:
+		if rv.Type() == int128Type {
+			return enc.WriteInt128(rv.Interface().(bin.Int128), order)
+		}
+		return encodeStruct(enc, rv)
+	default:
+		return fmt.Errorf("unsupported type %s", rv.Type())
+	}
+}
+
+func encodeElements(enc *bin.Encoder, rv reflect.Value, tag *fieldTag) error {
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		data := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(data), rv)
+		return enc.WriteBytes(data, false)
+	}
+	elemTag := &fieldTag{big: tag.big}
+	for i := 0; i < rv.Len(); i++ {
+		if err := encodeValue(enc, rv.Index(i), elemTag, true); err != nil {
+			return fmt.Errorf("index %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func encodeStruct(enc *bin.Encoder, rv reflect.Value) error {
+	typ := rv.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag, err := parseFieldTag(field)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", typ.Name(), field.Name, err)
+		}
+		if tag.skip || !field.IsExported() {
+			continue
+		}
+		if tag.enum {
+			if i != 0 {
+				return fmt.Errorf("%s.%s: the enum tag must be on the first field", typ.Name(), field.Name)
+			}
+			return encodeEnum(enc, rv, tag)
+		}
+		if err := encodeValue(enc, rv.Field(i), tag, true); err != nil {
+			return fmt.Errorf("%s.%s: %w", typ.Name(), field.Name, err)
+		}
+	}
+	return nil
+}
+
+func encodeEnum(enc *bin.Encoder, rv reflect.Value, tag *fieldTag) error {
+	typ := rv.Type()
+	index, err := variantIndex(rv)
+	if err != nil {
+		return err
+	}
+	if err := encodeValue(enc, rv.Field(0), tag, false); err != nil {
+		return fmt.Errorf("%s: variant: %w", typ.Name(), err)
+	}
+	variant := typ.Field(index)
+	variantTag, err := parseFieldTag(variant)
+	if err != nil {
+		return fmt.Errorf("%s.%s: %w", typ.Name(), variant.Name, err)
+	}
+	if err := encodeValue(enc, rv.Field(index), variantTag, true); err != nil {
+		return fmt.Errorf("%s.%s: %w", typ.Name(), variant.Name, err)
+	}
+	return nil
+}
+
+func writeLength(enc *bin.Encoder, prefix lengthPrefix, n int) error {
+	switch prefix {
+	case lengthU8:
+		return enc.WriteUint8(uint8(n))
+	case lengthU16:
+		return enc.WriteUint16(uint16(n), binary.LittleEndian)
+	case lengthU64:
+		return enc.WriteUint64(uint64(n), binary.LittleEndian)
+	case lengthCompact:
+		return enc.WriteCompactU16(n)
+	default:
+		return enc.WriteUint32(uint32(n), binary.LittleEndian)
+	}
+}
+
+func boolToUint32(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,170 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package layout
+
+import (
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+type testMint struct {
+	MintAuthority   *solana.PublicKey `layout:"coption"`
+	Supply          uint64
+	Decimals        uint8
+	IsInitialized   bool
+	FreezeAuthority *solana.PublicKey `layout:"coption"`
+}
+
+func TestUnmarshal_coption(t *testing.T) {
+	authority := solana.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi")
+	data := []byte{
+		1, 0, 0, 0,
+		5, 234, 156, 241, 108, 228, 17, 152, 241, 164, 153, 55, 200, 140, 55, 10, 148, 212, 175, 255, 137, 181, 186, 203, 142, 244, 94, 99, 36, 187, 120, 247,
+		9, 169, 49, 235, 241, 182, 6, 0,
+		6,
+		1,
+		0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	}
+
+	var mint testMint
+	require.NoError(t, Unmarshal(data, &mint))
+	require.Equal(t, testMint{
+		MintAuthority: authority.ToPointer(),
+		Supply:        1890000009537801,
+		Decimals:      6,
+		IsInitialized: true,
+	}, mint)
+
+	encoded, err := Marshal(mint)
+	require.NoError(t, err)
+	require.Equal(t, data, encoded)
+}
+
+type testLockup struct {
+	UnixTimestamp int64
+	Custodian     solana.PublicKey
+}
+
+type testState struct {
+	Kind        uint8 `layout:"enum"`
+	Empty       *struct{}
+	Initialized *testLockup
+	Frozen      *testFrozen
+}
+
+type testFrozen struct {
+	Reason string  `layout:"len=u8"`
+	Until  *uint64 `layout:"option"`
+}
+
+type testAccount struct {
+	State    testState
+	Signers  []solana.PublicKey
+	Seeds    [2]uint16 `layout:"big"`
+	Balance  bin.Uint128
+	Memo     string
+	internal int
+	Ignored  uint64 `layout:"-"`
+}
+
+func TestMarshal_roundTrip(t *testing.T) {
+	until := uint64(42)
+	signer := solana.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi")
+	cases := []testAccount{
+		{
+			State:   testState{Kind: 0, Empty: &struct{}{}},
+			Balance: bin.Uint128{Lo: 1},
+		},
+		{
+			State:   testState{Kind: 1, Initialized: &testLockup{UnixTimestamp: -1, Custodian: signer}},
+			Signers: []solana.PublicKey{signer, signer},
+			Seeds:   [2]uint16{1, 2},
+			Memo:    "memo",
+		},
+		{
+			State: testState{Kind: 2, Frozen: &testFrozen{Reason: "audit", Until: &until}},
+		},
+		{
+			State: testState{Kind: 2, Frozen: &testFrozen{}},
+		},
+	}
+	for _, account := range cases {
+		data, err := Marshal(&account)
+		require.NoError(t, err)
+
+		var decoded testAccount
+		require.NoError(t, Unmarshal(data, &decoded))
+		require.Equal(t, account, decoded)
+	}
+
+	data, err := Marshal(cases[2])
+	require.NoError(t, err)
+	require.Equal(t, []byte{
+		2,                          // Kind
+		5, 'a', 'u', 'd', 'i', 't', // Reason
+		1, 42, 0, 0, 0, 0, 0, 0, 0, // Until
+		0, 0, 0, 0, // Signers
+		0, 0, 0, 0, // Seeds
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // Balance
+		0, 0, 0, 0, // Memo
+	}, data)
+}
+
+func TestUnmarshal_errors(t *testing.T) {
+	var account testAccount
+	err := Unmarshal([]byte{3}, &account)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown variant 3")
+
+	err = Unmarshal([]byte{0, 255, 255, 255, 255}, &account)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeds")
+
+	require.Error(t, Unmarshal([]byte{1, 0}, &account))
+
+	var invalid struct {
+		Value uint64 `layout:"option"`
+	}
+	err = Unmarshal([]byte{0}, &invalid)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "pointer")
+}
+
+type testInstruction struct {
+	Kind     uint32 `layout:"enum"`
+	Transfer *struct {
+		Lamports uint64
+	}
+	Assign *struct {
+		Owner solana.PublicKey
+	}
+}
+
+func TestMarshal_instruction(t *testing.T) {
+	data, err := Marshal(testInstruction{
+		Kind:     0,
+		Transfer: &struct{ Lamports uint64 }{Lamports: 1000},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 0, 0, 0, 232, 3, 0, 0, 0, 0, 0, 0}, data)
+
+	_, err = Marshal(testInstruction{Kind: 2})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown variant 2")
+}
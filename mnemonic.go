@@ -0,0 +1,145 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalidMnemonic is returned when a mnemonic has an unknown word,
+// an unsupported number of words, or a wrong checksum.
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+var (
+	bip39WordsOnce sync.Once
+	bip39Words     []string
+	bip39Indexes   map[string]int
+)
+
+func bip39Wordlist() ([]string, map[string]int) {
+	bip39WordsOnce.Do(func() {
+		bip39Words = strings.Fields(bip39English)
+		bip39Indexes = make(map[string]int, len(bip39Words))
+		for i, word := range bip39Words {
+			bip39Indexes[word] = i
+		}
+	})
+	return bip39Words, bip39Indexes
+}
+
+// NewMnemonic generates a BIP-39 mnemonic from bitSize bits of random entropy.
+// bitSize must be a multiple of 32 between 128 (12 words) and 256 (24 words).
+func NewMnemonic(bitSize int) (string, error) {
+	if bitSize < 128 || bitSize > 256 || bitSize%32 != 0 {
+		return "", fmt.Errorf("invalid entropy size %d: must be a multiple of 32 between 128 and 256", bitSize)
+	}
+	entropy := make([]byte, bitSize/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", fmt.Errorf("read entropy: %w", err)
+	}
+	return MnemonicFromEntropy(entropy)
+}
+
+// MnemonicFromEntropy returns the BIP-39 mnemonic encoding the provided entropy,
+// which must be between 16 and 32 bytes long, in multiples of 4 bytes.
+func MnemonicFromEntropy(entropy []byte) (string, error) {
+	bitSize := len(entropy) * 8
+	if bitSize < 128 || bitSize > 256 || bitSize%32 != 0 {
+		return "", fmt.Errorf("invalid entropy length %d: must be a multiple of 4 between 16 and 32 bytes", len(entropy))
+	}
+	words, _ := bip39Wordlist()
+
+	// The checksum is the first bitSize/32 bits of the sha256 of the entropy.
+	checksum := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), checksum[0])
+
+	numWords := (bitSize + bitSize/32) / 11
+	out := make([]string, numWords)
+	for i := 0; i < numWords; i++ {
+		index := 0
+		for bit := i * 11; bit < (i+1)*11; bit++ {
+			index = index<<1 | int(data[bit/8]>>(7-bit%8)&1)
+		}
+		out[i] = words[index]
+	}
+	return strings.Join(out, " "), nil
+}
+
+// EntropyFromMnemonic returns the entropy encoded in the provided
+// BIP-39 mnemonic, after checking its checksum.
+func EntropyFromMnemonic(mnemonic string) ([]byte, error) {
+	_, indexes := bip39Wordlist()
+	fields := strings.Fields(mnemonic)
+	switch len(fields) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, fmt.Errorf("%w: unexpected number of words %d", ErrInvalidMnemonic, len(fields))
+	}
+
+	totalBits := len(fields) * 11
+	checksumBits := totalBits / 33
+	data := make([]byte, (totalBits+7)/8)
+	for i, word := range fields {
+		index, ok := indexes[word]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown word %q", ErrInvalidMnemonic, word)
+		}
+		for bit := 0; bit < 11; bit++ {
+			if index>>(10-bit)&1 == 1 {
+				pos := i*11 + bit
+				data[pos/8] |= 1 << (7 - pos%8)
+			}
+		}
+	}
+
+	entropy := data[:(totalBits-checksumBits)/8]
+	checksum := sha256.Sum256(entropy)
+	mask := byte(0xff) << (8 - checksumBits)
+	if checksum[0]&mask != data[len(entropy)]&mask {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidMnemonic)
+	}
+	return entropy, nil
+}
+
+// IsValidMnemonic reports whether the provided string is a valid
+// BIP-39 mnemonic in the English wordlist.
+func IsValidMnemonic(mnemonic string) bool {
+	_, err := EntropyFromMnemonic(mnemonic)
+	return err == nil
+}
+
+// SeedFromMnemonic returns the 64-byte BIP-39 seed of the provided mnemonic
+// and (optional) passphrase, after validating the mnemonic.
+func SeedFromMnemonic(mnemonic string, passphrase string) ([]byte, error) {
+	if _, err := EntropyFromMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	if !utf8.ValidString(passphrase) {
+		return nil, errors.New("passphrase is not valid UTF-8")
+	}
+	normalized := norm.NFKD.String(strings.Join(strings.Fields(mnemonic), " "))
+	salt := norm.NFKD.String("mnemonic" + passphrase)
+	return pbkdf2.Key([]byte(normalized), []byte(salt), 2048, 64, sha512.New), nil
+}
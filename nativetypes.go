@@ -25,7 +25,6 @@ import (
 
 	bin "github.com/gagliardetto/binary"
 	"github.com/mostynb/zstdpool-freelist"
-	"github.com/mr-tron/base58"
 )
 
 type Padding []byte
@@ -54,8 +53,7 @@ func HashFromBytes(in []byte) Hash {
 
 // MarshalText implements encoding.TextMarshaler.
 func (ha Hash) MarshalText() ([]byte, error) {
-	s := base58.Encode(ha[:])
-	return []byte(s), nil
+	return ha.AppendBase58(make([]byte, 0, PublicKeyBase58MaxLength)), nil
 }
 
 // UnmarshalText implements encoding.TextUnmarshaler.
@@ -69,7 +67,8 @@ func (ha *Hash) UnmarshalText(data []byte) (err error) {
 }
 
 func (ha Hash) MarshalJSON() ([]byte, error) {
-	return json.Marshal(base58.Encode(ha[:]))
+	buf := append(make([]byte, 0, PublicKeyBase58MaxLength+2), '"')
+	return append(ha.AppendBase58(buf), '"'), nil
 }
 
 func (ha *Hash) UnmarshalJSON(data []byte) (err error) {
@@ -97,7 +96,14 @@ func (ha Hash) IsZero() bool {
 }
 
 func (ha Hash) String() string {
-	return base58.Encode(ha[:])
+	var buf [PublicKeyBase58MaxLength]byte
+	return string(ha.AppendBase58(buf[:0]))
+}
+
+// AppendBase58 appends the base58 encoding of the hash to dst,
+// without allocating if dst has a capacity of PublicKeyBase58MaxLength left.
+func (ha Hash) AppendBase58(dst []byte) []byte {
+	return appendBase58(dst, ha[:])
 }
 
 type Signature [64]byte
@@ -114,16 +120,15 @@ func (sig Signature) Equals(pb Signature) bool {
 
 // SignatureFromBase58 decodes a base58 string into a Signature.
 func SignatureFromBase58(in string) (out Signature, err error) {
-	val, err := base58.Decode(in)
+	n, err := decodeBase58Into(out[:], in)
 	if err != nil {
 		return
 	}
 
-	if len(val) != SignatureLength {
-		err = fmt.Errorf("invalid length, expected 64, got %d", len(val))
+	if n != SignatureLength {
+		err = fmt.Errorf("invalid length, expected 64, got %d", n)
 		return
 	}
-	copy(out[:], val)
 	return
 }
 
@@ -154,8 +159,7 @@ func SignatureFromBytes(in []byte) (out Signature) {
 }
 
 func (p Signature) MarshalText() ([]byte, error) {
-	s := base58.Encode(p[:])
-	return []byte(s), nil
+	return p.AppendBase58(make([]byte, 0, SignatureBase58MaxLength)), nil
 }
 
 func (p *Signature) UnmarshalText(data []byte) (err error) {
@@ -168,7 +172,8 @@ func (p *Signature) UnmarshalText(data []byte) (err error) {
 }
 
 func (p Signature) MarshalJSON() ([]byte, error) {
-	return json.Marshal(base58.Encode(p[:]))
+	buf := append(make([]byte, 0, SignatureBase58MaxLength+2), '"')
+	return append(p.AppendBase58(buf), '"'), nil
 }
 
 func (p *Signature) UnmarshalJSON(data []byte) (err error) {
@@ -178,17 +183,15 @@ func (p *Signature) UnmarshalJSON(data []byte) (err error) {
 		return
 	}
 
-	dat, err := base58.Decode(s)
+	var target Signature
+	n, err := decodeBase58Into(target[:], s)
 	if err != nil {
 		return err
 	}
 
-	if len(dat) != SignatureLength {
-		return fmt.Errorf("invalid length for Signature, expected 64, got %d", len(dat))
+	if n != SignatureLength {
+		return fmt.Errorf("invalid length for Signature, expected 64, got %d", n)
 	}
-
-	target := Signature{}
-	copy(target[:], dat)
 	*p = target
 	return
 }
@@ -199,13 +202,23 @@ func (s Signature) Verify(pubkey PublicKey, msg []byte) bool {
 }
 
 func (p Signature) String() string {
-	return base58.Encode(p[:])
+	var buf [SignatureBase58MaxLength]byte
+	return string(p.AppendBase58(buf[:0]))
+}
+
+// SignatureBase58MaxLength is the maximum length of the base58 encoding of a signature.
+const SignatureBase58MaxLength = 88
+
+// AppendBase58 appends the base58 encoding of the signature to dst,
+// without allocating if dst has a capacity of SignatureBase58MaxLength left.
+func (p Signature) AppendBase58(dst []byte) []byte {
+	return appendBase58(dst, p[:])
 }
 
 type Base58 []byte
 
 func (t Base58) MarshalJSON() ([]byte, error) {
-	return json.Marshal(base58.Encode(t))
+	return json.Marshal(encodeBase58(t))
 }
 
 func (t *Base58) UnmarshalJSON(data []byte) (err error) {
@@ -220,12 +233,12 @@ func (t *Base58) UnmarshalJSON(data []byte) (err error) {
 		return nil
 	}
 
-	*t, err = base58.Decode(s)
+	*t, err = decodeBase58(s)
 	return
 }
 
 func (t Base58) String() string {
-	return base58.Encode(t)
+	return encodeBase58(t)
 }
 
 type Data struct {
@@ -265,7 +278,7 @@ func (t *Data) UnmarshalJSON(data []byte) (err error) {
 	switch t.Encoding {
 	case EncodingBase58:
 		var err error
-		t.Content, err = base58.Decode(contentString)
+		t.Content, err = decodeBase58(contentString)
 		if err != nil {
 			return err
 		}
@@ -280,13 +293,7 @@ func (t *Data) UnmarshalJSON(data []byte) (err error) {
 		if err != nil {
 			return err
 		}
-		dec, err := zstdDecoderPool.Get(nil)
-		if err != nil {
-			return err
-		}
-		defer zstdDecoderPool.Put(dec)
-
-		t.Content, err = dec.DecodeAll(rawBytes, nil)
+		t.Content, err = DecompressZstd(rawBytes)
 		if err != nil {
 			return err
 		}
@@ -296,12 +303,23 @@ func (t *Data) UnmarshalJSON(data []byte) (err error) {
 	return
 }
 
+// DecompressZstd decompresses the provided Zstandard-compressed bytes
+// using a pooled decoder.
+func DecompressZstd(compressed []byte) ([]byte, error) {
+	dec, err := zstdDecoderPool.Get(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer zstdDecoderPool.Put(dec)
+	return dec.DecodeAll(compressed, nil)
+}
+
 var zstdEncoderPool = zstdpool.NewEncoderPool()
 
 func (t Data) String() string {
 	switch EncodingType(t.Encoding) {
 	case EncodingBase58:
-		return base58.Encode(t.Content)
+		return encodeBase58(t.Content)
 	case EncodingBase64:
 		return base64.StdEncoding.EncodeToString(t.Content)
 	case EncodingBase64Zstd:
@@ -0,0 +1,123 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTooManySignatures is returned by PackInstructions when a group of
+// instructions requires more signatures than PackOpts.MaxSignatures.
+var ErrTooManySignatures = errors.New("too many signatures")
+
+// PackOpts configures PackInstructions and PackInstructionGroups.
+type PackOpts struct {
+	// Prefix is prepended to the instructions of each transaction,
+	// e.g. the compute budget instructions.
+	Prefix []Instruction
+
+	// AddressTables, if set, are used to load the accounts of v0 transactions
+	// (see TransactionAddressTables).
+	AddressTables map[PublicKey]PublicKeySlice
+
+	// MaxSignatures limits the number of signatures of each transaction.
+	// Zero means the transactions are only limited by MaxTransactionSize.
+	MaxSignatures int
+}
+
+// PackInstructions packs the instructions, in order, into as few transactions
+// as possible, each fitting in MaxTransactionSize once signed.
+// The transactions are paid by payer, and ready to be signed.
+func PackInstructions(
+	instructions []Instruction,
+	recentBlockHash Hash,
+	payer PublicKey,
+	opts *PackOpts,
+) ([]*Transaction, error) {
+	groups := make([][]Instruction, len(instructions))
+	for i, instruction := range instructions {
+		groups[i] = []Instruction{instruction}
+	}
+	return PackInstructionGroups(groups, recentBlockHash, payer, opts)
+}
+
+// PackInstructionGroups is like PackInstructions, but never splits
+// a group of instructions across transactions, e.g. the creation
+// of a token account and the transfer to it.
+//
+// It returns an error wrapping a *TransactionSizeError or ErrTooManySignatures
+// if a group doesn't fit in a transaction on its own.
+func PackInstructionGroups(
+	groups [][]Instruction,
+	recentBlockHash Hash,
+	payer PublicKey,
+	opts *PackOpts,
+) ([]*Transaction, error) {
+	if opts == nil {
+		opts = &PackOpts{}
+	}
+	txOpts := []TransactionOption{TransactionPayer(payer)}
+	if len(opts.AddressTables) > 0 {
+		txOpts = append(txOpts, TransactionAddressTables(opts.AddressTables), TransactionV0())
+	}
+	build := func(instructions []Instruction) (*Transaction, error) {
+		all := make([]Instruction, 0, len(opts.Prefix)+len(instructions))
+		all = append(append(all, opts.Prefix...), instructions...)
+		tx, err := NewTransaction(all, recentBlockHash, txOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.CheckSize(); err != nil {
+			return nil, err
+		}
+		if signatures := int(tx.Message.Header.NumRequiredSignatures); opts.MaxSignatures > 0 && signatures > opts.MaxSignatures {
+			return nil, fmt.Errorf("%w: %d (max %d)", ErrTooManySignatures, signatures, opts.MaxSignatures)
+		}
+		return tx, nil
+	}
+
+	var out []*Transaction
+	var current []Instruction
+	var currentTx *Transaction
+	for i, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		candidate := append(current[:len(current):len(current)], group...)
+		tx, err := build(candidate)
+		if err == nil {
+			current, currentTx = candidate, tx
+			continue
+		}
+		if !errors.Is(err, ErrTransactionTooLarge) && !errors.Is(err, ErrTooManySignatures) {
+			return nil, fmt.Errorf("pack: group %d: %w", i, err)
+		}
+		if currentTx == nil {
+			return nil, fmt.Errorf("pack: group %d doesn't fit in a transaction: %w", i, err)
+		}
+
+		// Start a new transaction with the group.
+		out = append(out, currentTx)
+		current = append([]Instruction{}, group...)
+		if currentTx, err = build(current); err != nil {
+			return nil, fmt.Errorf("pack: group %d doesn't fit in a transaction: %w", i, err)
+		}
+	}
+	if currentTx != nil {
+		out = append(out, currentTx)
+	}
+	return out, nil
+}
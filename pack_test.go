@@ -0,0 +1,108 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackInstructions(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	program := NewWallet().PublicKey()
+	budget := NewInstruction(NewWallet().PublicKey(), nil, []byte{3, 0, 0, 0, 0, 0, 0, 0, 0})
+
+	recipients := make(PublicKeySlice, 60)
+	instructions := make([]Instruction, len(recipients))
+	for i := range recipients {
+		recipients[i] = NewWallet().PublicKey()
+		instructions[i] = NewInstruction(
+			program,
+			AccountMetaSlice{Meta(payer).SIGNER().WRITE(), Meta(recipients[i]).WRITE()},
+			[]byte{2, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0},
+		)
+	}
+
+	txs, err := PackInstructions(instructions, Hash{}, payer, &PackOpts{Prefix: []Instruction{budget}})
+	require.NoError(t, err)
+	require.Greater(t, len(txs), 1)
+
+	packed := 0
+	for i, tx := range txs {
+		require.NoError(t, tx.CheckSize())
+		require.Equal(t, payer, tx.Message.AccountKeys[0])
+		require.Equal(t, budget.ProgramID(), mustProgram(t, tx, 0))
+
+		n := len(tx.Message.Instructions) - 1
+		packed += n
+		// Each transaction is full: the next instruction would not fit.
+		if i < len(txs)-1 {
+			next, err := NewTransaction(
+				append([]Instruction{budget}, instructions[packed-n:packed+1]...),
+				Hash{},
+				TransactionPayer(payer),
+			)
+			require.NoError(t, err)
+			require.ErrorIs(t, next.CheckSize(), ErrTransactionTooLarge)
+		}
+	}
+	require.Equal(t, len(instructions), packed)
+
+	// The accounts loaded from an address table take less room.
+	withTables, err := PackInstructions(instructions, Hash{}, payer, &PackOpts{
+		AddressTables: map[PublicKey]PublicKeySlice{NewWallet().PublicKey(): recipients[:50]},
+	})
+	require.NoError(t, err)
+	require.Less(t, len(withTables), len(txs))
+	require.True(t, withTables[0].Message.IsVersioned())
+}
+
+func mustProgram(t *testing.T, tx *Transaction, index int) PublicKey {
+	program, err := tx.Message.Program(tx.Message.Instructions[index].ProgramIDIndex)
+	require.NoError(t, err)
+	return program
+}
+
+func TestPackInstructionGroups(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	program := NewWallet().PublicKey()
+
+	signed := func() Instruction {
+		return NewInstruction(program, AccountMetaSlice{Meta(payer).SIGNER().WRITE(), Meta(NewWallet().PublicKey()).SIGNER()}, []byte{1})
+	}
+	groups := [][]Instruction{
+		{signed(), signed()},
+		{signed()},
+		{},
+		{signed(), signed()},
+	}
+	txs, err := PackInstructionGroups(groups, Hash{}, payer, &PackOpts{MaxSignatures: 4})
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	require.Len(t, txs[0].Message.Instructions, 3)
+	require.Len(t, txs[1].Message.Instructions, 2)
+	require.Equal(t, uint8(4), txs[0].Message.Header.NumRequiredSignatures)
+
+	_, err = PackInstructionGroups([][]Instruction{{signed(), signed(), signed(), signed()}}, Hash{}, payer, &PackOpts{MaxSignatures: 4})
+	require.ErrorIs(t, err, ErrTooManySignatures)
+
+	large := NewInstruction(program, AccountMetaSlice{Meta(payer).SIGNER().WRITE()}, make([]byte, MaxTransactionSize))
+	_, err = PackInstructions([]Instruction{signed(), large}, Hash{}, payer, nil)
+	var sizeErr *TransactionSizeError
+	require.True(t, errors.As(err, &sizeErr))
+	require.Contains(t, err.Error(), "group 1")
+}
@@ -29,6 +29,9 @@ type Create struct {
 	Wallet solana.PublicKey `bin:"-" borsh_skip:"true"`
 	Mint   solana.PublicKey `bin:"-" borsh_skip:"true"`
 
+	// Token program of the mint; defaults to the SPL Token program.
+	TokenProgram solana.PublicKey `bin:"-" borsh_skip:"true"`
+
 	// [0] = [WRITE, SIGNER] Payer
 	// ··········· Funding account
 	//
@@ -45,7 +48,7 @@ type Create struct {
 	// ··········· System program ID
 	//
 	// [5] = [] TokenProgram
-	// ··········· SPL token program ID
+	// ··········· SPL token (or token-2022) program ID
 	//
 	// [6] = [] SysVarRent
 	// ··········· SysVarRentPubkey
@@ -73,57 +76,36 @@ func (inst *Create) SetMint(mint solana.PublicKey) *Create {
 	return inst
 }
 
+// SetTokenProgram sets the token program of the mint,
+// e.g. solana.Token2022ProgramID; the default is solana.TokenProgramID.
+func (inst *Create) SetTokenProgram(tokenProgram solana.PublicKey) *Create {
+	inst.TokenProgram = tokenProgram
+	return inst
+}
+
+func (inst Create) tokenProgramID() solana.PublicKey {
+	if inst.TokenProgram.IsZero() {
+		return solana.TokenProgramID
+	}
+	return inst.TokenProgram
+}
+
 func (inst Create) Build() *Instruction {
 
 	// Find the associatedTokenAddress;
-	associatedTokenAddress, _, _ := solana.FindAssociatedTokenAddress(
+	associatedTokenAddress, _, _ := solana.FindAssociatedTokenAddressWithProgramID(
 		inst.Wallet,
 		inst.Mint,
+		inst.tokenProgramID(),
 	)
 
-	keys := []*solana.AccountMeta{
-		{
-			PublicKey:  inst.Payer,
-			IsSigner:   true,
-			IsWritable: true,
-		},
-		{
-			PublicKey:  associatedTokenAddress,
-			IsSigner:   false,
-			IsWritable: true,
-		},
-		{
-			PublicKey:  inst.Wallet,
-			IsSigner:   false,
-			IsWritable: false,
-		},
-		{
-			PublicKey:  inst.Mint,
-			IsSigner:   false,
-			IsWritable: false,
-		},
-		{
-			PublicKey:  solana.SystemProgramID,
-			IsSigner:   false,
-			IsWritable: false,
-		},
-		{
-			PublicKey:  solana.TokenProgramID,
-			IsSigner:   false,
-			IsWritable: false,
-		},
-		{
-			PublicKey:  solana.SysVarRentPubkey,
-			IsSigner:   false,
-			IsWritable: false,
-		},
-	}
+	keys := createAccounts(inst.Payer, associatedTokenAddress, inst.Wallet, inst.Mint, inst.tokenProgramID())
 
 	inst.AccountMetaSlice = keys
 
 	return &Instruction{BaseVariant: bin.BaseVariant{
 		Impl:   inst,
-		TypeID: bin.NoTypeIDDefaultID,
+		TypeID: bin.TypeIDFromUint8(Instruction_Create),
 	}}
 }
 
@@ -147,9 +129,10 @@ func (inst *Create) Validate() error {
 	if inst.Mint.IsZero() {
 		return errors.New("Mint not set")
 	}
-	_, _, err := solana.FindAssociatedTokenAddress(
+	_, _, err := solana.FindAssociatedTokenAddressWithProgramID(
 		inst.Wallet,
 		inst.Mint,
+		inst.tokenProgramID(),
 	)
 	if err != nil {
 		return fmt.Errorf("error while FindAssociatedTokenAddress: %w", err)
@@ -182,6 +165,53 @@ func (inst *Create) EncodeToTree(parent treeout.Branches) {
 		})
 }
 
+// createAccounts returns the accounts of the Create and CreateIdempotent instructions.
+func createAccounts(
+	payer solana.PublicKey,
+	associatedTokenAddress solana.PublicKey,
+	wallet solana.PublicKey,
+	mint solana.PublicKey,
+	tokenProgram solana.PublicKey,
+) []*solana.AccountMeta {
+	return []*solana.AccountMeta{
+		{
+			PublicKey:  payer,
+			IsSigner:   true,
+			IsWritable: true,
+		},
+		{
+			PublicKey:  associatedTokenAddress,
+			IsSigner:   false,
+			IsWritable: true,
+		},
+		{
+			PublicKey:  wallet,
+			IsSigner:   false,
+			IsWritable: false,
+		},
+		{
+			PublicKey:  mint,
+			IsSigner:   false,
+			IsWritable: false,
+		},
+		{
+			PublicKey:  solana.SystemProgramID,
+			IsSigner:   false,
+			IsWritable: false,
+		},
+		{
+			PublicKey:  tokenProgram,
+			IsSigner:   false,
+			IsWritable: false,
+		},
+		{
+			PublicKey:  solana.SysVarRentPubkey,
+			IsSigner:   false,
+			IsWritable: false,
+		},
+	}
+}
+
 func (inst Create) MarshalWithEncoder(encoder *bin.Encoder) error {
 	return encoder.WriteBytes([]byte{}, false)
 }
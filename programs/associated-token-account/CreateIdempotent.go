@@ -0,0 +1,177 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package associatedtokenaccount
+
+import (
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	solana "github.com/gagliardetto/solana-go"
+	format "github.com/gagliardetto/solana-go/text/format"
+	treeout "github.com/gagliardetto/treeout"
+)
+
+// CreateIdempotent creates the associated token account like Create,
+// but succeeds without effect if the account already exists with the
+// same owner, so that it can be included in a transaction without
+// first checking whether the account exists.
+type CreateIdempotent struct {
+	Payer  solana.PublicKey `bin:"-" borsh_skip:"true"`
+	Wallet solana.PublicKey `bin:"-" borsh_skip:"true"`
+	Mint   solana.PublicKey `bin:"-" borsh_skip:"true"`
+
+	// Token program of the mint; defaults to the SPL Token program.
+	TokenProgram solana.PublicKey `bin:"-" borsh_skip:"true"`
+
+	// [0] = [WRITE, SIGNER] Payer
+	// ··········· Funding account
+	//
+	// [1] = [WRITE] AssociatedTokenAccount
+	// ··········· Associated token account address to be created
+	//
+	// [2] = [] Wallet
+	// ··········· Wallet address for the new associated token account
+	//
+	// [3] = [] TokenMint
+	// ··········· The token mint for the new associated token account
+	//
+	// [4] = [] SystemProgram
+	// ··········· System program ID
+	//
+	// [5] = [] TokenProgram
+	// ··········· SPL token (or token-2022) program ID
+	//
+	// [6] = [] SysVarRent
+	// ··········· SysVarRentPubkey
+	solana.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewCreateIdempotentInstructionBuilder creates a new `CreateIdempotent` instruction builder.
+func NewCreateIdempotentInstructionBuilder() *CreateIdempotent {
+	nd := &CreateIdempotent{}
+	return nd
+}
+
+func (inst *CreateIdempotent) SetPayer(payer solana.PublicKey) *CreateIdempotent {
+	inst.Payer = payer
+	return inst
+}
+
+func (inst *CreateIdempotent) SetWallet(wallet solana.PublicKey) *CreateIdempotent {
+	inst.Wallet = wallet
+	return inst
+}
+
+func (inst *CreateIdempotent) SetMint(mint solana.PublicKey) *CreateIdempotent {
+	inst.Mint = mint
+	return inst
+}
+
+// SetTokenProgram sets the token program of the mint,
+// e.g. solana.Token2022ProgramID; the default is solana.TokenProgramID.
+func (inst *CreateIdempotent) SetTokenProgram(tokenProgram solana.PublicKey) *CreateIdempotent {
+	inst.TokenProgram = tokenProgram
+	return inst
+}
+
+func (inst CreateIdempotent) tokenProgramID() solana.PublicKey {
+	if inst.TokenProgram.IsZero() {
+		return solana.TokenProgramID
+	}
+	return inst.TokenProgram
+}
+
+func (inst CreateIdempotent) Build() *Instruction {
+	associatedTokenAddress, _ := Address(inst.Wallet, inst.Mint, inst.tokenProgramID())
+	inst.AccountMetaSlice = createAccounts(inst.Payer, associatedTokenAddress, inst.Wallet, inst.Mint, inst.tokenProgramID())
+
+	return &Instruction{BaseVariant: bin.BaseVariant{
+		Impl:   inst,
+		TypeID: bin.TypeIDFromUint8(Instruction_CreateIdempotent),
+	}}
+}
+
+// ValidateAndBuild validates the instruction accounts.
+// If there is a validation error, return the error.
+// Otherwise, build and return the instruction.
+func (inst CreateIdempotent) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *CreateIdempotent) Validate() error {
+	if inst.Payer.IsZero() {
+		return errors.New("Payer not set")
+	}
+	if inst.Wallet.IsZero() {
+		return errors.New("Wallet not set")
+	}
+	if inst.Mint.IsZero() {
+		return errors.New("Mint not set")
+	}
+	if _, err := Address(inst.Wallet, inst.Mint, inst.tokenProgramID()); err != nil {
+		return fmt.Errorf("error while FindAssociatedTokenAddress: %w", err)
+	}
+	return nil
+}
+
+func (inst *CreateIdempotent) EncodeToTree(parent treeout.Branches) {
+	parent.Child(format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch treeout.Branches) {
+			programBranch.Child(format.Instruction("CreateIdempotent")).
+				//
+				ParentFunc(func(instructionBranch treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params[len=0]").ParentFunc(func(paramsBranch treeout.Branches) {})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts[len=7]").ParentFunc(func(accountsBranch treeout.Branches) {
+						accountsBranch.Child(format.Meta("                 payer", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(format.Meta("associatedTokenAddress", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(format.Meta("                wallet", inst.AccountMetaSlice.Get(2)))
+						accountsBranch.Child(format.Meta("             tokenMint", inst.AccountMetaSlice.Get(3)))
+						accountsBranch.Child(format.Meta("         systemProgram", inst.AccountMetaSlice.Get(4)))
+						accountsBranch.Child(format.Meta("          tokenProgram", inst.AccountMetaSlice.Get(5)))
+						accountsBranch.Child(format.Meta("            sysVarRent", inst.AccountMetaSlice.Get(6)))
+					})
+				})
+		})
+}
+
+func (inst CreateIdempotent) MarshalWithEncoder(encoder *bin.Encoder) error {
+	return nil
+}
+
+func (inst *CreateIdempotent) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	return nil
+}
+
+// NewCreateIdempotentInstruction declares a new CreateIdempotent instruction
+// for a mint of the SPL Token program; use SetTokenProgram for Token-2022 mints.
+func NewCreateIdempotentInstruction(
+	payer solana.PublicKey,
+	walletAddress solana.PublicKey,
+	splTokenMintAddress solana.PublicKey,
+) *CreateIdempotent {
+	return NewCreateIdempotentInstructionBuilder().
+		SetPayer(payer).
+		SetWallet(walletAddress).
+		SetMint(splTokenMintAddress)
+}
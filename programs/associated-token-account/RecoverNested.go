@@ -0,0 +1,229 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package associatedtokenaccount
+
+import (
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	solana "github.com/gagliardetto/solana-go"
+	format "github.com/gagliardetto/solana-go/text/format"
+	treeout "github.com/gagliardetto/treeout"
+)
+
+// RecoverNested transfers the tokens of a nested associated token account
+// (the associated token account of NestedMint owned by the associated
+// token account of OwnerMint of Wallet) to the associated token account
+// of NestedMint of Wallet, and closes it, refunding its lamports to Wallet.
+//
+// Both mints must be owned by the same token program.
+type RecoverNested struct {
+	Wallet     solana.PublicKey `bin:"-" borsh_skip:"true"`
+	OwnerMint  solana.PublicKey `bin:"-" borsh_skip:"true"`
+	NestedMint solana.PublicKey `bin:"-" borsh_skip:"true"`
+
+	// Token program of the mints; defaults to the SPL Token program.
+	TokenProgram solana.PublicKey `bin:"-" borsh_skip:"true"`
+
+	// [0] = [WRITE] NestedAssociatedTokenAccount
+	// ··········· Nested associated token account, must be owned by [3]
+	//
+	// [1] = [] NestedTokenMint
+	// ··········· Token mint for the nested associated token account
+	//
+	// [2] = [WRITE] DestinationAssociatedTokenAccount
+	// ··········· Wallet's associated token account of the nested mint
+	//
+	// [3] = [] OwnerAssociatedTokenAccount
+	// ··········· Owner associated token account address, must be owned by [5]
+	//
+	// [4] = [] OwnerTokenMint
+	// ··········· Token mint for the owner associated token account
+	//
+	// [5] = [WRITE, SIGNER] Wallet
+	// ··········· Wallet address for the owner associated token account
+	//
+	// [6] = [] TokenProgram
+	// ··········· SPL token (or token-2022) program ID
+	solana.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewRecoverNestedInstructionBuilder creates a new `RecoverNested` instruction builder.
+func NewRecoverNestedInstructionBuilder() *RecoverNested {
+	nd := &RecoverNested{}
+	return nd
+}
+
+func (inst *RecoverNested) SetWallet(wallet solana.PublicKey) *RecoverNested {
+	inst.Wallet = wallet
+	return inst
+}
+
+func (inst *RecoverNested) SetOwnerMint(mint solana.PublicKey) *RecoverNested {
+	inst.OwnerMint = mint
+	return inst
+}
+
+func (inst *RecoverNested) SetNestedMint(mint solana.PublicKey) *RecoverNested {
+	inst.NestedMint = mint
+	return inst
+}
+
+// SetTokenProgram sets the token program of the mints,
+// e.g. solana.Token2022ProgramID; the default is solana.TokenProgramID.
+func (inst *RecoverNested) SetTokenProgram(tokenProgram solana.PublicKey) *RecoverNested {
+	inst.TokenProgram = tokenProgram
+	return inst
+}
+
+func (inst RecoverNested) tokenProgramID() solana.PublicKey {
+	if inst.TokenProgram.IsZero() {
+		return solana.TokenProgramID
+	}
+	return inst.TokenProgram
+}
+
+// addresses returns the nested, destination and owner associated token accounts.
+func (inst RecoverNested) addresses() (nested, destination, owner solana.PublicKey, err error) {
+	tokenProgram := inst.tokenProgramID()
+	if owner, err = Address(inst.Wallet, inst.OwnerMint, tokenProgram); err != nil {
+		return
+	}
+	if nested, err = Address(owner, inst.NestedMint, tokenProgram); err != nil {
+		return
+	}
+	destination, err = Address(inst.Wallet, inst.NestedMint, tokenProgram)
+	return
+}
+
+func (inst RecoverNested) Build() *Instruction {
+	nested, destination, owner, _ := inst.addresses()
+
+	inst.AccountMetaSlice = []*solana.AccountMeta{
+		{
+			PublicKey:  nested,
+			IsSigner:   false,
+			IsWritable: true,
+		},
+		{
+			PublicKey:  inst.NestedMint,
+			IsSigner:   false,
+			IsWritable: false,
+		},
+		{
+			PublicKey:  destination,
+			IsSigner:   false,
+			IsWritable: true,
+		},
+		{
+			PublicKey:  owner,
+			IsSigner:   false,
+			IsWritable: false,
+		},
+		{
+			PublicKey:  inst.OwnerMint,
+			IsSigner:   false,
+			IsWritable: false,
+		},
+		{
+			PublicKey:  inst.Wallet,
+			IsSigner:   true,
+			IsWritable: true,
+		},
+		{
+			PublicKey:  inst.tokenProgramID(),
+			IsSigner:   false,
+			IsWritable: false,
+		},
+	}
+
+	return &Instruction{BaseVariant: bin.BaseVariant{
+		Impl:   inst,
+		TypeID: bin.TypeIDFromUint8(Instruction_RecoverNested),
+	}}
+}
+
+// ValidateAndBuild validates the instruction accounts.
+// If there is a validation error, return the error.
+// Otherwise, build and return the instruction.
+func (inst RecoverNested) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *RecoverNested) Validate() error {
+	if inst.Wallet.IsZero() {
+		return errors.New("Wallet not set")
+	}
+	if inst.OwnerMint.IsZero() {
+		return errors.New("OwnerMint not set")
+	}
+	if inst.NestedMint.IsZero() {
+		return errors.New("NestedMint not set")
+	}
+	if _, _, _, err := inst.addresses(); err != nil {
+		return fmt.Errorf("error while FindAssociatedTokenAddress: %w", err)
+	}
+	return nil
+}
+
+func (inst *RecoverNested) EncodeToTree(parent treeout.Branches) {
+	parent.Child(format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch treeout.Branches) {
+			programBranch.Child(format.Instruction("RecoverNested")).
+				//
+				ParentFunc(func(instructionBranch treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params[len=0]").ParentFunc(func(paramsBranch treeout.Branches) {})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts[len=7]").ParentFunc(func(accountsBranch treeout.Branches) {
+						accountsBranch.Child(format.Meta("     nestedAssociatedTokenAddress", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(format.Meta("                  nestedTokenMint", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(format.Meta("destinationAssociatedTokenAddress", inst.AccountMetaSlice.Get(2)))
+						accountsBranch.Child(format.Meta("      ownerAssociatedTokenAddress", inst.AccountMetaSlice.Get(3)))
+						accountsBranch.Child(format.Meta("                   ownerTokenMint", inst.AccountMetaSlice.Get(4)))
+						accountsBranch.Child(format.Meta("                           wallet", inst.AccountMetaSlice.Get(5)))
+						accountsBranch.Child(format.Meta("                     tokenProgram", inst.AccountMetaSlice.Get(6)))
+					})
+				})
+		})
+}
+
+func (inst RecoverNested) MarshalWithEncoder(encoder *bin.Encoder) error {
+	return nil
+}
+
+func (inst *RecoverNested) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	return nil
+}
+
+// NewRecoverNestedInstruction declares a new RecoverNested instruction
+// for mints of the SPL Token program; use SetTokenProgram for Token-2022 mints.
+func NewRecoverNestedInstruction(
+	wallet solana.PublicKey,
+	ownerMint solana.PublicKey,
+	nestedMint solana.PublicKey,
+) *RecoverNested {
+	return NewRecoverNestedInstructionBuilder().
+		SetWallet(wallet).
+		SetOwnerMint(ownerMint).
+		SetNestedMint(nestedMint)
+}
@@ -15,6 +15,7 @@
 package associatedtokenaccount
 
 import (
+	"bytes"
 	"fmt"
 
 	spew "github.com/davecgh/go-spew/spew"
@@ -49,12 +50,49 @@ func (inst *Instruction) EncodeToTree(parent treeout.Branches) {
 	}
 }
 
+const (
+	// Creates an associated token account for the given wallet address and token mint.
+	// Returns an error if the account exists.
+	//
+	// NOTE: encoded without instruction data, as originally defined by the program.
+	Instruction_Create uint8 = iota
+
+	// Creates an associated token account for the given wallet address and token mint,
+	// if it doesn't already exist. Returns an error if the account exists,
+	// but with a different owner.
+	Instruction_CreateIdempotent
+
+	// Transfers from and closes a nested associated token account: an
+	// associated token account owned by an associated token account.
+	Instruction_RecoverNested
+)
+
+// InstructionIDToName returns the name of the instruction given its ID.
+func InstructionIDToName(id uint8) string {
+	switch id {
+	case Instruction_Create:
+		return "Create"
+	case Instruction_CreateIdempotent:
+		return "CreateIdempotent"
+	case Instruction_RecoverNested:
+		return "RecoverNested"
+	default:
+		return ""
+	}
+}
+
 var InstructionImplDef = bin.NewVariantDefinition(
-	bin.NoTypeIDEncoding, // NOTE: the associated-token-account program has no ID encoding.
+	bin.Uint8TypeIDEncoding,
 	[]bin.VariantType{
 		{
 			"Create", (*Create)(nil),
 		},
+		{
+			"CreateIdempotent", (*CreateIdempotent)(nil),
+		},
+		{
+			"RecoverNested", (*RecoverNested)(nil),
+		},
 	},
 )
 
@@ -67,7 +105,11 @@ func (inst *Instruction) Accounts() (out []*solana.AccountMeta) {
 }
 
 func (inst *Instruction) Data() ([]byte, error) {
-	return []byte{}, nil
+	buf := new(bytes.Buffer)
+	if err := bin.NewBinEncoder(buf).Encode(inst); err != nil {
+		return nil, fmt.Errorf("unable to encode instruction: %w", err)
+	}
+	return buf.Bytes(), nil
 }
 
 func (inst *Instruction) TextEncode(encoder *text.Encoder, option *text.Option) error {
@@ -75,10 +117,21 @@ func (inst *Instruction) TextEncode(encoder *text.Encoder, option *text.Option)
 }
 
 func (inst *Instruction) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	if !decoder.HasRemaining() {
+		// Create, without instruction data.
+		inst.TypeID = bin.TypeIDFromUint8(Instruction_Create)
+		inst.Impl = new(Create)
+		return nil
+	}
 	return inst.BaseVariant.UnmarshalBinaryVariant(decoder, InstructionImplDef)
 }
 
 func (inst Instruction) MarshalWithEncoder(encoder *bin.Encoder) error {
+	if id := inst.TypeID.Uint8(); id != Instruction_Create {
+		if err := encoder.WriteUint8(id); err != nil {
+			return fmt.Errorf("unable to write variant type: %w", err)
+		}
+	}
 	return encoder.Encode(inst.Impl)
 }
 
@@ -0,0 +1,98 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package associatedtokenaccount
+
+import (
+	"testing"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateIdempotent(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	wallet := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+
+	inst, err := NewCreateIdempotentInstruction(payer, wallet, mint).
+		SetTokenProgram(solana.Token2022ProgramID).
+		ValidateAndBuild()
+	require.NoError(t, err)
+
+	data, err := inst.Data()
+	require.NoError(t, err)
+	require.Equal(t, []byte{Instruction_CreateIdempotent}, data)
+
+	ata, err := Address(wallet, mint, solana.Token2022ProgramID)
+	require.NoError(t, err)
+	accounts := inst.Accounts()
+	require.Len(t, accounts, 7)
+	require.Equal(t, ata, accounts[1].PublicKey)
+	require.Equal(t, solana.Token2022ProgramID, accounts[5].PublicKey)
+
+	decoded, err := DecodeInstruction(accounts, data)
+	require.NoError(t, err)
+	require.IsType(t, &CreateIdempotent{}, decoded.Impl)
+	require.Equal(t, accounts, decoded.Accounts())
+}
+
+func TestCreate_noData(t *testing.T) {
+	inst := NewCreateInstruction(solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()).Build()
+	data, err := inst.Data()
+	require.NoError(t, err)
+	require.Empty(t, data)
+
+	decoded, err := DecodeInstruction(inst.Accounts(), data)
+	require.NoError(t, err)
+	require.IsType(t, &Create{}, decoded.Impl)
+
+	decoded, err = DecodeInstruction(inst.Accounts(), []byte{Instruction_Create})
+	require.NoError(t, err)
+	require.IsType(t, &Create{}, decoded.Impl)
+}
+
+func TestRecoverNested(t *testing.T) {
+	wallet := solana.NewWallet().PublicKey()
+	ownerMint := solana.NewWallet().PublicKey()
+	nestedMint := solana.NewWallet().PublicKey()
+
+	inst, err := NewRecoverNestedInstruction(wallet, ownerMint, nestedMint).ValidateAndBuild()
+	require.NoError(t, err)
+
+	data, err := inst.Data()
+	require.NoError(t, err)
+	require.Equal(t, []byte{Instruction_RecoverNested}, data)
+
+	owner, err := Address(wallet, ownerMint, solana.PublicKey{})
+	require.NoError(t, err)
+	nested, err := Address(owner, nestedMint, solana.TokenProgramID)
+	require.NoError(t, err)
+	destination, err := Address(wallet, nestedMint, solana.TokenProgramID)
+	require.NoError(t, err)
+
+	accounts := inst.Accounts()
+	require.Len(t, accounts, 7)
+	require.Equal(t, nested, accounts[0].PublicKey)
+	require.True(t, accounts[0].IsWritable)
+	require.Equal(t, destination, accounts[2].PublicKey)
+	require.Equal(t, owner, accounts[3].PublicKey)
+	require.Equal(t, wallet, accounts[5].PublicKey)
+	require.True(t, accounts[5].IsSigner)
+	require.Equal(t, solana.TokenProgramID, accounts[6].PublicKey)
+
+	decoded, err := DecodeInstruction(accounts, data)
+	require.NoError(t, err)
+	require.IsType(t, &RecoverNested{}, decoded.Impl)
+}
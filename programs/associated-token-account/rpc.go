@@ -0,0 +1,85 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package associatedtokenaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Address returns the associated token account of owner for mint,
+// owned by tokenProgram: solana.TokenProgramID (the default, if zero)
+// or solana.Token2022ProgramID.
+func Address(owner solana.PublicKey, mint solana.PublicKey, tokenProgram solana.PublicKey) (solana.PublicKey, error) {
+	if tokenProgram.IsZero() {
+		tokenProgram = solana.TokenProgramID
+	}
+	ata, _, err := solana.FindAssociatedTokenAddressWithProgramID(owner, mint, tokenProgram)
+	return ata, err
+}
+
+// GetOrCreateATAInstruction returns the associated token account of wallet
+// for mint, owned by tokenProgram (solana.TokenProgramID if zero),
+// and appends to instructions the instruction creating it
+// if the account doesn't exist yet.
+func GetOrCreateATAInstruction(
+	ctx context.Context,
+	rpcCli *rpc.Client,
+	instructions []solana.Instruction,
+	payer solana.PublicKey,
+	wallet solana.PublicKey,
+	mint solana.PublicKey,
+	tokenProgram solana.PublicKey, // optional
+) (solana.PublicKey, []solana.Instruction, error) {
+	if tokenProgram.IsZero() {
+		tokenProgram = solana.TokenProgramID
+	}
+	ata, _, err := solana.FindAssociatedTokenAddressWithProgramID(wallet, mint, tokenProgram)
+	if err != nil {
+		return solana.PublicKey{}, instructions, fmt.Errorf("error while FindAssociatedTokenAddress: %w", err)
+	}
+
+	resp, err := rpcCli.GetAccountInfoWithOpts(
+		ctx,
+		ata,
+		&rpc.GetAccountInfoOpts{
+			// Only the owner is needed.
+			DataSlice: &rpc.DataSlice{
+				Offset: new(uint64),
+				Length: new(uint64),
+			},
+		},
+	)
+	switch {
+	case errors.Is(err, rpc.ErrNotFound):
+		inst, err := NewCreateInstruction(payer, wallet, mint).
+			SetTokenProgram(tokenProgram).
+			ValidateAndBuild()
+		if err != nil {
+			return solana.PublicKey{}, instructions, err
+		}
+		return ata, append(instructions, inst), nil
+	case err != nil:
+		return solana.PublicKey{}, instructions, err
+	}
+	if !resp.Value.Owner.Equals(tokenProgram) {
+		return solana.PublicKey{}, instructions, fmt.Errorf("associated token account %s is owned by %s, not by %s", ata, resp.Value.Owner, tokenProgram)
+	}
+	return ata, instructions, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package associatedtokenaccount
+
+import (
+	"context"
+	"testing"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrCreateATAInstruction(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	client := rpc.New(server.URL())
+
+	payer := solana.NewWallet().PublicKey()
+	wallet := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+	expected, _, err := solana.FindAssociatedTokenAddressWithProgramID(wallet, mint, solana.Token2022ProgramID)
+	require.NoError(t, err)
+
+	{
+		// The account doesn't exist:
+		server.Handle("getAccountInfo", map[string]interface{}{
+			"context": map[string]interface{}{"slot": 1},
+			"value":   nil,
+		})
+		ata, instructions, err := GetOrCreateATAInstruction(context.Background(), client, nil, payer, wallet, mint, solana.Token2022ProgramID)
+		require.NoError(t, err)
+		require.Equal(t, expected, ata)
+		require.Len(t, instructions, 1)
+		accounts := instructions[0].Accounts()
+		require.Equal(t, expected, accounts[1].PublicKey)
+		require.Equal(t, solana.Token2022ProgramID, accounts[5].PublicKey)
+	}
+	{
+		// The account exists:
+		server.Handle("getAccountInfo", map[string]interface{}{
+			"context": map[string]interface{}{"slot": 1},
+			"value": map[string]interface{}{
+				"data":       []string{"", "base64"},
+				"executable": false,
+				"lamports":   2039280,
+				"owner":      solana.Token2022ProgramID.String(),
+				"rentEpoch":  0,
+			},
+		})
+		ata, instructions, err := GetOrCreateATAInstruction(context.Background(), client, nil, payer, wallet, mint, solana.Token2022ProgramID)
+		require.NoError(t, err)
+		require.Equal(t, expected, ata)
+		require.Empty(t, instructions)
+
+		// Owned by another token program:
+		_, _, err = GetOrCreateATAInstruction(context.Background(), client, nil, payer, wallet, mint, solana.TokenProgramID)
+		require.Error(t, err)
+	}
+}
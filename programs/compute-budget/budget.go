@@ -0,0 +1,270 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package computebudget
+
+import (
+	"fmt"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+)
+
+// Budget is the compute budget requested by the ComputeBudget instructions
+// of a transaction. A nil field is not requested.
+type Budget struct {
+	ComputeUnitLimit *uint32
+	// Price of a compute unit, in micro-lamports.
+	ComputeUnitPrice            *uint64
+	LoadedAccountsDataSizeLimit *uint32
+	HeapFrame                   *uint32
+}
+
+// Instructions returns the instructions requesting the budget.
+func (b Budget) Instructions() (out []ag_solanago.Instruction) {
+	if b.ComputeUnitLimit != nil {
+		out = append(out, NewSetComputeUnitLimitInstruction(*b.ComputeUnitLimit).Build())
+	}
+	if b.ComputeUnitPrice != nil {
+		out = append(out, NewSetComputeUnitPriceInstruction(*b.ComputeUnitPrice).Build())
+	}
+	if b.LoadedAccountsDataSizeLimit != nil {
+		out = append(out, NewSetLoadedAccountsDataSizeLimitInstruction(*b.LoadedAccountsDataSizeLimit).Build())
+	}
+	if b.HeapFrame != nil {
+		out = append(out, NewRequestHeapFrameInstruction(*b.HeapFrame).Build())
+	}
+	return out
+}
+
+// set records the request of inst, and fails if it is already requested,
+// as the runtime rejects transactions with duplicate instructions.
+func (b *Budget) set(inst *Instruction) error {
+	duplicate := false
+	switch impl := inst.Impl.(type) {
+	case *SetComputeUnitLimit:
+		duplicate = b.ComputeUnitLimit != nil
+		b.ComputeUnitLimit = &impl.Units
+	case *SetComputeUnitPrice:
+		duplicate = b.ComputeUnitPrice != nil
+		b.ComputeUnitPrice = &impl.MicroLamports
+	case *SetLoadedAccountsDataSizeLimit:
+		duplicate = b.LoadedAccountsDataSizeLimit != nil
+		b.LoadedAccountsDataSizeLimit = &impl.Bytes
+	case *RequestHeapFrame:
+		duplicate = b.HeapFrame != nil
+		b.HeapFrame = &impl.HeapSize
+	}
+	if duplicate {
+		return fmt.Errorf("duplicate %s instruction", InstructionIDToName(inst.TypeID.Uint8()))
+	}
+	return nil
+}
+
+// merge overrides the requests of b with the ones of other.
+func (b *Budget) merge(other Budget) {
+	if other.ComputeUnitLimit != nil {
+		b.ComputeUnitLimit = other.ComputeUnitLimit
+	}
+	if other.ComputeUnitPrice != nil {
+		b.ComputeUnitPrice = other.ComputeUnitPrice
+	}
+	if other.LoadedAccountsDataSizeLimit != nil {
+		b.LoadedAccountsDataSizeLimit = other.LoadedAccountsDataSizeLimit
+	}
+	if other.HeapFrame != nil {
+		b.HeapFrame = other.HeapFrame
+	}
+}
+
+// IsComputeBudgetInstruction reports whether inst is executed by the ComputeBudget program.
+func IsComputeBudgetInstruction(inst ag_solanago.Instruction) bool {
+	return inst.ProgramID().Equals(ProgramID)
+}
+
+// decodeBudgetInstruction decodes inst from its data, so that the Impl
+// of built instructions (values) and decoded ones (pointers) are alike.
+func decodeBudgetInstruction(inst ag_solanago.Instruction) (*Instruction, error) {
+	data, err := inst.Data()
+	if err != nil {
+		return nil, err
+	}
+	return DecodeInstruction(nil, data)
+}
+
+// ParseInstructions returns the budget requested by the ComputeBudget
+// instructions among the provided instructions.
+func ParseInstructions(instructions []ag_solanago.Instruction) (*Budget, error) {
+	budget := &Budget{}
+	for i, inst := range instructions {
+		if !IsComputeBudgetInstruction(inst) {
+			continue
+		}
+		decoded, err := decodeBudgetInstruction(inst)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		if err := budget.set(decoded); err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+	}
+	return budget, nil
+}
+
+// StripInstructions returns the provided instructions
+// without the ComputeBudget instructions.
+func StripInstructions(instructions []ag_solanago.Instruction) []ag_solanago.Instruction {
+	out := make([]ag_solanago.Instruction, 0, len(instructions))
+	for _, inst := range instructions {
+		if !IsComputeBudgetInstruction(inst) {
+			out = append(out, inst)
+		}
+	}
+	return out
+}
+
+// ReplaceInstructions returns the provided instructions with the
+// ComputeBudget instructions replaced by the ones requesting budget;
+// the requests of the existing instructions that are not set in budget are kept.
+func ReplaceInstructions(instructions []ag_solanago.Instruction, budget Budget) ([]ag_solanago.Instruction, error) {
+	current, err := ParseInstructions(instructions)
+	if err != nil {
+		return nil, err
+	}
+	current.merge(budget)
+	return append(current.Instructions(), StripInstructions(instructions)...), nil
+}
+
+// ParseTransaction returns the budget requested by the ComputeBudget
+// instructions of a compiled transaction.
+func ParseTransaction(tx *ag_solanago.Transaction) (*Budget, error) {
+	budget := &Budget{}
+	for i, inst := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(inst.ProgramIDIndex)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		if !programID.Equals(ProgramID) {
+			continue
+		}
+		decoded, err := DecodeInstruction(nil, inst.Data)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		if err := budget.set(decoded); err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+	}
+	return budget, nil
+}
+
+// StripTransaction removes the ComputeBudget instructions of a compiled transaction.
+// The ComputeBudget program is kept in the account keys.
+// The signatures of the transaction are invalidated: sign it again.
+func StripTransaction(tx *ag_solanago.Transaction) error {
+	indexes, err := budgetInstructionIndexes(tx)
+	if err != nil {
+		return err
+	}
+	instructions := tx.Message.Instructions[:0]
+	for i, inst := range tx.Message.Instructions {
+		if _, ok := indexes[i]; !ok {
+			instructions = append(instructions, inst)
+		}
+	}
+	tx.Message.Instructions = instructions
+	return nil
+}
+
+// SetTransactionBudget replaces the ComputeBudget instructions of a compiled
+// transaction with the ones requesting budget; the requests of the existing
+// instructions that are not set in budget are kept. The ComputeBudget program
+// is added to the account keys if needed.
+// The signatures of the transaction are invalidated: sign it again.
+func SetTransactionBudget(tx *ag_solanago.Transaction, budget Budget) error {
+	current, err := ParseTransaction(tx)
+	if err != nil {
+		return err
+	}
+	current.merge(budget)
+	if err := StripTransaction(tx); err != nil {
+		return err
+	}
+
+	programIndex := addStaticReadonlyKey(&tx.Message, ProgramID)
+	var compiled []ag_solanago.CompiledInstruction
+	for _, inst := range current.Instructions() {
+		data, err := inst.Data()
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, ag_solanago.CompiledInstruction{
+			ProgramIDIndex: programIndex,
+			Accounts:       []uint16{},
+			Data:           data,
+		})
+	}
+	tx.Message.Instructions = append(compiled, tx.Message.Instructions...)
+	return nil
+}
+
+// budgetInstructionIndexes returns the indexes of the ComputeBudget instructions of tx.
+func budgetInstructionIndexes(tx *ag_solanago.Transaction) (map[int]struct{}, error) {
+	indexes := map[int]struct{}{}
+	for i, inst := range tx.Message.Instructions {
+		programID, err := tx.Message.Program(inst.ProgramIDIndex)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		if programID.Equals(ProgramID) {
+			indexes[i] = struct{}{}
+		}
+	}
+	return indexes, nil
+}
+
+// addStaticReadonlyKey returns the index of key among the static account keys
+// of the message, appending it as a readonly non-signer account if missing.
+// The indexes of the accounts loaded from address tables are shifted accordingly.
+func addStaticReadonlyKey(msg *ag_solanago.Message, key ag_solanago.PublicKey) uint16 {
+	numStatic := len(msg.AccountKeys)
+	if msg.IsResolved() {
+		numStatic -= msg.NumLookups()
+	}
+	for i, existing := range msg.AccountKeys[:numStatic] {
+		if existing.Equals(key) {
+			return uint16(i)
+		}
+	}
+
+	keys := make(ag_solanago.PublicKeySlice, 0, len(msg.AccountKeys)+1)
+	keys = append(keys, msg.AccountKeys[:numStatic]...)
+	keys = append(keys, key)
+	msg.AccountKeys = append(keys, msg.AccountKeys[numStatic:]...)
+	msg.Header.NumReadonlyUnsignedAccounts++
+
+	shift := func(index uint16) uint16 {
+		if int(index) >= numStatic {
+			return index + 1
+		}
+		return index
+	}
+	for i := range msg.Instructions {
+		inst := &msg.Instructions[i]
+		inst.ProgramIDIndex = shift(inst.ProgramIDIndex)
+		for j := range inst.Accounts {
+			inst.Accounts[j] = shift(inst.Accounts[j])
+		}
+	}
+	return uint16(numStatic)
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package computebudget
+
+import (
+	"testing"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func uint32Ptr(v uint32) *uint32 { return &v }
+func uint64Ptr(v uint64) *uint64 { return &v }
+
+func TestBudget_instructions(t *testing.T) {
+	program := ag_solanago.NewWallet().PublicKey()
+	other := ag_solanago.NewInstruction(program, nil, []byte{1})
+	instructions := []ag_solanago.Instruction{
+		NewSetComputeUnitLimitInstruction(200_000).Build(),
+		other,
+		NewSetComputeUnitPriceInstruction(10).Build(),
+	}
+
+	budget, err := ParseInstructions(instructions)
+	require.NoError(t, err)
+	require.Equal(t, &Budget{ComputeUnitLimit: uint32Ptr(200_000), ComputeUnitPrice: uint64Ptr(10)}, budget)
+
+	require.Equal(t, []ag_solanago.Instruction{other}, StripInstructions(instructions))
+
+	replaced, err := ReplaceInstructions(instructions, Budget{ComputeUnitPrice: uint64Ptr(5000), LoadedAccountsDataSizeLimit: uint32Ptr(1 << 16)})
+	require.NoError(t, err)
+	require.Len(t, replaced, 4)
+	require.Equal(t, other, replaced[3])
+	budget, err = ParseInstructions(replaced)
+	require.NoError(t, err)
+	require.Equal(t, &Budget{
+		ComputeUnitLimit:            uint32Ptr(200_000),
+		ComputeUnitPrice:            uint64Ptr(5000),
+		LoadedAccountsDataSizeLimit: uint32Ptr(1 << 16),
+	}, budget)
+
+	_, err = ParseInstructions(append(instructions, NewSetComputeUnitPriceInstruction(1).Build()))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "duplicate SetComputeUnitPrice instruction")
+}
+
+func TestBudget_transaction(t *testing.T) {
+	payer := ag_solanago.NewWallet().PublicKey()
+	program := ag_solanago.NewWallet().PublicKey()
+	table := ag_solanago.NewWallet().PublicKey()
+	writable := ag_solanago.NewWallet().PublicKey()
+	other := ag_solanago.NewInstruction(program, ag_solanago.AccountMetaSlice{
+		ag_solanago.Meta(writable).WRITE(),
+	}, []byte{1})
+
+	tx, err := ag_solanago.NewTransaction(
+		[]ag_solanago.Instruction{other},
+		ag_solanago.Hash{1},
+		ag_solanago.TransactionPayer(payer),
+		ag_solanago.TransactionAddressTables(map[ag_solanago.PublicKey]ag_solanago.PublicKeySlice{
+			table: {writable},
+		}),
+	)
+	require.NoError(t, err)
+	require.Len(t, tx.Message.AccountKeys, 2)
+	require.Equal(t, uint16(2), tx.Message.Instructions[0].Accounts[0])
+
+	budget, err := ParseTransaction(tx)
+	require.NoError(t, err)
+	require.Equal(t, &Budget{}, budget)
+
+	require.NoError(t, SetTransactionBudget(tx, Budget{ComputeUnitLimit: uint32Ptr(50_000), ComputeUnitPrice: uint64Ptr(1000)}))
+	require.Equal(t, ag_solanago.PublicKeySlice{payer, program, ProgramID}, tx.Message.AccountKeys)
+	require.Equal(t, uint8(2), tx.Message.Header.NumReadonlyUnsignedAccounts)
+	require.Len(t, tx.Message.Instructions, 3)
+	// The index of the account loaded from the address table is shifted.
+	require.Equal(t, uint16(3), tx.Message.Instructions[2].Accounts[0])
+
+	// Round trip through the wire format.
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	decoded, err := ag_solanago.TransactionFromBytes(raw)
+	require.NoError(t, err)
+	budget, err = ParseTransaction(decoded)
+	require.NoError(t, err)
+	require.Equal(t, &Budget{ComputeUnitLimit: uint32Ptr(50_000), ComputeUnitPrice: uint64Ptr(1000)}, budget)
+
+	// Replacing the price keeps the limit and reuses the program key.
+	require.NoError(t, SetTransactionBudget(decoded, Budget{ComputeUnitPrice: uint64Ptr(7)}))
+	require.Len(t, decoded.Message.AccountKeys, 3)
+	budget, err = ParseTransaction(decoded)
+	require.NoError(t, err)
+	require.Equal(t, &Budget{ComputeUnitLimit: uint32Ptr(50_000), ComputeUnitPrice: uint64Ptr(7)}, budget)
+
+	require.NoError(t, StripTransaction(decoded))
+	require.Len(t, decoded.Message.Instructions, 1)
+	budget, err = ParseTransaction(decoded)
+	require.NoError(t, err)
+	require.Equal(t, &Budget{}, budget)
+}
@@ -57,6 +57,10 @@ const (
 	// Set a compute unit price in "micro-lamports" to pay a higher transaction
 	// fee for higher transaction prioritization.
 	Instruction_SetComputeUnitPrice
+
+	// Set a specific transaction-wide account data size limit, in bytes,
+	// that is allowed to load.
+	Instruction_SetLoadedAccountsDataSizeLimit
 )
 
 // InstructionIDToName returns the name of the instruction given its ID.
@@ -70,6 +74,8 @@ func InstructionIDToName(id uint8) string {
 		return "SetComputeUnitLimit"
 	case Instruction_SetComputeUnitPrice:
 		return "SetComputeUnitPrice"
+	case Instruction_SetLoadedAccountsDataSizeLimit:
+		return "SetLoadedAccountsDataSizeLimit"
 	default:
 		return ""
 	}
@@ -102,6 +108,9 @@ var InstructionImplDef = ag_binary.NewVariantDefinition(
 		{
 			"SetComputeUnitPrice", (*SetComputeUnitPrice)(nil),
 		},
+		{
+			"SetLoadedAccountsDataSizeLimit", (*SetLoadedAccountsDataSizeLimit)(nil),
+		},
 	},
 )
 
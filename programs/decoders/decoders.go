@@ -0,0 +1,34 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package decoders registers the instruction decoders of the built-in
+// programs, so that solana.DecodeInstruction and
+// solana.DecodeTransactionInstructions can decode their instructions.
+//
+// Import it for its side effects:
+//
+//	import _ "github.com/gagliardetto/solana-go/programs/decoders"
+package decoders
+
+import (
+	_ "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	_ "github.com/gagliardetto/solana-go/programs/compute-budget"
+	_ "github.com/gagliardetto/solana-go/programs/ed25519"
+	_ "github.com/gagliardetto/solana-go/programs/memo"
+	_ "github.com/gagliardetto/solana-go/programs/secp256k1"
+	_ "github.com/gagliardetto/solana-go/programs/stake"
+	_ "github.com/gagliardetto/solana-go/programs/system"
+	_ "github.com/gagliardetto/solana-go/programs/token"
+	_ "github.com/gagliardetto/solana-go/programs/token-2022"
+)
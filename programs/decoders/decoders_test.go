@@ -0,0 +1,69 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package decoders
+
+import (
+	"testing"
+
+	solana "github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/memo"
+	"github.com/gagliardetto/solana-go/programs/stake"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTransactionInstructions(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	recipient := solana.NewWallet().PublicKey()
+	stakeAccount := solana.NewWallet().PublicKey()
+	unknownProgram := solana.NewWallet().PublicKey()
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			computebudget.NewSetComputeUnitPriceInstruction(1000).Build(),
+			system.NewTransferInstruction(42, payer, recipient).Build(),
+			stake.NewWithdrawInstruction(7, stakeAccount, recipient, solana.SysVarClockPubkey, solana.SysVarStakeHistoryPubkey, payer).Build(),
+			memo.NewMemoInstruction([]byte("hello"), payer),
+			solana.NewInstruction(unknownProgram, solana.AccountMetaSlice{solana.Meta(recipient)}, []byte{1, 2, 3}),
+		},
+		solana.Hash{},
+		solana.TransactionPayer(payer),
+	)
+	require.NoError(t, err)
+
+	decoded, err := solana.DecodeTransactionInstructions(tx)
+	require.NoError(t, err)
+	require.Len(t, decoded, 5)
+
+	price := decoded[0].Instruction.(*computebudget.Instruction).Impl.(*computebudget.SetComputeUnitPrice)
+	require.Equal(t, uint64(1000), price.MicroLamports)
+
+	transfer := decoded[1].Instruction.(*system.Instruction).Impl.(*system.Transfer)
+	require.Equal(t, uint64(42), *transfer.Lamports)
+	require.Equal(t, recipient, transfer.GetRecipientAccount().PublicKey)
+
+	withdraw := decoded[2].Instruction.(*stake.Instruction).Impl.(*stake.Withdraw)
+	require.Equal(t, uint64(7), *withdraw.Lamports)
+	require.Equal(t, payer, withdraw.GetWithdrawAuthority().PublicKey)
+	require.Contains(t, decoded[2].String(), "Withdraw")
+
+	require.Equal(t, "hello", decoded[3].Instruction.(*memo.Instruction).Text())
+
+	require.Equal(t, unknownProgram, decoded[4].ProgramID)
+	require.Nil(t, decoded[4].Instruction)
+	require.ErrorIs(t, decoded[4].Err, solana.ErrInstructionDecoderNotFound)
+	require.Contains(t, decoded[4].String(), unknownProgram.String())
+}
@@ -0,0 +1,273 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Ed25519 precompile: verifies ed25519 signatures over messages found
+// in the data of the transaction instructions.
+
+package ed25519
+
+import (
+	crypto_ed25519 "crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	solana "github.com/gagliardetto/solana-go"
+	format "github.com/gagliardetto/solana-go/text/format"
+	treeout "github.com/gagliardetto/treeout"
+)
+
+var ProgramID solana.PublicKey = solana.Ed25519ProgramID
+
+func SetProgramID(pubkey solana.PublicKey) {
+	ProgramID = pubkey
+	solana.RegisterInstructionDecoder(ProgramID, registryDecodeInstruction)
+}
+
+const ProgramName = "Ed25519SigVerify"
+
+func init() {
+	solana.RegisterInstructionDecoder(ProgramID, registryDecodeInstruction)
+}
+
+const (
+	// SignatureOffsetsStart is the position of the first SignatureOffsets
+	// in the instruction data, after the number of signatures and a padding byte.
+	SignatureOffsetsStart = 2
+	// SignatureOffsetsSize is the encoded size of SignatureOffsets.
+	SignatureOffsetsSize = 14
+
+	// CurrentInstruction is the instruction index referring to
+	// the data of the verify instruction itself.
+	CurrentInstruction = math.MaxUint16
+)
+
+var ErrInvalidInstructionData = errors.New("invalid ed25519 instruction data")
+
+// SignatureOffsets locates the signature, the public key and the message
+// of a verification, each in the data of the instruction at the provided
+// index of the transaction (or CurrentInstruction).
+type SignatureOffsets struct {
+	SignatureOffset           uint16
+	SignatureInstructionIndex uint16
+	PublicKeyOffset           uint16
+	PublicKeyInstructionIndex uint16
+	MessageDataOffset         uint16
+	MessageDataSize           uint16
+	MessageInstructionIndex   uint16
+}
+
+// SignedMessage is a message signed by an ed25519 key.
+type SignedMessage struct {
+	PublicKey solana.PublicKey
+	Signature solana.Signature
+	Message   []byte
+}
+
+// Instruction verifies ed25519 signatures; the transaction fails
+// if any of them is invalid. It takes no accounts.
+type Instruction struct {
+	Offsets []SignatureOffsets
+
+	// Payload is the data following the offsets, where the offsets
+	// referring to CurrentInstruction point to (offsets are relative
+	// to the start of the instruction data, not of the payload).
+	Payload []byte
+}
+
+// NewVerifyInstruction creates an instruction verifying the provided
+// signed messages, whose public keys, signatures and messages are all
+// carried by the instruction itself.
+func NewVerifyInstruction(messages ...SignedMessage) (*Instruction, error) {
+	if len(messages) == 0 || len(messages) > math.MaxUint8 {
+		return nil, fmt.Errorf("invalid number of signatures: %d", len(messages))
+	}
+	inst := &Instruction{}
+	offset := SignatureOffsetsStart + len(messages)*SignatureOffsetsSize
+	for _, message := range messages {
+		publicKeyOffset := offset
+		signatureOffset := publicKeyOffset + solana.PublicKeyLength
+		messageOffset := signatureOffset + solana.SignatureLength
+		offset = messageOffset + len(message.Message)
+		if offset > math.MaxUint16 {
+			return nil, errors.New("messages are too long")
+		}
+		inst.Offsets = append(inst.Offsets, SignatureOffsets{
+			SignatureOffset:           uint16(signatureOffset),
+			SignatureInstructionIndex: CurrentInstruction,
+			PublicKeyOffset:           uint16(publicKeyOffset),
+			PublicKeyInstructionIndex: CurrentInstruction,
+			MessageDataOffset:         uint16(messageOffset),
+			MessageDataSize:           uint16(len(message.Message)),
+			MessageInstructionIndex:   CurrentInstruction,
+		})
+		inst.Payload = append(inst.Payload, message.PublicKey[:]...)
+		inst.Payload = append(inst.Payload, message.Signature[:]...)
+		inst.Payload = append(inst.Payload, message.Message...)
+	}
+	return inst, nil
+}
+
+// NewVerifyInstructionWithPrivateKey signs the message with the private key,
+// and creates an instruction verifying the signature.
+func NewVerifyInstructionWithPrivateKey(key solana.PrivateKey, message []byte) (*Instruction, error) {
+	signature, err := key.Sign(message)
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifyInstruction(SignedMessage{
+		PublicKey: key.PublicKey(),
+		Signature: signature,
+		Message:   message,
+	})
+}
+
+func (inst *Instruction) ProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (inst *Instruction) Accounts() []*solana.AccountMeta {
+	return nil
+}
+
+func (inst *Instruction) Data() ([]byte, error) {
+	if len(inst.Offsets) > math.MaxUint8 {
+		return nil, fmt.Errorf("invalid number of signatures: %d", len(inst.Offsets))
+	}
+	buf := make([]byte, SignatureOffsetsStart, SignatureOffsetsStart+len(inst.Offsets)*SignatureOffsetsSize+len(inst.Payload))
+	buf[0] = uint8(len(inst.Offsets))
+	for _, o := range inst.Offsets {
+		buf = binary.LittleEndian.AppendUint16(buf, o.SignatureOffset)
+		buf = binary.LittleEndian.AppendUint16(buf, o.SignatureInstructionIndex)
+		buf = binary.LittleEndian.AppendUint16(buf, o.PublicKeyOffset)
+		buf = binary.LittleEndian.AppendUint16(buf, o.PublicKeyInstructionIndex)
+		buf = binary.LittleEndian.AppendUint16(buf, o.MessageDataOffset)
+		buf = binary.LittleEndian.AppendUint16(buf, o.MessageDataSize)
+		buf = binary.LittleEndian.AppendUint16(buf, o.MessageInstructionIndex)
+	}
+	return append(buf, inst.Payload...), nil
+}
+
+// SignedMessages returns the signed messages verified by the instruction;
+// it fails if any of them refers to the data of another instruction.
+func (inst *Instruction) SignedMessages() ([]SignedMessage, error) {
+	data, err := inst.Data()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SignedMessage, 0, len(inst.Offsets))
+	for i, o := range inst.Offsets {
+		if o.SignatureInstructionIndex != CurrentInstruction ||
+			o.PublicKeyInstructionIndex != CurrentInstruction ||
+			o.MessageInstructionIndex != CurrentInstruction {
+			return nil, fmt.Errorf("signature %d refers to another instruction", i)
+		}
+		signature, ok := slice(data, o.SignatureOffset, solana.SignatureLength)
+		if !ok {
+			return nil, fmt.Errorf("signature %d: signature out of bounds", i)
+		}
+		publicKey, ok := slice(data, o.PublicKeyOffset, solana.PublicKeyLength)
+		if !ok {
+			return nil, fmt.Errorf("signature %d: public key out of bounds", i)
+		}
+		message, ok := slice(data, o.MessageDataOffset, int(o.MessageDataSize))
+		if !ok {
+			return nil, fmt.Errorf("signature %d: message out of bounds", i)
+		}
+		out = append(out, SignedMessage{
+			PublicKey: solana.PublicKeyFromBytes(publicKey),
+			Signature: solana.SignatureFromBytes(signature),
+			Message:   message,
+		})
+	}
+	return out, nil
+}
+
+// Verify checks the signatures locally, as the program would;
+// it fails if any of them refers to the data of another instruction.
+func (inst *Instruction) Verify() error {
+	messages, err := inst.SignedMessages()
+	if err != nil {
+		return err
+	}
+	for i, m := range messages {
+		if !crypto_ed25519.Verify(m.PublicKey[:], m.Message, m.Signature[:]) {
+			return fmt.Errorf("signature %d: invalid signature by %s", i, m.PublicKey)
+		}
+	}
+	return nil
+}
+
+func slice(data []byte, offset uint16, size int) ([]byte, bool) {
+	end := int(offset) + size
+	if end > len(data) {
+		return nil, false
+	}
+	return data[offset:end], true
+}
+
+func (inst *Instruction) EncodeToTree(parent treeout.Branches) {
+	parent.Child(format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch treeout.Branches) {
+			programBranch.Child(format.Instruction("Verify")).
+				//
+				ParentFunc(func(instructionBranch treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch treeout.Branches) {
+						for i, o := range inst.Offsets {
+							paramsBranch.Child(format.Param(fmt.Sprintf("Signature[%d]", i), o))
+						}
+					})
+				})
+		})
+}
+
+func registryDecodeInstruction(accounts []*solana.AccountMeta, data []byte) (interface{}, error) {
+	inst, err := DecodeInstruction(accounts, data)
+	if err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+func DecodeInstruction(accounts []*solana.AccountMeta, data []byte) (*Instruction, error) {
+	if len(data) < SignatureOffsetsStart {
+		return nil, ErrInvalidInstructionData
+	}
+	count := int(data[0])
+	start := SignatureOffsetsStart + count*SignatureOffsetsSize
+	if len(data) < start {
+		return nil, ErrInvalidInstructionData
+	}
+	inst := &Instruction{
+		Offsets: make([]SignatureOffsets, count),
+		Payload: data[start:],
+	}
+	for i := range inst.Offsets {
+		b := data[SignatureOffsetsStart+i*SignatureOffsetsSize:]
+		inst.Offsets[i] = SignatureOffsets{
+			SignatureOffset:           binary.LittleEndian.Uint16(b[0:]),
+			SignatureInstructionIndex: binary.LittleEndian.Uint16(b[2:]),
+			PublicKeyOffset:           binary.LittleEndian.Uint16(b[4:]),
+			PublicKeyInstructionIndex: binary.LittleEndian.Uint16(b[6:]),
+			MessageDataOffset:         binary.LittleEndian.Uint16(b[8:]),
+			MessageDataSize:           binary.LittleEndian.Uint16(b[10:]),
+			MessageInstructionIndex:   binary.LittleEndian.Uint16(b[12:]),
+		}
+	}
+	return inst, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ed25519
+
+import (
+	"testing"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyInstruction(t *testing.T) {
+	key := solana.NewWallet().PrivateKey
+	message := []byte("hello")
+
+	inst, err := NewVerifyInstructionWithPrivateKey(key, message)
+	require.NoError(t, err)
+	require.NoError(t, inst.Verify())
+	require.Nil(t, inst.Accounts())
+
+	data, err := inst.Data()
+	require.NoError(t, err)
+	require.Len(t, data, 16+32+64+len(message))
+	require.Equal(t, []byte{
+		1, 0, // number of signatures, padding
+		48, 0, 0xff, 0xff, // signature
+		16, 0, 0xff, 0xff, // public key
+		112, 0, 5, 0, 0xff, 0xff, // message
+	}, data[:16])
+	pubkey := key.PublicKey()
+	require.Equal(t, pubkey[:], data[16:48])
+	require.Equal(t, message, data[112:])
+
+	decoded, err := solana.DecodeInstruction(ProgramID, nil, data)
+	require.NoError(t, err)
+	require.Equal(t, inst, decoded)
+
+	messages, err := decoded.(*Instruction).SignedMessages()
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	require.Equal(t, pubkey, messages[0].PublicKey)
+	require.Equal(t, message, messages[0].Message)
+}
+
+func TestVerifyInstruction_Multiple(t *testing.T) {
+	alice := solana.NewWallet().PrivateKey
+	bob := solana.NewWallet().PrivateKey
+
+	var messages []SignedMessage
+	for _, m := range []struct {
+		key     solana.PrivateKey
+		message string
+	}{{alice, "a"}, {bob, "bb"}} {
+		signature, err := m.key.Sign([]byte(m.message))
+		require.NoError(t, err)
+		messages = append(messages, SignedMessage{m.key.PublicKey(), signature, []byte(m.message)})
+	}
+	inst, err := NewVerifyInstruction(messages...)
+	require.NoError(t, err)
+	require.NoError(t, inst.Verify())
+	require.EqualValues(t, 2+2*14, inst.Offsets[0].PublicKeyOffset)
+	require.EqualValues(t, 2+2*14+32+64+1, inst.Offsets[1].PublicKeyOffset)
+
+	// A signature by the wrong key.
+	messages[1].PublicKey = alice.PublicKey()
+	inst, err = NewVerifyInstruction(messages...)
+	require.NoError(t, err)
+	require.Error(t, inst.Verify())
+
+	_, err = DecodeInstruction(nil, []byte{2, 0, 1})
+	require.ErrorIs(t, err, ErrInvalidInstructionData)
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Memo program: validates a string of UTF-8 encoded characters and verifies
+// that any accounts provided are signers of the transaction.
+
+package memo
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	solana "github.com/gagliardetto/solana-go"
+	format "github.com/gagliardetto/solana-go/text/format"
+	treeout "github.com/gagliardetto/treeout"
+)
+
+var ProgramID solana.PublicKey = solana.MemoProgramID
+
+// LegacyProgramID is the ID of the first version of the Memo program,
+// which is still found in older transactions.
+var LegacyProgramID = solana.MustPublicKeyFromBase58("Memo1UhkJRfHyvLMcVucJwxXeuD728EqVDDwQDxFMNo")
+
+func SetProgramID(pubkey solana.PublicKey) {
+	ProgramID = pubkey
+	solana.RegisterInstructionDecoder(ProgramID, registryDecodeInstruction)
+}
+
+const ProgramName = "Memo"
+
+func init() {
+	solana.RegisterInstructionDecoder(ProgramID, registryDecodeInstruction)
+	solana.RegisterInstructionDecoder(LegacyProgramID, registryDecodeInstruction)
+}
+
+var ErrInvalidUTF8 = errors.New("memo is not valid UTF-8")
+
+// MaxMessageSize is the size of the largest memo that fits in a legacy
+// transaction with a single signature, whose only instruction is
+// an unsigned memo: 1232 bytes, minus 170 bytes of signature, header,
+// account keys, blockhash and instruction framing.
+// Every additional signer or instruction leaves less room.
+const MaxMessageSize = 1062
+
+// ErrMessageTooLong is returned by Validate for memos
+// longer than MaxMessageSize.
+var ErrMessageTooLong = fmt.Errorf("memo is longer than %d bytes", MaxMessageSize)
+
+// Instruction records a memo, signed by the provided signers (if any).
+type Instruction struct {
+	// The memo; the program rejects memos that are not valid UTF-8.
+	Message []byte
+
+	// [0..n] = [SIGNER] Signers
+	// ··········· Accounts that must sign the memo
+	Signers solana.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewMemoInstruction creates a new memo instruction signed by the provided signers.
+func NewMemoInstruction(message []byte, signers ...solana.PublicKey) *Instruction {
+	inst := &Instruction{
+		Message: message,
+	}
+	for _, signer := range signers {
+		inst.Signers.Append(solana.Meta(signer).SIGNER())
+	}
+	return inst
+}
+
+func (inst *Instruction) ProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (inst *Instruction) Accounts() []*solana.AccountMeta {
+	return inst.Signers.GetAccounts()
+}
+
+func (inst *Instruction) Data() ([]byte, error) {
+	return inst.Message, nil
+}
+
+// Text returns the memo as a string.
+func (inst *Instruction) Text() string {
+	return string(inst.Message)
+}
+
+// Validate checks that the memo is valid UTF-8, and that it is
+// not longer than MaxMessageSize (which leaves room only for the fee payer:
+// the transaction may still be too large once built).
+func (inst *Instruction) Validate() error {
+	if !utf8.Valid(inst.Message) {
+		return ErrInvalidUTF8
+	}
+	if len(inst.Message) > MaxMessageSize {
+		return ErrMessageTooLong
+	}
+	return nil
+}
+
+// ValidateAndBuild validates the instruction and returns it.
+func (inst *Instruction) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+func (inst *Instruction) EncodeToTree(parent treeout.Branches) {
+	parent.Child(format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch treeout.Branches) {
+			programBranch.Child(format.Instruction("Memo")).
+				//
+				ParentFunc(func(instructionBranch treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch treeout.Branches) {
+						paramsBranch.Child(format.Param("Message", inst.Text()))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch treeout.Branches) {
+						for i := range inst.Signers {
+							accountsBranch.Child(format.Meta("Signer", inst.Signers[i]))
+						}
+					})
+				})
+		})
+}
+
+func registryDecodeInstruction(accounts []*solana.AccountMeta, data []byte) (interface{}, error) {
+	inst, err := DecodeInstruction(accounts, data)
+	if err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+func DecodeInstruction(accounts []*solana.AccountMeta, data []byte) (*Instruction, error) {
+	inst := &Instruction{
+		Message: data,
+		Signers: accounts,
+	}
+	if !utf8.Valid(inst.Message) {
+		return nil, ErrInvalidUTF8
+	}
+	return inst, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memo
+
+import (
+	"testing"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoInstruction(t *testing.T) {
+	signer := solana.NewWallet().PublicKey()
+	inst := NewMemoInstruction([]byte("hello"), signer)
+
+	data, err := inst.Data()
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), data)
+	require.Equal(t, []*solana.AccountMeta{solana.Meta(signer).SIGNER()}, inst.Accounts())
+
+	decoded, err := solana.DecodeInstruction(ProgramID, inst.Accounts(), data)
+	require.NoError(t, err)
+	require.Equal(t, "hello", decoded.(*Instruction).Text())
+
+	decoded, err = solana.DecodeInstruction(LegacyProgramID, nil, data)
+	require.NoError(t, err)
+	require.Equal(t, "hello", decoded.(*Instruction).Text())
+
+	_, err = DecodeInstruction(nil, []byte{0xff})
+	require.ErrorIs(t, err, ErrInvalidUTF8)
+}
+
+func TestMemoInstruction_MaxMessageSize(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+
+	inst, err := NewMemoInstruction(make([]byte, MaxMessageSize)).ValidateAndBuild()
+	require.NoError(t, err)
+	tx, err := solana.NewTransaction([]solana.Instruction{inst}, solana.Hash{}, solana.TransactionPayer(payer))
+	require.NoError(t, err)
+	tx.Signatures = make([]solana.Signature, 1)
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	require.Len(t, raw, 1232)
+
+	_, err = NewMemoInstruction(make([]byte, MaxMessageSize+1)).ValidateAndBuild()
+	require.ErrorIs(t, err, ErrMessageTooLong)
+
+	// Decoding doesn't enforce the size.
+	_, err = DecodeInstruction(nil, make([]byte, MaxMessageSize+1))
+	require.NoError(t, err)
+}
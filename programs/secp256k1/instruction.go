@@ -0,0 +1,277 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Secp256k1 precompile: verifies that secp256k1 signatures over the keccak256
+// hash of messages recover to Ethereum addresses; the signatures, addresses
+// and messages are found in the data of the transaction instructions.
+
+package secp256k1
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	solana "github.com/gagliardetto/solana-go"
+	format "github.com/gagliardetto/solana-go/text/format"
+	treeout "github.com/gagliardetto/treeout"
+	"golang.org/x/crypto/sha3"
+)
+
+var ProgramID solana.PublicKey = solana.Secp256k1ProgramID
+
+func SetProgramID(pubkey solana.PublicKey) {
+	ProgramID = pubkey
+	solana.RegisterInstructionDecoder(ProgramID, registryDecodeInstruction)
+}
+
+const ProgramName = "Secp256k1SigVerify"
+
+func init() {
+	solana.RegisterInstructionDecoder(ProgramID, registryDecodeInstruction)
+}
+
+const (
+	// SignatureOffsetsStart is the position of the first SignatureOffsets
+	// in the instruction data, after the number of signatures.
+	SignatureOffsetsStart = 1
+	// SignatureOffsetsSize is the encoded size of SignatureOffsets.
+	SignatureOffsetsSize = 11
+
+	EthAddressSize = 20
+	// SignatureSize is the size of a signature, followed by its recovery ID.
+	SignatureSize = 64
+)
+
+var ErrInvalidInstructionData = errors.New("invalid secp256k1 instruction data")
+
+// EthAddress is the last 20 bytes of the keccak256 hash of a public key.
+type EthAddress [EthAddressSize]byte
+
+// EthAddressFromPublicKey returns the Ethereum address of the provided
+// uncompressed secp256k1 public key, with or without its 0x04 prefix.
+func EthAddressFromPublicKey(publicKey []byte) (EthAddress, error) {
+	if len(publicKey) == 65 && publicKey[0] == 0x04 {
+		publicKey = publicKey[1:]
+	}
+	if len(publicKey) != 64 {
+		return EthAddress{}, fmt.Errorf("invalid uncompressed public key length: %d", len(publicKey))
+	}
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write(publicKey)
+	var out EthAddress
+	copy(out[:], hash.Sum(nil)[12:])
+	return out, nil
+}
+
+// SignatureOffsets locates the signature (followed by its recovery ID),
+// the Ethereum address and the message of a verification, each in the data
+// of the instruction at the provided index of the transaction.
+type SignatureOffsets struct {
+	SignatureOffset            uint16
+	SignatureInstructionIndex  uint8
+	EthAddressOffset           uint16
+	EthAddressInstructionIndex uint8
+	MessageDataOffset          uint16
+	MessageDataSize            uint16
+	MessageInstructionIndex    uint8
+}
+
+// SignedMessage is a message signed by a secp256k1 key. The signature
+// is over the keccak256 hash of the message, in the [R || S] format,
+// and RecoveryID is 0 or 1.
+type SignedMessage struct {
+	EthAddress EthAddress
+	Signature  [SignatureSize]byte
+	RecoveryID uint8
+	Message    []byte
+}
+
+// Instruction verifies secp256k1 signatures; the transaction fails
+// if any of them is invalid. It takes no accounts.
+type Instruction struct {
+	Offsets []SignatureOffsets
+
+	// Payload is the data following the offsets (offsets are relative
+	// to the start of the instruction data, not of the payload).
+	Payload []byte
+}
+
+// NewVerifyInstruction creates an instruction verifying the provided
+// signed messages, whose addresses, signatures and messages are all
+// carried by the instruction itself.
+//
+// Unlike the ed25519 precompile, the secp256k1 precompile has no index
+// referring to the current instruction: instructionIndex must be
+// the index of the returned instruction in the transaction.
+func NewVerifyInstruction(instructionIndex uint8, messages ...SignedMessage) (*Instruction, error) {
+	if len(messages) == 0 || len(messages) > math.MaxUint8 {
+		return nil, fmt.Errorf("invalid number of signatures: %d", len(messages))
+	}
+	inst := &Instruction{}
+	offset := SignatureOffsetsStart + len(messages)*SignatureOffsetsSize
+	for _, message := range messages {
+		if message.RecoveryID > 3 {
+			return nil, fmt.Errorf("invalid recovery ID: %d", message.RecoveryID)
+		}
+		addressOffset := offset
+		signatureOffset := addressOffset + EthAddressSize
+		messageOffset := signatureOffset + SignatureSize + 1
+		offset = messageOffset + len(message.Message)
+		if offset > math.MaxUint16 {
+			return nil, errors.New("messages are too long")
+		}
+		inst.Offsets = append(inst.Offsets, SignatureOffsets{
+			SignatureOffset:            uint16(signatureOffset),
+			SignatureInstructionIndex:  instructionIndex,
+			EthAddressOffset:           uint16(addressOffset),
+			EthAddressInstructionIndex: instructionIndex,
+			MessageDataOffset:          uint16(messageOffset),
+			MessageDataSize:            uint16(len(message.Message)),
+			MessageInstructionIndex:    instructionIndex,
+		})
+		inst.Payload = append(inst.Payload, message.EthAddress[:]...)
+		inst.Payload = append(inst.Payload, message.Signature[:]...)
+		inst.Payload = append(inst.Payload, message.RecoveryID)
+		inst.Payload = append(inst.Payload, message.Message...)
+	}
+	return inst, nil
+}
+
+func (inst *Instruction) ProgramID() solana.PublicKey {
+	return ProgramID
+}
+
+func (inst *Instruction) Accounts() []*solana.AccountMeta {
+	return nil
+}
+
+func (inst *Instruction) Data() ([]byte, error) {
+	if len(inst.Offsets) > math.MaxUint8 {
+		return nil, fmt.Errorf("invalid number of signatures: %d", len(inst.Offsets))
+	}
+	buf := make([]byte, SignatureOffsetsStart, SignatureOffsetsStart+len(inst.Offsets)*SignatureOffsetsSize+len(inst.Payload))
+	buf[0] = uint8(len(inst.Offsets))
+	for _, o := range inst.Offsets {
+		buf = binary.LittleEndian.AppendUint16(buf, o.SignatureOffset)
+		buf = append(buf, o.SignatureInstructionIndex)
+		buf = binary.LittleEndian.AppendUint16(buf, o.EthAddressOffset)
+		buf = append(buf, o.EthAddressInstructionIndex)
+		buf = binary.LittleEndian.AppendUint16(buf, o.MessageDataOffset)
+		buf = binary.LittleEndian.AppendUint16(buf, o.MessageDataSize)
+		buf = append(buf, o.MessageInstructionIndex)
+	}
+	return append(buf, inst.Payload...), nil
+}
+
+// SignedMessages returns the signed messages verified by the instruction,
+// assuming that it is at instructionIndex in the transaction; it fails
+// if any of them refers to the data of another instruction.
+func (inst *Instruction) SignedMessages(instructionIndex uint8) ([]SignedMessage, error) {
+	data, err := inst.Data()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SignedMessage, 0, len(inst.Offsets))
+	for i, o := range inst.Offsets {
+		if o.SignatureInstructionIndex != instructionIndex ||
+			o.EthAddressInstructionIndex != instructionIndex ||
+			o.MessageInstructionIndex != instructionIndex {
+			return nil, fmt.Errorf("signature %d refers to another instruction", i)
+		}
+		signature, ok := slice(data, o.SignatureOffset, SignatureSize+1)
+		if !ok {
+			return nil, fmt.Errorf("signature %d: signature out of bounds", i)
+		}
+		address, ok := slice(data, o.EthAddressOffset, EthAddressSize)
+		if !ok {
+			return nil, fmt.Errorf("signature %d: eth address out of bounds", i)
+		}
+		message, ok := slice(data, o.MessageDataOffset, int(o.MessageDataSize))
+		if !ok {
+			return nil, fmt.Errorf("signature %d: message out of bounds", i)
+		}
+		m := SignedMessage{
+			RecoveryID: signature[SignatureSize],
+			Message:    message,
+		}
+		copy(m.EthAddress[:], address)
+		copy(m.Signature[:], signature)
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+func slice(data []byte, offset uint16, size int) ([]byte, bool) {
+	end := int(offset) + size
+	if end > len(data) {
+		return nil, false
+	}
+	return data[offset:end], true
+}
+
+func (inst *Instruction) EncodeToTree(parent treeout.Branches) {
+	parent.Child(format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch treeout.Branches) {
+			programBranch.Child(format.Instruction("Verify")).
+				//
+				ParentFunc(func(instructionBranch treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch treeout.Branches) {
+						for i, o := range inst.Offsets {
+							paramsBranch.Child(format.Param(fmt.Sprintf("Signature[%d]", i), o))
+						}
+					})
+				})
+		})
+}
+
+func registryDecodeInstruction(accounts []*solana.AccountMeta, data []byte) (interface{}, error) {
+	inst, err := DecodeInstruction(accounts, data)
+	if err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+func DecodeInstruction(accounts []*solana.AccountMeta, data []byte) (*Instruction, error) {
+	if len(data) < SignatureOffsetsStart {
+		return nil, ErrInvalidInstructionData
+	}
+	count := int(data[0])
+	start := SignatureOffsetsStart + count*SignatureOffsetsSize
+	if len(data) < start {
+		return nil, ErrInvalidInstructionData
+	}
+	inst := &Instruction{
+		Offsets: make([]SignatureOffsets, count),
+		Payload: data[start:],
+	}
+	for i := range inst.Offsets {
+		b := data[SignatureOffsetsStart+i*SignatureOffsetsSize:]
+		inst.Offsets[i] = SignatureOffsets{
+			SignatureOffset:            binary.LittleEndian.Uint16(b[0:]),
+			SignatureInstructionIndex:  b[2],
+			EthAddressOffset:           binary.LittleEndian.Uint16(b[3:]),
+			EthAddressInstructionIndex: b[5],
+			MessageDataOffset:          binary.LittleEndian.Uint16(b[6:]),
+			MessageDataSize:            binary.LittleEndian.Uint16(b[8:]),
+			MessageInstructionIndex:    b[10],
+		}
+	}
+	return inst, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secp256k1
+
+import (
+	"encoding/hex"
+	"testing"
+
+	solana "github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEthAddressFromPublicKey(t *testing.T) {
+	// Public key of the private key 1 (the generator point).
+	publicKey, err := hex.DecodeString("04" +
+		"79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798" +
+		"483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8")
+	require.NoError(t, err)
+
+	address, err := EthAddressFromPublicKey(publicKey)
+	require.NoError(t, err)
+	require.Equal(t, "7e5f4552091a69125d5dfcb7b8c2659029395bdf", hex.EncodeToString(address[:]))
+
+	_, err = EthAddressFromPublicKey(publicKey[:33])
+	require.Error(t, err)
+}
+
+func TestVerifyInstruction(t *testing.T) {
+	message := SignedMessage{
+		RecoveryID: 1,
+		Message:    []byte("hello"),
+	}
+	for i := range message.EthAddress {
+		message.EthAddress[i] = 0xaa
+	}
+	for i := range message.Signature {
+		message.Signature[i] = 0xbb
+	}
+
+	inst, err := NewVerifyInstruction(2, message)
+	require.NoError(t, err)
+	data, err := inst.Data()
+	require.NoError(t, err)
+	require.Len(t, data, 12+20+65+5)
+	require.Equal(t, []byte{
+		1,        // number of signatures
+		32, 0, 2, // signature
+		12, 0, 2, // eth address
+		97, 0, 5, 0, 2, // message
+	}, data[:12])
+	require.Equal(t, byte(1), data[96])
+	require.Equal(t, []byte("hello"), data[97:])
+
+	decoded, err := solana.DecodeInstruction(ProgramID, nil, data)
+	require.NoError(t, err)
+	require.Equal(t, inst, decoded)
+
+	messages, err := decoded.(*Instruction).SignedMessages(2)
+	require.NoError(t, err)
+	require.Equal(t, []SignedMessage{message}, messages)
+
+	_, err = decoded.(*Instruction).SignedMessages(0)
+	require.Error(t, err)
+
+	_, err = NewVerifyInstruction(0, SignedMessage{RecoveryID: 4})
+	require.Error(t, err)
+}
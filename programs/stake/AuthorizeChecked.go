@@ -0,0 +1,220 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Authorize a key to manage stake or withdrawal, requiring the new authority to sign
+type AuthorizeChecked struct {
+	// Type of the authority to change
+	StakeAuthorize *StakeAuthorize
+
+	// [0] = [WRITE] StakeAccount
+	// ··········· Stake account to be updated
+	//
+	// [1] = [] ClockSysvar
+	// ··········· Clock sysvar
+	//
+	// [2] = [SIGNER] Authority
+	// ··········· The stake or withdraw authority
+	//
+	// [3] = [SIGNER] NewAuthority
+	// ··········· The new stake or withdraw authority
+	//
+	// [4] = [SIGNER] LockupAuthority
+	// ··········· Lockup authority, if updating StakeAuthorize::Withdrawer before lockup expiration (optional)
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewAuthorizeCheckedInstructionBuilder creates a new `AuthorizeChecked` instruction builder.
+func NewAuthorizeCheckedInstructionBuilder() *AuthorizeChecked {
+	nd := &AuthorizeChecked{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 5),
+	}
+	return nd
+}
+
+// Type of the authority to change
+func (inst *AuthorizeChecked) SetStakeAuthorize(stakeAuthorize StakeAuthorize) *AuthorizeChecked {
+	inst.StakeAuthorize = &stakeAuthorize
+	return inst
+}
+
+// Stake account to be updated
+func (inst *AuthorizeChecked) SetStakeAccount(stakeAccount ag_solanago.PublicKey) *AuthorizeChecked {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(stakeAccount).WRITE()
+	return inst
+}
+
+func (inst *AuthorizeChecked) GetStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Clock sysvar
+func (inst *AuthorizeChecked) SetClockSysvar(clockSysvar ag_solanago.PublicKey) *AuthorizeChecked {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(clockSysvar)
+	return inst
+}
+
+func (inst *AuthorizeChecked) GetClockSysvar() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+// The stake or withdraw authority
+func (inst *AuthorizeChecked) SetAuthority(authority ag_solanago.PublicKey) *AuthorizeChecked {
+	inst.AccountMetaSlice[2] = ag_solanago.Meta(authority).SIGNER()
+	return inst
+}
+
+func (inst *AuthorizeChecked) GetAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(2)
+}
+
+// The new stake or withdraw authority
+func (inst *AuthorizeChecked) SetNewAuthority(newAuthority ag_solanago.PublicKey) *AuthorizeChecked {
+	inst.AccountMetaSlice[3] = ag_solanago.Meta(newAuthority).SIGNER()
+	return inst
+}
+
+func (inst *AuthorizeChecked) GetNewAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(3)
+}
+
+// Lockup authority, if updating StakeAuthorize::Withdrawer before lockup expiration
+func (inst *AuthorizeChecked) SetLockupAuthority(lockupAuthority ag_solanago.PublicKey) *AuthorizeChecked {
+	inst.AccountMetaSlice[4] = ag_solanago.Meta(lockupAuthority).SIGNER()
+	return inst
+}
+
+func (inst *AuthorizeChecked) GetLockupAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(4)
+}
+
+func (inst AuthorizeChecked) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_AuthorizeChecked, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst AuthorizeChecked) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *AuthorizeChecked) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.StakeAuthorize == nil {
+			return errors.New("StakeAuthorize parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("StakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("ClockSysvar is not set")
+		}
+		if inst.AccountMetaSlice.Get(2) == nil {
+			return fmt.Errorf("Authority is not set")
+		}
+		if inst.AccountMetaSlice.Get(3) == nil {
+			return fmt.Errorf("NewAuthority is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *AuthorizeChecked) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("AuthorizeChecked")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("StakeAuthorize", *inst.StakeAuthorize))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("          Stake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("          Clock", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(ag_format.Meta("      Authority", inst.AccountMetaSlice.Get(2)))
+						accountsBranch.Child(ag_format.Meta("   NewAuthority", inst.AccountMetaSlice.Get(3)))
+						accountsBranch.Child(ag_format.Meta("LockupAuthority", inst.AccountMetaSlice.Get(4)))
+					})
+				})
+		})
+}
+
+func (inst AuthorizeChecked) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	// Serialize `StakeAuthorize` param:
+	{
+		err := encoder.Encode(*inst.StakeAuthorize)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (inst *AuthorizeChecked) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	// Deserialize `StakeAuthorize` param:
+	{
+		err := decoder.Decode(&inst.StakeAuthorize)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewAuthorizeCheckedInstruction declares a new AuthorizeChecked instruction with the provided parameters and accounts.
+func NewAuthorizeCheckedInstruction(
+	// Parameters:
+	stakeAuthorize StakeAuthorize,
+	// Accounts:
+	stakeAccount ag_solanago.PublicKey,
+	clockSysvar ag_solanago.PublicKey,
+	authority ag_solanago.PublicKey,
+	newAuthority ag_solanago.PublicKey,
+) *AuthorizeChecked {
+	return NewAuthorizeCheckedInstructionBuilder().
+		SetStakeAuthorize(stakeAuthorize).
+		SetStakeAccount(stakeAccount).
+		SetClockSysvar(clockSysvar).
+		SetAuthority(authority).
+		SetNewAuthority(newAuthority)
+}
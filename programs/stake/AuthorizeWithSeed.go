@@ -0,0 +1,289 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Authorize a key to manage stake or withdrawal with a derived key
+type AuthorizeWithSeed struct {
+	// New authority
+	NewAuthority *ag_solanago.PublicKey
+
+	// Type of the authority to change
+	StakeAuthorize *StakeAuthorize
+
+	// Seed used to derive the current authority
+	AuthoritySeed *string
+
+	// Owner used to derive the current authority
+	AuthorityOwner *ag_solanago.PublicKey
+
+	// [0] = [WRITE] StakeAccount
+	// ··········· Stake account to be updated
+	//
+	// [1] = [SIGNER] AuthorityBase
+	// ··········· Base key of the stake or withdraw authority
+	//
+	// [2] = [] ClockSysvar
+	// ··········· Clock sysvar
+	//
+	// [3] = [SIGNER] LockupAuthority
+	// ··········· Lockup authority, if updating StakeAuthorize::Withdrawer before lockup expiration (optional)
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewAuthorizeWithSeedInstructionBuilder creates a new `AuthorizeWithSeed` instruction builder.
+func NewAuthorizeWithSeedInstructionBuilder() *AuthorizeWithSeed {
+	nd := &AuthorizeWithSeed{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 4),
+	}
+	return nd
+}
+
+// New authority
+func (inst *AuthorizeWithSeed) SetNewAuthority(newAuthority ag_solanago.PublicKey) *AuthorizeWithSeed {
+	inst.NewAuthority = &newAuthority
+	return inst
+}
+
+// Type of the authority to change
+func (inst *AuthorizeWithSeed) SetStakeAuthorize(stakeAuthorize StakeAuthorize) *AuthorizeWithSeed {
+	inst.StakeAuthorize = &stakeAuthorize
+	return inst
+}
+
+// Seed used to derive the current authority
+func (inst *AuthorizeWithSeed) SetAuthoritySeed(authoritySeed string) *AuthorizeWithSeed {
+	inst.AuthoritySeed = &authoritySeed
+	return inst
+}
+
+// Owner used to derive the current authority
+func (inst *AuthorizeWithSeed) SetAuthorityOwner(authorityOwner ag_solanago.PublicKey) *AuthorizeWithSeed {
+	inst.AuthorityOwner = &authorityOwner
+	return inst
+}
+
+// Stake account to be updated
+func (inst *AuthorizeWithSeed) SetStakeAccount(stakeAccount ag_solanago.PublicKey) *AuthorizeWithSeed {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(stakeAccount).WRITE()
+	return inst
+}
+
+func (inst *AuthorizeWithSeed) GetStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Base key of the stake or withdraw authority
+func (inst *AuthorizeWithSeed) SetAuthorityBase(authorityBase ag_solanago.PublicKey) *AuthorizeWithSeed {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(authorityBase).SIGNER()
+	return inst
+}
+
+func (inst *AuthorizeWithSeed) GetAuthorityBase() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+// Clock sysvar
+func (inst *AuthorizeWithSeed) SetClockSysvar(clockSysvar ag_solanago.PublicKey) *AuthorizeWithSeed {
+	inst.AccountMetaSlice[2] = ag_solanago.Meta(clockSysvar)
+	return inst
+}
+
+func (inst *AuthorizeWithSeed) GetClockSysvar() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(2)
+}
+
+// Lockup authority, if updating StakeAuthorize::Withdrawer before lockup expiration
+func (inst *AuthorizeWithSeed) SetLockupAuthority(lockupAuthority ag_solanago.PublicKey) *AuthorizeWithSeed {
+	inst.AccountMetaSlice[3] = ag_solanago.Meta(lockupAuthority).SIGNER()
+	return inst
+}
+
+func (inst *AuthorizeWithSeed) GetLockupAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(3)
+}
+
+func (inst AuthorizeWithSeed) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_AuthorizeWithSeed, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst AuthorizeWithSeed) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *AuthorizeWithSeed) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.NewAuthority == nil {
+			return errors.New("NewAuthority parameter is not set")
+		}
+		if inst.StakeAuthorize == nil {
+			return errors.New("StakeAuthorize parameter is not set")
+		}
+		if inst.AuthoritySeed == nil {
+			return errors.New("AuthoritySeed parameter is not set")
+		}
+		if inst.AuthorityOwner == nil {
+			return errors.New("AuthorityOwner parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("StakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("AuthorityBase is not set")
+		}
+		if inst.AccountMetaSlice.Get(2) == nil {
+			return fmt.Errorf("ClockSysvar is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *AuthorizeWithSeed) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("AuthorizeWithSeed")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("  NewAuthority", *inst.NewAuthority))
+						paramsBranch.Child(ag_format.Param("StakeAuthorize", *inst.StakeAuthorize))
+						paramsBranch.Child(ag_format.Param(" AuthoritySeed", *inst.AuthoritySeed))
+						paramsBranch.Child(ag_format.Param("AuthorityOwner", *inst.AuthorityOwner))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("          Stake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("  AuthorityBase", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(ag_format.Meta("          Clock", inst.AccountMetaSlice.Get(2)))
+						accountsBranch.Child(ag_format.Meta("LockupAuthority", inst.AccountMetaSlice.Get(3)))
+					})
+				})
+		})
+}
+
+func (inst AuthorizeWithSeed) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	// Serialize `NewAuthority` param:
+	{
+		err := encoder.Encode(*inst.NewAuthority)
+		if err != nil {
+			return err
+		}
+	}
+	// Serialize `StakeAuthorize` param:
+	{
+		err := encoder.Encode(*inst.StakeAuthorize)
+		if err != nil {
+			return err
+		}
+	}
+	// Serialize `AuthoritySeed` param:
+	{
+		err := encoder.WriteRustString(*inst.AuthoritySeed)
+		if err != nil {
+			return err
+		}
+	}
+	// Serialize `AuthorityOwner` param:
+	{
+		err := encoder.Encode(*inst.AuthorityOwner)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (inst *AuthorizeWithSeed) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	// Deserialize `NewAuthority` param:
+	{
+		err := decoder.Decode(&inst.NewAuthority)
+		if err != nil {
+			return err
+		}
+	}
+	// Deserialize `StakeAuthorize` param:
+	{
+		err := decoder.Decode(&inst.StakeAuthorize)
+		if err != nil {
+			return err
+		}
+	}
+	// Deserialize `AuthoritySeed` param:
+	{
+		value, err := decoder.ReadRustString()
+		if err != nil {
+			return err
+		}
+		inst.AuthoritySeed = &value
+	}
+	// Deserialize `AuthorityOwner` param:
+	{
+		err := decoder.Decode(&inst.AuthorityOwner)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewAuthorizeWithSeedInstruction declares a new AuthorizeWithSeed instruction with the provided parameters and accounts.
+func NewAuthorizeWithSeedInstruction(
+	// Parameters:
+	newAuthority ag_solanago.PublicKey,
+	stakeAuthorize StakeAuthorize,
+	authoritySeed string,
+	authorityOwner ag_solanago.PublicKey,
+	// Accounts:
+	stakeAccount ag_solanago.PublicKey,
+	authorityBase ag_solanago.PublicKey,
+	clockSysvar ag_solanago.PublicKey,
+) *AuthorizeWithSeed {
+	return NewAuthorizeWithSeedInstructionBuilder().
+		SetNewAuthority(newAuthority).
+		SetStakeAuthorize(stakeAuthorize).
+		SetAuthoritySeed(authoritySeed).
+		SetAuthorityOwner(authorityOwner).
+		SetStakeAccount(stakeAccount).
+		SetAuthorityBase(authorityBase).
+		SetClockSysvar(clockSysvar)
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Deactivates the stake in the account
+type Deactivate struct {
+	// [0] = [WRITE] StakeAccount
+	// ··········· Delegated stake account
+	//
+	// [1] = [] ClockSysvar
+	// ··········· Clock sysvar
+	//
+	// [2] = [SIGNER] StakeAuthority
+	// ··········· Stake authority
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewDeactivateInstructionBuilder creates a new `Deactivate` instruction builder.
+func NewDeactivateInstructionBuilder() *Deactivate {
+	nd := &Deactivate{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 3),
+	}
+	return nd
+}
+
+// Delegated stake account
+func (inst *Deactivate) SetStakeAccount(stakeAccount ag_solanago.PublicKey) *Deactivate {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(stakeAccount).WRITE()
+	return inst
+}
+
+func (inst *Deactivate) GetStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Clock sysvar
+func (inst *Deactivate) SetClockSysvar(clockSysvar ag_solanago.PublicKey) *Deactivate {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(clockSysvar)
+	return inst
+}
+
+func (inst *Deactivate) GetClockSysvar() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+// Stake authority
+func (inst *Deactivate) SetStakeAuthority(stakeAuthority ag_solanago.PublicKey) *Deactivate {
+	inst.AccountMetaSlice[2] = ag_solanago.Meta(stakeAuthority).SIGNER()
+	return inst
+}
+
+func (inst *Deactivate) GetStakeAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(2)
+}
+
+func (inst Deactivate) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_Deactivate, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst Deactivate) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *Deactivate) Validate() error {
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("StakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("ClockSysvar is not set")
+		}
+		if inst.AccountMetaSlice.Get(2) == nil {
+			return fmt.Errorf("StakeAuthority is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *Deactivate) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("Deactivate")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("         Stake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("         Clock", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(ag_format.Meta("StakeAuthority", inst.AccountMetaSlice.Get(2)))
+					})
+				})
+		})
+}
+
+func (inst Deactivate) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	return nil
+}
+
+func (inst *Deactivate) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	return nil
+}
+
+// NewDeactivateInstruction declares a new Deactivate instruction with the provided parameters and accounts.
+func NewDeactivateInstruction(
+	// Accounts:
+	stakeAccount ag_solanago.PublicKey,
+	clockSysvar ag_solanago.PublicKey,
+	stakeAuthority ag_solanago.PublicKey,
+) *Deactivate {
+	return NewDeactivateInstructionBuilder().
+		SetStakeAccount(stakeAccount).
+		SetClockSysvar(clockSysvar).
+		SetStakeAuthority(stakeAuthority)
+}
@@ -0,0 +1,152 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Deactivate stake delegated to a vote account that has been delinquent for at least MINIMUM_DELINQUENT_EPOCHS_FOR_DEACTIVATION epochs
+type DeactivateDelinquent struct {
+	// [0] = [WRITE] StakeAccount
+	// ··········· Delegated stake account
+	//
+	// [1] = [] DelinquentVoteAccount
+	// ··········· Delinquent vote account for the delegated stake account
+	//
+	// [2] = [] ReferenceVoteAccount
+	// ··········· Reference vote account that has voted at least once in the last MINIMUM_DELINQUENT_EPOCHS_FOR_DEACTIVATION epochs
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewDeactivateDelinquentInstructionBuilder creates a new `DeactivateDelinquent` instruction builder.
+func NewDeactivateDelinquentInstructionBuilder() *DeactivateDelinquent {
+	nd := &DeactivateDelinquent{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 3),
+	}
+	return nd
+}
+
+// Delegated stake account
+func (inst *DeactivateDelinquent) SetStakeAccount(stakeAccount ag_solanago.PublicKey) *DeactivateDelinquent {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(stakeAccount).WRITE()
+	return inst
+}
+
+func (inst *DeactivateDelinquent) GetStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Delinquent vote account for the delegated stake account
+func (inst *DeactivateDelinquent) SetDelinquentVoteAccount(delinquentVoteAccount ag_solanago.PublicKey) *DeactivateDelinquent {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(delinquentVoteAccount)
+	return inst
+}
+
+func (inst *DeactivateDelinquent) GetDelinquentVoteAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+// Reference vote account that has voted at least once in the last MINIMUM_DELINQUENT_EPOCHS_FOR_DEACTIVATION epochs
+func (inst *DeactivateDelinquent) SetReferenceVoteAccount(referenceVoteAccount ag_solanago.PublicKey) *DeactivateDelinquent {
+	inst.AccountMetaSlice[2] = ag_solanago.Meta(referenceVoteAccount)
+	return inst
+}
+
+func (inst *DeactivateDelinquent) GetReferenceVoteAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(2)
+}
+
+func (inst DeactivateDelinquent) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_DeactivateDelinquent, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst DeactivateDelinquent) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *DeactivateDelinquent) Validate() error {
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("StakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("DelinquentVoteAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(2) == nil {
+			return fmt.Errorf("ReferenceVoteAccount is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *DeactivateDelinquent) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("DeactivateDelinquent")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("         Stake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("DelinquentVote", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(ag_format.Meta(" ReferenceVote", inst.AccountMetaSlice.Get(2)))
+					})
+				})
+		})
+}
+
+func (inst DeactivateDelinquent) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	return nil
+}
+
+func (inst *DeactivateDelinquent) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	return nil
+}
+
+// NewDeactivateDelinquentInstruction declares a new DeactivateDelinquent instruction with the provided parameters and accounts.
+func NewDeactivateDelinquentInstruction(
+	// Accounts:
+	stakeAccount ag_solanago.PublicKey,
+	delinquentVoteAccount ag_solanago.PublicKey,
+	referenceVoteAccount ag_solanago.PublicKey,
+) *DeactivateDelinquent {
+	return NewDeactivateDelinquentInstructionBuilder().
+		SetStakeAccount(stakeAccount).
+		SetDelinquentVoteAccount(delinquentVoteAccount).
+		SetReferenceVoteAccount(referenceVoteAccount)
+}
@@ -0,0 +1,209 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Delegate a stake to a particular vote account
+type DelegateStake struct {
+	// [0] = [WRITE] StakeAccount
+	// ··········· Initialized stake account to be delegated
+	//
+	// [1] = [] VoteAccount
+	// ··········· Vote account to which this stake will be delegated
+	//
+	// [2] = [] ClockSysvar
+	// ··········· Clock sysvar
+	//
+	// [3] = [] StakeHistorySysvar
+	// ··········· Stake history sysvar that carries stake warmup/cooldown history
+	//
+	// [4] = [] StakeConfigAccount
+	// ··········· Address of config account that carries stake config
+	//
+	// [5] = [SIGNER] StakeAuthority
+	// ··········· Stake authority
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewDelegateStakeInstructionBuilder creates a new `DelegateStake` instruction builder.
+func NewDelegateStakeInstructionBuilder() *DelegateStake {
+	nd := &DelegateStake{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 6),
+	}
+	return nd
+}
+
+// Initialized stake account to be delegated
+func (inst *DelegateStake) SetStakeAccount(stakeAccount ag_solanago.PublicKey) *DelegateStake {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(stakeAccount).WRITE()
+	return inst
+}
+
+func (inst *DelegateStake) GetStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Vote account to which this stake will be delegated
+func (inst *DelegateStake) SetVoteAccount(voteAccount ag_solanago.PublicKey) *DelegateStake {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(voteAccount)
+	return inst
+}
+
+func (inst *DelegateStake) GetVoteAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+// Clock sysvar
+func (inst *DelegateStake) SetClockSysvar(clockSysvar ag_solanago.PublicKey) *DelegateStake {
+	inst.AccountMetaSlice[2] = ag_solanago.Meta(clockSysvar)
+	return inst
+}
+
+func (inst *DelegateStake) GetClockSysvar() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(2)
+}
+
+// Stake history sysvar that carries stake warmup/cooldown history
+func (inst *DelegateStake) SetStakeHistorySysvar(stakeHistorySysvar ag_solanago.PublicKey) *DelegateStake {
+	inst.AccountMetaSlice[3] = ag_solanago.Meta(stakeHistorySysvar)
+	return inst
+}
+
+func (inst *DelegateStake) GetStakeHistorySysvar() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(3)
+}
+
+// Address of config account that carries stake config
+func (inst *DelegateStake) SetStakeConfigAccount(stakeConfigAccount ag_solanago.PublicKey) *DelegateStake {
+	inst.AccountMetaSlice[4] = ag_solanago.Meta(stakeConfigAccount)
+	return inst
+}
+
+func (inst *DelegateStake) GetStakeConfigAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(4)
+}
+
+// Stake authority
+func (inst *DelegateStake) SetStakeAuthority(stakeAuthority ag_solanago.PublicKey) *DelegateStake {
+	inst.AccountMetaSlice[5] = ag_solanago.Meta(stakeAuthority).SIGNER()
+	return inst
+}
+
+func (inst *DelegateStake) GetStakeAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(5)
+}
+
+func (inst DelegateStake) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_DelegateStake, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst DelegateStake) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *DelegateStake) Validate() error {
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("StakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("VoteAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(2) == nil {
+			return fmt.Errorf("ClockSysvar is not set")
+		}
+		if inst.AccountMetaSlice.Get(3) == nil {
+			return fmt.Errorf("StakeHistorySysvar is not set")
+		}
+		if inst.AccountMetaSlice.Get(4) == nil {
+			return fmt.Errorf("StakeConfigAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(5) == nil {
+			return fmt.Errorf("StakeAuthority is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *DelegateStake) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("DelegateStake")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("         Stake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("          Vote", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(ag_format.Meta("         Clock", inst.AccountMetaSlice.Get(2)))
+						accountsBranch.Child(ag_format.Meta("  StakeHistory", inst.AccountMetaSlice.Get(3)))
+						accountsBranch.Child(ag_format.Meta("   StakeConfig", inst.AccountMetaSlice.Get(4)))
+						accountsBranch.Child(ag_format.Meta("StakeAuthority", inst.AccountMetaSlice.Get(5)))
+					})
+				})
+		})
+}
+
+func (inst DelegateStake) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	return nil
+}
+
+func (inst *DelegateStake) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	return nil
+}
+
+// NewDelegateStakeInstruction declares a new DelegateStake instruction with the provided parameters and accounts.
+func NewDelegateStakeInstruction(
+	// Accounts:
+	stakeAccount ag_solanago.PublicKey,
+	voteAccount ag_solanago.PublicKey,
+	clockSysvar ag_solanago.PublicKey,
+	stakeHistorySysvar ag_solanago.PublicKey,
+	stakeConfigAccount ag_solanago.PublicKey,
+	stakeAuthority ag_solanago.PublicKey,
+) *DelegateStake {
+	return NewDelegateStakeInstructionBuilder().
+		SetStakeAccount(stakeAccount).
+		SetVoteAccount(voteAccount).
+		SetClockSysvar(clockSysvar).
+		SetStakeHistorySysvar(stakeHistorySysvar).
+		SetStakeConfigAccount(stakeConfigAccount).
+		SetStakeAuthority(stakeAuthority)
+}
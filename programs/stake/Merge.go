@@ -0,0 +1,190 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Merge two stake accounts
+type Merge struct {
+	// [0] = [WRITE] DestinationStakeAccount
+	// ··········· Destination stake account for the merge
+	//
+	// [1] = [WRITE] SourceStakeAccount
+	// ··········· Source stake account to merge into the destination, which is drained
+	//
+	// [2] = [] ClockSysvar
+	// ··········· Clock sysvar
+	//
+	// [3] = [] StakeHistorySysvar
+	// ··········· Stake history sysvar that carries stake warmup/cooldown history
+	//
+	// [4] = [SIGNER] StakeAuthority
+	// ··········· Stake authority
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewMergeInstructionBuilder creates a new `Merge` instruction builder.
+func NewMergeInstructionBuilder() *Merge {
+	nd := &Merge{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 5),
+	}
+	return nd
+}
+
+// Destination stake account for the merge
+func (inst *Merge) SetDestinationStakeAccount(destinationStakeAccount ag_solanago.PublicKey) *Merge {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(destinationStakeAccount).WRITE()
+	return inst
+}
+
+func (inst *Merge) GetDestinationStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Source stake account to merge into the destination, which is drained
+func (inst *Merge) SetSourceStakeAccount(sourceStakeAccount ag_solanago.PublicKey) *Merge {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(sourceStakeAccount).WRITE()
+	return inst
+}
+
+func (inst *Merge) GetSourceStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+// Clock sysvar
+func (inst *Merge) SetClockSysvar(clockSysvar ag_solanago.PublicKey) *Merge {
+	inst.AccountMetaSlice[2] = ag_solanago.Meta(clockSysvar)
+	return inst
+}
+
+func (inst *Merge) GetClockSysvar() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(2)
+}
+
+// Stake history sysvar that carries stake warmup/cooldown history
+func (inst *Merge) SetStakeHistorySysvar(stakeHistorySysvar ag_solanago.PublicKey) *Merge {
+	inst.AccountMetaSlice[3] = ag_solanago.Meta(stakeHistorySysvar)
+	return inst
+}
+
+func (inst *Merge) GetStakeHistorySysvar() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(3)
+}
+
+// Stake authority
+func (inst *Merge) SetStakeAuthority(stakeAuthority ag_solanago.PublicKey) *Merge {
+	inst.AccountMetaSlice[4] = ag_solanago.Meta(stakeAuthority).SIGNER()
+	return inst
+}
+
+func (inst *Merge) GetStakeAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(4)
+}
+
+func (inst Merge) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_Merge, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst Merge) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *Merge) Validate() error {
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("DestinationStakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("SourceStakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(2) == nil {
+			return fmt.Errorf("ClockSysvar is not set")
+		}
+		if inst.AccountMetaSlice.Get(3) == nil {
+			return fmt.Errorf("StakeHistorySysvar is not set")
+		}
+		if inst.AccountMetaSlice.Get(4) == nil {
+			return fmt.Errorf("StakeAuthority is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *Merge) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("Merge")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("DestinationStake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("     SourceStake", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(ag_format.Meta("           Clock", inst.AccountMetaSlice.Get(2)))
+						accountsBranch.Child(ag_format.Meta("    StakeHistory", inst.AccountMetaSlice.Get(3)))
+						accountsBranch.Child(ag_format.Meta("  StakeAuthority", inst.AccountMetaSlice.Get(4)))
+					})
+				})
+		})
+}
+
+func (inst Merge) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	return nil
+}
+
+func (inst *Merge) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	return nil
+}
+
+// NewMergeInstruction declares a new Merge instruction with the provided parameters and accounts.
+func NewMergeInstruction(
+	// Accounts:
+	destinationStakeAccount ag_solanago.PublicKey,
+	sourceStakeAccount ag_solanago.PublicKey,
+	clockSysvar ag_solanago.PublicKey,
+	stakeHistorySysvar ag_solanago.PublicKey,
+	stakeAuthority ag_solanago.PublicKey,
+) *Merge {
+	return NewMergeInstructionBuilder().
+		SetDestinationStakeAccount(destinationStakeAccount).
+		SetSourceStakeAccount(sourceStakeAccount).
+		SetClockSysvar(clockSysvar).
+		SetStakeHistorySysvar(stakeHistorySysvar).
+		SetStakeAuthority(stakeAuthority)
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Move unstaked lamports between accounts with the same authorities and lockups
+type MoveLamports struct {
+	// Amount of lamports to move
+	Lamports *uint64
+
+	// [0] = [WRITE] SourceStakeAccount
+	// ··········· Active or inactive source stake account
+	//
+	// [1] = [WRITE] DestinationStakeAccount
+	// ··········· Mergeable destination stake account
+	//
+	// [2] = [SIGNER] StakeAuthority
+	// ··········· Stake authority
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewMoveLamportsInstructionBuilder creates a new `MoveLamports` instruction builder.
+func NewMoveLamportsInstructionBuilder() *MoveLamports {
+	nd := &MoveLamports{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 3),
+	}
+	return nd
+}
+
+// Amount of lamports to move
+func (inst *MoveLamports) SetLamports(lamports uint64) *MoveLamports {
+	inst.Lamports = &lamports
+	return inst
+}
+
+// Active or inactive source stake account
+func (inst *MoveLamports) SetSourceStakeAccount(sourceStakeAccount ag_solanago.PublicKey) *MoveLamports {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(sourceStakeAccount).WRITE()
+	return inst
+}
+
+func (inst *MoveLamports) GetSourceStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Mergeable destination stake account
+func (inst *MoveLamports) SetDestinationStakeAccount(destinationStakeAccount ag_solanago.PublicKey) *MoveLamports {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(destinationStakeAccount).WRITE()
+	return inst
+}
+
+func (inst *MoveLamports) GetDestinationStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+// Stake authority
+func (inst *MoveLamports) SetStakeAuthority(stakeAuthority ag_solanago.PublicKey) *MoveLamports {
+	inst.AccountMetaSlice[2] = ag_solanago.Meta(stakeAuthority).SIGNER()
+	return inst
+}
+
+func (inst *MoveLamports) GetStakeAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(2)
+}
+
+func (inst MoveLamports) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_MoveLamports, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst MoveLamports) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *MoveLamports) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.Lamports == nil {
+			return errors.New("Lamports parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("SourceStakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("DestinationStakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(2) == nil {
+			return fmt.Errorf("StakeAuthority is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *MoveLamports) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("MoveLamports")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("Lamports", *inst.Lamports))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("     SourceStake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("DestinationStake", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(ag_format.Meta("  StakeAuthority", inst.AccountMetaSlice.Get(2)))
+					})
+				})
+		})
+}
+
+func (inst MoveLamports) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	// Serialize `Lamports` param:
+	{
+		err := encoder.Encode(*inst.Lamports)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (inst *MoveLamports) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	// Deserialize `Lamports` param:
+	{
+		err := decoder.Decode(&inst.Lamports)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewMoveLamportsInstruction declares a new MoveLamports instruction with the provided parameters and accounts.
+func NewMoveLamportsInstruction(
+	// Parameters:
+	lamports uint64,
+	// Accounts:
+	sourceStakeAccount ag_solanago.PublicKey,
+	destinationStakeAccount ag_solanago.PublicKey,
+	stakeAuthority ag_solanago.PublicKey,
+) *MoveLamports {
+	return NewMoveLamportsInstructionBuilder().
+		SetLamports(lamports).
+		SetSourceStakeAccount(sourceStakeAccount).
+		SetDestinationStakeAccount(destinationStakeAccount).
+		SetStakeAuthority(stakeAuthority)
+}
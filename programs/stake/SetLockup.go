@@ -0,0 +1,256 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Set stake lockup
+type SetLockup struct {
+	// Lockup expiration, as a Unix timestamp (optional)
+	UnixTimestamp *int64
+
+	// Lockup expiration epoch (optional)
+	Epoch *uint64
+
+	// Lockup custodian (optional)
+	Custodian *ag_solanago.PublicKey
+
+	// [0] = [WRITE] StakeAccount
+	// ··········· Initialized stake account
+	//
+	// [1] = [SIGNER] Authority
+	// ··········· Lockup authority or withdraw authority
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewSetLockupInstructionBuilder creates a new `SetLockup` instruction builder.
+func NewSetLockupInstructionBuilder() *SetLockup {
+	nd := &SetLockup{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 2),
+	}
+	return nd
+}
+
+// Lockup expiration, as a Unix timestamp
+func (inst *SetLockup) SetUnixTimestamp(unixTimestamp int64) *SetLockup {
+	inst.UnixTimestamp = &unixTimestamp
+	return inst
+}
+
+// Lockup expiration epoch
+func (inst *SetLockup) SetEpoch(epoch uint64) *SetLockup {
+	inst.Epoch = &epoch
+	return inst
+}
+
+// Lockup custodian
+func (inst *SetLockup) SetCustodian(custodian ag_solanago.PublicKey) *SetLockup {
+	inst.Custodian = &custodian
+	return inst
+}
+
+// Initialized stake account
+func (inst *SetLockup) SetStakeAccount(stakeAccount ag_solanago.PublicKey) *SetLockup {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(stakeAccount).WRITE()
+	return inst
+}
+
+func (inst *SetLockup) GetStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Lockup authority or withdraw authority
+func (inst *SetLockup) SetAuthority(authority ag_solanago.PublicKey) *SetLockup {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(authority).SIGNER()
+	return inst
+}
+
+func (inst *SetLockup) GetAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+func (inst SetLockup) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_SetLockup, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst SetLockup) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *SetLockup) Validate() error {
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("StakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("Authority is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *SetLockup) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("SetLockup")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("UnixTimestamp (OPT)", inst.UnixTimestamp))
+						paramsBranch.Child(ag_format.Param("        Epoch (OPT)", inst.Epoch))
+						paramsBranch.Child(ag_format.Param("    Custodian (OPT)", inst.Custodian))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("    Stake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("Authority", inst.AccountMetaSlice.Get(1)))
+					})
+				})
+		})
+}
+
+func (inst SetLockup) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	// Serialize `UnixTimestamp` param:
+	{
+		if inst.UnixTimestamp == nil {
+			err := encoder.WriteBool(false)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := encoder.WriteBool(true)
+			if err != nil {
+				return err
+			}
+			err = encoder.Encode(*inst.UnixTimestamp)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// Serialize `Epoch` param:
+	{
+		if inst.Epoch == nil {
+			err := encoder.WriteBool(false)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := encoder.WriteBool(true)
+			if err != nil {
+				return err
+			}
+			err = encoder.Encode(*inst.Epoch)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// Serialize `Custodian` param:
+	{
+		if inst.Custodian == nil {
+			err := encoder.WriteBool(false)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := encoder.WriteBool(true)
+			if err != nil {
+				return err
+			}
+			err = encoder.Encode(*inst.Custodian)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (inst *SetLockup) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	// Deserialize `UnixTimestamp` param:
+	{
+		ok, err := decoder.ReadBool()
+		if err != nil {
+			return err
+		}
+		if ok {
+			err = decoder.Decode(&inst.UnixTimestamp)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// Deserialize `Epoch` param:
+	{
+		ok, err := decoder.ReadBool()
+		if err != nil {
+			return err
+		}
+		if ok {
+			err = decoder.Decode(&inst.Epoch)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// Deserialize `Custodian` param:
+	{
+		ok, err := decoder.ReadBool()
+		if err != nil {
+			return err
+		}
+		if ok {
+			err = decoder.Decode(&inst.Custodian)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NewSetLockupInstruction declares a new SetLockup instruction with the provided parameters and accounts.
+func NewSetLockupInstruction(
+	// Accounts:
+	stakeAccount ag_solanago.PublicKey,
+	authority ag_solanago.PublicKey,
+) *SetLockup {
+	return NewSetLockupInstructionBuilder().
+		SetStakeAccount(stakeAccount).
+		SetAuthority(authority)
+}
@@ -0,0 +1,229 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Set stake lockup, requiring the new lockup authority to sign
+type SetLockupChecked struct {
+	// Lockup expiration, as a Unix timestamp (optional)
+	UnixTimestamp *int64
+
+	// Lockup expiration epoch (optional)
+	Epoch *uint64
+
+	// [0] = [WRITE] StakeAccount
+	// ··········· Initialized stake account
+	//
+	// [1] = [SIGNER] Authority
+	// ··········· Lockup authority or withdraw authority
+	//
+	// [2] = [SIGNER] NewLockupAuthority
+	// ··········· New lockup authority (optional)
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewSetLockupCheckedInstructionBuilder creates a new `SetLockupChecked` instruction builder.
+func NewSetLockupCheckedInstructionBuilder() *SetLockupChecked {
+	nd := &SetLockupChecked{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 3),
+	}
+	return nd
+}
+
+// Lockup expiration, as a Unix timestamp
+func (inst *SetLockupChecked) SetUnixTimestamp(unixTimestamp int64) *SetLockupChecked {
+	inst.UnixTimestamp = &unixTimestamp
+	return inst
+}
+
+// Lockup expiration epoch
+func (inst *SetLockupChecked) SetEpoch(epoch uint64) *SetLockupChecked {
+	inst.Epoch = &epoch
+	return inst
+}
+
+// Initialized stake account
+func (inst *SetLockupChecked) SetStakeAccount(stakeAccount ag_solanago.PublicKey) *SetLockupChecked {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(stakeAccount).WRITE()
+	return inst
+}
+
+func (inst *SetLockupChecked) GetStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Lockup authority or withdraw authority
+func (inst *SetLockupChecked) SetAuthority(authority ag_solanago.PublicKey) *SetLockupChecked {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(authority).SIGNER()
+	return inst
+}
+
+func (inst *SetLockupChecked) GetAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+// New lockup authority
+func (inst *SetLockupChecked) SetNewLockupAuthority(newLockupAuthority ag_solanago.PublicKey) *SetLockupChecked {
+	inst.AccountMetaSlice[2] = ag_solanago.Meta(newLockupAuthority).SIGNER()
+	return inst
+}
+
+func (inst *SetLockupChecked) GetNewLockupAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(2)
+}
+
+func (inst SetLockupChecked) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_SetLockupChecked, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst SetLockupChecked) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *SetLockupChecked) Validate() error {
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("StakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("Authority is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *SetLockupChecked) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("SetLockupChecked")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("UnixTimestamp (OPT)", inst.UnixTimestamp))
+						paramsBranch.Child(ag_format.Param("        Epoch (OPT)", inst.Epoch))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("             Stake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("         Authority", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(ag_format.Meta("NewLockupAuthority", inst.AccountMetaSlice.Get(2)))
+					})
+				})
+		})
+}
+
+func (inst SetLockupChecked) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	// Serialize `UnixTimestamp` param:
+	{
+		if inst.UnixTimestamp == nil {
+			err := encoder.WriteBool(false)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := encoder.WriteBool(true)
+			if err != nil {
+				return err
+			}
+			err = encoder.Encode(*inst.UnixTimestamp)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// Serialize `Epoch` param:
+	{
+		if inst.Epoch == nil {
+			err := encoder.WriteBool(false)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := encoder.WriteBool(true)
+			if err != nil {
+				return err
+			}
+			err = encoder.Encode(*inst.Epoch)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (inst *SetLockupChecked) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	// Deserialize `UnixTimestamp` param:
+	{
+		ok, err := decoder.ReadBool()
+		if err != nil {
+			return err
+		}
+		if ok {
+			err = decoder.Decode(&inst.UnixTimestamp)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	// Deserialize `Epoch` param:
+	{
+		ok, err := decoder.ReadBool()
+		if err != nil {
+			return err
+		}
+		if ok {
+			err = decoder.Decode(&inst.Epoch)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NewSetLockupCheckedInstruction declares a new SetLockupChecked instruction with the provided parameters and accounts.
+func NewSetLockupCheckedInstruction(
+	// Accounts:
+	stakeAccount ag_solanago.PublicKey,
+	authority ag_solanago.PublicKey,
+) *SetLockupChecked {
+	return NewSetLockupCheckedInstructionBuilder().
+		SetStakeAccount(stakeAccount).
+		SetAuthority(authority)
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Split u64 tokens and stake off a stake account into another stake account
+type Split struct {
+	// Number of lamports to split
+	Lamports *uint64
+
+	// [0] = [WRITE] StakeAccount
+	// ··········· Stake account to be split; must be in the Initialized or Stake state
+	//
+	// [1] = [WRITE] SplitStakeAccount
+	// ··········· Uninitialized stake account that will take the split-off amount
+	//
+	// [2] = [SIGNER] StakeAuthority
+	// ··········· Stake authority
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewSplitInstructionBuilder creates a new `Split` instruction builder.
+func NewSplitInstructionBuilder() *Split {
+	nd := &Split{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 3),
+	}
+	return nd
+}
+
+// Number of lamports to split
+func (inst *Split) SetLamports(lamports uint64) *Split {
+	inst.Lamports = &lamports
+	return inst
+}
+
+// Stake account to be split; must be in the Initialized or Stake state
+func (inst *Split) SetStakeAccount(stakeAccount ag_solanago.PublicKey) *Split {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(stakeAccount).WRITE()
+	return inst
+}
+
+func (inst *Split) GetStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Uninitialized stake account that will take the split-off amount
+func (inst *Split) SetSplitStakeAccount(splitStakeAccount ag_solanago.PublicKey) *Split {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(splitStakeAccount).WRITE()
+	return inst
+}
+
+func (inst *Split) GetSplitStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+// Stake authority
+func (inst *Split) SetStakeAuthority(stakeAuthority ag_solanago.PublicKey) *Split {
+	inst.AccountMetaSlice[2] = ag_solanago.Meta(stakeAuthority).SIGNER()
+	return inst
+}
+
+func (inst *Split) GetStakeAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(2)
+}
+
+func (inst Split) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_Split, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst Split) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *Split) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.Lamports == nil {
+			return errors.New("Lamports parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("StakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("SplitStakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(2) == nil {
+			return fmt.Errorf("StakeAuthority is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *Split) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("Split")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("Lamports", *inst.Lamports))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("         Stake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("    SplitStake", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(ag_format.Meta("StakeAuthority", inst.AccountMetaSlice.Get(2)))
+					})
+				})
+		})
+}
+
+func (inst Split) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	// Serialize `Lamports` param:
+	{
+		err := encoder.Encode(*inst.Lamports)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (inst *Split) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	// Deserialize `Lamports` param:
+	{
+		err := decoder.Decode(&inst.Lamports)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewSplitInstruction declares a new Split instruction with the provided parameters and accounts.
+func NewSplitInstruction(
+	// Parameters:
+	lamports uint64,
+	// Accounts:
+	stakeAccount ag_solanago.PublicKey,
+	splitStakeAccount ag_solanago.PublicKey,
+	stakeAuthority ag_solanago.PublicKey,
+) *Split {
+	return NewSplitInstructionBuilder().
+		SetLamports(lamports).
+		SetStakeAccount(stakeAccount).
+		SetSplitStakeAccount(splitStakeAccount).
+		SetStakeAuthority(stakeAuthority)
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Withdraw unstaked lamports from the stake account
+type Withdraw struct {
+	// Number of lamports to withdraw
+	Lamports *uint64
+
+	// [0] = [WRITE] StakeAccount
+	// ··········· Stake account from which to withdraw
+	//
+	// [1] = [WRITE] RecipientAccount
+	// ··········· Recipient account
+	//
+	// [2] = [] ClockSysvar
+	// ··········· Clock sysvar
+	//
+	// [3] = [] StakeHistorySysvar
+	// ··········· Stake history sysvar that carries stake warmup/cooldown history
+	//
+	// [4] = [SIGNER] WithdrawAuthority
+	// ··········· Withdraw authority
+	//
+	// [5] = [SIGNER] LockupAuthority
+	// ··········· Lockup authority, if before lockup expiration (optional)
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewWithdrawInstructionBuilder creates a new `Withdraw` instruction builder.
+func NewWithdrawInstructionBuilder() *Withdraw {
+	nd := &Withdraw{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 6),
+	}
+	return nd
+}
+
+// Number of lamports to withdraw
+func (inst *Withdraw) SetLamports(lamports uint64) *Withdraw {
+	inst.Lamports = &lamports
+	return inst
+}
+
+// Stake account from which to withdraw
+func (inst *Withdraw) SetStakeAccount(stakeAccount ag_solanago.PublicKey) *Withdraw {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(stakeAccount).WRITE()
+	return inst
+}
+
+func (inst *Withdraw) GetStakeAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(0)
+}
+
+// Recipient account
+func (inst *Withdraw) SetRecipientAccount(recipientAccount ag_solanago.PublicKey) *Withdraw {
+	inst.AccountMetaSlice[1] = ag_solanago.Meta(recipientAccount).WRITE()
+	return inst
+}
+
+func (inst *Withdraw) GetRecipientAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(1)
+}
+
+// Clock sysvar
+func (inst *Withdraw) SetClockSysvar(clockSysvar ag_solanago.PublicKey) *Withdraw {
+	inst.AccountMetaSlice[2] = ag_solanago.Meta(clockSysvar)
+	return inst
+}
+
+func (inst *Withdraw) GetClockSysvar() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(2)
+}
+
+// Stake history sysvar that carries stake warmup/cooldown history
+func (inst *Withdraw) SetStakeHistorySysvar(stakeHistorySysvar ag_solanago.PublicKey) *Withdraw {
+	inst.AccountMetaSlice[3] = ag_solanago.Meta(stakeHistorySysvar)
+	return inst
+}
+
+func (inst *Withdraw) GetStakeHistorySysvar() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(3)
+}
+
+// Withdraw authority
+func (inst *Withdraw) SetWithdrawAuthority(withdrawAuthority ag_solanago.PublicKey) *Withdraw {
+	inst.AccountMetaSlice[4] = ag_solanago.Meta(withdrawAuthority).SIGNER()
+	return inst
+}
+
+func (inst *Withdraw) GetWithdrawAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(4)
+}
+
+// Lockup authority, if before lockup expiration
+func (inst *Withdraw) SetLockupAuthority(lockupAuthority ag_solanago.PublicKey) *Withdraw {
+	inst.AccountMetaSlice[5] = ag_solanago.Meta(lockupAuthority).SIGNER()
+	return inst
+}
+
+func (inst *Withdraw) GetLockupAuthority() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice.Get(5)
+}
+
+func (inst Withdraw) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: ag_binary.TypeIDFromUint32(Instruction_Withdraw, binary.LittleEndian),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst Withdraw) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *Withdraw) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.Lamports == nil {
+			return errors.New("Lamports parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice.Get(0) == nil {
+			return fmt.Errorf("StakeAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(1) == nil {
+			return fmt.Errorf("RecipientAccount is not set")
+		}
+		if inst.AccountMetaSlice.Get(2) == nil {
+			return fmt.Errorf("ClockSysvar is not set")
+		}
+		if inst.AccountMetaSlice.Get(3) == nil {
+			return fmt.Errorf("StakeHistorySysvar is not set")
+		}
+		if inst.AccountMetaSlice.Get(4) == nil {
+			return fmt.Errorf("WithdrawAuthority is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *Withdraw) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("Withdraw")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("Lamports", *inst.Lamports))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("            Stake", inst.AccountMetaSlice.Get(0)))
+						accountsBranch.Child(ag_format.Meta("        Recipient", inst.AccountMetaSlice.Get(1)))
+						accountsBranch.Child(ag_format.Meta("            Clock", inst.AccountMetaSlice.Get(2)))
+						accountsBranch.Child(ag_format.Meta("     StakeHistory", inst.AccountMetaSlice.Get(3)))
+						accountsBranch.Child(ag_format.Meta("WithdrawAuthority", inst.AccountMetaSlice.Get(4)))
+						accountsBranch.Child(ag_format.Meta("  LockupAuthority", inst.AccountMetaSlice.Get(5)))
+					})
+				})
+		})
+}
+
+func (inst Withdraw) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	// Serialize `Lamports` param:
+	{
+		err := encoder.Encode(*inst.Lamports)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (inst *Withdraw) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	// Deserialize `Lamports` param:
+	{
+		err := decoder.Decode(&inst.Lamports)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewWithdrawInstruction declares a new Withdraw instruction with the provided parameters and accounts.
+func NewWithdrawInstruction(
+	// Parameters:
+	lamports uint64,
+	// Accounts:
+	stakeAccount ag_solanago.PublicKey,
+	recipientAccount ag_solanago.PublicKey,
+	clockSysvar ag_solanago.PublicKey,
+	stakeHistorySysvar ag_solanago.PublicKey,
+	withdrawAuthority ag_solanago.PublicKey,
+) *Withdraw {
+	return NewWithdrawInstructionBuilder().
+		SetLamports(lamports).
+		SetStakeAccount(stakeAccount).
+		SetRecipientAccount(recipientAccount).
+		SetClockSysvar(clockSysvar).
+		SetStakeHistorySysvar(stakeHistorySysvar).
+		SetWithdrawAuthority(withdrawAuthority)
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+)
+
+// StakeAccountSize is the size of the data of a stake account.
+const StakeAccountSize = 200
+
+// StakeStateType is the discriminant of the state of a stake account.
+type StakeStateType uint32
+
+const (
+	StakeStateUninitialized StakeStateType = iota
+	StakeStateInitialized
+	StakeStateStake
+	StakeStateRewardsPool
+)
+
+func (t StakeStateType) String() string {
+	switch t {
+	case StakeStateUninitialized:
+		return "Uninitialized"
+	case StakeStateInitialized:
+		return "Initialized"
+	case StakeStateStake:
+		return "Stake"
+	case StakeStateRewardsPool:
+		return "RewardsPool"
+	default:
+		return ""
+	}
+}
+
+// Meta holds the parameters of an initialized stake account.
+type Meta struct {
+	// Lamports kept in the account to stay rent exempt.
+	RentExemptReserve uint64
+	Authorized        Authorized
+	Lockup            Lockup
+}
+
+// Delegation describes the stake delegated to a vote account.
+type Delegation struct {
+	// Vote account the stake is delegated to.
+	VoterPubkey ag_solanago.PublicKey
+	// Activated stake amount, set at delegate() time.
+	Stake uint64
+	// Epoch at which this stake was activated, math.MaxUint64 if it is a bootstrap stake.
+	ActivationEpoch uint64
+	// Epoch at which this stake was deactivated, math.MaxUint64 if it was never deactivated.
+	DeactivationEpoch uint64
+	// Deprecated: not used by the stake program anymore.
+	WarmupCooldownRate float64
+}
+
+// Stake is the delegation of a stake account.
+type Stake struct {
+	Delegation Delegation
+	// Credits observed is credits from vote account state when delegated or redeemed.
+	CreditsObserved uint64
+}
+
+// StakeState is the decoded data of a stake account.
+type StakeState struct {
+	Type StakeStateType
+
+	// Set if the account is initialized or delegated.
+	Meta *Meta
+	// Set if the account is delegated.
+	Stake *Stake
+	// Flags of a delegated account, e.g. whether it must be fully activated before deactivation.
+	StakeFlags uint8
+}
+
+// IsDelegated returns whether the stake account is delegated.
+func (state *StakeState) IsDelegated() bool {
+	return state.Type == StakeStateStake && state.Stake != nil
+}
+
+// Decode decodes the data of a stake account.
+func (state *StakeState) Decode(data []byte) error {
+	dec := ag_binary.NewBinDecoder(data)
+	if err := dec.Decode(state); err != nil {
+		return fmt.Errorf("unable to decode stake state: %w", err)
+	}
+	return nil
+}
+
+func (state *StakeState) UnmarshalWithDecoder(dec *ag_binary.Decoder) error {
+	v, err := dec.ReadUint32(binary.LittleEndian)
+	if err != nil {
+		return err
+	}
+	*state = StakeState{Type: StakeStateType(v)}
+	switch state.Type {
+	case StakeStateUninitialized, StakeStateRewardsPool:
+		return nil
+	case StakeStateInitialized:
+		state.Meta = new(Meta)
+		return dec.Decode(state.Meta)
+	case StakeStateStake:
+		state.Meta = new(Meta)
+		if err := dec.Decode(state.Meta); err != nil {
+			return err
+		}
+		state.Stake = new(Stake)
+		if err := dec.Decode(state.Stake); err != nil {
+			return err
+		}
+		// Accounts created before the flags were introduced hold no byte here.
+		if dec.Remaining() > 0 {
+			state.StakeFlags, err = dec.ReadUint8()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown stake state type: %d", v)
+	}
+}
+
+func (state StakeState) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	err := encoder.WriteUint32(uint32(state.Type), binary.LittleEndian)
+	if err != nil {
+		return err
+	}
+	switch state.Type {
+	case StakeStateInitialized:
+		if state.Meta == nil {
+			return fmt.Errorf("Meta is not set")
+		}
+		return encoder.Encode(state.Meta)
+	case StakeStateStake:
+		if state.Meta == nil || state.Stake == nil {
+			return fmt.Errorf("Meta and Stake must be set")
+		}
+		if err := encoder.Encode(state.Meta); err != nil {
+			return err
+		}
+		if err := encoder.Encode(state.Stake); err != nil {
+			return err
+		}
+		return encoder.WriteUint8(state.StakeFlags)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"bytes"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_require "github.com/stretchr/testify/require"
+)
+
+func TestStakeState(t *testing.T) {
+	delegated := StakeState{
+		Type: StakeStateStake,
+		Meta: &Meta{
+			RentExemptReserve: 2282880,
+			Authorized: Authorized{
+				Staker:     ag_solanago.MustPublicKeyFromBase58("7HZaCWazgTuuFuajxaaxGYbGnyVKwxvsJKue1W4Nvyro"),
+				Withdrawer: ag_solanago.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi"),
+			},
+			Lockup: Lockup{
+				UnixTimestamp: 1700000000,
+				Epoch:         600,
+			},
+		},
+		Stake: &Stake{
+			Delegation: Delegation{
+				VoterPubkey:        ag_solanago.MustPublicKeyFromBase58("5rL3AaidKJa4ChSV3ys1SvpDg9L4amKiwYayGR5oL3dq"),
+				Stake:              1000000000,
+				ActivationEpoch:    500,
+				DeactivationEpoch:  ^uint64(0),
+				WarmupCooldownRate: 0.25,
+			},
+			CreditsObserved: 12345,
+		},
+		StakeFlags: 1,
+	}
+
+	for _, state := range []StakeState{
+		{Type: StakeStateUninitialized},
+		{Type: StakeStateInitialized, Meta: delegated.Meta},
+		delegated,
+		{Type: StakeStateRewardsPool},
+	} {
+		t.Run(state.Type.String(), func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			ag_require.NoError(t, encodeT(state, buf))
+			ag_require.LessOrEqual(t, buf.Len(), StakeAccountSize)
+
+			// Account data is zero padded to the account size.
+			data := make([]byte, StakeAccountSize)
+			copy(data, buf.Bytes())
+
+			got := new(StakeState)
+			ag_require.NoError(t, got.Decode(data))
+			ag_require.Equal(t, &state, got)
+			ag_require.Equal(t, state.Type == StakeStateStake, got.IsDelegated())
+		})
+	}
+
+	t.Run("layout", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		ag_require.NoError(t, encodeT(delegated, buf))
+		data := buf.Bytes()
+		ag_require.Len(t, data, 197)
+		// Voter pubkey follows the discriminant and the meta.
+		ag_require.Equal(t, delegated.Stake.Delegation.VoterPubkey[:], data[124:156])
+		ag_require.Equal(t, uint8(1), data[196])
+	})
+
+	t.Run("unknown type", func(t *testing.T) {
+		ag_require.Error(t, new(StakeState).Decode([]byte{4, 0, 0, 0}))
+	})
+}
+
+func TestStakeHistory_Decode(t *testing.T) {
+	buf := new(bytes.Buffer)
+	enc := ag_binary.NewBinEncoder(buf)
+	ag_require.NoError(t, enc.WriteUint64(2, ag_binary.LE))
+	for _, v := range []uint64{11, 350, 750, 0, 10, 100, 1000, 0} {
+		ag_require.NoError(t, enc.WriteUint64(v, ag_binary.LE))
+	}
+
+	var history StakeHistory
+	ag_require.NoError(t, history.Decode(buf.Bytes()))
+	ag_require.Equal(t,
+		StakeHistory{
+			{Epoch: 11, Entry: StakeHistoryEntry{Effective: 350, Activating: 750}},
+			{Epoch: 10, Entry: StakeHistoryEntry{Effective: 100, Activating: 1000}},
+		},
+		history,
+	)
+
+	entry, ok := history.Get(10)
+	ag_require.True(t, ok)
+	ag_require.Equal(t, uint64(100), entry.Effective)
+	_, ok = history.Get(12)
+	ag_require.False(t, ok)
+
+	ag_require.Error(t, new(StakeHistory).Decode(buf.Bytes()[:40]))
+}
@@ -0,0 +1,239 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"fmt"
+	"math"
+
+	ag_binary "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+const (
+	// DefaultWarmupCooldownRate is the fraction of the cluster effective stake
+	// that can be activated or deactivated in an epoch.
+	DefaultWarmupCooldownRate = 0.25
+	// NewWarmupCooldownRate is the rate in effect since the new rate activation epoch.
+	NewWarmupCooldownRate = 0.09
+)
+
+// WarmupCooldownRate returns the warmup and cooldown rate in effect at the provided epoch;
+// newRateActivationEpoch is nil if the reduced rate is not active on the cluster.
+func WarmupCooldownRate(epoch uint64, newRateActivationEpoch *uint64) float64 {
+	if newRateActivationEpoch == nil || epoch < *newRateActivationEpoch {
+		return DefaultWarmupCooldownRate
+	}
+	return NewWarmupCooldownRate
+}
+
+// StakeHistoryEntry is the cluster-wide stake of an epoch.
+type StakeHistoryEntry struct {
+	// Effective stake at this epoch.
+	Effective uint64
+	// Sum of portion of activations at this epoch.
+	Activating uint64
+	// Sum of portion of deactivations at this epoch.
+	Deactivating uint64
+}
+
+// StakeHistoryEpochEntry is an entry of the StakeHistory sysvar.
+type StakeHistoryEpochEntry struct {
+	Epoch uint64
+	Entry StakeHistoryEntry
+}
+
+// StakeHistory is the decoded data of the StakeHistory sysvar,
+// ordered from the most recent epoch.
+type StakeHistory []StakeHistoryEpochEntry
+
+// Decode decodes the data of the StakeHistory sysvar.
+func (history *StakeHistory) Decode(data []byte) error {
+	dec := ag_binary.NewBinDecoder(data)
+	length, err := dec.ReadUint64(ag_binary.LE)
+	if err != nil {
+		return fmt.Errorf("unable to decode stake history: %w", err)
+	}
+	// Each entry is 32 bytes long.
+	if length > uint64(dec.Remaining()/32) {
+		return fmt.Errorf("unable to decode stake history: %d entries do not fit in %d bytes", length, dec.Remaining())
+	}
+	out := make(StakeHistory, length)
+	for i := range out {
+		if err := dec.Decode(&out[i]); err != nil {
+			return fmt.Errorf("unable to decode stake history entry %d: %w", i, err)
+		}
+	}
+	*history = out
+	return nil
+}
+
+// Get returns the entry of the provided epoch.
+func (history StakeHistory) Get(epoch uint64) (*StakeHistoryEntry, bool) {
+	// Entries are sorted by descending epoch, with one entry per epoch.
+	if len(history) > 0 && history[0].Epoch >= epoch {
+		if i := history[0].Epoch - epoch; i < uint64(len(history)) && history[i].Epoch == epoch {
+			return &history[i].Entry, true
+		}
+	}
+	for i := range history {
+		if history[i].Epoch == epoch {
+			return &history[i].Entry, true
+		}
+	}
+	return nil, false
+}
+
+// StakeActivationStatus is the amount of stake of a delegation in each
+// activation phase at an epoch.
+type StakeActivationStatus struct {
+	Effective    uint64
+	Activating   uint64
+	Deactivating uint64
+}
+
+// State returns the activation state of the delegation.
+func (status StakeActivationStatus) State() rpc.ActivationStateType {
+	switch {
+	case status.Deactivating > 0:
+		return rpc.ActivationStateDeactivating
+	case status.Activating > 0:
+		return rpc.ActivationStateActivating
+	case status.Effective > 0:
+		return rpc.ActivationStateActive
+	default:
+		return rpc.ActivationStateInactive
+	}
+}
+
+// Inactive returns the lamports of the stake account that are not effective,
+// given its balance and rent exempt reserve, as computed by getStakeActivation.
+func (status StakeActivationStatus) Inactive(lamports uint64, rentExemptReserve uint64) uint64 {
+	active := status.Effective + rentExemptReserve
+	if active >= lamports {
+		return 0
+	}
+	return lamports - active
+}
+
+// ActivationStatus computes the effective, activating and deactivating stake of the
+// delegation at targetEpoch, following the warmup and cooldown of the stake program.
+// newRateActivationEpoch is the epoch since which the reduced warmup and cooldown
+// rate is in effect, nil if it is not active.
+func (d *Delegation) ActivationStatus(
+	targetEpoch uint64,
+	history StakeHistory,
+	newRateActivationEpoch *uint64,
+) StakeActivationStatus {
+	effective, activating := d.effectiveAndActivating(targetEpoch, history, newRateActivationEpoch)
+
+	switch {
+	case targetEpoch < d.DeactivationEpoch:
+		return StakeActivationStatus{Effective: effective, Activating: activating}
+	case targetEpoch == d.DeactivationEpoch:
+		// Only the effective stake can be deactivated.
+		return StakeActivationStatus{Effective: effective, Deactivating: effective}
+	}
+
+	prevClusterStake, ok := history.Get(d.DeactivationEpoch)
+	if !ok {
+		// No history or the epoch dropped out of it: fully deactivated.
+		return StakeActivationStatus{}
+	}
+	prevEpoch := d.DeactivationEpoch
+	current := effective
+	for {
+		epoch := prevEpoch + 1
+		if prevClusterStake.Deactivating == 0 {
+			break
+		}
+
+		// The portion of the cluster deactivating stake that belongs to this delegation.
+		weight := float64(current) / float64(prevClusterStake.Deactivating)
+		rate := WarmupCooldownRate(epoch, newRateActivationEpoch)
+		newlyNotEffectiveClusterStake := float64(prevClusterStake.Effective) * rate
+		newlyNotEffective := uint64(math.Max(1, weight*newlyNotEffectiveClusterStake))
+
+		if newlyNotEffective >= current {
+			current = 0
+			break
+		}
+		current -= newlyNotEffective
+		if epoch >= targetEpoch {
+			break
+		}
+		next, ok := history.Get(epoch)
+		if !ok {
+			break
+		}
+		prevEpoch, prevClusterStake = epoch, next
+	}
+	return StakeActivationStatus{Effective: current, Deactivating: current}
+}
+
+func (d *Delegation) effectiveAndActivating(
+	targetEpoch uint64,
+	history StakeHistory,
+	newRateActivationEpoch *uint64,
+) (effective uint64, activating uint64) {
+	switch {
+	case d.ActivationEpoch == math.MaxUint64:
+		// Bootstrap stake is fully effective since genesis.
+		return d.Stake, 0
+	case d.ActivationEpoch == d.DeactivationEpoch:
+		// Deactivated in the same epoch it was activated.
+		return 0, 0
+	case targetEpoch == d.ActivationEpoch:
+		return 0, d.Stake
+	case targetEpoch < d.ActivationEpoch:
+		return 0, 0
+	}
+
+	prevClusterStake, ok := history.Get(d.ActivationEpoch)
+	if !ok {
+		// No history or the epoch dropped out of it: fully effective.
+		return d.Stake, 0
+	}
+	prevEpoch := d.ActivationEpoch
+	current := uint64(0)
+	for {
+		epoch := prevEpoch + 1
+		if prevClusterStake.Activating == 0 {
+			break
+		}
+
+		// The portion of the cluster activating stake that belongs to this delegation.
+		remaining := d.Stake - current
+		weight := float64(remaining) / float64(prevClusterStake.Activating)
+		rate := WarmupCooldownRate(epoch, newRateActivationEpoch)
+		newlyEffectiveClusterStake := float64(prevClusterStake.Effective) * rate
+		newlyEffective := uint64(math.Max(1, weight*newlyEffectiveClusterStake))
+
+		current += newlyEffective
+		if current >= d.Stake {
+			current = d.Stake
+			break
+		}
+		if epoch >= targetEpoch || epoch >= d.DeactivationEpoch {
+			break
+		}
+		next, ok := history.Get(epoch)
+		if !ok {
+			break
+		}
+		prevEpoch, prevClusterStake = epoch, next
+	}
+	return current, d.Stake - current
+}
@@ -0,0 +1,74 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	ag_require "github.com/stretchr/testify/require"
+)
+
+func TestDelegation_ActivationStatus(t *testing.T) {
+	history := StakeHistory{
+		{Epoch: 20, Entry: StakeHistoryEntry{Effective: 1000, Deactivating: 400}},
+		{Epoch: 11, Entry: StakeHistoryEntry{Effective: 350, Activating: 750}},
+		{Epoch: 10, Entry: StakeHistoryEntry{Effective: 100, Activating: 1000}},
+	}
+	newRateEpoch := uint64(21)
+
+	activating := Delegation{Stake: 500, ActivationEpoch: 10, DeactivationEpoch: math.MaxUint64}
+	deactivating := Delegation{Stake: 100, ActivationEpoch: 0, DeactivationEpoch: 20}
+
+	tests := []struct {
+		name       string
+		delegation Delegation
+		epoch      uint64
+		newRate    *uint64
+		expected   StakeActivationStatus
+		state      rpc.ActivationStateType
+	}{
+		{"before activation", activating, 9, nil, StakeActivationStatus{}, rpc.ActivationStateInactive},
+		{"activation epoch", activating, 10, nil, StakeActivationStatus{Activating: 500}, rpc.ActivationStateActivating},
+		// 500/1000 of 25% of 100.
+		{"first warmup epoch", activating, 11, nil, StakeActivationStatus{Effective: 12, Activating: 488}, rpc.ActivationStateActivating},
+		// 488/750 of 25% of 350.
+		{"second warmup epoch", activating, 12, nil, StakeActivationStatus{Effective: 68, Activating: 432}, rpc.ActivationStateActivating},
+		// No history after epoch 11.
+		{"missing history", activating, 13, nil, StakeActivationStatus{Effective: 68, Activating: 432}, rpc.ActivationStateActivating},
+		{"active", deactivating, 19, nil, StakeActivationStatus{Effective: 100}, rpc.ActivationStateActive},
+		{"deactivation epoch", deactivating, 20, nil, StakeActivationStatus{Effective: 100, Deactivating: 100}, rpc.ActivationStateDeactivating},
+		// 100/400 of 25% of 1000.
+		{"first cooldown epoch", deactivating, 21, nil, StakeActivationStatus{Effective: 38, Deactivating: 38}, rpc.ActivationStateDeactivating},
+		// 100/400 of 9% of 1000.
+		{"reduced cooldown rate", deactivating, 21, &newRateEpoch, StakeActivationStatus{Effective: 78, Deactivating: 78}, rpc.ActivationStateDeactivating},
+		{"instantly deactivated", Delegation{Stake: 100, ActivationEpoch: 5, DeactivationEpoch: 5}, 6, nil, StakeActivationStatus{}, rpc.ActivationStateInactive},
+		{"bootstrap", Delegation{Stake: 100, ActivationEpoch: math.MaxUint64, DeactivationEpoch: math.MaxUint64}, 6, nil, StakeActivationStatus{Effective: 100}, rpc.ActivationStateActive},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.delegation.ActivationStatus(test.epoch, history, test.newRate)
+			ag_require.Equal(t, test.expected, got)
+			ag_require.Equal(t, test.state, got.State())
+		})
+	}
+
+	// Without history, the stake is fully effective after the activation epoch.
+	ag_require.Equal(t, StakeActivationStatus{Effective: 500}, activating.ActivationStatus(11, nil, nil))
+
+	ag_require.Equal(t, uint64(600), StakeActivationStatus{Effective: 400}.Inactive(1010, 10))
+	ag_require.Equal(t, uint64(0), StakeActivationStatus{Effective: 400}.Inactive(100, 10))
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	"context"
+	"fmt"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// GetStakeAccountDecoded fetches and decodes the stake account.
+func GetStakeAccountDecoded(
+	ctx context.Context,
+	rpcCli *rpc.Client,
+	stakeAccount ag_solanago.PublicKey,
+	commitment rpc.CommitmentType, // optional
+) (*StakeState, error) {
+	resp, err := rpcCli.GetAccountInfoWithOpts(
+		ctx,
+		stakeAccount,
+		&rpc.GetAccountInfoOpts{
+			Commitment: commitment,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if owner := resp.Value.Owner; !owner.Equals(ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by the stake program: owner is %s", stakeAccount, owner)
+	}
+
+	out := new(StakeState)
+	if err := out.Decode(resp.GetBinary()); err != nil {
+		return nil, fmt.Errorf("unable to decode stake account %s: %w", stakeAccount, err)
+	}
+	return out, nil
+}
+
+// GetStakeHistory fetches and decodes the StakeHistory sysvar.
+func GetStakeHistory(
+	ctx context.Context,
+	rpcCli *rpc.Client,
+	commitment rpc.CommitmentType, // optional
+) (StakeHistory, error) {
+	resp, err := rpcCli.GetAccountInfoWithOpts(
+		ctx,
+		ag_solanago.SysVarStakeHistoryPubkey,
+		&rpc.GetAccountInfoOpts{
+			Commitment: commitment,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var out StakeHistory
+	if err := out.Decode(resp.GetBinary()); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetStakeActivationDecoded computes the activation of a stake account at the provided epoch
+// from the account and the StakeHistory sysvar, which are fetched in a single request.
+// It replaces the getStakeActivation RPC method, which is not served by recent validators.
+//
+// newRateActivationEpoch is the epoch since which the reduced warmup and cooldown
+// rate is in effect on the cluster, nil if it is not active.
+func GetStakeActivationDecoded(
+	ctx context.Context,
+	rpcCli *rpc.Client,
+	stakeAccount ag_solanago.PublicKey,
+	epoch uint64,
+	newRateActivationEpoch *uint64,
+	commitment rpc.CommitmentType, // optional
+) (*rpc.GetStakeActivationResult, error) {
+	resp, err := rpcCli.GetMultipleAccountsWithOpts(
+		ctx,
+		[]ag_solanago.PublicKey{stakeAccount, ag_solanago.SysVarStakeHistoryPubkey},
+		&rpc.GetMultipleAccountsOpts{
+			Commitment: commitment,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Value) != 2 || resp.Value[0] == nil || resp.Value[1] == nil {
+		return nil, rpc.ErrNotFound
+	}
+	account := resp.Value[0]
+	if !account.Owner.Equals(ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by the stake program: owner is %s", stakeAccount, account.Owner)
+	}
+
+	var state StakeState
+	if err := state.Decode(account.Data.GetBinary()); err != nil {
+		return nil, fmt.Errorf("unable to decode stake account %s: %w", stakeAccount, err)
+	}
+	if !state.IsDelegated() {
+		return nil, fmt.Errorf("stake account %s is not delegated", stakeAccount)
+	}
+	var history StakeHistory
+	if err := history.Decode(resp.Value[1].Data.GetBinary()); err != nil {
+		return nil, err
+	}
+
+	status := state.Stake.Delegation.ActivationStatus(epoch, history, newRateActivationEpoch)
+	return &rpc.GetStakeActivationResult{
+		State:    status.State(),
+		Active:   status.Effective,
+		Inactive: status.Inactive(account.Lamports, state.Meta.RentExemptReserve),
+	}, nil
+}
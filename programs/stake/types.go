@@ -0,0 +1,57 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stake
+
+import (
+	ag_solanago "github.com/gagliardetto/solana-go"
+)
+
+// StakeAuthorize selects the authority changed by the Authorize instructions.
+type StakeAuthorize uint32
+
+const (
+	StakeAuthorizeStaker StakeAuthorize = iota
+	StakeAuthorizeWithdrawer
+)
+
+func (a StakeAuthorize) String() string {
+	switch a {
+	case StakeAuthorizeStaker:
+		return "Staker"
+	case StakeAuthorizeWithdrawer:
+		return "Withdrawer"
+	default:
+		return ""
+	}
+}
+
+// Authorized holds the authorities of a stake account.
+type Authorized struct {
+	// Authority allowed to delegate and deactivate the stake.
+	Staker ag_solanago.PublicKey
+	// Authority allowed to withdraw from the stake account.
+	Withdrawer ag_solanago.PublicKey
+}
+
+// Lockup prevents withdrawals from a stake account until both
+// the timestamp and the epoch are reached, unless the custodian signs.
+type Lockup struct {
+	// Unix timestamp at which the lockup expires.
+	UnixTimestamp int64
+	// Epoch at which the lockup expires.
+	Epoch uint64
+	// Custodian allowed to bypass the lockup.
+	Custodian ag_solanago.PublicKey
+}
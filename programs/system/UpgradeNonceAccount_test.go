@@ -0,0 +1,61 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	ag_gofuzz "github.com/gagliardetto/gofuzz"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_require "github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode_UpgradeNonceAccount(t *testing.T) {
+	fu := ag_gofuzz.New().NilChance(0)
+	for i := 0; i < 1; i++ {
+		t.Run("UpgradeNonceAccount"+strconv.Itoa(i), func(t *testing.T) {
+			{
+				params := new(UpgradeNonceAccount)
+				fu.Fuzz(params)
+				params.AccountMetaSlice = nil
+				buf := new(bytes.Buffer)
+				err := encodeT(*params, buf)
+				ag_require.NoError(t, err)
+				//
+				got := new(UpgradeNonceAccount)
+				err = decodeT(got, buf.Bytes())
+				got.AccountMetaSlice = nil
+				ag_require.NoError(t, err)
+				ag_require.Equal(t, params, got)
+			}
+		})
+	}
+}
+
+func TestDecodeInstruction_UpgradeNonceAccount(t *testing.T) {
+	nonce := ag_solanago.NewWallet().PublicKey()
+	inst := NewUpgradeNonceAccountInstruction(nonce).Build()
+	data, err := inst.Data()
+	ag_require.NoError(t, err)
+	ag_require.Equal(t, []byte{12, 0, 0, 0}, data)
+
+	decoded, err := DecodeInstruction(inst.Accounts(), data)
+	ag_require.NoError(t, err)
+	ag_require.IsType(t, &UpgradeNonceAccount{}, decoded.Impl)
+	ag_require.Equal(t, nonce, decoded.Impl.(*UpgradeNonceAccount).GetNonceAccount().PublicKey)
+	ag_require.True(t, decoded.Impl.(*UpgradeNonceAccount).GetNonceAccount().IsWritable)
+}
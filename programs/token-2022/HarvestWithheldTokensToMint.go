@@ -0,0 +1,161 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token2022
+
+import (
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Permissionless instruction to transfer all withheld tokens to the mint.
+//
+// Succeeds for frozen accounts.
+//
+// Accounts provided should include the `TransferFeeAmount` extension. If
+// not, the account is skipped.
+type HarvestWithheldTokensToMint struct {
+	// [0] = [WRITE] mint
+	// ··········· The mint.
+	//
+	// [1...] = [WRITE] sources
+	// ··········· The source accounts to harvest from.
+	Accounts ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+	Sources  ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+func (obj *HarvestWithheldTokensToMint) SetAccounts(accounts []*ag_solanago.AccountMeta) error {
+	obj.Accounts, obj.Sources = ag_solanago.AccountMetaSlice(accounts).SplitFrom(1)
+	return nil
+}
+
+func (slice HarvestWithheldTokensToMint) GetAccounts() (accounts []*ag_solanago.AccountMeta) {
+	accounts = append(accounts, slice.Accounts...)
+	accounts = append(accounts, slice.Sources...)
+	return
+}
+
+// NewHarvestWithheldTokensToMintInstructionBuilder creates a new `HarvestWithheldTokensToMint` instruction builder.
+func NewHarvestWithheldTokensToMintInstructionBuilder() *HarvestWithheldTokensToMint {
+	nd := &HarvestWithheldTokensToMint{
+		Accounts: make(ag_solanago.AccountMetaSlice, 1),
+		Sources:  make(ag_solanago.AccountMetaSlice, 0),
+	}
+	return nd
+}
+
+// SetMintAccount sets the "mint" account.
+// The mint.
+func (inst *HarvestWithheldTokensToMint) SetMintAccount(mint ag_solanago.PublicKey) *HarvestWithheldTokensToMint {
+	inst.Accounts[0] = ag_solanago.Meta(mint).WRITE()
+	return inst
+}
+
+// GetMintAccount gets the "mint" account.
+// The mint.
+func (inst *HarvestWithheldTokensToMint) GetMintAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[0]
+}
+
+// AddSourceAccount adds an account to the "sources" accounts.
+// The source accounts to harvest from.
+func (inst *HarvestWithheldTokensToMint) AddSourceAccount(account ag_solanago.PublicKey) *HarvestWithheldTokensToMint {
+	inst.Sources = append(inst.Sources, ag_solanago.Meta(account).WRITE())
+	return inst
+}
+
+func (inst HarvestWithheldTokensToMint) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: typeIDOf(Instruction_TransferFeeExtension, TransferFeeInstruction_HarvestWithheldTokensToMint),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst HarvestWithheldTokensToMint) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *HarvestWithheldTokensToMint) Validate() error {
+	// Check whether all (required) accounts are set:
+	{
+		if inst.Accounts[0] == nil {
+			return errors.New("accounts.Mint is not set")
+		}
+		if len(inst.Sources) == 0 {
+			return fmt.Errorf("accounts.Sources is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *HarvestWithheldTokensToMint) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("HarvestWithheldTokensToMint")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("mint", inst.Accounts[0]))
+
+						sourcesBranch := accountsBranch.Child(fmt.Sprintf("sources[len=%v]", len(inst.Sources)))
+						for i, v := range inst.Sources {
+							if len(inst.Sources) > 9 && i < 10 {
+								sourcesBranch.Child(ag_format.Meta(fmt.Sprintf(" [%v]", i), v))
+							} else {
+								sourcesBranch.Child(ag_format.Meta(fmt.Sprintf("[%v]", i), v))
+							}
+						}
+					})
+				})
+		})
+}
+
+func (obj HarvestWithheldTokensToMint) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
+	return nil
+}
+func (obj *HarvestWithheldTokensToMint) UnmarshalWithDecoder(decoder *ag_binary.Decoder) (err error) {
+	return nil
+}
+
+// NewHarvestWithheldTokensToMintInstruction declares a new HarvestWithheldTokensToMint instruction with the provided parameters and accounts.
+func NewHarvestWithheldTokensToMintInstruction(
+	// Accounts:
+	mint ag_solanago.PublicKey,
+	sources []ag_solanago.PublicKey,
+) *HarvestWithheldTokensToMint {
+	inst := NewHarvestWithheldTokensToMintInstructionBuilder().
+		SetMintAccount(mint)
+	for _, account := range sources {
+		inst.AddSourceAccount(account)
+	}
+	return inst
+}
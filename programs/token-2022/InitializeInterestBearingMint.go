@@ -0,0 +1,172 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token2022
+
+import (
+	"errors"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Initialize a new mint with the `InterestBearing` extension.
+//
+// Fails if the mint has already been initialized, so must be called before
+// `InitializeMint`.
+type InitializeInterestBearingMint struct {
+	// The public key for the account that can update the rate.
+	RateAuthority *ag_solanago.PublicKey `bin:"optional"`
+
+	// The initial interest rate, in basis points.
+	Rate *int16
+
+	// [0] = [WRITE] mint
+	// ··········· The mint to initialize.
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewInitializeInterestBearingMintInstructionBuilder creates a new `InitializeInterestBearingMint` instruction builder.
+func NewInitializeInterestBearingMintInstructionBuilder() *InitializeInterestBearingMint {
+	nd := &InitializeInterestBearingMint{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 1),
+	}
+	return nd
+}
+
+// SetRateAuthority sets the "rateAuthority" parameter.
+// The public key for the account that can update the rate.
+func (inst *InitializeInterestBearingMint) SetRateAuthority(rateAuthority ag_solanago.PublicKey) *InitializeInterestBearingMint {
+	inst.RateAuthority = &rateAuthority
+	return inst
+}
+
+// SetRate sets the "rate" parameter.
+// The initial interest rate, in basis points.
+func (inst *InitializeInterestBearingMint) SetRate(rate int16) *InitializeInterestBearingMint {
+	inst.Rate = &rate
+	return inst
+}
+
+// SetMintAccount sets the "mint" account.
+// The mint to initialize.
+func (inst *InitializeInterestBearingMint) SetMintAccount(mint ag_solanago.PublicKey) *InitializeInterestBearingMint {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(mint).WRITE()
+	return inst
+}
+
+// GetMintAccount gets the "mint" account.
+// The mint to initialize.
+func (inst *InitializeInterestBearingMint) GetMintAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice[0]
+}
+
+func (inst InitializeInterestBearingMint) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: typeIDOf(Instruction_InterestBearingMintExtension, ExtensionInstruction_Initialize),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst InitializeInterestBearingMint) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *InitializeInterestBearingMint) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.Rate == nil {
+			return errors.New("Rate parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice[0] == nil {
+			return errors.New("accounts.Mint is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *InitializeInterestBearingMint) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("InitializeInterestBearingMint")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("RateAuthority (OPT)", inst.RateAuthority))
+						paramsBranch.Child(ag_format.Param("         Rate", *inst.Rate))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("mint", inst.AccountMetaSlice[0]))
+					})
+				})
+		})
+}
+
+func (obj InitializeInterestBearingMint) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
+	// Serialize `RateAuthority` param:
+	err = encodeOptionalPubkey(encoder, obj.RateAuthority)
+	if err != nil {
+		return err
+	}
+	// Serialize `Rate` param:
+	err = encoder.Encode(obj.Rate)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (obj *InitializeInterestBearingMint) UnmarshalWithDecoder(decoder *ag_binary.Decoder) (err error) {
+	// Deserialize `RateAuthority`:
+	obj.RateAuthority, err = decodeOptionalPubkeyFrom(decoder)
+	if err != nil {
+		return err
+	}
+	// Deserialize `Rate`:
+	err = decoder.Decode(&obj.Rate)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewInitializeInterestBearingMintInstruction declares a new InitializeInterestBearingMint instruction with the provided parameters and accounts.
+func NewInitializeInterestBearingMintInstruction(
+	// Parameters:
+	rateAuthority ag_solanago.PublicKey,
+	rate int16,
+	// Accounts:
+	mint ag_solanago.PublicKey,
+) *InitializeInterestBearingMint {
+	return NewInitializeInterestBearingMintInstructionBuilder().
+		SetRateAuthority(rateAuthority).
+		SetRate(rate).
+		SetMintAccount(mint)
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token2022
+
+import (
+	"errors"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Initialize the permanent delegate on a new mint.
+//
+// Fails if the mint has already been initialized, so must be called before
+// `InitializeMint`.
+type InitializePermanentDelegate struct {
+	// Authority that may sign for `Transfer`s and `Burn`s on any account.
+	Delegate *ag_solanago.PublicKey
+
+	// [0] = [WRITE] mint
+	// ··········· The mint to initialize.
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewInitializePermanentDelegateInstructionBuilder creates a new `InitializePermanentDelegate` instruction builder.
+func NewInitializePermanentDelegateInstructionBuilder() *InitializePermanentDelegate {
+	nd := &InitializePermanentDelegate{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 1),
+	}
+	return nd
+}
+
+// SetDelegate sets the "delegate" parameter.
+// Authority that may sign for `Transfer`s and `Burn`s on any account.
+func (inst *InitializePermanentDelegate) SetDelegate(delegate ag_solanago.PublicKey) *InitializePermanentDelegate {
+	inst.Delegate = &delegate
+	return inst
+}
+
+// SetMintAccount sets the "mint" account.
+// The mint to initialize.
+func (inst *InitializePermanentDelegate) SetMintAccount(mint ag_solanago.PublicKey) *InitializePermanentDelegate {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(mint).WRITE()
+	return inst
+}
+
+// GetMintAccount gets the "mint" account.
+// The mint to initialize.
+func (inst *InitializePermanentDelegate) GetMintAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice[0]
+}
+
+func (inst InitializePermanentDelegate) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: typeIDOf(Instruction_InitializePermanentDelegate),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst InitializePermanentDelegate) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *InitializePermanentDelegate) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.Delegate == nil {
+			return errors.New("Delegate parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice[0] == nil {
+			return errors.New("accounts.Mint is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *InitializePermanentDelegate) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("InitializePermanentDelegate")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("Delegate", *inst.Delegate))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("mint", inst.AccountMetaSlice[0]))
+					})
+				})
+		})
+}
+
+func (obj InitializePermanentDelegate) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
+	// Serialize `Delegate` param:
+	err = encoder.Encode(obj.Delegate)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (obj *InitializePermanentDelegate) UnmarshalWithDecoder(decoder *ag_binary.Decoder) (err error) {
+	// Deserialize `Delegate`:
+	err = decoder.Decode(&obj.Delegate)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewInitializePermanentDelegateInstruction declares a new InitializePermanentDelegate instruction with the provided parameters and accounts.
+func NewInitializePermanentDelegateInstruction(
+	// Parameters:
+	delegate ag_solanago.PublicKey,
+	// Accounts:
+	mint ag_solanago.PublicKey,
+) *InitializePermanentDelegate {
+	return NewInitializePermanentDelegateInstructionBuilder().
+		SetDelegate(delegate).
+		SetMintAccount(mint)
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token2022
+
+import (
+	"errors"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Initialize the transfer fee on a new mint.
+//
+// Fails if the mint has already been initialized, so must be called before
+// `InitializeMint`.
+type InitializeTransferFeeConfig struct {
+	// Pubkey that may update the fees.
+	TransferFeeConfigAuthority *ag_solanago.PublicKey `bin:"optional"`
+
+	// Withdraw instructions must be signed by this key.
+	WithdrawWithheldAuthority *ag_solanago.PublicKey `bin:"optional"`
+
+	// Amount of transfer collected as fees, expressed as basis points of the
+	// transfer amount.
+	TransferFeeBasisPoints *uint16
+
+	// Maximum fee assessed on transfers.
+	MaximumFee *uint64
+
+	// [0] = [WRITE] mint
+	// ··········· The mint to initialize.
+	ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+// NewInitializeTransferFeeConfigInstructionBuilder creates a new `InitializeTransferFeeConfig` instruction builder.
+func NewInitializeTransferFeeConfigInstructionBuilder() *InitializeTransferFeeConfig {
+	nd := &InitializeTransferFeeConfig{
+		AccountMetaSlice: make(ag_solanago.AccountMetaSlice, 1),
+	}
+	return nd
+}
+
+// SetTransferFeeConfigAuthority sets the "transferFeeConfigAuthority" parameter.
+// Pubkey that may update the fees.
+func (inst *InitializeTransferFeeConfig) SetTransferFeeConfigAuthority(transferFeeConfigAuthority ag_solanago.PublicKey) *InitializeTransferFeeConfig {
+	inst.TransferFeeConfigAuthority = &transferFeeConfigAuthority
+	return inst
+}
+
+// SetWithdrawWithheldAuthority sets the "withdrawWithheldAuthority" parameter.
+// Withdraw instructions must be signed by this key.
+func (inst *InitializeTransferFeeConfig) SetWithdrawWithheldAuthority(withdrawWithheldAuthority ag_solanago.PublicKey) *InitializeTransferFeeConfig {
+	inst.WithdrawWithheldAuthority = &withdrawWithheldAuthority
+	return inst
+}
+
+// SetTransferFeeBasisPoints sets the "transferFeeBasisPoints" parameter.
+// Amount of transfer collected as fees, expressed as basis points of the
+// transfer amount.
+func (inst *InitializeTransferFeeConfig) SetTransferFeeBasisPoints(transferFeeBasisPoints uint16) *InitializeTransferFeeConfig {
+	inst.TransferFeeBasisPoints = &transferFeeBasisPoints
+	return inst
+}
+
+// SetMaximumFee sets the "maximumFee" parameter.
+// Maximum fee assessed on transfers.
+func (inst *InitializeTransferFeeConfig) SetMaximumFee(maximumFee uint64) *InitializeTransferFeeConfig {
+	inst.MaximumFee = &maximumFee
+	return inst
+}
+
+// SetMintAccount sets the "mint" account.
+// The mint to initialize.
+func (inst *InitializeTransferFeeConfig) SetMintAccount(mint ag_solanago.PublicKey) *InitializeTransferFeeConfig {
+	inst.AccountMetaSlice[0] = ag_solanago.Meta(mint).WRITE()
+	return inst
+}
+
+// GetMintAccount gets the "mint" account.
+// The mint to initialize.
+func (inst *InitializeTransferFeeConfig) GetMintAccount() *ag_solanago.AccountMeta {
+	return inst.AccountMetaSlice[0]
+}
+
+func (inst InitializeTransferFeeConfig) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: typeIDOf(Instruction_TransferFeeExtension, TransferFeeInstruction_InitializeTransferFeeConfig),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst InitializeTransferFeeConfig) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *InitializeTransferFeeConfig) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.TransferFeeBasisPoints == nil {
+			return errors.New("TransferFeeBasisPoints parameter is not set")
+		}
+		if inst.MaximumFee == nil {
+			return errors.New("MaximumFee parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if inst.AccountMetaSlice[0] == nil {
+			return errors.New("accounts.Mint is not set")
+		}
+	}
+	return nil
+}
+
+func (inst *InitializeTransferFeeConfig) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("InitializeTransferFeeConfig")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("TransferFeeConfigAuthority (OPT)", inst.TransferFeeConfigAuthority))
+						paramsBranch.Child(ag_format.Param(" WithdrawWithheldAuthority (OPT)", inst.WithdrawWithheldAuthority))
+						paramsBranch.Child(ag_format.Param("    TransferFeeBasisPoints", *inst.TransferFeeBasisPoints))
+						paramsBranch.Child(ag_format.Param("                MaximumFee", *inst.MaximumFee))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("mint", inst.AccountMetaSlice[0]))
+					})
+				})
+		})
+}
+
+func (obj InitializeTransferFeeConfig) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
+	// Serialize `TransferFeeConfigAuthority` param:
+	err = encodeCOptionPubkey(encoder, obj.TransferFeeConfigAuthority)
+	if err != nil {
+		return err
+	}
+	// Serialize `WithdrawWithheldAuthority` param:
+	err = encodeCOptionPubkey(encoder, obj.WithdrawWithheldAuthority)
+	if err != nil {
+		return err
+	}
+	// Serialize `TransferFeeBasisPoints` param:
+	err = encoder.Encode(obj.TransferFeeBasisPoints)
+	if err != nil {
+		return err
+	}
+	// Serialize `MaximumFee` param:
+	err = encoder.Encode(obj.MaximumFee)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (obj *InitializeTransferFeeConfig) UnmarshalWithDecoder(decoder *ag_binary.Decoder) (err error) {
+	// Deserialize `TransferFeeConfigAuthority`:
+	obj.TransferFeeConfigAuthority, err = decodeCOptionPubkeyFrom(decoder)
+	if err != nil {
+		return err
+	}
+	// Deserialize `WithdrawWithheldAuthority`:
+	obj.WithdrawWithheldAuthority, err = decodeCOptionPubkeyFrom(decoder)
+	if err != nil {
+		return err
+	}
+	// Deserialize `TransferFeeBasisPoints`:
+	err = decoder.Decode(&obj.TransferFeeBasisPoints)
+	if err != nil {
+		return err
+	}
+	// Deserialize `MaximumFee`:
+	err = decoder.Decode(&obj.MaximumFee)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewInitializeTransferFeeConfigInstruction declares a new InitializeTransferFeeConfig instruction with the provided parameters and accounts.
+func NewInitializeTransferFeeConfigInstruction(
+	// Parameters:
+	transferFeeConfigAuthority ag_solanago.PublicKey,
+	withdrawWithheldAuthority ag_solanago.PublicKey,
+	transferFeeBasisPoints uint16,
+	maximumFee uint64,
+	// Accounts:
+	mint ag_solanago.PublicKey,
+) *InitializeTransferFeeConfig {
+	return NewInitializeTransferFeeConfigInstructionBuilder().
+		SetTransferFeeConfigAuthority(transferFeeConfigAuthority).
+		SetWithdrawWithheldAuthority(withdrawWithheldAuthority).
+		SetTransferFeeBasisPoints(transferFeeBasisPoints).
+		SetMaximumFee(maximumFee).
+		SetMintAccount(mint)
+}
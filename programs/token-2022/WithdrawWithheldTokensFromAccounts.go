@@ -0,0 +1,266 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token2022
+
+import (
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	ag_format "github.com/gagliardetto/solana-go/text/format"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Transfer all withheld tokens to an account. Signed by the mint's
+// withdraw withheld tokens authority.
+type WithdrawWithheldTokensFromAccounts struct {
+	// Number of token accounts harvested.
+	NumTokenAccounts *uint8
+
+	// [0] = [] mint
+	// ··········· The token mint. Must include the `TransferFeeConfig` extension.
+	//
+	// [1] = [WRITE] destination
+	// ··········· The fee receiver account. Must include the `TransferFeeAmount`
+	// ··········· extension and be associated with the provided mint.
+	//
+	// [2] = [] authority
+	// ··········· The mint's `withdraw_withheld_authority`.
+	//
+	// [3...] = [SIGNER] signers
+	// ··········· M signer accounts.
+	//
+	// [3+M...] = [WRITE] sources
+	// ··········· The source accounts to withdraw from.
+	Accounts ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+	Signers  ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+	Sources  ag_solanago.AccountMetaSlice `bin:"-" borsh_skip:"true"`
+}
+
+func (obj *WithdrawWithheldTokensFromAccounts) SetAccounts(accounts []*ag_solanago.AccountMeta) error {
+	var rest ag_solanago.AccountMetaSlice
+	obj.Accounts, rest = ag_solanago.AccountMetaSlice(accounts).SplitFrom(3)
+	sources := 0
+	if obj.NumTokenAccounts != nil {
+		sources = int(*obj.NumTokenAccounts)
+	}
+	if sources > len(rest) {
+		return fmt.Errorf("expected %v source accounts, got %v", sources, len(rest))
+	}
+	obj.Signers, obj.Sources = rest[:len(rest)-sources], rest[len(rest)-sources:]
+	return nil
+}
+
+func (slice WithdrawWithheldTokensFromAccounts) GetAccounts() (accounts []*ag_solanago.AccountMeta) {
+	accounts = append(accounts, slice.Accounts...)
+	accounts = append(accounts, slice.Signers...)
+	accounts = append(accounts, slice.Sources...)
+	return
+}
+
+// NewWithdrawWithheldTokensFromAccountsInstructionBuilder creates a new `WithdrawWithheldTokensFromAccounts` instruction builder.
+func NewWithdrawWithheldTokensFromAccountsInstructionBuilder() *WithdrawWithheldTokensFromAccounts {
+	nd := &WithdrawWithheldTokensFromAccounts{
+		Accounts: make(ag_solanago.AccountMetaSlice, 3),
+		Signers:  make(ag_solanago.AccountMetaSlice, 0),
+		Sources:  make(ag_solanago.AccountMetaSlice, 0),
+	}
+	return nd
+}
+
+// SetMintAccount sets the "mint" account.
+// The token mint.
+func (inst *WithdrawWithheldTokensFromAccounts) SetMintAccount(mint ag_solanago.PublicKey) *WithdrawWithheldTokensFromAccounts {
+	inst.Accounts[0] = ag_solanago.Meta(mint)
+	return inst
+}
+
+// GetMintAccount gets the "mint" account.
+// The token mint.
+func (inst *WithdrawWithheldTokensFromAccounts) GetMintAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[0]
+}
+
+// SetDestinationAccount sets the "destination" account.
+// The fee receiver account.
+func (inst *WithdrawWithheldTokensFromAccounts) SetDestinationAccount(destination ag_solanago.PublicKey) *WithdrawWithheldTokensFromAccounts {
+	inst.Accounts[1] = ag_solanago.Meta(destination).WRITE()
+	return inst
+}
+
+// GetDestinationAccount gets the "destination" account.
+// The fee receiver account.
+func (inst *WithdrawWithheldTokensFromAccounts) GetDestinationAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[1]
+}
+
+// SetAuthorityAccount sets the "authority" account.
+// The mint's `withdraw_withheld_authority`.
+func (inst *WithdrawWithheldTokensFromAccounts) SetAuthorityAccount(authority ag_solanago.PublicKey, multisigSigners ...ag_solanago.PublicKey) *WithdrawWithheldTokensFromAccounts {
+	inst.Accounts[2] = ag_solanago.Meta(authority)
+	if len(multisigSigners) == 0 {
+		inst.Accounts[2].SIGNER()
+	}
+	for _, signer := range multisigSigners {
+		inst.Signers = append(inst.Signers, ag_solanago.Meta(signer).SIGNER())
+	}
+	return inst
+}
+
+// GetAuthorityAccount gets the "authority" account.
+// The mint's `withdraw_withheld_authority`.
+func (inst *WithdrawWithheldTokensFromAccounts) GetAuthorityAccount() *ag_solanago.AccountMeta {
+	return inst.Accounts[2]
+}
+
+// AddSourceAccount adds an account to the "sources" accounts,
+// and updates the "numTokenAccounts" parameter.
+// The source accounts to withdraw from.
+func (inst *WithdrawWithheldTokensFromAccounts) AddSourceAccount(account ag_solanago.PublicKey) *WithdrawWithheldTokensFromAccounts {
+	inst.Sources = append(inst.Sources, ag_solanago.Meta(account).WRITE())
+	numTokenAccounts := uint8(len(inst.Sources))
+	inst.NumTokenAccounts = &numTokenAccounts
+	return inst
+}
+
+func (inst WithdrawWithheldTokensFromAccounts) Build() *Instruction {
+	return &Instruction{BaseVariant: ag_binary.BaseVariant{
+		Impl:   inst,
+		TypeID: typeIDOf(Instruction_TransferFeeExtension, TransferFeeInstruction_WithdrawWithheldTokensFromAccounts),
+	}}
+}
+
+// ValidateAndBuild validates the instruction parameters and accounts;
+// if there is a validation error, it returns the error.
+// Otherwise, it builds and returns the instruction.
+func (inst WithdrawWithheldTokensFromAccounts) ValidateAndBuild() (*Instruction, error) {
+	if err := inst.Validate(); err != nil {
+		return nil, err
+	}
+	return inst.Build(), nil
+}
+
+func (inst *WithdrawWithheldTokensFromAccounts) Validate() error {
+	// Check whether all (required) parameters are set:
+	{
+		if inst.NumTokenAccounts == nil {
+			return errors.New("NumTokenAccounts parameter is not set")
+		}
+	}
+
+	// Check whether all (required) accounts are set:
+	{
+		if inst.Accounts[0] == nil {
+			return errors.New("accounts.Mint is not set")
+		}
+		if inst.Accounts[1] == nil {
+			return errors.New("accounts.Destination is not set")
+		}
+		if inst.Accounts[2] == nil {
+			return errors.New("accounts.Authority is not set")
+		}
+		if !inst.Accounts[2].IsSigner && len(inst.Signers) == 0 {
+			return fmt.Errorf("accounts.Signers is not set")
+		}
+		if len(inst.Signers) > MAX_SIGNERS {
+			return fmt.Errorf("too many signers; got %v, but max is 11", len(inst.Signers))
+		}
+		if len(inst.Sources) == 0 {
+			return fmt.Errorf("accounts.Sources is not set")
+		}
+		if int(*inst.NumTokenAccounts) != len(inst.Sources) {
+			return fmt.Errorf("NumTokenAccounts is %v, but %v source accounts are set", *inst.NumTokenAccounts, len(inst.Sources))
+		}
+	}
+	return nil
+}
+
+func (inst *WithdrawWithheldTokensFromAccounts) EncodeToTree(parent ag_treeout.Branches) {
+	parent.Child(ag_format.Program(ProgramName, ProgramID)).
+		//
+		ParentFunc(func(programBranch ag_treeout.Branches) {
+			programBranch.Child(ag_format.Instruction("WithdrawWithheldTokensFromAccounts")).
+				//
+				ParentFunc(func(instructionBranch ag_treeout.Branches) {
+
+					// Parameters of the instruction:
+					instructionBranch.Child("Params").ParentFunc(func(paramsBranch ag_treeout.Branches) {
+						paramsBranch.Child(ag_format.Param("NumTokenAccounts", *inst.NumTokenAccounts))
+					})
+
+					// Accounts of the instruction:
+					instructionBranch.Child("Accounts").ParentFunc(func(accountsBranch ag_treeout.Branches) {
+						accountsBranch.Child(ag_format.Meta("       mint", inst.Accounts[0]))
+						accountsBranch.Child(ag_format.Meta("destination", inst.Accounts[1]))
+						accountsBranch.Child(ag_format.Meta("  authority", inst.Accounts[2]))
+
+						signersBranch := accountsBranch.Child(fmt.Sprintf("signers[len=%v]", len(inst.Signers)))
+						for i, v := range inst.Signers {
+							if len(inst.Signers) > 9 && i < 10 {
+								signersBranch.Child(ag_format.Meta(fmt.Sprintf(" [%v]", i), v))
+							} else {
+								signersBranch.Child(ag_format.Meta(fmt.Sprintf("[%v]", i), v))
+							}
+						}
+
+						sourcesBranch := accountsBranch.Child(fmt.Sprintf("sources[len=%v]", len(inst.Sources)))
+						for i, v := range inst.Sources {
+							if len(inst.Sources) > 9 && i < 10 {
+								sourcesBranch.Child(ag_format.Meta(fmt.Sprintf(" [%v]", i), v))
+							} else {
+								sourcesBranch.Child(ag_format.Meta(fmt.Sprintf("[%v]", i), v))
+							}
+						}
+					})
+				})
+		})
+}
+
+func (obj WithdrawWithheldTokensFromAccounts) MarshalWithEncoder(encoder *ag_binary.Encoder) (err error) {
+	// Serialize `NumTokenAccounts` param:
+	err = encoder.Encode(obj.NumTokenAccounts)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+func (obj *WithdrawWithheldTokensFromAccounts) UnmarshalWithDecoder(decoder *ag_binary.Decoder) (err error) {
+	// Deserialize `NumTokenAccounts`:
+	err = decoder.Decode(&obj.NumTokenAccounts)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// NewWithdrawWithheldTokensFromAccountsInstruction declares a new WithdrawWithheldTokensFromAccounts instruction with the provided parameters and accounts.
+func NewWithdrawWithheldTokensFromAccountsInstruction(
+	// Accounts:
+	mint ag_solanago.PublicKey,
+	destination ag_solanago.PublicKey,
+	authority ag_solanago.PublicKey,
+	multisigSigners []ag_solanago.PublicKey,
+	sources []ag_solanago.PublicKey,
+) *WithdrawWithheldTokensFromAccounts {
+	inst := NewWithdrawWithheldTokensFromAccountsInstructionBuilder().
+		SetMintAccount(mint).
+		SetDestinationAccount(destination).
+		SetAuthorityAccount(authority, multisigSigners...)
+	for _, account := range sources {
+		inst.AddSourceAccount(account)
+	}
+	return inst
+}
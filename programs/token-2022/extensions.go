@@ -0,0 +1,404 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token2022
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+)
+
+const (
+	// Size of the base mint state, shared with the Token program.
+	MINT_SIZE = 82
+	// Size of the base account state, shared with the Token program.
+	ACCOUNT_SIZE = 165
+	// Size of a multisig account, which never has extensions.
+	MULTISIG_SIZE = 355
+)
+
+// AccountType is the byte following the base state of an account with extensions.
+type AccountType uint8
+
+const (
+	AccountTypeUninitialized AccountType = iota
+	AccountTypeMint
+	AccountTypeAccount
+)
+
+type ExtensionType uint16
+
+const (
+	ExtensionUninitialized ExtensionType = iota
+	ExtensionTransferFeeConfig
+	ExtensionTransferFeeAmount
+	ExtensionMintCloseAuthority
+	ExtensionConfidentialTransferMint
+	ExtensionConfidentialTransferAccount
+	ExtensionDefaultAccountState
+	ExtensionImmutableOwner
+	ExtensionMemoTransfer
+	ExtensionNonTransferable
+	ExtensionInterestBearingConfig
+	ExtensionCpiGuard
+	ExtensionPermanentDelegate
+	ExtensionNonTransferableAccount
+	ExtensionTransferHook
+	ExtensionTransferHookAccount
+	ExtensionConfidentialTransferFeeConfig
+	ExtensionConfidentialTransferFeeAmount
+	ExtensionMetadataPointer
+	ExtensionTokenMetadata
+	ExtensionGroupPointer
+	ExtensionTokenGroup
+	ExtensionGroupMemberPointer
+	ExtensionTokenGroupMember
+)
+
+// Extension is a raw TLV entry of the extension data of a mint or account.
+type Extension struct {
+	Type ExtensionType
+	Data []byte
+}
+
+// Mint is a Token-2022 mint, with its parsed extensions.
+// Extensions without a typed representation are only available in Extensions.
+type Mint struct {
+	token.Mint
+
+	TransferFeeConfig     *TransferFeeConfig
+	MintCloseAuthority    *ag_solanago.PublicKey
+	InterestBearingConfig *InterestBearingConfig
+	PermanentDelegate     *ag_solanago.PublicKey
+	TransferHook          *TransferHook
+	MetadataPointer       *MetadataPointer
+	TokenMetadata         *TokenMetadata
+
+	// All the extensions of the mint, in order.
+	Extensions []Extension
+}
+
+// Account is a Token-2022 token account, with its parsed extensions.
+// Extensions without a typed representation are only available in Extensions.
+type Account struct {
+	token.Account
+
+	TransferFeeAmount   *TransferFeeAmount
+	TransferHookAccount *TransferHookAccount
+	ImmutableOwner      bool
+
+	// All the extensions of the account, in order.
+	Extensions []Extension
+}
+
+type TransferFee struct {
+	// First epoch where the transfer fee takes effect.
+	Epoch uint64
+	// Maximum fee assessed on transfers, expressed as an amount of tokens.
+	MaximumFee uint64
+	// Amount of transfer collected as fees, expressed as basis points of the transfer amount.
+	TransferFeeBasisPoints uint16
+}
+
+// Calculate returns the fee assessed on a transfer of the provided amount.
+func (fee TransferFee) Calculate(amount uint64) uint64 {
+	if fee.TransferFeeBasisPoints == 0 || amount == 0 {
+		return 0
+	}
+	// Ceiling division, computed without overflowing for large amounts.
+	bps := uint64(fee.TransferFeeBasisPoints)
+	raw := (amount/10_000)*bps + ((amount%10_000)*bps+9_999)/10_000
+	if raw > fee.MaximumFee {
+		return fee.MaximumFee
+	}
+	return raw
+}
+
+type TransferFeeConfig struct {
+	// Optional authority to set the fee.
+	TransferFeeConfigAuthority *ag_solanago.PublicKey
+	// Withdraw from mint instructions must be signed by this key.
+	WithdrawWithheldAuthority *ag_solanago.PublicKey
+	// Withheld transfer fee tokens that have been moved to the mint for withdrawal.
+	WithheldAmount   uint64
+	OlderTransferFee TransferFee
+	NewerTransferFee TransferFee
+}
+
+// EpochFee returns the transfer fee in effect at the provided epoch.
+func (config *TransferFeeConfig) EpochFee(epoch uint64) TransferFee {
+	if epoch >= config.NewerTransferFee.Epoch {
+		return config.NewerTransferFee
+	}
+	return config.OlderTransferFee
+}
+
+type TransferFeeAmount struct {
+	// Amount withheld during transfers, to be harvested to the mint.
+	WithheldAmount uint64
+}
+
+type InterestBearingConfig struct {
+	RateAuthority           *ag_solanago.PublicKey
+	InitializationTimestamp int64
+	PreUpdateAverageRate    int16
+	LastUpdateTimestamp     int64
+	// Current rate, in basis points.
+	CurrentRate int16
+}
+
+type TransferHook struct {
+	// Authority that can set the transfer hook program ID.
+	Authority *ag_solanago.PublicKey
+	// Program called during transfers.
+	ProgramID *ag_solanago.PublicKey
+}
+
+type TransferHookAccount struct {
+	// Whether the account is currently transferring tokens.
+	Transferring bool
+}
+
+type MetadataPointer struct {
+	// Authority that can set the metadata address.
+	Authority *ag_solanago.PublicKey
+	// Account address that holds the metadata.
+	MetadataAddress *ag_solanago.PublicKey
+}
+
+type TokenMetadata struct {
+	UpdateAuthority    *ag_solanago.PublicKey
+	Mint               ag_solanago.PublicKey
+	Name               string
+	Symbol             string
+	URI                string
+	AdditionalMetadata [][2]string
+}
+
+// DecodeMint decodes a Token-2022 mint and its extensions.
+func DecodeMint(data []byte) (*Mint, error) {
+	if len(data) < MINT_SIZE {
+		return nil, fmt.Errorf("mint data too short: %d bytes", len(data))
+	}
+	out := new(Mint)
+	if err := out.Mint.UnmarshalWithDecoder(ag_binary.NewBinDecoder(data[:MINT_SIZE])); err != nil {
+		return nil, fmt.Errorf("unable to decode mint: %w", err)
+	}
+	extensions, err := decodeExtensions(data, AccountTypeMint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode mint extensions: %w", err)
+	}
+	out.Extensions = extensions
+
+	for _, ext := range extensions {
+		switch ext.Type {
+		case ExtensionTransferFeeConfig:
+			out.TransferFeeConfig, err = decodeTransferFeeConfig(ext.Data)
+		case ExtensionMintCloseAuthority:
+			out.MintCloseAuthority, err = decodeOptionalPubkey(ext.Data)
+		case ExtensionInterestBearingConfig:
+			out.InterestBearingConfig, err = decodeInterestBearingConfig(ext.Data)
+		case ExtensionPermanentDelegate:
+			out.PermanentDelegate, err = decodeOptionalPubkey(ext.Data)
+		case ExtensionTransferHook:
+			out.TransferHook = new(TransferHook)
+			out.TransferHook.Authority, out.TransferHook.ProgramID, err = decodeOptionalPubkeyPair(ext.Data)
+		case ExtensionMetadataPointer:
+			out.MetadataPointer = new(MetadataPointer)
+			out.MetadataPointer.Authority, out.MetadataPointer.MetadataAddress, err = decodeOptionalPubkeyPair(ext.Data)
+		case ExtensionTokenMetadata:
+			out.TokenMetadata, err = decodeTokenMetadata(ext.Data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode extension %d: %w", ext.Type, err)
+		}
+	}
+	return out, nil
+}
+
+// DecodeAccount decodes a Token-2022 token account and its extensions.
+func DecodeAccount(data []byte) (*Account, error) {
+	if len(data) < ACCOUNT_SIZE {
+		return nil, fmt.Errorf("account data too short: %d bytes", len(data))
+	}
+	out := new(Account)
+	if err := out.Account.UnmarshalWithDecoder(ag_binary.NewBinDecoder(data[:ACCOUNT_SIZE])); err != nil {
+		return nil, fmt.Errorf("unable to decode account: %w", err)
+	}
+	extensions, err := decodeExtensions(data, AccountTypeAccount)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode account extensions: %w", err)
+	}
+	out.Extensions = extensions
+
+	for _, ext := range extensions {
+		switch ext.Type {
+		case ExtensionTransferFeeAmount:
+			if len(ext.Data) < 8 {
+				return nil, errors.New("transfer fee amount extension too short")
+			}
+			out.TransferFeeAmount = &TransferFeeAmount{
+				WithheldAmount: binary.LittleEndian.Uint64(ext.Data),
+			}
+		case ExtensionTransferHookAccount:
+			if len(ext.Data) < 1 {
+				return nil, errors.New("transfer hook account extension too short")
+			}
+			out.TransferHookAccount = &TransferHookAccount{
+				Transferring: ext.Data[0] != 0,
+			}
+		case ExtensionImmutableOwner:
+			out.ImmutableOwner = true
+		}
+	}
+	return out, nil
+}
+
+// decodeExtensions decodes the TLV entries following the base state
+// (padded to the size of an account) and the account type.
+func decodeExtensions(data []byte, expected AccountType) ([]Extension, error) {
+	if len(data) <= ACCOUNT_SIZE || len(data) == MULTISIG_SIZE {
+		return nil, nil
+	}
+	if got := AccountType(data[ACCOUNT_SIZE]); got != expected {
+		return nil, fmt.Errorf("unexpected account type %d, expected %d", got, expected)
+	}
+
+	var out []Extension
+	rest := data[ACCOUNT_SIZE+1:]
+	for len(rest) >= 4 {
+		typ := ExtensionType(binary.LittleEndian.Uint16(rest[0:2]))
+		length := int(binary.LittleEndian.Uint16(rest[2:4]))
+		if typ == ExtensionUninitialized {
+			// The rest of the data is unused space.
+			break
+		}
+		rest = rest[4:]
+		if len(rest) < length {
+			return nil, fmt.Errorf("extension %d: length %d exceeds remaining data", typ, length)
+		}
+		out = append(out, Extension{Type: typ, Data: rest[:length]})
+		rest = rest[length:]
+	}
+	return out, nil
+}
+
+// decodeOptionalPubkey decodes an OptionalNonZeroPubkey, where the zero key means none.
+func decodeOptionalPubkey(data []byte) (*ag_solanago.PublicKey, error) {
+	if len(data) < 32 {
+		return nil, errors.New("public key too short")
+	}
+	key := ag_solanago.PublicKeyFromBytes(data[:32])
+	if key.IsZero() {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+func decodeOptionalPubkeyPair(data []byte) (*ag_solanago.PublicKey, *ag_solanago.PublicKey, error) {
+	if len(data) < 64 {
+		return nil, nil, errors.New("public keys too short")
+	}
+	first, _ := decodeOptionalPubkey(data[:32])
+	second, _ := decodeOptionalPubkey(data[32:64])
+	return first, second, nil
+}
+
+func decodeTransferFeeConfig(data []byte) (*TransferFeeConfig, error) {
+	if len(data) < 108 {
+		return nil, errors.New("transfer fee config too short")
+	}
+	out := new(TransferFeeConfig)
+	out.TransferFeeConfigAuthority, out.WithdrawWithheldAuthority, _ = decodeOptionalPubkeyPair(data)
+	out.WithheldAmount = binary.LittleEndian.Uint64(data[64:72])
+	out.OlderTransferFee = decodeTransferFee(data[72:90])
+	out.NewerTransferFee = decodeTransferFee(data[90:108])
+	return out, nil
+}
+
+func decodeTransferFee(data []byte) TransferFee {
+	return TransferFee{
+		Epoch:                  binary.LittleEndian.Uint64(data[0:8]),
+		MaximumFee:             binary.LittleEndian.Uint64(data[8:16]),
+		TransferFeeBasisPoints: binary.LittleEndian.Uint16(data[16:18]),
+	}
+}
+
+func decodeInterestBearingConfig(data []byte) (*InterestBearingConfig, error) {
+	if len(data) < 52 {
+		return nil, errors.New("interest bearing config too short")
+	}
+	out := new(InterestBearingConfig)
+	out.RateAuthority, _ = decodeOptionalPubkey(data)
+	out.InitializationTimestamp = int64(binary.LittleEndian.Uint64(data[32:40]))
+	out.PreUpdateAverageRate = int16(binary.LittleEndian.Uint16(data[40:42]))
+	out.LastUpdateTimestamp = int64(binary.LittleEndian.Uint64(data[42:50]))
+	out.CurrentRate = int16(binary.LittleEndian.Uint16(data[50:52]))
+	return out, nil
+}
+
+func decodeTokenMetadata(data []byte) (*TokenMetadata, error) {
+	if len(data) < 64 {
+		return nil, errors.New("token metadata too short")
+	}
+	out := new(TokenMetadata)
+	out.UpdateAuthority, _ = decodeOptionalPubkey(data)
+	out.Mint = ag_solanago.PublicKeyFromBytes(data[32:64])
+
+	dec := ag_binary.NewBorshDecoder(data[64:])
+	var err error
+	if out.Name, err = readBorshString(dec); err != nil {
+		return nil, err
+	}
+	if out.Symbol, err = readBorshString(dec); err != nil {
+		return nil, err
+	}
+	if out.URI, err = readBorshString(dec); err != nil {
+		return nil, err
+	}
+	count, err := dec.ReadUint32(binary.LittleEndian)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < count; i++ {
+		key, err := readBorshString(dec)
+		if err != nil {
+			return nil, err
+		}
+		value, err := readBorshString(dec)
+		if err != nil {
+			return nil, err
+		}
+		out.AdditionalMetadata = append(out.AdditionalMetadata, [2]string{key, value})
+	}
+	return out, nil
+}
+
+// readBorshString reads a string prefixed by its length as a u32.
+func readBorshString(dec *ag_binary.Decoder) (string, error) {
+	length, err := dec.ReadUint32(binary.LittleEndian)
+	if err != nil {
+		return "", err
+	}
+	v, err := dec.ReadNBytes(int(length))
+	if err != nil {
+		return "", err
+	}
+	return string(v), nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token2022
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/stretchr/testify/require"
+)
+
+func tlv(typ ExtensionType, value []byte) []byte {
+	out := make([]byte, 4, 4+len(value))
+	binary.LittleEndian.PutUint16(out[0:2], uint16(typ))
+	binary.LittleEndian.PutUint16(out[2:4], uint16(len(value)))
+	return append(out, value...)
+}
+
+func u64(v uint64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, v)
+}
+
+func u16(v uint16) []byte {
+	return binary.LittleEndian.AppendUint16(nil, v)
+}
+
+func TestDecodeMint(t *testing.T) {
+	authority := ag_solanago.NewWallet().PublicKey()
+	hook := ag_solanago.NewWallet().PublicKey()
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, ag_binary.NewBinEncoder(buf).Encode(token.Mint{
+		MintAuthority: &authority,
+		Supply:        1_000_000,
+		Decimals:      6,
+		IsInitialized: true,
+	}))
+	data := append(buf.Bytes(), make([]byte, ACCOUNT_SIZE-MINT_SIZE)...)
+	data = append(data, byte(AccountTypeMint))
+
+	var feeConfig []byte
+	feeConfig = append(feeConfig, authority[:]...)
+	feeConfig = append(feeConfig, make([]byte, 32)...)
+	feeConfig = append(feeConfig, u64(7)...)
+	feeConfig = append(append(append(feeConfig, u64(100)...), u64(10)...), u16(25)...)
+	feeConfig = append(append(append(feeConfig, u64(200)...), u64(5000)...), u16(50)...)
+	data = append(data, tlv(ExtensionTransferFeeConfig, feeConfig)...)
+	data = append(data, tlv(ExtensionPermanentDelegate, authority[:])...)
+	data = append(data, tlv(ExtensionTransferHook, append(make([]byte, 32), hook[:]...))...)
+
+	var metadata []byte
+	metadata = append(metadata, authority[:]...)
+	metadata = append(metadata, make([]byte, 32)...)
+	for _, s := range []string{"Token", "TKN", "https://example.com"} {
+		metadata = append(binary.LittleEndian.AppendUint32(metadata, uint32(len(s))), s...)
+	}
+	metadata = binary.LittleEndian.AppendUint32(metadata, 1)
+	for _, s := range []string{"key", "value"} {
+		metadata = append(binary.LittleEndian.AppendUint32(metadata, uint32(len(s))), s...)
+	}
+	data = append(data, tlv(ExtensionTokenMetadata, metadata)...)
+	data = append(data, tlv(ExtensionNonTransferable, nil)...)
+
+	mint, err := DecodeMint(data)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1_000_000), mint.Supply)
+	require.Equal(t, uint8(6), mint.Decimals)
+	require.Len(t, mint.Extensions, 5)
+	require.Equal(t, ExtensionNonTransferable, mint.Extensions[4].Type)
+
+	require.Equal(t, &authority, mint.TransferFeeConfig.TransferFeeConfigAuthority)
+	require.Nil(t, mint.TransferFeeConfig.WithdrawWithheldAuthority)
+	require.Equal(t, uint64(7), mint.TransferFeeConfig.WithheldAmount)
+	require.Equal(t, uint16(25), mint.TransferFeeConfig.EpochFee(150).TransferFeeBasisPoints)
+	require.Equal(t, uint16(50), mint.TransferFeeConfig.EpochFee(200).TransferFeeBasisPoints)
+
+	require.Equal(t, &authority, mint.PermanentDelegate)
+	require.Nil(t, mint.TransferHook.Authority)
+	require.Equal(t, &hook, mint.TransferHook.ProgramID)
+	require.Equal(t, "TKN", mint.TokenMetadata.Symbol)
+	require.Equal(t, [][2]string{{"key", "value"}}, mint.TokenMetadata.AdditionalMetadata)
+
+	_, err = DecodeAccount(data)
+	require.Error(t, err)
+}
+
+func TestDecodeAccount(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, ag_binary.NewBinEncoder(buf).Encode(token.Account{
+		Mint:   ag_solanago.NewWallet().PublicKey(),
+		Owner:  ag_solanago.NewWallet().PublicKey(),
+		Amount: 42,
+		State:  token.Initialized,
+	}))
+	require.Len(t, buf.Bytes(), ACCOUNT_SIZE)
+
+	account, err := DecodeAccount(buf.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), account.Amount)
+	require.Empty(t, account.Extensions)
+
+	data := append(buf.Bytes(), byte(AccountTypeAccount))
+	data = append(data, tlv(ExtensionTransferFeeAmount, u64(9))...)
+	data = append(data, tlv(ExtensionImmutableOwner, nil)...)
+	data = append(data, tlv(ExtensionTransferHookAccount, []byte{1})...)
+	// Trailing unused space.
+	data = append(data, make([]byte, 8)...)
+
+	account, err = DecodeAccount(data)
+	require.NoError(t, err)
+	require.Equal(t, uint64(9), account.TransferFeeAmount.WithheldAmount)
+	require.True(t, account.ImmutableOwner)
+	require.True(t, account.TransferHookAccount.Transferring)
+}
+
+func TestTransferFee_Calculate(t *testing.T) {
+	fee := TransferFee{TransferFeeBasisPoints: 50, MaximumFee: 5000}
+	require.Equal(t, uint64(0), fee.Calculate(0))
+	require.Equal(t, uint64(1), fee.Calculate(1))
+	require.Equal(t, uint64(5), fee.Calculate(1000))
+	require.Equal(t, uint64(6), fee.Calculate(1001))
+	require.Equal(t, uint64(5000), fee.Calculate(1<<63))
+	require.Equal(t, uint64(0), TransferFee{}.Calculate(1000))
+}
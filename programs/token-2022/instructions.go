@@ -0,0 +1,308 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Token-2022 (Token Extensions) program on the Solana blockchain.
+// The base instructions are shared with the Token program; this package
+// provides the extension-specific instructions and the extension-aware state.
+
+package token2022
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	ag_spew "github.com/davecgh/go-spew/spew"
+	ag_binary "github.com/gagliardetto/binary"
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	ag_text "github.com/gagliardetto/solana-go/text"
+	ag_treeout "github.com/gagliardetto/treeout"
+)
+
+// Maximum number of multisignature signers (max N)
+const MAX_SIGNERS = 11
+
+var ProgramID ag_solanago.PublicKey = ag_solanago.Token2022ProgramID
+
+func SetProgramID(pubkey ag_solanago.PublicKey) {
+	ProgramID = pubkey
+	ag_solanago.RegisterInstructionDecoder(ProgramID, registryDecodeInstruction)
+}
+
+const ProgramName = "Token2022"
+
+func init() {
+	if !ProgramID.IsZero() {
+		ag_solanago.RegisterInstructionDecoder(ProgramID, registryDecodeInstruction)
+	}
+}
+
+// Discriminators of the Token-2022 instructions; extension instructions
+// are followed by a second byte selecting the extension sub-instruction.
+// The instructions below Instruction_TransferFeeExtension have the layouts
+// of the Token program, and are decoded into the types of the token package.
+const (
+	Instruction_TransferFeeExtension         uint8 = 26
+	Instruction_InterestBearingMintExtension uint8 = 33
+	Instruction_InitializePermanentDelegate  uint8 = 35
+	Instruction_TransferHookExtension        uint8 = 36
+	Instruction_MetadataPointerExtension     uint8 = 39
+)
+
+// Sub-instructions of the TransferFeeExtension instruction.
+const (
+	TransferFeeInstruction_InitializeTransferFeeConfig uint8 = iota
+	TransferFeeInstruction_TransferCheckedWithFee
+	TransferFeeInstruction_WithdrawWithheldTokensFromMint
+	TransferFeeInstruction_WithdrawWithheldTokensFromAccounts
+	TransferFeeInstruction_HarvestWithheldTokensToMint
+	TransferFeeInstruction_SetTransferFee
+)
+
+// Sub-instruction initializing an extension, shared by the
+// InterestBearingMint, TransferHook and MetadataPointer extensions.
+const ExtensionInstruction_Initialize uint8 = 0
+
+type instructionDef struct {
+	discriminator []byte
+	name          string
+	impl          interface{}
+}
+
+var instructionDefs = []instructionDef{
+	{
+		[]byte{Instruction_TransferFeeExtension, TransferFeeInstruction_InitializeTransferFeeConfig},
+		"InitializeTransferFeeConfig", (*InitializeTransferFeeConfig)(nil),
+	},
+	{
+		[]byte{Instruction_TransferFeeExtension, TransferFeeInstruction_TransferCheckedWithFee},
+		"TransferCheckedWithFee", (*TransferCheckedWithFee)(nil),
+	},
+	{
+		[]byte{Instruction_TransferFeeExtension, TransferFeeInstruction_WithdrawWithheldTokensFromMint},
+		"WithdrawWithheldTokensFromMint", (*WithdrawWithheldTokensFromMint)(nil),
+	},
+	{
+		[]byte{Instruction_TransferFeeExtension, TransferFeeInstruction_WithdrawWithheldTokensFromAccounts},
+		"WithdrawWithheldTokensFromAccounts", (*WithdrawWithheldTokensFromAccounts)(nil),
+	},
+	{
+		[]byte{Instruction_TransferFeeExtension, TransferFeeInstruction_HarvestWithheldTokensToMint},
+		"HarvestWithheldTokensToMint", (*HarvestWithheldTokensToMint)(nil),
+	},
+	{
+		[]byte{Instruction_TransferFeeExtension, TransferFeeInstruction_SetTransferFee},
+		"SetTransferFee", (*SetTransferFee)(nil),
+	},
+	{
+		[]byte{Instruction_InterestBearingMintExtension, ExtensionInstruction_Initialize},
+		"InitializeInterestBearingMint", (*InitializeInterestBearingMint)(nil),
+	},
+	{
+		[]byte{Instruction_InitializePermanentDelegate},
+		"InitializePermanentDelegate", (*InitializePermanentDelegate)(nil),
+	},
+	{
+		[]byte{Instruction_TransferHookExtension, ExtensionInstruction_Initialize},
+		"InitializeTransferHook", (*InitializeTransferHook)(nil),
+	},
+	{
+		[]byte{Instruction_MetadataPointerExtension, ExtensionInstruction_Initialize},
+		"InitializeMetadataPointer", (*InitializeMetadataPointer)(nil),
+	},
+}
+
+func typeIDOf(discriminator ...uint8) ag_binary.TypeID {
+	return ag_binary.TypeIDFromBytes(discriminator)
+}
+
+func findInstructionDef(id ag_binary.TypeID) (instructionDef, bool) {
+	for _, def := range instructionDefs {
+		if typeIDOf(def.discriminator...) == id {
+			return def, true
+		}
+	}
+	return instructionDef{}, false
+}
+
+// isBaseInstruction reports whether id is the ID of an instruction
+// shared with the Token program.
+func isBaseInstruction(id ag_binary.TypeID) bool {
+	return id.Uint8() < Instruction_TransferFeeExtension && id == typeIDOf(id.Uint8())
+}
+
+// InstructionIDToName returns the name of the instruction given its ID.
+func InstructionIDToName(id ag_binary.TypeID) string {
+	if isBaseInstruction(id) {
+		return token.InstructionIDToName(id.Uint8())
+	}
+	def, ok := findInstructionDef(id)
+	if !ok {
+		return ""
+	}
+	return def.name
+}
+
+type Instruction struct {
+	ag_binary.BaseVariant
+}
+
+func (inst *Instruction) EncodeToTree(parent ag_treeout.Branches) {
+	if enToTree, ok := inst.Impl.(ag_text.EncodableToTree); ok {
+		enToTree.EncodeToTree(parent)
+	} else {
+		parent.Child(ag_spew.Sdump(inst))
+	}
+}
+
+func (inst *Instruction) ProgramID() ag_solanago.PublicKey {
+	return ProgramID
+}
+
+func (inst *Instruction) Accounts() (out []*ag_solanago.AccountMeta) {
+	return inst.Impl.(ag_solanago.AccountsGettable).GetAccounts()
+}
+
+func (inst *Instruction) Data() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := ag_binary.NewBinEncoder(buf).Encode(inst); err != nil {
+		return nil, fmt.Errorf("unable to encode instruction: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (inst *Instruction) TextEncode(encoder *ag_text.Encoder, option *ag_text.Option) error {
+	return encoder.Encode(inst.Impl, option)
+}
+
+func (inst *Instruction) UnmarshalWithDecoder(decoder *ag_binary.Decoder) error {
+	peeked, err := decoder.Peek(1)
+	if err != nil {
+		return fmt.Errorf("unable to read instruction discriminator: %w", err)
+	}
+	if peeked[0] < Instruction_TransferFeeExtension {
+		base := new(token.Instruction)
+		if err := decoder.Decode(base); err != nil {
+			return err
+		}
+		inst.BaseVariant = base.BaseVariant
+		return nil
+	}
+
+	first, err := decoder.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("unable to read instruction discriminator: %w", err)
+	}
+	discriminator := []byte{first}
+	if first != Instruction_InitializePermanentDelegate {
+		second, err := decoder.ReadUint8()
+		if err != nil {
+			return fmt.Errorf("unable to read extension instruction discriminator: %w", err)
+		}
+		discriminator = append(discriminator, second)
+	}
+
+	def, ok := findInstructionDef(typeIDOf(discriminator...))
+	if !ok {
+		return fmt.Errorf("unsupported instruction %v", discriminator)
+	}
+	impl := reflect.New(reflect.TypeOf(def.impl).Elem()).Interface()
+	if err := decoder.Decode(impl); err != nil {
+		return fmt.Errorf("unable to decode %s: %w", def.name, err)
+	}
+	inst.TypeID = typeIDOf(discriminator...)
+	inst.Impl = impl
+	return nil
+}
+
+func (inst Instruction) MarshalWithEncoder(encoder *ag_binary.Encoder) error {
+	if isBaseInstruction(inst.TypeID) {
+		return token.Instruction{BaseVariant: inst.BaseVariant}.MarshalWithEncoder(encoder)
+	}
+	def, ok := findInstructionDef(inst.TypeID)
+	if !ok {
+		return fmt.Errorf("unknown instruction type %v", inst.TypeID)
+	}
+	if err := encoder.WriteBytes(def.discriminator, false); err != nil {
+		return fmt.Errorf("unable to write variant type: %w", err)
+	}
+	return encoder.Encode(inst.Impl)
+}
+
+func registryDecodeInstruction(accounts []*ag_solanago.AccountMeta, data []byte) (interface{}, error) {
+	inst, err := DecodeInstruction(accounts, data)
+	if err != nil {
+		return nil, err
+	}
+	return inst, nil
+}
+
+func DecodeInstruction(accounts []*ag_solanago.AccountMeta, data []byte) (*Instruction, error) {
+	inst := new(Instruction)
+	if err := ag_binary.NewBinDecoder(data).Decode(inst); err != nil {
+		return nil, fmt.Errorf("unable to decode instruction: %w", err)
+	}
+	if v, ok := inst.Impl.(ag_solanago.AccountsSettable); ok {
+		err := v.SetAccounts(accounts)
+		if err != nil {
+			return nil, fmt.Errorf("unable to set accounts for instruction: %w", err)
+		}
+	}
+	return inst, nil
+}
+
+// encodeOptionalPubkey encodes an OptionalNonZeroPubkey, where nil is encoded as the zero key.
+func encodeOptionalPubkey(encoder *ag_binary.Encoder, key *ag_solanago.PublicKey) error {
+	if key == nil {
+		return encoder.WriteBytes(make([]byte, 32), false)
+	}
+	return encoder.WriteBytes(key[:], false)
+}
+
+func decodeOptionalPubkeyFrom(decoder *ag_binary.Decoder) (*ag_solanago.PublicKey, error) {
+	v, err := decoder.ReadNBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOptionalPubkey(v)
+}
+
+// encodeCOptionPubkey encodes a COption<Pubkey> as packed by the transfer fee
+// instructions: a single zero byte for none, or a one byte followed by the key.
+func encodeCOptionPubkey(encoder *ag_binary.Encoder, key *ag_solanago.PublicKey) error {
+	if key == nil {
+		return encoder.WriteUint8(0)
+	}
+	if err := encoder.WriteUint8(1); err != nil {
+		return err
+	}
+	return encoder.WriteBytes(key[:], false)
+}
+
+func decodeCOptionPubkeyFrom(decoder *ag_binary.Decoder) (*ag_solanago.PublicKey, error) {
+	flag, err := decoder.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	if flag == 0 {
+		return nil, nil
+	}
+	v, err := decoder.ReadNBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	key := ag_solanago.PublicKeyFromBytes(v)
+	return &key, nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token2022
+
+import (
+	"encoding/hex"
+	"testing"
+
+	ag_solanago "github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstructionData(t *testing.T) {
+	mint := ag_solanago.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+
+	tests := []struct {
+		name    string
+		inst    *Instruction
+		hexData string
+	}{
+		{
+			name: "TransferCheckedWithFee",
+			inst: NewTransferCheckedWithFeeInstruction(
+				1000, 6, 5,
+				ag_solanago.NewWallet().PublicKey(), mint, ag_solanago.NewWallet().PublicKey(), ag_solanago.NewWallet().PublicKey(), nil,
+			).Build(),
+			hexData: "1a01e80300000000000006" + "0500000000000000",
+		},
+		{
+			name:    "SetTransferFee",
+			inst:    NewSetTransferFeeInstruction(50, 1000, mint, ag_solanago.NewWallet().PublicKey(), nil).Build(),
+			hexData: "1a053200" + "e803000000000000",
+		},
+		{
+			name:    "InitializeTransferFeeConfig",
+			inst:    NewInitializeTransferFeeConfigInstructionBuilder().SetTransferFeeBasisPoints(50).SetMaximumFee(1000).SetMintAccount(mint).Build(),
+			hexData: "1a0000003200" + "e803000000000000",
+		},
+		{
+			name:    "HarvestWithheldTokensToMint",
+			inst:    NewHarvestWithheldTokensToMintInstruction(mint, []ag_solanago.PublicKey{ag_solanago.NewWallet().PublicKey()}).Build(),
+			hexData: "1a04",
+		},
+		{
+			name:    "WithdrawWithheldTokensFromMint",
+			inst:    NewWithdrawWithheldTokensFromMintInstruction(mint, ag_solanago.NewWallet().PublicKey(), ag_solanago.NewWallet().PublicKey(), nil).Build(),
+			hexData: "1a02",
+		},
+		{
+			name: "WithdrawWithheldTokensFromAccounts",
+			inst: NewWithdrawWithheldTokensFromAccountsInstruction(
+				mint, ag_solanago.NewWallet().PublicKey(), ag_solanago.NewWallet().PublicKey(),
+				[]ag_solanago.PublicKey{ag_solanago.NewWallet().PublicKey(), ag_solanago.NewWallet().PublicKey()},
+				[]ag_solanago.PublicKey{ag_solanago.NewWallet().PublicKey()},
+			).Build(),
+			hexData: "1a0301",
+		},
+		{
+			name: "TransferChecked",
+			inst: &Instruction{BaseVariant: token.NewTransferCheckedInstruction(
+				1000, 6,
+				ag_solanago.NewWallet().PublicKey(), mint, ag_solanago.NewWallet().PublicKey(), ag_solanago.NewWallet().PublicKey(), nil,
+			).Build().BaseVariant},
+			hexData: "0ce803000000000000" + "06",
+		},
+		{
+			name:    "InitializePermanentDelegate",
+			inst:    NewInitializePermanentDelegateInstruction(mint, mint).Build(),
+			hexData: "23" + hex.EncodeToString(mint[:]),
+		},
+		{
+			name:    "InitializeMetadataPointer",
+			inst:    NewInitializeMetadataPointerInstructionBuilder().SetMetadataAddress(mint).SetMintAccount(mint).Build(),
+			hexData: "2700" + hex.EncodeToString(make([]byte, 32)) + hex.EncodeToString(mint[:]),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := test.inst.Data()
+			require.NoError(t, err)
+			require.Equal(t, test.hexData, hex.EncodeToString(data))
+
+			decoded, err := DecodeInstruction(test.inst.Accounts(), data)
+			require.NoError(t, err)
+			require.Equal(t, test.name, InstructionIDToName(decoded.TypeID))
+			redata, err := decoded.Data()
+			require.NoError(t, err)
+			require.Equal(t, data, redata)
+		})
+	}
+}
+
+func TestDecodeTransactionInstructions(t *testing.T) {
+	payer := ag_solanago.NewWallet().PublicKey()
+	mint := ag_solanago.NewWallet().PublicKey()
+	source := ag_solanago.NewWallet().PublicKey()
+	destination := ag_solanago.NewWallet().PublicKey()
+
+	transfer := token.NewTransferCheckedInstruction(1000, 6, source, mint, destination, payer, nil).Build()
+	data, err := transfer.Data()
+	require.NoError(t, err)
+	tx, err := ag_solanago.NewTransaction(
+		[]ag_solanago.Instruction{
+			ag_solanago.NewInstruction(ProgramID, transfer.Accounts(), data),
+			NewWithdrawWithheldTokensFromAccountsInstruction(mint, destination, payer, nil, []ag_solanago.PublicKey{source}).Build(),
+		},
+		ag_solanago.Hash{},
+		ag_solanago.TransactionPayer(payer),
+	)
+	require.NoError(t, err)
+
+	decoded, err := ag_solanago.DecodeTransactionInstructions(tx)
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+
+	require.NoError(t, decoded[0].Err)
+	inst := decoded[0].Instruction.(*Instruction)
+	require.Equal(t, "TransferChecked", InstructionIDToName(inst.TypeID))
+	checked := inst.Impl.(*token.TransferChecked)
+	require.Equal(t, uint64(1000), *checked.Amount)
+	require.Equal(t, uint8(6), *checked.Decimals)
+	require.Equal(t, destination, checked.GetDestinationAccount().PublicKey)
+
+	require.NoError(t, decoded[1].Err)
+	withdraw := decoded[1].Instruction.(*Instruction).Impl.(*WithdrawWithheldTokensFromAccounts)
+	require.Equal(t, uint8(1), *withdraw.NumTokenAccounts)
+	require.Empty(t, withdraw.Signers)
+	require.Equal(t, source, withdraw.Sources[0].PublicKey)
+}
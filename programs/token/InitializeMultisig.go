@@ -156,6 +156,9 @@ func (inst *InitializeMultisig) Validate() error {
 		if len(inst.Signers) > MAX_SIGNERS {
 			return fmt.Errorf("too many signers; got %v, but max is 11", len(inst.Signers))
 		}
+		if *inst.M == 0 || int(*inst.M) > len(inst.Signers) {
+			return fmt.Errorf("M must be between 1 and the number of signers (%v), got %v", len(inst.Signers), *inst.M)
+		}
 	}
 	return nil
 }
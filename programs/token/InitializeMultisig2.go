@@ -123,6 +123,9 @@ func (inst *InitializeMultisig2) Validate() error {
 		if len(inst.Signers) > MAX_SIGNERS {
 			return fmt.Errorf("too many signers; got %v, but max is 11", len(inst.Signers))
 		}
+		if *inst.M == 0 || int(*inst.M) > len(inst.Signers) {
+			return fmt.Errorf("M must be between 1 and the number of signers (%v), got %v", len(inst.Signers), *inst.M)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,255 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// mintDecimalsOffset is the offset of the decimals in the data of a mint.
+const mintDecimalsOffset = 44
+
+// MintInfo is what a transfer needs to know about a mint.
+type MintInfo struct {
+	Decimals uint8
+	// TokenProgram owning the mint:
+	// solana.TokenProgramID or solana.Token2022ProgramID.
+	TokenProgram solana.PublicKey
+}
+
+// MintCache caches the MintInfo of mints, which don't change
+// once the mints are initialized. It is safe for concurrent use.
+type MintCache struct {
+	lock  sync.RWMutex
+	mints map[solana.PublicKey]MintInfo
+}
+
+// NewMintCache creates a new, empty, MintCache.
+func NewMintCache() *MintCache {
+	return &MintCache{mints: make(map[solana.PublicKey]MintInfo)}
+}
+
+// Get returns the cached MintInfo of mint.
+func (c *MintCache) Get(mint solana.PublicKey) (MintInfo, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	info, ok := c.mints[mint]
+	return info, ok
+}
+
+// Set caches the MintInfo of mint.
+func (c *MintCache) Set(mint solana.PublicKey, info MintInfo) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.mints[mint] = info
+}
+
+// ParseUiAmount converts an amount of tokens accounting for decimals
+// (e.g. "1.5") to a raw amount (e.g. 1500000 for 6 decimals).
+// It fails if the amount has more fractional digits than decimals.
+func ParseUiAmount(uiAmount string, decimals uint8) (uint64, error) {
+	whole, fraction, _ := strings.Cut(uiAmount, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("invalid amount %q", uiAmount)
+	}
+	if len(fraction) > int(decimals) {
+		return 0, fmt.Errorf("amount %q has more than %d decimals", uiAmount, decimals)
+	}
+	digits := whole + fraction + strings.Repeat("0", int(decimals)-len(fraction))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid amount %q", uiAmount)
+		}
+	}
+	amount, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q overflows: max is %d raw units", uiAmount, uint64(math.MaxUint64))
+	}
+	return amount, nil
+}
+
+// TransferOpts configures BuildTokenTransfer.
+type TransferOpts struct {
+	// Payer of the creation of the destination token account,
+	// if it doesn't exist. Defaults to the sender.
+	Payer solana.PublicKey
+
+	// Mints caches the decimals and token program of the mints,
+	// which are otherwise fetched at each transfer.
+	//
+	// This parameter is optional.
+	Mints *MintCache
+
+	// Commitment of the reads of the mint and of the destination account.
+	Commitment rpc.CommitmentType
+
+	// MultisigSigners are the keys signing for the sender when it is
+	// a multisig account (see Multisig.SelectSigners), in which case
+	// the Payer must be set, since a multisig account cannot sign.
+	//
+	// This parameter is optional.
+	MultisigSigners []solana.PublicKey
+}
+
+// BuildTokenTransfer returns the instructions transferring uiAmount tokens
+// of mint (e.g. "1.5", see ParseUiAmount) from the associated token account
+// of from to the associated token account of toOwner, which is created
+// (idempotently) if it doesn't exist yet, with a TransferChecked instruction.
+//
+// The decimals and the token program of the mint (SPL Token or Token-2022)
+// are fetched, along with the destination account, in a single
+// getMultipleAccounts call, unless the mint is in opts.Mints.
+func BuildTokenTransfer(
+	ctx context.Context,
+	rpcCli *rpc.Client,
+	from solana.PublicKey,
+	toOwner solana.PublicKey,
+	mint solana.PublicKey,
+	uiAmount string,
+	opts *TransferOpts, // optional
+) ([]solana.Instruction, error) {
+	if opts == nil {
+		opts = &TransferOpts{}
+	}
+	payer := opts.Payer
+	if payer.IsZero() {
+		if len(opts.MultisigSigners) > 0 {
+			return nil, fmt.Errorf("a payer is required to transfer from the multisig %s", from)
+		}
+		payer = from
+	}
+
+	info, destination, exists, err := fetchTransferAccounts(ctx, rpcCli, toOwner, mint, opts)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := ParseUiAmount(uiAmount, info.Decimals)
+	if err != nil {
+		return nil, err
+	}
+	source, err := associatedtokenaccount.Address(from, mint, info.TokenProgram)
+	if err != nil {
+		return nil, fmt.Errorf("error while FindAssociatedTokenAddress: %w", err)
+	}
+
+	var instructions []solana.Instruction
+	if !exists {
+		create, err := associatedtokenaccount.NewCreateIdempotentInstructionBuilder().
+			SetPayer(payer).
+			SetWallet(toOwner).
+			SetMint(mint).
+			SetTokenProgram(info.TokenProgram).
+			ValidateAndBuild()
+		if err != nil {
+			return nil, err
+		}
+		instructions = append(instructions, create)
+	}
+	transfer, err := NewTransferCheckedInstruction(amount, info.Decimals, source, mint, destination, from, opts.MultisigSigners).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+	if info.TokenProgram.Equals(ProgramID) {
+		return append(instructions, transfer), nil
+	}
+	// The instruction is the same for Token-2022, sent to another program.
+	data, err := transfer.Data()
+	if err != nil {
+		return nil, err
+	}
+	return append(instructions, solana.NewInstruction(info.TokenProgram, transfer.Accounts(), data)), nil
+}
+
+// fetchTransferAccounts returns the MintInfo of mint, and the associated
+// token account of owner along with whether it exists.
+func fetchTransferAccounts(
+	ctx context.Context,
+	rpcCli *rpc.Client,
+	owner solana.PublicKey,
+	mint solana.PublicKey,
+	opts *TransferOpts,
+) (info MintInfo, ata solana.PublicKey, exists bool, err error) {
+	var cached bool
+	if opts.Mints != nil {
+		info, cached = opts.Mints.Get(mint)
+	}
+	// The address of the destination depends on the token program:
+	// fetch the possible addresses along with the mint if it's not cached.
+	programs := []solana.PublicKey{info.TokenProgram}
+	if !cached {
+		programs = []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID}
+	}
+	var accounts []solana.PublicKey
+	if !cached {
+		accounts = append(accounts, mint)
+	}
+	atas := make([]solana.PublicKey, len(programs))
+	for i, program := range programs {
+		atas[i], err = associatedtokenaccount.Address(owner, mint, program)
+		if err != nil {
+			return info, ata, false, fmt.Errorf("error while FindAssociatedTokenAddress: %w", err)
+		}
+	}
+	accounts = append(accounts, atas...)
+
+	offset, length := uint64(mintDecimalsOffset), uint64(1)
+	resp, err := rpcCli.GetMultipleAccountsWithOpts(ctx, accounts, &rpc.GetMultipleAccountsOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: opts.Commitment,
+		DataSlice:  &rpc.DataSlice{Offset: &offset, Length: &length},
+	})
+	if err != nil {
+		return info, ata, false, err
+	}
+	if len(resp.Value) != len(accounts) {
+		return info, ata, false, fmt.Errorf("expected %d accounts, got %d", len(accounts), len(resp.Value))
+	}
+
+	values := resp.Value
+	if !cached {
+		mintAccount := values[0]
+		values = values[1:]
+		if mintAccount == nil {
+			return info, ata, false, fmt.Errorf("mint %s: %w", mint, rpc.ErrNotFound)
+		}
+		info.TokenProgram = mintAccount.Owner
+		switch {
+		case info.TokenProgram.Equals(solana.TokenProgramID):
+		case info.TokenProgram.Equals(solana.Token2022ProgramID):
+			values, atas = values[1:], atas[1:]
+		default:
+			return info, ata, false, fmt.Errorf("account %s is not owned by a token program: owner is %s", mint, info.TokenProgram)
+		}
+		data := mintAccount.Data.GetBinary()
+		if len(data) != 1 {
+			return info, ata, false, errors.New("unable to read the decimals of the mint")
+		}
+		info.Decimals = data[0]
+		if opts.Mints != nil {
+			opts.Mints.Set(mint, info)
+		}
+	}
+	return info, atas[0], values[0] != nil, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"context"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	associatedtokenaccount "github.com/gagliardetto/solana-go/programs/associated-token-account"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUiAmount(t *testing.T) {
+	for _, tt := range []struct {
+		uiAmount string
+		decimals uint8
+		expected uint64
+		err      bool
+	}{
+		{"1.5", 6, 1_500_000, false},
+		{"1", 0, 1, false},
+		{".25", 2, 25, false},
+		{"42.", 3, 42_000, false},
+		{"0.0000001", 6, 0, true},
+		{"-1", 6, 0, true},
+		{"1e6", 6, 0, true},
+		{"", 6, 0, true},
+		{".", 6, 0, true},
+		{"18446744073709.551615", 6, 18446744073709551615, false},
+		{"18446744073709.551616", 6, 0, true},
+	} {
+		amount, err := ParseUiAmount(tt.uiAmount, tt.decimals)
+		if tt.err {
+			require.Error(t, err, tt.uiAmount)
+			continue
+		}
+		require.NoError(t, err, tt.uiAmount)
+		require.Equal(t, tt.expected, amount, tt.uiAmount)
+	}
+}
+
+func TestBuildTokenTransfer(t *testing.T) {
+	from, to := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	mint := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	account := func(owner solana.PublicKey, data []byte) map[string]interface{} {
+		return map[string]interface{}{
+			"data":       []string{base64.StdEncoding.EncodeToString(data), "base64"},
+			"executable": false,
+			"lamports":   1461600,
+			"owner":      owner.String(),
+			"rentEpoch":  0,
+		}
+	}
+
+	server := rpctest.NewServer()
+	defer server.Close()
+	client := rpc.New(server.URL())
+	ctx := context.Background()
+	requested := func(i int) []interface{} {
+		var params []interface{}
+		require.NoError(t, stdjson.Unmarshal(server.Requests("getMultipleAccounts")[i].Params, &params))
+		return params[0].([]interface{})
+	}
+
+	// The mint and the candidate destination accounts are fetched together.
+	server.Handle("getMultipleAccounts", map[string]interface{}{
+		"context": map[string]interface{}{"slot": 1},
+		"value":   []interface{}{account(solana.TokenProgramID, []byte{6}), nil, nil},
+	})
+	mints := NewMintCache()
+	instructions, err := BuildTokenTransfer(ctx, client, from, to, mint, "1.5", &TransferOpts{Mints: mints})
+	require.NoError(t, err)
+	require.Len(t, instructions, 2)
+	require.Len(t, requested(0), 3)
+
+	destination, err := associatedtokenaccount.Address(to, mint, solana.TokenProgramID)
+	require.NoError(t, err)
+	create := instructions[0].(*associatedtokenaccount.Instruction)
+	require.IsType(t, associatedtokenaccount.CreateIdempotent{}, create.Impl)
+	transfer := instructions[1].(*Instruction).Impl.(TransferChecked)
+	require.Equal(t, uint64(1_500_000), *transfer.Amount)
+	require.Equal(t, uint8(6), *transfer.Decimals)
+	require.Equal(t, destination, transfer.GetDestinationAccount().PublicKey)
+	require.Equal(t, from, transfer.GetOwnerAccount().PublicKey)
+
+	info, ok := mints.Get(mint)
+	require.True(t, ok)
+	require.Equal(t, MintInfo{Decimals: 6, TokenProgram: solana.TokenProgramID}, info)
+
+	// With the mint cached, only the existing destination account is fetched.
+	server.Handle("getMultipleAccounts", map[string]interface{}{
+		"context": map[string]interface{}{"slot": 1},
+		"value":   []interface{}{account(solana.TokenProgramID, []byte{0})},
+	})
+	instructions, err = BuildTokenTransfer(ctx, client, from, to, mint, "2", &TransferOpts{Mints: mints})
+	require.NoError(t, err)
+	require.Len(t, instructions, 1)
+	require.Equal(t, []interface{}{destination.String()}, requested(1))
+
+	_, err = BuildTokenTransfer(ctx, client, from, to, mint, "0.0000001", &TransferOpts{Mints: mints})
+	require.Error(t, err)
+
+	// Token-2022 mints are transferred through their program.
+	server.Handle("getMultipleAccounts", map[string]interface{}{
+		"context": map[string]interface{}{"slot": 1},
+		"value":   []interface{}{account(solana.Token2022ProgramID, []byte{2}), nil, account(solana.Token2022ProgramID, []byte{0})},
+	})
+	instructions, err = BuildTokenTransfer(ctx, client, from, to, mint, "3", nil)
+	require.NoError(t, err)
+	require.Len(t, instructions, 1)
+	require.Equal(t, solana.Token2022ProgramID, instructions[0].ProgramID())
+	data, err := instructions[0].Data()
+	require.NoError(t, err)
+	require.Equal(t, []byte{Instruction_TransferChecked, 44, 1, 0, 0, 0, 0, 0, 0, 2}, data)
+	destination2022, err := associatedtokenaccount.Address(to, mint, solana.Token2022ProgramID)
+	require.NoError(t, err)
+	require.Equal(t, destination2022, instructions[0].Accounts()[2].PublicKey)
+}
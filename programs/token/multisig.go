@@ -0,0 +1,148 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// MULTISIG_SIZE is the size of a multisig account,
+// of both the SPL Token and the Token-2022 programs.
+const MULTISIG_SIZE = 355
+
+// Decode decodes and checks the data of a multisig account.
+func (m *Multisig) Decode(data []byte) error {
+	if len(data) != MULTISIG_SIZE {
+		return fmt.Errorf("unable to decode multisig: expected %d bytes, got %d", MULTISIG_SIZE, len(data))
+	}
+	dec := bin.NewBinDecoder(data)
+	if err := dec.Decode(m); err != nil {
+		return fmt.Errorf("unable to decode multisig: %w", err)
+	}
+	if !m.IsInitialized {
+		return errors.New("multisig is not initialized")
+	}
+	if m.N > MAX_SIGNERS || m.M == 0 || m.M > m.N {
+		return fmt.Errorf("invalid multisig: %d of %d signers", m.M, m.N)
+	}
+	return nil
+}
+
+// SignerKeys returns the N signers of the multisig.
+func (m *Multisig) SignerKeys() solana.PublicKeySlice {
+	n := int(m.N)
+	if n > MAX_SIGNERS {
+		n = MAX_SIGNERS
+	}
+	return append(solana.PublicKeySlice(nil), m.Signers[:n]...)
+}
+
+// IsSigner reports whether the key is one of the signers of the multisig.
+func (m *Multisig) IsSigner(key solana.PublicKey) bool {
+	return m.SignerKeys().Contains(key)
+}
+
+// SelectSigners returns M signers of the multisig among the available keys,
+// in the order of the signers of the multisig, to be attached to the
+// token instructions of which the multisig is the authority
+// (e.g. as the multisigSigners of NewTransferCheckedInstruction).
+// The keys that are not signers of the multisig are ignored.
+func (m *Multisig) SelectSigners(available ...solana.PublicKey) (solana.PublicKeySlice, error) {
+	var selected solana.PublicKeySlice
+	for _, signer := range m.SignerKeys() {
+		if len(selected) == int(m.M) {
+			break
+		}
+		if solana.PublicKeySlice(available).Contains(signer) && !selected.Contains(signer) {
+			selected = append(selected, signer)
+		}
+	}
+	if len(selected) < int(m.M) {
+		return nil, fmt.Errorf("multisig requires %d signers, but only %d of the available keys are signers", m.M, len(selected))
+	}
+	return selected, nil
+}
+
+// GetMultisigDecoded fetches and decodes the multisig account;
+// the multisig can belong to the SPL Token or to the Token-2022 program.
+func GetMultisigDecoded(
+	ctx context.Context,
+	rpcCli *rpc.Client,
+	multisig solana.PublicKey,
+	commitment rpc.CommitmentType, // optional
+) (*Multisig, error) {
+	resp, err := rpcCli.GetAccountInfoWithOpts(
+		ctx,
+		multisig,
+		&rpc.GetAccountInfoOpts{
+			Commitment: commitment,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if owner := resp.Value.Owner; !owner.Equals(solana.TokenProgramID) && !owner.Equals(solana.Token2022ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by a token program: owner is %s", multisig, owner)
+	}
+
+	out := new(Multisig)
+	if err := out.Decode(resp.GetBinary()); err != nil {
+		return nil, fmt.Errorf("unable to decode multisig %s: %w", multisig, err)
+	}
+	return out, nil
+}
+
+// NewCreateMultisigInstructions returns the instructions creating the
+// multisig account, funded with lamports (the rent-exempt minimum for
+// MULTISIG_SIZE bytes) by payer, and initializing it with the m of n signers.
+// The multisig account must sign the transaction, as a new account.
+//
+// The account is created for the SPL Token program if tokenProgram is zero.
+func NewCreateMultisigInstructions(
+	lamports uint64,
+	m uint8,
+	payer solana.PublicKey,
+	multisig solana.PublicKey,
+	signers []solana.PublicKey,
+	tokenProgram solana.PublicKey, // optional
+) ([]solana.Instruction, error) {
+	if tokenProgram.IsZero() {
+		tokenProgram = ProgramID
+	}
+	initialize, err := NewInitializeMultisig2Instruction(m, multisig, signers).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+	create, err := system.NewCreateAccountInstruction(lamports, MULTISIG_SIZE, tokenProgram, payer, multisig).ValidateAndBuild()
+	if err != nil {
+		return nil, err
+	}
+	if tokenProgram.Equals(ProgramID) {
+		return []solana.Instruction{create, initialize}, nil
+	}
+	// The instruction is the same for Token-2022, sent to another program.
+	data, err := initialize.Data()
+	if err != nil {
+		return nil, err
+	}
+	return []solana.Instruction{create, solana.NewInstruction(tokenProgram, initialize.Accounts(), data)}, nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMultisig(m uint8, signers ...solana.PublicKey) *Multisig {
+	multisig := &Multisig{M: m, N: uint8(len(signers)), IsInitialized: true}
+	copy(multisig.Signers[:], signers)
+	return multisig
+}
+
+func TestMultisig_Decode(t *testing.T) {
+	a, b, c := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	multisig := newTestMultisig(2, a, b, c)
+	buf := new(bytes.Buffer)
+	require.NoError(t, bin.NewBinEncoder(buf).Encode(multisig))
+	require.Equal(t, MULTISIG_SIZE, buf.Len())
+
+	got := new(Multisig)
+	require.NoError(t, got.Decode(buf.Bytes()))
+	require.Equal(t, multisig, got)
+	require.Equal(t, solana.PublicKeySlice{a, b, c}, got.SignerKeys())
+	require.True(t, got.IsSigner(b))
+	require.False(t, got.IsSigner(solana.PublicKey{}))
+
+	require.Error(t, new(Multisig).Decode(buf.Bytes()[:MINT_SIZE]))
+	require.Error(t, new(Multisig).Decode(make([]byte, MULTISIG_SIZE)), "not initialized")
+	invalid := append([]byte{4}, buf.Bytes()[1:]...)
+	require.Error(t, new(Multisig).Decode(invalid), "M greater than N")
+
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getAccountInfo", accountInfoResult(solana.TokenProgramID, buf.Bytes()))
+	fetched, err := GetMultisigDecoded(context.Background(), rpc.New(server.URL()), solana.NewWallet().PublicKey(), "")
+	require.NoError(t, err)
+	require.Equal(t, multisig, fetched)
+}
+
+func TestMultisig_SelectSigners(t *testing.T) {
+	a, b, c := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	multisig := newTestMultisig(2, a, b, c)
+
+	selected, err := multisig.SelectSigners(solana.NewWallet().PublicKey(), c, a, c, b)
+	require.NoError(t, err)
+	require.Equal(t, solana.PublicKeySlice{a, b}, selected)
+
+	_, err = multisig.SelectSigners(c, c, solana.NewWallet().PublicKey())
+	require.EqualError(t, err, "multisig requires 2 signers, but only 1 of the available keys are signers")
+}
+
+func TestNewCreateMultisigInstructions(t *testing.T) {
+	payer, multisig := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	signers := []solana.PublicKey{solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()}
+
+	instructions, err := NewCreateMultisigInstructions(2_000_000, 2, payer, multisig, signers, solana.PublicKey{})
+	require.NoError(t, err)
+	require.Len(t, instructions, 2)
+	require.Equal(t, solana.SystemProgramID, instructions[0].ProgramID())
+	require.Equal(t, ProgramID, instructions[1].ProgramID())
+	initialize := instructions[1].(*Instruction).Impl.(InitializeMultisig2)
+	require.Equal(t, uint8(2), *initialize.M)
+	require.Equal(t, multisig, initialize.GetAccount().PublicKey)
+
+	instructions, err = NewCreateMultisigInstructions(2_000_000, 1, payer, multisig, signers, solana.Token2022ProgramID)
+	require.NoError(t, err)
+	require.Equal(t, solana.Token2022ProgramID, instructions[1].ProgramID())
+	require.Len(t, instructions[1].Accounts(), 3)
+
+	_, err = NewCreateMultisigInstructions(2_000_000, 3, payer, multisig, signers, solana.PublicKey{})
+	require.Error(t, err, "M greater than the number of signers")
+}
+
+func TestMultisigTransferSigning(t *testing.T) {
+	payer := solana.NewWallet()
+	wallets := []*solana.Wallet{solana.NewWallet(), solana.NewWallet(), solana.NewWallet()}
+	multisig := newTestMultisig(2, wallets[0].PublicKey(), wallets[1].PublicKey(), wallets[2].PublicKey())
+	multisigAddress := solana.NewWallet().PublicKey()
+
+	// Only the second and third signers are available.
+	signers, err := multisig.SelectSigners(wallets[2].PublicKey(), wallets[1].PublicKey())
+	require.NoError(t, err)
+	transfer, err := NewTransferCheckedInstruction(
+		1_000, 6,
+		solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(),
+		multisigAddress, signers,
+	).ValidateAndBuild()
+	require.NoError(t, err)
+
+	tx, err := solana.NewTransaction([]solana.Instruction{transfer}, solana.Hash{1}, solana.TransactionPayer(payer.PublicKey()))
+	require.NoError(t, err)
+	require.Equal(t, solana.PublicKeySlice{payer.PublicKey(), wallets[1].PublicKey(), wallets[2].PublicKey()}, tx.MissingSigners())
+	for _, key := range tx.Message.AccountKeys[:tx.Message.Header.NumRequiredSignatures] {
+		require.NotEqual(t, multisigAddress, key, "the multisig doesn't sign")
+	}
+
+	// Each party signs separately.
+	for _, wallet := range []*solana.Wallet{wallets[1], payer, wallets[2]} {
+		_, err := tx.PartialSign(func(key solana.PublicKey) *solana.PrivateKey {
+			if key.Equals(wallet.PublicKey()) {
+				return &wallet.PrivateKey
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	require.Empty(t, tx.MissingSigners())
+	require.NoError(t, tx.VerifySignatures())
+}
+
+func TestBuildTokenTransfer_multisigRequiresPayer(t *testing.T) {
+	_, err := BuildTokenTransfer(context.Background(), nil,
+		solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), "1",
+		&TransferOpts{MultisigSigners: []solana.PublicKey{solana.NewWallet().PublicKey()}},
+	)
+	require.Error(t, err)
+}
@@ -20,17 +20,19 @@ package token
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 
 	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
 
 const MINT_SIZE = 82
 
 func (mint *Mint) Decode(data []byte) error {
-	mint = new(Mint)
 	dec := bin.NewBinDecoder(data)
-	if err := dec.Decode(&mint); err != nil {
+	if err := dec.Decode(mint); err != nil {
 		return fmt.Errorf("unable to decode mint: %w", err)
 	}
 	return nil
@@ -67,3 +69,154 @@ func FetchMints(ctx context.Context, rpcCli *rpc.Client) (out []*Mint, err error
 	}
 	return
 }
+
+// GetMintDecoded fetches and decodes the mint account;
+// the mint can belong to the SPL Token or to the Token-2022 program,
+// in which case its extensions are ignored.
+func GetMintDecoded(
+	ctx context.Context,
+	rpcCli *rpc.Client,
+	mint solana.PublicKey,
+	commitment rpc.CommitmentType, // optional
+) (*Mint, error) {
+	resp, err := rpcCli.GetAccountInfoWithOpts(
+		ctx,
+		mint,
+		&rpc.GetAccountInfoOpts{
+			Commitment: commitment,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if owner := resp.Value.Owner; !owner.Equals(solana.TokenProgramID) && !owner.Equals(solana.Token2022ProgramID) {
+		return nil, fmt.Errorf("account %s is not owned by a token program: owner is %s", mint, owner)
+	}
+
+	out := new(Mint)
+	if err := out.Decode(resp.GetBinary()); err != nil {
+		return nil, fmt.Errorf("unable to decode mint %s: %w", mint, err)
+	}
+	return out, nil
+}
+
+// TokenAccountBalance is the balance of a token account.
+type TokenAccountBalance struct {
+	// Slot at which the balance was read.
+	Slot uint64
+
+	// Raw amount of tokens, ignoring decimals.
+	Amount uint64
+
+	// Number of decimals of the mint.
+	Decimals uint8
+
+	// Amount of tokens as a string, accounting for decimals.
+	UiAmountString string
+}
+
+// GetTokenAccountBalanceDecoded returns the balance of a token account,
+// with the amount decoded to an integer.
+func GetTokenAccountBalanceDecoded(
+	ctx context.Context,
+	rpcCli *rpc.Client,
+	account solana.PublicKey,
+	commitment rpc.CommitmentType, // optional
+) (*TokenAccountBalance, error) {
+	resp, err := rpcCli.GetTokenAccountBalance(ctx, account, commitment)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Value == nil {
+		return nil, rpc.ErrNotFound
+	}
+	amount, err := strconv.ParseUint(resp.Value.Amount, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode amount %q: %w", resp.Value.Amount, err)
+	}
+	return &TokenAccountBalance{
+		Slot:           resp.Context.Slot,
+		Amount:         amount,
+		Decimals:       resp.Value.Decimals,
+		UiAmountString: resp.Value.UiAmountString,
+	}, nil
+}
+
+// TokenHolder is a wallet holding tokens of a mint.
+type TokenHolder struct {
+	// Owner of the token accounts.
+	Owner solana.PublicKey
+
+	// Token accounts of the owner, among the largest accounts of the mint,
+	// from the largest to the smallest.
+	Accounts []solana.PublicKey
+
+	// Raw amount of tokens held in Accounts, ignoring decimals.
+	Amount uint64
+
+	// Number of decimals of the mint.
+	Decimals uint8
+}
+
+// GetLargestTokenHolders returns the owners of the largest token accounts
+// of the mint (see rpc.Client.GetTokenLargestAccounts), ranked by the amount
+// they hold in those accounts, from the largest to the smallest.
+// The accounts are fetched with a single getMultipleAccounts call,
+// at the slot of the largest accounts or later.
+// The accounts closed in the meantime are skipped.
+func GetLargestTokenHolders(
+	ctx context.Context,
+	rpcCli *rpc.Client,
+	mint solana.PublicKey,
+	commitment rpc.CommitmentType, // optional
+) (holders []*TokenHolder, slot uint64, err error) {
+	largest, err := rpcCli.GetTokenLargestAccounts(ctx, mint, commitment)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(largest.Value) == 0 {
+		return nil, largest.Context.Slot, nil
+	}
+	addresses := make([]solana.PublicKey, len(largest.Value))
+	for i, account := range largest.Value {
+		addresses[i] = account.Address
+	}
+	minContextSlot := largest.Context.Slot
+	resp, err := rpcCli.GetMultipleAccountsWithOpts(ctx, addresses, &rpc.GetMultipleAccountsOpts{
+		Encoding:       solana.EncodingBase64,
+		Commitment:     commitment,
+		MinContextSlot: &minContextSlot,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(resp.Value) != len(addresses) {
+		return nil, 0, fmt.Errorf("expected %d accounts, got %d", len(addresses), len(resp.Value))
+	}
+
+	byOwner := make(map[solana.PublicKey]*TokenHolder)
+	for i, info := range resp.Value {
+		if info == nil {
+			continue
+		}
+		var account Account
+		if err := bin.NewBinDecoder(info.Data.GetBinary()).Decode(&account); err != nil {
+			return nil, 0, fmt.Errorf("unable to decode token account %s: %w", addresses[i], err)
+		}
+		holder, ok := byOwner[account.Owner]
+		if !ok {
+			holder = &TokenHolder{
+				Owner:    account.Owner,
+				Decimals: largest.Value[i].Decimals,
+			}
+			byOwner[account.Owner] = holder
+			holders = append(holders, holder)
+		}
+		holder.Accounts = append(holder.Accounts, addresses[i])
+		holder.Amount += account.Amount
+	}
+	sort.SliceStable(holders, func(i, j int) bool {
+		return holders[i].Amount > holders[j].Amount
+	})
+	return holders, resp.Context.Slot, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func accountInfoResult(owner solana.PublicKey, data []byte) map[string]interface{} {
+	return map[string]interface{}{
+		"context": map[string]interface{}{"slot": 1},
+		"value": map[string]interface{}{
+			"data":       []string{base64.StdEncoding.EncodeToString(data), "base64"},
+			"executable": false,
+			"lamports":   1461600,
+			"owner":      owner.String(),
+			"rentEpoch":  0,
+		},
+	}
+}
+
+func TestGetMintDecoded(t *testing.T) {
+	mint := Mint{
+		MintAuthority: solana.MustPublicKeyFromBase58("Q6XprfkF8RQQKoQVG33xT88H7wi8Uk1B1CC7YAs69Gi").ToPointer(),
+		Supply:        1890000009537801,
+		Decimals:      6,
+		IsInitialized: true,
+	}
+	buf := new(bytes.Buffer)
+	require.NoError(t, bin.NewBinEncoder(buf).Encode(mint))
+	require.Equal(t, MINT_SIZE, buf.Len())
+
+	server := rpctest.NewServer()
+	defer server.Close()
+	client := rpc.New(server.URL())
+	mintAddress := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+
+	{
+		// Token-2022 mints have extensions after the base mint.
+		server.Handle("getAccountInfo", accountInfoResult(solana.Token2022ProgramID, append(buf.Bytes(), make([]byte, 100)...)))
+		got, err := GetMintDecoded(context.Background(), client, mintAddress, rpc.CommitmentConfirmed)
+		require.NoError(t, err)
+		require.Equal(t, &mint, got)
+
+		var params []interface{}
+		require.NoError(t, stdjson.Unmarshal(server.Requests("getAccountInfo")[0].Params, &params))
+		require.Equal(t, mintAddress.String(), params[0])
+		require.Equal(t, "confirmed", params[1].(map[string]interface{})["commitment"])
+	}
+	{
+		server.Handle("getAccountInfo", accountInfoResult(solana.SystemProgramID, buf.Bytes()))
+		_, err := GetMintDecoded(context.Background(), client, mintAddress, "")
+		require.Error(t, err)
+	}
+}
+
+func TestGetTokenAccountBalanceDecoded(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	client := rpc.New(server.URL())
+
+	server.Handle("getTokenAccountBalance", map[string]interface{}{
+		"context": map[string]interface{}{"slot": 1114},
+		"value": map[string]interface{}{
+			"amount":         "18446744073709551615",
+			"decimals":       2,
+			"uiAmount":       184467440737095516.15,
+			"uiAmountString": "184467440737095516.15",
+		},
+	})
+	got, err := GetTokenAccountBalanceDecoded(context.Background(), client, solana.NewWallet().PublicKey(), "")
+	require.NoError(t, err)
+	require.Equal(t,
+		&TokenAccountBalance{
+			Slot:           1114,
+			Amount:         18446744073709551615,
+			Decimals:       2,
+			UiAmountString: "184467440737095516.15",
+		},
+		got,
+	)
+}
+
+func TestGetLargestTokenHolders(t *testing.T) {
+	mint := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	alice, bob := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	accounts := []solana.PublicKey{solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()}
+	tokenAccount := func(owner solana.PublicKey, amount uint64) map[string]interface{} {
+		buf := new(bytes.Buffer)
+		require.NoError(t, bin.NewBinEncoder(buf).Encode(Account{Mint: mint, Owner: owner, Amount: amount, State: Initialized}))
+		return map[string]interface{}{
+			"data":       []string{base64.StdEncoding.EncodeToString(buf.Bytes()), "base64"},
+			"executable": false,
+			"lamports":   2039280,
+			"owner":      solana.TokenProgramID.String(),
+			"rentEpoch":  0,
+		}
+	}
+
+	server := rpctest.NewServer()
+	defer server.Close()
+	client := rpc.New(server.URL())
+	largest := func(address solana.PublicKey, amount string) map[string]interface{} {
+		return map[string]interface{}{"address": address.String(), "amount": amount, "decimals": 6, "uiAmountString": "0"}
+	}
+	server.Handle("getTokenLargestAccounts", map[string]interface{}{
+		"context": map[string]interface{}{"slot": 100},
+		"value": []interface{}{
+			largest(accounts[0], "500"),
+			largest(accounts[1], "400"),
+			largest(accounts[2], "300"),
+			largest(accounts[3], "200"),
+		},
+	})
+	server.Handle("getMultipleAccounts", map[string]interface{}{
+		"context": map[string]interface{}{"slot": 101},
+		"value": []interface{}{
+			tokenAccount(alice, 500),
+			tokenAccount(bob, 400),
+			tokenAccount(bob, 300),
+			nil, // closed
+		},
+	})
+
+	holders, slot, err := GetLargestTokenHolders(context.Background(), client, mint, rpc.CommitmentConfirmed)
+	require.NoError(t, err)
+	require.Equal(t, uint64(101), slot)
+	require.Equal(t, []*TokenHolder{
+		{Owner: bob, Accounts: []solana.PublicKey{accounts[1], accounts[2]}, Amount: 700, Decimals: 6},
+		{Owner: alice, Accounts: []solana.PublicKey{accounts[0]}, Amount: 500, Decimals: 6},
+	}, holders)
+
+	var params []interface{}
+	require.NoError(t, stdjson.Unmarshal(server.Requests("getMultipleAccounts")[0].Params, &params))
+	require.Equal(t, float64(100), params[1].(map[string]interface{})["minContextSlot"])
+}
@@ -0,0 +1,631 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tokenmetadata decodes the accounts of the Metaplex Token Metadata
+// program, derives their addresses, and builds its most common instructions.
+package tokenmetadata
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+var ProgramID solana.PublicKey = solana.TokenMetadataProgramID
+
+func SetProgramID(pubkey solana.PublicKey) {
+	ProgramID = pubkey
+}
+
+const ProgramName = "TokenMetadata"
+
+// Key is the first byte of every account of the program, identifying its type.
+type Key uint8
+
+const (
+	KeyUninitialized Key = iota
+	KeyEditionV1
+	KeyMasterEditionV1
+	KeyReservationListV1
+	KeyMetadataV1
+	KeyReservationListV2
+	KeyMasterEditionV2
+	KeyEditionMarker
+	KeyUseAuthorityRecord
+	KeyCollectionAuthorityRecord
+	KeyTokenOwnedEscrow
+	KeyTokenRecord
+	KeyMetadataDelegate
+	KeyEditionMarkerV2
+	KeyHolderDelegate
+)
+
+// ErrUnexpectedKey is returned when decoding an account
+// whose Key doesn't match the expected account type.
+var ErrUnexpectedKey = errors.New("unexpected account key")
+
+type TokenStandard uint8
+
+const (
+	TokenStandardNonFungible TokenStandard = iota
+	TokenStandardFungibleAsset
+	TokenStandardFungible
+	TokenStandardNonFungibleEdition
+	TokenStandardProgrammableNonFungible
+	TokenStandardProgrammableNonFungibleEdition
+)
+
+type UseMethod uint8
+
+const (
+	UseMethodBurn UseMethod = iota
+	UseMethodMultiple
+	UseMethodSingle
+)
+
+type TokenState uint8
+
+const (
+	TokenStateUnlocked TokenState = iota
+	TokenStateLocked
+	TokenStateListed
+)
+
+type TokenDelegateRole uint8
+
+const (
+	TokenDelegateRoleSale TokenDelegateRole = iota
+	TokenDelegateRoleTransfer
+	TokenDelegateRoleUtility
+	TokenDelegateRoleStaking
+	TokenDelegateRoleStandard
+	TokenDelegateRoleLockedTransfer
+	TokenDelegateRoleMigration
+)
+
+type Creator struct {
+	Address  solana.PublicKey
+	Verified bool
+	// Share is the percentage of the royalties of the creator.
+	Share uint8
+}
+
+type Collection struct {
+	Verified bool
+	Key      solana.PublicKey
+}
+
+type Uses struct {
+	UseMethod UseMethod
+	Remaining uint64
+	Total     uint64
+}
+
+// CollectionDetails is set on the metadata of collection NFTs.
+type CollectionDetails struct {
+	// Size is the number of verified items of a V1 collection;
+	// V2 collections don't track it.
+	Size uint64
+	// V2 is set for collections whose size is not tracked.
+	V2 bool
+}
+
+type ProgrammableConfig struct {
+	RuleSet *solana.PublicKey
+}
+
+// Data is the data of a Metadata account.
+// Name, Symbol and URI are padded with NUL bytes on chain;
+// they are trimmed when decoding.
+type Data struct {
+	Name                 string
+	Symbol               string
+	URI                  string
+	SellerFeeBasisPoints uint16
+	Creators             []Creator // nil if absent
+}
+
+// DataV2 is the data of the CreateMetadataAccountV3
+// and UpdateMetadataAccountV2 instructions.
+type DataV2 struct {
+	Name                 string
+	Symbol               string
+	URI                  string
+	SellerFeeBasisPoints uint16
+	Creators             []Creator   // optional
+	Collection           *Collection // optional
+	Uses                 *Uses       // optional
+}
+
+type Metadata struct {
+	Key                 Key
+	UpdateAuthority     solana.PublicKey
+	Mint                solana.PublicKey
+	Data                Data
+	PrimarySaleHappened bool
+	IsMutable           bool
+	EditionNonce        *uint8
+	TokenStandard       *TokenStandard
+	Collection          *Collection
+	Uses                *Uses
+	CollectionDetails   *CollectionDetails
+	ProgrammableConfig  *ProgrammableConfig
+}
+
+// DecodeMetadata decodes the data of a Metadata account.
+func DecodeMetadata(data []byte) (*Metadata, error) {
+	var out Metadata
+	if err := out.UnmarshalWithDecoder(bin.NewBorshDecoder(data)); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (m *Metadata) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
+	if err = readKey(decoder, &m.Key, KeyMetadataV1); err != nil {
+		return err
+	}
+	if m.UpdateAuthority, err = readPublicKey(decoder); err != nil {
+		return fmt.Errorf("failed to decode UpdateAuthority: %w", err)
+	}
+	if m.Mint, err = readPublicKey(decoder); err != nil {
+		return fmt.Errorf("failed to decode Mint: %w", err)
+	}
+	if err = m.Data.UnmarshalWithDecoder(decoder); err != nil {
+		return fmt.Errorf("failed to decode Data: %w", err)
+	}
+	if m.PrimarySaleHappened, err = decoder.ReadBool(); err != nil {
+		return fmt.Errorf("failed to decode PrimarySaleHappened: %w", err)
+	}
+	if m.IsMutable, err = decoder.ReadBool(); err != nil {
+		return fmt.Errorf("failed to decode IsMutable: %w", err)
+	}
+
+	// The following fields were added over time:
+	// accounts created before may end here.
+	if !decoder.HasRemaining() {
+		return nil
+	}
+	if m.EditionNonce, err = readOptionUint8(decoder); err != nil {
+		return fmt.Errorf("failed to decode EditionNonce: %w", err)
+	}
+	if !decoder.HasRemaining() {
+		return nil
+	}
+	var standard *uint8
+	if standard, err = readOptionUint8(decoder); err != nil {
+		return fmt.Errorf("failed to decode TokenStandard: %w", err)
+	}
+	if standard != nil {
+		m.TokenStandard = (*TokenStandard)(standard)
+	}
+	if !decoder.HasRemaining() {
+		return nil
+	}
+	if m.Collection, err = readOptionCollection(decoder); err != nil {
+		return fmt.Errorf("failed to decode Collection: %w", err)
+	}
+	if !decoder.HasRemaining() {
+		return nil
+	}
+	if m.Uses, err = readOptionUses(decoder); err != nil {
+		return fmt.Errorf("failed to decode Uses: %w", err)
+	}
+	if !decoder.HasRemaining() {
+		return nil
+	}
+	if m.CollectionDetails, err = readOptionCollectionDetails(decoder); err != nil {
+		return fmt.Errorf("failed to decode CollectionDetails: %w", err)
+	}
+	if !decoder.HasRemaining() {
+		return nil
+	}
+	has, err := decoder.ReadOption()
+	if err != nil {
+		return fmt.Errorf("failed to decode ProgrammableConfig: %w", err)
+	}
+	if !has {
+		return nil
+	}
+	variant, err := decoder.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("failed to decode ProgrammableConfig: %w", err)
+	}
+	if variant != 0 {
+		return fmt.Errorf("failed to decode ProgrammableConfig: unknown variant %d", variant)
+	}
+	m.ProgrammableConfig = &ProgrammableConfig{}
+	if m.ProgrammableConfig.RuleSet, err = readOptionPublicKey(decoder); err != nil {
+		return fmt.Errorf("failed to decode ProgrammableConfig: %w", err)
+	}
+	return nil
+}
+
+func (d *Data) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
+	if d.Name, err = readTrimmedString(decoder); err != nil {
+		return fmt.Errorf("name: %w", err)
+	}
+	if d.Symbol, err = readTrimmedString(decoder); err != nil {
+		return fmt.Errorf("symbol: %w", err)
+	}
+	if d.URI, err = readTrimmedString(decoder); err != nil {
+		return fmt.Errorf("uri: %w", err)
+	}
+	if d.SellerFeeBasisPoints, err = decoder.ReadUint16(bin.LE); err != nil {
+		return fmt.Errorf("seller fee basis points: %w", err)
+	}
+	if d.Creators, err = readOptionCreators(decoder); err != nil {
+		return fmt.Errorf("creators: %w", err)
+	}
+	return nil
+}
+
+func (d DataV2) MarshalWithEncoder(encoder *bin.Encoder) error {
+	if err := encoder.WriteString(d.Name); err != nil {
+		return err
+	}
+	if err := encoder.WriteString(d.Symbol); err != nil {
+		return err
+	}
+	if err := encoder.WriteString(d.URI); err != nil {
+		return err
+	}
+	if err := encoder.WriteUint16(d.SellerFeeBasisPoints, bin.LE); err != nil {
+		return err
+	}
+	if err := writeOptionCreators(encoder, d.Creators); err != nil {
+		return err
+	}
+	if err := encoder.WriteOption(d.Collection != nil); err != nil {
+		return err
+	}
+	if d.Collection != nil {
+		if err := encoder.WriteBool(d.Collection.Verified); err != nil {
+			return err
+		}
+		if _, err := encoder.Write(d.Collection.Key[:]); err != nil {
+			return err
+		}
+	}
+	if err := encoder.WriteOption(d.Uses != nil); err != nil {
+		return err
+	}
+	if d.Uses != nil {
+		if err := encoder.WriteUint8(uint8(d.Uses.UseMethod)); err != nil {
+			return err
+		}
+		if err := encoder.WriteUint64(d.Uses.Remaining, bin.LE); err != nil {
+			return err
+		}
+		if err := encoder.WriteUint64(d.Uses.Total, bin.LE); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MasterEdition is the edition account of an original NFT.
+type MasterEdition struct {
+	Key    Key
+	Supply uint64
+	// MaxSupply is the maximum number of printed editions,
+	// or nil if unlimited.
+	MaxSupply *uint64
+}
+
+// DecodeMasterEdition decodes the data of a MasterEditionV2 account.
+func DecodeMasterEdition(data []byte) (*MasterEdition, error) {
+	var out MasterEdition
+	if err := out.UnmarshalWithDecoder(bin.NewBorshDecoder(data)); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (m *MasterEdition) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
+	if err = readKey(decoder, &m.Key, KeyMasterEditionV2); err != nil {
+		return err
+	}
+	if m.Supply, err = decoder.ReadUint64(bin.LE); err != nil {
+		return fmt.Errorf("failed to decode Supply: %w", err)
+	}
+	has, err := decoder.ReadOption()
+	if err != nil {
+		return fmt.Errorf("failed to decode MaxSupply: %w", err)
+	}
+	if has {
+		maxSupply, err := decoder.ReadUint64(bin.LE)
+		if err != nil {
+			return fmt.Errorf("failed to decode MaxSupply: %w", err)
+		}
+		m.MaxSupply = &maxSupply
+	}
+	return nil
+}
+
+// Edition is the edition account of an NFT printed from a master edition.
+type Edition struct {
+	Key Key
+	// Parent is the master edition account the edition was printed from.
+	Parent  solana.PublicKey
+	Edition uint64
+}
+
+// DecodeEdition decodes the data of an EditionV1 account.
+func DecodeEdition(data []byte) (*Edition, error) {
+	var out Edition
+	if err := out.UnmarshalWithDecoder(bin.NewBorshDecoder(data)); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (e *Edition) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
+	if err = readKey(decoder, &e.Key, KeyEditionV1); err != nil {
+		return err
+	}
+	if e.Parent, err = readPublicKey(decoder); err != nil {
+		return fmt.Errorf("failed to decode Parent: %w", err)
+	}
+	if e.Edition, err = decoder.ReadUint64(bin.LE); err != nil {
+		return fmt.Errorf("failed to decode Edition: %w", err)
+	}
+	return nil
+}
+
+// TokenRecord holds the state of a token account
+// of a programmable NFT.
+type TokenRecord struct {
+	Key             Key
+	Bump            uint8
+	State           TokenState
+	RuleSetRevision *uint64
+	Delegate        *solana.PublicKey
+	DelegateRole    *TokenDelegateRole
+	LockedTransfer  *solana.PublicKey
+}
+
+// DecodeTokenRecord decodes the data of a TokenRecord account.
+func DecodeTokenRecord(data []byte) (*TokenRecord, error) {
+	var out TokenRecord
+	if err := out.UnmarshalWithDecoder(bin.NewBorshDecoder(data)); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *TokenRecord) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
+	if err = readKey(decoder, &r.Key, KeyTokenRecord); err != nil {
+		return err
+	}
+	if r.Bump, err = decoder.ReadUint8(); err != nil {
+		return fmt.Errorf("failed to decode Bump: %w", err)
+	}
+	state, err := decoder.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("failed to decode State: %w", err)
+	}
+	r.State = TokenState(state)
+	has, err := decoder.ReadOption()
+	if err != nil {
+		return fmt.Errorf("failed to decode RuleSetRevision: %w", err)
+	}
+	if has {
+		revision, err := decoder.ReadUint64(bin.LE)
+		if err != nil {
+			return fmt.Errorf("failed to decode RuleSetRevision: %w", err)
+		}
+		r.RuleSetRevision = &revision
+	}
+	if r.Delegate, err = readOptionPublicKey(decoder); err != nil {
+		return fmt.Errorf("failed to decode Delegate: %w", err)
+	}
+	role, err := readOptionUint8(decoder)
+	if err != nil {
+		return fmt.Errorf("failed to decode DelegateRole: %w", err)
+	}
+	if role != nil {
+		r.DelegateRole = (*TokenDelegateRole)(role)
+	}
+	if r.LockedTransfer, err = readOptionPublicKey(decoder); err != nil {
+		return fmt.Errorf("failed to decode LockedTransfer: %w", err)
+	}
+	return nil
+}
+
+func readKey(decoder *bin.Decoder, key *Key, expected Key) error {
+	k, err := decoder.ReadUint8()
+	if err != nil {
+		return fmt.Errorf("failed to decode Key: %w", err)
+	}
+	*key = Key(k)
+	if *key != expected {
+		return fmt.Errorf("%w: %d, expected %d", ErrUnexpectedKey, k, expected)
+	}
+	return nil
+}
+
+func readPublicKey(decoder *bin.Decoder) (out solana.PublicKey, err error) {
+	b, err := decoder.ReadNBytes(solana.PublicKeyLength)
+	if err != nil {
+		return out, err
+	}
+	copy(out[:], b)
+	return out, nil
+}
+
+func readTrimmedString(decoder *bin.Decoder) (string, error) {
+	s, err := decoder.ReadString()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(s, "\x00"), nil
+}
+
+func readOptionUint8(decoder *bin.Decoder) (*uint8, error) {
+	has, err := decoder.ReadOption()
+	if err != nil || !has {
+		return nil, err
+	}
+	v, err := decoder.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func readOptionPublicKey(decoder *bin.Decoder) (*solana.PublicKey, error) {
+	has, err := decoder.ReadOption()
+	if err != nil || !has {
+		return nil, err
+	}
+	key, err := readPublicKey(decoder)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func readOptionCreators(decoder *bin.Decoder) ([]Creator, error) {
+	has, err := decoder.ReadOption()
+	if err != nil || !has {
+		return nil, err
+	}
+	n, err := decoder.ReadLength()
+	if err != nil {
+		return nil, err
+	}
+	creators := make([]Creator, 0, n)
+	for i := 0; i < n; i++ {
+		var c Creator
+		if c.Address, err = readPublicKey(decoder); err != nil {
+			return nil, err
+		}
+		if c.Verified, err = decoder.ReadBool(); err != nil {
+			return nil, err
+		}
+		if c.Share, err = decoder.ReadUint8(); err != nil {
+			return nil, err
+		}
+		creators = append(creators, c)
+	}
+	return creators, nil
+}
+
+func writeOptionCreators(encoder *bin.Encoder, creators []Creator) error {
+	if err := encoder.WriteOption(creators != nil); err != nil {
+		return err
+	}
+	if creators == nil {
+		return nil
+	}
+	if err := encoder.WriteLength(len(creators)); err != nil {
+		return err
+	}
+	for _, c := range creators {
+		if _, err := encoder.Write(c.Address[:]); err != nil {
+			return err
+		}
+		if err := encoder.WriteBool(c.Verified); err != nil {
+			return err
+		}
+		if err := encoder.WriteUint8(c.Share); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readOptionCollection(decoder *bin.Decoder) (*Collection, error) {
+	has, err := decoder.ReadOption()
+	if err != nil || !has {
+		return nil, err
+	}
+	var c Collection
+	if c.Verified, err = decoder.ReadBool(); err != nil {
+		return nil, err
+	}
+	if c.Key, err = readPublicKey(decoder); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func readOptionUses(decoder *bin.Decoder) (*Uses, error) {
+	has, err := decoder.ReadOption()
+	if err != nil || !has {
+		return nil, err
+	}
+	method, err := decoder.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	u := Uses{UseMethod: UseMethod(method)}
+	if u.Remaining, err = decoder.ReadUint64(bin.LE); err != nil {
+		return nil, err
+	}
+	if u.Total, err = decoder.ReadUint64(bin.LE); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func readOptionCollectionDetails(decoder *bin.Decoder) (*CollectionDetails, error) {
+	has, err := decoder.ReadOption()
+	if err != nil || !has {
+		return nil, err
+	}
+	variant, err := decoder.ReadUint8()
+	if err != nil {
+		return nil, err
+	}
+	switch variant {
+	case 0:
+		size, err := decoder.ReadUint64(bin.LE)
+		if err != nil {
+			return nil, err
+		}
+		return &CollectionDetails{Size: size}, nil
+	case 1:
+		if err := decoder.Discard(8); err != nil {
+			return nil, err
+		}
+		return &CollectionDetails{V2: true}, nil
+	default:
+		return nil, fmt.Errorf("unknown variant %d", variant)
+	}
+}
+
+func writeOptionCollectionDetails(encoder *bin.Encoder, details *CollectionDetails) error {
+	if err := encoder.WriteOption(details != nil); err != nil {
+		return err
+	}
+	if details == nil {
+		return nil
+	}
+	if details.V2 {
+		if err := encoder.WriteUint8(1); err != nil {
+			return err
+		}
+		_, err := encoder.Write(make([]byte, 8))
+		return err
+	}
+	if err := encoder.WriteUint8(0); err != nil {
+		return err
+	}
+	return encoder.WriteUint64(details.Size, bin.LE)
+}
@@ -0,0 +1,127 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenmetadata
+
+import (
+	"bytes"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeMetadata(t *testing.T, updateAuthority, mint, creator, collection solana.PublicKey, full bool) []byte {
+	buf := new(bytes.Buffer)
+	enc := bin.NewBorshEncoder(buf)
+	require.NoError(t, enc.WriteUint8(uint8(KeyMetadataV1)))
+	enc.Write(updateAuthority[:])
+	enc.Write(mint[:])
+	require.NoError(t, enc.WriteString("Name\x00\x00\x00"))
+	require.NoError(t, enc.WriteString("SYM\x00"))
+	require.NoError(t, enc.WriteString("https://example.com\x00\x00"))
+	require.NoError(t, enc.WriteUint16(500, bin.LE))
+	require.NoError(t, writeOptionCreators(enc, []Creator{{Address: creator, Verified: true, Share: 100}}))
+	require.NoError(t, enc.WriteBool(true))  // primary sale happened
+	require.NoError(t, enc.WriteBool(false)) // is mutable
+	if !full {
+		return buf.Bytes()
+	}
+	enc.Write([]byte{1, 254})                                        // edition nonce
+	enc.Write([]byte{1, byte(TokenStandardProgrammableNonFungible)}) // token standard
+	enc.Write([]byte{1, 1})                                          // collection, verified
+	enc.Write(collection[:])
+	enc.Write([]byte{0}) // uses
+	require.NoError(t, writeOptionCollectionDetails(enc, &CollectionDetails{Size: 7}))
+	enc.Write([]byte{1, 0, 0})  // programmable config, no rule set
+	enc.Write(make([]byte, 16)) // padding
+	return buf.Bytes()
+}
+
+func TestDecodeMetadata(t *testing.T) {
+	updateAuthority, mint := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	creator, collection := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+
+	metadata, err := DecodeMetadata(encodeMetadata(t, updateAuthority, mint, creator, collection, true))
+	require.NoError(t, err)
+	require.Equal(t, updateAuthority, metadata.UpdateAuthority)
+	require.Equal(t, mint, metadata.Mint)
+	require.Equal(t, Data{
+		Name:                 "Name",
+		Symbol:               "SYM",
+		URI:                  "https://example.com",
+		SellerFeeBasisPoints: 500,
+		Creators:             []Creator{{Address: creator, Verified: true, Share: 100}},
+	}, metadata.Data)
+	require.True(t, metadata.PrimarySaleHappened)
+	require.False(t, metadata.IsMutable)
+	require.EqualValues(t, 254, *metadata.EditionNonce)
+	require.Equal(t, TokenStandardProgrammableNonFungible, *metadata.TokenStandard)
+	require.Equal(t, &Collection{Verified: true, Key: collection}, metadata.Collection)
+	require.Nil(t, metadata.Uses)
+	require.Equal(t, &CollectionDetails{Size: 7}, metadata.CollectionDetails)
+	require.Equal(t, &ProgrammableConfig{}, metadata.ProgrammableConfig)
+
+	// Accounts created before the optional fields were added.
+	metadata, err = DecodeMetadata(encodeMetadata(t, updateAuthority, mint, creator, collection, false))
+	require.NoError(t, err)
+	require.Equal(t, "Name", metadata.Data.Name)
+	require.Nil(t, metadata.EditionNonce)
+	require.Nil(t, metadata.TokenStandard)
+
+	_, err = DecodeMasterEdition(encodeMetadata(t, updateAuthority, mint, creator, collection, false))
+	require.ErrorIs(t, err, ErrUnexpectedKey)
+}
+
+func TestDecodeMasterEdition(t *testing.T) {
+	data := []byte{byte(KeyMasterEditionV2), 3, 0, 0, 0, 0, 0, 0, 0, 1, 10, 0, 0, 0, 0, 0, 0, 0}
+	edition, err := DecodeMasterEdition(data)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, edition.Supply)
+	require.EqualValues(t, 10, *edition.MaxSupply)
+
+	edition, err = DecodeMasterEdition([]byte{byte(KeyMasterEditionV2), 0, 0, 0, 0, 0, 0, 0, 0, 0})
+	require.NoError(t, err)
+	require.Nil(t, edition.MaxSupply)
+}
+
+func TestDecodeTokenRecord(t *testing.T) {
+	delegate := solana.NewWallet().PublicKey()
+	data := []byte{byte(KeyTokenRecord), 255, byte(TokenStateLocked), 0, 1}
+	data = append(data, delegate[:]...)
+	data = append(data, 1, byte(TokenDelegateRoleStaking), 0)
+
+	record, err := DecodeTokenRecord(data)
+	require.NoError(t, err)
+	require.EqualValues(t, 255, record.Bump)
+	require.Equal(t, TokenStateLocked, record.State)
+	require.Nil(t, record.RuleSetRevision)
+	require.Equal(t, &delegate, record.Delegate)
+	require.Equal(t, TokenDelegateRoleStaking, *record.DelegateRole)
+	require.Nil(t, record.LockedTransfer)
+}
+
+func TestFindMetadataAddress(t *testing.T) {
+	mint := solana.NewWallet().PublicKey()
+	got, _, err := FindMetadataAddress(mint)
+	require.NoError(t, err)
+	want, _, err := solana.FindTokenMetadataAddress(mint)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	edition, _, err := FindEditionAddress(mint)
+	require.NoError(t, err)
+	require.NotEqual(t, got, edition)
+}
@@ -0,0 +1,190 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenmetadata
+
+import (
+	"bytes"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+// Discriminators of the instructions of the program.
+const (
+	Instruction_UpdateMetadataAccountV2 uint8 = 15
+	Instruction_VerifyCollection        uint8 = 18
+	Instruction_CreateMetadataAccountV3 uint8 = 33
+)
+
+// CreateMetadataAccountV3Args are the arguments of the CreateMetadataAccountV3 instruction.
+type CreateMetadataAccountV3Args struct {
+	Data      DataV2
+	IsMutable bool
+	// CollectionDetails is set to create the metadata of a collection NFT.
+	//
+	// This parameter is optional.
+	CollectionDetails *CollectionDetails
+}
+
+// NewCreateMetadataAccountV3Instruction creates the Metadata account of the mint.
+// The update authority must sign if it is a verified creator.
+func NewCreateMetadataAccountV3Instruction(
+	args CreateMetadataAccountV3Args,
+	mint solana.PublicKey,
+	mintAuthority solana.PublicKey,
+	payer solana.PublicKey,
+	updateAuthority solana.PublicKey,
+	updateAuthorityIsSigner bool,
+) (solana.Instruction, error) {
+	metadata, _, err := FindMetadataAddress(mint)
+	if err != nil {
+		return nil, fmt.Errorf("find metadata address: %w", err)
+	}
+	buf := new(bytes.Buffer)
+	encoder := bin.NewBorshEncoder(buf)
+	if err := encoder.WriteUint8(Instruction_CreateMetadataAccountV3); err != nil {
+		return nil, err
+	}
+	if err := args.Data.MarshalWithEncoder(encoder); err != nil {
+		return nil, fmt.Errorf("encode data: %w", err)
+	}
+	if err := encoder.WriteBool(args.IsMutable); err != nil {
+		return nil, err
+	}
+	if err := writeOptionCollectionDetails(encoder, args.CollectionDetails); err != nil {
+		return nil, fmt.Errorf("encode collection details: %w", err)
+	}
+
+	updateAuthorityMeta := solana.Meta(updateAuthority)
+	if updateAuthorityIsSigner {
+		updateAuthorityMeta.SIGNER()
+	}
+	return solana.NewInstruction(
+		ProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(metadata).WRITE(),
+			solana.Meta(mint),
+			solana.Meta(mintAuthority).SIGNER(),
+			solana.Meta(payer).WRITE().SIGNER(),
+			updateAuthorityMeta,
+			solana.Meta(solana.SystemProgramID),
+		},
+		buf.Bytes(),
+	), nil
+}
+
+// UpdateMetadataAccountV2Args are the arguments of the UpdateMetadataAccountV2
+// instruction; the fields left nil are not updated.
+type UpdateMetadataAccountV2Args struct {
+	Data                *DataV2
+	NewUpdateAuthority  *solana.PublicKey
+	PrimarySaleHappened *bool
+	IsMutable           *bool
+}
+
+// NewUpdateMetadataAccountV2Instruction updates the Metadata account of the mint.
+func NewUpdateMetadataAccountV2Instruction(
+	args UpdateMetadataAccountV2Args,
+	mint solana.PublicKey,
+	updateAuthority solana.PublicKey,
+) (solana.Instruction, error) {
+	metadata, _, err := FindMetadataAddress(mint)
+	if err != nil {
+		return nil, fmt.Errorf("find metadata address: %w", err)
+	}
+	buf := new(bytes.Buffer)
+	encoder := bin.NewBorshEncoder(buf)
+	if err := encoder.WriteUint8(Instruction_UpdateMetadataAccountV2); err != nil {
+		return nil, err
+	}
+	if err := encoder.WriteOption(args.Data != nil); err != nil {
+		return nil, err
+	}
+	if args.Data != nil {
+		if err := args.Data.MarshalWithEncoder(encoder); err != nil {
+			return nil, fmt.Errorf("encode data: %w", err)
+		}
+	}
+	if err := encoder.WriteOption(args.NewUpdateAuthority != nil); err != nil {
+		return nil, err
+	}
+	if args.NewUpdateAuthority != nil {
+		if _, err := encoder.Write(args.NewUpdateAuthority[:]); err != nil {
+			return nil, err
+		}
+	}
+	for _, flag := range []*bool{args.PrimarySaleHappened, args.IsMutable} {
+		if err := encoder.WriteOption(flag != nil); err != nil {
+			return nil, err
+		}
+		if flag != nil {
+			if err := encoder.WriteBool(*flag); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return solana.NewInstruction(
+		ProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(metadata).WRITE(),
+			solana.Meta(updateAuthority).SIGNER(),
+		},
+		buf.Bytes(),
+	), nil
+}
+
+// NewVerifyCollectionInstruction verifies that the NFT of the mint is part
+// of the collection of collectionMint. The collection authority is the update
+// authority of the collection, or a delegate holding a collection
+// authority record (see FindCollectionAuthorityRecordAddress),
+// in which case delegated must be set.
+func NewVerifyCollectionInstruction(
+	mint solana.PublicKey,
+	collectionMint solana.PublicKey,
+	collectionAuthority solana.PublicKey,
+	payer solana.PublicKey,
+	delegated bool,
+) (solana.Instruction, error) {
+	metadata, _, err := FindMetadataAddress(mint)
+	if err != nil {
+		return nil, fmt.Errorf("find metadata address: %w", err)
+	}
+	collectionMetadata, _, err := FindMetadataAddress(collectionMint)
+	if err != nil {
+		return nil, fmt.Errorf("find collection metadata address: %w", err)
+	}
+	collectionEdition, _, err := FindEditionAddress(collectionMint)
+	if err != nil {
+		return nil, fmt.Errorf("find collection edition address: %w", err)
+	}
+	accounts := solana.AccountMetaSlice{
+		solana.Meta(metadata).WRITE(),
+		solana.Meta(collectionAuthority).WRITE().SIGNER(),
+		solana.Meta(payer).WRITE().SIGNER(),
+		solana.Meta(collectionMint),
+		solana.Meta(collectionMetadata),
+		solana.Meta(collectionEdition),
+	}
+	if delegated {
+		record, _, err := FindCollectionAuthorityRecordAddress(collectionMint, collectionAuthority)
+		if err != nil {
+			return nil, fmt.Errorf("find collection authority record address: %w", err)
+		}
+		accounts = append(accounts, solana.Meta(record))
+	}
+	return solana.NewInstruction(ProgramID, accounts, []byte{Instruction_VerifyCollection}), nil
+}
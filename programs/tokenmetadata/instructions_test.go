@@ -0,0 +1,83 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenmetadata
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCreateMetadataAccountV3Instruction(t *testing.T) {
+	mint, authority := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	inst, err := NewCreateMetadataAccountV3Instruction(
+		CreateMetadataAccountV3Args{
+			Data:      DataV2{Name: "A", Symbol: "B", URI: "C", SellerFeeBasisPoints: 1},
+			IsMutable: true,
+		},
+		mint, authority, authority, authority, true,
+	)
+	require.NoError(t, err)
+	require.Equal(t, ProgramID, inst.ProgramID())
+
+	data, err := inst.Data()
+	require.NoError(t, err)
+	require.Equal(t, []byte{
+		Instruction_CreateMetadataAccountV3,
+		1, 0, 0, 0, 'A',
+		1, 0, 0, 0, 'B',
+		1, 0, 0, 0, 'C',
+		1, 0, // seller fee basis points
+		0, 0, 0, // creators, collection, uses
+		1, // is mutable
+		0, // collection details
+	}, data)
+
+	metadata, _, err := FindMetadataAddress(mint)
+	require.NoError(t, err)
+	accounts := inst.Accounts()
+	require.Equal(t, metadata, accounts[0].PublicKey)
+	require.True(t, accounts[0].IsWritable)
+	require.True(t, accounts[4].IsSigner)
+}
+
+func TestNewUpdateMetadataAccountV2Instruction(t *testing.T) {
+	isMutable := false
+	inst, err := NewUpdateMetadataAccountV2Instruction(
+		UpdateMetadataAccountV2Args{IsMutable: &isMutable},
+		solana.NewWallet().PublicKey(),
+		solana.NewWallet().PublicKey(),
+	)
+	require.NoError(t, err)
+	data, err := inst.Data()
+	require.NoError(t, err)
+	require.Equal(t, []byte{Instruction_UpdateMetadataAccountV2, 0, 0, 0, 1, 0}, data)
+}
+
+func TestNewVerifyCollectionInstruction(t *testing.T) {
+	mint, collectionMint, authority := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	inst, err := NewVerifyCollectionInstruction(mint, collectionMint, authority, authority, true)
+	require.NoError(t, err)
+	data, err := inst.Data()
+	require.NoError(t, err)
+	require.Equal(t, []byte{Instruction_VerifyCollection}, data)
+
+	record, _, err := FindCollectionAuthorityRecordAddress(collectionMint, authority)
+	require.NoError(t, err)
+	accounts := inst.Accounts()
+	require.Len(t, accounts, 7)
+	require.Equal(t, record, accounts[6].PublicKey)
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenmetadata
+
+import (
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// EditionMarkerBitSize is the number of editions tracked by an edition marker account.
+const EditionMarkerBitSize = 248
+
+func findAddress(mint solana.PublicKey, seeds ...[]byte) (solana.PublicKey, uint8, error) {
+	return solana.FindProgramAddress(
+		append([][]byte{[]byte("metadata"), ProgramID[:], mint[:]}, seeds...),
+		ProgramID,
+	)
+}
+
+// FindMetadataAddress returns the address of the Metadata account of the mint.
+func FindMetadataAddress(mint solana.PublicKey) (solana.PublicKey, uint8, error) {
+	return findAddress(mint)
+}
+
+// FindEditionAddress returns the address of the MasterEdition,
+// or Edition account of the mint.
+func FindEditionAddress(mint solana.PublicKey) (solana.PublicKey, uint8, error) {
+	return findAddress(mint, []byte("edition"))
+}
+
+// FindEditionMarkerAddress returns the address of the edition marker account
+// tracking the printed edition number of the master edition mint.
+func FindEditionMarkerAddress(mint solana.PublicKey, edition uint64) (solana.PublicKey, uint8, error) {
+	return findAddress(mint, []byte("edition"), []byte(strconv.FormatUint(edition/EditionMarkerBitSize, 10)))
+}
+
+// FindTokenRecordAddress returns the address of the TokenRecord account
+// of the token account of a programmable NFT.
+func FindTokenRecordAddress(mint solana.PublicKey, token solana.PublicKey) (solana.PublicKey, uint8, error) {
+	return findAddress(mint, []byte("token_record"), token[:])
+}
+
+// FindCollectionAuthorityRecordAddress returns the address of the record
+// delegating the authority of the collection mint to authority.
+func FindCollectionAuthorityRecordAddress(mint solana.PublicKey, authority solana.PublicKey) (solana.PublicKey, uint8, error) {
+	return findAddress(mint, []byte("collection_authority"), authority[:])
+}
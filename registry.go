@@ -21,7 +21,12 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gagliardetto/solana-go/text"
+	"github.com/gagliardetto/treeout"
 )
 
 var ErrInstructionDecoderNotFound = errors.New("instruction decoder not found")
@@ -101,3 +106,87 @@ func DecodeInstruction(programID PublicKey, accounts []*AccountMeta, data []byte
 	}
 	return decoder(accounts, data)
 }
+
+// DecodedInstruction is an instruction of a transaction,
+// decoded with the decoder registered for its program.
+type DecodedInstruction struct {
+	ProgramID PublicKey
+	Accounts  []*AccountMeta
+	Data      []byte
+
+	// Instruction is the value returned by the decoder of the program
+	// (e.g. *system.Instruction); nil if the instruction could not be decoded.
+	Instruction interface{}
+	// Err is ErrInstructionDecoderNotFound if no decoder is registered
+	// for the program, or the error returned by the decoder.
+	Err error
+}
+
+// String returns a human-readable representation of the instruction.
+// To disable colors, set "github.com/gagliardetto/solana-go/text".DisableColors = true
+func (inst *DecodedInstruction) String() string {
+	tree := treeout.New("")
+	if enToTree, ok := inst.Instruction.(text.EncodableToTree); ok {
+		enToTree.EncodeToTree(tree)
+		return strings.TrimSpace(tree.String())
+	}
+	if inst.Instruction != nil {
+		return spew.Sdump(inst.Instruction)
+	}
+	tree.Child(text.IndigoBG("Program") + ": " + text.Bold("<unknown>") + " " + text.ColorizeBG(inst.ProgramID.String())).
+		ParentFunc(func(programBranch treeout.Branches) {
+			if inst.Err != nil {
+				programBranch.Child(text.RedBG(inst.Err.Error()))
+			}
+			programBranch.Child(text.Sf("data[len=%v bytes]", len(inst.Data)))
+			programBranch.Child(text.Sf("accounts[len=%v]", len(inst.Accounts))).ParentFunc(func(accountsBranch treeout.Branches) {
+				for i := range inst.Accounts {
+					accountsBranch.Child(formatMeta(text.Sf("accounts[%v]", i), inst.Accounts[i]))
+				}
+			})
+		})
+	return strings.TrimSpace(tree.String())
+}
+
+// DecodeTransactionInstructions decodes the instructions of the transaction
+// with the registered decoders. The decoders are registered by importing
+// the program packages (e.g. github.com/gagliardetto/solana-go/programs/system),
+// or github.com/gagliardetto/solana-go/programs/decoders for all the built-in ones.
+//
+// An error is returned only if the accounts of the instructions cannot be
+// resolved (e.g. the address tables of a versioned transaction are not set,
+// see Message.SetAddressTables); instructions that cannot be decoded
+// are returned with their Err set.
+func DecodeTransactionInstructions(tx *Transaction) ([]*DecodedInstruction, error) {
+	metas, err := tx.Message.AccountMetaList()
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve accounts: %w", err)
+	}
+
+	out := make([]*DecodedInstruction, len(tx.Message.Instructions))
+	for i, compiled := range tx.Message.Instructions {
+		programID, err := tx.ResolveProgramIDIndex(compiled.ProgramIDIndex)
+		if err != nil {
+			return nil, fmt.Errorf("instruction %d: %w", i, err)
+		}
+		accounts := make([]*AccountMeta, len(compiled.Accounts))
+		for j, index := range compiled.Accounts {
+			if int(index) >= len(metas) {
+				return nil, fmt.Errorf("instruction %d: account index %d out of range", i, index)
+			}
+			accounts[j] = metas[index]
+		}
+
+		decoded := &DecodedInstruction{
+			ProgramID: programID,
+			Accounts:  accounts,
+			Data:      compiled.Data,
+		}
+		decoded.Instruction, decoded.Err = DecodeInstruction(programID, accounts, compiled.Data)
+		if decoded.Err != nil {
+			decoded.Instruction = nil
+		}
+		out[i] = decoded
+	}
+	return out, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/remotesigner"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Transfers lamports from an account whose key is held by a signing service.
+func main() {
+	endpoint := flag.String("signer", "http://127.0.0.1:8899", "endpoint of the signing service")
+	token := flag.String("token", "", "bearer token of the signing service")
+	from := flag.String("from", "", "account held by the signing service")
+	to := flag.String("to", "", "recipient")
+	flag.Parse()
+
+	client := rpc.New(rpc.DevNet_RPC)
+	signer := remotesigner.New(*endpoint, solana.MustPublicKeyFromBase58(*from), &remotesigner.Opts{
+		Header: remotesigner.BearerHeader(*token),
+	})
+
+	recent, err := client.GetLatestBlockhash(context.TODO(), rpc.CommitmentFinalized)
+	if err != nil {
+		panic(err)
+	}
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(
+				1,
+				signer.PublicKey(),
+				solana.MustPublicKeyFromBase58(*to),
+			).Build(),
+		},
+		recent.Value.Blockhash,
+		solana.TransactionPayer(signer.PublicKey()),
+	)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := tx.SignWith(signer); err != nil {
+		panic(err)
+	}
+
+	sig, err := client.SendTransaction(context.TODO(), tx)
+	if err != nil {
+		panic(err)
+	}
+	spew.Dump(sig)
+}
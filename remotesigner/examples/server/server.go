@@ -0,0 +1,47 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/remotesigner"
+)
+
+// A signing service holding the key of a solana-keygen file.
+func main() {
+	keyFile := flag.String("key", "", "solana-keygen file of the key to hold")
+	addr := flag.String("addr", "127.0.0.1:8899", "address to listen on")
+	token := flag.String("token", "", "bearer token required from the clients")
+	flag.Parse()
+
+	key, err := solana.PrivateKeyFromSolanaKeygenFile(*keyFile)
+	if err != nil {
+		panic(err)
+	}
+	log.Printf("signing for %s on %s", key.PublicKey(), *addr)
+
+	handler := remotesigner.NewHandler([]solana.PrivateKey{key}, &remotesigner.HandlerOpts{
+		AuthToken: *token,
+		Approve: func(publicKey solana.PublicKey, message []byte) error {
+			log.Printf("signing %d bytes for %s", len(message), publicKey)
+			return nil
+		},
+	})
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
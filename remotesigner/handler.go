@@ -0,0 +1,105 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesigner
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+type HandlerOpts struct {
+	// AuthToken, if set, must be provided by clients as a bearer token.
+	AuthToken string
+
+	// Approve, if set, is called before every signature and can reject
+	// the request by returning an error, e.g. to enforce a policy
+	// on the transactions that the service signs.
+	Approve func(publicKey solana.PublicKey, message []byte) error
+}
+
+type handler struct {
+	keys map[solana.PublicKey]solana.PrivateKey
+	opts HandlerOpts
+}
+
+// NewHandler returns the http.Handler of a signing service
+// holding the provided keys.
+func NewHandler(keys []solana.PrivateKey, opts *HandlerOpts) http.Handler {
+	h := &handler{
+		keys: make(map[solana.PublicKey]solana.PrivateKey, len(keys)),
+	}
+	for _, key := range keys {
+		h.keys[key.PublicKey()] = key
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+func (h *handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeResponse(rw, http.StatusMethodNotAllowed, signResponse{Error: "method not allowed"})
+		return
+	}
+	if h.opts.AuthToken != "" {
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.opts.AuthToken)) != 1 {
+			writeResponse(rw, http.StatusUnauthorized, signResponse{Error: "unauthorized"})
+			return
+		}
+	}
+
+	var in signRequest
+	if err := json.NewDecoder(io.LimitReader(req.Body, 1<<20)).Decode(&in); err != nil {
+		writeResponse(rw, http.StatusBadRequest, signResponse{Error: "invalid request: " + err.Error()})
+		return
+	}
+	message, err := base64.StdEncoding.DecodeString(in.Message)
+	if err != nil {
+		writeResponse(rw, http.StatusBadRequest, signResponse{Error: "invalid message: " + err.Error()})
+		return
+	}
+	key, ok := h.keys[in.PublicKey]
+	if !ok {
+		writeResponse(rw, http.StatusNotFound, signResponse{Error: "unknown key " + in.PublicKey.String()})
+		return
+	}
+	if h.opts.Approve != nil {
+		if err := h.opts.Approve(in.PublicKey, message); err != nil {
+			writeResponse(rw, http.StatusForbidden, signResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	signature, err := key.Sign(message)
+	if err != nil {
+		writeResponse(rw, http.StatusInternalServerError, signResponse{Error: err.Error()})
+		return
+	}
+	writeResponse(rw, http.StatusOK, signResponse{Signature: &signature})
+}
+
+func writeResponse(rw http.ResponseWriter, status int, resp signResponse) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	json.NewEncoder(rw).Encode(resp)
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remotesigner implements a solana.Signer whose keys are held
+// by a remote HTTP service, and the handler of such a service, so that
+// applications can sign transactions without loading the keys in process.
+//
+// The protocol is a single POST request carrying the public key of
+// the signer and the base64-encoded message, answered with the
+// base58-encoded signature.
+package remotesigner
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+var DefaultTimeout = 10 * time.Second
+
+type signRequest struct {
+	PublicKey solana.PublicKey `json:"publicKey"`
+	Message   string           `json:"message"`
+}
+
+type signResponse struct {
+	Signature *solana.Signature `json:"signature,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+type Opts struct {
+	// HTTPClient used to reach the signing service.
+	// Defaults to a client with a timeout of DefaultTimeout.
+	HTTPClient *http.Client
+
+	// Header is added to every request, e.g. to authenticate
+	// to the signing service (see BearerHeader).
+	//
+	// This parameter is optional.
+	Header http.Header
+}
+
+// BearerHeader returns a header authenticating with the provided token,
+// as expected by a handler created with HandlerOpts.AuthToken.
+func BearerHeader(token string) http.Header {
+	return http.Header{"Authorization": []string{"Bearer " + token}}
+}
+
+// Signer is a solana.Signer that asks the signing service at endpoint
+// to sign messages with the key of publicKey.
+type Signer struct {
+	endpoint  string
+	publicKey solana.PublicKey
+	client    *http.Client
+	header    http.Header
+}
+
+var _ solana.Signer = &Signer{}
+
+func New(endpoint string, publicKey solana.PublicKey, opts *Opts) *Signer {
+	s := &Signer{
+		endpoint:  endpoint,
+		publicKey: publicKey,
+		client:    &http.Client{Timeout: DefaultTimeout},
+	}
+	if opts != nil {
+		if opts.HTTPClient != nil {
+			s.client = opts.HTTPClient
+		}
+		s.header = opts.Header
+	}
+	return s
+}
+
+func (s *Signer) PublicKey() solana.PublicKey {
+	return s.publicKey
+}
+
+func (s *Signer) Sign(message []byte) (solana.Signature, error) {
+	return s.SignWithContext(context.Background(), message)
+}
+
+// SignWithContext asks the signing service to sign the message,
+// until ctx is done.
+func (s *Signer) SignWithContext(ctx context.Context, message []byte) (solana.Signature, error) {
+	body, err := json.Marshal(signRequest{
+		PublicKey: s.publicKey,
+		Message:   base64.StdEncoding.EncodeToString(message),
+	})
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("remote sign: encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("remote sign: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range s.header {
+		req.Header[key] = values
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("remote sign: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out signResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&out); err != nil {
+		return solana.Signature{}, fmt.Errorf("remote sign: decode response (status %s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return solana.Signature{}, fmt.Errorf("remote sign: %s: %s", resp.Status, out.Error)
+	}
+	if out.Signature == nil || !out.Signature.Verify(s.publicKey, message) {
+		return solana.Signature{}, fmt.Errorf("remote sign: invalid signature for %s", s.publicKey)
+	}
+	return *out.Signature, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesigner
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	payer := solana.NewWallet().PrivateKey
+	other := solana.NewWallet().PrivateKey
+
+	server := httptest.NewServer(NewHandler([]solana.PrivateKey{payer}, &HandlerOpts{
+		AuthToken: "secret",
+		Approve: func(publicKey solana.PublicKey, message []byte) error {
+			if len(message) == 0 {
+				return errors.New("empty message")
+			}
+			return nil
+		},
+	}))
+	defer server.Close()
+
+	signer := New(server.URL, payer.PublicKey(), &Opts{Header: BearerHeader("secret")})
+	require.Equal(t, payer.PublicKey(), signer.PublicKey())
+
+	signature, err := signer.Sign([]byte("hello"))
+	require.NoError(t, err)
+	require.True(t, signature.Verify(payer.PublicKey(), []byte("hello")))
+
+	_, err = signer.Sign(nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "empty message")
+
+	_, err = New(server.URL, payer.PublicKey(), nil).Sign([]byte("hello"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unauthorized")
+
+	_, err = New(server.URL, other.PublicKey(), &Opts{Header: BearerHeader("secret")}).Sign([]byte("hello"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown key")
+
+	// A transaction is signed by a mix of local and remote signers.
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			solana.NewInstruction(
+				solana.SystemProgramID,
+				solana.AccountMetaSlice{
+					solana.Meta(payer.PublicKey()).WRITE().SIGNER(),
+					solana.Meta(other.PublicKey()).WRITE().SIGNER(),
+				},
+				[]byte{0x01},
+			),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	require.NoError(t, err)
+	_, err = tx.SignWith(signer, other)
+	require.NoError(t, err)
+	require.NoError(t, tx.VerifySignatures())
+}
@@ -0,0 +1,347 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analyzer computes the balance changes and the fees of
+// confirmed transactions, from their metadata.
+package analyzer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/mr-tron/base58"
+)
+
+// LamportsPerSignature is the base fee of a transaction, per signature.
+var LamportsPerSignature uint64 = 5000
+
+var ErrNoMeta = errors.New("transaction has no metadata")
+
+// Report is the outcome of a confirmed transaction.
+type Report struct {
+	FeePayer solana.PublicKey
+	// Failed is true if the transaction failed (only the fee was charged).
+	Failed bool
+	Fee    Fee
+
+	// SOL holds the accounts whose balance changed, in the order of the account keys.
+	SOL []BalanceChange
+	// Tokens holds the token accounts whose balance changed, in the order of the account keys.
+	Tokens []TokenBalanceChange
+	// Owners holds the token balance changes aggregated by owner and mint,
+	// sorted by owner then mint. Token accounts of unknown owner are not included.
+	Owners []OwnerTokenChange
+
+	// Created holds the accounts funded by the transaction
+	// (their balance was zero), e.g. with their rent-exempt reserve.
+	Created []BalanceChange
+	// Closed holds the accounts emptied by the transaction,
+	// whose rent-exempt reserve was reclaimed.
+	Closed []BalanceChange
+}
+
+// Fee is the fee charged to the fee payer, split in the base fee
+// (per signature) and the prioritization fee.
+type Fee struct {
+	Total    uint64
+	Base     uint64
+	Priority uint64
+
+	// The compute budget requested by the transaction, if any.
+	ComputeUnitPrice     *uint64
+	ComputeUnitLimit     *uint32
+	ComputeUnitsConsumed *uint64
+}
+
+// BalanceChange is the change of the SOL balance of an account, in lamports.
+type BalanceChange struct {
+	Account solana.PublicKey
+	Pre     uint64
+	Post    uint64
+	Delta   int64
+}
+
+// TokenBalanceChange is the change of the balance of a token account,
+// in raw amounts (ignoring decimals).
+type TokenBalanceChange struct {
+	Account solana.PublicKey
+	// Owner of the token account, or nil if not reported by the node.
+	Owner    *solana.PublicKey
+	Mint     solana.PublicKey
+	Decimals uint8
+	Pre      *big.Int
+	Post     *big.Int
+	Delta    *big.Int
+}
+
+// OwnerTokenChange is the change of the token balance of an owner,
+// across all its token accounts of the mint.
+type OwnerTokenChange struct {
+	Owner    solana.PublicKey
+	Mint     solana.PublicKey
+	Decimals uint8
+	Delta    *big.Int
+}
+
+// AnalyzeResult analyzes a transaction returned by getTransaction.
+func AnalyzeResult(res *rpc.GetTransactionResult) (*Report, error) {
+	if res.Meta == nil {
+		return nil, ErrNoMeta
+	}
+	if res.Transaction == nil {
+		return nil, errors.New("analyze: result has no transaction")
+	}
+	tx, err := res.Transaction.GetTransaction()
+	if err != nil {
+		return nil, fmt.Errorf("analyze: decode transaction: %w", err)
+	}
+	return Analyze(tx, res.Meta)
+}
+
+// AnalyzeNotification analyzes a transaction notified by transactionSubscribe
+// with the base58 or base64 encoding.
+func AnalyzeNotification(res *ws.TransactionResult) (*Report, error) {
+	encoded := res.Transaction.Transaction
+	if len(encoded) != 2 {
+		return nil, errors.New("analyze: notification has no encoded transaction")
+	}
+	var raw []byte
+	var err error
+	switch solana.EncodingType(encoded[1]) {
+	case solana.EncodingBase64:
+		raw, err = base64.StdEncoding.DecodeString(encoded[0])
+	case solana.EncodingBase58:
+		raw, err = base58.Decode(encoded[0])
+	default:
+		return nil, fmt.Errorf("analyze: unsupported transaction encoding: %s", encoded[1])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("analyze: decode transaction: %w", err)
+	}
+	tx, err := solana.TransactionFromBytes(raw)
+	if err != nil {
+		return nil, fmt.Errorf("analyze: decode transaction: %w", err)
+	}
+
+	notified := res.Transaction.Meta
+	meta := &rpc.TransactionMeta{
+		Err:          notified.Err,
+		Fee:          notified.Fee,
+		PreBalances:  notified.PreBalances,
+		PostBalances: notified.PostBalances,
+	}
+	if notified.ComputeUnitsConsumed > 0 {
+		meta.ComputeUnitsConsumed = &notified.ComputeUnitsConsumed
+	}
+	if err := convert(notified.PreTokenBalances, &meta.PreTokenBalances); err != nil {
+		return nil, fmt.Errorf("analyze: decode pre token balances: %w", err)
+	}
+	if err := convert(notified.PostTokenBalances, &meta.PostTokenBalances); err != nil {
+		return nil, fmt.Errorf("analyze: decode post token balances: %w", err)
+	}
+	if err := convert(notified.LoadedAddresses.Writable, &meta.LoadedAddresses.Writable); err != nil {
+		return nil, fmt.Errorf("analyze: decode loaded addresses: %w", err)
+	}
+	if err := convert(notified.LoadedAddresses.Readable, &meta.LoadedAddresses.ReadOnly); err != nil {
+		return nil, fmt.Errorf("analyze: decode loaded addresses: %w", err)
+	}
+	return Analyze(tx, meta)
+}
+
+// convert converts the loosely typed in into out, through JSON.
+func convert(in interface{}, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// Analyze computes the report of a confirmed transaction from its metadata.
+func Analyze(tx *solana.Transaction, meta *rpc.TransactionMeta) (*Report, error) {
+	if meta == nil {
+		return nil, ErrNoMeta
+	}
+	keys := accountKeys(tx, meta)
+	if len(meta.PreBalances) != len(keys) || len(meta.PostBalances) != len(keys) {
+		return nil, fmt.Errorf("analyze: %d account keys but %d pre and %d post balances",
+			len(keys), len(meta.PreBalances), len(meta.PostBalances))
+	}
+
+	report := &Report{
+		Failed: meta.Err != nil,
+		Fee:    fee(tx, meta),
+	}
+	if len(keys) > 0 {
+		report.FeePayer = keys[0]
+	}
+
+	for i, key := range keys {
+		pre, post := meta.PreBalances[i], meta.PostBalances[i]
+		if pre == post {
+			continue
+		}
+		change := BalanceChange{Account: key, Pre: pre, Post: post, Delta: int64(post) - int64(pre)}
+		report.SOL = append(report.SOL, change)
+		if pre == 0 {
+			report.Created = append(report.Created, change)
+		}
+		if post == 0 {
+			report.Closed = append(report.Closed, change)
+		}
+	}
+
+	tokens, err := tokenChanges(keys, meta)
+	if err != nil {
+		return nil, err
+	}
+	report.Tokens = tokens
+	report.Owners = ownerChanges(tokens)
+	return report, nil
+}
+
+// accountKeys returns the static account keys of tx followed
+// by the writable and readonly keys loaded from address tables.
+func accountKeys(tx *solana.Transaction, meta *rpc.TransactionMeta) solana.PublicKeySlice {
+	static := tx.Message.AccountKeys
+	if tx.Message.IsResolved() {
+		static = static[:len(static)-tx.Message.NumLookups()]
+	}
+	keys := make(solana.PublicKeySlice, 0, len(static)+len(meta.LoadedAddresses.Writable)+len(meta.LoadedAddresses.ReadOnly))
+	keys = append(keys, static...)
+	keys = append(keys, meta.LoadedAddresses.Writable...)
+	return append(keys, meta.LoadedAddresses.ReadOnly...)
+}
+
+func fee(tx *solana.Transaction, meta *rpc.TransactionMeta) Fee {
+	f := Fee{
+		Total:                meta.Fee,
+		Base:                 LamportsPerSignature * uint64(tx.Message.Header.NumRequiredSignatures),
+		ComputeUnitsConsumed: meta.ComputeUnitsConsumed,
+	}
+	if f.Base > f.Total {
+		f.Base = f.Total
+	}
+	f.Priority = f.Total - f.Base
+	if budget, err := computebudget.ParseTransaction(tx); err == nil {
+		f.ComputeUnitPrice = budget.ComputeUnitPrice
+		f.ComputeUnitLimit = budget.ComputeUnitLimit
+	}
+	return f
+}
+
+func tokenChanges(keys solana.PublicKeySlice, meta *rpc.TransactionMeta) ([]TokenBalanceChange, error) {
+	byIndex := map[uint16]*TokenBalanceChange{}
+	record := func(balance rpc.TokenBalance, post bool) error {
+		if int(balance.AccountIndex) >= len(keys) {
+			return fmt.Errorf("analyze: token balance of account %d out of %d", balance.AccountIndex, len(keys))
+		}
+		change, ok := byIndex[balance.AccountIndex]
+		if !ok {
+			change = &TokenBalanceChange{
+				Account: keys[balance.AccountIndex],
+				Mint:    balance.Mint,
+				Pre:     new(big.Int),
+				Post:    new(big.Int),
+			}
+			byIndex[balance.AccountIndex] = change
+		}
+		if balance.Owner != nil {
+			change.Owner = balance.Owner
+		}
+		amount := new(big.Int)
+		if balance.UiTokenAmount != nil {
+			change.Decimals = balance.UiTokenAmount.Decimals
+			if _, ok := amount.SetString(balance.UiTokenAmount.Amount, 10); !ok {
+				return fmt.Errorf("analyze: invalid token amount %q of account %s", balance.UiTokenAmount.Amount, change.Account)
+			}
+		}
+		if post {
+			change.Post = amount
+		} else {
+			change.Pre = amount
+		}
+		return nil
+	}
+	for _, balance := range meta.PreTokenBalances {
+		if err := record(balance, false); err != nil {
+			return nil, err
+		}
+	}
+	for _, balance := range meta.PostTokenBalances {
+		if err := record(balance, true); err != nil {
+			return nil, err
+		}
+	}
+
+	indexes := make([]int, 0, len(byIndex))
+	for index := range byIndex {
+		indexes = append(indexes, int(index))
+	}
+	sort.Ints(indexes)
+	var out []TokenBalanceChange
+	for _, index := range indexes {
+		change := byIndex[uint16(index)]
+		change.Delta = new(big.Int).Sub(change.Post, change.Pre)
+		if change.Delta.Sign() != 0 {
+			out = append(out, *change)
+		}
+	}
+	return out, nil
+}
+
+func ownerChanges(tokens []TokenBalanceChange) []OwnerTokenChange {
+	type ownerMint struct {
+		owner, mint solana.PublicKey
+	}
+	byOwnerMint := map[ownerMint]*OwnerTokenChange{}
+	for _, token := range tokens {
+		if token.Owner == nil {
+			continue
+		}
+		key := ownerMint{*token.Owner, token.Mint}
+		change, ok := byOwnerMint[key]
+		if !ok {
+			change = &OwnerTokenChange{Owner: key.owner, Mint: key.mint, Decimals: token.Decimals, Delta: new(big.Int)}
+			byOwnerMint[key] = change
+		}
+		change.Delta.Add(change.Delta, token.Delta)
+	}
+
+	out := make([]OwnerTokenChange, 0, len(byOwnerMint))
+	for _, change := range byOwnerMint {
+		if change.Delta.Sign() != 0 {
+			out = append(out, *change)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if c := bytes.Compare(out[i].Owner[:], out[j].Owner[:]); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(out[i].Mint[:], out[j].Mint[:]) < 0
+	})
+	return out
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyzer
+
+import (
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	created := solana.NewWallet().PublicKey()
+	source := solana.NewWallet().PublicKey()
+	destination := solana.NewWallet().PublicKey()
+	program := solana.NewWallet().PublicKey()
+	alice := solana.NewWallet().PublicKey()
+	bob := solana.NewWallet().PublicKey()
+	mint := solana.NewWallet().PublicKey()
+
+	tx, err := solana.NewTransaction([]solana.Instruction{
+		computebudget.NewSetComputeUnitPriceInstruction(10_000).Build(),
+		solana.NewInstruction(program, solana.AccountMetaSlice{
+			solana.Meta(created).WRITE(),
+			solana.Meta(source).WRITE(),
+			solana.Meta(destination).WRITE(),
+		}, []byte{1}),
+	}, solana.Hash{1}, solana.TransactionPayer(payer))
+	require.NoError(t, err)
+	keys := tx.Message.AccountKeys
+	index := func(key solana.PublicKey) uint16 {
+		i, err := tx.Message.GetAccountIndex(key)
+		require.NoError(t, err)
+		return i
+	}
+
+	balances := func(values map[solana.PublicKey]uint64) []uint64 {
+		out := make([]uint64, len(keys))
+		for i, key := range keys {
+			out[i] = values[key]
+		}
+		return out
+	}
+	units := uint64(1500)
+	meta := &rpc.TransactionMeta{
+		Fee: 5015,
+		PreBalances: balances(map[solana.PublicKey]uint64{
+			payer: 1_000_000_000, source: 2_039_280, destination: 2_039_280, program: 1,
+		}),
+		PostBalances: balances(map[solana.PublicKey]uint64{
+			payer: 1_000_000_000 - 5015 - 890_880, created: 890_880, destination: 4_078_560, program: 1,
+		}),
+		PreTokenBalances: []rpc.TokenBalance{
+			{AccountIndex: index(source), Owner: &alice, Mint: mint, UiTokenAmount: &rpc.UiTokenAmount{Amount: "100", Decimals: 6}},
+			{AccountIndex: index(destination), Owner: &bob, Mint: mint, UiTokenAmount: &rpc.UiTokenAmount{Amount: "5", Decimals: 6}},
+		},
+		PostTokenBalances: []rpc.TokenBalance{
+			{AccountIndex: index(destination), Owner: &bob, Mint: mint, UiTokenAmount: &rpc.UiTokenAmount{Amount: "105", Decimals: 6}},
+		},
+		ComputeUnitsConsumed: &units,
+	}
+
+	report, err := Analyze(tx, meta)
+	require.NoError(t, err)
+
+	assert.Equal(t, payer, report.FeePayer)
+	assert.False(t, report.Failed)
+	assert.Equal(t, uint64(5015), report.Fee.Total)
+	assert.Equal(t, uint64(5000), report.Fee.Base)
+	assert.Equal(t, uint64(15), report.Fee.Priority)
+	require.NotNil(t, report.Fee.ComputeUnitPrice)
+	assert.Equal(t, uint64(10_000), *report.Fee.ComputeUnitPrice)
+	assert.Equal(t, &units, report.Fee.ComputeUnitsConsumed)
+
+	require.Len(t, report.SOL, 4)
+	assert.Equal(t, BalanceChange{Account: payer, Pre: 1_000_000_000, Post: 1_000_000_000 - 5015 - 890_880, Delta: -5015 - 890_880}, report.SOL[0])
+	assert.Equal(t, []BalanceChange{{Account: created, Pre: 0, Post: 890_880, Delta: 890_880}}, report.Created)
+	assert.Equal(t, []BalanceChange{{Account: source, Pre: 2_039_280, Post: 0, Delta: -2_039_280}}, report.Closed)
+
+	require.Len(t, report.Tokens, 2)
+	assert.Equal(t, source, report.Tokens[0].Account)
+	assert.Equal(t, big.NewInt(-100), report.Tokens[0].Delta)
+	assert.Equal(t, big.NewInt(0), report.Tokens[0].Post)
+	assert.Equal(t, big.NewInt(100), report.Tokens[1].Delta)
+
+	require.Len(t, report.Owners, 2)
+	for _, owner := range report.Owners {
+		assert.Equal(t, mint, owner.Mint)
+		assert.Equal(t, uint8(6), owner.Decimals)
+		switch owner.Owner {
+		case alice:
+			assert.Equal(t, big.NewInt(-100), owner.Delta)
+		case bob:
+			assert.Equal(t, big.NewInt(100), owner.Delta)
+		default:
+			t.Errorf("unexpected owner %s", owner.Owner)
+		}
+	}
+
+	t.Run("notification", func(t *testing.T) {
+		raw, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		var res ws.TransactionResult
+		res.Transaction.Transaction = []string{base64.StdEncoding.EncodeToString(raw), "base64"}
+		res.Transaction.Meta.Fee = meta.Fee
+		res.Transaction.Meta.PreBalances = meta.PreBalances
+		res.Transaction.Meta.PostBalances = meta.PostBalances
+		res.Transaction.Meta.PreTokenBalances = []interface{}{
+			map[string]interface{}{"accountIndex": index(source), "owner": alice.String(), "mint": mint.String(), "uiTokenAmount": map[string]interface{}{"amount": "100", "decimals": 6}},
+		}
+
+		report, err := AnalyzeNotification(&res)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(15), report.Fee.Priority)
+		require.Len(t, report.Tokens, 1)
+		assert.Equal(t, big.NewInt(-100), report.Tokens[0].Delta)
+	})
+
+	t.Run("mismatched balances", func(t *testing.T) {
+		_, err := Analyze(tx, &rpc.TransactionMeta{PreBalances: []uint64{1}})
+		require.Error(t, err)
+	})
+}
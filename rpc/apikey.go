@@ -0,0 +1,130 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultAPIKeyParam is the query parameter used to pass the API key
+// when neither Options.APIKeyParam nor Options.APIKeyHeader is set.
+// It matches the parameter expected by Helius.
+const DefaultAPIKeyParam = "api-key"
+
+// APIKeyUsage reports how many requests were sent with an API key.
+type APIKeyUsage struct {
+	Key string
+	// Number of HTTP requests sent with the key, including retries.
+	Requests uint64
+	// Number of those requests rejected with HTTP 429 (Too Many Requests).
+	RateLimited uint64
+}
+
+type apiKeyCounter struct {
+	key         string
+	requests    atomic.Uint64
+	rateLimited atomic.Uint64
+}
+
+// apiKeyTransport injects an API key into each request,
+// rotating between the configured keys in round-robin order.
+type apiKeyTransport struct {
+	base   http.RoundTripper
+	param  string
+	header string
+
+	lock sync.Mutex
+	next int
+	keys []*apiKeyCounter
+}
+
+func newAPIKeyTransport(base http.RoundTripper, opts *Options) *apiKeyTransport {
+	tr := &apiKeyTransport{
+		base:   base,
+		param:  opts.APIKeyParam,
+		header: opts.APIKeyHeader,
+	}
+	if tr.param == "" && tr.header == "" {
+		tr.param = DefaultAPIKeyParam
+	}
+	for _, key := range opts.APIKeys {
+		tr.keys = append(tr.keys, &apiKeyCounter{key: key})
+	}
+	return tr
+}
+
+func (tr *apiKeyTransport) pick() *apiKeyCounter {
+	tr.lock.Lock()
+	defer tr.lock.Unlock()
+	key := tr.keys[tr.next]
+	tr.next = (tr.next + 1) % len(tr.keys)
+	return key
+}
+
+func (tr *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := tr.pick()
+
+	// The request must not be modified, so work on a copy.
+	clone := req.Clone(req.Context())
+	if tr.header != "" {
+		clone.Header.Set(tr.header, key.key)
+	}
+	if tr.param != "" {
+		query := clone.URL.Query()
+		query.Set(tr.param, key.key)
+		clone.URL.RawQuery = query.Encode()
+	}
+
+	key.requests.Add(1)
+	resp, err := tr.base.RoundTrip(clone)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		key.rateLimited.Add(1)
+	}
+	return resp, err
+}
+
+// CloseIdleConnections closes the idle connections of the underlying transport.
+func (tr *apiKeyTransport) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+	if c, ok := tr.base.(closeIdler); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+func (tr *apiKeyTransport) usage() []APIKeyUsage {
+	out := make([]APIKeyUsage, len(tr.keys))
+	for i, key := range tr.keys {
+		out[i] = APIKeyUsage{
+			Key:         key.key,
+			Requests:    key.requests.Load(),
+			RateLimited: key.rateLimited.Load(),
+		}
+	}
+	return out
+}
+
+// APIKeyUsage returns the usage counters of the API keys configured
+// via Options.APIKeys, in the order the keys were provided.
+// It returns nil if the client was not created with API keys.
+func (cl *Client) APIKeyUsage() []APIKeyUsage {
+	if cl.apiKeys == nil {
+		return nil
+	}
+	return cl.apiKeys.usage()
+}
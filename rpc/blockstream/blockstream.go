@@ -0,0 +1,283 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package blockstream streams the blocks of the chain in order and without gaps,
+// starting from any slot: the blocks up to the tip are backfilled with getBlock,
+// and the new blocks are fetched as slotSubscribe (or polling) reports them.
+package blockstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.uber.org/zap"
+)
+
+var (
+	// Number of blocks fetched concurrently.
+	DefaultConcurrency = 4
+
+	// Maximum number of slots listed per getBlocks request.
+	DefaultBatchSize uint64 = 500
+
+	// Interval at which the tip is polled; with a ws client,
+	// new slots are also notified by slotSubscribe.
+	DefaultPollInterval = 2 * time.Second
+
+	// Attempts and delay between attempts to fetch a block
+	// that is not available yet (e.g. reported as skipped by a lagging node).
+	DefaultFetchAttempts = 10
+	DefaultFetchDelay    = 400 * time.Millisecond
+)
+
+type Opts struct {
+	// Commitment of the blocks: rpc.CommitmentConfirmed or rpc.CommitmentFinalized.
+	// Defaults to rpc.CommitmentConfirmed.
+	Commitment rpc.CommitmentType
+
+	// Options of the getBlock requests; their Commitment is ignored.
+	//
+	// This parameter is optional.
+	GetBlockOpts *rpc.GetBlockOpts
+
+	// Number of blocks fetched concurrently.
+	// Defaults to DefaultConcurrency.
+	Concurrency int
+
+	// Maximum number of slots listed per getBlocks request.
+	// Defaults to DefaultBatchSize.
+	BatchSize uint64
+
+	// Interval at which the tip is polled.
+	// Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+
+	// Attempts and delay between attempts to fetch a block that is not available yet.
+	// Default to DefaultFetchAttempts and DefaultFetchDelay.
+	FetchAttempts int
+	FetchDelay    time.Duration
+}
+
+// Block is a block of the stream.
+type Block struct {
+	Slot uint64
+	*rpc.GetBlockResult
+}
+
+// Streamer streams the blocks of the chain.
+type Streamer struct {
+	rpcClient *rpc.Client
+	wsClient  *ws.Client
+	opts      Opts
+}
+
+// New creates a new Streamer. The ws client is used to be notified
+// of new slots, and may be nil, in which case the tip is only polled.
+func New(rpcClient *rpc.Client, wsClient *ws.Client, opts *Opts) *Streamer {
+	s := &Streamer{
+		rpcClient: rpcClient,
+		wsClient:  wsClient,
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.Commitment == "" {
+		s.opts.Commitment = rpc.CommitmentConfirmed
+	}
+	if s.opts.Concurrency <= 0 {
+		s.opts.Concurrency = DefaultConcurrency
+	}
+	if s.opts.BatchSize == 0 {
+		s.opts.BatchSize = DefaultBatchSize
+	}
+	if s.opts.PollInterval <= 0 {
+		s.opts.PollInterval = DefaultPollInterval
+	}
+	if s.opts.FetchAttempts <= 0 {
+		s.opts.FetchAttempts = DefaultFetchAttempts
+	}
+	if s.opts.FetchDelay <= 0 {
+		s.opts.FetchDelay = DefaultFetchDelay
+	}
+	return s
+}
+
+// Run calls fn with every block from startSlot, in slot order and without gaps:
+// the skipped slots, listed by getBlocks, are the only ones without a block.
+// Run returns when ctx is done, when fn returns an error, or when a block
+// cannot be fetched; the slot of the next block to deliver is returned
+// along with the error, to resume the stream.
+func (s *Streamer) Run(ctx context.Context, startSlot uint64, fn func(block *Block) error) (next uint64, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	newSlots := make(chan struct{}, 1)
+	if s.wsClient != nil {
+		sub, err := s.wsClient.SlotSubscribe()
+		if err != nil {
+			return startSlot, fmt.Errorf("blockstream: slot subscribe: %w", err)
+		}
+		defer sub.Unsubscribe()
+		go notifySlots(ctx, sub, newSlots)
+	}
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	next = startSlot
+	for {
+		tip, err := s.rpcClient.GetSlot(ctx, s.opts.Commitment)
+		if err != nil {
+			return next, fmt.Errorf("blockstream: get slot: %w", err)
+		}
+		for next <= tip {
+			end := tip
+			if end-next >= s.opts.BatchSize {
+				end = next + s.opts.BatchSize - 1
+			}
+			slots, err := s.rpcClient.GetBlocks(ctx, next, &end, s.opts.Commitment)
+			if err != nil {
+				return next, fmt.Errorf("blockstream: get blocks from %d to %d: %w", next, end, err)
+			}
+			next, err = s.deliver(ctx, next, slots, fn)
+			if err != nil {
+				return next, err
+			}
+			next = end + 1
+		}
+
+		select {
+		case <-ctx.Done():
+			return next, ctx.Err()
+		case <-newSlots:
+		case <-ticker.C:
+		}
+	}
+}
+
+// notifySlots signals newSlots, without blocking, on every slot notification.
+func notifySlots(ctx context.Context, sub *ws.SlotSubscription, newSlots chan<- struct{}) {
+	for {
+		_, err := sub.RecvWithContext(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				zlog.Warn("slot subscription failed, polling the tip", zap.Error(err))
+			}
+			return
+		}
+		select {
+		case newSlots <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type fetchResult struct {
+	block *Block
+	err   error
+}
+
+// deliver fetches the blocks of slots concurrently, and calls fn with them in order.
+// It returns the slot of the next block to deliver.
+func (s *Streamer) deliver(ctx context.Context, next uint64, slots rpc.BlocksResult, fn func(block *Block) error) (uint64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan fetchResult, len(slots))
+	for i := range results {
+		results[i] = make(chan fetchResult, 1)
+	}
+	go func() {
+		sem := make(chan struct{}, s.opts.Concurrency)
+		for i, slot := range slots {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(i int, slot uint64) {
+				defer func() { <-sem }()
+				block, err := s.fetchBlock(ctx, slot)
+				results[i] <- fetchResult{block: block, err: err}
+			}(i, slot)
+		}
+	}()
+
+	for i, slot := range slots {
+		var result fetchResult
+		select {
+		case result = <-results[i]:
+		case <-ctx.Done():
+			return next, ctx.Err()
+		}
+		if result.err != nil {
+			return slot, fmt.Errorf("blockstream: get block %d: %w", slot, result.err)
+		}
+		if err := fn(result.block); err != nil {
+			return slot, err
+		}
+		next = slot + 1
+	}
+	return next, nil
+}
+
+// fetchBlock fetches the block of slot, retrying while it is not available.
+func (s *Streamer) fetchBlock(ctx context.Context, slot uint64) (*Block, error) {
+	opts := rpc.GetBlockOpts{}
+	if s.opts.GetBlockOpts != nil {
+		opts = *s.opts.GetBlockOpts
+	}
+	opts.Commitment = s.opts.Commitment
+
+	for attempt := 1; ; attempt++ {
+		block, err := s.rpcClient.GetBlockWithOpts(ctx, slot, &opts)
+		if err == nil {
+			return &Block{Slot: slot, GetBlockResult: block}, nil
+		}
+		if attempt >= s.opts.FetchAttempts || !isNotAvailable(err) {
+			return nil, err
+		}
+		zlog.Debug("block not available, retrying",
+			zap.Uint64("slot", slot),
+			zap.Int("attempt", attempt),
+			zap.Error(err),
+		)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.opts.FetchDelay):
+		}
+	}
+}
+
+// isNotAvailable reports whether err means that the block is not available yet
+// on the node that served the request.
+func isNotAvailable(err error) bool {
+	var skipped *rpc.SlotSkippedError
+	if errors.As(err, &skipped) || errors.Is(err, rpc.ErrNotConfirmed) {
+		return true
+	}
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case rpc.ErrorCodeBlockNotAvailable, rpc.ErrorCodeBlockStatusNotAvailableYet:
+			return true
+		}
+	}
+	return false
+}
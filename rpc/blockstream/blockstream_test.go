@@ -0,0 +1,122 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockstream
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/stretchr/testify/require"
+)
+
+var errStop = errors.New("stop")
+
+func TestStreamer(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	// Slots 13 and 16 are skipped.
+	produced := map[uint64]bool{10: true, 11: true, 12: true, 14: true, 15: true, 17: true, 18: true}
+	var tip uint64 = 15
+	server.HandleFunc("getSlot", func(stdjson.RawMessage) (interface{}, error) {
+		return atomic.LoadUint64(&tip), nil
+	})
+	server.HandleFunc("getBlocks", func(params stdjson.RawMessage) (interface{}, error) {
+		var in []interface{}
+		stdjson.Unmarshal(params, &in)
+		out := []uint64{}
+		for slot := uint64(in[0].(float64)); slot <= uint64(in[1].(float64)); slot++ {
+			if produced[slot] {
+				out = append(out, slot)
+			}
+		}
+		return out, nil
+	})
+	var notAvailable int32
+	server.HandleFunc("getBlock", func(params stdjson.RawMessage) (interface{}, error) {
+		var in []interface{}
+		stdjson.Unmarshal(params, &in)
+		slot := uint64(in[0].(float64))
+		// The first attempt to fetch slot 14 fails as if the node was lagging.
+		if slot == 14 && atomic.AddInt32(&notAvailable, 1) == 1 {
+			return nil, &jsonrpc.RPCError{Code: rpc.ErrorCodeSlotSkipped, Message: "Slot 14 was skipped, or missing due to ledger jump to recent snapshot"}
+		}
+		return map[string]interface{}{
+			"blockhash":         "11111111111111111111111111111111",
+			"previousBlockhash": "11111111111111111111111111111111",
+			"parentSlot":        slot - 1,
+			"transactions":      []interface{}{},
+		}, nil
+	})
+
+	wsClient, err := ws.Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	streamer := New(rpc.New(server.URL()), wsClient, &Opts{
+		BatchSize:  2,
+		FetchDelay: time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		// Advance the tip once the backfill is done.
+		server.WaitForSubscription(ctx, "slotSubscribe")
+		atomic.StoreUint64(&tip, 18)
+		server.Notify("slotSubscribe", map[string]interface{}{"parent": 17, "root": 0, "slot": 18})
+	}()
+
+	var got []uint64
+	next, err := streamer.Run(ctx, 11, func(block *Block) error {
+		got = append(got, block.Slot)
+		if block.Slot == 18 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	require.Equal(t, uint64(18), next)
+	require.Equal(t, []uint64{11, 12, 14, 15, 17, 18}, got)
+	require.Equal(t, int32(2), atomic.LoadInt32(&notAvailable))
+}
+
+func TestStreamer_fetchError(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	server.Handle("getSlot", 12)
+	server.Handle("getBlocks", []uint64{10, 11, 12})
+	server.HandleError("getBlock", rpc.ErrorCodeBlockNotAvailable, "Block not available for slot 10")
+
+	streamer := New(rpc.New(server.URL()), nil, &Opts{
+		FetchAttempts: 3,
+		FetchDelay:    time.Millisecond,
+	})
+	next, err := streamer.Run(context.Background(), 10, func(block *Block) error {
+		return nil
+	})
+	require.Error(t, err)
+	require.Equal(t, uint64(10), next)
+	require.GreaterOrEqual(t, len(server.Requests("getBlock")), 3)
+}
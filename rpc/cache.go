@@ -0,0 +1,285 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"container/list"
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+var (
+	// DefaultCacheTTLs are the TTLs of the results of the methods
+	// that change often but are read by many callers.
+	DefaultCacheTTLs = map[string]time.Duration{
+		"getLatestBlockhash": time.Second,
+		"getSlot":            400 * time.Millisecond,
+	}
+	// DefaultCacheMaxEntries is the number of results kept in the cache.
+	DefaultCacheMaxEntries = 10_000
+)
+
+// CacheOpts configures the cache of a Client (see WithCache).
+type CacheOpts struct {
+	// TTLs are the durations for which the results of each method are cached.
+	// Defaults to DefaultCacheTTLs; a zero or negative TTL disables the
+	// caching of the method.
+	TTLs map[string]time.Duration
+
+	// MaxEntries bounds the number of cached results; the least recently used
+	// results are evicted first. Defaults to DefaultCacheMaxEntries.
+	MaxEntries int
+
+	// DisableImmutable disables the caching of the immutable results:
+	// the genesis hash, and the finalized blocks and transactions.
+	DisableImmutable bool
+}
+
+// WithCache caches the results of the calls, and coalesces the concurrent
+// identical read calls (methods starting with "get" or "is") into one request.
+//
+// The immutable results (getGenesisHash, and getBlock and getTransaction
+// at the finalized commitment) are cached until they are evicted, the others
+// for the TTL of their method. Null results and errors are never cached.
+// A nil opts uses the defaults.
+func WithCache(opts *CacheOpts) ClientOption {
+	return func(d *clientDefaults) {
+		if opts == nil {
+			opts = &CacheOpts{}
+		}
+		d.cache = opts
+	}
+}
+
+// cachingClient caches the results of the wrapped client.
+type cachingClient struct {
+	rpcClient JSONRPCClient
+
+	ttls             map[string]time.Duration
+	disableImmutable bool
+
+	lock       sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+	flights    map[string]*cacheFlight
+}
+
+type cacheEntry struct {
+	key    string
+	result stdjson.RawMessage
+	// expires is zero for immutable results.
+	expires time.Time
+}
+
+// cacheFlight is a call shared by concurrent identical calls.
+type cacheFlight struct {
+	done   chan struct{}
+	result stdjson.RawMessage
+	err    error
+}
+
+func newCachingClient(rpcClient JSONRPCClient, opts *CacheOpts) *cachingClient {
+	c := &cachingClient{
+		rpcClient:        rpcClient,
+		ttls:             DefaultCacheTTLs,
+		disableImmutable: opts.DisableImmutable,
+		maxEntries:       DefaultCacheMaxEntries,
+		entries:          map[string]*list.Element{},
+		lru:              list.New(),
+		flights:          map[string]*cacheFlight{},
+	}
+	if opts.TTLs != nil {
+		c.ttls = opts.TTLs
+	}
+	if opts.MaxEntries > 0 {
+		c.maxEntries = opts.MaxEntries
+	}
+	return c
+}
+
+func (c *cachingClient) CallForInto(ctx context.Context, out interface{}, method string, params any) error {
+	immutable, ttl := c.policy(method, params)
+	coalesce := strings.HasPrefix(method, "get") || strings.HasPrefix(method, "is")
+	if !immutable && ttl <= 0 && !coalesce {
+		return c.rpcClient.CallForInto(ctx, out, method, params)
+	}
+
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return c.rpcClient.CallForInto(ctx, out, method, params)
+	}
+	key := method + string(encodedParams)
+
+	if result, ok := c.get(key); ok {
+		return json.Unmarshal(result, out)
+	}
+
+	var result stdjson.RawMessage
+	if coalesce {
+		result, err = c.do(ctx, key, method, params)
+	} else {
+		err = c.rpcClient.CallForInto(ctx, &result, method, params)
+	}
+	if err != nil {
+		return err
+	}
+	if len(result) == 0 {
+		result = stdjson.RawMessage("null")
+	}
+	if immutable || ttl > 0 {
+		c.set(key, result, immutable, ttl)
+	}
+	return json.Unmarshal(result, out)
+}
+
+// do calls the method, sharing the call with the concurrent calls with the same key.
+func (c *cachingClient) do(ctx context.Context, key string, method string, params any) (stdjson.RawMessage, error) {
+	c.lock.Lock()
+	if flight, ok := c.flights[key]; ok {
+		c.lock.Unlock()
+		select {
+		case <-flight.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// The shared call failed because its caller gave up: call on our own.
+		if flight.err != nil && (errors.Is(flight.err, context.Canceled) || errors.Is(flight.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+			var result stdjson.RawMessage
+			err := c.rpcClient.CallForInto(ctx, &result, method, params)
+			return result, err
+		}
+		return flight.result, flight.err
+	}
+	flight := &cacheFlight{done: make(chan struct{})}
+	c.flights[key] = flight
+	c.lock.Unlock()
+
+	flight.err = c.rpcClient.CallForInto(ctx, &flight.result, method, params)
+
+	c.lock.Lock()
+	delete(c.flights, key)
+	c.lock.Unlock()
+	close(flight.done)
+	return flight.result, flight.err
+}
+
+// policy returns whether the result of the call is immutable,
+// and otherwise for how long it can be cached.
+func (c *cachingClient) policy(method string, params any) (immutable bool, ttl time.Duration) {
+	if !c.disableImmutable {
+		switch method {
+		case "getGenesisHash":
+			return true, 0
+		case "getBlock", "getTransaction":
+			if commitmentOf(method, params) == CommitmentFinalized {
+				return true, 0
+			}
+		}
+	}
+	return false, c.ttls[method]
+}
+
+// commitmentOf returns the commitment of the call, which defaults to finalized.
+func commitmentOf(method string, params any) CommitmentType {
+	positional, ok := params.([]interface{})
+	position, known := configPositions[method]
+	if !ok || !known || len(positional) <= position {
+		return CommitmentFinalized
+	}
+	var value interface{}
+	switch config := positional[position].(type) {
+	case M:
+		value = config["commitment"]
+	case map[string]interface{}:
+		value = config["commitment"]
+	}
+	switch commitment := value.(type) {
+	case CommitmentType:
+		if commitment != "" {
+			return commitment
+		}
+	case string:
+		if commitment != "" {
+			return CommitmentType(commitment)
+		}
+	}
+	return CommitmentFinalized
+}
+
+func (c *cachingClient) get(key string) (stdjson.RawMessage, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.result, true
+}
+
+func (c *cachingClient) set(key string, result stdjson.RawMessage, immutable bool, ttl time.Duration) {
+	if len(result) == 0 || string(result) == "null" {
+		return
+	}
+	entry := &cacheEntry{key: key, result: result}
+	if !immutable {
+		entry.expires = time.Now().Add(ttl)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *cachingClient) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	return c.rpcClient.CallWithCallback(ctx, method, params, callback)
+}
+
+func (c *cachingClient) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	return c.rpcClient.CallBatch(ctx, requests)
+}
+
+// Close closes the wrapped client.
+func (c *cachingClient) Close() error {
+	if closer, ok := c.rpcClient.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WithCache(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getGenesisHash", "5eykt4UsFv8P8NJdTREpY1vzqKqZKvdpKuc147dw2N9d")
+	server.Handle("getSlot", 100)
+	server.Handle("getBlock", M{"blockhash": "5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB", "parentSlot": 1})
+	server.Handle("getTransaction", nil)
+
+	client := New(server.URL(), WithCache(&CacheOpts{
+		TTLs: map[string]time.Duration{"getSlot": 50 * time.Millisecond},
+	}))
+	ctx := context.Background()
+
+	t.Run("immutable", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			hash, err := client.GetGenesisHash(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, solana.MustHashFromBase58("5eykt4UsFv8P8NJdTREpY1vzqKqZKvdpKuc147dw2N9d"), hash)
+		}
+		assert.Len(t, server.Requests("getGenesisHash"), 1)
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			slot, err := client.GetSlot(ctx, "")
+			require.NoError(t, err)
+			assert.Equal(t, uint64(100), slot)
+		}
+		assert.Len(t, server.Requests("getSlot"), 1)
+
+		time.Sleep(60 * time.Millisecond)
+		_, err := client.GetSlot(ctx, "")
+		require.NoError(t, err)
+		assert.Len(t, server.Requests("getSlot"), 2)
+	})
+
+	t.Run("commitment", func(t *testing.T) {
+		_, err := client.GetBlockWithOpts(ctx, 10, &GetBlockOpts{Commitment: CommitmentConfirmed})
+		require.NoError(t, err)
+		_, err = client.GetBlockWithOpts(ctx, 10, &GetBlockOpts{Commitment: CommitmentConfirmed})
+		require.NoError(t, err)
+		assert.Len(t, server.Requests("getBlock"), 2)
+
+		_, err = client.GetBlock(ctx, 10)
+		require.NoError(t, err)
+		_, err = client.GetBlock(ctx, 10)
+		require.NoError(t, err)
+		assert.Len(t, server.Requests("getBlock"), 3)
+	})
+
+	t.Run("null results", func(t *testing.T) {
+		sig := solana.Signature{1}
+		for i := 0; i < 2; i++ {
+			_, err := client.GetTransaction(ctx, sig, nil)
+			assert.ErrorIs(t, err, ErrNotFound)
+		}
+		assert.Len(t, server.Requests("getTransaction"), 2)
+	})
+}
+
+func TestClient_WithCache_eviction(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getBlock", M{"parentSlot": 1})
+
+	client := New(server.URL(), WithCache(&CacheOpts{MaxEntries: 2}))
+	var requested []uint64
+	for _, slot := range []uint64{1, 2, 1, 3, 1, 2} {
+		_, err := client.GetBlock(context.Background(), slot)
+		require.NoError(t, err)
+	}
+	for _, req := range server.Requests("getBlock") {
+		var params []interface{}
+		require.NoError(t, stdjson.Unmarshal(req.Params, &params))
+		requested = append(requested, uint64(params[0].(float64)))
+	}
+	// Block 2 is the least recently used when block 3 is cached.
+	assert.Equal(t, []uint64{1, 2, 3, 2}, requested)
+}
+
+func TestClient_WithCache_defaultCommitment(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getBlock", M{"parentSlot": 1})
+
+	// The cache sees the commitment set by the client defaults.
+	client := New(server.URL(), WithCache(nil), WithCommitment(CommitmentConfirmed))
+	for i := 0; i < 2; i++ {
+		_, err := client.GetBlock(context.Background(), 10)
+		require.NoError(t, err)
+	}
+	assert.Len(t, server.Requests("getBlock"), 2)
+}
+
+func TestClient_WithCache_coalescing(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	release := make(chan struct{})
+	server.HandleFunc("getBalance", func(stdjson.RawMessage) (interface{}, error) {
+		<-release
+		return M{"context": M{"slot": 1}, "value": 42}, nil
+	})
+
+	client := New(server.URL(), WithCache(nil))
+	account := solana.MustPublicKeyFromBase58("7HZaCWazgTuuFuajxaaxGYbGnyVKwxvsJKue1W4Nvyro")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out, err := client.GetBalance(context.Background(), account, "")
+			assert.NoError(t, err)
+			if err == nil {
+				assert.Equal(t, uint64(42), out.Value)
+			}
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return len(server.Requests("getBalance")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	// Let the other calls join the flight.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Len(t, server.Requests("getBalance"), 1)
+
+	// The result of getBalance is not cached.
+	_, err := client.GetBalance(context.Background(), account, "")
+	require.NoError(t, err)
+	assert.Len(t, server.Requests("getBalance"), 2)
+}
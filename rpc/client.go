@@ -27,6 +27,7 @@ import (
 
 	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 	"github.com/klauspost/compress/gzhttp"
+	"go.uber.org/zap"
 )
 
 var (
@@ -37,6 +38,8 @@ var (
 type Client struct {
 	rpcURL    string
 	rpcClient JSONRPCClient
+	apiKeys   *apiKeyTransport
+	logger    *zap.Logger
 }
 
 type JSONRPCClient interface {
@@ -46,14 +49,19 @@ type JSONRPCClient interface {
 }
 
 // New creates a new Solana JSON RPC client.
+// The options set the defaults applied to every call
+// (e.g. WithCommitment, WithRequestTimeout); the options
+// of each call take precedence over them.
 // Client is safe for concurrent use by multiple goroutines.
-func New(rpcEndpoint string) *Client {
-	opts := &jsonrpc.RPCClientOpts{
+func New(rpcEndpoint string, opts ...ClientOption) *Client {
+	rpcOpts := &jsonrpc.RPCClientOpts{
 		HTTPClient: newHTTP(),
 	}
 
-	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, opts)
-	return NewWithCustomRPCClient(rpcClient)
+	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, rpcOpts)
+	cl := NewWithCustomRPCClient(NewTypedErrorClient(rpcClient))
+	applyClientOptions(cl, opts)
+	return cl
 }
 
 // New creates a new Solana JSON RPC client with the provided custom headers.
@@ -64,7 +72,7 @@ func NewWithHeaders(rpcEndpoint string, headers map[string]string) *Client {
 		CustomHeaders: headers,
 	}
 	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, opts)
-	return NewWithCustomRPCClient(rpcClient)
+	return NewWithCustomRPCClient(NewTypedErrorClient(rpcClient))
 }
 
 // Close closes the client.
@@ -80,12 +88,26 @@ func (cl *Client) Close() error {
 
 // NewWithCustomRPCClient creates a new Solana RPC client
 // with the provided RPC client.
+//
+// The errors of the RPC client are returned as they are; wrap it
+// with NewTypedErrorClient to get the typed errors of this package.
 func NewWithCustomRPCClient(rpcClient JSONRPCClient) *Client {
 	return &Client{
 		rpcClient: rpcClient,
 	}
 }
 
+// NewTypedErrorClient wraps rpcClient so that the JSON-RPC errors it returns
+// are converted into the typed errors of this package
+// (e.g. *SendTransactionPreflightFailureError) when possible;
+// the original error remains available via errors.As.
+//
+// The clients created by New, NewWithHeaders and NewWithOptions
+// already convert the errors.
+func NewTypedErrorClient(rpcClient JSONRPCClient) JSONRPCClient {
+	return &typedErrorClient{rpcClient: rpcClient}
+}
+
 var (
 	defaultMaxIdleConnsPerHost = 9
 	defaultTimeout             = 5 * time.Minute
@@ -24,6 +24,10 @@ import (
 	stdjson "encoding/json"
 	"fmt"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/AlekSi/pointer"
@@ -1791,6 +1795,105 @@ func TestClient_GetProgramAccounts(t *testing.T) {
 	assert.Equal(t, expected, out)
 }
 
+func TestClient_ScanProgramAccounts(t *testing.T) {
+	program := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	var keys []solana.PublicKey
+	for i := 0; i < 5; i++ {
+		keys = append(keys, solana.NewWallet().PublicKey())
+	}
+	closed := keys[1]
+	reassigned := keys[3]
+
+	var multipleCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Method string               `json:"method"`
+			Params []stdjson.RawMessage `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&in))
+
+		var result bytes.Buffer
+		switch in.Method {
+		case "getProgramAccounts":
+			var conf struct {
+				DataSlice DataSlice   `json:"dataSlice"`
+				Filters   []RPCFilter `json:"filters"`
+			}
+			require.NoError(t, stdjson.Unmarshal(in.Params[1], &conf))
+			require.Equal(t, uint64(0), *conf.DataSlice.Length)
+			require.Equal(t, []RPCFilter{NewDataSizeFilter(4)}, conf.Filters)
+
+			result.WriteString("[")
+			for i, key := range keys {
+				if i > 0 {
+					result.WriteString(",")
+				}
+				fmt.Fprintf(&result, `{"pubkey":%q,"account":{"data":["","base64"],"executable":false,"lamports":1,"owner":%q,"rentEpoch":0}}`, key, program)
+			}
+			result.WriteString("]")
+		case "getMultipleAccounts":
+			multipleCalls++
+			var requested []solana.PublicKey
+			require.NoError(t, stdjson.Unmarshal(in.Params[0], &requested))
+			require.LessOrEqual(t, len(requested), 2)
+			result.WriteString(`{"context":{"slot":1},"value":[`)
+			for i, key := range requested {
+				if i > 0 {
+					result.WriteString(",")
+				}
+				owner := program
+				switch key {
+				case closed:
+					result.WriteString("null")
+					continue
+				case reassigned:
+					owner = solana.SystemProgramID
+				}
+				fmt.Fprintf(&result, `{"data":[%q,"base64"],"executable":false,"lamports":1,"owner":%q,"rentEpoch":0}`, base64.StdEncoding.EncodeToString(key[:4]), owner)
+			}
+			result.WriteString(`]}`)
+		}
+		rw.Write([]byte(wrapIntoRPC(result.String())))
+	}))
+	defer server.Close()
+
+	client := New(server.URL)
+	accounts, errs := client.ScanProgramAccounts(context.Background(), program, &ScanProgramAccountsOpts{
+		Filters:   []RPCFilter{NewDataSizeFilter(4)},
+		ChunkSize: 2,
+	})
+	var got []solana.PublicKey
+	for acc := range accounts {
+		require.Equal(t, acc.Pubkey[:4], acc.Account.Data.GetBinary())
+		got = append(got, acc.Pubkey)
+	}
+	require.NoError(t, <-errs)
+	require.Equal(t, 3, multipleCalls)
+
+	var expected []solana.PublicKey
+	for _, key := range keys {
+		if key != closed && key != reassigned {
+			expected = append(expected, key)
+		}
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		return bytes.Compare(expected[i][:], expected[j][:]) < 0
+	})
+	require.Equal(t, expected, got)
+
+	accounts, errs = client.ScanProgramAccounts(context.Background(), program, &ScanProgramAccountsOpts{
+		Filters:  []RPCFilter{NewDataSizeFilter(4)},
+		KeysOnly: true,
+	})
+	var count int
+	for range accounts {
+		count++
+	}
+	require.NoError(t, <-errs)
+	require.Equal(t, len(keys), count)
+	require.Equal(t, 3, multipleCalls)
+}
+
 func TestClient_GetRecentPerformanceSamples(t *testing.T) {
 	responseBody := `[{"numSlots":84,"numTransactions":90402,"samplePeriodSecs":60,"slot":83998844}]`
 	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
@@ -2966,7 +3069,54 @@ func TestClient_IsBlockhashValid(t *testing.T) {
 }
 
 func TestClient_SimulateTransaction(t *testing.T) {
-	// TODO
+	responseBody := `{"context":{"slot":218},"value":{"err":null,"accounts":null,"logs":["Program 83astBRguLMdt2h5U1Tpdq5tjFoJ6noeGwaY3mDLVcri invoke [1]","Program 83astBRguLMdt2h5U1Tpdq5tjFoJ6noeGwaY3mDLVcri success"],"returnData":{"data":["Kg==","base64"],"programId":"83astBRguLMdt2h5U1Tpdq5tjFoJ6noeGwaY3mDLVcri"},"unitsConsumed":2366,"innerInstructions":[{"index":0,"instructions":[{"programIdIndex":2,"accounts":[0,1],"data":"3Bxs4NN8M2Yn4TLb"}]}],"replacementBlockhash":{"blockhash":"6oFLsE7kmgJx9PjR4R63VRNtpAVJ648gCTr3nq5Hihit","lastValidBlockHeight":256}}}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := New(server.URL)
+
+	minContextSlot := uint64(200)
+	out, err := client.SimulateRawTransactionWithOpts(
+		context.Background(),
+		[]byte{1, 2, 3},
+		&SimulateTransactionOpts{
+			Commitment:             CommitmentConfirmed,
+			ReplaceRecentBlockhash: true,
+			InnerInstructions:      true,
+			MinContextSlot:         &minContextSlot,
+		},
+	)
+	require.NoError(t, err)
+
+	reqBody := server.RequestBody(t)
+	assert.Equal(t, "simulateTransaction", reqBody["method"])
+	assert.Equal(t,
+		[]interface{}{
+			"AQID",
+			map[string]interface{}{
+				"encoding":               "base64",
+				"commitment":             string(CommitmentConfirmed),
+				"replaceRecentBlockhash": true,
+				"innerInstructions":      true,
+				"minContextSlot":         float64(200),
+			},
+		},
+		reqBody["params"],
+	)
+
+	assert.Equal(t, uint64(2366), *out.Value.UnitsConsumed)
+	assert.Equal(t, solana.MustPublicKeyFromBase58("83astBRguLMdt2h5U1Tpdq5tjFoJ6noeGwaY3mDLVcri"), out.Value.ReturnData.ProgramId)
+	assert.Equal(t, []byte{42}, out.Value.ReturnData.Data.Content)
+	require.Len(t, out.Value.InnerInstructions, 1)
+	assert.Equal(t, uint16(2), out.Value.InnerInstructions[0].Instructions[0].ProgramIDIndex)
+	assert.Equal(t, solana.MustHashFromBase58("6oFLsE7kmgJx9PjR4R63VRNtpAVJ648gCTr3nq5Hihit"), out.Value.ReplacementBlockhash.Blockhash)
+	assert.Equal(t, uint64(256), out.Value.ReplacementBlockhash.LastValidBlockHeight)
+
+	_, err = client.SimulateRawTransactionWithOpts(
+		context.Background(),
+		[]byte{1, 2, 3},
+		&SimulateTransactionOpts{SigVerify: true, ReplaceRecentBlockhash: true},
+	)
+	require.Error(t, err)
 }
 
 func TestClient_GetFeeForMessage(t *testing.T) {
@@ -3123,3 +3273,222 @@ func TestClient_GetRecentPrioritizationFees(t *testing.T) {
 
 	assert.Equal(t, expected, got, "both deserialized values must be equal")
 }
+
+func TestHeliusClient_GetTokenAccounts(t *testing.T) {
+	responseBody := `{"total":1,"limit":10,"cursor":"abc","token_accounts":[{"address":"7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932","mint":"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v","owner":"11111111111111111111111111111111","amount":5000,"delegated_amount":0,"frozen":false}]}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := &HeliusClient{Client: New(server.URL)}
+
+	mint := "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"
+	limit := 10
+	out, err := client.GetTokenAccounts(context.Background(), TokenAccountsOpts{
+		Mint:    &mint,
+		Limit:   &limit,
+		Options: &TokenAccountsOptions{ShowZeroBalance: true},
+	})
+	require.NoError(t, err)
+
+	reqBody := server.RequestBody(t)
+	reqBody["id"] = any(nil)
+	assert.Equal(t,
+		map[string]interface{}{
+			"id":      any(nil),
+			"jsonrpc": "2.0",
+			"method":  "getTokenAccounts",
+			"params": map[string]interface{}{
+				"mint":  mint,
+				"limit": float64(10),
+				"options": map[string]interface{}{
+					"showZeroBalance": true,
+				},
+			},
+		},
+		reqBody,
+	)
+
+	cursor := "abc"
+	assert.Equal(t,
+		&TokenAccountsResult{
+			Total:  1,
+			Limit:  10,
+			Cursor: &cursor,
+			TokenAccounts: []TokenAccountsItem{
+				{
+					Address: "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932",
+					Mint:    mint,
+					Owner:   "11111111111111111111111111111111",
+					Amount:  5000,
+				},
+			},
+		},
+		out,
+	)
+
+	_, err = client.GetTokenAccounts(context.Background(), TokenAccountsOpts{})
+	require.Error(t, err)
+}
+
+func TestHeliusClient_GetNftEditions(t *testing.T) {
+	responseBody := `{"total":1,"limit":10,"page":1,"master_edition_address":"8SHfqzJYABeGfiG1apwiEYt6TvfGQiL1pdwEjvTKsyiZ","supply":1,"max_supply":100,"editions":[{"mint":"GJvFDcBWf6aDncd1TBzx2ou1rgLFYaMBdbYLBa9oTAEw","edition_address":"AoxgzXKEsJmUyF5pBb3djn9cJFA26zh2SQHvd9EYijZV","edition":1}]}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := &HeliusClient{Client: New(server.URL)}
+
+	mint := "Ey2Qb8kLctbchQsMnhZs5DjY32To2QtPuXNwWvk4NosL"
+	limit := 10
+	out, err := client.GetNftEditions(context.Background(), GetNftEditionsOpts{
+		Mint:  mint,
+		Limit: &limit,
+	})
+	require.NoError(t, err)
+
+	reqBody := server.RequestBody(t)
+	reqBody["id"] = any(nil)
+	assert.Equal(t,
+		map[string]interface{}{
+			"id":      any(nil),
+			"jsonrpc": "2.0",
+			"method":  "getNftEditions",
+			"params": map[string]interface{}{
+				"mint":  mint,
+				"limit": float64(10),
+			},
+		},
+		reqBody,
+	)
+
+	maxSupply := uint64(100)
+	assert.Equal(t,
+		&GetNftEditionsResult{
+			Total:                1,
+			Limit:                10,
+			Page:                 1,
+			MasterEditionAddress: "8SHfqzJYABeGfiG1apwiEYt6TvfGQiL1pdwEjvTKsyiZ",
+			Supply:               1,
+			MaxSupply:            &maxSupply,
+			Editions: []NftEdition{
+				{
+					Mint:           "GJvFDcBWf6aDncd1TBzx2ou1rgLFYaMBdbYLBa9oTAEw",
+					EditionAddress: "AoxgzXKEsJmUyF5pBb3djn9cJFA26zh2SQHvd9EYijZV",
+					Edition:        1,
+				},
+			},
+		},
+		out,
+	)
+
+	_, err = client.GetNftEditions(context.Background(), GetNftEditionsOpts{Mint: "invalid"})
+	require.Error(t, err)
+}
+
+func TestHeliusClient_GetSignaturesForAsset(t *testing.T) {
+	responseBody := `{"total":2,"limit":2,"page":1,"items":[["5nLi8m72bU6PBcz4Xrk23P6KTGy9ufF92kZiQXjTv9ELgkUxrNaiCGhMF4vh6RAcisw9DEQWJt9ogM3G2uCuwwV7","MintToCollectionV1"],["323Ag4J69gagBt3neUvajNauMydiXZTmXYSfdK5swWcK1iwCUypcXv45UFcy5PTt136G9gtQ45oyPJRs1f2zFZ3v","Transfer"]]}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := &HeliusClient{Client: New(server.URL)}
+
+	tree := "8SHfqzJYABeGfiG1apwiEYt6TvfGQiL1pdwEjvTKsyiZ"
+	leafIndex := uint64(42)
+	limit := 2
+	out, err := client.GetSignaturesForAsset(context.Background(), GetSignaturesForAssetOpts{
+		Tree:      tree,
+		LeafIndex: &leafIndex,
+		Limit:     &limit,
+	})
+	require.NoError(t, err)
+
+	reqBody := server.RequestBody(t)
+	assert.Equal(t, "getSignaturesForAsset", reqBody["method"])
+	assert.Equal(t,
+		map[string]interface{}{
+			"tree":      tree,
+			"leafIndex": float64(42),
+			"limit":     float64(2),
+		},
+		reqBody["params"],
+	)
+
+	assert.Equal(t,
+		&GetSignaturesForAssetResult{
+			Total: 2,
+			Limit: 2,
+			Page:  1,
+			Items: []AssetSignature{
+				{
+					Signature: solana.MustSignatureFromBase58("5nLi8m72bU6PBcz4Xrk23P6KTGy9ufF92kZiQXjTv9ELgkUxrNaiCGhMF4vh6RAcisw9DEQWJt9ogM3G2uCuwwV7"),
+					Type:      "MintToCollectionV1",
+				},
+				{
+					Signature: solana.MustSignatureFromBase58("323Ag4J69gagBt3neUvajNauMydiXZTmXYSfdK5swWcK1iwCUypcXv45UFcy5PTt136G9gtQ45oyPJRs1f2zFZ3v"),
+					Type:      "Transfer",
+				},
+			},
+		},
+		out,
+	)
+
+	{
+		buf, err := json.Marshal(out.Items[1])
+		require.NoError(t, err)
+		assert.JSONEq(t, `["323Ag4J69gagBt3neUvajNauMydiXZTmXYSfdK5swWcK1iwCUypcXv45UFcy5PTt136G9gtQ45oyPJRs1f2zFZ3v","Transfer"]`, string(buf))
+	}
+
+	_, err = client.GetSignaturesForAsset(context.Background(), GetSignaturesForAssetOpts{Tree: tree})
+	require.Error(t, err)
+	_, err = client.GetSignaturesForAsset(context.Background(), GetSignaturesForAssetOpts{Id: "invalid"})
+	require.Error(t, err)
+	_, err = client.GetSignaturesForAsset(context.Background(), GetSignaturesForAssetOpts{Id: tree, Tree: tree, LeafIndex: &leafIndex})
+	require.Error(t, err)
+}
+
+func TestHeliusClient_GetTokenSupplyByMintList(t *testing.T) {
+	var batchSizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var requests []struct {
+			ID     int           `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&requests))
+		batchSizes = append(batchSizes, len(requests))
+
+		var responses []string
+		for _, request := range requests {
+			require.Equal(t, "getTokenSupply", request.Method)
+			require.Equal(t, map[string]interface{}{"commitment": "confirmed"}, request.Params[1])
+			if request.Params[0] == solana.SystemProgramID.String() {
+				responses = append(responses, fmt.Sprintf(`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid param: not a Token mint"},"id":%d}`, request.ID))
+				continue
+			}
+			responses = append(responses, fmt.Sprintf(`{"jsonrpc":"2.0","result":{"context":{"slot":7},"value":{"amount":"1000","decimals":2,"uiAmount":10,"uiAmountString":"10"}},"id":%d}`, request.ID))
+		}
+		rw.Write([]byte("[" + strings.Join(responses, ",") + "]"))
+	}))
+	defer server.Close()
+
+	client := &HeliusClient{Client: New(server.URL)}
+	mints := []solana.PublicKey{
+		solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"),
+		solana.SystemProgramID,
+		solana.MustPublicKeyFromBase58("Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB"),
+	}
+	out, err := client.GetTokenSupplyByMintList(context.Background(), mints, &GetTokenSupplyByMintListOpts{
+		Commitment: CommitmentConfirmed,
+		BatchSize:  2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int{2, 1}, batchSizes)
+	require.Len(t, out, 3)
+
+	for i, supply := range out {
+		require.Equal(t, mints[i], supply.Mint)
+	}
+	require.NoError(t, out[0].Err)
+	require.Equal(t, uint64(7), out[0].Slot)
+	require.Equal(t, "1000", out[0].Supply.Amount)
+	require.Equal(t, uint8(2), out[0].Supply.Decimals)
+	require.Error(t, out[1].Err)
+	require.Nil(t, out[1].Supply)
+	require.NoError(t, out[2].Err)
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"github.com/gagliardetto/solana-go"
+)
+
+// WithAccountDataCompression requests the account data of getAccountInfo,
+// getMultipleAccounts and getProgramAccounts calls as "base64+zstd"
+// instead of "base64", which makes large responses (e.g. program scans)
+// several times smaller. The data is decompressed transparently
+// (see DataBytesOrJSON.Binary).
+//
+// Calls whose dataSlice limits the data of each account to fewer than
+// minDataSize bytes are left uncompressed, as compressing small payloads
+// gains little; calls whose opts set an encoding are never changed.
+func WithAccountDataCompression(minDataSize uint64) ClientOption {
+	return func(d *clientDefaults) { d.compressAbove = &minDataSize }
+}
+
+// implicitEncoding is the encoding requested by the account data methods
+// when the caller doesn't set one. Unlike an encoding set by the caller,
+// it is replaced by "base64+zstd" when the client prefers compression.
+type implicitEncoding solana.EncodingType
+
+// accountDataMethods are the methods whose account data can be compressed.
+var accountDataMethods = map[string]bool{
+	"getAccountInfo":      true,
+	"getMultipleAccounts": true,
+	"getProgramAccounts":  true,
+}
+
+// compressAccountData returns params with the encoding of the account data
+// set to base64+zstd, if the client prefers compression for the call.
+// The params of the caller are not modified.
+func (d *clientDefaults) compressAccountData(method string, params any) any {
+	if d.compressAbove == nil || !accountDataMethods[method] {
+		return params
+	}
+	positional, ok := params.([]interface{})
+	if !ok || len(positional) < 1 {
+		return params
+	}
+
+	var config map[string]interface{}
+	if len(positional) > 1 {
+		switch existing := positional[1].(type) {
+		case M:
+			config = existing
+		case map[string]interface{}:
+			config = existing
+		case nil:
+		default:
+			return params
+		}
+	}
+	if encoding, ok := config["encoding"]; ok {
+		if _, isDefault := encoding.(implicitEncoding); !isDefault {
+			return params
+		}
+	}
+	if slice, ok := config["dataSlice"].(M); ok {
+		if length, ok := slice["length"].(*uint64); ok && length != nil && *length < *d.compressAbove {
+			return params
+		}
+	}
+
+	merged := make(M, len(config)+1)
+	for key, value := range config {
+		merged[key] = value
+	}
+	merged["encoding"] = solana.EncodingBase64Zstd
+
+	out := []interface{}{positional[0], merged}
+	if len(positional) > 2 {
+		out = append(out, positional[2:]...)
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WithAccountDataCompression(t *testing.T) {
+	data := bytes.Repeat([]byte{1, 2, 3, 4}, 1000)
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	compressed := base64.StdEncoding.EncodeToString(enc.EncodeAll(data, nil))
+
+	account := M{
+		"lamports":   1,
+		"owner":      solana.SystemProgramID.String(),
+		"data":       []string{compressed, "base64+zstd"},
+		"executable": false,
+		"rentEpoch":  0,
+	}
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getProgramAccounts", []M{{"pubkey": solana.SystemProgramID.String(), "account": account}})
+	server.Handle("getMultipleAccounts", M{"context": M{"slot": 1}, "value": []M{account}})
+
+	client := New(server.URL(), WithAccountDataCompression(128))
+	ctx := context.Background()
+
+	accounts, err := client.GetProgramAccounts(ctx, solana.SystemProgramID)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	assert.Equal(t, data, accounts[0].Account.Data.GetBinary())
+	assert.JSONEq(t,
+		`["11111111111111111111111111111111",{"encoding":"base64+zstd"}]`,
+		string(server.Requests("getProgramAccounts")[0].Params),
+	)
+
+	multiple, err := client.GetMultipleAccounts(ctx, solana.SystemProgramID)
+	require.NoError(t, err)
+	assert.Equal(t, data, multiple.Value[0].Data.GetBinary())
+	assert.JSONEq(t,
+		`[["11111111111111111111111111111111"],{"encoding":"base64+zstd"}]`,
+		string(server.Requests("getMultipleAccounts")[0].Params),
+	)
+
+	// Small data slices and other encodings are left as they are.
+	offset, length := uint64(0), uint64(32)
+	_, err = client.GetProgramAccountsWithOpts(ctx, solana.SystemProgramID, &GetProgramAccountsOpts{
+		DataSlice: &DataSlice{Offset: &offset, Length: &length},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`["11111111111111111111111111111111",{"encoding":"base64","dataSlice":{"offset":0,"length":32}}]`,
+		string(server.Requests("getProgramAccounts")[1].Params),
+	)
+	_, err = client.GetProgramAccountsWithOpts(ctx, solana.SystemProgramID, &GetProgramAccountsOpts{
+		Encoding: solana.EncodingJSONParsed,
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`["11111111111111111111111111111111",{"encoding":"jsonParsed"}]`,
+		string(server.Requests("getProgramAccounts")[2].Params),
+	)
+}
+
+func TestClient_AccountDataCompressionOptIn(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getMultipleAccounts", M{"context": M{"slot": 1}, "value": []M{}})
+	server.Handle("getAccountInfo", M{"context": M{"slot": 1}, "value": nil})
+	ctx := context.Background()
+
+	// Without the option the account data is requested as before.
+	_, err := New(server.URL()).GetMultipleAccounts(ctx, solana.SystemProgramID)
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`[["11111111111111111111111111111111"]]`,
+		string(server.Requests("getMultipleAccounts")[0].Params),
+	)
+	_, err = New(server.URL()).GetAccountInfo(ctx, solana.SystemProgramID)
+	require.ErrorIs(t, err, ErrNotFound)
+	assert.JSONEq(t,
+		`["11111111111111111111111111111111",{"encoding":"base64"}]`,
+		string(server.Requests("getAccountInfo")[0].Params),
+	)
+
+	client := New(server.URL(), WithAccountDataCompression(128))
+	_, err = client.GetAccountInfo(ctx, solana.SystemProgramID)
+	require.ErrorIs(t, err, ErrNotFound)
+	assert.JSONEq(t,
+		`["11111111111111111111111111111111",{"encoding":"base64+zstd"}]`,
+		string(server.Requests("getAccountInfo")[1].Params),
+	)
+
+	// An encoding set by the caller is never replaced.
+	_, err = client.GetAccountInfoWithOpts(ctx, solana.SystemProgramID, &GetAccountInfoOpts{
+		Encoding: solana.EncodingBase64,
+	})
+	require.ErrorIs(t, err, ErrNotFound)
+	assert.JSONEq(t,
+		`["11111111111111111111111111111111",{"encoding":"base64"}]`,
+		string(server.Requests("getAccountInfo")[2].Params),
+	)
+}
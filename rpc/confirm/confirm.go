@@ -0,0 +1,348 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package confirm tracks the confirmation of transaction signatures,
+// reporting their progression from processed to confirmed to finalized.
+// It relies on signatureSubscribe when a ws client is available, and falls
+// back to polling getSignatureStatuses otherwise.
+package confirm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.uber.org/zap"
+)
+
+var DefaultPollInterval = 1 * time.Second
+
+// maxStatusesPerRequest is the maximum number of signatures
+// accepted by getSignatureStatuses.
+const maxStatusesPerRequest = 256
+
+var ErrTrackerClosed = errors.New("confirmation tracker closed")
+
+type Opts struct {
+	// PollInterval is the period at which the statuses of the signatures
+	// that are not tracked with a subscription are polled.
+	// Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+}
+
+// Event reports the progression of a tracked signature.
+type Event struct {
+	Signature solana.Signature
+
+	// Status reached by the transaction; empty if Err is set.
+	Status rpc.ConfirmationStatusType
+	// Slot the transaction was processed in.
+	Slot uint64
+	// TxErr is the error of the transaction, if it failed;
+	// a failed transaction is confirmed nonetheless.
+	TxErr interface{}
+
+	// Err is set on the last event if the tracking stopped
+	// before the target commitment was reached, e.g. because
+	// the context was done or the tracker closed.
+	Err error
+}
+
+// Tracker tracks the confirmation of signatures, sharing a ws connection
+// between their subscriptions, and a single polling loop between
+// the signatures that are not tracked with a subscription.
+type Tracker struct {
+	rpcClient *rpc.Client
+	wsClient  *ws.Client
+	opts      Opts
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	lock   sync.Mutex
+	polled map[*tracked]struct{}
+}
+
+// NewTracker creates a new Tracker. The ws client is optional: without it,
+// all the signatures are tracked by polling.
+func NewTracker(rpcClient *rpc.Client, wsClient *ws.Client, opts *Opts) *Tracker {
+	t := &Tracker{
+		rpcClient: rpcClient,
+		wsClient:  wsClient,
+		polled:    map[*tracked]struct{}{},
+	}
+	if opts != nil {
+		t.opts = *opts
+	}
+	if t.opts.PollInterval <= 0 {
+		t.opts.PollInterval = DefaultPollInterval
+	}
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	t.wg.Add(1)
+	go t.poll()
+	return t
+}
+
+// Close stops tracking all the signatures; their channels receive
+// a last event with ErrTrackerClosed.
+func (t *Tracker) Close() {
+	t.cancel()
+	t.wg.Wait()
+}
+
+// Track starts tracking the signature until it reaches the commitment
+// (rpc.CommitmentConfirmed if empty), or ctx is done.
+//
+// The returned channel receives an event for each status reached by
+// the transaction, in order and up to the commitment, and is closed
+// after the last one.
+// It is buffered, so that the events are not lost if it is consumed late.
+func (t *Tracker) Track(ctx context.Context, signature solana.Signature, commitment rpc.CommitmentType) <-chan Event {
+	target := level(commitment)
+	if target == 0 {
+		target = levelConfirmed
+	}
+	tr := &tracked{
+		signature: signature,
+		target:    target,
+		events:    make(chan Event, levelFinalized+1),
+	}
+	tr.ctx, tr.cancel = context.WithCancel(ctx)
+
+	if t.ctx.Err() != nil {
+		tr.stop(ErrTrackerClosed)
+		return tr.events
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		select {
+		case <-tr.ctx.Done():
+			tr.stop(ctx.Err())
+		case <-t.ctx.Done():
+			tr.stop(ErrTrackerClosed)
+		}
+		t.lock.Lock()
+		delete(t.polled, tr)
+		t.lock.Unlock()
+	}()
+
+	if t.wsClient == nil || !t.subscribe(tr) {
+		t.startPolling(tr)
+		return tr.events
+	}
+	// The transaction may have reached some statuses
+	// before the subscriptions were active.
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.check(tr.ctx, []*tracked{tr})
+	}()
+	return tr.events
+}
+
+// subscribe subscribes to the signature at each commitment up to the target.
+// It returns false if any of the subscriptions fails.
+func (t *Tracker) subscribe(tr *tracked) bool {
+	var subs []*ws.SignatureSubscription
+	for l := levelProcessed; l <= tr.target; l++ {
+		sub, err := t.wsClient.SignatureSubscribe(tr.signature, commitments[l])
+		if err != nil {
+			zlog.Debug("unable to subscribe to signature, polling",
+				zap.Stringer("signature", tr.signature),
+				zap.Error(err),
+			)
+			for _, sub := range subs {
+				sub.Unsubscribe()
+			}
+			return false
+		}
+		subs = append(subs, sub)
+	}
+	for i, sub := range subs {
+		t.wg.Add(1)
+		go t.recv(tr, sub, levelProcessed+i)
+	}
+	return true
+}
+
+func (t *Tracker) recv(tr *tracked, sub *ws.SignatureSubscription, l int) {
+	defer t.wg.Done()
+	defer sub.Unsubscribe()
+	res, err := sub.RecvWithContext(tr.ctx)
+	if err != nil {
+		if tr.ctx.Err() == nil {
+			zlog.Debug("signature subscription failed, polling",
+				zap.Stringer("signature", tr.signature),
+				zap.Error(err),
+			)
+			t.startPolling(tr)
+		}
+		return
+	}
+	tr.update(l, res.Context.Slot, res.Value.Err)
+}
+
+func (t *Tracker) startPolling(tr *tracked) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if tr.ctx.Err() == nil {
+		t.polled[tr] = struct{}{}
+	}
+}
+
+func (t *Tracker) poll() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(t.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		t.lock.Lock()
+		pending := make([]*tracked, 0, len(t.polled))
+		for tr := range t.polled {
+			pending = append(pending, tr)
+		}
+		t.lock.Unlock()
+
+		for len(pending) > 0 {
+			n := len(pending)
+			if n > maxStatusesPerRequest {
+				n = maxStatusesPerRequest
+			}
+			t.check(t.ctx, pending[:n])
+			pending = pending[n:]
+		}
+	}
+}
+
+// check fetches the statuses of the provided signatures.
+func (t *Tracker) check(ctx context.Context, trs []*tracked) {
+	sigs := make([]solana.Signature, len(trs))
+	for i, tr := range trs {
+		sigs[i] = tr.signature
+	}
+	out, err := t.rpcClient.GetSignatureStatuses(ctx, true, sigs...)
+	if err != nil {
+		if ctx.Err() == nil {
+			zlog.Debug("unable to get signature statuses", zap.Int("signatures", len(sigs)), zap.Error(err))
+		}
+		return
+	}
+	for i, status := range out.Value {
+		if i < len(trs) && status != nil {
+			trs[i].update(level(rpc.CommitmentType(status.ConfirmationStatus)), status.Slot, status.Err)
+		}
+	}
+}
+
+const (
+	levelProcessed = iota + 1
+	levelConfirmed
+	levelFinalized
+)
+
+var commitments = map[int]rpc.CommitmentType{
+	levelProcessed: rpc.CommitmentProcessed,
+	levelConfirmed: rpc.CommitmentConfirmed,
+	levelFinalized: rpc.CommitmentFinalized,
+}
+
+var statuses = map[int]rpc.ConfirmationStatusType{
+	levelProcessed: rpc.ConfirmationStatusProcessed,
+	levelConfirmed: rpc.ConfirmationStatusConfirmed,
+	levelFinalized: rpc.ConfirmationStatusFinalized,
+}
+
+// level returns the rank of a commitment, or of a confirmation status;
+// zero if unknown.
+func level(commitment rpc.CommitmentType) int {
+	switch commitment {
+	case rpc.CommitmentProcessed, rpc.CommitmentRecent:
+		return levelProcessed
+	case rpc.CommitmentConfirmed, rpc.CommitmentSingle, rpc.CommitmentSingleGossip:
+		return levelConfirmed
+	case rpc.CommitmentFinalized, rpc.CommitmentMax, rpc.CommitmentRoot:
+		return levelFinalized
+	}
+	return 0
+}
+
+// tracked is a signature being tracked.
+type tracked struct {
+	signature solana.Signature
+	target    int
+	events    chan Event
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	lock    sync.Mutex
+	reached int
+	done    bool
+}
+
+// update records that the transaction reached the status of level l,
+// and delivers an event for each status it implies that was not
+// delivered yet (e.g. processed and confirmed for finalized), so that
+// every status is reported once and in order whatever the order
+// of the notifications.
+func (tr *tracked) update(l int, slot uint64, txErr interface{}) {
+	if l > tr.target {
+		l = tr.target
+	}
+	tr.lock.Lock()
+	if tr.done || l <= tr.reached {
+		tr.lock.Unlock()
+		return
+	}
+	for tr.reached < l {
+		tr.reached++
+		tr.events <- Event{
+			Signature: tr.signature,
+			Status:    statuses[tr.reached],
+			Slot:      slot,
+			TxErr:     txErr,
+		}
+	}
+	finished := l == tr.target
+	if finished {
+		tr.done = true
+		close(tr.events)
+	}
+	tr.lock.Unlock()
+	if finished {
+		tr.cancel()
+	}
+}
+
+// stop ends the tracking with err, unless it already finished.
+func (tr *tracked) stop(err error) {
+	tr.lock.Lock()
+	if !tr.done {
+		tr.done = true
+		tr.events <- Event{Signature: tr.signature, Err: err}
+		close(tr.events)
+	}
+	tr.lock.Unlock()
+	tr.cancel()
+}
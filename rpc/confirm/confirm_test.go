@@ -0,0 +1,134 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confirm
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(t *testing.T, events <-chan Event) []Event {
+	var out []Event
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return out
+			}
+			out = append(out, ev)
+		case <-timeout:
+			t.Fatal("timed out waiting for events")
+		}
+	}
+}
+
+func TestTracker_Subscribe(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getSignatureStatuses", rpc.M{"context": rpc.M{"slot": 1}, "value": []interface{}{nil}})
+
+	wsClient, err := ws.Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	tracker := NewTracker(rpc.New(server.URL()), wsClient, &Opts{PollInterval: time.Hour})
+	defer tracker.Close()
+
+	sig := solana.Signature{1}
+	events := tracker.Track(context.Background(), sig, rpc.CommitmentFinalized)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.Eventually(t, func() bool {
+		_, err := server.WaitForSubscription(ctx, "signatureSubscribe")
+		return err == nil && len(server.Requests("getSignatureStatuses")) == 1
+	}, 5*time.Second, time.Millisecond)
+	// Wait for the three subscriptions to be active.
+	require.Eventually(t, func() bool {
+		n, err := server.Notify("signatureSubscribe", rpc.M{"context": rpc.M{"slot": 42}, "value": rpc.M{"err": nil}})
+		return err == nil && n == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	got := collect(t, events)
+	require.Len(t, got, 3)
+	for i, status := range []rpc.ConfirmationStatusType{
+		rpc.ConfirmationStatusProcessed,
+		rpc.ConfirmationStatusConfirmed,
+		rpc.ConfirmationStatusFinalized,
+	} {
+		require.Equal(t, status, got[i].Status)
+		require.Equal(t, sig, got[i].Signature)
+		require.EqualValues(t, 42, got[i].Slot)
+		require.NoError(t, got[i].Err)
+	}
+}
+
+func TestTracker_Poll(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	var polls int32
+	server.HandleFunc("getSignatureStatuses", func(params stdjson.RawMessage) (interface{}, error) {
+		var status interface{}
+		switch atomic.AddInt32(&polls, 1) {
+		case 1:
+		case 2:
+			status = rpc.M{"slot": 7, "confirmationStatus": "processed", "err": nil}
+		default:
+			status = rpc.M{"slot": 7, "confirmationStatus": "finalized", "err": rpc.M{"InstructionError": []interface{}{0, "Custom"}}}
+		}
+		return rpc.M{"context": rpc.M{"slot": 10}, "value": []interface{}{status}}, nil
+	})
+
+	tracker := NewTracker(rpc.New(server.URL()), nil, &Opts{PollInterval: 5 * time.Millisecond})
+	defer tracker.Close()
+
+	got := collect(t, tracker.Track(context.Background(), solana.Signature{2}, ""))
+	require.Len(t, got, 2)
+	require.Equal(t, rpc.ConfirmationStatusProcessed, got[0].Status)
+	require.Nil(t, got[0].TxErr)
+	// Finalized is reported as the target commitment.
+	require.Equal(t, rpc.ConfirmationStatusConfirmed, got[1].Status)
+	require.NotNil(t, got[1].TxErr)
+}
+
+func TestTracker_Canceled(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getSignatureStatuses", rpc.M{"context": rpc.M{"slot": 1}, "value": []interface{}{nil}})
+
+	tracker := NewTracker(rpc.New(server.URL()), nil, &Opts{PollInterval: 5 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := tracker.Track(ctx, solana.Signature{3}, rpc.CommitmentConfirmed)
+	cancel()
+	got := collect(t, events)
+	require.Len(t, got, 1)
+	require.ErrorIs(t, got[0].Err, context.Canceled)
+
+	tracker.Close()
+	got = collect(t, tracker.Track(context.Background(), solana.Signature{3}, rpc.CommitmentConfirmed))
+	require.Len(t, got, 1)
+	require.ErrorIs(t, got[0].Err, ErrTrackerClosed)
+}
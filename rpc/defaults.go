@@ -0,0 +1,293 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"go.uber.org/zap"
+)
+
+// DefaultRetryBackoff is the delay before the first retry of a call
+// (see RetryPolicy); it doubles on each retry.
+var DefaultRetryBackoff = 200 * time.Millisecond
+
+// ClientOption configures the defaults of a Client created with New
+// or NewWithOptions.
+type ClientOption func(d *clientDefaults)
+
+type clientDefaults struct {
+	commitment     CommitmentType
+	minContextSlot *uint64
+	requestTimeout time.Duration
+	retry          *RetryPolicy
+	logger         *zap.Logger
+	cache          *CacheOpts
+	compressAbove  *uint64
+}
+
+// WithCommitment sets the commitment used by the calls that accept one
+// and don't set it. Methods that don't support the processed commitment
+// (e.g. getSignaturesForAddress) are sent without it in that case.
+// For sendTransaction, it is used as the preflight commitment.
+func WithCommitment(commitment CommitmentType) ClientOption {
+	return func(d *clientDefaults) { d.commitment = commitment }
+}
+
+// WithMinContextSlot sets the minimum slot at which the calls that accept
+// a minContextSlot and don't set it can be evaluated.
+func WithMinContextSlot(slot uint64) ClientOption {
+	return func(d *clientDefaults) { d.minContextSlot = &slot }
+}
+
+// WithRequestTimeout limits the duration of each call, retries included,
+// unless the context of the call already has a deadline.
+func WithRequestTimeout(timeout time.Duration) ClientOption {
+	return func(d *clientDefaults) { d.requestTimeout = timeout }
+}
+
+// WithMaxRetries retries the calls that fail with a transient error,
+// up to maxRetries times, with the default backoff.
+// It is a shorthand for WithRetryPolicy(RetryPolicy{MaxRetries: maxRetries}):
+// the calls with side effects, such as sendTransaction, are not retried.
+func WithMaxRetries(maxRetries int) ClientOption {
+	return WithRetryPolicy(RetryPolicy{MaxRetries: maxRetries})
+}
+
+// WithLogger sets the logger receiving the logs of the client, e.g. to scope
+// them with fields or to set their level per client.
+// Defaults to the package logger (see github.com/streamingfast/logging).
+func WithLogger(logger *zap.Logger) ClientOption {
+	return func(d *clientDefaults) { d.logger = logger }
+}
+
+// applyClientOptions configures cl with the provided options,
+// wrapping its JSON-RPC client to cache the results and to apply
+// the call defaults, if any.
+func applyClientOptions(cl *Client, opts []ClientOption) {
+	d := &clientDefaults{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	cl.logger = d.logger
+	if typed, ok := cl.rpcClient.(*typedErrorClient); ok {
+		typed.logger = d.logger
+	}
+	if d.cache != nil {
+		// The cache sees the params with the defaults applied, e.g. the commitment.
+		cl.rpcClient = newCachingClient(cl.rpcClient, d.cache)
+	}
+	if d.commitment == "" && d.minContextSlot == nil && d.requestTimeout <= 0 && (d.retry == nil || d.retry.MaxRetries <= 0) && d.compressAbove == nil {
+		return
+	}
+	cl.rpcClient = &defaultsClient{rpcClient: cl.rpcClient, defaults: d}
+}
+
+// configPositions is the position of the configuration object
+// in the params of the methods that accept a commitment.
+var configPositions = map[string]int{
+	"getAccountInfo":                    1,
+	"getBalance":                        1,
+	"getBlock":                          1,
+	"getBlockHeight":                    0,
+	"getBlockProduction":                0,
+	"getBlocksWithLimit":                2,
+	"getEpochInfo":                      0,
+	"getFeeForMessage":                  1,
+	"getInflationGovernor":              0,
+	"getInflationReward":                1,
+	"getLargestAccounts":                0,
+	"getLatestBlockhash":                0,
+	"getMinimumBalanceForRentExemption": 1,
+	"getMultipleAccounts":               1,
+	"getProgramAccounts":                1,
+	"getSignaturesForAddress":           1,
+	"getSlot":                           0,
+	"getSlotLeader":                     0,
+	"getStakeActivation":                1,
+	"getSupply":                         0,
+	"getTokenAccountBalance":            1,
+	"getTokenAccountsByDelegate":        2,
+	"getTokenAccountsByOwner":           2,
+	"getTokenLargestAccounts":           1,
+	"getTokenSupply":                    1,
+	"getTransaction":                    1,
+	"getTransactionCount":               0,
+	"getVoteAccounts":                   0,
+	"isBlockhashValid":                  1,
+	"requestAirdrop":                    2,
+	"sendTransaction":                   1,
+	"simulateTransaction":               1,
+}
+
+// minContextSlotMethods are the methods that accept a minContextSlot.
+var minContextSlotMethods = map[string]bool{
+	"getAccountInfo":             true,
+	"getBalance":                 true,
+	"getBlockHeight":             true,
+	"getEpochInfo":               true,
+	"getFeeForMessage":           true,
+	"getInflationReward":         true,
+	"getLatestBlockhash":         true,
+	"getMultipleAccounts":        true,
+	"getProgramAccounts":         true,
+	"getSignaturesForAddress":    true,
+	"getSlot":                    true,
+	"getSlotLeader":              true,
+	"getStakeActivation":         true,
+	"getTokenAccountsByDelegate": true,
+	"getTokenAccountsByOwner":    true,
+	"getTransactionCount":        true,
+	"isBlockhashValid":           true,
+	"sendTransaction":            true,
+	"simulateTransaction":        true,
+}
+
+// confirmedOnlyMethods are the methods that reject the processed commitment.
+var confirmedOnlyMethods = map[string]bool{
+	"getBlock":                true,
+	"getBlocksWithLimit":      true,
+	"getInflationReward":      true,
+	"getSignaturesForAddress": true,
+	"getTransaction":          true,
+}
+
+// defaultsClient applies the client defaults to each call.
+type defaultsClient struct {
+	rpcClient JSONRPCClient
+	defaults  *clientDefaults
+}
+
+func (c *defaultsClient) CallForInto(ctx context.Context, out interface{}, method string, params any) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	params = c.defaults.compressAccountData(method, c.defaults.apply(method, params))
+	return c.retry(ctx, method, !nonIdempotentMethods[method], func() error {
+		return c.rpcClient.CallForInto(ctx, out, method, params)
+	})
+}
+
+func (c *defaultsClient) CallWithCallback(ctx context.Context, method string, params []interface{}, callback func(*http.Request, *http.Response) error) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	if withDefaults, ok := c.defaults.compressAccountData(method, c.defaults.apply(method, params)).([]interface{}); ok {
+		params = withDefaults
+	}
+	return c.retry(ctx, method, !nonIdempotentMethods[method], func() error {
+		return c.rpcClient.CallWithCallback(ctx, method, params, callback)
+	})
+}
+
+func (c *defaultsClient) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (out jsonrpc.RPCResponses, err error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	withDefaults := make(jsonrpc.RPCRequests, len(requests))
+	idempotent := true
+	for i, request := range requests {
+		copied := *request
+		copied.Params = c.defaults.compressAccountData(request.Method, c.defaults.apply(request.Method, request.Params))
+		withDefaults[i] = &copied
+		idempotent = idempotent && !nonIdempotentMethods[request.Method]
+	}
+	err = c.retry(ctx, "batch", idempotent, func() (err error) {
+		out, err = c.rpcClient.CallBatch(ctx, withDefaults)
+		return err
+	})
+	return out, err
+}
+
+// Close closes the wrapped client.
+func (c *defaultsClient) Close() error {
+	if closer, ok := c.rpcClient.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (c *defaultsClient) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.defaults.requestTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.defaults.requestTimeout)
+}
+
+// apply returns params with the defaults added to the configuration object,
+// if the method accepts it and the call did not set them.
+// The params of the caller are not modified.
+func (d *clientDefaults) apply(method string, params any) any {
+	position, ok := configPositions[method]
+	if !ok {
+		return params
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	positional, ok := params.([]interface{})
+	if !ok || len(positional) < position {
+		return params
+	}
+
+	commitmentKey := "commitment"
+	if method == "sendTransaction" {
+		commitmentKey = "preflightCommitment"
+	}
+	defaults := M{}
+	if d.commitment != "" && !(d.commitment == CommitmentProcessed && confirmedOnlyMethods[method]) {
+		defaults[commitmentKey] = d.commitment
+	}
+	if d.minContextSlot != nil && minContextSlotMethods[method] {
+		defaults["minContextSlot"] = *d.minContextSlot
+	}
+	if len(defaults) == 0 {
+		return params
+	}
+
+	var config map[string]interface{}
+	if len(positional) > position {
+		switch existing := positional[position].(type) {
+		case M:
+			config = existing
+		case map[string]interface{}:
+			config = existing
+		case nil:
+		default:
+			// Typed configuration objects are left as they are.
+			return params
+		}
+	}
+
+	merged := make(M, len(config)+len(defaults))
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	for key, value := range config {
+		merged[key] = value
+	}
+
+	out := make([]interface{}, position+1, len(positional)+1)
+	copy(out, positional[:position])
+	out[position] = merged
+	if len(positional) > position+1 {
+		out = append(out, positional[position+1:]...)
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestClient_DefaultCommitment(t *testing.T) {
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(`{"context":{"slot":1},"value":42}`)))
+	defer closer()
+	client := New(server.URL, WithCommitment(CommitmentConfirmed), WithMinContextSlot(100))
+
+	pubkeyString := "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"
+	_, err := client.GetBalance(context.Background(), solana.MustPublicKeyFromBase58(pubkeyString), "")
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]interface{}{
+			pubkeyString,
+			map[string]interface{}{
+				"commitment":     string(CommitmentConfirmed),
+				"minContextSlot": float64(100),
+			},
+		},
+		server.RequestBody(t)["params"],
+	)
+}
+
+func TestClient_DefaultCommitmentOverride(t *testing.T) {
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(`{"context":{"slot":1},"value":42}`)))
+	defer closer()
+	client := New(server.URL, WithCommitment(CommitmentConfirmed))
+
+	pubkeyString := "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"
+	_, err := client.GetBalance(context.Background(), solana.MustPublicKeyFromBase58(pubkeyString), CommitmentFinalized)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		[]interface{}{
+			pubkeyString,
+			map[string]interface{}{
+				"commitment": string(CommitmentFinalized),
+			},
+		},
+		server.RequestBody(t)["params"],
+	)
+}
+
+func TestClientDefaults_apply(t *testing.T) {
+	d := &clientDefaults{commitment: CommitmentProcessed}
+
+	{
+		// Methods that require at least the confirmed commitment.
+		params := []interface{}{"sig", M{"limit": 10}}
+		assert.Equal(t, params, d.apply("getSignaturesForAddress", params))
+	}
+	{
+		// sendTransaction takes a preflight commitment.
+		params := []interface{}{"tx", M{"encoding": "base64"}}
+		assert.Equal(t,
+			[]interface{}{"tx", M{"encoding": "base64", "preflightCommitment": CommitmentProcessed}},
+			d.apply("sendTransaction", params),
+		)
+		assert.Equal(t, M{"encoding": "base64"}, params[1], "the params of the caller must not be modified")
+	}
+	{
+		// Unknown methods are left as they are.
+		params := []interface{}{"a"}
+		assert.Equal(t, params, d.apply("getHealth", params))
+	}
+}
+
+func TestClient_RequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := New(server.URL, WithRequestTimeout(50*time.Millisecond))
+	start := time.Now()
+	_, err := client.GetSlot(context.Background(), "")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestClient_MaxRetries(t *testing.T) {
+	defer func(backoff time.Duration) { DefaultRetryBackoff = backoff }(DefaultRetryBackoff)
+	DefaultRetryBackoff = time.Millisecond
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.Write([]byte(wrapIntoRPC(`42`)))
+	}))
+	defer server.Close()
+
+	{
+		client := New(server.URL, WithMaxRetries(1))
+		_, err := client.GetSlot(context.Background(), "")
+		require.Error(t, err)
+		assert.EqualValues(t, 2, atomic.LoadInt32(&requests))
+	}
+	{
+		atomic.StoreInt32(&requests, 0)
+		client := New(server.URL, WithMaxRetries(3))
+		slot, err := client.GetSlot(context.Background(), "")
+		require.NoError(t, err)
+		assert.EqualValues(t, 42, slot)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+	}
+}
+
+func TestClient_WithLogger(t *testing.T) {
+	defer func(backoff time.Duration) { DefaultRetryBackoff = backoff }(DefaultRetryBackoff)
+	DefaultRetryBackoff = time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	client := NewWithOptions(server.URL, &Options{Logger: zap.New(core)}, WithMaxRetries(2))
+	_, err := client.GetSlot(context.Background(), "")
+	require.Error(t, err)
+
+	retries := logs.FilterMessage("rpc call failed, retrying").All()
+	require.Len(t, retries, 2)
+	assert.Equal(t, "getSlot", retries[0].ContextMap()["method"])
+}
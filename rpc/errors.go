@@ -14,6 +14,20 @@
 
 package rpc
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"go.uber.org/zap"
+)
+
 // rpc error:
 // - https://github.com/solana-labs/solana/blob/d5961e9d9f005966f409fbddd40c3651591b27fb/client/src/rpc_custom_error.rs
 
@@ -23,3 +37,193 @@ package rpc
 
 // instruction error
 // - https://github.com/solana-labs/solana/blob/f6371cce176d481b4132e5061262ca015db0f8b1/sdk/program/src/instruction.rs
+
+// Custom JSON-RPC error codes returned by Solana nodes.
+const (
+	ErrorCodeBlockCleanedUp                           = -32001
+	ErrorCodeSendTransactionPreflightFailure          = -32002
+	ErrorCodeTransactionSignatureVerificationFailure  = -32003
+	ErrorCodeBlockNotAvailable                        = -32004
+	ErrorCodeNodeUnhealthy                            = -32005
+	ErrorCodeTransactionPrecompileVerificationFailure = -32006
+	ErrorCodeSlotSkipped                              = -32007
+	ErrorCodeNoSnapshot                               = -32008
+	ErrorCodeLongTermStorageSlotSkipped               = -32009
+	ErrorCodeKeyExcludedFromSecondaryIndex            = -32010
+	ErrorCodeTransactionHistoryNotAvailable           = -32011
+	ErrorCodeScanError                                = -32012
+	ErrorCodeTransactionSignatureLenMismatch          = -32013
+	ErrorCodeBlockStatusNotAvailableYet               = -32014
+	ErrorCodeUnsupportedTransactionVersion            = -32015
+	ErrorCodeMinContextSlotNotReached                 = -32016
+
+	// Returned by some RPC providers instead of (or in addition to) HTTP 429.
+	ErrorCodeRateLimited = -32429
+)
+
+// ErrBlockhashNotFound matches (via errors.Is) a preflight failure
+// caused by a blockhash that is unknown to the node, or expired.
+var ErrBlockhashNotFound = errors.New("blockhash not found")
+
+// SendTransactionPreflightFailureError is returned when the preflight
+// simulation of a sent transaction fails.
+type SendTransactionPreflightFailureError struct {
+	*jsonrpc.RPCError
+
+	// Result of the simulation: transaction error, logs and units consumed.
+	Result SimulateTransactionResult
+}
+
+func (e *SendTransactionPreflightFailureError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+func (e *SendTransactionPreflightFailureError) Unwrap() error {
+	return e.RPCError
+}
+
+func (e *SendTransactionPreflightFailureError) Is(target error) bool {
+	return target == ErrBlockhashNotFound && e.Result.Err == "BlockhashNotFound"
+}
+
+// NodeUnhealthyError is returned when the node is behind the cluster.
+type NodeUnhealthyError struct {
+	*jsonrpc.RPCError
+
+	// Number of slots the node is behind, if known.
+	NumSlotsBehind *uint64
+}
+
+func (e *NodeUnhealthyError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+func (e *NodeUnhealthyError) Unwrap() error {
+	return e.RPCError
+}
+
+// SlotSkippedError is returned when the requested slot was skipped,
+// or is missing from the node (or long-term storage).
+type SlotSkippedError struct {
+	*jsonrpc.RPCError
+
+	// Slot that was skipped, parsed from the error message;
+	// zero if it could not be parsed.
+	Slot uint64
+}
+
+func (e *SlotSkippedError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+func (e *SlotSkippedError) Unwrap() error {
+	return e.RPCError
+}
+
+// RateLimitError is returned when a request is rejected because of
+// rate limiting, either with HTTP 429 or with a JSON-RPC error.
+type RateLimitError struct {
+	// RetryAfter is the delay requested by the server before retrying;
+	// zero if the server did not provide one.
+	RetryAfter time.Duration
+
+	err error
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited (retry after %s): %s", e.RetryAfter, e.err)
+	}
+	return fmt.Sprintf("rate limited: %s", e.err)
+}
+
+// Unwrap returns the underlying *jsonrpc.HTTPError or *jsonrpc.RPCError.
+func (e *RateLimitError) Unwrap() error {
+	return e.err
+}
+
+// newTypedError converts the errors returned by the JSON-RPC client
+// into the typed errors of this package; other errors are returned as is.
+func newTypedError(log *zap.Logger, err error) error {
+	switch e := err.(type) {
+	case *jsonrpc.HTTPError:
+		if e.Code == http.StatusTooManyRequests {
+			retryAfter, _ := parseRetryAfter(e.Header.Get("Retry-After"), time.Now())
+			return &RateLimitError{RetryAfter: retryAfter, err: e}
+		}
+	case *jsonrpc.RPCError:
+		switch e.Code {
+		case ErrorCodeSendTransactionPreflightFailure:
+			out := &SendTransactionPreflightFailureError{RPCError: e}
+			if err := remarshal(e.Data, &out.Result); err != nil {
+				logger(log).Debug("unable to decode preflight failure data", zap.Error(err))
+			}
+			return out
+		case ErrorCodeNodeUnhealthy:
+			out := &NodeUnhealthyError{RPCError: e}
+			var data struct {
+				NumSlotsBehind *uint64 `json:"numSlotsBehind"`
+			}
+			if remarshal(e.Data, &data) == nil {
+				out.NumSlotsBehind = data.NumSlotsBehind
+			}
+			return out
+		case ErrorCodeSlotSkipped, ErrorCodeLongTermStorageSlotSkipped:
+			out := &SlotSkippedError{RPCError: e}
+			// e.g. "Slot 123 was skipped, or missing due to ledger jump to recent snapshot"
+			if rest := strings.TrimPrefix(e.Message, "Slot "); rest != e.Message {
+				if end := strings.IndexByte(rest, ' '); end > 0 {
+					out.Slot, _ = strconv.ParseUint(rest[:end], 10, 64)
+				}
+			}
+			return out
+		case ErrorCodeRateLimited, http.StatusTooManyRequests:
+			return &RateLimitError{err: e}
+		}
+	}
+	return err
+}
+
+// remarshal decodes the generic JSON value in into out.
+func remarshal(in interface{}, out interface{}) error {
+	if in == nil {
+		return nil
+	}
+	buf, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, out)
+}
+
+// typedErrorClient converts the errors of the wrapped client with newTypedError.
+type typedErrorClient struct {
+	rpcClient JSONRPCClient
+	logger    *zap.Logger
+}
+
+func (c *typedErrorClient) CallForInto(ctx context.Context, out interface{}, method string, params any) error {
+	return newTypedError(c.logger, c.rpcClient.CallForInto(ctx, out, method, params))
+}
+
+func (c *typedErrorClient) CallWithCallback(
+	ctx context.Context,
+	method string,
+	params []interface{},
+	callback func(*http.Request, *http.Response) error,
+) error {
+	return newTypedError(c.logger, c.rpcClient.CallWithCallback(ctx, method, params, callback))
+}
+
+func (c *typedErrorClient) CallBatch(ctx context.Context, requests jsonrpc.RPCRequests) (jsonrpc.RPCResponses, error) {
+	out, err := c.rpcClient.CallBatch(ctx, requests)
+	return out, newTypedError(c.logger, err)
+}
+
+// Close closes the wrapped client.
+func (c *typedErrorClient) Close() error {
+	if closer, ok := c.rpcClient.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newErrorServer(status int, header http.Header, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		for k, v := range header {
+			rw.Header()[k] = v
+		}
+		rw.WriteHeader(status)
+		rw.Write([]byte(body))
+	}))
+}
+
+func TestTypedErrors_preflightFailure(t *testing.T) {
+	server := newErrorServer(http.StatusOK, nil, `{"jsonrpc":"2.0","error":{"code":-32002,"message":"Transaction simulation failed: Blockhash not found","data":{"accounts":null,"err":"BlockhashNotFound","logs":["Program log: hello"],"unitsConsumed":1234}},"id":1}`)
+	defer server.Close()
+
+	_, err := New(server.URL).SendRawTransaction(context.Background(), []byte{1, 2, 3})
+	require.Error(t, err)
+
+	var preflightErr *SendTransactionPreflightFailureError
+	require.True(t, errors.As(err, &preflightErr))
+	assert.Equal(t, []string{"Program log: hello"}, preflightErr.Result.Logs)
+	require.NotNil(t, preflightErr.Result.UnitsConsumed)
+	assert.Equal(t, uint64(1234), *preflightErr.Result.UnitsConsumed)
+	assert.ErrorIs(t, err, ErrBlockhashNotFound)
+
+	// The JSON-RPC error is still available.
+	var rpcErr *jsonrpc.RPCError
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, ErrorCodeSendTransactionPreflightFailure, rpcErr.Code)
+}
+
+func TestTypedErrors_nodeUnhealthy(t *testing.T) {
+	server := newErrorServer(http.StatusOK, nil, `{"jsonrpc":"2.0","error":{"code":-32005,"message":"Node is behind by 42 slots","data":{"numSlotsBehind":42}},"id":1}`)
+	defer server.Close()
+
+	_, err := New(server.URL).GetSlot(context.Background(), "")
+	var unhealthyErr *NodeUnhealthyError
+	require.True(t, errors.As(err, &unhealthyErr))
+	require.NotNil(t, unhealthyErr.NumSlotsBehind)
+	assert.Equal(t, uint64(42), *unhealthyErr.NumSlotsBehind)
+	assert.NotErrorIs(t, err, ErrBlockhashNotFound)
+}
+
+func TestTypedErrors_slotSkipped(t *testing.T) {
+	server := newErrorServer(http.StatusOK, nil, `{"jsonrpc":"2.0","error":{"code":-32007,"message":"Slot 123456 was skipped, or missing due to ledger jump to recent snapshot"},"id":1}`)
+	defer server.Close()
+
+	_, err := New(server.URL).GetBlock(context.Background(), 123456)
+	var skippedErr *SlotSkippedError
+	require.True(t, errors.As(err, &skippedErr))
+	assert.Equal(t, uint64(123456), skippedErr.Slot)
+}
+
+func TestTypedErrors_rateLimit(t *testing.T) {
+	server := newErrorServer(http.StatusTooManyRequests, http.Header{"Retry-After": {"7"}}, `Too many requests`)
+	defer server.Close()
+
+	_, err := NewWithOptions(server.URL, &Options{RateLimitRetries: -1}).GetBalance(context.Background(), solana.PublicKey{}, "")
+	var rateLimitErr *RateLimitError
+	require.True(t, errors.As(err, &rateLimitErr))
+	assert.Equal(t, 7*time.Second, rateLimitErr.RetryAfter)
+
+	var httpErr *jsonrpc.HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusTooManyRequests, httpErr.Code)
+
+	server = newErrorServer(http.StatusOK, nil, `{"jsonrpc":"2.0","error":{"code":-32429,"message":"rate limited"},"id":1}`)
+	defer server.Close()
+	_, err = New(server.URL).GetSlot(context.Background(), "")
+	require.True(t, errors.As(err, &rateLimitErr))
+	assert.Zero(t, rateLimitErr.RetryAfter)
+}
+
+func TestTypedErrors_customRPCClient(t *testing.T) {
+	server := newErrorServer(http.StatusOK, nil, `{"jsonrpc":"2.0","error":{"code":-32005,"message":"Node is behind by 42 slots","data":{"numSlotsBehind":42}},"id":1}`)
+	defer server.Close()
+
+	// The errors of a custom client are returned as they are.
+	_, err := NewWithCustomRPCClient(jsonrpc.NewClient(server.URL)).GetSlot(context.Background(), "")
+	_, ok := err.(*jsonrpc.RPCError)
+	require.True(t, ok, "got %T", err)
+
+	_, err = NewWithCustomRPCClient(NewTypedErrorClient(jsonrpc.NewClient(server.URL))).GetSlot(context.Background(), "")
+	var unhealthyErr *NodeUnhealthyError
+	require.True(t, errors.As(err, &unhealthyErr))
+}
@@ -0,0 +1,264 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/mr-tron/base58"
+)
+
+// MaxBase58MemcmpBytes is the maximum length of memcmp bytes
+// accepted by RPC nodes when encoded as base58; longer
+// byte strings must be encoded as base64.
+const MaxBase58MemcmpBytes = 128
+
+// Match reports whether the provided account data satisfies the filter,
+// evaluating it the same way the RPC node does.
+// A filter with neither Memcmp nor DataSize set matches any data.
+func (f RPCFilter) Match(data []byte) bool {
+	if f.DataSize != 0 && uint64(len(data)) != f.DataSize {
+		return false
+	}
+	if f.Memcmp != nil {
+		return f.Memcmp.Match(data)
+	}
+	return true
+}
+
+// Match reports whether the provided account data contains
+// the filter bytes at the filter offset.
+func (f *RPCFilterMemcmp) Match(data []byte) bool {
+	if f.Offset > uint64(len(data)) {
+		return false
+	}
+	end := f.Offset + uint64(len(f.Bytes))
+	if end > uint64(len(data)) {
+		return false
+	}
+	return bytes.Equal(data[f.Offset:end], f.Bytes)
+}
+
+// MatchFilters reports whether the provided account data satisfies all filters
+// (there is an implicit AND between filters).
+func MatchFilters(filters []RPCFilter, data []byte) bool {
+	for _, f := range filters {
+		if !f.Match(data) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewDataSizeFilter returns a filter matching accounts whose data is exactly size bytes.
+func NewDataSizeFilter(size uint64) RPCFilter {
+	return RPCFilter{DataSize: size}
+}
+
+// NewMemcmpFilter returns a filter matching accounts whose data contains
+// the provided bytes at offset. The bytes are sent base58-encoded,
+// or base64-encoded when longer than MaxBase58MemcmpBytes.
+func NewMemcmpFilter(offset uint64, data []byte) RPCFilter {
+	memcmp := &RPCFilterMemcmp{
+		Offset: offset,
+		Bytes:  solana.Base58(data),
+	}
+	if len(data) > MaxBase58MemcmpBytes {
+		memcmp.Encoding = solana.EncodingBase64
+	}
+	return RPCFilter{Memcmp: memcmp}
+}
+
+// NewMemcmpPubkeyFilter returns a filter matching accounts whose data
+// contains the provided public key at offset.
+func NewMemcmpPubkeyFilter(offset uint64, key solana.PublicKey) RPCFilter {
+	return NewMemcmpFilter(offset, key[:])
+}
+
+// MarshalJSON encodes the bytes with the filter encoding (base58 by default).
+func (f RPCFilterMemcmp) MarshalJSON() ([]byte, error) {
+	out := rpcFilterMemcmpJSON{
+		Offset:   f.Offset,
+		Encoding: f.Encoding,
+	}
+	switch f.Encoding {
+	case "", solana.EncodingBase58:
+		out.Bytes = base58.Encode(f.Bytes)
+	case solana.EncodingBase64:
+		out.Bytes = base64.StdEncoding.EncodeToString(f.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported memcmp encoding %q", f.Encoding)
+	}
+	return json.Marshal(out)
+}
+
+func (f *RPCFilterMemcmp) UnmarshalJSON(data []byte) (err error) {
+	var in rpcFilterMemcmpJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	f.Offset = in.Offset
+	f.Encoding = in.Encoding
+	switch in.Encoding {
+	case "", solana.EncodingBase58:
+		f.Bytes, err = base58.Decode(in.Bytes)
+	case solana.EncodingBase64:
+		f.Bytes, err = base64.StdEncoding.DecodeString(in.Bytes)
+	default:
+		err = fmt.Errorf("unsupported memcmp encoding %q", in.Encoding)
+	}
+	return err
+}
+
+type rpcFilterMemcmpJSON struct {
+	Offset   uint64              `json:"offset"`
+	Bytes    string              `json:"bytes"`
+	Encoding solana.EncodingType `json:"encoding,omitempty"`
+}
+
+// FilterBuilder builds a list of filters.
+// Errors are accumulated and returned by Build.
+type FilterBuilder struct {
+	filters []RPCFilter
+	err     error
+}
+
+// NewFilterBuilder creates an empty FilterBuilder.
+func NewFilterBuilder() *FilterBuilder {
+	return &FilterBuilder{}
+}
+
+// DataSize adds a dataSize filter.
+func (b *FilterBuilder) DataSize(size uint64) *FilterBuilder {
+	b.filters = append(b.filters, NewDataSizeFilter(size))
+	return b
+}
+
+// Memcmp adds a memcmp filter (see NewMemcmpFilter).
+func (b *FilterBuilder) Memcmp(offset uint64, data []byte) *FilterBuilder {
+	b.filters = append(b.filters, NewMemcmpFilter(offset, data))
+	return b
+}
+
+// MemcmpPubkey adds a memcmp filter comparing a public key.
+func (b *FilterBuilder) MemcmpPubkey(offset uint64, key solana.PublicKey) *FilterBuilder {
+	b.filters = append(b.filters, NewMemcmpPubkeyFilter(offset, key))
+	return b
+}
+
+// MemcmpField adds a memcmp filter comparing the field of the account
+// layout described by the struct layout (see FieldOffset) with value,
+// encoded with the binary (little endian) encoding.
+func (b *FilterBuilder) MemcmpField(layout interface{}, field string, value interface{}) *FilterBuilder {
+	if b.err != nil {
+		return b
+	}
+	offset, err := FieldOffset(layout, field)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	data, err := bin.MarshalBin(value)
+	if err != nil {
+		b.err = fmt.Errorf("memcmp field %s: encode value: %w", field, err)
+		return b
+	}
+	return b.Memcmp(offset, data)
+}
+
+// Build returns the filters, or the first error encountered.
+func (b *FilterBuilder) Build() ([]RPCFilter, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.filters, nil
+}
+
+var (
+	typeOfUint128 = reflect.TypeOf(bin.Uint128{})
+	typeOfInt128  = reflect.TypeOf(bin.Int128{})
+)
+
+// FieldOffset returns the byte offset of the named field in the binary layout
+// of the provided struct (or pointer to struct), e.g. FieldOffset(token.Account{}, "Owner").
+// Nested fields are not supported; all the fields that precede the named one
+// must have a fixed size (integers, booleans, arrays such as solana.PublicKey,
+// and structs made of those). Fields tagged `bin:"-"` are skipped.
+func FieldOffset(layout interface{}, field string) (uint64, error) {
+	typ := reflect.TypeOf(layout)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return 0, fmt.Errorf("field offset: %T is not a struct", layout)
+	}
+	var offset uint64
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Tag.Get("bin") == "-" {
+			continue
+		}
+		if f.Name == field {
+			return offset, nil
+		}
+		size, ok := fixedSize(f.Type)
+		if !ok {
+			return 0, fmt.Errorf("field offset: %s.%s precedes %s and has no fixed size", typ.Name(), f.Name, field)
+		}
+		offset += size
+	}
+	return 0, fmt.Errorf("field offset: %s has no field %s", typ.Name(), field)
+}
+
+// fixedSize returns the size of the binary encoding of values of the provided type,
+// if it is the same for all values.
+func fixedSize(typ reflect.Type) (uint64, bool) {
+	if typ == typeOfUint128 || typ == typeOfInt128 {
+		return 16, true
+	}
+	switch typ.Kind() {
+	case reflect.Bool, reflect.Uint8, reflect.Int8:
+		return 1, true
+	case reflect.Uint16, reflect.Int16:
+		return 2, true
+	case reflect.Uint32, reflect.Int32, reflect.Float32:
+		return 4, true
+	case reflect.Uint64, reflect.Int64, reflect.Float64:
+		return 8, true
+	case reflect.Array:
+		size, ok := fixedSize(typ.Elem())
+		return size * uint64(typ.Len()), ok
+	case reflect.Struct:
+		var total uint64
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if f.Tag.Get("bin") == "-" {
+				continue
+			}
+			size, ok := fixedSize(f.Type)
+			if !ok {
+				return 0, false
+			}
+			total += size
+		}
+		return total, true
+	}
+	return 0, false
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/mr-tron/base58"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchFilters(t *testing.T) {
+	data := []byte{1, 2, 3, 4, 5}
+
+	assert.True(t, MatchFilters(nil, data))
+	assert.True(t, MatchFilters([]RPCFilter{{DataSize: 5}}, data))
+	assert.False(t, MatchFilters([]RPCFilter{{DataSize: 4}}, data))
+
+	assert.True(t, MatchFilters([]RPCFilter{
+		{DataSize: 5},
+		{Memcmp: &RPCFilterMemcmp{Offset: 2, Bytes: solana.Base58{3, 4}}},
+	}, data))
+	assert.False(t, MatchFilters([]RPCFilter{
+		{DataSize: 5},
+		{Memcmp: &RPCFilterMemcmp{Offset: 2, Bytes: solana.Base58{4, 4}}},
+	}, data))
+
+	// Out of bounds.
+	assert.False(t, MatchFilters([]RPCFilter{
+		{Memcmp: &RPCFilterMemcmp{Offset: 4, Bytes: solana.Base58{5, 6}}},
+	}, data))
+	assert.False(t, MatchFilters([]RPCFilter{
+		{Memcmp: &RPCFilterMemcmp{Offset: 10, Bytes: solana.Base58{}}},
+	}, data))
+}
+
+// tokenAccount mirrors the layout of an SPL token account.
+type tokenAccount struct {
+	Mint     solana.PublicKey
+	Owner    solana.PublicKey
+	Amount   uint64
+	Delegate *solana.PublicKey `bin:"optional"`
+	State    uint8
+}
+
+func TestFilterBuilder(t *testing.T) {
+	owner := solana.MustPublicKeyFromBase58("9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM")
+	filters, err := NewFilterBuilder().
+		DataSize(165).
+		MemcmpField(tokenAccount{}, "Owner", owner).
+		MemcmpField(tokenAccount{}, "Amount", uint64(1)).
+		Memcmp(0, bytes.Repeat([]byte{1}, MaxBase58MemcmpBytes+1)).
+		Build()
+	require.NoError(t, err)
+
+	buf, err := stdjson.Marshal(filters)
+	require.NoError(t, err)
+	long := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, MaxBase58MemcmpBytes+1))
+	assert.JSONEq(t,
+		`[
+			{"dataSize":165},
+			{"memcmp":{"offset":32,"bytes":"9WzDXwBbmkg8ZTbNMqUxvQRAyrZzDsGYdLVL9zYtAWWM"}},
+			{"memcmp":{"offset":64,"bytes":"`+base58.Encode([]byte{1, 0, 0, 0, 0, 0, 0, 0})+`"}},
+			{"memcmp":{"offset":0,"bytes":"`+long+`","encoding":"base64"}}
+		]`,
+		string(buf),
+	)
+
+	var decoded []RPCFilter
+	require.NoError(t, stdjson.Unmarshal(buf, &decoded))
+	assert.Equal(t, filters, decoded)
+
+	// Fields after a variable-size field are not supported.
+	_, err = NewFilterBuilder().MemcmpField(tokenAccount{}, "State", uint8(1)).Build()
+	require.Error(t, err)
+	_, err = NewFilterBuilder().MemcmpField(tokenAccount{}, "Missing", uint8(1)).Build()
+	require.Error(t, err)
+}
+
+func TestFieldOffset(t *testing.T) {
+	type layout struct {
+		Discriminator [8]byte
+		Flag          bool
+		Ignored       string `bin:"-"`
+		Authority     solana.PublicKey
+		Nested        struct{ A, B uint16 }
+		Amount        uint64
+	}
+	offset, err := FieldOffset(&layout{}, "Authority")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), offset)
+
+	offset, err = FieldOffset(layout{}, "Amount")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(45), offset)
+
+	_, err = FieldOffset(42, "Amount")
+	require.Error(t, err)
+}
@@ -113,7 +113,7 @@ func (cl *Client) getAccountInfoWithOpts(
 
 	obj := M{
 		// default encoding:
-		"encoding": solana.EncodingBase64,
+		"encoding": implicitEncoding(solana.EncodingBase64),
 	}
 
 	if opts != nil {
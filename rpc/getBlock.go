@@ -85,6 +85,38 @@ func (cl *Client) GetBlockWithOpts(
 	opts *GetBlockOpts,
 ) (out *GetBlockResult, err error) {
 
+	params, err := newGetBlockParams(
+		slot,
+		opts,
+		// Valid encodings:
+		// solana.EncodingJSON, // TODO
+		// solana.EncodingJSONParsed, // TODO
+		solana.EncodingBase58,
+		solana.EncodingBase64,
+		solana.EncodingBase64Zstd,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cl.rpcClient.CallForInto(ctx, &out, "getBlock", params)
+
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		// Block is not confirmed.
+		return nil, ErrNotConfirmed
+	}
+	return
+}
+
+// newGetBlockParams builds the getBlock params, accepting only the provided encodings.
+func newGetBlockParams(
+	slot uint64,
+	opts *GetBlockOpts,
+	encodings ...solana.EncodingType,
+) ([]interface{}, error) {
 	obj := M{
 		"encoding": solana.EncodingBase64,
 	}
@@ -100,15 +132,7 @@ func (cl *Client) GetBlockWithOpts(
 			obj["commitment"] = opts.Commitment
 		}
 		if opts.Encoding != "" {
-			if !solana.IsAnyOfEncodingType(
-				opts.Encoding,
-				// Valid encodings:
-				// solana.EncodingJSON, // TODO
-				// solana.EncodingJSONParsed, // TODO
-				solana.EncodingBase58,
-				solana.EncodingBase64,
-				solana.EncodingBase64Zstd,
-			) {
+			if !solana.IsAnyOfEncodingType(opts.Encoding, encodings...) {
 				return nil, fmt.Errorf("provided encoding is not supported: %s", opts.Encoding)
 			}
 			obj["encoding"] = opts.Encoding
@@ -118,18 +142,7 @@ func (cl *Client) GetBlockWithOpts(
 		}
 	}
 
-	params := []interface{}{slot, obj}
-
-	err = cl.rpcClient.CallForInto(ctx, &out, "getBlock", params)
-
-	if err != nil {
-		return nil, err
-	}
-	if out == nil {
-		// Block is not confirmed.
-		return nil, ErrNotConfirmed
-	}
-	return
+	return []interface{}{slot, obj}, nil
 }
 
 type GetBlockResult struct {
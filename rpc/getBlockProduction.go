@@ -113,3 +113,36 @@ type SlotRangeResponse struct {
 	// Last slot of block production information (inclusive)
 	LastSlot uint64 `json:"lastSlot"`
 }
+
+// BlockProduction is the block production of a validator over a slot range.
+type BlockProduction struct {
+	// Number of slots the validator was the leader of.
+	LeaderSlots int64
+
+	// Number of blocks the validator produced.
+	BlocksProduced int64
+}
+
+// SkippedSlots returns the number of leader slots without a block.
+func (p BlockProduction) SkippedSlots() int64 {
+	return p.LeaderSlots - p.BlocksProduced
+}
+
+// SkipRate returns the fraction of leader slots without a block,
+// or zero if the validator had no leader slots.
+func (p BlockProduction) SkipRate() float64 {
+	if p.LeaderSlots <= 0 {
+		return 0
+	}
+	return float64(p.SkippedSlots()) / float64(p.LeaderSlots)
+}
+
+// Get returns the block production of the validator,
+// and false if the validator had no leader slots in the range.
+func (m IdentityToSlotsBlocks) Get(identity solana.PublicKey) (BlockProduction, bool) {
+	counts, ok := m[identity]
+	if !ok {
+		return BlockProduction{}, false
+	}
+	return BlockProduction{LeaderSlots: counts[0], BlocksProduced: counts[1]}, true
+}
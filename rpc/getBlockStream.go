@@ -0,0 +1,264 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// GetBlockStream is like GetBlockWithOpts, but decodes the response directly
+// from the HTTP body and calls fn for each transaction of the block as soon as
+// it is decoded, instead of buffering the whole block in memory.
+// The index is the position of the transaction in the block.
+//
+// The returned result holds the block header, signatures and rewards;
+// its Transactions field is always nil.
+// Returning an error from fn stops the decoding and is returned as is.
+//
+// Unlike GetBlockWithOpts, the "json" and "jsonParsed" encodings are supported.
+func (cl *Client) GetBlockStream(
+	ctx context.Context,
+	slot uint64,
+	opts *GetBlockOpts,
+	fn func(index int, tx *TransactionWithMeta) error,
+) (out *GetBlockResult, err error) {
+	params, err := newGetBlockParams(
+		slot,
+		opts,
+		// Valid encodings:
+		solana.EncodingJSON,
+		solana.EncodingJSONParsed,
+		solana.EncodingBase58,
+		solana.EncodingBase64,
+		solana.EncodingBase64Zstd,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cl.rpcClient.CallWithCallback(ctx, "getBlock", params,
+		func(req *http.Request, resp *http.Response) error {
+			// The callback can be invoked more than once if the call is retried.
+			out = nil
+			return decodeResultStream(req, resp, "getBlock", func(dec *stdjson.Decoder) error {
+				var err error
+				out, err = decodeBlockStream(dec, fn)
+				return err
+			})
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		// Block is not confirmed.
+		return nil, ErrNotConfirmed
+	}
+	return
+}
+
+// GetTransactionStream is like GetTransaction, but decodes the response
+// directly from the HTTP body, without buffering the raw response first.
+//
+// Unlike GetTransaction, the "json" encoding is supported.
+func (cl *Client) GetTransactionStream(
+	ctx context.Context,
+	txSig solana.Signature, // transaction signature
+	opts *GetTransactionOpts,
+) (out *GetTransactionResult, err error) {
+	params, err := newGetTransactionParams(
+		txSig,
+		opts,
+		// Valid encodings:
+		solana.EncodingJSON,
+		solana.EncodingBase58,
+		solana.EncodingBase64,
+		solana.EncodingBase64Zstd,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cl.rpcClient.CallWithCallback(ctx, "getTransaction", params,
+		func(req *http.Request, resp *http.Response) error {
+			out = nil
+			return decodeResultStream(req, resp, "getTransaction", func(dec *stdjson.Decoder) error {
+				return dec.Decode(&out)
+			})
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, ErrNotFound
+	}
+	return
+}
+
+// decodeResultStream decodes a JSON-RPC response from the body of resp,
+// handing the decoder to decodeResult when the "result" member is reached.
+//
+// Responses with an HTTP error status are fully decoded before decodeResult
+// could be called, so that a retried call never decodes a partial result.
+func decodeResultStream(
+	req *http.Request,
+	resp *http.Response,
+	method string,
+	decodeResult func(dec *stdjson.Decoder) error,
+) error {
+	if resp.StatusCode >= 400 {
+		var rpcResponse *jsonrpc.RPCResponse
+		err := stdjson.NewDecoder(resp.Body).Decode(&rpcResponse)
+		if err == nil && rpcResponse != nil && rpcResponse.Error != nil {
+			return rpcResponse.Error
+		}
+		httpErr := jsonrpc.NewHTTPError(
+			resp.StatusCode,
+			fmt.Errorf("rpc call %v() on %v status code: %v. could not decode body to rpc response", method, req.URL.String(), resp.StatusCode),
+		)
+		httpErr.Header = resp.Header
+		return httpErr
+	}
+
+	dec := stdjson.NewDecoder(resp.Body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return fmt.Errorf("rpc call %v() on %v: could not decode body to rpc response: %w", method, req.URL.String(), err)
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("rpc call %v(): %w", method, err)
+		}
+		switch key {
+		case "result":
+			if err := decodeResult(dec); err != nil {
+				return err
+			}
+		case "error":
+			var rpcErr *jsonrpc.RPCError
+			if err := dec.Decode(&rpcErr); err != nil {
+				return fmt.Errorf("rpc call %v(): could not decode error: %w", method, err)
+			}
+			if rpcErr != nil {
+				return rpcErr
+			}
+		default:
+			var skip stdjson.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return fmt.Errorf("rpc call %v(): %w", method, err)
+			}
+		}
+	}
+	return nil
+}
+
+// decodeBlockStream decodes a getBlock result, calling fn for each transaction.
+// It returns nil if the result is null.
+func decodeBlockStream(
+	dec *stdjson.Decoder,
+	fn func(index int, tx *TransactionWithMeta) error,
+) (*GetBlockResult, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return nil, nil
+	}
+	if tok != stdjson.Delim('{') {
+		return nil, fmt.Errorf("unexpected token %v at start of block", tok)
+	}
+
+	out := new(GetBlockResult)
+	fields := map[string]interface{}{
+		"blockhash":         &out.Blockhash,
+		"previousBlockhash": &out.PreviousBlockhash,
+		"parentSlot":        &out.ParentSlot,
+		"signatures":        &out.Signatures,
+		"rewards":           &out.Rewards,
+		"blockTime":         &out.BlockTime,
+		"blockHeight":       &out.BlockHeight,
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key == "transactions" {
+			if err := decodeTransactionsStream(dec, fn); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		field, ok := fields[key.(string)]
+		if !ok {
+			var skip stdjson.RawMessage
+			field = &skip
+		}
+		if err := dec.Decode(field); err != nil {
+			return nil, fmt.Errorf("unable to decode block field %q: %w", key, err)
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func decodeTransactionsStream(
+	dec *stdjson.Decoder,
+	fn func(index int, tx *TransactionWithMeta) error,
+) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != stdjson.Delim('[') {
+		return fmt.Errorf("unexpected token %v at start of transactions", tok)
+	}
+	for index := 0; dec.More(); index++ {
+		var tx TransactionWithMeta
+		if err := dec.Decode(&tx); err != nil {
+			return fmt.Errorf("unable to decode transaction %d: %w", index, err)
+		}
+		if fn != nil {
+			if err := fn(index, &tx); err != nil {
+				return err
+			}
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *stdjson.Decoder, delim stdjson.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const streamTestTx = `{"meta":{"err":null,"fee":5000,"innerInstructions":[],"logMessages":[],"postBalances":[],"preBalances":[],"status":{"Ok":null}},"transaction":["AcpmPgtaSCzI2vuOUXduljmnoc1zIqMETzEJ8zmF+\/yy2AABHMNonpVleveVw4a4Fo7LUDWtxo2FkyzFr2x9DQIBAAMB47aX3y9Dfp+\/ycSDXt0Ph3TfZQBqPSXMQYToKtUtr5kNhniVeV7Las6qkeV8d0rksxV9de0GF7p4nzQUVEnrWwEEBAECAwAEdGVzdA==","base64"]}`
+
+const streamTestBlock = `{"blockHeight":69213636,"blockTime":1625227950,"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB","parentSlot":83987983,"previousBlockhash":"Aq9jSXe1jRzfiaBcRFLe4wm7j499vWVEeFQrq5nnXfZN","rewards":[{"lamports":1595000,"postBalance":482032983798,"pubkey":"5rL3AaidKJa4ChSV3ys1SvpDg9L4amKiwYayGR5oL3dq","rewardType":"Fee"}],"transactions":[` + streamTestTx + `,` + streamTestTx + `]}`
+
+func TestClient_GetBlockStream(t *testing.T) {
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(streamTestBlock)))
+	defer closer()
+	client := New(server.URL)
+
+	expected, err := client.GetBlock(context.Background(), 33)
+	require.NoError(t, err)
+
+	var txs []TransactionWithMeta
+	out, err := client.GetBlockStream(context.Background(), 33, nil, func(index int, tx *TransactionWithMeta) error {
+		assert.Equal(t, len(txs), index)
+		txs = append(txs, *tx)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, expected.Transactions, txs)
+	assert.Nil(t, out.Transactions)
+	expected.Transactions = nil
+	assert.Equal(t, expected, out)
+
+	reqBody := server.RequestBody(t)
+	assert.Equal(t,
+		[]interface{}{
+			float64(33),
+			map[string]interface{}{
+				"encoding": string(solana.EncodingBase64),
+			},
+		},
+		reqBody["params"],
+	)
+}
+
+func TestClient_GetBlockStream_jsonParsed(t *testing.T) {
+	block := `{"blockhash":"5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB","parentSlot":1,"transactions":[{"meta":null,"transaction":{"message":{"instructions":[{"parsed":{"type":"transfer"},"program":"system"}]},"signatures":[]}}]}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(block)))
+	defer closer()
+
+	var count int
+	_, err := New(server.URL).GetBlockStream(context.Background(), 2, &GetBlockOpts{Encoding: solana.EncodingJSONParsed},
+		func(index int, tx *TransactionWithMeta) error {
+			count++
+			assert.Equal(t, solana.EncodingJSONParsed, tx.Transaction.Encoding())
+			return nil
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, "jsonParsed", server.RequestBody(t)["params"].([]interface{})[1].(map[string]interface{})["encoding"])
+}
+
+func TestClient_GetBlockStream_stop(t *testing.T) {
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(streamTestBlock)))
+	defer closer()
+
+	errStop := errors.New("stop")
+	var count int
+	_, err := New(server.URL).GetBlockStream(context.Background(), 33, nil, func(int, *TransactionWithMeta) error {
+		count++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, count)
+}
+
+func TestClient_GetBlockStream_notConfirmed(t *testing.T) {
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(`null`)))
+	defer closer()
+
+	_, err := New(server.URL).GetBlockStream(context.Background(), 33, nil, nil)
+	assert.ErrorIs(t, err, ErrNotConfirmed)
+}
+
+func TestClient_GetBlockStream_errors(t *testing.T) {
+	t.Run("rpc error", func(t *testing.T) {
+		server := newErrorServer(http.StatusOK, nil, `{"jsonrpc":"2.0","error":{"code":-32007,"message":"Slot 33 was skipped, or missing due to ledger jump to recent snapshot"},"id":1}`)
+		defer server.Close()
+
+		_, err := New(server.URL).GetBlockStream(context.Background(), 33, nil, nil)
+		var skipped *SlotSkippedError
+		require.True(t, errors.As(err, &skipped))
+		assert.Equal(t, uint64(33), skipped.Slot)
+	})
+	t.Run("http error", func(t *testing.T) {
+		server := newErrorServer(http.StatusTooManyRequests, http.Header{"Retry-After": {"3"}}, `Too many requests`)
+		defer server.Close()
+
+		_, err := New(server.URL).GetBlockStream(context.Background(), 33, nil, nil)
+		var rateLimited *RateLimitError
+		require.True(t, errors.As(err, &rateLimited))
+		assert.Equal(t, 3*time.Second, rateLimited.RetryAfter)
+	})
+	t.Run("unsupported encoding", func(t *testing.T) {
+		_, err := New("http://localhost").GetBlockStream(context.Background(), 33, &GetBlockOpts{Encoding: "foo"}, nil)
+		require.Error(t, err)
+	})
+}
+
+func TestClient_GetTransactionStream(t *testing.T) {
+	response := wrapIntoRPC(`{"slot":48291656,` + streamTestTx[1:])
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(response))
+	defer closer()
+	client := New(server.URL)
+
+	sig := solana.MustSignatureFromBase58("53hoZ98EsCMA6L63GWM65M3Bd3WqA4LxD8bcJkbKoKWhbJFqX9M1WZ4fSjt8bYyZn21NwNnV2A25zirBni9Qk6LR")
+	expected, err := client.GetTransaction(context.Background(), sig, nil)
+	require.NoError(t, err)
+
+	out, err := client.GetTransactionStream(context.Background(), sig, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected, out)
+	assert.Equal(t, uint64(48291656), out.Slot)
+
+	notFound, closer2 := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(`null`)))
+	defer closer2()
+	_, err = New(notFound.URL).GetTransactionStream(context.Background(), sig, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
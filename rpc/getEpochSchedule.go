@@ -16,6 +16,7 @@ package rpc
 
 import (
 	"context"
+	"math/bits"
 )
 
 // GetEpochSchedule returns epoch schedule information from this cluster's genesis config.
@@ -40,3 +41,39 @@ type GetEpochScheduleResult struct {
 	// MINIMUM_SLOTS_PER_EPOCH * (2.pow(firstNormalEpoch) - 1)
 	FirstNormalSlot uint64 `json:"firstNormalSlot"`
 }
+
+// MinimumSlotsPerEpoch is the number of slots in the first epoch
+// when the schedule has a warmup period.
+const MinimumSlotsPerEpoch = 32
+
+// GetEpoch returns the epoch that contains the provided slot,
+// and the index of the slot relative to the start of that epoch.
+func (s *GetEpochScheduleResult) GetEpoch(slot uint64) (epoch uint64, slotIndex uint64) {
+	if slot < s.FirstNormalSlot {
+		// During warmup, epoch lengths double starting from MinimumSlotsPerEpoch.
+		epoch = uint64(bits.Len64(slot+MinimumSlotsPerEpoch)) - uint64(bits.TrailingZeros64(MinimumSlotsPerEpoch)) - 1
+		epochLen := uint64(1) << (epoch + uint64(bits.TrailingZeros64(MinimumSlotsPerEpoch)))
+		return epoch, slot - (epochLen - MinimumSlotsPerEpoch)
+	}
+	if s.SlotsPerEpoch == 0 {
+		return s.FirstNormalEpoch, slot - s.FirstNormalSlot
+	}
+	normalSlotIndex := slot - s.FirstNormalSlot
+	return s.FirstNormalEpoch + normalSlotIndex/s.SlotsPerEpoch, normalSlotIndex % s.SlotsPerEpoch
+}
+
+// GetFirstSlotInEpoch returns the first slot of the provided epoch.
+func (s *GetEpochScheduleResult) GetFirstSlotInEpoch(epoch uint64) uint64 {
+	if epoch <= s.FirstNormalEpoch {
+		return ((uint64(1) << epoch) - 1) * MinimumSlotsPerEpoch
+	}
+	return (epoch-s.FirstNormalEpoch)*s.SlotsPerEpoch + s.FirstNormalSlot
+}
+
+// GetSlotsInEpoch returns the number of slots in the provided epoch.
+func (s *GetEpochScheduleResult) GetSlotsInEpoch(epoch uint64) uint64 {
+	if epoch < s.FirstNormalEpoch {
+		return uint64(1) << (epoch + uint64(bits.TrailingZeros64(MinimumSlotsPerEpoch)))
+	}
+	return s.SlotsPerEpoch
+}
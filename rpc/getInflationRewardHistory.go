@@ -0,0 +1,168 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+var (
+	// DefaultInflationRewardEpochsPerBatch is the number of epochs
+	// requested in a single batch by GetInflationRewardHistory.
+	DefaultInflationRewardEpochsPerBatch = 10
+	// DefaultInflationRewardAddressesPerRequest is the maximum number of
+	// addresses of a single getInflationReward request.
+	DefaultInflationRewardAddressesPerRequest = 100
+)
+
+type GetInflationRewardHistoryOpts struct {
+	Commitment CommitmentType
+
+	// EpochsPerBatch is the number of epochs requested in a single batch.
+	// Defaults to DefaultInflationRewardEpochsPerBatch.
+	EpochsPerBatch int
+
+	// AddressesPerRequest is the maximum number of addresses of a single
+	// getInflationReward request; more addresses are split in several requests.
+	// Defaults to DefaultInflationRewardAddressesPerRequest.
+	AddressesPerRequest int
+}
+
+// InflationRewardHistory is the reward history of one of the addresses
+// passed to GetInflationRewardHistory.
+type InflationRewardHistory struct {
+	Address solana.PublicKey
+	// Rewards are the rewards of the address, by increasing epoch.
+	// The epochs in which the address received no reward
+	// (e.g. the stake was not delegated yet) are omitted.
+	Rewards []*GetInflationRewardResult
+}
+
+// GetInflationRewardHistory returns the rewards of each of the provided stake
+// or vote addresses, in the same order, for the epochs from firstEpoch
+// to lastEpoch included, by sending batches of getInflationReward requests.
+//
+// An error is returned if the rewards of an epoch cannot be read,
+// e.g. if it is not completed yet, or no longer available on the node.
+func (cl *Client) GetInflationRewardHistory(
+	ctx context.Context,
+	addresses []solana.PublicKey,
+	firstEpoch uint64,
+	lastEpoch uint64,
+	opts *GetInflationRewardHistoryOpts, // optional
+) ([]*InflationRewardHistory, error) {
+	if lastEpoch < firstEpoch {
+		return nil, fmt.Errorf("invalid epoch range: %d to %d", firstEpoch, lastEpoch)
+	}
+	if opts == nil {
+		opts = &GetInflationRewardHistoryOpts{}
+	}
+	epochsPerBatch := opts.EpochsPerBatch
+	if epochsPerBatch <= 0 {
+		epochsPerBatch = DefaultInflationRewardEpochsPerBatch
+	}
+	addressesPerRequest := opts.AddressesPerRequest
+	if addressesPerRequest <= 0 {
+		addressesPerRequest = DefaultInflationRewardAddressesPerRequest
+	}
+
+	out := make([]*InflationRewardHistory, len(addresses))
+	for i, address := range addresses {
+		out[i] = &InflationRewardHistory{Address: address}
+	}
+	if len(addresses) == 0 {
+		return out, nil
+	}
+
+	// A request reads a chunk of addresses in one epoch.
+	type chunk struct {
+		epoch uint64
+		start int
+		end   int
+	}
+	var pending []chunk
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		requests := make(jsonrpc.RPCRequests, len(pending))
+		for i, c := range pending {
+			conf := M{"epoch": c.epoch}
+			if opts.Commitment != "" {
+				conf["commitment"] = opts.Commitment
+			}
+			requests[i] = &jsonrpc.RPCRequest{
+				Method:  "getInflationReward",
+				Params:  []interface{}{addresses[c.start:c.end], conf},
+				ID:      i,
+				JSONRPC: "2.0",
+			}
+		}
+		responses, err := cl.rpcClient.CallBatch(ctx, requests)
+		if err != nil {
+			return err
+		}
+		byID := responses.AsMap()
+		for i, c := range pending {
+			response, ok := byID[i]
+			if !ok {
+				return fmt.Errorf("getInflationReward for epoch %d: missing response", c.epoch)
+			}
+			if response.Error != nil {
+				return fmt.Errorf("getInflationReward for epoch %d: %w", c.epoch, newTypedError(cl.logger, response.Error))
+			}
+			var rewards []*GetInflationRewardResult
+			if err := json.Unmarshal(response.Result, &rewards); err != nil {
+				return fmt.Errorf("getInflationReward for epoch %d: unable to decode result: %w", c.epoch, err)
+			}
+			if len(rewards) != c.end-c.start {
+				return fmt.Errorf("getInflationReward for epoch %d: got %d rewards for %d addresses", c.epoch, len(rewards), c.end-c.start)
+			}
+			for j, reward := range rewards {
+				if reward != nil {
+					history := out[c.start+j]
+					history.Rewards = append(history.Rewards, reward)
+				}
+			}
+		}
+		pending = pending[:0]
+		return nil
+	}
+
+	batchStart := firstEpoch
+	for epoch := firstEpoch; ; epoch++ {
+		for start := 0; start < len(addresses); start += addressesPerRequest {
+			end := start + addressesPerRequest
+			if end > len(addresses) {
+				end = len(addresses)
+			}
+			pending = append(pending, chunk{epoch: epoch, start: start, end: end})
+		}
+		if epoch-batchStart+1 >= uint64(epochsPerBatch) || epoch == lastEpoch {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			batchStart = epoch + 1
+		}
+		if epoch == lastEpoch {
+			break
+		}
+	}
+	return out, nil
+}
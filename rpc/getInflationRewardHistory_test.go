@@ -0,0 +1,82 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetInflationRewardHistory(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	stake, vote := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	server.HandleFunc("getInflationReward", func(params stdjson.RawMessage) (interface{}, error) {
+		var in []stdjson.RawMessage
+		require.NoError(t, stdjson.Unmarshal(params, &in))
+		var addresses []solana.PublicKey
+		require.NoError(t, stdjson.Unmarshal(in[0], &addresses))
+		var conf struct{ Epoch uint64 }
+		require.NoError(t, stdjson.Unmarshal(in[1], &conf))
+
+		out := make([]interface{}, len(addresses))
+		for i, address := range addresses {
+			// The stake account is delegated from epoch 11.
+			if address.Equals(stake) && conf.Epoch < 11 {
+				continue
+			}
+			out[i] = M{
+				"epoch":         conf.Epoch,
+				"effectiveSlot": conf.Epoch*432000 + 1,
+				"amount":        conf.Epoch,
+				"postBalance":   1000 + conf.Epoch,
+			}
+		}
+		return out, nil
+	})
+
+	history, err := New(server.URL()).GetInflationRewardHistory(
+		context.Background(),
+		[]solana.PublicKey{stake, vote},
+		10, 12,
+		&GetInflationRewardHistoryOpts{EpochsPerBatch: 2, AddressesPerRequest: 1},
+	)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	require.Equal(t, stake, history[0].Address)
+	require.Len(t, history[0].Rewards, 2)
+	require.EqualValues(t, 11, history[0].Rewards[0].Epoch)
+	require.EqualValues(t, 12, history[0].Rewards[1].Epoch)
+	require.EqualValues(t, 12*432000+1, history[0].Rewards[1].EffectiveSlot)
+	require.EqualValues(t, 1012, history[0].Rewards[1].PostBalance)
+
+	require.Equal(t, vote, history[1].Address)
+	require.Len(t, history[1].Rewards, 3)
+
+	// One request per address and epoch.
+	require.Len(t, server.Requests("getInflationReward"), 6)
+
+	server.HandleError("getInflationReward", -32004, "Block not available for slot 4752000")
+	_, err = New(server.URL()).GetInflationRewardHistory(context.Background(), []solana.PublicKey{vote}, 11, 11, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "epoch 11")
+}
@@ -78,3 +78,68 @@ func (cl *Client) GetLeaderScheduleWithOpts(
 // and their corresponding leader slot indices as values
 // (indices are relative to the first slot in the requested epoch).
 type GetLeaderScheduleResult map[solana.PublicKey][]uint64
+
+// LeaderSchedule is the leader schedule of an epoch, by absolute slot.
+type LeaderSchedule struct {
+	// Epoch of the schedule.
+	Epoch uint64
+
+	// FirstSlot is the first slot of the epoch.
+	FirstSlot uint64
+
+	// Leaders holds the leader of each slot of the epoch, by slot index;
+	// the slots without a leader in the schedule hold the zero key.
+	Leaders []solana.PublicKey
+}
+
+// NewLeaderSchedule indexes by slot the result of getLeaderSchedule
+// for the epoch starting at firstSlot and lasting slotsInEpoch slots.
+func NewLeaderSchedule(result GetLeaderScheduleResult, epoch, firstSlot, slotsInEpoch uint64) *LeaderSchedule {
+	leaders := make([]solana.PublicKey, slotsInEpoch)
+	for identity, indices := range result {
+		for _, index := range indices {
+			if index < slotsInEpoch {
+				leaders[index] = identity
+			}
+		}
+	}
+	return &LeaderSchedule{
+		Epoch:     epoch,
+		FirstSlot: firstSlot,
+		Leaders:   leaders,
+	}
+}
+
+// Leader returns the leader of the slot, and false if the slot
+// is not in the epoch or has no leader in the schedule.
+func (s *LeaderSchedule) Leader(slot uint64) (solana.PublicKey, bool) {
+	if slot < s.FirstSlot || slot-s.FirstSlot >= uint64(len(s.Leaders)) {
+		return solana.PublicKey{}, false
+	}
+	leader := s.Leaders[slot-s.FirstSlot]
+	return leader, !leader.IsZero()
+}
+
+// LastSlot returns the last slot of the epoch.
+func (s *LeaderSchedule) LastSlot() uint64 {
+	return s.FirstSlot + uint64(len(s.Leaders)) - 1
+}
+
+// GetEpochLeaderSchedule returns the leader schedule of the epoch,
+// indexed by absolute slot using the provided epoch schedule.
+func (cl *Client) GetEpochLeaderSchedule(
+	ctx context.Context,
+	schedule *GetEpochScheduleResult,
+	epoch uint64,
+	commitment CommitmentType, // optional
+) (*LeaderSchedule, error) {
+	firstSlot := schedule.GetFirstSlotInEpoch(epoch)
+	out, err := cl.GetLeaderScheduleWithOpts(ctx, &GetLeaderScheduleOpts{
+		Commitment: commitment,
+		Epoch:      &firstSlot,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewLeaderSchedule(out, epoch, firstSlot, schedule.GetSlotsInEpoch(epoch)), nil
+}
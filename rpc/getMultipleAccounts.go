@@ -56,6 +56,9 @@ func (cl *Client) GetMultipleAccountsWithOpts(
 		if opts.Commitment != "" {
 			obj["commitment"] = opts.Commitment
 		}
+		if opts.MinContextSlot != nil {
+			obj["minContextSlot"] = *opts.MinContextSlot
+		}
 		if opts.DataSlice != nil {
 			obj["dataSlice"] = M{
 				"offset": opts.DataSlice.Offset,
@@ -40,8 +40,35 @@ func (cl *Client) GetProgramAccountsWithOpts(
 	publicKey solana.PublicKey,
 	opts *GetProgramAccountsOpts,
 ) (out GetProgramAccountsResult, err error) {
+	params := []interface{}{publicKey, programAccountsConfig(opts)}
+
+	err = cl.rpcClient.CallForInto(ctx, &out, "getProgramAccounts", params)
+	return
+}
+
+type GetProgramAccountsWithContextResult struct {
+	RPCContext
+	Value GetProgramAccountsResult `json:"value"`
+}
+
+// GetProgramAccountsWithContext is like GetProgramAccountsWithOpts,
+// and also returns the slot at which the accounts were read.
+func (cl *Client) GetProgramAccountsWithContext(
+	ctx context.Context,
+	publicKey solana.PublicKey,
+	opts *GetProgramAccountsOpts,
+) (out *GetProgramAccountsWithContextResult, err error) {
+	obj := programAccountsConfig(opts)
+	obj["withContext"] = true
+	params := []interface{}{publicKey, obj}
+
+	err = cl.rpcClient.CallForInto(ctx, &out, "getProgramAccounts", params)
+	return
+}
+
+func programAccountsConfig(opts *GetProgramAccountsOpts) M {
 	obj := M{
-		"encoding": "base64",
+		"encoding": implicitEncoding(solana.EncodingBase64),
 	}
 	if opts != nil {
 		if opts.Commitment != "" {
@@ -59,10 +86,9 @@ func (cl *Client) GetProgramAccountsWithOpts(
 				"length": opts.DataSlice.Length,
 			}
 		}
+		if opts.MinContextSlot != nil {
+			obj["minContextSlot"] = *opts.MinContextSlot
+		}
 	}
-
-	params := []interface{}{publicKey, obj}
-
-	err = cl.rpcClient.CallForInto(ctx, &out, "getProgramAccounts", params)
-	return
+	return obj
 }
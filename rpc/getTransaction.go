@@ -42,19 +42,40 @@ func (cl *Client) GetTransaction(
 	txSig solana.Signature, // transaction signature
 	opts *GetTransactionOpts,
 ) (out *GetTransactionResult, err error) {
+	params, err := newGetTransactionParams(
+		txSig,
+		opts,
+		// Valid encodings:
+		// solana.EncodingJSON, // TODO
+		// solana.EncodingJSONParsed, // TODO
+		solana.EncodingBase58,
+		solana.EncodingBase64,
+		solana.EncodingBase64Zstd,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = cl.rpcClient.CallForInto(ctx, &out, "getTransaction", params)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, ErrNotFound
+	}
+	return
+}
+
+// newGetTransactionParams builds the getTransaction params, accepting only the provided encodings.
+func newGetTransactionParams(
+	txSig solana.Signature,
+	opts *GetTransactionOpts,
+	encodings ...solana.EncodingType,
+) ([]interface{}, error) {
 	params := []interface{}{txSig}
 	if opts != nil {
 		obj := M{}
 		if opts.Encoding != "" {
-			if !solana.IsAnyOfEncodingType(
-				opts.Encoding,
-				// Valid encodings:
-				// solana.EncodingJSON, // TODO
-				// solana.EncodingJSONParsed, // TODO
-				solana.EncodingBase58,
-				solana.EncodingBase64,
-				solana.EncodingBase64Zstd,
-			) {
+			if !solana.IsAnyOfEncodingType(opts.Encoding, encodings...) {
 				return nil, fmt.Errorf("provided encoding is not supported: %s", opts.Encoding)
 			}
 			obj["encoding"] = opts.Encoding
@@ -69,14 +90,7 @@ func (cl *Client) GetTransaction(
 			params = append(params, obj)
 		}
 	}
-	err = cl.rpcClient.CallForInto(ctx, &out, "getTransaction", params)
-	if err != nil {
-		return nil, err
-	}
-	if out == nil {
-		return nil, ErrNotFound
-	}
-	return
+	return params, nil
 }
 
 type GetTransactionResult struct {
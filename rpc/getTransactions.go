@@ -0,0 +1,107 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DefaultGetTransactionsConcurrency is the number of getTransaction
+// requests in flight at once in GetTransactions.
+var DefaultGetTransactionsConcurrency = 8
+
+type GetTransactionsOpts struct {
+	GetTransactionOpts
+
+	// Number of getTransaction requests in flight at once.
+	// Defaults to DefaultGetTransactionsConcurrency when zero.
+	Concurrency int
+}
+
+// TransactionBySignature is the transaction of one of the signatures
+// passed to GetTransactions.
+type TransactionBySignature struct {
+	Signature   solana.Signature
+	Transaction *GetTransactionResult
+	// Err is set if the transaction could not be fetched
+	// (ErrNotFound if it is not known to the node).
+	Err error
+}
+
+// GetTransactions returns the transaction of each of the provided signatures,
+// in the same order, by sending up to opts.Concurrency getTransaction requests at once.
+// The error of a single signature is reported in its TransactionBySignature.Err;
+// an error is returned only if the options are invalid or the ctx is done.
+func (cl *Client) GetTransactions(
+	ctx context.Context,
+	signatures []solana.Signature,
+	opts *GetTransactionsOpts,
+) (out []*TransactionBySignature, err error) {
+	if opts == nil {
+		opts = &GetTransactionsOpts{}
+	}
+	// Validate the options once, instead of failing every signature.
+	if _, err := newGetTransactionParams(solana.Signature{}, &opts.GetTransactionOpts,
+		solana.EncodingBase58,
+		solana.EncodingBase64,
+		solana.EncodingBase64Zstd,
+	); err != nil {
+		return nil, err
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultGetTransactionsConcurrency
+	}
+	if concurrency > len(signatures) {
+		concurrency = len(signatures)
+	}
+
+	out = make([]*TransactionBySignature, len(signatures))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				tx, err := cl.GetTransaction(ctx, signatures[i], &opts.GetTransactionOpts)
+				out[i] = &TransactionBySignature{
+					Signature:   signatures[i],
+					Transaction: tx,
+					Err:         err,
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range signatures {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_GetTransactions(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	var inFlight, maxInFlight int32
+	server.HandleFunc("getTransaction", func(params stdjson.RawMessage) (interface{}, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		var args []interface{}
+		if err := stdjson.Unmarshal(params, &args); err != nil {
+			return nil, err
+		}
+		sig := solana.MustSignatureFromBase58(args[0].(string))
+		switch sig[0] {
+		case 0:
+			return nil, nil
+		case 1:
+			return nil, errors.New("boom")
+		}
+		return M{"slot": int(sig[0]), "transaction": []string{"", "base64"}}, nil
+	})
+
+	signatures := []solana.Signature{{5}, {0}, {7}, {1}, {9}, {3}}
+	client := New(server.URL())
+	out, err := client.GetTransactions(context.Background(), signatures, &GetTransactionsOpts{
+		GetTransactionOpts: GetTransactionOpts{Encoding: solana.EncodingBase64},
+		Concurrency:        2,
+	})
+	require.NoError(t, err)
+	require.Len(t, out, len(signatures))
+
+	for i, sig := range signatures {
+		assert.Equal(t, sig, out[i].Signature)
+		switch sig[0] {
+		case 0:
+			assert.ErrorIs(t, out[i].Err, ErrNotFound)
+		case 1:
+			require.Error(t, out[i].Err)
+			assert.Contains(t, out[i].Err.Error(), "boom")
+		default:
+			require.NoError(t, out[i].Err)
+			assert.Equal(t, uint64(sig[0]), out[i].Transaction.Slot)
+		}
+	}
+	assert.Len(t, server.Requests("getTransaction"), len(signatures))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+
+	t.Run("invalid encoding", func(t *testing.T) {
+		_, err := client.GetTransactions(context.Background(), signatures, &GetTransactionsOpts{
+			GetTransactionOpts: GetTransactionOpts{Encoding: solana.EncodingJSONParsed},
+		})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+)
+
+// MaxAssetsPageLimit is the maximum number of assets per page of the DAS methods.
+const MaxAssetsPageLimit = 1000
+
+// ErrIteratorDone is returned by AssetIterator.Next once all the assets were returned.
+var ErrIteratorDone = errors.New("no more items in iterator")
+
+// AssetIterator iterates over the assets returned by a paginated DAS method
+// (see HeliusClient.AssetsByOwnerIterator and HeliusClient.SearchAssetsIterator),
+// fetching the next page once the current one is consumed.
+//
+// It paginates by page number, starting at the page of the options (default: 1),
+// or by cursor if a cursor is set in the options (empty to start from the first asset).
+// Pages hold MaxAssetsPageLimit assets, unless a lower limit is set in the options.
+//
+// An AssetIterator must not be used concurrently.
+type AssetIterator struct {
+	ctx     context.Context
+	limiter RateLimiter
+	fetch   func(ctx context.Context, page *int, cursor *string, limit int) (*GetAssetsByOwnerResult, error)
+
+	limit  int
+	page   int
+	cursor *string
+
+	items []GetAssetsByOwnerItem
+	last  bool
+	err   error
+}
+
+func newAssetIterator(
+	ctx context.Context,
+	limiter RateLimiter,
+	page *int,
+	limit *int,
+	cursor *string,
+	fetch func(ctx context.Context, page *int, cursor *string, limit int) (*GetAssetsByOwnerResult, error),
+) *AssetIterator {
+	it := &AssetIterator{
+		ctx:     ctx,
+		limiter: limiter,
+		fetch:   fetch,
+		limit:   MaxAssetsPageLimit,
+		page:    1,
+		cursor:  cursor,
+	}
+	if limit != nil && *limit > 0 && *limit < MaxAssetsPageLimit {
+		it.limit = *limit
+	}
+	if page != nil {
+		it.page = *page
+	}
+	return it
+}
+
+// AssetsByOwnerIterator returns an iterator over the assets of the owner.
+// The limiter, if not nil, is consulted before fetching each page.
+func (cl *HeliusClient) AssetsByOwnerIterator(
+	ctx context.Context,
+	opts GetAssetsByOwnerOpts,
+	limiter RateLimiter, // optional
+) *AssetIterator {
+	return newAssetIterator(ctx, limiter, opts.Page, opts.Limit, opts.Cursor,
+		func(ctx context.Context, page *int, cursor *string, limit int) (*GetAssetsByOwnerResult, error) {
+			pageOpts := opts
+			pageOpts.Page, pageOpts.Cursor, pageOpts.Limit = page, cursor, &limit
+			return cl.GetAssetsByOwner(ctx, pageOpts)
+		},
+	)
+}
+
+// SearchAssetsIterator returns an iterator over the assets matching
+// the search criteria. The limiter, if not nil, is consulted before
+// fetching each page.
+func (cl *HeliusClient) SearchAssetsIterator(
+	ctx context.Context,
+	opts SearchAssetsOpts,
+	limiter RateLimiter, // optional
+) *AssetIterator {
+	return newAssetIterator(ctx, limiter, opts.Page, opts.Limit, opts.Cursor,
+		func(ctx context.Context, page *int, cursor *string, limit int) (*GetAssetsByOwnerResult, error) {
+			pageOpts := opts
+			pageOpts.Page, pageOpts.Cursor, pageOpts.Limit = page, cursor, &limit
+			return cl.SearchAssets(ctx, pageOpts)
+		},
+	)
+}
+
+// HasNext reports whether there is an asset left, fetching the next page
+// if needed. It returns false once all the assets were returned,
+// or if fetching a page failed (see Err).
+func (it *AssetIterator) HasNext() bool {
+	for len(it.items) == 0 {
+		if it.last || it.err != nil {
+			return false
+		}
+		it.err = it.nextPage()
+	}
+	return true
+}
+
+// Next returns the next asset. It returns ErrIteratorDone once all
+// the assets were returned, or the error that failed fetching a page.
+func (it *AssetIterator) Next() (*GetAssetsByOwnerItem, error) {
+	if !it.HasNext() {
+		if it.err != nil {
+			return nil, it.err
+		}
+		return nil, ErrIteratorDone
+	}
+	item := &it.items[0]
+	it.items = it.items[1:]
+	return item, nil
+}
+
+// Err returns the error that failed fetching a page, if any.
+func (it *AssetIterator) Err() error {
+	return it.err
+}
+
+// nextPage fetches the next page of assets.
+func (it *AssetIterator) nextPage() error {
+	if it.limiter != nil {
+		if err := it.limiter.Wait(it.ctx); err != nil {
+			return err
+		}
+	}
+
+	var page *int
+	var cursor *string
+	if it.cursor != nil {
+		if *it.cursor != "" {
+			cursor = it.cursor
+		}
+	} else {
+		current := it.page
+		page = &current
+	}
+	out, err := it.fetch(it.ctx, page, cursor, it.limit)
+	if err != nil {
+		return err
+	}
+
+	it.items = out.Items
+	if it.cursor != nil {
+		it.last = out.Cursor == nil || *out.Cursor == "" || len(out.Items) == 0
+		it.cursor = out.Cursor
+	} else {
+		it.last = len(out.Items) < it.limit
+		it.page++
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func assetItems(from, to int) []M {
+	var items []M
+	for i := from; i < to; i++ {
+		items = append(items, M{"id": fmt.Sprintf("asset-%d", i)})
+	}
+	return items
+}
+
+func TestAssetsByOwnerIterator(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.HandleFunc("getAssetsByOwner", func(params stdjson.RawMessage) (interface{}, error) {
+		var in struct {
+			Page  int `json:"page"`
+			Limit int `json:"limit"`
+		}
+		require.NoError(t, stdjson.Unmarshal(params, &in))
+		from := (in.Page - 1) * in.Limit
+		to := from + in.Limit
+		if to > 5 {
+			to = 5
+		}
+		return M{"total": to - from, "limit": in.Limit, "page": in.Page, "items": assetItems(from, to)}, nil
+	})
+	client := &HeliusClient{Client: New(server.URL())}
+
+	limit := 2
+	var limiter countingLimiter
+	it := client.AssetsByOwnerIterator(context.Background(), GetAssetsByOwnerOpts{
+		OwnerAddress: solana.SystemProgramID.String(),
+		Limit:        &limit,
+	}, &limiter)
+	var ids []string
+	for it.HasNext() {
+		item, err := it.Next()
+		require.NoError(t, err)
+		ids = append(ids, item.Id)
+	}
+	require.NoError(t, it.Err())
+	require.Equal(t, []string{"asset-0", "asset-1", "asset-2", "asset-3", "asset-4"}, ids)
+	require.EqualValues(t, 3, limiter.calls)
+	_, err := it.Next()
+	require.ErrorIs(t, err, ErrIteratorDone)
+
+	// The limit is capped to the maximum page size.
+	limit = 5000
+	it = client.AssetsByOwnerIterator(context.Background(), GetAssetsByOwnerOpts{
+		OwnerAddress: solana.SystemProgramID.String(),
+		Limit:        &limit,
+	}, nil)
+	require.True(t, it.HasNext())
+	requests := server.Requests("getAssetsByOwner")
+	require.Contains(t, string(requests[len(requests)-1].Params), `"limit":1000`)
+}
+
+func TestSearchAssetsIterator(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.HandleFunc("searchAssets", func(params stdjson.RawMessage) (interface{}, error) {
+		var in struct {
+			Page   *int   `json:"page"`
+			Cursor string `json:"cursor"`
+		}
+		require.NoError(t, stdjson.Unmarshal(params, &in))
+		require.Nil(t, in.Page)
+		switch in.Cursor {
+		case "":
+			return M{"limit": 1000, "cursor": "c1", "items": assetItems(0, 2)}, nil
+		case "c1":
+			return M{"limit": 1000, "cursor": "c2", "items": assetItems(2, 3)}, nil
+		default:
+			return M{"limit": 1000, "items": []M{}}, nil
+		}
+	})
+	client := &HeliusClient{Client: New(server.URL())}
+
+	owner := solana.SystemProgramID.String()
+	cursor := ""
+	it := client.SearchAssetsIterator(context.Background(), SearchAssetsOpts{
+		OwnerAddress: &owner,
+		Cursor:       &cursor,
+	}, nil)
+	var ids []string
+	for {
+		item, err := it.Next()
+		if err == ErrIteratorDone {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, item.Id)
+	}
+	require.Equal(t, []string{"asset-0", "asset-1", "asset-2"}, ids)
+	require.Len(t, server.Requests("searchAssets"), 3)
+}
+
+func TestAssetIterator_error(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.HandleError("getAssetsByOwner", -32000, "boom")
+	client := &HeliusClient{Client: New(server.URL())}
+
+	it := client.AssetsByOwnerIterator(context.Background(), GetAssetsByOwnerOpts{
+		OwnerAddress: solana.SystemProgramID.String(),
+	}, nil)
+	require.False(t, it.HasNext())
+	require.Error(t, it.Err())
+	_, err := it.Next()
+	require.Equal(t, it.Err(), err)
+}
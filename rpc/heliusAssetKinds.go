@@ -0,0 +1,144 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// AssetInterface is the kind of a DAS asset.
+//
+// Values not listed below (e.g. added by newer indexers)
+// are kept as they are; IsKnown reports whether the value is one of the constants.
+type AssetInterface string
+
+const (
+	AssetInterfaceV1NFT           AssetInterface = "V1_NFT"
+	AssetInterfaceV1Print         AssetInterface = "V1_PRINT"
+	AssetInterfaceLegacyNFT       AssetInterface = "LEGACY_NFT"
+	AssetInterfaceV2NFT           AssetInterface = "V2_NFT"
+	AssetInterfaceFungibleAsset   AssetInterface = "FungibleAsset"
+	AssetInterfaceFungibleToken   AssetInterface = "FungibleToken"
+	AssetInterfaceCustom          AssetInterface = "Custom"
+	AssetInterfaceIdentity        AssetInterface = "Identity"
+	AssetInterfaceExecutable      AssetInterface = "Executable"
+	AssetInterfaceProgrammableNFT AssetInterface = "ProgrammableNFT"
+	AssetInterfaceMplCoreAsset    AssetInterface = "MplCoreAsset"
+)
+
+// IsKnown reports whether the interface is one of the AssetInterface constants.
+func (i AssetInterface) IsKnown() bool {
+	switch i {
+	case AssetInterfaceV1NFT, AssetInterfaceV1Print, AssetInterfaceLegacyNFT, AssetInterfaceV2NFT,
+		AssetInterfaceFungibleAsset, AssetInterfaceFungibleToken, AssetInterfaceCustom,
+		AssetInterfaceIdentity, AssetInterfaceExecutable, AssetInterfaceProgrammableNFT,
+		AssetInterfaceMplCoreAsset:
+		return true
+	}
+	return false
+}
+
+// IsNFT reports whether the asset is a non-fungible token
+// (including prints, programmable and core assets).
+func (i AssetInterface) IsNFT() bool {
+	switch i {
+	case AssetInterfaceV1NFT, AssetInterfaceV1Print, AssetInterfaceLegacyNFT,
+		AssetInterfaceV2NFT, AssetInterfaceProgrammableNFT, AssetInterfaceMplCoreAsset:
+		return true
+	}
+	return false
+}
+
+// IsFungible reports whether the asset is a fungible token
+// (with or without metadata).
+func (i AssetInterface) IsFungible() bool {
+	return i == AssetInterfaceFungibleAsset || i == AssetInterfaceFungibleToken
+}
+
+// TokenStandard is the Metaplex Token Metadata standard of a token.
+//
+// Values not listed below are kept as they are;
+// IsKnown reports whether the value is one of the constants.
+type TokenStandard string
+
+// The constants are in the order of the on-chain Metaplex enum.
+const (
+	TokenStandardNonFungible                    TokenStandard = "NonFungible"
+	TokenStandardFungibleAsset                  TokenStandard = "FungibleAsset"
+	TokenStandardFungible                       TokenStandard = "Fungible"
+	TokenStandardNonFungibleEdition             TokenStandard = "NonFungibleEdition"
+	TokenStandardProgrammableNonFungible        TokenStandard = "ProgrammableNonFungible"
+	TokenStandardProgrammableNonFungibleEdition TokenStandard = "ProgrammableNonFungibleEdition"
+)
+
+var tokenStandards = []TokenStandard{
+	TokenStandardNonFungible,
+	TokenStandardFungibleAsset,
+	TokenStandardFungible,
+	TokenStandardNonFungibleEdition,
+	TokenStandardProgrammableNonFungible,
+	TokenStandardProgrammableNonFungibleEdition,
+}
+
+// IsKnown reports whether the standard is one of the TokenStandard constants.
+func (s TokenStandard) IsKnown() bool {
+	for _, known := range tokenStandards {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNFT reports whether the standard is a non-fungible one (including editions).
+func (s TokenStandard) IsNFT() bool {
+	switch s {
+	case TokenStandardNonFungible, TokenStandardNonFungibleEdition,
+		TokenStandardProgrammableNonFungible, TokenStandardProgrammableNonFungibleEdition:
+		return true
+	}
+	return false
+}
+
+// IsFungible reports whether the standard is a fungible one.
+func (s TokenStandard) IsFungible() bool {
+	return s == TokenStandardFungible || s == TokenStandardFungibleAsset
+}
+
+// UnmarshalJSON accepts the name of the standard, null,
+// and the on-chain index of the standard (as returned by some indexers).
+// An index out of range is kept as its decimal representation.
+func (s *TokenStandard) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if data[0] != '"' {
+		index, err := strconv.ParseUint(string(data), 10, 8)
+		if err == nil && int(index) < len(tokenStandards) {
+			*s = tokenStandards[index]
+			return nil
+		}
+		*s = TokenStandard(data)
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	*s = TokenStandard(name)
+	return nil
+}
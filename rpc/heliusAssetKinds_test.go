@@ -0,0 +1,57 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssetInterface(t *testing.T) {
+	assert.True(t, AssetInterfaceProgrammableNFT.IsNFT())
+	assert.False(t, AssetInterfaceProgrammableNFT.IsFungible())
+	assert.True(t, AssetInterfaceFungibleToken.IsFungible())
+	assert.False(t, AssetInterfaceCustom.IsNFT() || AssetInterfaceCustom.IsFungible())
+
+	var item GetAssetsByOwnerItem
+	require.NoError(t, json.Unmarshal([]byte(`{"interface":"V9_NFT"}`), &item))
+	assert.Equal(t, AssetInterface("V9_NFT"), item.Interface)
+	assert.False(t, item.Interface.IsKnown())
+	assert.True(t, AssetInterfaceV1NFT.IsKnown())
+}
+
+func TestTokenStandard_UnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		in   string
+		want TokenStandard
+	}{
+		{`"ProgrammableNonFungible"`, TokenStandardProgrammableNonFungible},
+		{`"SemiFungible"`, TokenStandard("SemiFungible")},
+		{`2`, TokenStandardFungible},
+		{`42`, TokenStandard("42")},
+		{`null`, ""},
+	}
+	for _, c := range cases {
+		var metadata GetAssetMetadata
+		require.NoError(t, json.Unmarshal([]byte(`{"token_standard":`+c.in+`}`), &metadata), c.in)
+		assert.Equal(t, c.want, metadata.TokenStandard, c.in)
+	}
+
+	assert.True(t, TokenStandardNonFungibleEdition.IsNFT())
+	assert.True(t, TokenStandardFungibleAsset.IsFungible())
+	assert.False(t, TokenStandard("SemiFungible").IsKnown())
+}
@@ -0,0 +1,313 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// This file implements the ZK Compression (Photon) methods of the Helius RPC.
+// See https://www.zkcompression.com/developers/json-rpc-methods
+
+// CompressedAccountOpts identifies a compressed account
+// either by its address or by its hash; exactly one must be set.
+type CompressedAccountOpts struct {
+	Address *solana.PublicKey
+	Hash    *solana.Hash
+}
+
+func (opts CompressedAccountOpts) params() (M, error) {
+	switch {
+	case opts.Address != nil && opts.Hash != nil:
+		return nil, errors.New("only one of Address or Hash must be provided")
+	case opts.Address != nil:
+		return M{"address": opts.Address}, nil
+	case opts.Hash != nil:
+		return M{"hash": opts.Hash}, nil
+	default:
+		return nil, errors.New("one of Address or Hash is required")
+	}
+}
+
+type CompressedAccount struct {
+	// Address of the account, if it has one.
+	Address *solana.PublicKey `json:"address,omitempty"`
+	// Data of the account, if any.
+	Data *CompressedAccountData `json:"data,omitempty"`
+	// Hash of the account, which identifies its current state.
+	Hash     solana.Hash      `json:"hash"`
+	Lamports uint64           `json:"lamports"`
+	Owner    solana.PublicKey `json:"owner"`
+	// Index of the account in the state Merkle tree.
+	LeafIndex uint64 `json:"leafIndex"`
+	// State Merkle tree of the account.
+	Tree        solana.PublicKey `json:"tree"`
+	Seq         *uint64          `json:"seq,omitempty"`
+	SlotCreated uint64           `json:"slotCreated"`
+}
+
+type CompressedAccountData struct {
+	Discriminator uint64      `json:"discriminator"`
+	Data          []byte      `json:"data"` // base64-encoded in JSON
+	DataHash      solana.Hash `json:"dataHash"`
+}
+
+type GetCompressedAccountResult struct {
+	RPCContext
+	Value *CompressedAccount `json:"value"`
+}
+
+// GetCompressedAccount returns the compressed account with the provided address or hash.
+func (cl *HeliusClient) GetCompressedAccount(
+	ctx context.Context,
+	opts CompressedAccountOpts,
+) (out *GetCompressedAccountResult, err error) {
+	params, err := opts.params()
+	if err != nil {
+		return nil, err
+	}
+	err = cl.rpcClient.CallForInto(ctx, &out, "getCompressedAccount", params)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil || out.Value == nil {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
+
+// GetCompressedBalance returns the lamports of the compressed account
+// with the provided address or hash.
+func (cl *HeliusClient) GetCompressedBalance(
+	ctx context.Context,
+	opts CompressedAccountOpts,
+) (out *GetBalanceResult, err error) {
+	params, err := opts.params()
+	if err != nil {
+		return nil, err
+	}
+	err = cl.rpcClient.CallForInto(ctx, &out, "getCompressedBalance", params)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
+
+type GetCompressedTokenAccountsByOwnerOpts struct {
+	// Only return the accounts of this mint.
+	//
+	// This parameter is optional.
+	Mint *solana.PublicKey
+	// Cursor returned by the previous page.
+	//
+	// This parameter is optional.
+	Cursor *string
+	// Maximum number of accounts per page.
+	//
+	// This parameter is optional.
+	Limit *int
+}
+
+type CompressedTokenAccount struct {
+	Account   CompressedAccount   `json:"account"`
+	TokenData CompressedTokenData `json:"tokenData"`
+}
+
+type CompressedTokenData struct {
+	Mint     solana.PublicKey  `json:"mint"`
+	Owner    solana.PublicKey  `json:"owner"`
+	Amount   uint64            `json:"amount"`
+	Delegate *solana.PublicKey `json:"delegate,omitempty"`
+	// "initialized" or "frozen".
+	State string `json:"state"`
+	// Token extensions, base64-encoded.
+	Tlv *string `json:"tlv,omitempty"`
+}
+
+type GetCompressedTokenAccountsByOwnerResult struct {
+	RPCContext
+	Value struct {
+		Items []CompressedTokenAccount `json:"items"`
+		// Cursor of the next page; nil on the last page.
+		Cursor *string `json:"cursor,omitempty"`
+	} `json:"value"`
+}
+
+// GetCompressedTokenAccountsByOwner returns the compressed token accounts of owner.
+func (cl *HeliusClient) GetCompressedTokenAccountsByOwner(
+	ctx context.Context,
+	owner solana.PublicKey,
+	opts *GetCompressedTokenAccountsByOwnerOpts,
+) (out *GetCompressedTokenAccountsByOwnerResult, err error) {
+	params := M{
+		"owner": owner,
+	}
+	if opts != nil {
+		if opts.Mint != nil {
+			params["mint"] = opts.Mint
+		}
+		if opts.Cursor != nil {
+			params["cursor"] = opts.Cursor
+		}
+		if opts.Limit != nil {
+			params["limit"] = opts.Limit
+		}
+	}
+	err = cl.rpcClient.CallForInto(ctx, &out, "getCompressedTokenAccountsByOwner", params)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
+
+type CompressionSignature struct {
+	Signature solana.Signature        `json:"signature"`
+	Slot      uint64                  `json:"slot"`
+	BlockTime *solana.UnixTimeSeconds `json:"blockTime,omitempty"`
+}
+
+type GetCompressionSignaturesForAccountResult struct {
+	RPCContext
+	Value struct {
+		Items []CompressionSignature `json:"items"`
+	} `json:"value"`
+}
+
+// GetCompressionSignaturesForAccount returns the signatures of the transactions
+// that opened or closed the compressed account with the provided hash.
+func (cl *HeliusClient) GetCompressionSignaturesForAccount(
+	ctx context.Context,
+	hash solana.Hash,
+) (out *GetCompressionSignaturesForAccountResult, err error) {
+	err = cl.rpcClient.CallForInto(ctx, &out, "getCompressionSignaturesForAccount", M{"hash": hash})
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
+
+// AddressWithTree is a new address and the address Merkle tree
+// in which it must be created.
+type AddressWithTree struct {
+	Address solana.PublicKey `json:"address"`
+	Tree    solana.PublicKey `json:"tree"`
+}
+
+type GetValidityProofOpts struct {
+	// Hashes of the existing compressed accounts to prove.
+	//
+	// This parameter is optional.
+	Hashes []solana.Hash
+	// New addresses to prove the non-existence of.
+	//
+	// This parameter is optional.
+	NewAddressesWithTrees []AddressWithTree
+}
+
+// CompressedProofBytes is an element of a compressed proof.
+// It is encoded in JSON either as an array of numbers or as a base64 string.
+type CompressedProofBytes []byte
+
+func (b *CompressedProofBytes) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var numbers []uint8
+		if err := stdjson.Unmarshal(data, &numbers); err != nil {
+			return err
+		}
+		*b = numbers
+		return nil
+	}
+	var encoded string
+	if err := stdjson.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid proof bytes: %w", err)
+	}
+	*b = decoded
+	return nil
+}
+
+func (b CompressedProofBytes) MarshalJSON() ([]byte, error) {
+	numbers := make([]uint16, len(b))
+	for i, v := range b {
+		numbers[i] = uint16(v)
+	}
+	return stdjson.Marshal(numbers)
+}
+
+// CompressedProof is a Groth16 proof.
+type CompressedProof struct {
+	A CompressedProofBytes `json:"a"`
+	B CompressedProofBytes `json:"b"`
+	C CompressedProofBytes `json:"c"`
+}
+
+type ValidityProof struct {
+	CompressedProof CompressedProof    `json:"compressedProof"`
+	Roots           []solana.Hash      `json:"roots"`
+	RootIndices     []uint64           `json:"rootIndices"`
+	LeafIndices     []uint64           `json:"leafIndices"`
+	Leaves          []solana.Hash      `json:"leaves"`
+	MerkleTrees     []solana.PublicKey `json:"merkleTrees"`
+}
+
+type GetValidityProofResult struct {
+	RPCContext
+	Value *ValidityProof `json:"value"`
+}
+
+// GetValidityProof returns a proof that the compressed accounts with the provided
+// hashes exist, and that the provided new addresses don't.
+func (cl *HeliusClient) GetValidityProof(
+	ctx context.Context,
+	opts GetValidityProofOpts,
+) (out *GetValidityProofResult, err error) {
+	if len(opts.Hashes) == 0 && len(opts.NewAddressesWithTrees) == 0 {
+		return nil, errors.New("at least one of Hashes or NewAddressesWithTrees is required")
+	}
+	params := M{}
+	if len(opts.Hashes) > 0 {
+		params["hashes"] = opts.Hashes
+	}
+	if len(opts.NewAddressesWithTrees) > 0 {
+		params["newAddressesWithTrees"] = opts.NewAddressesWithTrees
+	}
+	err = cl.rpcClient.CallForInto(ctx, &out, "getValidityProof", params)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil || out.Value == nil {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
@@ -0,0 +1,167 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeliusClient_GetCompressedAccount(t *testing.T) {
+	responseBody := `{"context":{"slot":100},"value":{"address":null,"data":{"data":"AQID","dataHash":"11111111111111111111111111111111","discriminator":2},"hash":"4EVAJ81v7P6A9e3Ug4C8gPvLXLwfXqcMZT4XuHJvbjfg","lamports":1000,"leafIndex":5,"owner":"TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA","seq":7,"slotCreated":90,"tree":"smt1NamzXdq4AMqS2fS2F1i5KTYPZRhoHgWx38d8WsT"}}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := &HeliusClient{Client: New(server.URL)}
+
+	hash := solana.MustHashFromBase58("4EVAJ81v7P6A9e3Ug4C8gPvLXLwfXqcMZT4XuHJvbjfg")
+	out, err := client.GetCompressedAccount(context.Background(), CompressedAccountOpts{Hash: &hash})
+	require.NoError(t, err)
+
+	reqBody := server.RequestBody(t)
+	assert.Equal(t, "getCompressedAccount", reqBody["method"])
+	assert.Equal(t, map[string]interface{}{"hash": hash.String()}, reqBody["params"])
+
+	seq := uint64(7)
+	assert.Equal(t,
+		&CompressedAccount{
+			Data: &CompressedAccountData{
+				Discriminator: 2,
+				Data:          []byte{1, 2, 3},
+			},
+			Hash:        hash,
+			Lamports:    1000,
+			Owner:       solana.TokenProgramID,
+			LeafIndex:   5,
+			Tree:        solana.MustPublicKeyFromBase58("smt1NamzXdq4AMqS2fS2F1i5KTYPZRhoHgWx38d8WsT"),
+			Seq:         &seq,
+			SlotCreated: 90,
+		},
+		out.Value,
+	)
+	assert.Equal(t, uint64(100), out.Context.Slot)
+
+	_, err = client.GetCompressedAccount(context.Background(), CompressedAccountOpts{})
+	require.Error(t, err)
+}
+
+func TestHeliusClient_GetCompressedTokenAccountsByOwner(t *testing.T) {
+	responseBody := `{"context":{"slot":100},"value":{"cursor":"next","items":[{"account":{"hash":"4EVAJ81v7P6A9e3Ug4C8gPvLXLwfXqcMZT4XuHJvbjfg","lamports":0,"leafIndex":1,"owner":"cTokenmWW8bLPjZEBAUgYy3zKxQZW6VKi7bqNFEVv3m","slotCreated":90,"tree":"smt1NamzXdq4AMqS2fS2F1i5KTYPZRhoHgWx38d8WsT"},"tokenData":{"amount":42,"delegate":null,"mint":"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v","owner":"7HZaCWazgTuuFuajxaaxGYbGnyVKwxvsJKue1W4Nvyro","state":"initialized","tlv":null}}]}}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := &HeliusClient{Client: New(server.URL)}
+
+	owner := solana.MustPublicKeyFromBase58("7HZaCWazgTuuFuajxaaxGYbGnyVKwxvsJKue1W4Nvyro")
+	mint := solana.MustPublicKeyFromBase58("EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v")
+	limit := 10
+	out, err := client.GetCompressedTokenAccountsByOwner(context.Background(), owner, &GetCompressedTokenAccountsByOwnerOpts{
+		Mint:  &mint,
+		Limit: &limit,
+	})
+	require.NoError(t, err)
+
+	reqBody := server.RequestBody(t)
+	assert.Equal(t,
+		map[string]interface{}{
+			"owner": owner.String(),
+			"mint":  mint.String(),
+			"limit": float64(10),
+		},
+		reqBody["params"],
+	)
+
+	require.Len(t, out.Value.Items, 1)
+	assert.Equal(t, "next", *out.Value.Cursor)
+	assert.Equal(t,
+		CompressedTokenData{
+			Mint:   mint,
+			Owner:  owner,
+			Amount: 42,
+			State:  "initialized",
+		},
+		out.Value.Items[0].TokenData,
+	)
+	assert.Equal(t, uint64(1), out.Value.Items[0].Account.LeafIndex)
+}
+
+func TestHeliusClient_GetValidityProof(t *testing.T) {
+	responseBody := `{"context":{"slot":100},"value":{"compressedProof":{"a":[1,2],"b":[3],"c":[4,5,6]},"leafIndices":[5],"leaves":["4EVAJ81v7P6A9e3Ug4C8gPvLXLwfXqcMZT4XuHJvbjfg"],"merkleTrees":["smt1NamzXdq4AMqS2fS2F1i5KTYPZRhoHgWx38d8WsT"],"rootIndices":[3],"roots":["11111111111111111111111111111111"]}}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := &HeliusClient{Client: New(server.URL)}
+
+	hash := solana.MustHashFromBase58("4EVAJ81v7P6A9e3Ug4C8gPvLXLwfXqcMZT4XuHJvbjfg")
+	out, err := client.GetValidityProof(context.Background(), GetValidityProofOpts{
+		Hashes: []solana.Hash{hash},
+	})
+	require.NoError(t, err)
+
+	reqBody := server.RequestBody(t)
+	assert.Equal(t,
+		map[string]interface{}{
+			"hashes": []interface{}{hash.String()},
+		},
+		reqBody["params"],
+	)
+
+	assert.Equal(t,
+		&ValidityProof{
+			CompressedProof: CompressedProof{
+				A: CompressedProofBytes{1, 2},
+				B: CompressedProofBytes{3},
+				C: CompressedProofBytes{4, 5, 6},
+			},
+			Roots:       []solana.Hash{{}},
+			RootIndices: []uint64{3},
+			LeafIndices: []uint64{5},
+			Leaves:      []solana.Hash{hash},
+			MerkleTrees: []solana.PublicKey{solana.MustPublicKeyFromBase58("smt1NamzXdq4AMqS2fS2F1i5KTYPZRhoHgWx38d8WsT")},
+		},
+		out.Value,
+	)
+
+	_, err = client.GetValidityProof(context.Background(), GetValidityProofOpts{})
+	require.Error(t, err)
+}
+
+func TestHeliusClient_GetCompressionSignaturesForAccount(t *testing.T) {
+	responseBody := `{"context":{"slot":100},"value":{"items":[{"blockTime":1700000000,"signature":"5h6xBEauJ3PK6SWCZ1PGjBvj8vDdWG3KpwATGy1ARAXFSDwt8GFXM7W5Ncn16wmqokgpiKRLuS83KUxyZyv2sUYv","slot":95}]}}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := &HeliusClient{Client: New(server.URL)}
+
+	hash := solana.MustHashFromBase58("4EVAJ81v7P6A9e3Ug4C8gPvLXLwfXqcMZT4XuHJvbjfg")
+	out, err := client.GetCompressionSignaturesForAccount(context.Background(), hash)
+	require.NoError(t, err)
+
+	reqBody := server.RequestBody(t)
+	assert.Equal(t, map[string]interface{}{"hash": hash.String()}, reqBody["params"])
+
+	blockTime := solana.UnixTimeSeconds(1700000000)
+	assert.Equal(t,
+		[]CompressionSignature{
+			{
+				Signature: solana.MustSignatureFromBase58("5h6xBEauJ3PK6SWCZ1PGjBvj8vDdWG3KpwATGy1ARAXFSDwt8GFXM7W5Ncn16wmqokgpiKRLuS83KUxyZyv2sUYv"),
+				Slot:      95,
+				BlockTime: &blockTime,
+			},
+		},
+		out.Value.Items,
+	)
+}
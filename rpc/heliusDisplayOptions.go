@@ -0,0 +1,114 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIncompatibleDisplayOptions is matched by the errors returned when
+// the display options of a DAS request are incompatible with the method
+// or with the other parameters of the request.
+var ErrIncompatibleDisplayOptions = errors.New("incompatible display options")
+
+// MinimalAssetsOptions returns the display options producing the smallest
+// responses: no collection metadata, fungible tokens, balances or inscriptions.
+// It suits frequent lookups, e.g. triggered by ws notifications.
+func MinimalAssetsOptions() *GetAssetsByOwnerOptions {
+	return &GetAssetsByOwnerOptions{}
+}
+
+// FullMetadataAssetsOptions returns the display options including everything
+// that can be shown for the assets of an owner, except the zero-balance
+// token accounts.
+func FullMetadataAssetsOptions() *GetAssetsByOwnerOptions {
+	return &GetAssetsByOwnerOptions{
+		ShowUnverifiedCollections: true,
+		ShowCollectionMetadata:    true,
+		ShowGrandTotal:            true,
+		ShowFungible:              true,
+		ShowNativeBalance:         true,
+		ShowInscription:           true,
+	}
+}
+
+// FungibleAssetsOptions returns the display options for listing the
+// fungible tokens of an owner along with its native balance.
+//
+// The fungible tokens are shown in addition to the non-fungible assets;
+// use SearchAssets with the "fungible" token type to list only them.
+func FungibleAssetsOptions() *GetAssetsByOwnerOptions {
+	return &GetAssetsByOwnerOptions{
+		ShowFungible:      true,
+		ShowNativeBalance: true,
+	}
+}
+
+// DisplayOptions returns the options applicable to GetAsset and GetAssetBatch.
+func (o *GetAssetsByOwnerOptions) DisplayOptions() *GetAssetOptsDisplayOptions {
+	return &GetAssetOptsDisplayOptions{
+		ShowUnverifiedCollections: o.ShowUnverifiedCollections,
+		ShowCollectionMetadata:    o.ShowCollectionMetadata,
+		ShowFungible:              o.ShowFungible,
+		ShowInscription:           o.ShowInscription,
+	}
+}
+
+// Validate returns an error if the options cannot be used with the provided
+// DAS method ("getAssetsByOwner" or "searchAssets").
+func (o *GetAssetsByOwnerOptions) Validate(method string) error {
+	if o == nil {
+		return nil
+	}
+	if o.ShowZeroBalance {
+		if method != "getAssetsByOwner" {
+			return incompatibleDisplayOptions(method, "showZeroBalance is only supported by getAssetsByOwner")
+		}
+		if !o.ShowFungible {
+			return incompatibleDisplayOptions(method, "showZeroBalance requires showFungible, as only fungible token accounts have a balance")
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if the display options are incompatible
+// with the search criteria.
+func (opts *SearchAssetsOpts) Validate() error {
+	const method = "searchAssets"
+	o := opts.Options
+	if err := o.Validate(method); err != nil {
+		return err
+	}
+	if o == nil {
+		return nil
+	}
+	if o.ShowFungible {
+		if opts.Interface != nil && opts.Interface.IsNFT() {
+			return incompatibleDisplayOptions(method, fmt.Sprintf("showFungible cannot be used with the non-fungible interface %q", *opts.Interface))
+		}
+		if opts.TokenType != nil && *opts.TokenType != "fungible" && *opts.TokenType != "all" {
+			return incompatibleDisplayOptions(method, fmt.Sprintf("showFungible cannot be used with the token type %q", *opts.TokenType))
+		}
+	}
+	if o.ShowNativeBalance && opts.OwnerAddress == nil {
+		return incompatibleDisplayOptions(method, "showNativeBalance requires ownerAddress")
+	}
+	return nil
+}
+
+func incompatibleDisplayOptions(method string, reason string) error {
+	return fmt.Errorf("%s: %w: %s", method, ErrIncompatibleDisplayOptions, reason)
+}
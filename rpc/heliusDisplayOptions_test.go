@@ -0,0 +1,105 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAssetsByOwnerOptions_Validate(t *testing.T) {
+	for _, preset := range []*GetAssetsByOwnerOptions{
+		MinimalAssetsOptions(),
+		FullMetadataAssetsOptions(),
+		FungibleAssetsOptions(),
+	} {
+		assert.NoError(t, preset.Validate("getAssetsByOwner"))
+		assert.NoError(t, preset.Validate("searchAssets"))
+	}
+	assert.NoError(t, (*GetAssetsByOwnerOptions)(nil).Validate("searchAssets"))
+
+	zeroBalance := &GetAssetsByOwnerOptions{ShowFungible: true, ShowZeroBalance: true}
+	assert.NoError(t, zeroBalance.Validate("getAssetsByOwner"))
+	err := zeroBalance.Validate("searchAssets")
+	assert.True(t, errors.Is(err, ErrIncompatibleDisplayOptions))
+	assert.EqualError(t, err, "searchAssets: incompatible display options: showZeroBalance is only supported by getAssetsByOwner")
+
+	err = (&GetAssetsByOwnerOptions{ShowZeroBalance: true}).Validate("getAssetsByOwner")
+	assert.True(t, errors.Is(err, ErrIncompatibleDisplayOptions))
+}
+
+func TestSearchAssetsOpts_Validate(t *testing.T) {
+	owner := "86xCnPeV69n6t3DnyGvkKobf9FdN2H9oiVDdaMpo2MMY"
+	nft := AssetInterfaceProgrammableNFT
+	fungible := AssetInterfaceFungibleToken
+	nonFungible := "nonFungible"
+
+	tests := []struct {
+		name string
+		opts SearchAssetsOpts
+		err  string
+	}{
+		{"no options", SearchAssetsOpts{Interface: &nft}, ""},
+		{"fungible interface", SearchAssetsOpts{Interface: &fungible, Options: FungibleAssetsOptions(), OwnerAddress: &owner}, ""},
+		{"nft interface", SearchAssetsOpts{Interface: &nft, Options: &GetAssetsByOwnerOptions{ShowFungible: true}}, `showFungible cannot be used with the non-fungible interface "ProgrammableNFT"`},
+		{"non-fungible token type", SearchAssetsOpts{TokenType: &nonFungible, Options: &GetAssetsByOwnerOptions{ShowFungible: true}}, `showFungible cannot be used with the token type "nonFungible"`},
+		{"native balance without owner", SearchAssetsOpts{Options: &GetAssetsByOwnerOptions{ShowNativeBalance: true}}, "showNativeBalance requires ownerAddress"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.opts.Validate()
+			if test.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, ErrIncompatibleDisplayOptions))
+			assert.EqualError(t, err, "searchAssets: incompatible display options: "+test.err)
+		})
+	}
+}
+
+func TestHeliusClient_ValidatesDisplayOptions(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	client := &HeliusClient{Client: New(server.URL())}
+
+	_, err := client.SearchAssets(context.Background(), SearchAssetsOpts{
+		Options: &GetAssetsByOwnerOptions{ShowFungible: true, ShowZeroBalance: true},
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrIncompatibleDisplayOptions))
+	assert.Empty(t, server.Requests("searchAssets"))
+
+	_, err = client.GetAssetsByOwner(context.Background(), GetAssetsByOwnerOpts{
+		OwnerAddress: "86xCnPeV69n6t3DnyGvkKobf9FdN2H9oiVDdaMpo2MMY",
+		Options:      &GetAssetsByOwnerOptions{ShowZeroBalance: true},
+	})
+	assert.True(t, errors.Is(err, ErrIncompatibleDisplayOptions))
+	assert.Empty(t, server.Requests("getAssetsByOwner"))
+}
+
+func TestGetAssetsByOwnerOptions_DisplayOptions(t *testing.T) {
+	assert.Equal(t, &GetAssetOptsDisplayOptions{
+		ShowUnverifiedCollections: true,
+		ShowCollectionMetadata:    true,
+		ShowFungible:              true,
+		ShowInscription:           true,
+	}, FullMetadataAssetsOptions().DisplayOptions())
+}
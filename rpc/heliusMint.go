@@ -0,0 +1,207 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// This file implements the Helius Mint API, which mints compressed NFTs
+// on behalf of the caller. See https://docs.helius.dev/compression-and-das-api/mint-api
+
+// HeliusMintAuthority is the account with which Helius mints compressed NFTs;
+// minting into a collection requires delegating the collection authority
+// to it (see DelegateCollectionAuthority).
+var HeliusMintAuthority = solana.MustPublicKeyFromBase58("HnT5KVAywGgQDhmh6Usk4bxRg4RwKxCK4jmECyaDth5R")
+
+type MintCompressedNFTOpts struct {
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+	// Owner receives the minted NFT.
+	Owner       solana.PublicKey `json:"owner"`
+	Description string           `json:"description"`
+	Attributes  []NFTAttribute   `json:"attributes"`
+	// ImageURL and ExternalURL are uploaded to Arweave with the metadata,
+	// unless URI is provided.
+	//
+	// These parameters are optional.
+	ImageURL    string `json:"imageUrl,omitempty"`
+	ExternalURL string `json:"externalUrl,omitempty"`
+	// Royalties, in basis points.
+	//
+	// This parameter is optional.
+	SellerFeeBasisPoints *uint16 `json:"sellerFeeBasisPoints,omitempty"`
+	// Delegate of the minted NFT.
+	//
+	// This parameter is optional.
+	Delegate *solana.PublicKey `json:"delegate,omitempty"`
+	// Collection of the NFT, whose authority must be delegated
+	// to HeliusMintAuthority.
+	//
+	// This parameter is optional.
+	Collection *solana.PublicKey `json:"collection,omitempty"`
+	// URI of already uploaded off-chain metadata.
+	//
+	// This parameter is optional.
+	URI string `json:"uri,omitempty"`
+	// Creators of the NFT; their shares must add up to 100.
+	// Defaults to HeliusMintAuthority.
+	//
+	// This parameter is optional.
+	Creators []NFTCreator `json:"creators,omitempty"`
+	// ConfirmTransaction waits for the mint transaction to be confirmed,
+	// so that the asset ID is returned.
+	ConfirmTransaction bool `json:"confirmTransaction,omitempty"`
+}
+
+type NFTAttribute struct {
+	TraitType string `json:"trait_type"`
+	Value     string `json:"value"`
+}
+
+type NFTCreator struct {
+	Address solana.PublicKey `json:"address"`
+	// Share of the royalties, in percent.
+	Share uint8 `json:"share"`
+}
+
+type MintCompressedNFTResult struct {
+	Signature solana.Signature `json:"signature"`
+	Minted    bool             `json:"minted"`
+	// AssetID of the minted NFT; it is only known once the transaction
+	// is confirmed (see MintCompressedNFTOpts.ConfirmTransaction).
+	AssetID *solana.PublicKey `json:"assetId,omitempty"`
+}
+
+// MintCompressedNFT mints a compressed NFT with the Helius Mint API.
+// Helius pays for the mint, and is the update authority of the NFT.
+func (cl *HeliusClient) MintCompressedNFT(
+	ctx context.Context,
+	opts MintCompressedNFTOpts,
+) (out *MintCompressedNFTResult, err error) {
+	switch {
+	case opts.Name == "":
+		return nil, errors.New("Name is required")
+	case opts.Owner.IsZero():
+		return nil, errors.New("Owner is required")
+	case opts.SellerFeeBasisPoints != nil && *opts.SellerFeeBasisPoints > 10000:
+		return nil, fmt.Errorf("SellerFeeBasisPoints must be at most 10000, got %d", *opts.SellerFeeBasisPoints)
+	}
+	if len(opts.Creators) > 0 {
+		total := 0
+		for _, creator := range opts.Creators {
+			total += int(creator.Share)
+		}
+		if total != 100 {
+			return nil, fmt.Errorf("the shares of the creators must add up to 100, got %d", total)
+		}
+	}
+	if opts.Attributes == nil {
+		opts.Attributes = []NFTAttribute{}
+	}
+
+	err = cl.rpcClient.CallForInto(ctx, &out, "mintCompressedNft", opts)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		return nil, ErrNotFound
+	}
+	return out, nil
+}
+
+// approveCollectionAuthorityDiscriminator is the index of
+// the ApproveCollectionAuthority instruction of the Token Metadata program.
+const approveCollectionAuthorityDiscriminator = 23
+
+// NewDelegateCollectionAuthorityInstruction returns the instruction
+// of the Token Metadata program delegating the authority of the collection
+// to HeliusMintAuthority, so that the Mint API can mint into it.
+func NewDelegateCollectionAuthorityInstruction(
+	collectionMint solana.PublicKey,
+	updateAuthority solana.PublicKey,
+	payer solana.PublicKey,
+) (solana.Instruction, error) {
+	metadata, _, err := solana.FindTokenMetadataAddress(collectionMint)
+	if err != nil {
+		return nil, fmt.Errorf("find metadata address: %w", err)
+	}
+	record, _, err := solana.FindProgramAddress(
+		[][]byte{
+			[]byte("metadata"),
+			solana.TokenMetadataProgramID[:],
+			collectionMint[:],
+			[]byte("collection_authority"),
+			HeliusMintAuthority[:],
+		},
+		solana.TokenMetadataProgramID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("find collection authority record address: %w", err)
+	}
+	return solana.NewInstruction(
+		solana.TokenMetadataProgramID,
+		solana.AccountMetaSlice{
+			solana.Meta(record).WRITE(),
+			solana.Meta(HeliusMintAuthority),
+			solana.Meta(updateAuthority).WRITE().SIGNER(),
+			solana.Meta(payer).WRITE().SIGNER(),
+			solana.Meta(metadata),
+			solana.Meta(collectionMint),
+			solana.Meta(solana.SystemProgramID),
+		},
+		[]byte{approveCollectionAuthorityDiscriminator},
+	), nil
+}
+
+// DelegateCollectionAuthority delegates the authority of the collection
+// to HeliusMintAuthority (see MintCompressedNFTOpts.Collection), with
+// a transaction signed and paid by the update authority of the collection.
+func (cl *HeliusClient) DelegateCollectionAuthority(
+	ctx context.Context,
+	collectionMint solana.PublicKey,
+	updateAuthority solana.PrivateKey,
+) (solana.Signature, error) {
+	authority := updateAuthority.PublicKey()
+	instruction, err := NewDelegateCollectionAuthorityInstruction(collectionMint, authority, authority)
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	latest, err := cl.GetLatestBlockhash(ctx, CommitmentFinalized)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("get latest blockhash: %w", err)
+	}
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{instruction},
+		latest.Value.Blockhash,
+		solana.TransactionPayer(authority),
+	)
+	if err != nil {
+		return solana.Signature{}, fmt.Errorf("build transaction: %w", err)
+	}
+	if _, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		if key.Equals(authority) {
+			return &updateAuthority
+		}
+		return nil
+	}); err != nil {
+		return solana.Signature{}, fmt.Errorf("sign transaction: %w", err)
+	}
+	return cl.SendTransaction(ctx, tx)
+}
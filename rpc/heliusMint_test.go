@@ -0,0 +1,127 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeliusClient_MintCompressedNFT(t *testing.T) {
+	responseBody := `{"signature":"5HBaG2HpXsZvxDtrwYU8ZPbBbpGDPUaM8QcxpUwP9Bfhg7PeVG2VxRJbFMnXpNCq5x8sa9i5aWBo46RBfqHENN5B","minted":true,"assetId":"5fmn4XcDcE7NLL3TiBkJ4xUyKbWXhQMYEbnChHhSeSXv"}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := &HeliusClient{Client: New(server.URL)}
+
+	owner := solana.MustPublicKeyFromBase58("DCQnfUH6mHA333mzkU22b4hMvyqcejUBociodq8bB5HF")
+	royalties := uint16(500)
+	out, err := client.MintCompressedNFT(context.Background(), MintCompressedNFTOpts{
+		Name:                 "Exodia the Forbidden One",
+		Symbol:               "ETFO",
+		Owner:                owner,
+		Description:          "Exodia",
+		Attributes:           []NFTAttribute{{TraitType: "Type", Value: "Legendary"}},
+		ImageURL:             "https://example.com/exodia.png",
+		SellerFeeBasisPoints: &royalties,
+		Creators:             []NFTCreator{{Address: owner, Share: 100}},
+		ConfirmTransaction:   true,
+	})
+	require.NoError(t, err)
+
+	reqBody := server.RequestBody(t)
+	assert.Equal(t, "mintCompressedNft", reqBody["method"])
+	assert.Equal(t,
+		map[string]interface{}{
+			"name":                 "Exodia the Forbidden One",
+			"symbol":               "ETFO",
+			"owner":                owner.String(),
+			"description":          "Exodia",
+			"attributes":           []interface{}{map[string]interface{}{"trait_type": "Type", "value": "Legendary"}},
+			"imageUrl":             "https://example.com/exodia.png",
+			"sellerFeeBasisPoints": float64(500),
+			"creators":             []interface{}{map[string]interface{}{"address": owner.String(), "share": float64(100)}},
+			"confirmTransaction":   true,
+		},
+		reqBody["params"],
+	)
+
+	assetID := solana.MustPublicKeyFromBase58("5fmn4XcDcE7NLL3TiBkJ4xUyKbWXhQMYEbnChHhSeSXv")
+	assert.Equal(t,
+		&MintCompressedNFTResult{
+			Signature: solana.MustSignatureFromBase58("5HBaG2HpXsZvxDtrwYU8ZPbBbpGDPUaM8QcxpUwP9Bfhg7PeVG2VxRJbFMnXpNCq5x8sa9i5aWBo46RBfqHENN5B"),
+			Minted:    true,
+			AssetID:   &assetID,
+		},
+		out,
+	)
+
+	_, err = client.MintCompressedNFT(context.Background(), MintCompressedNFTOpts{Name: "name"})
+	require.Error(t, err)
+	_, err = client.MintCompressedNFT(context.Background(), MintCompressedNFTOpts{
+		Name:     "name",
+		Owner:    owner,
+		Creators: []NFTCreator{{Address: owner, Share: 50}},
+	})
+	require.Error(t, err)
+}
+
+func TestHeliusClient_DelegateCollectionAuthority(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getLatestBlockhash", M{
+		"context": M{"slot": 100},
+		"value":   M{"blockhash": "5M77sHdwzH6rckuQwF8HL1w52n7hjrh4GVTFiF6T8QyB", "lastValidBlockHeight": 200},
+	})
+	server.Handle("sendTransaction", "5HBaG2HpXsZvxDtrwYU8ZPbBbpGDPUaM8QcxpUwP9Bfhg7PeVG2VxRJbFMnXpNCq5x8sa9i5aWBo46RBfqHENN5B")
+	client := &HeliusClient{Client: New(server.URL())}
+
+	authority := solana.NewWallet().PrivateKey
+	collection := solana.MustPublicKeyFromBase58("5fmn4XcDcE7NLL3TiBkJ4xUyKbWXhQMYEbnChHhSeSXv")
+	sig, err := client.DelegateCollectionAuthority(context.Background(), collection, authority)
+	require.NoError(t, err)
+	assert.Equal(t, solana.MustSignatureFromBase58("5HBaG2HpXsZvxDtrwYU8ZPbBbpGDPUaM8QcxpUwP9Bfhg7PeVG2VxRJbFMnXpNCq5x8sa9i5aWBo46RBfqHENN5B"), sig)
+
+	requests := server.Requests("sendTransaction")
+	require.Len(t, requests, 1)
+	var params []interface{}
+	require.NoError(t, stdjson.Unmarshal(requests[0].Params, &params))
+	tx, err := solana.TransactionFromBase64(params[0].(string))
+	require.NoError(t, err)
+	require.NoError(t, tx.VerifySignatures())
+
+	require.Len(t, tx.Message.Instructions, 1)
+	instruction := tx.Message.Instructions[0]
+	programID, err := tx.Message.Program(instruction.ProgramIDIndex)
+	require.NoError(t, err)
+	assert.Equal(t, solana.TokenMetadataProgramID, programID)
+	assert.Equal(t, solana.Base58{23}, instruction.Data)
+
+	metadata, _, err := solana.FindTokenMetadataAddress(collection)
+	require.NoError(t, err)
+	accounts, err := instruction.ResolveInstructionAccounts(&tx.Message)
+	require.NoError(t, err)
+	require.Len(t, accounts, 7)
+	assert.Equal(t, HeliusMintAuthority, accounts[1].PublicKey)
+	assert.Equal(t, authority.PublicKey(), accounts[2].PublicKey)
+	assert.True(t, accounts[2].IsSigner)
+	assert.Equal(t, metadata, accounts[4].PublicKey)
+	assert.Equal(t, collection, accounts[5].PublicKey)
+}
@@ -0,0 +1,246 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DefaultHeliusAPIURL is the base URL of the Helius REST APIs.
+var DefaultHeliusAPIURL = "https://api.helius.xyz"
+
+type WebhookType string
+
+const (
+	WebhookTypeEnhanced       WebhookType = "enhanced"
+	WebhookTypeRaw            WebhookType = "raw"
+	WebhookTypeDiscord        WebhookType = "discord"
+	WebhookTypeEnhancedDevnet WebhookType = "enhancedDevnet"
+	WebhookTypeRawDevnet      WebhookType = "rawDevnet"
+	WebhookTypeDiscordDevnet  WebhookType = "discordDevnet"
+)
+
+type WebhookTxnStatus string
+
+const (
+	WebhookTxnStatusAll     WebhookTxnStatus = "all"
+	WebhookTxnStatusSuccess WebhookTxnStatus = "success"
+	WebhookTxnStatusFailed  WebhookTxnStatus = "failed"
+)
+
+// Webhook is the configuration of a Helius webhook.
+type Webhook struct {
+	// WebhookID is set by Helius on creation.
+	WebhookID string `json:"webhookID,omitempty"`
+	Wallet    string `json:"wallet,omitempty"`
+
+	// WebhookURL is the URL the events are posted to.
+	WebhookURL string `json:"webhookURL"`
+	// TransactionTypes filters the events by type (e.g. "ANY", "SWAP", "NFT_SALE").
+	TransactionTypes []string `json:"transactionTypes"`
+	// AccountAddresses are the accounts whose transactions trigger the webhook.
+	AccountAddresses []solana.PublicKey `json:"accountAddresses"`
+	WebhookType      WebhookType        `json:"webhookType"`
+	// AuthHeader is sent as the Authorization header of the posted events
+	// (see NewWebhookHandler).
+	AuthHeader string           `json:"authHeader,omitempty"`
+	TxnStatus  WebhookTxnStatus `json:"txnStatus,omitempty"`
+	Encoding   string           `json:"encoding,omitempty"`
+}
+
+// CreateWebhook creates a webhook, and returns it with its WebhookID.
+func (cl *HeliusClient) CreateWebhook(ctx context.Context, webhook *Webhook) (out *Webhook, err error) {
+	err = cl.webhooksCall(ctx, http.MethodPost, "", webhook, &out)
+	return
+}
+
+// GetWebhook returns the webhook with the provided ID,
+// or ErrNotFound if it does not exist.
+func (cl *HeliusClient) GetWebhook(ctx context.Context, webhookID string) (out *Webhook, err error) {
+	err = cl.webhooksCall(ctx, http.MethodGet, webhookID, nil, &out)
+	return
+}
+
+// GetAllWebhooks returns all the webhooks of the API key.
+func (cl *HeliusClient) GetAllWebhooks(ctx context.Context) (out []*Webhook, err error) {
+	err = cl.webhooksCall(ctx, http.MethodGet, "", nil, &out)
+	return
+}
+
+// EditWebhook replaces the configuration of the webhook with the provided ID.
+func (cl *HeliusClient) EditWebhook(ctx context.Context, webhookID string, webhook *Webhook) (out *Webhook, err error) {
+	err = cl.webhooksCall(ctx, http.MethodPut, webhookID, webhook, &out)
+	return
+}
+
+// DeleteWebhook deletes the webhook with the provided ID.
+func (cl *HeliusClient) DeleteWebhook(ctx context.Context, webhookID string) error {
+	return cl.webhooksCall(ctx, http.MethodDelete, webhookID, nil, nil)
+}
+
+func (cl *HeliusClient) webhooksCall(ctx context.Context, method string, webhookID string, in interface{}, out interface{}) error {
+	apiURL := cl.apiURL
+	if apiURL == "" {
+		apiURL = DefaultHeliusAPIURL
+	}
+	endpoint := apiURL + "/v0/webhooks"
+	if webhookID != "" {
+		endpoint += "/" + url.PathEscape(webhookID)
+	}
+	endpoint += "?" + url.Values{DefaultAPIKeyParam: {cl.apiKey}}.Encode()
+
+	var body io.Reader
+	if in != nil {
+		data, err := stdjson.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("webhooks: encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("webhooks: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := newHTTP().Do(req)
+	if err != nil {
+		return fmt.Errorf("webhooks: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("webhooks: read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhooks: %s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := stdjson.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("webhooks: decode response: %w", err)
+	}
+	return nil
+}
+
+// EnhancedTransaction is an event posted by an enhanced webhook.
+type EnhancedTransaction struct {
+	Description string                 `json:"description"`
+	Type        string                 `json:"type"`
+	Source      string                 `json:"source"`
+	Fee         uint64                 `json:"fee"`
+	FeePayer    solana.PublicKey       `json:"feePayer"`
+	Signature   solana.Signature       `json:"signature"`
+	Slot        uint64                 `json:"slot"`
+	Timestamp   solana.UnixTimeSeconds `json:"timestamp"`
+
+	NativeTransfers  []EnhancedNativeTransfer  `json:"nativeTransfers"`
+	TokenTransfers   []EnhancedTokenTransfer   `json:"tokenTransfers"`
+	AccountData      []EnhancedAccountData     `json:"accountData"`
+	TransactionError *EnhancedTransactionError `json:"transactionError"`
+	Instructions     []EnhancedInstruction     `json:"instructions"`
+	// Events holds the parsed events (e.g. "nft", "swap") by name.
+	Events map[string]stdjson.RawMessage `json:"events"`
+}
+
+type EnhancedNativeTransfer struct {
+	FromUserAccount string `json:"fromUserAccount"`
+	ToUserAccount   string `json:"toUserAccount"`
+	Amount          uint64 `json:"amount"`
+}
+
+type EnhancedTokenTransfer struct {
+	FromUserAccount  string           `json:"fromUserAccount"`
+	ToUserAccount    string           `json:"toUserAccount"`
+	FromTokenAccount string           `json:"fromTokenAccount"`
+	ToTokenAccount   string           `json:"toTokenAccount"`
+	TokenAmount      float64          `json:"tokenAmount"`
+	Mint             solana.PublicKey `json:"mint"`
+	TokenStandard    TokenStandard    `json:"tokenStandard"`
+}
+
+type EnhancedAccountData struct {
+	Account             solana.PublicKey             `json:"account"`
+	NativeBalanceChange int64                        `json:"nativeBalanceChange"`
+	TokenBalanceChanges []EnhancedTokenBalanceChange `json:"tokenBalanceChanges"`
+}
+
+type EnhancedTokenBalanceChange struct {
+	UserAccount    string           `json:"userAccount"`
+	TokenAccount   string           `json:"tokenAccount"`
+	Mint           solana.PublicKey `json:"mint"`
+	RawTokenAmount struct {
+		TokenAmount string `json:"tokenAmount"`
+		Decimals    uint8  `json:"decimals"`
+	} `json:"rawTokenAmount"`
+}
+
+type EnhancedTransactionError struct {
+	Error string `json:"error"`
+}
+
+type EnhancedInstruction struct {
+	Accounts          []solana.PublicKey    `json:"accounts"`
+	Data              string                `json:"data"`
+	ProgramID         solana.PublicKey      `json:"programId"`
+	InnerInstructions []EnhancedInstruction `json:"innerInstructions"`
+}
+
+// WebhookHandlerFunc processes the events posted to a webhook.
+// Returning an error makes the handler respond with a server error,
+// so that Helius retries the delivery.
+type WebhookHandlerFunc func(ctx context.Context, events []EnhancedTransaction) error
+
+// NewWebhookHandler returns an http.Handler receiving the events of
+// an enhanced webhook. If authHeader is not empty, requests whose
+// Authorization header does not match it (see Webhook.AuthHeader)
+// are rejected.
+func NewWebhookHandler(authHeader string, fn WebhookHandlerFunc) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if authHeader != "" && subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte(authHeader)) != 1 {
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var events []EnhancedTransaction
+		if err := stdjson.NewDecoder(req.Body).Decode(&events); err != nil {
+			http.Error(rw, "invalid payload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := fn(req.Context(), events); err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	})
+}
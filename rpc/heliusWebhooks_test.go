@@ -0,0 +1,131 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeliusClient_Webhooks(t *testing.T) {
+	type call struct {
+		method, path, apiKey, body string
+	}
+	var calls []call
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		calls = append(calls, call{req.Method, req.URL.Path, req.URL.Query().Get("api-key"), string(body)})
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/v0/webhooks/missing":
+			http.Error(rw, `{"error":"not found"}`, http.StatusNotFound)
+		case req.Method == http.MethodGet && req.URL.Path == "/v0/webhooks":
+			rw.Write([]byte(`[{"webhookID":"wh1","webhookURL":"https://example.com/hook","transactionTypes":["ANY"],"accountAddresses":[],"webhookType":"enhanced"}]`))
+		case req.Method == http.MethodDelete:
+			rw.WriteHeader(http.StatusOK)
+		default:
+			var webhook Webhook
+			stdjson.Unmarshal(body, &webhook)
+			webhook.WebhookID = "wh1"
+			stdjson.NewEncoder(rw).Encode(webhook)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHelius("https://mainnet.helius-rpc.com/?api-key=secret").WithAPI(server.URL, "secret")
+	require.Equal(t, "secret", NewHelius("https://mainnet.helius-rpc.com/?api-key=secret").apiKey)
+
+	account := solana.MustPublicKeyFromBase58("7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932")
+	created, err := client.CreateWebhook(context.Background(), &Webhook{
+		WebhookURL:       "https://example.com/hook",
+		TransactionTypes: []string{"ANY"},
+		AccountAddresses: []solana.PublicKey{account},
+		WebhookType:      WebhookTypeEnhanced,
+		AuthHeader:       "Bearer hook",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "wh1", created.WebhookID)
+	require.Equal(t, []solana.PublicKey{account}, created.AccountAddresses)
+
+	_, err = client.EditWebhook(context.Background(), "wh1", created)
+	require.NoError(t, err)
+
+	all, err := client.GetAllWebhooks(context.Background())
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+
+	_, err = client.GetWebhook(context.Background(), "missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, client.DeleteWebhook(context.Background(), "wh1"))
+
+	require.Equal(t, []string{"POST /v0/webhooks", "PUT /v0/webhooks/wh1", "GET /v0/webhooks", "GET /v0/webhooks/missing", "DELETE /v0/webhooks/wh1"}, func() []string {
+		var out []string
+		for _, c := range calls {
+			require.Equal(t, "secret", c.apiKey)
+			out = append(out, c.method+" "+c.path)
+		}
+		return out
+	}())
+	require.Contains(t, calls[0].body, `"authHeader":"Bearer hook"`)
+}
+
+func TestNewWebhookHandler(t *testing.T) {
+	payload := `[{"description":"A transferred 0.1 SOL to B.","type":"TRANSFER","source":"SYSTEM_PROGRAM","fee":5000,"feePayer":"7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932","signature":"5h6xBEauJ3PK6SWCZ1PGjBvj8vDdWG3KpwATGy1ARAXFSDwt8GFXM7W5Ncn16wmqokgpiKRLuS83KUxyZyv2sUYv","slot":250000000,"timestamp":1700000000,"nativeTransfers":[{"fromUserAccount":"7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932","toUserAccount":"11111111111111111111111111111111","amount":100000000}],"tokenTransfers":[],"accountData":[{"account":"7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932","nativeBalanceChange":-100005000,"tokenBalanceChanges":[]}],"transactionError":null,"instructions":[{"accounts":["7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932"],"data":"3Bxs4h24hBtQy9rw","programId":"11111111111111111111111111111111","innerInstructions":[]}],"events":{}}]`
+
+	var got []EnhancedTransaction
+	fail := false
+	handler := NewWebhookHandler("Bearer hook", func(ctx context.Context, events []EnhancedTransaction) error {
+		if fail {
+			return errors.New("boom")
+		}
+		got = events
+		return nil
+	})
+
+	post := func(auth string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(payload)))
+		req.Header.Set("Authorization", auth)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusUnauthorized, post("Bearer nope"))
+	require.Equal(t, http.StatusOK, post("Bearer hook"))
+	require.Len(t, got, 1)
+	require.Equal(t, "TRANSFER", got[0].Type)
+	require.Equal(t, uint64(100000000), got[0].NativeTransfers[0].Amount)
+	require.Equal(t, int64(-100005000), got[0].AccountData[0].NativeBalanceChange)
+	require.Equal(t, solana.SystemProgramID, got[0].Instructions[0].ProgramID)
+
+	fail = true
+	require.Equal(t, http.StatusInternalServerError, post("Bearer hook"))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{"))
+	req.Header.Set("Authorization", "Bearer hook")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -2,19 +2,62 @@ package rpc
 
 import (
 	"context"
+	stdjson "encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
 )
 
 type HeliusClient struct {
 	*Client
+
+	// apiURL and apiKey are used by the REST APIs (e.g. webhooks).
+	apiURL string
+	apiKey string
 }
 
 func NewHelius(rpcEndpoint string) *HeliusClient {
 	return &HeliusClient{
 		Client: New(rpcEndpoint),
+		apiURL: DefaultHeliusAPIURL,
+		apiKey: apiKeyFromEndpoint(rpcEndpoint),
+	}
+}
+
+// NewHeliusWithOptions creates a new Helius RPC client configured with the provided options.
+// Set Options.APIKeys to pass the API key(s) as the "api-key" query parameter
+// instead of including it in rpcEndpoint.
+func NewHeliusWithOptions(rpcEndpoint string, opts *Options) *HeliusClient {
+	cl := &HeliusClient{
+		Client: NewWithOptions(rpcEndpoint, opts),
+		apiURL: DefaultHeliusAPIURL,
+		apiKey: apiKeyFromEndpoint(rpcEndpoint),
 	}
+	if opts != nil && len(opts.APIKeys) > 0 {
+		cl.apiKey = opts.APIKeys[0]
+	}
+	return cl
+}
+
+// WithAPI returns a copy of the client that uses the provided base URL
+// and API key for the Helius REST APIs (e.g. webhooks). By default,
+// DefaultHeliusAPIURL is used with the API key of the RPC endpoint.
+func (cl *HeliusClient) WithAPI(apiURL string, apiKey string) *HeliusClient {
+	out := *cl
+	out.apiURL = strings.TrimSuffix(apiURL, "/")
+	out.apiKey = apiKey
+	return &out
+}
+
+func apiKeyFromEndpoint(rpcEndpoint string) string {
+	u, err := url.Parse(rpcEndpoint)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get(DefaultAPIKeyParam)
 }
 
 type GetAssetOpts struct {
@@ -54,6 +97,7 @@ type GetAssetsByOwnerOpts struct {
 	OwnerAddress string                   `json:"ownerAddress"`
 	Page         *int                     `json:"page,omitempty"`
 	Limit        *int                     `json:"limit,omitempty"`
+	Cursor       *string                  `json:"cursor,omitempty"`
 	SortBy       *GetAssetsByOwnerSortBy  `json:"sortBy,omitempty"`
 	Before       *string                  `json:"before,omitempty"`
 	After        *string                  `json:"after,omitempty"`
@@ -95,8 +139,53 @@ func (cl *HeliusClient) GetAsset(
 	return out, nil
 }
 
+// DefaultAssetBatchSize is the maximum number of assets
+// requested at once by GetAssetBatch.
+var DefaultAssetBatchSize = 1000
+
+// GetAssetBatch returns the assets with the provided ids, in the same order;
+// the entries of the assets that are not found are nil.
+// The ids are requested in batches of DefaultAssetBatchSize.
+func (cl *HeliusClient) GetAssetBatch(
+	ctx context.Context,
+	ids []string,
+	displayOptions *GetAssetOptsDisplayOptions, // optional
+) ([]*GetAssetResult, error) {
+	found := make(map[string]*GetAssetResult, len(ids))
+	for start := 0; start < len(ids); start += DefaultAssetBatchSize {
+		end := start + DefaultAssetBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		params := M{
+			"ids": ids[start:end],
+		}
+		if displayOptions != nil {
+			params["displayOptions"] = displayOptions
+		}
+
+		var batch []*GetAssetResult
+		if err := cl.rpcClient.CallForInto(ctx, &batch, "getAssetBatch", params); err != nil {
+			return nil, err
+		}
+		// The results are matched by id, as the order of the
+		// response is not relied upon.
+		for _, asset := range batch {
+			if asset != nil {
+				found[asset.Id] = asset
+			}
+		}
+	}
+
+	out := make([]*GetAssetResult, len(ids))
+	for i, id := range ids {
+		out[i] = found[id]
+	}
+	return out, nil
+}
+
 type GetAssetResult struct {
-	Interface      string                  `json:"interface"` // enum :V1_NFT V1_PRINT LEGACY_NFT V2_NFT FungibleAsset FungibleToken Custom Identity Executable ProgrammableNFT
+	Interface      AssetInterface          `json:"interface"`
 	Id             string                  `json:"id"`
 	Content        *GetAssetContent        `json:"content"`
 	Authorities    []GetAssetAuthorities   `json:"authorities"`
@@ -127,11 +216,11 @@ type GetAssetFile struct {
 }
 
 type GetAssetMetadata struct {
-	Description   string `json:"description"`
-	Name          string `json:"name"`
-	Symbol        string `json:"symbol"`
-	TokenStandard string `json:"token_standard"`
-	Attributes    []any  `json:"attributes"`
+	Description   string        `json:"description"`
+	Name          string        `json:"name"`
+	Symbol        string        `json:"symbol"`
+	TokenStandard TokenStandard `json:"token_standard"`
+	Attributes    []any         `json:"attributes"`
 }
 
 type GetAssetLinks struct {
@@ -185,8 +274,65 @@ type GetAssetOwnership struct {
 	Burnt          bool    `json:"burnt"`
 }
 
+// GetAssetMintExtensions holds the Token-2022 extensions of a mint.
+// Extensions not set on the mint are nil.
 type GetAssetMintExtensions struct {
-	// TODO
+	TransferFeeConfig     *GetAssetTransferFeeConfig     `json:"transfer_fee_config,omitempty"`
+	InterestBearingConfig *GetAssetInterestBearingConfig `json:"interest_bearing_config,omitempty"`
+	MetadataPointer       *GetAssetMetadataPointer       `json:"metadata_pointer,omitempty"`
+	PermanentDelegate     *GetAssetPermanentDelegate     `json:"permanent_delegate,omitempty"`
+	TransferHook          *GetAssetTransferHook          `json:"transfer_hook,omitempty"`
+	MintCloseAuthority    *GetAssetMintCloseAuthority    `json:"mint_close_authority,omitempty"`
+	Metadata              *GetAssetTokenMetadata         `json:"metadata,omitempty"`
+}
+
+type GetAssetTransferFee struct {
+	Epoch                  uint64 `json:"epoch"`
+	MaximumFee             uint64 `json:"maximum_fee"`
+	TransferFeeBasisPoints uint16 `json:"transfer_fee_basis_points"`
+}
+
+type GetAssetTransferFeeConfig struct {
+	TransferFeeConfigAuthority string              `json:"transfer_fee_config_authority"`
+	WithdrawWithheldAuthority  string              `json:"withdraw_withheld_authority"`
+	WithheldAmount             uint64              `json:"withheld_amount"`
+	OlderTransferFee           GetAssetTransferFee `json:"older_transfer_fee"`
+	NewerTransferFee           GetAssetTransferFee `json:"newer_transfer_fee"`
+}
+
+type GetAssetInterestBearingConfig struct {
+	RateAuthority           string `json:"rate_authority"`
+	InitializationTimestamp int64  `json:"initialization_timestamp"`
+	PreUpdateAverageRate    int16  `json:"pre_update_average_rate"`
+	LastUpdateTimestamp     int64  `json:"last_update_timestamp"`
+	CurrentRate             int16  `json:"current_rate"`
+}
+
+type GetAssetMetadataPointer struct {
+	Authority       string `json:"authority"`
+	MetadataAddress string `json:"metadata_address"`
+}
+
+type GetAssetPermanentDelegate struct {
+	Delegate string `json:"delegate"`
+}
+
+type GetAssetTransferHook struct {
+	Authority string `json:"authority"`
+	ProgramID string `json:"program_id"`
+}
+
+type GetAssetMintCloseAuthority struct {
+	CloseAuthority string `json:"close_authority"`
+}
+
+type GetAssetTokenMetadata struct {
+	UpdateAuthority    string               `json:"update_authority"`
+	Mint               string               `json:"mint"`
+	Name               string               `json:"name"`
+	Symbol             string               `json:"symbol"`
+	URI                string               `json:"uri"`
+	AdditionalMetadata []stdjson.RawMessage `json:"additional_metadata,omitempty"`
 }
 
 type GetAssetSupply struct {
@@ -213,11 +359,28 @@ type GetAssetPriceInfo struct {
 }
 
 type GetAssetInscription struct {
-	// TODO
+	// Inscription number.
+	Order                  uint64 `json:"order"`
+	Size                   uint64 `json:"size"`
+	ContentType            string `json:"contentType"`
+	Encoding               string `json:"encoding"`
+	ValidationHash         string `json:"validationHash"`
+	InscriptionDataAccount string `json:"inscriptionDataAccount"`
+	Authority              string `json:"authority"`
 }
 
+// GetAssetSPL20 is the SPL-20 payload of an inscription.
 type GetAssetSPL20 struct {
-	// TODO
+	// Protocol, "spl-20".
+	P string `json:"p"`
+	// Operation, e.g. "deploy" or "mint".
+	Op   string `json:"op"`
+	Tick string `json:"tick"`
+	// Amount, as a decimal string.
+	Amt string `json:"amt"`
+	// Maximum supply and mint limit, set by "deploy" operations.
+	Max string `json:"max,omitempty"`
+	Lim string `json:"lim,omitempty"`
 }
 
 func (cl *HeliusClient) GetAssetsByOwner(
@@ -232,6 +395,10 @@ func (cl *HeliusClient) GetAssetsByOwner(
 		return nil, fmt.Errorf("OwnerAddress is not a valid public key")
 	}
 
+	if err := opts.Options.Validate("getAssetsByOwner"); err != nil {
+		return nil, err
+	}
+
 	params := M{}
 	params["ownerAddress"] = opts.OwnerAddress
 
@@ -241,6 +408,9 @@ func (cl *HeliusClient) GetAssetsByOwner(
 	if opts.Limit != nil {
 		params["limit"] = opts.Limit
 	}
+	if opts.Cursor != nil {
+		params["cursor"] = opts.Cursor
+	}
 	if opts.SortBy != nil {
 		params["sortBy"] = opts.SortBy
 	}
@@ -268,15 +438,68 @@ func (cl *HeliusClient) GetAssetsByOwner(
 }
 
 type GetAssetsByOwnerResult struct {
-	Total         int                    `json:"total"`
-	Limit         int                    `json:"limit"`
-	Page          int                    `json:"page"`
-	Items         []GetAssetsByOwnerItem `json:"items"`
-	NativeBalance any                    `json:"native_balance"` // TODO
+	Total         int                     `json:"total"`
+	Limit         int                     `json:"limit"`
+	Page          int                     `json:"page"`
+	Cursor        *string                 `json:"cursor,omitempty"`
+	Items         []GetAssetsByOwnerItem  `json:"items"`
+	NativeBalance *GetAssetsNativeBalance `json:"native_balance"`
+}
+
+type SearchAssetsOpts struct {
+	OwnerAddress     *string `json:"ownerAddress,omitempty"`
+	CreatorAddress   *string `json:"creatorAddress,omitempty"`
+	CreatorVerified  *bool   `json:"creatorVerified,omitempty"`
+	AuthorityAddress *string `json:"authorityAddress,omitempty"`
+	// Grouping is a group key and value, e.g. ["collection", "<collection address>"].
+	Grouping   []string        `json:"grouping,omitempty"`
+	Burnt      *bool           `json:"burnt,omitempty"`
+	Compressed *bool           `json:"compressed,omitempty"`
+	Interface  *AssetInterface `json:"interface,omitempty"`
+	// TokenType is one of "fungible", "nonFungible", "regularNft", "compressedNft" or "all".
+	TokenType *string                  `json:"tokenType,omitempty"`
+	Page      *int                     `json:"page,omitempty"`
+	Limit     *int                     `json:"limit,omitempty"`
+	Cursor    *string                  `json:"cursor,omitempty"`
+	SortBy    *GetAssetsByOwnerSortBy  `json:"sortBy,omitempty"`
+	Before    *string                  `json:"before,omitempty"`
+	After     *string                  `json:"after,omitempty"`
+	Options   *GetAssetsByOwnerOptions `json:"options,omitempty"`
+}
+
+// SearchAssets returns the assets matching all the provided criteria.
+func (cl *HeliusClient) SearchAssets(
+	ctx context.Context,
+	opts SearchAssetsOpts,
+) (out *GetAssetsByOwnerResult, err error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	err = cl.rpcClient.CallForInto(ctx, &out, "searchAssets", opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out == nil {
+		return nil, ErrNotFound
+	}
+
+	return out, nil
+}
+
+// GetAssetsNativeBalance is the SOL balance of the owner,
+// returned when GetAssetsByOwnerOptions.ShowNativeBalance is set.
+type GetAssetsNativeBalance struct {
+	Lamports    uint64  `json:"lamports"`
+	PricePerSol float64 `json:"price_per_sol"`
+	// Value of the balance, in the currency of PricePerSol.
+	TotalPrice float64 `json:"total_price"`
 }
 
 type GetAssetsByOwnerItem struct {
-	Interface      string                         `json:"interface"` // enum :V1_NFT V1_PRINT LEGACY_NFT V2_NFT FungibleAsset FungibleToken Custom Identity Executable ProgrammableNFT
+	Interface      AssetInterface                 `json:"interface"`
 	Id             string                         `json:"id"`
 	Content        *GetAssetContent               `json:"content"`
 	Authorities    []GetAssetAuthorities          `json:"authorities"`
@@ -304,3 +527,357 @@ type GetAssetsByOwnerItemTokenInfo struct {
 	MintAuthority          string             `json:"mint_authority"`
 	FreezeAuthority        string             `json:"freeze_authority"`
 }
+
+type TokenAccountsOptions struct {
+	ShowZeroBalance bool `json:"showZeroBalance"`
+}
+
+type TokenAccountsOpts struct {
+	// Mint address to filter token accounts by.
+	Mint *string `json:"mint,omitempty"`
+	// Owner address to filter token accounts by.
+	Owner   *string               `json:"owner,omitempty"`
+	Page    *int                  `json:"page,omitempty"`
+	Limit   *int                  `json:"limit,omitempty"`
+	Cursor  *string               `json:"cursor,omitempty"`
+	Before  *string               `json:"before,omitempty"`
+	After   *string               `json:"after,omitempty"`
+	Options *TokenAccountsOptions `json:"options,omitempty"`
+}
+
+// GetTokenAccounts returns the token accounts of a mint and/or owner.
+// At least one of Mint or Owner must be provided.
+func (cl *HeliusClient) GetTokenAccounts(
+	ctx context.Context,
+	opts TokenAccountsOpts,
+) (out *TokenAccountsResult, err error) {
+	if opts.Mint == nil && opts.Owner == nil {
+		return nil, fmt.Errorf("at least one of Mint or Owner is required")
+	}
+
+	params := M{}
+	if opts.Mint != nil {
+		if _, err := solana.PublicKeyFromBase58(*opts.Mint); err != nil {
+			return nil, fmt.Errorf("Mint is not a valid public key")
+		}
+		params["mint"] = opts.Mint
+	}
+	if opts.Owner != nil {
+		if _, err := solana.PublicKeyFromBase58(*opts.Owner); err != nil {
+			return nil, fmt.Errorf("Owner is not a valid public key")
+		}
+		params["owner"] = opts.Owner
+	}
+	if opts.Page != nil {
+		params["page"] = opts.Page
+	}
+	if opts.Limit != nil {
+		params["limit"] = opts.Limit
+	}
+	if opts.Cursor != nil {
+		params["cursor"] = opts.Cursor
+	}
+	if opts.Before != nil {
+		params["before"] = opts.Before
+	}
+	if opts.After != nil {
+		params["after"] = opts.After
+	}
+	if opts.Options != nil {
+		params["options"] = opts.Options
+	}
+
+	err = cl.rpcClient.CallForInto(ctx, &out, "getTokenAccounts", params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out == nil {
+		return nil, ErrNotFound
+	}
+
+	return out, nil
+}
+
+type TokenAccountsResult struct {
+	Total         int                 `json:"total"`
+	Limit         int                 `json:"limit"`
+	Page          *int                `json:"page,omitempty"`
+	Cursor        *string             `json:"cursor,omitempty"`
+	TokenAccounts []TokenAccountsItem `json:"token_accounts"`
+}
+
+type TokenAccountsItem struct {
+	Address         string `json:"address"`
+	Mint            string `json:"mint"`
+	Owner           string `json:"owner"`
+	Amount          uint64 `json:"amount"`
+	DelegatedAmount uint64 `json:"delegated_amount"`
+	Frozen          bool   `json:"frozen"`
+}
+
+type GetNftEditionsOpts struct {
+	// Mint of the master edition.
+	Mint string
+	// Page to return, starting at 1.
+	//
+	// This parameter is optional.
+	Page *int
+	// Maximum number of editions per page.
+	//
+	// This parameter is optional.
+	Limit *int
+}
+
+// GetNftEditions returns the print editions of a master edition NFT.
+func (cl *HeliusClient) GetNftEditions(
+	ctx context.Context,
+	opts GetNftEditionsOpts,
+) (out *GetNftEditionsResult, err error) {
+	if _, err := solana.PublicKeyFromBase58(opts.Mint); err != nil {
+		return nil, fmt.Errorf("Mint is not a valid public key")
+	}
+
+	params := M{
+		"mint": opts.Mint,
+	}
+	if opts.Page != nil {
+		params["page"] = opts.Page
+	}
+	if opts.Limit != nil {
+		params["limit"] = opts.Limit
+	}
+
+	err = cl.rpcClient.CallForInto(ctx, &out, "getNftEditions", params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out == nil {
+		return nil, ErrNotFound
+	}
+
+	return out, nil
+}
+
+type GetNftEditionsResult struct {
+	Total                int          `json:"total"`
+	Limit                int          `json:"limit"`
+	Page                 int          `json:"page"`
+	MasterEditionAddress string       `json:"master_edition_address"`
+	Supply               uint64       `json:"supply"`
+	MaxSupply            *uint64      `json:"max_supply,omitempty"`
+	Editions             []NftEdition `json:"editions"`
+}
+
+type NftEdition struct {
+	Mint           string `json:"mint"`
+	EditionAddress string `json:"edition_address"`
+	Edition        uint64 `json:"edition"`
+}
+
+type GetSignaturesForAssetOpts struct {
+	// Id of the asset.
+	// Either Id, or Tree and LeafIndex must be provided.
+	Id string
+	// Merkle tree and leaf index of a compressed asset.
+	Tree      string
+	LeafIndex *uint64
+	// Page to return, starting at 1.
+	//
+	// This parameter is optional.
+	Page *int
+	// Maximum number of signatures per page.
+	//
+	// This parameter is optional.
+	Limit *int
+	// Cursors to paginate before or after a signature, instead of by page.
+	//
+	// This parameter is optional.
+	Before *string
+	After  *string
+}
+
+// GetSignaturesForAsset returns the signatures of the transactions
+// that modified a compressed asset, with the type of each transaction.
+func (cl *HeliusClient) GetSignaturesForAsset(
+	ctx context.Context,
+	opts GetSignaturesForAssetOpts,
+) (out *GetSignaturesForAssetResult, err error) {
+	params := M{}
+	switch {
+	case opts.Id != "" && (opts.Tree != "" || opts.LeafIndex != nil):
+		return nil, fmt.Errorf("only one of Id or Tree and LeafIndex must be provided")
+	case opts.Id != "":
+		if _, err := solana.PublicKeyFromBase58(opts.Id); err != nil {
+			return nil, fmt.Errorf("Id is not a valid public key")
+		}
+		params["id"] = opts.Id
+	case opts.Tree != "" && opts.LeafIndex != nil:
+		if _, err := solana.PublicKeyFromBase58(opts.Tree); err != nil {
+			return nil, fmt.Errorf("Tree is not a valid public key")
+		}
+		params["tree"] = opts.Tree
+		params["leafIndex"] = *opts.LeafIndex
+	default:
+		return nil, fmt.Errorf("either Id, or Tree and LeafIndex are required")
+	}
+	if opts.Page != nil {
+		params["page"] = opts.Page
+	}
+	if opts.Limit != nil {
+		params["limit"] = opts.Limit
+	}
+	if opts.Before != nil {
+		params["before"] = opts.Before
+	}
+	if opts.After != nil {
+		params["after"] = opts.After
+	}
+
+	err = cl.rpcClient.CallForInto(ctx, &out, "getSignaturesForAsset", params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if out == nil {
+		return nil, ErrNotFound
+	}
+
+	return out, nil
+}
+
+type GetSignaturesForAssetResult struct {
+	Total  int              `json:"total"`
+	Limit  int              `json:"limit"`
+	Page   int              `json:"page,omitempty"`
+	Before string           `json:"before,omitempty"`
+	After  string           `json:"after,omitempty"`
+	Items  []AssetSignature `json:"items"`
+}
+
+// AssetSignature is a transaction that modified an asset,
+// encoded as a [signature, type] pair in JSON.
+type AssetSignature struct {
+	Signature solana.Signature
+	// Type of the transaction, e.g. "MintToCollectionV1" or "Transfer".
+	Type string
+}
+
+func (sig AssetSignature) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{sig.Signature, sig.Type})
+}
+
+func (sig *AssetSignature) UnmarshalJSON(data []byte) error {
+	var pair []stdjson.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("expected a [signature, type] pair, got %d elements", len(pair))
+	}
+	if err := json.Unmarshal(pair[0], &sig.Signature); err != nil {
+		return err
+	}
+	return json.Unmarshal(pair[1], &sig.Type)
+}
+
+// DefaultTokenSupplyBatchSize is the number of mints queried
+// per batch request by GetTokenSupplyByMintList.
+var DefaultTokenSupplyBatchSize = 100
+
+type GetTokenSupplyByMintListOpts struct {
+	// This parameter is optional.
+	Commitment CommitmentType
+	// Number of mints queried per batch request.
+	// Defaults to DefaultTokenSupplyBatchSize when zero.
+	BatchSize int
+}
+
+// TokenSupplyByMint is the supply of one of the mints
+// passed to GetTokenSupplyByMintList.
+type TokenSupplyByMint struct {
+	Mint solana.PublicKey
+	// Slot at which the supply was read.
+	Slot   uint64
+	Supply *UiTokenAmount
+	// Err is set if the supply of the mint could not be read
+	// (e.g. the account is not a token mint).
+	Err error
+}
+
+// GetTokenSupplyByMintList returns the supply of each of the provided mints,
+// in the same order, by sending batches of getTokenSupply requests.
+// The error of a single mint is reported in its TokenSupplyByMint.Err;
+// an error is returned only if a batch request fails.
+func (cl *HeliusClient) GetTokenSupplyByMintList(
+	ctx context.Context,
+	mints []solana.PublicKey,
+	opts *GetTokenSupplyByMintListOpts,
+) (out []*TokenSupplyByMint, err error) {
+	if opts == nil {
+		opts = &GetTokenSupplyByMintListOpts{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultTokenSupplyBatchSize
+	}
+
+	out = make([]*TokenSupplyByMint, 0, len(mints))
+	for start := 0; start < len(mints); start += batchSize {
+		end := start + batchSize
+		if end > len(mints) {
+			end = len(mints)
+		}
+		batch := mints[start:end]
+
+		requests := make(jsonrpc.RPCRequests, len(batch))
+		for i, mint := range batch {
+			params := []interface{}{mint}
+			if opts.Commitment != "" {
+				params = append(params, M{"commitment": opts.Commitment})
+			}
+			requests[i] = &jsonrpc.RPCRequest{
+				Method:  "getTokenSupply",
+				Params:  params,
+				ID:      i,
+				JSONRPC: "2.0",
+			}
+		}
+
+		responses, err := cl.rpcClient.CallBatch(ctx, requests)
+		if err != nil {
+			return nil, err
+		}
+		byID := responses.AsMap()
+		for i, mint := range batch {
+			supply := &TokenSupplyByMint{Mint: mint}
+			out = append(out, supply)
+
+			response, ok := byID[i]
+			if !ok {
+				supply.Err = ErrNotFound
+				continue
+			}
+			if response.Error != nil {
+				supply.Err = newTypedError(cl.logger, response.Error)
+				continue
+			}
+			var result *GetTokenSupplyResult
+			if err := json.Unmarshal(response.Result, &result); err != nil {
+				supply.Err = fmt.Errorf("unable to decode getTokenSupply result: %w", err)
+				continue
+			}
+			if result == nil || result.Value == nil {
+				supply.Err = ErrNotFound
+				continue
+			}
+			supply.Slot = result.Context.Slot
+			supply.Supply = result.Value
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeliusClient_GetAssetsByOwner(t *testing.T) {
+	responseBody := `{
+		"total": 1,
+		"limit": 10,
+		"page": 1,
+		"items": [{
+			"interface": "V1_NFT",
+			"id": "AKo9P7S8FE9NYeAcrtZEpimwQAXJMp8Lrt8p4dMkHkY2",
+			"inscription": {
+				"order": 308332,
+				"size": 52,
+				"contentType": "application/text",
+				"encoding": "base64",
+				"validationHash": "907e00a18f952ade319c21b90764e5d0a08ec31c92e792f806a995e8524535ca",
+				"inscriptionDataAccount": "9qM9ThkVPxjq4TyBjCs1qpY15VYVim2Qh7uR5yG1Da3T",
+				"authority": "3ZuqPFGwTP6akcbmr4RbGmn4EjDt3GnxxE1AmvsDcZ4N"
+			},
+			"spl20": {"p": "spl-20", "op": "mint", "tick": "helius", "amt": "1"}
+		}],
+		"native_balance": {"lamports": 1500000000, "price_per_sol": 150.5, "total_price": 225.75}
+	}`
+	server, closer := mockJSONRPC(t, stdjson.RawMessage(wrapIntoRPC(responseBody)))
+	defer closer()
+	client := &HeliusClient{Client: New(server.URL)}
+
+	out, err := client.GetAssetsByOwner(context.Background(), GetAssetsByOwnerOpts{
+		OwnerAddress: "86xCnPeV69n6t3DnyGvkKobf9FdN2H9oiVDdaMpo2MMY",
+		Options:      &GetAssetsByOwnerOptions{ShowNativeBalance: true, ShowInscription: true},
+	})
+	require.NoError(t, err)
+
+	require.NotNil(t, out.NativeBalance)
+	assert.Equal(t, &GetAssetsNativeBalance{Lamports: 1500000000, PricePerSol: 150.5, TotalPrice: 225.75}, out.NativeBalance)
+
+	require.Len(t, out.Items, 1)
+	assert.Equal(t, &GetAssetInscription{
+		Order:                  308332,
+		Size:                   52,
+		ContentType:            "application/text",
+		Encoding:               "base64",
+		ValidationHash:         "907e00a18f952ade319c21b90764e5d0a08ec31c92e792f806a995e8524535ca",
+		InscriptionDataAccount: "9qM9ThkVPxjq4TyBjCs1qpY15VYVim2Qh7uR5yG1Da3T",
+		Authority:              "3ZuqPFGwTP6akcbmr4RbGmn4EjDt3GnxxE1AmvsDcZ4N",
+	}, out.Items[0].Inscription)
+	assert.Equal(t, &GetAssetSPL20{P: "spl-20", Op: "mint", Tick: "helius", Amt: "1"}, out.Items[0].SPL20)
+}
+
+func TestHeliusClient_GetAssetBatch(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	defer func(size int) { DefaultAssetBatchSize = size }(DefaultAssetBatchSize)
+	DefaultAssetBatchSize = 2
+
+	var batches [][]string
+	server.HandleFunc("getAssetBatch", func(params stdjson.RawMessage) (interface{}, error) {
+		var in struct {
+			Ids            []string                    `json:"ids"`
+			DisplayOptions *GetAssetOptsDisplayOptions `json:"displayOptions"`
+		}
+		require.NoError(t, stdjson.Unmarshal(params, &in))
+		require.NotNil(t, in.DisplayOptions)
+		require.True(t, in.DisplayOptions.ShowFungible)
+		batches = append(batches, in.Ids)
+
+		// Missing assets are null, and the order is not guaranteed.
+		var out []interface{}
+		for i := len(in.Ids) - 1; i >= 0; i-- {
+			if in.Ids[i] == "missing" {
+				out = append(out, nil)
+				continue
+			}
+			out = append(out, map[string]interface{}{"id": in.Ids[i], "interface": "FungibleToken"})
+		}
+		return out, nil
+	})
+
+	client := &HeliusClient{Client: New(server.URL())}
+	out, err := client.GetAssetBatch(context.Background(), []string{"a", "missing", "b"}, &GetAssetOptsDisplayOptions{ShowFungible: true})
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"a", "missing"}, {"b"}}, batches)
+
+	require.Len(t, out, 3)
+	require.Equal(t, "a", out[0].Id)
+	require.Nil(t, out[1])
+	require.Equal(t, "b", out[2].Id)
+	require.Equal(t, AssetInterface("FungibleToken"), out[2].Interface)
+}
@@ -0,0 +1,215 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package rpc
+
+import (
+	"context"
+	"iter"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// blocksRangeChunk is the number of slots listed per getBlocks request by AllBlocks.
+const blocksRangeChunk = 1000
+
+// AllSignaturesForAddress iterates over the signatures of the transactions
+// involving the account, backwards in time, fetching pages of opts.Limit
+// signatures (starting from opts.Before, until opts.Until if set).
+// The iteration stops after the first error.
+func (cl *Client) AllSignaturesForAddress(
+	ctx context.Context,
+	account solana.PublicKey,
+	opts *GetSignaturesForAddressOpts, // optional
+) iter.Seq2[*TransactionSignature, error] {
+	return func(yield func(*TransactionSignature, error) bool) {
+		var pageOpts GetSignaturesForAddressOpts
+		if opts != nil {
+			pageOpts = *opts
+		}
+		for {
+			page, err := cl.GetSignaturesForAddressWithOpts(ctx, account, &pageOpts)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, sig := range page {
+				if !yield(sig, nil) {
+					return
+				}
+			}
+			if len(page) == 0 || (pageOpts.Limit != nil && len(page) < *pageOpts.Limit) {
+				return
+			}
+			pageOpts.Before = page[len(page)-1].Signature
+		}
+	}
+}
+
+// BlockAtSlot is a block yielded by AllBlocks.
+type BlockAtSlot struct {
+	Slot  uint64
+	Block *GetBlockResult
+}
+
+// AllBlocks iterates over the confirmed blocks between startSlot and endSlot (inclusive),
+// skipping the slots that have no block. Blocks are fetched one at a time, when
+// the iteration reaches them. The iteration stops after the first error.
+func (cl *Client) AllBlocks(
+	ctx context.Context,
+	startSlot uint64,
+	endSlot uint64,
+	opts *GetBlockOpts, // optional
+) iter.Seq2[*BlockAtSlot, error] {
+	return func(yield func(*BlockAtSlot, error) bool) {
+		var commitment CommitmentType
+		if opts != nil {
+			commitment = opts.Commitment
+		}
+		for from := startSlot; from <= endSlot; from += blocksRangeChunk {
+			to := from + blocksRangeChunk - 1
+			if to > endSlot || to < from {
+				to = endSlot
+			}
+			slots, err := cl.GetBlocks(ctx, from, &to, commitment)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, slot := range slots {
+				block, err := cl.GetBlockWithOpts(ctx, slot, opts)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if !yield(&BlockAtSlot{Slot: slot, Block: block}, nil) {
+					return
+				}
+			}
+			if to == endSlot {
+				return
+			}
+		}
+	}
+}
+
+// AllAssetsByOwner iterates over the assets of the owner,
+// paginating like AssetsByOwnerIterator.
+// The iteration stops after the first error.
+func (cl *HeliusClient) AllAssetsByOwner(
+	ctx context.Context,
+	opts GetAssetsByOwnerOpts,
+) iter.Seq2[*GetAssetsByOwnerItem, error] {
+	return func(yield func(*GetAssetsByOwnerItem, error) bool) {
+		yieldAssets(cl.AssetsByOwnerIterator(ctx, opts, nil), yield)
+	}
+}
+
+// AllSearchAssets iterates over the assets matching the search criteria,
+// paginating like SearchAssetsIterator.
+// The iteration stops after the first error.
+func (cl *HeliusClient) AllSearchAssets(
+	ctx context.Context,
+	opts SearchAssetsOpts,
+) iter.Seq2[*GetAssetsByOwnerItem, error] {
+	return func(yield func(*GetAssetsByOwnerItem, error) bool) {
+		yieldAssets(cl.SearchAssetsIterator(ctx, opts, nil), yield)
+	}
+}
+
+// yieldAssets yields the assets of the iterator, then its error, if any.
+func yieldAssets(it *AssetIterator, yield func(*GetAssetsByOwnerItem, error) bool) {
+	for it.HasNext() {
+		item, _ := it.Next()
+		if !yield(item, nil) {
+			return
+		}
+	}
+	if err := it.Err(); err != nil {
+		yield(nil, err)
+	}
+}
+
+// AllNftEditions iterates over the print editions of a master edition NFT,
+// fetching pages of opts.Limit editions starting at opts.Page (default: 1).
+// The iteration stops after the first error.
+func (cl *HeliusClient) AllNftEditions(
+	ctx context.Context,
+	opts GetNftEditionsOpts,
+) iter.Seq2[*NftEdition, error] {
+	return func(yield func(*NftEdition, error) bool) {
+		page := 1
+		if opts.Page != nil {
+			page = *opts.Page
+		}
+		for ; ; page++ {
+			pageOpts := opts
+			pageOpts.Page = &page
+			out, err := cl.GetNftEditions(ctx, pageOpts)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for i := range out.Editions {
+				if !yield(&out.Editions[i], nil) {
+					return
+				}
+			}
+			if len(out.Editions) == 0 || (out.Limit > 0 && len(out.Editions) < out.Limit) {
+				return
+			}
+		}
+	}
+}
+
+// AllTokenAccounts iterates over the token accounts of a mint and/or owner,
+// following the cursor returned by each page (or the page number,
+// for providers that don't return a cursor).
+// The iteration stops after the first error.
+func (cl *HeliusClient) AllTokenAccounts(
+	ctx context.Context,
+	opts TokenAccountsOpts,
+) iter.Seq2[*TokenAccountsItem, error] {
+	return func(yield func(*TokenAccountsItem, error) bool) {
+		pageOpts := opts
+		for {
+			out, err := cl.GetTokenAccounts(ctx, pageOpts)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for i := range out.TokenAccounts {
+				if !yield(&out.TokenAccounts[i], nil) {
+					return
+				}
+			}
+			if len(out.TokenAccounts) == 0 || (out.Limit > 0 && len(out.TokenAccounts) < out.Limit) {
+				return
+			}
+			if out.Cursor != nil && *out.Cursor != "" {
+				cursor := *out.Cursor
+				pageOpts.Cursor = &cursor
+				pageOpts.Page = nil
+			} else {
+				page := 2
+				if pageOpts.Page != nil {
+					page = *pageOpts.Page + 1
+				}
+				pageOpts.Page = &page
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package rpc
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_AllSignaturesForAddress(t *testing.T) {
+	var sigs []solana.Signature
+	for i := 0; i < 5; i++ {
+		sigs = append(sigs, solana.Signature{byte(i + 1)})
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Params []stdjson.RawMessage `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&in))
+		var opts struct {
+			Limit  int              `json:"limit"`
+			Before solana.Signature `json:"before"`
+		}
+		require.NoError(t, stdjson.Unmarshal(in.Params[1], &opts))
+
+		start := 0
+		for i, sig := range sigs {
+			if sig == opts.Before {
+				start = i + 1
+			}
+		}
+		end := start + opts.Limit
+		if end > len(sigs) {
+			end = len(sigs)
+		}
+		var page []string
+		for _, sig := range sigs[start:end] {
+			page = append(page, fmt.Sprintf(`{"signature":%q,"slot":1,"err":null,"memo":null,"blockTime":null}`, sig))
+		}
+		rw.Write([]byte(wrapIntoRPC("[" + strings.Join(page, ",") + "]")))
+	}))
+	defer server.Close()
+
+	limit := 2
+	var got []solana.Signature
+	for sig, err := range New(server.URL).AllSignaturesForAddress(context.Background(), solana.PublicKey{}, &GetSignaturesForAddressOpts{Limit: &limit}) {
+		require.NoError(t, err)
+		got = append(got, sig.Signature)
+	}
+	require.Equal(t, sigs, got)
+}
+
+func TestClient_AllBlocks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Method string               `json:"method"`
+			Params []stdjson.RawMessage `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&in))
+		switch in.Method {
+		case "getBlocks":
+			rw.Write([]byte(wrapIntoRPC(`[10,12]`)))
+		case "getBlock":
+			rw.Write([]byte(wrapIntoRPC(fmt.Sprintf(`{"blockhash":"11111111111111111111111111111111","previousBlockhash":"11111111111111111111111111111111","parentSlot":%s,"transactions":[]}`, in.Params[0]))))
+		}
+	}))
+	defer server.Close()
+
+	var slots []uint64
+	for block, err := range New(server.URL).AllBlocks(context.Background(), 10, 12, nil) {
+		require.NoError(t, err)
+		require.Equal(t, block.Slot, block.Block.ParentSlot)
+		slots = append(slots, block.Slot)
+	}
+	require.Equal(t, []uint64{10, 12}, slots)
+}
+
+func TestHeliusClient_AllNftEditions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Params struct {
+				Page  int `json:"page"`
+				Limit int `json:"limit"`
+			} `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&in))
+		var editions []string
+		for i := (in.Params.Page-1)*in.Params.Limit + 1; i <= in.Params.Page*in.Params.Limit && i <= 3; i++ {
+			editions = append(editions, fmt.Sprintf(`{"mint":"11111111111111111111111111111111","edition_address":"11111111111111111111111111111111","edition":%d}`, i))
+		}
+		rw.Write([]byte(wrapIntoRPC(fmt.Sprintf(`{"total":3,"limit":%d,"page":%d,"master_edition_address":"11111111111111111111111111111111","supply":3,"max_supply":10,"editions":[%s]}`, in.Params.Limit, in.Params.Page, strings.Join(editions, ",")))))
+	}))
+	defer server.Close()
+
+	limit := 2
+	var got []uint64
+	for edition, err := range (&HeliusClient{Client: New(server.URL)}).AllNftEditions(context.Background(), GetNftEditionsOpts{
+		Mint:  "7xLk17EQQ5KLDLDe44wCmupJKJjTGd8hs3eSVVhCx932",
+		Limit: &limit,
+	}) {
+		require.NoError(t, err)
+		got = append(got, edition.Edition)
+	}
+	require.Equal(t, []uint64{1, 2, 3}, got)
+}
+
+func TestHeliusClient_AllAssetsByOwner(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.HandleFunc("getAssetsByOwner", func(params stdjson.RawMessage) (interface{}, error) {
+		var in struct {
+			Page   int     `json:"page"`
+			Limit  int     `json:"limit"`
+			Cursor *string `json:"cursor"`
+		}
+		require.NoError(t, stdjson.Unmarshal(params, &in))
+		require.Nil(t, in.Cursor)
+		require.Equal(t, MaxAssetsPageLimit, in.Limit)
+		if in.Page > 1 {
+			return M{"limit": in.Limit, "page": in.Page, "items": assetItems(1000, 1001)}, nil
+		}
+		return M{"limit": in.Limit, "page": in.Page, "items": assetItems(0, 1000)}, nil
+	})
+	client := &HeliusClient{Client: New(server.URL())}
+
+	limit := 5000
+	var count int
+	for item, err := range client.AllAssetsByOwner(context.Background(), GetAssetsByOwnerOpts{
+		OwnerAddress: solana.SystemProgramID.String(),
+		Limit:        &limit,
+	}) {
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("asset-%d", count), item.Id)
+		count++
+	}
+	require.Equal(t, 1001, count)
+	require.Len(t, server.Requests("getAssetsByOwner"), 2)
+}
+
+func TestHeliusClient_AllSearchAssets(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.HandleFunc("searchAssets", func(params stdjson.RawMessage) (interface{}, error) {
+		var in struct {
+			Page   *int   `json:"page"`
+			Cursor string `json:"cursor"`
+		}
+		require.NoError(t, stdjson.Unmarshal(params, &in))
+		require.Nil(t, in.Page)
+		if in.Cursor == "" {
+			return M{"limit": 1000, "cursor": "c1", "items": assetItems(0, 2)}, nil
+		}
+		return nil, fmt.Errorf("boom")
+	})
+	client := &HeliusClient{Client: New(server.URL())}
+
+	cursor := ""
+	var ids []string
+	var err error
+	for item, itemErr := range client.AllSearchAssets(context.Background(), SearchAssetsOpts{Cursor: &cursor}) {
+		if itemErr != nil {
+			err = itemErr
+			break
+		}
+		ids = append(ids, item.Id)
+	}
+	require.Equal(t, []string{"asset-0", "asset-1"}, ids)
+	require.Error(t, err)
+}
@@ -0,0 +1,188 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedactedSecret replaces the redacted secrets in the recorded exchanges.
+const RedactedSecret = "REDACTED"
+
+// Exchange is the raw HTTP exchange of a call, as sent and received
+// by the client: the JSON request before any compression by the transport,
+// and the JSON response after decompression.
+type Exchange struct {
+	// Method is the JSON-RPC method of the call, empty for batch calls.
+	Method        string
+	URL           string
+	RequestHeader http.Header
+	Request       []byte
+
+	// StatusCode, Header and Response are empty if Err is not nil.
+	StatusCode int
+	Header     http.Header
+	Response   []byte
+
+	Duration time.Duration
+	// Err is the error that prevented the reception of the response;
+	// errors returned by the server in a response are in Response.
+	Err error
+}
+
+// DebugOptions configures the recording of the exchanges of a client.
+type DebugOptions struct {
+	// OnExchange is called with the exchange of each call,
+	// once its response is fully received. It must not modify it.
+	//
+	// This parameter is optional.
+	OnExchange func(exchange *Exchange)
+
+	// Redact lists secrets (e.g. API keys) replaced with RedactedSecret
+	// in the URL, the headers, the bodies and the error of the exchanges,
+	// both those passed to OnExchange and those captured with WithDebugCapture.
+	//
+	// This parameter is optional.
+	Redact []string
+}
+
+type debugCaptureKey struct{}
+
+// DebugCapture receives the exchanges of the calls made with
+// a context returned by WithDebugCapture.
+type DebugCapture struct {
+	lock      sync.Mutex
+	exchanges []*Exchange
+}
+
+// WithDebugCapture returns a context capturing the raw exchanges
+// of the calls made with it into the returned DebugCapture.
+// It works with any client, whether or not DebugOptions are set.
+func WithDebugCapture(ctx context.Context) (context.Context, *DebugCapture) {
+	capture := &DebugCapture{}
+	return context.WithValue(ctx, debugCaptureKey{}, capture), capture
+}
+
+// Exchanges returns the exchanges captured so far, in the order they completed.
+func (c *DebugCapture) Exchanges() []*Exchange {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]*Exchange(nil), c.exchanges...)
+}
+
+// Last returns the last exchange captured, or nil if none was.
+func (c *DebugCapture) Last() *Exchange {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.exchanges) == 0 {
+		return nil
+	}
+	return c.exchanges[len(c.exchanges)-1]
+}
+
+func (c *DebugCapture) add(exchange *Exchange) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.exchanges = append(c.exchanges, exchange)
+}
+
+// send sends the request, recording the response into the ResponseInfo of ctx,
+// and the exchange into the DebugCapture of ctx and the debug hook of the client.
+// The response body is buffered when the exchange is recorded.
+func (client *rpcClient) send(ctx context.Context, httpRequest *http.Request, method string) (*http.Response, error) {
+	capture, _ := ctx.Value(debugCaptureKey{}).(*DebugCapture)
+	onExchange := client.debug.OnExchange
+	if capture == nil && onExchange == nil {
+		httpResponse, err := client.httpClient.Do(httpRequest)
+		if err != nil {
+			return nil, err
+		}
+		recordResponse(ctx, httpResponse)
+		return httpResponse, nil
+	}
+
+	exchange := &Exchange{
+		Method:        method,
+		URL:           httpRequest.URL.String(),
+		RequestHeader: httpRequest.Header.Clone(),
+	}
+	if httpRequest.GetBody != nil {
+		if body, err := httpRequest.GetBody(); err == nil {
+			exchange.Request, _ = io.ReadAll(body)
+		}
+	}
+	start := time.Now()
+	httpResponse, err := client.httpClient.Do(httpRequest)
+	if err == nil {
+		recordResponse(ctx, httpResponse)
+		var body []byte
+		body, err = io.ReadAll(httpResponse.Body)
+		httpResponse.Body.Close()
+		if err == nil {
+			httpResponse.Body = io.NopCloser(bytes.NewReader(body))
+			exchange.StatusCode = httpResponse.StatusCode
+			exchange.Header = httpResponse.Header.Clone()
+			exchange.Response = body
+		} else {
+			httpResponse = nil
+		}
+	}
+	exchange.Duration = time.Since(start)
+	exchange.Err = err
+	exchange.redact(client.debug.Redact)
+
+	if capture != nil {
+		capture.add(exchange)
+	}
+	if onExchange != nil {
+		onExchange(exchange)
+	}
+	return httpResponse, err
+}
+
+// redact replaces the secrets in the exchange.
+func (e *Exchange) redact(secrets []string) {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		e.URL = strings.ReplaceAll(e.URL, secret, RedactedSecret)
+		redactHeader(e.RequestHeader, secret)
+		redactHeader(e.Header, secret)
+		e.Request = bytes.ReplaceAll(e.Request, []byte(secret), []byte(RedactedSecret))
+		e.Response = bytes.ReplaceAll(e.Response, []byte(secret), []byte(RedactedSecret))
+		if e.Err != nil && strings.Contains(e.Err.Error(), secret) {
+			e.Err = &redactedError{
+				msg: strings.ReplaceAll(e.Err.Error(), secret, RedactedSecret),
+				err: e.Err,
+			}
+		}
+	}
+}
+
+func redactHeader(header http.Header, secret string) {
+	for _, values := range header {
+		for i, value := range values {
+			values[i] = strings.ReplaceAll(value, secret, RedactedSecret)
+		}
+	}
+}
+
+// redactedError hides secrets from the message of err,
+// while still matching it with errors.Is and errors.As.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,90 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Echo", r.URL.Query().Get("api-key"))
+		if body[0] == '[' {
+			fmt.Fprint(w, `[{"jsonrpc":"2.0","id":0,"result":1},{"jsonrpc":"2.0","id":1,"result":2}]`)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":{"key":"secret-key","extra":true}}`)
+	}))
+	defer server.Close()
+
+	var exchanges []*Exchange
+	rpcClient := NewClientWithOpts(server.URL+"/?api-key=secret-key", &RPCClientOpts{
+		Debug: &DebugOptions{
+			OnExchange: func(exchange *Exchange) {
+				exchanges = append(exchanges, exchange)
+			},
+			Redact: []string{"secret-key"},
+		},
+	})
+
+	var out struct {
+		Key   string `json:"key"`
+		Extra bool   `json:"extra"`
+	}
+	ctx, capture := WithDebugCapture(context.Background())
+	require.NoError(t, rpcClient.CallForInto(ctx, &out, "getThing", []interface{}{"a"}))
+	// The response is still decoded from the buffered body, unredacted.
+	require.Equal(t, "secret-key", out.Key)
+
+	require.Len(t, exchanges, 1)
+	exchange := exchanges[0]
+	require.Same(t, exchange, capture.Last())
+	require.Equal(t, "getThing", exchange.Method)
+	require.Equal(t, server.URL+"/?api-key=REDACTED", exchange.URL)
+	require.Equal(t, "application/json", exchange.RequestHeader.Get("Content-Type"))
+	require.Contains(t, string(exchange.Request), `"method":"getThing"`)
+	require.Contains(t, string(exchange.Request), `"params":["a"]`)
+	require.Equal(t, http.StatusOK, exchange.StatusCode)
+	require.Equal(t, "REDACTED", exchange.Header.Get("X-Echo"))
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"result":{"key":"REDACTED","extra":true}}`, string(exchange.Response))
+	require.NoError(t, exchange.Err)
+
+	_, err := rpcClient.CallBatch(context.Background(), RPCRequests{NewRequest("getSlot"), NewRequest("getSlot")})
+	require.NoError(t, err)
+	require.Len(t, exchanges, 2)
+	require.Equal(t, "", exchanges[1].Method)
+	require.Contains(t, string(exchanges[1].Response), `"result":2`)
+	// The batch call was made without the capturing context.
+	require.Len(t, capture.Exchanges(), 1)
+}
+
+func TestWithDebugCapture(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `rate limited`)
+	}))
+	rpcClient := NewClient(server.URL)
+
+	ctx, capture := WithDebugCapture(context.Background())
+	require.Nil(t, capture.Last())
+	_, err := rpcClient.Call(ctx, "getSlot")
+	require.Error(t, err)
+	exchange := capture.Last()
+	require.NotNil(t, exchange)
+	require.Equal(t, http.StatusTooManyRequests, exchange.StatusCode)
+	require.Equal(t, "rate limited", string(exchange.Response))
+
+	// Transport errors are recorded too.
+	server.Close()
+	_, err = rpcClient.Call(ctx, "getSlot")
+	require.Error(t, err)
+	require.Len(t, capture.Exchanges(), 2)
+	require.Error(t, capture.Last().Err)
+	require.Zero(t, capture.Last().StatusCode)
+}
@@ -237,7 +237,9 @@ func (e *RPCError) Error() string {
 // Otherwise a RPCResponse object is returned with a RPCError field that is not nil.
 type HTTPError struct {
 	Code int
-	err  error
+	// Header of the HTTP response, e.g. to read Retry-After.
+	Header http.Header
+	err    error
 }
 
 // HTTPClient is an abstraction for a HTTP client
@@ -262,6 +264,7 @@ type rpcClient struct {
 	endpoint      string
 	httpClient    HTTPClient
 	customHeaders map[string]string
+	debug         DebugOptions
 }
 
 // RPCClientOpts can be provided to NewClientWithOpts() to change configuration of RPCClient.
@@ -269,9 +272,12 @@ type rpcClient struct {
 // HTTPClient: provide a custom http.Client (e.g. to set a proxy, or tls options)
 //
 // CustomHeaders: provide custom headers, e.g. to set BasicAuth
+//
+// Debug: record the raw JSON request and response of each call
 type RPCClientOpts struct {
 	HTTPClient    HTTPClient
 	CustomHeaders map[string]string
+	Debug         *DebugOptions
 }
 
 // RPCResponses is of type []*RPCResponse.
@@ -355,6 +361,10 @@ func NewClientWithOpts(endpoint string, opts *RPCClientOpts) RPCClient {
 		}
 	}
 
+	if opts.Debug != nil {
+		rpcClient.debug = *opts.Debug
+	}
+
 	return rpcClient
 }
 
@@ -502,8 +512,9 @@ func (client *rpcClient) doCall(
 				// if we have some http error, return it
 				if httpResponse.StatusCode >= 400 {
 					return &HTTPError{
-						Code: httpResponse.StatusCode,
-						err:  fmt.Errorf("rpc call %v() on %v status code: %v. could not decode body to rpc response: %w", RPCRequest.Method, httpRequest.URL.String(), httpResponse.StatusCode, err),
+						Code:   httpResponse.StatusCode,
+						Header: httpResponse.Header,
+						err:    fmt.Errorf("rpc call %v() on %v status code: %v. could not decode body to rpc response: %w", RPCRequest.Method, httpRequest.URL.String(), httpResponse.StatusCode, err),
 					}
 				}
 				return fmt.Errorf("rpc call %v() on %v status code: %v. could not decode body to rpc response: %w", RPCRequest.Method, httpRequest.URL.String(), httpResponse.StatusCode, err)
@@ -514,8 +525,9 @@ func (client *rpcClient) doCall(
 				// if we have some http error, return it
 				if httpResponse.StatusCode >= 400 {
 					return &HTTPError{
-						Code: httpResponse.StatusCode,
-						err:  fmt.Errorf("rpc call %v() on %v status code: %v. rpc response missing", RPCRequest.Method, httpRequest.URL.String(), httpResponse.StatusCode),
+						Code:   httpResponse.StatusCode,
+						Header: httpResponse.Header,
+						err:    fmt.Errorf("rpc call %v() on %v status code: %v. rpc response missing", RPCRequest.Method, httpRequest.URL.String(), httpResponse.StatusCode),
 					}
 				}
 				return fmt.Errorf("rpc call %v() on %v status code: %v. rpc response missing", RPCRequest.Method, httpRequest.URL.String(), httpResponse.StatusCode)
@@ -563,7 +575,7 @@ func (client *rpcClient) doCallWithCallbackOnHTTPResponse(
 		}
 		return fmt.Errorf("rpc call %v(): %w", RPCRequest.Method, err)
 	}
-	httpResponse, err := client.httpClient.Do(httpRequest)
+	httpResponse, err := client.send(ctx, httpRequest, RPCRequest.Method)
 	if err != nil {
 		return fmt.Errorf("rpc call %v() on %v: %w", RPCRequest.Method, httpRequest.URL.String(), err)
 	}
@@ -580,7 +592,7 @@ func (client *rpcClient) doBatchCall(ctx context.Context, rpcRequest []*RPCReque
 		}
 		return nil, fmt.Errorf("rpc batch call: %w", err)
 	}
-	httpResponse, err := client.httpClient.Do(httpRequest)
+	httpResponse, err := client.send(ctx, httpRequest, "")
 	if err != nil {
 		return nil, fmt.Errorf("rpc batch call on %v: %w", httpRequest.URL.String(), err)
 	}
@@ -596,8 +608,9 @@ func (client *rpcClient) doBatchCall(ctx context.Context, rpcRequest []*RPCReque
 		// if we have some http error, return it
 		if httpResponse.StatusCode >= 400 {
 			return nil, &HTTPError{
-				Code: httpResponse.StatusCode,
-				err:  fmt.Errorf("rpc batch call on %v status code: %v. could not decode body to rpc response: %w", httpRequest.URL.String(), httpResponse.StatusCode, err),
+				Code:   httpResponse.StatusCode,
+				Header: httpResponse.Header,
+				err:    fmt.Errorf("rpc batch call on %v status code: %v. could not decode body to rpc response: %w", httpRequest.URL.String(), httpResponse.StatusCode, err),
 			}
 		}
 		return nil, fmt.Errorf("rpc batch call on %v status code: %v. could not decode body to rpc response: %w", httpRequest.URL.String(), httpResponse.StatusCode, err)
@@ -608,8 +621,9 @@ func (client *rpcClient) doBatchCall(ctx context.Context, rpcRequest []*RPCReque
 		// if we have some http error, return it
 		if httpResponse.StatusCode >= 400 {
 			return nil, &HTTPError{
-				Code: httpResponse.StatusCode,
-				err:  fmt.Errorf("rpc batch call on %v status code: %v. rpc response missing", httpRequest.URL.String(), httpResponse.StatusCode),
+				Code:   httpResponse.StatusCode,
+				Header: httpResponse.Header,
+				err:    fmt.Errorf("rpc batch call on %v status code: %v. rpc response missing", httpRequest.URL.String(), httpResponse.StatusCode),
 			}
 		}
 		return nil, fmt.Errorf("rpc batch call on %v status code: %v. rpc response missing", httpRequest.URL.String(), httpResponse.StatusCode)
@@ -0,0 +1,110 @@
+package jsonrpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+type responseInfoKey struct{}
+
+// ResponseInfo receives the status code and the headers of the HTTP responses
+// to the calls made with a context returned by WithResponseInfo,
+// e.g. to read the rate limit counters or the request ID of a provider.
+//
+// When several calls share the context, it holds the last response received.
+type ResponseInfo struct {
+	lock       sync.Mutex
+	statusCode int
+	header     http.Header
+	responses  int
+}
+
+// WithResponseInfo returns a context capturing the HTTP responses
+// to the calls made with it into the returned ResponseInfo.
+func WithResponseInfo(ctx context.Context) (context.Context, *ResponseInfo) {
+	info := &ResponseInfo{}
+	return context.WithValue(ctx, responseInfoKey{}, info), info
+}
+
+// recordResponse records the response into the ResponseInfo of ctx, if any.
+func recordResponse(ctx context.Context, resp *http.Response) {
+	info, ok := ctx.Value(responseInfoKey{}).(*ResponseInfo)
+	if !ok {
+		return
+	}
+	info.lock.Lock()
+	defer info.lock.Unlock()
+	info.statusCode = resp.StatusCode
+	info.header = resp.Header.Clone()
+	info.responses++
+}
+
+// StatusCode returns the status code of the last response, or zero if none was received.
+func (r *ResponseInfo) StatusCode() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.statusCode
+}
+
+// Header returns the headers of the last response, or nil if none was received.
+func (r *ResponseInfo) Header() http.Header {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.header
+}
+
+// Responses returns the number of responses received.
+func (r *ResponseInfo) Responses() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.responses
+}
+
+// RequestIDHeaders are the headers in which providers return
+// the ID of a request, in order of preference.
+var RequestIDHeaders = []string{
+	"X-Request-Id",
+	"X-Amzn-Requestid",
+	"Cf-Ray",
+}
+
+// RequestID returns the ID the provider assigned to the last request
+// (see RequestIDHeaders), e.g. to reference it in a support ticket,
+// or an empty string if none was returned.
+func (r *ResponseInfo) RequestID() string {
+	header := r.Header()
+	for _, name := range RequestIDHeaders {
+		if id := header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// RateLimitRemaining returns the number of requests remaining in the
+// current rate limit window, read from the X-RateLimit-Remaining
+// (or RateLimit-Remaining) header; ok is false if it was not returned.
+func (r *ResponseInfo) RateLimitRemaining() (remaining int64, ok bool) {
+	return r.headerInt("X-Ratelimit-Remaining", "Ratelimit-Remaining")
+}
+
+// RateLimitLimit returns the number of requests allowed in the rate limit
+// window, read from the X-RateLimit-Limit (or RateLimit-Limit) header;
+// ok is false if it was not returned.
+func (r *ResponseInfo) RateLimitLimit() (limit int64, ok bool) {
+	return r.headerInt("X-Ratelimit-Limit", "Ratelimit-Limit")
+}
+
+// headerInt returns the integer value of the first of the headers returned.
+func (r *ResponseInfo) headerInt(names ...string) (int64, bool) {
+	header := r.Header()
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			v, err := strconv.ParseInt(value, 10, 64)
+			return v, err == nil
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,56 @@
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithResponseInfo(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", fmt.Sprint(100-calls))
+		w.Header().Set("X-Request-Id", fmt.Sprintf("req-%d", calls))
+		if calls == 2 {
+			fmt.Fprint(w, `[{"jsonrpc":"2.0","id":0,"result":1},{"jsonrpc":"2.0","id":1,"result":2}]`)
+			return
+		}
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":0,"result":1}`)
+	}))
+	defer server.Close()
+	rpcClient := NewClient(server.URL)
+
+	ctx, info := WithResponseInfo(context.Background())
+	require.Equal(t, "", info.RequestID())
+	_, ok := info.RateLimitRemaining()
+	require.False(t, ok)
+
+	_, err := rpcClient.Call(ctx, "getSlot")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, info.StatusCode())
+	require.Equal(t, "req-1", info.RequestID())
+	remaining, ok := info.RateLimitRemaining()
+	require.True(t, ok)
+	require.Equal(t, int64(99), remaining)
+	limit, ok := info.RateLimitLimit()
+	require.True(t, ok)
+	require.Equal(t, int64(100), limit)
+
+	_, err = rpcClient.CallBatch(ctx, RPCRequests{NewRequest("getSlot"), NewRequest("getSlot")})
+	require.NoError(t, err)
+	require.Equal(t, "req-2", info.RequestID())
+	remaining, _ = info.RateLimitRemaining()
+	require.Equal(t, int64(98), remaining)
+	require.Equal(t, 2, info.Responses())
+
+	// Calls made without the context are not recorded.
+	_, err = rpcClient.Call(context.Background(), "getSlot")
+	require.NoError(t, err)
+	require.Equal(t, "req-2", info.RequestID())
+}
@@ -28,3 +28,11 @@ var zlog *zap.Logger
 func init() {
 	logging.Register("github.com/gagliardetto/solana-go/rpc", &zlog)
 }
+
+// logger returns l, or the package logger if l is nil.
+func logger(l *zap.Logger) *zap.Logger {
+	if l != nil {
+		return l
+	}
+	return zlog
+}
@@ -0,0 +1,289 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/klauspost/compress/gzhttp"
+	"go.uber.org/zap"
+)
+
+// RateLimiter is consulted before each HTTP request sent by the client.
+// It is satisfied by *rate.Limiter from golang.org/x/time/rate.
+type RateLimiter interface {
+	// Wait blocks until the request is allowed to proceed
+	// or the context is done.
+	Wait(ctx context.Context) error
+}
+
+var (
+	DefaultRateLimitRetries    = 3
+	DefaultRateLimitMinBackoff = 250 * time.Millisecond
+	DefaultRateLimitMaxBackoff = 10 * time.Second
+)
+
+// Options configures a Client created with NewWithOptions.
+type Options struct {
+	// Headers are added to each RPC request.
+	//
+	// This parameter is optional.
+	Headers map[string]string
+
+	// RateLimiter is consulted before each request, including retries.
+	//
+	// This parameter is optional.
+	RateLimiter RateLimiter
+
+	// RateLimitRetries is the maximum number of times a request rejected
+	// with HTTP 429 (Too Many Requests) is retried.
+	// Defaults to DefaultRateLimitRetries when zero;
+	// a negative value disables retries.
+	RateLimitRetries int
+
+	// RateLimitMinBackoff is the base delay used when a 429 response
+	// carries no Retry-After header. The delay doubles on each retry
+	// (with jitter) up to RateLimitMaxBackoff.
+	// Defaults to DefaultRateLimitMinBackoff when zero.
+	RateLimitMinBackoff time.Duration
+
+	// RateLimitMaxBackoff caps the delay between retries, including
+	// the one requested by the server via Retry-After.
+	// Defaults to DefaultRateLimitMaxBackoff when zero.
+	RateLimitMaxBackoff time.Duration
+
+	// APIKeys are injected into each request, so that they don't have
+	// to be baked into the endpoint URL. When more than one key is provided,
+	// requests (including 429 retries) rotate between them in round-robin order.
+	// Per-key counters are available via Client.APIKeyUsage.
+	//
+	// This parameter is optional.
+	APIKeys []string
+
+	// APIKeyParam is the query parameter used to pass the API key.
+	// Defaults to DefaultAPIKeyParam when neither APIKeyParam
+	// nor APIKeyHeader is set.
+	APIKeyParam string
+
+	// APIKeyHeader, if set, is the header used to pass the API key.
+	APIKeyHeader string
+
+	// HTTPClient is used as a template for the HTTP client of the Client:
+	// its Timeout, Jar and CheckRedirect are kept, and its Transport
+	// (http.DefaultTransport when nil) replaces the default transport.
+	// The transport options below are then ignored, unless Transport is also set.
+	//
+	// This parameter is optional.
+	HTTPClient *http.Client
+
+	// Transport replaces the default transport. Rate limiting, API keys
+	// and compression are still applied on top of it; the transport
+	// options below are ignored.
+	//
+	// This parameter is optional.
+	Transport http.RoundTripper
+
+	// Timeout is the maximum duration of each call, including the reading
+	// of the response and the retries of rate limited requests.
+	// Defaults to 5 minutes when zero; it overrides HTTPClient.Timeout when set.
+	Timeout time.Duration
+
+	// MaxIdleConnsPerHost is the maximum number of idle (keep-alive)
+	// connections kept per host. Defaults to 9 when zero.
+	// High-QPS users should raise it to their expected concurrency,
+	// to avoid opening a new connection for each request.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost limits the total number of connections per host.
+	// Defaults to MaxIdleConnsPerHost when zero; a negative value
+	// removes the limit.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept.
+	// Defaults to 5 minutes when zero.
+	IdleConnTimeout time.Duration
+
+	// KeepAlive is the interval of the TCP keep-alive probes.
+	// Defaults to 180 seconds when zero.
+	KeepAlive time.Duration
+
+	// TLSClientConfig is the TLS configuration of the connections.
+	//
+	// This parameter is optional.
+	TLSClientConfig *tls.Config
+
+	// DisableHTTP2 prevents the negotiation of HTTP/2,
+	// so that each connection serves one request at a time over HTTP/1.1.
+	DisableHTTP2 bool
+
+	// Proxy returns the proxy to use for a request (see http.Transport.Proxy
+	// and http.ProxyURL). Defaults to http.ProxyFromEnvironment when nil.
+	//
+	// This parameter is optional.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// CompressRequests gzip-compresses the request bodies, which reduces
+	// the upload of large requests (e.g. batches or big transactions).
+	// The endpoint must accept the "Content-Encoding: gzip" header.
+	CompressRequests bool
+
+	// RetryPolicy retries the calls that fail with a transient error
+	// (see RetryPolicy). By default, each call is attempted once,
+	// apart from the retries of rate limited requests.
+	//
+	// This parameter is optional.
+	RetryPolicy *RetryPolicy
+
+	// Logger receives the logs of the client, e.g. to scope them
+	// with fields or to set their level per client.
+	// Defaults to the package logger (see github.com/streamingfast/logging).
+	Logger *zap.Logger
+
+	// Debug is called with the exact JSON request and response of each call,
+	// e.g. to diagnose the quirks of a provider without a proxy.
+	// The exchanges of single calls can also be captured with jsonrpc.WithDebugCapture.
+	//
+	// This parameter is optional.
+	Debug func(exchange *jsonrpc.Exchange)
+
+	// DebugRedactAPIKeys replaces the API keys with jsonrpc.RedactedSecret
+	// in the recorded exchanges: the APIKeys, the API key query parameter
+	// of the endpoint, and the value of the APIKeyHeader and Authorization headers.
+	DebugRedactAPIKeys bool
+}
+
+// NewWithOptions creates a new Solana JSON RPC client configured with the provided options.
+// The client options set the defaults applied to every call, as with New.
+// Client is safe for concurrent use by multiple goroutines.
+func NewWithOptions(rpcEndpoint string, opts *Options, clientOpts ...ClientOption) *Client {
+	if opts == nil {
+		opts = &Options{}
+	}
+	var base http.RoundTripper = gzhttp.Transport(newHTTPTransportWithOptions(opts))
+	var apiKeys *apiKeyTransport
+	if len(opts.APIKeys) > 0 {
+		apiKeys = newAPIKeyTransport(base, opts)
+		base = apiKeys
+	}
+	var transport http.RoundTripper = newRateLimitTransport(base, opts)
+	if opts.CompressRequests {
+		transport = &gzipRequestTransport{base: transport}
+	}
+	httpClient := &http.Client{Timeout: defaultTimeout}
+	if opts.HTTPClient != nil {
+		*httpClient = *opts.HTTPClient
+	}
+	if opts.Timeout > 0 {
+		httpClient.Timeout = opts.Timeout
+	}
+	httpClient.Transport = transport
+	rpcClient := jsonrpc.NewClientWithOpts(rpcEndpoint, &jsonrpc.RPCClientOpts{
+		HTTPClient:    httpClient,
+		CustomHeaders: opts.Headers,
+		Debug:         newDebugOptions(rpcEndpoint, opts),
+	})
+	cl := NewWithCustomRPCClient(NewTypedErrorClient(rpcClient))
+	if opts.Logger != nil {
+		clientOpts = append([]ClientOption{WithLogger(opts.Logger)}, clientOpts...)
+	}
+	if opts.RetryPolicy != nil {
+		clientOpts = append([]ClientOption{WithRetryPolicy(*opts.RetryPolicy)}, clientOpts...)
+	}
+	applyClientOptions(cl, clientOpts)
+	cl.apiKeys = apiKeys
+	return cl
+}
+
+// newHTTPTransportWithOptions returns the base transport configured by opts.
+func newHTTPTransportWithOptions(opts *Options) http.RoundTripper {
+	if opts.Transport != nil {
+		return opts.Transport
+	}
+	if opts.HTTPClient != nil {
+		if opts.HTTPClient.Transport != nil {
+			return opts.HTTPClient.Transport
+		}
+		return http.DefaultTransport
+	}
+
+	tr := newHTTPTransport()
+	if opts.MaxIdleConnsPerHost > 0 {
+		tr.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+		tr.MaxConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.MaxConnsPerHost > 0 {
+		tr.MaxConnsPerHost = opts.MaxConnsPerHost
+	}
+	if opts.MaxConnsPerHost < 0 {
+		tr.MaxConnsPerHost = 0
+	}
+	if opts.IdleConnTimeout > 0 {
+		tr.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.KeepAlive > 0 || opts.Timeout > 0 {
+		dialer := &net.Dialer{
+			Timeout:   defaultTimeout,
+			KeepAlive: defaultKeepAlive,
+		}
+		if opts.KeepAlive > 0 {
+			dialer.KeepAlive = opts.KeepAlive
+		}
+		if opts.Timeout > 0 {
+			dialer.Timeout = opts.Timeout
+		}
+		tr.DialContext = dialer.DialContext
+	}
+	if opts.TLSClientConfig != nil {
+		tr.TLSClientConfig = opts.TLSClientConfig.Clone()
+	}
+	if opts.DisableHTTP2 {
+		tr.ForceAttemptHTTP2 = false
+		// A non-nil empty map disables HTTP/2 (see the http package docs).
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	if opts.Proxy != nil {
+		tr.Proxy = opts.Proxy
+	}
+	return tr
+}
+
+// newDebugOptions returns the debug options of the JSON RPC client.
+func newDebugOptions(rpcEndpoint string, opts *Options) *jsonrpc.DebugOptions {
+	debug := &jsonrpc.DebugOptions{OnExchange: opts.Debug}
+	if !opts.DebugRedactAPIKeys {
+		return debug
+	}
+	debug.Redact = append(debug.Redact, opts.APIKeys...)
+	param := opts.APIKeyParam
+	if param == "" {
+		param = DefaultAPIKeyParam
+	}
+	if u, err := url.Parse(rpcEndpoint); err == nil {
+		debug.Redact = append(debug.Redact, u.Query()[param]...)
+	}
+	for name, value := range opts.Headers {
+		if http.CanonicalHeaderKey(name) == "Authorization" ||
+			(opts.APIKeyHeader != "" && http.CanonicalHeaderKey(name) == http.CanonicalHeaderKey(opts.APIKeyHeader)) {
+			debug.Redact = append(debug.Redact, value)
+		}
+	}
+	return debug
+}
@@ -0,0 +1,73 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorityfee
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+)
+
+// Priority configures the compute budget instructions of a transaction.
+type Priority struct {
+	// ComputeUnitPrice is the price of a compute unit in micro-lamports.
+	// Zero means no SetComputeUnitPrice instruction is added.
+	ComputeUnitPrice uint64
+
+	// ComputeUnitLimit is the maximum number of compute units the transaction may consume.
+	// Zero means no SetComputeUnitLimit instruction is added.
+	ComputeUnitLimit uint32
+
+	// Estimator recommends the compute unit price when ComputeUnitPrice is zero,
+	// from the writable accounts of the transaction (e.g. a *Estimator).
+	//
+	// This parameter is optional.
+	Estimator PriceEstimator
+}
+
+// PriceEstimator recommends a compute unit price, in micro-lamports,
+// for a transaction locking the provided writable accounts.
+type PriceEstimator interface {
+	RecommendedPrice(ctx context.Context, writable solana.PublicKeySlice) (uint64, error)
+}
+
+// Apply returns the provided instructions, preceded by the compute budget
+// instructions of the priority. The compute budget instructions already
+// among them are replaced, keeping the requests the priority doesn't set.
+func (p *Priority) Apply(ctx context.Context, instructions []solana.Instruction) ([]solana.Instruction, error) {
+	var budget computebudget.Budget
+	if p.ComputeUnitLimit > 0 {
+		limit := p.ComputeUnitLimit
+		budget.ComputeUnitLimit = &limit
+	}
+	price := p.ComputeUnitPrice
+	if price == 0 && p.Estimator != nil {
+		var err error
+		price, err = p.Estimator.RecommendedPrice(ctx, WritableAccounts(instructions))
+		if err != nil {
+			return nil, fmt.Errorf("estimate compute unit price: %w", err)
+		}
+	}
+	if price > 0 {
+		budget.ComputeUnitPrice = &price
+	}
+	instructions, err := computebudget.ReplaceInstructions(instructions, budget)
+	if err != nil {
+		return nil, fmt.Errorf("compute budget: %w", err)
+	}
+	return instructions, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package priorityfee recommends a compute unit price from the
+// prioritization fees paid in recent slots (see getRecentPrioritizationFees).
+package priorityfee
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+var (
+	// Percentile of the recent fees recommended by default.
+	DefaultPercentile = 75.0
+)
+
+type Opts struct {
+	// Percentile (between 0 and 100) of the recent fees used as the
+	// recommended price. Defaults to DefaultPercentile.
+	Percentile float64
+
+	// Additional percentiles reported in Estimate.Percentiles.
+	//
+	// This parameter is optional.
+	Percentiles []float64
+
+	// EMAAlpha enables the exponential moving average of the fees, slot
+	// by slot, with this smoothing factor (between 0 and 1; higher values
+	// follow the recent slots more closely). When set, the average is
+	// recommended instead of the percentile.
+	//
+	// This parameter is optional.
+	EMAAlpha float64
+
+	// Number of most recent slots considered; zero considers all the
+	// slots returned by the node (up to 150).
+	//
+	// This parameter is optional.
+	Slots int
+
+	// ExcludeZero ignores the slots in which no prioritization fee was paid.
+	ExcludeZero bool
+
+	// Bounds of the recommended price, in micro-lamports per compute unit;
+	// a zero MaxPrice means no upper bound.
+	//
+	// These parameters are optional.
+	MinPrice uint64
+	MaxPrice uint64
+}
+
+// Estimate summarizes the recent prioritization fees,
+// in micro-lamports per compute unit.
+type Estimate struct {
+	// Number of slots the estimate is computed from.
+	Slots int
+
+	Min    uint64
+	Max    uint64
+	Median uint64
+	// The fee at Opts.Percentile.
+	Percentile uint64
+	// The fees at Opts.Percentiles, by percentile.
+	Percentiles map[float64]uint64
+	// The exponential moving average of the fees, if Opts.EMAAlpha is set.
+	EMA float64
+
+	// Recommended compute unit price, within Opts.MinPrice and Opts.MaxPrice.
+	Recommended uint64
+}
+
+// Estimator recommends compute unit prices from the recent prioritization fees.
+type Estimator struct {
+	client *rpc.Client
+	opts   Opts
+}
+
+func New(client *rpc.Client, opts *Opts) *Estimator {
+	e := &Estimator{
+		client: client,
+	}
+	if opts != nil {
+		e.opts = *opts
+	}
+	if e.opts.Percentile <= 0 {
+		e.opts.Percentile = DefaultPercentile
+	}
+	return e
+}
+
+// Estimate fetches the prioritization fees paid in recent slots by the
+// transactions locking the provided writable accounts, and summarizes them.
+func (e *Estimator) Estimate(ctx context.Context, writable solana.PublicKeySlice) (*Estimate, error) {
+	fees, err := e.client.GetRecentPrioritizationFees(ctx, writable)
+	if err != nil {
+		return nil, fmt.Errorf("priority fee estimate: %w", err)
+	}
+	return Compute(fees, &e.opts), nil
+}
+
+// RecommendedPrice returns the recommended compute unit price, in micro-lamports,
+// for a transaction locking the provided writable accounts.
+func (e *Estimator) RecommendedPrice(ctx context.Context, writable solana.PublicKeySlice) (uint64, error) {
+	estimate, err := e.Estimate(ctx, writable)
+	if err != nil {
+		return 0, err
+	}
+	return estimate.Recommended, nil
+}
+
+// Compute summarizes the provided prioritization fees.
+func Compute(fees []rpc.PriorizationFeeResult, opts *Opts) *Estimate {
+	if opts == nil {
+		opts = &Opts{}
+	}
+	percentile := opts.Percentile
+	if percentile <= 0 {
+		percentile = DefaultPercentile
+	}
+
+	bySlot := make([]rpc.PriorizationFeeResult, 0, len(fees))
+	for _, fee := range fees {
+		if opts.ExcludeZero && fee.PrioritizationFee == 0 {
+			continue
+		}
+		bySlot = append(bySlot, fee)
+	}
+	sort.Slice(bySlot, func(i, j int) bool { return bySlot[i].Slot < bySlot[j].Slot })
+	if opts.Slots > 0 && len(bySlot) > opts.Slots {
+		bySlot = bySlot[len(bySlot)-opts.Slots:]
+	}
+
+	sorted := make([]uint64, len(bySlot))
+	for i, fee := range bySlot {
+		sorted[i] = fee.PrioritizationFee
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	out := &Estimate{
+		Slots:      len(sorted),
+		Median:     Percentile(sorted, 50),
+		Percentile: Percentile(sorted, percentile),
+	}
+	if len(sorted) > 0 {
+		out.Min = sorted[0]
+		out.Max = sorted[len(sorted)-1]
+	}
+	if len(opts.Percentiles) > 0 {
+		out.Percentiles = make(map[float64]uint64, len(opts.Percentiles))
+		for _, p := range opts.Percentiles {
+			out.Percentiles[p] = Percentile(sorted, p)
+		}
+	}
+
+	out.Recommended = out.Percentile
+	if opts.EMAAlpha > 0 && len(bySlot) > 0 {
+		out.EMA = float64(bySlot[0].PrioritizationFee)
+		for _, fee := range bySlot[1:] {
+			out.EMA = opts.EMAAlpha*float64(fee.PrioritizationFee) + (1-opts.EMAAlpha)*out.EMA
+		}
+		out.Recommended = uint64(math.Ceil(out.EMA))
+	}
+
+	if out.Recommended < opts.MinPrice {
+		out.Recommended = opts.MinPrice
+	}
+	if opts.MaxPrice > 0 && out.Recommended > opts.MaxPrice {
+		out.Recommended = opts.MaxPrice
+	}
+	return out
+}
+
+// Percentile returns the value at the percentile p (between 0 and 100)
+// of the sorted values, using the nearest-rank method; zero if there are no values.
+func Percentile(sorted []uint64, p float64) uint64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	switch {
+	case p <= 0:
+		return sorted[0]
+	case p >= 100:
+		return sorted[len(sorted)-1]
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// WritableAccounts returns the accounts written by the instructions,
+// i.e. the accounts whose locks the prioritization fees compete for.
+func WritableAccounts(instructions []solana.Instruction) solana.PublicKeySlice {
+	var out solana.PublicKeySlice
+	seen := map[solana.PublicKey]bool{}
+	for _, inst := range instructions {
+		for _, account := range inst.Accounts() {
+			if account.IsWritable && !seen[account.PublicKey] {
+				seen[account.PublicKey] = true
+				out = append(out, account.PublicKey)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package priorityfee
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func fees(values ...uint64) []rpc.PriorizationFeeResult {
+	out := make([]rpc.PriorizationFeeResult, len(values))
+	for i, v := range values {
+		// Returned newest first, as the slots are not ordered by the node.
+		out[i] = rpc.PriorizationFeeResult{Slot: uint64(100 - i), PrioritizationFee: v}
+	}
+	return out
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []uint64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+	require.Equal(t, uint64(10), Percentile(sorted, 0))
+	require.Equal(t, uint64(10), Percentile(sorted, 5))
+	require.Equal(t, uint64(50), Percentile(sorted, 50))
+	require.Equal(t, uint64(80), Percentile(sorted, 75))
+	require.Equal(t, uint64(100), Percentile(sorted, 99))
+	require.Equal(t, uint64(100), Percentile(sorted, 100))
+	require.Equal(t, uint64(0), Percentile(nil, 50))
+}
+
+func TestCompute(t *testing.T) {
+	// Slot 100 paid 0, slot 91 paid 900.
+	all := fees(0, 100, 200, 300, 400, 500, 600, 700, 800, 900)
+
+	out := Compute(all, &Opts{Percentiles: []float64{25, 90}})
+	require.Equal(t, 10, out.Slots)
+	require.Equal(t, uint64(0), out.Min)
+	require.Equal(t, uint64(900), out.Max)
+	require.Equal(t, uint64(400), out.Median)
+	require.Equal(t, uint64(700), out.Percentile)
+	require.Equal(t, map[float64]uint64{25: 200, 90: 800}, out.Percentiles)
+	require.Equal(t, uint64(700), out.Recommended)
+
+	t.Run("exclude zero", func(t *testing.T) {
+		out := Compute(all, &Opts{ExcludeZero: true})
+		require.Equal(t, 9, out.Slots)
+		require.Equal(t, uint64(100), out.Min)
+	})
+
+	t.Run("recent slots", func(t *testing.T) {
+		// The 3 most recent slots are 100, 99 and 98.
+		out := Compute(all, &Opts{Slots: 3, Percentile: 100})
+		require.Equal(t, 3, out.Slots)
+		require.Equal(t, uint64(200), out.Recommended)
+	})
+
+	t.Run("ema", func(t *testing.T) {
+		out := Compute(fees(0, 100, 800, 900), &Opts{EMAAlpha: 0.5})
+		// From the oldest slot: 900 -> 850 -> 475 -> 237.5
+		require.Equal(t, 237.5, out.EMA)
+		require.Equal(t, uint64(238), out.Recommended)
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		require.Equal(t, uint64(1000), Compute(all, &Opts{MinPrice: 1000}).Recommended)
+		require.Equal(t, uint64(50), Compute(all, &Opts{MaxPrice: 50}).Recommended)
+		require.Equal(t, uint64(10), Compute(nil, &Opts{MinPrice: 10}).Recommended)
+	})
+}
+
+func TestEstimator(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getRecentPrioritizationFees", fees(10, 20, 30, 40))
+
+	payer := solana.NewWallet().PublicKey()
+	recipient := solana.NewWallet().PublicKey()
+	writable := WritableAccounts([]solana.Instruction{
+		system.NewTransferInstruction(1, payer, recipient).Build(),
+		system.NewTransferInstruction(1, payer, recipient).Build(),
+	})
+	require.Equal(t, solana.PublicKeySlice{payer, recipient}, writable)
+
+	price, err := New(rpc.New(server.URL()), &Opts{Percentile: 50}).RecommendedPrice(context.Background(), writable)
+	require.NoError(t, err)
+	require.Equal(t, uint64(20), price)
+
+	requests := server.Requests("getRecentPrioritizationFees")
+	require.Len(t, requests, 1)
+	var params []solana.PublicKeySlice
+	require.NoError(t, stdjson.Unmarshal(requests[0].Params, &params))
+	require.Equal(t, []solana.PublicKeySlice{writable}, params)
+}
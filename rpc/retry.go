@@ -0,0 +1,149 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"go.uber.org/zap"
+)
+
+// RetryPolicy configures the retries of the calls that fail with
+// a transient error: a network error (e.g. a connection reset),
+// an HTTP 5xx response, or a node behind the cluster (NodeUnhealthyError).
+// Other JSON-RPC errors (e.g. a failed preflight) are never retried.
+//
+// Calls with side effects (sendTransaction and requestAirdrop, or a batch
+// containing one) are not retried unless RetryNonIdempotent is set.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries of a call.
+	MaxRetries int
+
+	// MinBackoff is the delay before the first retry; it doubles
+	// on each retry up to MaxBackoff.
+	// Defaults to DefaultRetryBackoff when zero.
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the delay between retries.
+	// Defaults to DefaultRateLimitMaxBackoff when zero.
+	MaxBackoff time.Duration
+
+	// RetryNonIdempotent allows retrying the calls with side effects.
+	// Resending the same signed transaction cannot execute it twice,
+	// but a retried airdrop may be granted twice.
+	RetryNonIdempotent bool
+}
+
+// WithRetryPolicy retries the calls that fail with a transient error
+// as configured by policy.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(d *clientDefaults) { d.retry = &policy }
+}
+
+// nonIdempotentMethods are the methods that are not retried
+// unless RetryPolicy.RetryNonIdempotent is set.
+var nonIdempotentMethods = map[string]bool{
+	"sendTransaction": true,
+	"requestAirdrop":  true,
+}
+
+// RetryError is returned by a call that was retried, with the error
+// of its last attempt.
+type RetryError struct {
+	Method   string
+	Attempts int
+
+	err error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s failed after %d attempts: %s", e.Method, e.Attempts, e.err)
+}
+
+// Unwrap returns the error of the last attempt.
+func (e *RetryError) Unwrap() error {
+	return e.err
+}
+
+func (c *defaultsClient) retry(ctx context.Context, method string, idempotent bool, call func() error) error {
+	policy := c.defaults.retry
+	if policy == nil || policy.MaxRetries <= 0 || (!idempotent && !policy.RetryNonIdempotent) {
+		return call()
+	}
+	backoff := policy.MinBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	maxBackoff := policy.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRateLimitMaxBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= policy.MaxRetries || ctx.Err() != nil || !isRetryable(err) {
+			if err != nil && attempt > 0 {
+				return &RetryError{Method: method, Attempts: attempt + 1, err: err}
+			}
+			return err
+		}
+
+		logger(c.defaults.logger).Debug("rpc call failed, retrying",
+			zap.String("method", method),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("wait", backoff),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &RetryError{Method: method, Attempts: attempt + 1, err: err}
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// isRetryable reports whether err is a transient error: a network error,
+// an HTTP 5xx response, or a node behind the cluster.
+func isRetryable(err error) bool {
+	var httpErr *jsonrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code >= 500
+	}
+	var unhealthy *NodeUnhealthyError
+	if errors.As(err, &unhealthy) {
+		return true
+	}
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
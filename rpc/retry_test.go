@@ -0,0 +1,77 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_RetryPolicy(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.Write([]byte(`{"jsonrpc":"2.0","error":{"code":-32005,"message":"Node is behind by 42 slots","data":{"numSlotsBehind":42}},"id":0}`))
+	}))
+	defer server.Close()
+
+	policy := &RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond}
+	client := NewWithOptions(server.URL, &Options{RetryPolicy: policy})
+
+	_, err := client.GetSlot(context.Background(), "")
+	require.Error(t, err)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+
+	var retryErr *RetryError
+	require.True(t, errors.As(err, &retryErr))
+	assert.Equal(t, "getSlot", retryErr.Method)
+	assert.Equal(t, 3, retryErr.Attempts)
+	var unhealthy *NodeUnhealthyError
+	require.True(t, errors.As(err, &unhealthy))
+	assert.EqualValues(t, 42, *unhealthy.NumSlotsBehind)
+}
+
+func TestClient_RetryPolicy_NonIdempotent(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	{
+		client := New(server.URL, WithRetryPolicy(RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond}))
+		_, err := client.SendEncodedTransaction(context.Background(), "AQ==")
+		require.Error(t, err)
+		assert.EqualValues(t, 1, atomic.LoadInt32(&requests))
+		var retryErr *RetryError
+		assert.False(t, errors.As(err, &retryErr))
+	}
+	{
+		atomic.StoreInt32(&requests, 0)
+		client := New(server.URL, WithRetryPolicy(RetryPolicy{MaxRetries: 2, MinBackoff: time.Millisecond, RetryNonIdempotent: true}))
+		_, err := client.SendEncodedTransaction(context.Background(), "AQ==")
+		require.Error(t, err)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+	}
+}
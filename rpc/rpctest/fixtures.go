@@ -0,0 +1,244 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpctest
+
+import (
+	"bufio"
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+)
+
+// Fixture is a recorded call or notification. Fixture files contain
+// one JSON-encoded Fixture per line.
+type Fixture struct {
+	// Method of the call, or subscribe method of the notification.
+	Method string `json:"method"`
+	// Params of the call. If set, the fixture only answers calls
+	// with equal params.
+	Params stdjson.RawMessage `json:"params,omitempty"`
+	// Result of the call or notification.
+	Result stdjson.RawMessage `json:"result,omitempty"`
+	// Error of the call.
+	Error *jsonrpc.RPCError `json:"error,omitempty"`
+	// Notification marks a notification, sent by Replay.
+	Notification bool `json:"notification,omitempty"`
+}
+
+// ReadFixtures reads fixtures encoded one per line.
+func ReadFixtures(r io.Reader) ([]Fixture, error) {
+	var out []Fixture
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var fixture Fixture
+		if err := stdjson.Unmarshal(scanner.Bytes(), &fixture); err != nil {
+			return nil, fmt.Errorf("read fixtures: line %d: %w", line, err)
+		}
+		out = append(out, fixture)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read fixtures: %w", err)
+	}
+	return out, nil
+}
+
+// WriteFixtures writes fixtures encoded one per line.
+func WriteFixtures(w io.Writer, fixtures []Fixture) error {
+	encoder := stdjson.NewEncoder(w)
+	for _, fixture := range fixtures {
+		if err := encoder.Encode(fixture); err != nil {
+			return fmt.Errorf("write fixtures: %w", err)
+		}
+	}
+	return nil
+}
+
+// Load programs the server with fixtures. The calls of a method are
+// answered by its fixtures in order, the last one answering all the
+// remaining calls; they take precedence over the handlers.
+// Notification fixtures are sent by Replay.
+func (s *Server) Load(fixtures ...Fixture) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, fixture := range fixtures {
+		if fixture.Notification {
+			s.notifications[fixture.Method] = append(s.notifications[fixture.Method], fixture.Result)
+		} else {
+			s.fixtures[fixture.Method] = append(s.fixtures[fixture.Method], fixture)
+		}
+	}
+}
+
+// LoadFile programs the server with the fixtures of a file (see Load).
+func (s *Server) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("load fixtures: %w", err)
+	}
+	defer f.Close()
+	fixtures, err := ReadFixtures(f)
+	if err != nil {
+		return err
+	}
+	s.Load(fixtures...)
+	return nil
+}
+
+// Replay sends, in order, the notification fixtures of method
+// (e.g. "slotSubscribe") to its subscriptions, and returns
+// the number of notifications sent.
+func (s *Server) Replay(method string) (int, error) {
+	s.lock.Lock()
+	notifications := s.notifications[method]
+	s.lock.Unlock()
+	for i, result := range notifications {
+		if _, err := s.notify(method, result); err != nil {
+			return i, err
+		}
+	}
+	return len(notifications), nil
+}
+
+// nextFixture returns the fixture answering a call; the lock must be held.
+func (s *Server) nextFixture(method string, params stdjson.RawMessage) (Fixture, bool) {
+	fixtures := s.fixtures[method]
+	for i, fixture := range fixtures {
+		if fixture.Params != nil && !jsonEqual(fixture.Params, params) {
+			continue
+		}
+		if len(fixtures) > 1 {
+			s.fixtures[method] = append(fixtures[:i:i], fixtures[i+1:]...)
+		}
+		return fixture, true
+	}
+	return Fixture{}, false
+}
+
+func jsonEqual(a, b stdjson.RawMessage) bool {
+	var va, vb interface{}
+	if stdjson.Unmarshal(a, &va) != nil || stdjson.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	ea, _ := stdjson.Marshal(va)
+	eb, _ := stdjson.Marshal(vb)
+	return bytes.Equal(ea, eb)
+}
+
+// Recorder is an http.RoundTripper recording the calls sent through it
+// as fixtures, e.g. to record a session against a real endpoint
+// and replay it with Server.Load.
+type Recorder struct {
+	transport http.RoundTripper
+
+	lock     sync.Mutex
+	fixtures []Fixture
+}
+
+// NewRecorder creates a Recorder sending the requests with transport,
+// or http.DefaultTransport if nil.
+func NewRecorder(transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{transport: transport}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.record(reqBody, respBody)
+	return resp, nil
+}
+
+func (r *Recorder) record(reqBody, respBody []byte) {
+	var requests []rawRequest
+	var responses []rawResponse
+	if err := stdjson.Unmarshal(reqBody, &requests); err != nil {
+		var single rawRequest
+		if stdjson.Unmarshal(reqBody, &single) != nil {
+			return
+		}
+		requests = []rawRequest{single}
+	}
+	if err := stdjson.Unmarshal(respBody, &responses); err != nil {
+		var single rawResponse
+		if stdjson.Unmarshal(respBody, &single) != nil {
+			return
+		}
+		responses = []rawResponse{single}
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, req := range requests {
+		for _, resp := range responses {
+			if !bytes.Equal(req.ID, resp.ID) {
+				continue
+			}
+			r.fixtures = append(r.fixtures, Fixture{
+				Method: req.Method,
+				Params: req.Params,
+				Result: resp.Result,
+				Error:  resp.Error,
+			})
+		}
+	}
+}
+
+// Fixtures returns the recorded fixtures.
+func (r *Recorder) Fixtures() []Fixture {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]Fixture(nil), r.fixtures...)
+}
+
+// WriteFile writes the recorded fixtures to a file.
+func (r *Recorder) WriteFile(path string) error {
+	var buf bytes.Buffer
+	if err := WriteFixtures(&buf, r.Fixtures()); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpctest
+
+import (
+	"bytes"
+	"context"
+	stdjson "encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RPC(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.Handle("getSlot", 42)
+	server.HandleError("getHealth", -32005, "Node is unhealthy")
+
+	client := rpc.New(server.URL())
+	slot, err := client.GetSlot(context.Background(), rpc.CommitmentFinalized)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), slot)
+
+	_, err = client.GetHealth(context.Background())
+	var rpcErr *jsonrpc.RPCError
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, -32005, rpcErr.Code)
+
+	_, err = client.GetBlockHeight(context.Background(), rpc.CommitmentFinalized)
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, ErrorCodeMethodNotFound, rpcErr.Code)
+
+	requests := server.Requests("getSlot")
+	require.Len(t, requests, 1)
+	require.JSONEq(t, `[{"commitment":"finalized"}]`, string(requests[0].Params))
+}
+
+func TestServer_WS(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client, err := ws.Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer client.Close()
+
+	sub, err := client.SlotSubscribe()
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = server.WaitForSubscription(ctx, "slotSubscribe")
+	require.NoError(t, err)
+
+	n, err := server.Notify("slotSubscribe", ws.SlotResult{Parent: 9, Root: 1, Slot: 10})
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	got, err := sub.RecvWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), got.Slot)
+}
+
+func TestRecorder_replay(t *testing.T) {
+	upstream := NewServer()
+	defer upstream.Close()
+	upstream.Handle("getSlot", 7)
+	upstream.HandleError("getHealth", -32005, "Node is unhealthy")
+
+	recorder := NewRecorder(nil)
+	client := rpc.NewWithCustomRPCClient(jsonrpc.NewClientWithOpts(upstream.URL(), &jsonrpc.RPCClientOpts{
+		HTTPClient: &http.Client{Transport: recorder},
+	}))
+	_, err := client.GetSlot(context.Background(), "")
+	require.NoError(t, err)
+	_, err = client.GetHealth(context.Background())
+	require.Error(t, err)
+	require.Len(t, recorder.Fixtures(), 2)
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	require.NoError(t, recorder.WriteFile(path))
+
+	// Replay the session, with recorded notifications.
+	replay := NewServer()
+	defer replay.Close()
+	require.NoError(t, replay.LoadFile(path))
+	var buf bytes.Buffer
+	require.NoError(t, WriteFixtures(&buf, []Fixture{
+		{Method: "slotSubscribe", Notification: true, Result: stdjson.RawMessage(`{"parent":1,"root":0,"slot":2}`)},
+		{Method: "slotSubscribe", Notification: true, Result: stdjson.RawMessage(`{"parent":2,"root":0,"slot":3}`)},
+	}))
+	notifications, err := ReadFixtures(&buf)
+	require.NoError(t, err)
+	replay.Load(notifications...)
+
+	client = rpc.New(replay.URL())
+	slot, err := client.GetSlot(context.Background(), "")
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), slot)
+	_, err = client.GetHealth(context.Background())
+	var rpcErr *jsonrpc.RPCError
+	require.ErrorAs(t, err, &rpcErr)
+	require.Equal(t, -32005, rpcErr.Code)
+
+	wsClient, err := ws.Connect(context.Background(), replay.WSURL())
+	require.NoError(t, err)
+	defer wsClient.Close()
+	sub, err := wsClient.SlotSubscribe()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = replay.WaitForSubscription(ctx, "slotSubscribe")
+	require.NoError(t, err)
+	n, err := replay.Replay("slotSubscribe")
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	for _, expected := range []uint64{2, 3} {
+		got, err := sub.RecvWithContext(ctx)
+		require.NoError(t, err)
+		require.Equal(t, expected, got.Slot)
+	}
+}
+
+func TestServer_fixturesInOrder(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+	server.Load(
+		Fixture{Method: "getSlot", Result: stdjson.RawMessage(`1`)},
+		Fixture{Method: "getSlot", Result: stdjson.RawMessage(`2`)},
+	)
+
+	client := rpc.New(server.URL())
+	for _, expected := range []uint64{1, 2, 2} {
+		slot, err := client.GetSlot(context.Background(), "")
+		require.NoError(t, err)
+		require.Equal(t, expected, slot)
+	}
+}
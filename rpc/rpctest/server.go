@@ -0,0 +1,419 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rpctest provides an in-process Solana JSON-RPC server, serving
+// both HTTP requests and websocket subscriptions, for testing code built
+// on the rpc and ws packages without hitting real endpoints.
+package rpctest
+
+import (
+	"bytes"
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/gorilla/websocket"
+)
+
+// HandlerFunc returns the result of a call with the provided params.
+// A returned *jsonrpc.RPCError is sent as is; other errors are sent
+// with the ErrorCodeServer code.
+type HandlerFunc func(params stdjson.RawMessage) (interface{}, error)
+
+// ErrorCodeServer is the code of the errors returned by HandlerFuncs
+// that are not a *jsonrpc.RPCError.
+const ErrorCodeServer = -32000
+
+// ErrorCodeMethodNotFound is the code of the error sent for methods
+// that have no handler.
+const ErrorCodeMethodNotFound = -32601
+
+// Request is a call received by the server.
+type Request struct {
+	Method string
+	Params stdjson.RawMessage
+}
+
+type rawRequest struct {
+	JSONRPC string             `json:"jsonrpc"`
+	ID      stdjson.RawMessage `json:"id"`
+	Method  string             `json:"method"`
+	Params  stdjson.RawMessage `json:"params,omitempty"`
+}
+
+type rawResponse struct {
+	JSONRPC string             `json:"jsonrpc"`
+	ID      stdjson.RawMessage `json:"id"`
+	Result  stdjson.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpc.RPCError  `json:"error,omitempty"`
+}
+
+// Server is an in-process JSON-RPC server, programmed with canned
+// responses (Handle, HandleFunc, HandleError, Load) and notifications
+// (Notify, Replay). Websocket connections are served on the same URL.
+type Server struct {
+	server *httptest.Server
+
+	lock          sync.Mutex
+	handlers      map[string]HandlerFunc
+	fixtures      map[string][]Fixture
+	notifications map[string][]stdjson.RawMessage
+	requests      []Request
+	nextSubID     uint64
+	subs          map[uint64]*subscription
+	subscribed    chan struct{}
+}
+
+type subscription struct {
+	id     uint64
+	method string
+	conn   *wsConn
+}
+
+type wsConn struct {
+	lock sync.Mutex
+	conn *websocket.Conn
+}
+
+func (c *wsConn) writeJSON(v interface{}) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+// NewServer starts a new server; call Close to stop it.
+func NewServer() *Server {
+	s := &Server{
+		handlers:      map[string]HandlerFunc{},
+		fixtures:      map[string][]Fixture{},
+		notifications: map[string][]stdjson.RawMessage{},
+		subs:          map[uint64]*subscription{},
+		subscribed:    make(chan struct{}),
+	}
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+// URL returns the HTTP URL of the server, to be used with rpc.New.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// WSURL returns the websocket URL of the server, to be used with ws.Connect.
+func (s *Server) WSURL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+// Close stops the server, closing all the websocket connections.
+func (s *Server) Close() {
+	s.server.CloseClientConnections()
+	s.server.Close()
+}
+
+// Handle responds to all the calls of method with result.
+func (s *Server) Handle(method string, result interface{}) {
+	s.HandleFunc(method, func(stdjson.RawMessage) (interface{}, error) {
+		return result, nil
+	})
+}
+
+// HandleError responds to all the calls of method with an error.
+func (s *Server) HandleError(method string, code int, message string) {
+	s.HandleFunc(method, func(stdjson.RawMessage) (interface{}, error) {
+		return nil, &jsonrpc.RPCError{Code: code, Message: message}
+	})
+}
+
+// HandleFunc responds to all the calls of method with the result of fn.
+func (s *Server) HandleFunc(method string, fn HandlerFunc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.handlers[method] = fn
+}
+
+// Requests returns the calls received by the server for method,
+// or all the calls if method is empty, excluding subscriptions.
+func (s *Server) Requests(method string) []Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var out []Request
+	for _, req := range s.requests {
+		if method == "" || req.Method == method {
+			out = append(out, req)
+		}
+	}
+	return out
+}
+
+func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if websocket.IsWebSocketUpgrade(req) {
+		s.serveWS(rw, req)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var out interface{}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []rawRequest
+		if err := stdjson.Unmarshal(body, &batch); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		responses := make([]*rawResponse, len(batch))
+		for i, in := range batch {
+			responses[i] = s.call(in)
+		}
+		out = responses
+	} else {
+		var in rawRequest
+		if err := stdjson.Unmarshal(body, &in); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		out = s.call(in)
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	stdjson.NewEncoder(rw).Encode(out)
+}
+
+func (s *Server) call(in rawRequest) *rawResponse {
+	resp := &rawResponse{JSONRPC: "2.0", ID: in.ID}
+
+	s.lock.Lock()
+	s.requests = append(s.requests, Request{Method: in.Method, Params: in.Params})
+	fixture, fromFixture := s.nextFixture(in.Method, in.Params)
+	handler, found := s.handlers[in.Method]
+	s.lock.Unlock()
+
+	if fromFixture {
+		resp.Result = fixture.Result
+		resp.Error = fixture.Error
+		if resp.Result == nil && resp.Error == nil {
+			resp.Result = stdjson.RawMessage("null")
+		}
+		return resp
+	}
+	if !found {
+		resp.Error = &jsonrpc.RPCError{
+			Code:    ErrorCodeMethodNotFound,
+			Message: fmt.Sprintf("Method not found: %s", in.Method),
+		}
+		return resp
+	}
+
+	result, err := handler(in.Params)
+	if err != nil {
+		if rpcErr, ok := err.(*jsonrpc.RPCError); ok {
+			resp.Error = rpcErr
+		} else {
+			resp.Error = &jsonrpc.RPCError{Code: ErrorCodeServer, Message: err.Error()}
+		}
+		return resp
+	}
+	resp.Result, err = stdjson.Marshal(result)
+	if err != nil {
+		resp.Error = &jsonrpc.RPCError{Code: ErrorCodeServer, Message: err.Error()}
+	}
+	return resp
+}
+
+func (s *Server) serveWS(rw http.ResponseWriter, req *http.Request) {
+	upgrader := websocket.Upgrader{}
+	raw, err := upgrader.Upgrade(rw, req, nil)
+	if err != nil {
+		return
+	}
+	conn := &wsConn{conn: raw}
+	defer func() {
+		raw.Close()
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		for id, sub := range s.subs {
+			if sub.conn == conn {
+				delete(s.subs, id)
+			}
+		}
+	}()
+
+	for {
+		_, msg, err := raw.ReadMessage()
+		if err != nil {
+			return
+		}
+		var in rawRequest
+		if err := stdjson.Unmarshal(msg, &in); err != nil {
+			return
+		}
+
+		switch {
+		case strings.HasSuffix(in.Method, "Unsubscribe"):
+			err = conn.writeJSON(s.unsubscribe(in))
+		case strings.HasSuffix(in.Method, "Subscribe"):
+			err = s.subscribe(in, conn)
+		default:
+			err = conn.writeJSON(s.call(in))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// subscribe confirms a subscription, and registers it once confirmed
+// so that no notification is sent before the confirmation.
+func (s *Server) subscribe(in rawRequest, conn *wsConn) error {
+	s.lock.Lock()
+	s.nextSubID++
+	sub := &subscription{
+		id:     s.nextSubID,
+		method: in.Method,
+		conn:   conn,
+	}
+	s.lock.Unlock()
+
+	err := conn.writeJSON(&rawResponse{
+		JSONRPC: "2.0",
+		ID:      in.ID,
+		Result:  stdjson.RawMessage(fmt.Sprintf("%d", sub.id)),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.subs[sub.id] = sub
+	close(s.subscribed)
+	s.subscribed = make(chan struct{})
+	return nil
+}
+
+func (s *Server) unsubscribe(in rawRequest) *rawResponse {
+	var params []uint64
+	stdjson.Unmarshal(in.Params, &params)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	found := false
+	if len(params) > 0 {
+		_, found = s.subs[params[0]]
+		delete(s.subs, params[0])
+	}
+	return &rawResponse{
+		JSONRPC: "2.0",
+		ID:      in.ID,
+		Result:  stdjson.RawMessage(fmt.Sprintf("%t", found)),
+	}
+}
+
+// WaitForSubscription waits until a subscription with the provided method
+// (e.g. "slotSubscribe") is active, and returns its ID.
+func (s *Server) WaitForSubscription(ctx context.Context, method string) (uint64, error) {
+	for {
+		s.lock.Lock()
+		for id, sub := range s.subs {
+			if sub.method == method {
+				s.lock.Unlock()
+				return id, nil
+			}
+		}
+		subscribed := s.subscribed
+		s.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-subscribed:
+		}
+	}
+}
+
+// Notify sends a notification with the provided result to all
+// the subscriptions with the provided method (e.g. "slotSubscribe"),
+// and returns the number of subscriptions notified.
+func (s *Server) Notify(method string, result interface{}) (int, error) {
+	data, err := stdjson.Marshal(result)
+	if err != nil {
+		return 0, fmt.Errorf("notify: encode result: %w", err)
+	}
+	return s.notify(method, data)
+}
+
+func (s *Server) notify(method string, result stdjson.RawMessage) (int, error) {
+	s.lock.Lock()
+	var subs []*subscription
+	for _, sub := range s.subs {
+		if sub.method == method {
+			subs = append(subs, sub)
+		}
+	}
+	s.lock.Unlock()
+
+	notificationMethod := strings.TrimSuffix(method, "Subscribe") + "Notification"
+	for _, sub := range subs {
+		err := sub.conn.writeJSON(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  notificationMethod,
+			"params": map[string]interface{}{
+				"result":       result,
+				"subscription": sub.id,
+			},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("notify: %w", err)
+		}
+	}
+	return len(subs), nil
+}
+
+// NotifyError cancels all the subscriptions with the provided method
+// (e.g. "slotSubscribe") with an error notification, as a node does
+// when it restarts, and returns the number of subscriptions canceled.
+func (s *Server) NotifyError(method string, code int, message string) (int, error) {
+	s.lock.Lock()
+	var subs []*subscription
+	for id, sub := range s.subs {
+		if sub.method == method {
+			subs = append(subs, sub)
+			delete(s.subs, id)
+		}
+	}
+	s.lock.Unlock()
+
+	notificationMethod := strings.TrimSuffix(method, "Subscribe") + "Notification"
+	for _, sub := range subs {
+		err := sub.conn.writeJSON(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  notificationMethod,
+			"params": map[string]interface{}{
+				"error":        &jsonrpc.RPCError{Code: code, Message: message},
+				"subscription": sub.id,
+			},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("notify error: %w", err)
+		}
+	}
+	return len(subs), nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// DefaultScanChunkSize is the number of accounts fetched per
+// getMultipleAccounts request by ScanProgramAccounts; it is
+// the maximum accepted by RPC nodes.
+var DefaultScanChunkSize = 100
+
+type ScanProgramAccountsOpts struct {
+	Commitment CommitmentType
+
+	// Filter on accounts, implicit AND between filters
+	// (see NewFilterBuilder).
+	//
+	// This parameter is optional.
+	Filters []RPCFilter
+
+	// DataSlice limits the returned account data.
+	//
+	// This parameter is optional.
+	DataSlice *DataSlice
+
+	// KeysOnly skips fetching the account data: the streamed accounts
+	// only carry their public key and an empty Account.
+	KeysOnly bool
+
+	// ChunkSize is the number of accounts fetched per request.
+	// Defaults to DefaultScanChunkSize.
+	ChunkSize int
+
+	// Buffer is the capacity of the returned accounts channel.
+	// Defaults to ChunkSize.
+	Buffer int
+}
+
+// ScanProgramAccounts streams the accounts owned by the provided program
+// that match the filters, without holding the full result set in memory.
+//
+// It first lists the matching account keys with a getProgramAccounts request
+// that returns no data (an empty dataSlice), then fetches the accounts in
+// chunks, sorted by key, with getMultipleAccounts. Accounts closed, reassigned
+// or (when no DataSlice is set) no longer matching the filters between
+// the two steps are skipped.
+//
+// The accounts channel is closed when the scan is complete; the error channel
+// then receives the error that interrupted the scan, if any, and is closed.
+// Cancel ctx to stop the scan early.
+func (cl *Client) ScanProgramAccounts(
+	ctx context.Context,
+	program solana.PublicKey,
+	opts *ScanProgramAccountsOpts, // optional
+) (<-chan *KeyedAccount, <-chan error) {
+	var o ScanProgramAccountsOpts
+	if opts != nil {
+		o = *opts
+	}
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = DefaultScanChunkSize
+	}
+	if o.Buffer <= 0 {
+		o.Buffer = o.ChunkSize
+	}
+
+	accounts := make(chan *KeyedAccount, o.Buffer)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		err := cl.scanProgramAccounts(ctx, program, &o, accounts)
+		close(accounts)
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return accounts, errs
+}
+
+func (cl *Client) scanProgramAccounts(
+	ctx context.Context,
+	program solana.PublicKey,
+	opts *ScanProgramAccountsOpts,
+	out chan<- *KeyedAccount,
+) error {
+	zero := uint64(0)
+	keyed, err := cl.GetProgramAccountsWithOpts(ctx, program, &GetProgramAccountsOpts{
+		Commitment: opts.Commitment,
+		Filters:    opts.Filters,
+		DataSlice:  &DataSlice{Offset: &zero, Length: &zero},
+	})
+	if err != nil {
+		return fmt.Errorf("scan program accounts: list keys: %w", err)
+	}
+	keys := make([]solana.PublicKey, 0, len(keyed))
+	for _, acc := range keyed {
+		if acc != nil {
+			keys = append(keys, acc.Pubkey)
+		}
+	}
+	// Release the listing, only the keys are needed from now on.
+	keyed = nil
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i][:], keys[j][:]) < 0
+	})
+
+	send := func(acc *KeyedAccount) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case out <- acc:
+			return nil
+		}
+	}
+
+	if opts.KeysOnly {
+		for _, key := range keys {
+			if err := send(&KeyedAccount{Pubkey: key, Account: &Account{}}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for start := 0; start < len(keys); start += opts.ChunkSize {
+		end := start + opts.ChunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		chunk := keys[start:end]
+		res, err := cl.GetMultipleAccountsWithOpts(ctx, chunk, &GetMultipleAccountsOpts{
+			Encoding:   solana.EncodingBase64,
+			Commitment: opts.Commitment,
+			DataSlice:  opts.DataSlice,
+		})
+		if err != nil {
+			return fmt.Errorf("scan program accounts: fetch accounts %d-%d of %d: %w", start, end, len(keys), err)
+		}
+		for i, acc := range res.Value {
+			if acc == nil || i >= len(chunk) || !acc.Owner.Equals(program) {
+				continue
+			}
+			// The account may have changed since it was listed.
+			if opts.DataSlice == nil && acc.Data != nil && !MatchFilters(opts.Filters, acc.Data.GetBinary()) {
+				continue
+			}
+			if err := send(&KeyedAccount{Pubkey: chunk[i], Account: acc}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,432 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.uber.org/zap"
+)
+
+// EventKind identifies what caused a callback to fire.
+type EventKind int
+
+const (
+	// EventEpochBoundary fires on the first observed slot of a new epoch.
+	EventEpochBoundary EventKind = iota
+	// EventBeforeEpochEnd fires once per epoch, when the
+	// configured number of slots (or fewer) remain in the epoch.
+	EventBeforeEpochEnd
+	// EventLeaderWindow fires once per leader window of a validator,
+	// when the window is about to start (or has started).
+	EventLeaderWindow
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventEpochBoundary:
+		return "epoch_boundary"
+	case EventBeforeEpochEnd:
+		return "before_epoch_end"
+	case EventLeaderWindow:
+		return "leader_window"
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// LeaderWindow is a range of consecutive leader slots of a validator.
+type LeaderWindow struct {
+	Identity solana.PublicKey
+	// First and last absolute slot of the window (inclusive).
+	FirstSlot uint64
+	LastSlot  uint64
+}
+
+// Event is passed to the callbacks.
+type Event struct {
+	Kind EventKind
+	// Slot that triggered the event.
+	Slot uint64
+	// Epoch that contains Slot, and the index of Slot in that epoch.
+	Epoch        uint64
+	SlotIndex    uint64
+	SlotsInEpoch uint64
+	// Set for EventLeaderWindow.
+	Window *LeaderWindow
+}
+
+// SlotsRemaining returns the number of slots left in the epoch after Slot.
+func (e Event) SlotsRemaining() uint64 {
+	return e.SlotsInEpoch - e.SlotIndex - 1
+}
+
+// Callback is invoked by the scheduler when a task fires.
+// Callbacks run sequentially on the goroutine that advances the slot,
+// so a slow callback delays the following ones.
+type Callback func(ctx context.Context, event Event)
+
+// DefaultLeaderSchedulePreload is the default of Opts.LeaderSchedulePreload.
+var DefaultLeaderSchedulePreload uint64 = 1000
+
+type Opts struct {
+	// Commitment of the epoch schedule and leader schedule requests.
+	// Defaults to rpc.CommitmentConfirmed.
+	Commitment rpc.CommitmentType
+
+	// LeaderSchedulePreload is the number of slots before the end of an epoch
+	// from which the leader schedule of the next epoch is fetched, so that
+	// the first slots of the next epoch don't wait for it.
+	// Defaults to DefaultLeaderSchedulePreload.
+	LeaderSchedulePreload uint64
+}
+
+type beforeEndTask struct {
+	slots     uint64
+	fn        Callback
+	lastEpoch *uint64
+}
+
+type leaderTask struct {
+	identity solana.PublicKey
+	lead     uint64
+	fn       Callback
+
+	epoch   *uint64 // epoch of the loaded windows
+	windows []LeaderWindow
+	next    int // index of the next window to fire
+}
+
+// Scheduler fires callbacks at epoch boundaries, a number of slots before
+// the end of each epoch, and ahead of the leader slots of validators.
+//
+// The scheduler is driven by slots: either from a slot subscription
+// (see Start), or from a custom slot source (see Advance).
+type Scheduler struct {
+	rpcClient *rpc.Client
+	opts      Opts
+
+	lock        sync.Mutex
+	schedule    *rpc.GetEpochScheduleResult
+	lastSlot    uint64
+	epoch       *uint64
+	onBoundary  []Callback
+	beforeEnd   []*beforeEndTask
+	leaderTasks []*leaderTask
+	// Leader schedules by epoch, fetched once per epoch.
+	leaderSchedules map[uint64]rpc.GetLeaderScheduleResult
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a new Scheduler; the rpcClient is used to fetch
+// the epoch schedule and the leader schedules.
+func New(rpcClient *rpc.Client, opts *Opts) *Scheduler {
+	s := &Scheduler{
+		rpcClient: rpcClient,
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.Commitment == "" {
+		s.opts.Commitment = rpc.CommitmentConfirmed
+	}
+	if s.opts.LeaderSchedulePreload == 0 {
+		s.opts.LeaderSchedulePreload = DefaultLeaderSchedulePreload
+	}
+	return s
+}
+
+// OnEpochBoundary registers a callback that fires on the first
+// observed slot of every new epoch.
+func (s *Scheduler) OnEpochBoundary(fn Callback) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onBoundary = append(s.onBoundary, fn)
+}
+
+// BeforeEpochEnd registers a callback that fires once per epoch,
+// as soon as at most the provided number of slots remain in the epoch.
+func (s *Scheduler) BeforeEpochEnd(slots uint64, fn Callback) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.beforeEnd = append(s.beforeEnd, &beforeEndTask{slots: slots, fn: fn})
+}
+
+// OnLeaderWindow registers a callback that fires once for every window
+// of consecutive leader slots of the validator identity, lead slots
+// before the window starts. If the window is already underway when it
+// is first observed, the callback fires immediately.
+func (s *Scheduler) OnLeaderWindow(identity solana.PublicKey, lead uint64, fn Callback) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.leaderTasks = append(s.leaderTasks, &leaderTask{identity: identity, lead: lead, fn: fn})
+}
+
+// Start subscribes to slot notifications with the provided client
+// and advances the scheduler until ctx is done or Close is called.
+func (s *Scheduler) Start(ctx context.Context, wsClient *ws.Client) error {
+	if _, err := s.epochSchedule(ctx); err != nil {
+		return err
+	}
+	sub, err := wsClient.SlotSubscribe()
+	if err != nil {
+		return fmt.Errorf("scheduler: slot subscribe: %w", err)
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		defer sub.Unsubscribe()
+		for {
+			got, err := sub.RecvWithContext(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					zlog.Warn("scheduler slot subscription ended", zap.Error(err))
+				}
+				return
+			}
+			if err := s.Advance(ctx, got.Slot); err != nil && ctx.Err() == nil {
+				zlog.Warn("unable to advance scheduler", zap.Uint64("slot", got.Slot), zap.Error(err))
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops the slot subscription started by Start,
+// and waits for the running callbacks to return.
+func (s *Scheduler) Close() {
+	s.CloseWithContext(context.Background())
+}
+
+// CloseWithContext stops the slot subscription started by Start,
+// and waits for the running callbacks to return until ctx is done,
+// in which case ctx.Err() is returned.
+func (s *Scheduler) CloseWithContext(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Advance moves the scheduler to the provided slot and fires the due callbacks.
+// Slots lower than or equal to the last observed slot are ignored.
+// It can be used to drive the scheduler from a custom slot source.
+func (s *Scheduler) Advance(ctx context.Context, slot uint64) error {
+	schedule, err := s.epochSchedule(ctx)
+	if err != nil {
+		return err
+	}
+	// The leader schedule is fetched without holding the lock,
+	// so that a slow request doesn't block the registration of tasks.
+	epoch, slotIndex := schedule.GetEpoch(slot)
+	leaders, leadersErr := s.leaderSchedule(ctx, schedule, epoch)
+
+	// Callbacks are invoked without holding the lock,
+	// so that they can register new tasks.
+	due, err := s.advance(ctx, schedule, slot, leaders, leadersErr)
+	for _, fire := range due {
+		fire()
+	}
+
+	if schedule.GetSlotsInEpoch(epoch)-slotIndex-1 <= s.opts.LeaderSchedulePreload {
+		if _, err := s.leaderSchedule(ctx, schedule, epoch+1); err != nil {
+			zlog.Debug("unable to preload leader schedule", zap.Uint64("epoch", epoch+1), zap.Error(err))
+		}
+	}
+	return err
+}
+
+// advance records the slot and returns the due callbacks. The leader
+// windows of the epoch are loaded from leaders, the leader schedule
+// of the epoch, or leadersErr is returned if fetching it failed.
+func (s *Scheduler) advance(
+	ctx context.Context,
+	schedule *rpc.GetEpochScheduleResult,
+	slot uint64,
+	leaders rpc.GetLeaderScheduleResult,
+	leadersErr error,
+) (due []func(), err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.epoch != nil && slot <= s.lastSlot {
+		return nil, nil
+	}
+
+	epoch, slotIndex := schedule.GetEpoch(slot)
+	base := Event{
+		Slot:         slot,
+		Epoch:        epoch,
+		SlotIndex:    slotIndex,
+		SlotsInEpoch: schedule.GetSlotsInEpoch(epoch),
+	}
+	newEpoch := s.epoch != nil && epoch > *s.epoch
+	s.lastSlot = slot
+	s.epoch = &epoch
+
+	if newEpoch {
+		event := base
+		event.Kind = EventEpochBoundary
+		for _, fn := range s.onBoundary {
+			due = append(due, fire(ctx, fn, event))
+		}
+	}
+
+	for _, task := range s.beforeEnd {
+		if task.lastEpoch != nil && *task.lastEpoch == epoch {
+			continue
+		}
+		if base.SlotsRemaining() > task.slots {
+			continue
+		}
+		task.lastEpoch = &epoch
+		event := base
+		event.Kind = EventBeforeEpochEnd
+		due = append(due, fire(ctx, task.fn, event))
+	}
+
+	for _, task := range s.leaderTasks {
+		if task.epoch == nil || *task.epoch != epoch {
+			if leaders == nil {
+				// Not fetched: failed, or the task was registered meanwhile.
+				err = leadersErr
+				continue
+			}
+			task.loadWindows(leaders, schedule, epoch)
+		}
+		for task.next < len(task.windows) {
+			window := task.windows[task.next]
+			if slot > window.LastSlot {
+				// Missed entirely, e.g. the scheduler started late.
+				task.next++
+				continue
+			}
+			if slot+task.lead < window.FirstSlot {
+				break
+			}
+			task.next++
+			event := base
+			event.Kind = EventLeaderWindow
+			event.Window = &window
+			due = append(due, fire(ctx, task.fn, event))
+		}
+	}
+	return due, err
+}
+
+func fire(ctx context.Context, fn Callback, event Event) func() {
+	return func() { fn(ctx, event) }
+}
+
+func (s *Scheduler) epochSchedule(ctx context.Context) (*rpc.GetEpochScheduleResult, error) {
+	s.lock.Lock()
+	schedule := s.schedule
+	s.lock.Unlock()
+	if schedule != nil {
+		return schedule, nil
+	}
+
+	schedule, err := s.rpcClient.GetEpochSchedule(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler: get epoch schedule: %w", err)
+	}
+	if schedule == nil {
+		return nil, fmt.Errorf("scheduler: get epoch schedule: %w", rpc.ErrNotFound)
+	}
+	s.lock.Lock()
+	s.schedule = schedule
+	s.lock.Unlock()
+	return schedule, nil
+}
+
+// leaderSchedule returns the leader schedule of the epoch, fetching it
+// if it is not cached yet. It returns nil if there is no leader task.
+func (s *Scheduler) leaderSchedule(ctx context.Context, schedule *rpc.GetEpochScheduleResult, epoch uint64) (rpc.GetLeaderScheduleResult, error) {
+	s.lock.Lock()
+	leaders, cached := s.leaderSchedules[epoch]
+	noTasks := len(s.leaderTasks) == 0
+	s.lock.Unlock()
+	if cached || noTasks {
+		return leaders, nil
+	}
+
+	firstSlot := schedule.GetFirstSlotInEpoch(epoch)
+	leaders, err := s.rpcClient.GetLeaderScheduleWithOpts(ctx, &rpc.GetLeaderScheduleOpts{
+		Commitment: s.opts.Commitment,
+		Epoch:      &firstSlot,
+	})
+	if err != nil && err != rpc.ErrNotFound {
+		return nil, fmt.Errorf("scheduler: get leader schedule for epoch %d: %w", epoch, err)
+	}
+	if leaders == nil {
+		leaders = rpc.GetLeaderScheduleResult{}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.leaderSchedules == nil {
+		s.leaderSchedules = make(map[uint64]rpc.GetLeaderScheduleResult)
+	}
+	s.leaderSchedules[epoch] = leaders
+	// Drop the schedules of the older epochs.
+	for cached := range s.leaderSchedules {
+		if cached+1 < epoch {
+			delete(s.leaderSchedules, cached)
+		}
+	}
+	return leaders, nil
+}
+
+// loadWindows groups the leader slots of the task identity
+// in the provided epoch into windows.
+func (task *leaderTask) loadWindows(leaders rpc.GetLeaderScheduleResult, schedule *rpc.GetEpochScheduleResult, epoch uint64) {
+	task.epoch = &epoch
+	task.windows = leaderWindows(task.identity, schedule.GetFirstSlotInEpoch(epoch), leaders[task.identity])
+	task.next = 0
+}
+
+// leaderWindows groups the provided slot indices (relative to firstSlot)
+// into windows of consecutive absolute slots.
+func leaderWindows(identity solana.PublicKey, firstSlot uint64, indices []uint64) []LeaderWindow {
+	sorted := make([]uint64, len(indices))
+	copy(sorted, indices)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var out []LeaderWindow
+	for _, index := range sorted {
+		slot := firstSlot + index
+		if n := len(out); n > 0 && out[n-1].LastSlot+1 >= slot {
+			if slot > out[n-1].LastSlot {
+				out[n-1].LastSlot = slot
+			}
+			continue
+		}
+		out = append(out, LeaderWindow{Identity: identity, FirstSlot: slot, LastSlot: slot})
+	}
+	return out
+}
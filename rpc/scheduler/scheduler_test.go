@@ -0,0 +1,178 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func newScheduleServer(t *testing.T, identity solana.PublicKey) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var in struct {
+			ID     any                  `json:"id"`
+			Method string               `json:"method"`
+			Params []stdjson.RawMessage `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&in))
+
+		var result string
+		switch in.Method {
+		case "getEpochSchedule":
+			result = `{"slotsPerEpoch":100,"leaderScheduleSlotOffset":100,"warmup":false,"firstNormalEpoch":0,"firstNormalSlot":0}`
+		case "getLeaderSchedule":
+			var slot uint64
+			require.NoError(t, stdjson.Unmarshal(in.Params[0], &slot))
+			if slot == 100 {
+				result = fmt.Sprintf(`{%q:[51,10,11,12,50]}`, identity)
+			} else {
+				result = `{}`
+			}
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(rw, `{"jsonrpc":"2.0","result":%s,"id":%q}`, result, fmt.Sprint(in.ID))
+	}))
+}
+
+func TestScheduler(t *testing.T) {
+	identity := solana.NewWallet().PublicKey()
+	server := newScheduleServer(t, identity)
+	defer server.Close()
+
+	s := New(rpc.New(server.URL), nil)
+	var events []Event
+	record := func(ctx context.Context, event Event) {
+		events = append(events, event)
+	}
+	s.OnEpochBoundary(record)
+	s.BeforeEpochEnd(5, record)
+	s.OnLeaderWindow(identity, 2, record)
+
+	ctx := context.Background()
+	for _, slot := range []uint64{90, 96, 97, 99, 101, 104, 108, 108, 120, 149, 160, 195} {
+		require.NoError(t, s.Advance(ctx, slot))
+	}
+
+	type fired struct {
+		kind   EventKind
+		slot   uint64
+		window *LeaderWindow
+	}
+	var got []fired
+	for _, event := range events {
+		got = append(got, fired{event.Kind, event.Slot, event.Window})
+	}
+	require.Equal(t, []fired{
+		{EventBeforeEpochEnd, 96, nil},
+		{EventEpochBoundary, 101, nil},
+		{EventLeaderWindow, 108, &LeaderWindow{Identity: identity, FirstSlot: 110, LastSlot: 112}},
+		{EventLeaderWindow, 149, &LeaderWindow{Identity: identity, FirstSlot: 150, LastSlot: 151}},
+		{EventBeforeEpochEnd, 195, nil},
+	}, got)
+
+	require.Equal(t, uint64(1), events[1].Epoch)
+	require.Equal(t, uint64(1), events[1].SlotIndex)
+	require.Equal(t, uint64(4), events[4].SlotsRemaining())
+}
+
+func TestScheduler_leaderScheduleCache(t *testing.T) {
+	identity := solana.NewWallet().PublicKey()
+	var lock sync.Mutex
+	var fetched []uint64
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var in struct {
+			ID     any                  `json:"id"`
+			Method string               `json:"method"`
+			Params []stdjson.RawMessage `json:"params"`
+		}
+		require.NoError(t, stdjson.NewDecoder(req.Body).Decode(&in))
+
+		result := `{"slotsPerEpoch":100,"leaderScheduleSlotOffset":100,"warmup":false,"firstNormalEpoch":0,"firstNormalSlot":0}`
+		if in.Method == "getLeaderSchedule" {
+			var slot uint64
+			require.NoError(t, stdjson.Unmarshal(in.Params[0], &slot))
+			lock.Lock()
+			fetched = append(fetched, slot)
+			lock.Unlock()
+			if slot == 200 {
+				close(blocked)
+				<-release
+			}
+			result = fmt.Sprintf(`{%q:[10]}`, identity)
+		}
+		fmt.Fprintf(rw, `{"jsonrpc":"2.0","result":%s,"id":%q}`, result, fmt.Sprint(in.ID))
+	}))
+	defer server.Close()
+
+	s := New(rpc.New(server.URL), &Opts{LeaderSchedulePreload: 10})
+	var windows []uint64
+	s.OnLeaderWindow(identity, 2, func(ctx context.Context, event Event) {
+		windows = append(windows, event.Window.FirstSlot)
+	})
+
+	ctx := context.Background()
+	for _, slot := range []uint64{5, 8, 50, 90, 95, 101, 108, 150} {
+		require.NoError(t, s.Advance(ctx, slot))
+	}
+	require.Equal(t, []uint64{10, 110}, windows)
+	// Once per epoch, the next one being preloaded near the boundary.
+	require.Equal(t, []uint64{0, 100}, fetched)
+
+	// The registration of tasks doesn't wait for the leader schedule.
+	advanced := make(chan error, 1)
+	go func() { advanced <- s.Advance(ctx, 195) }()
+	<-blocked
+	registered := make(chan struct{})
+	go func() {
+		s.OnEpochBoundary(func(ctx context.Context, event Event) {})
+		close(registered)
+	}()
+	select {
+	case <-registered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("registration blocked by the leader schedule request")
+	}
+	close(release)
+	require.NoError(t, <-advanced)
+	require.NoError(t, s.Advance(ctx, 208))
+	require.Equal(t, []uint64{10, 110, 210}, windows)
+	require.Equal(t, []uint64{0, 100, 200}, fetched)
+}
+
+func TestLeaderWindows(t *testing.T) {
+	identity := solana.NewWallet().PublicKey()
+	require.Equal(t,
+		[]LeaderWindow{
+			{Identity: identity, FirstSlot: 1000, LastSlot: 1003},
+			{Identity: identity, FirstSlot: 1008, LastSlot: 1008},
+		},
+		leaderWindows(identity, 1000, []uint64{8, 3, 0, 1, 2, 2}),
+	)
+	require.Nil(t, leaderWindows(identity, 1000, nil))
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.uber.org/zap"
+)
+
+var ErrBlockhashUnavailable = errors.New("no recent blockhash available")
+
+var (
+	DefaultBlockhashRefreshInterval = 2 * time.Second
+	DefaultBlockhashRefreshSlots    = uint64(4)
+)
+
+type BlockhashCacheOpts struct {
+	// Commitment used to fetch the blockhash.
+	// Defaults to rpc.CommitmentConfirmed.
+	Commitment rpc.CommitmentType
+
+	// RefreshInterval is the period at which getLatestBlockhash is polled.
+	// Defaults to DefaultBlockhashRefreshInterval.
+	RefreshInterval time.Duration
+
+	// SlotClient, if set, is used to subscribe to slot notifications
+	// and refresh the blockhash every RefreshSlots slots,
+	// in addition to polling.
+	//
+	// This parameter is optional.
+	SlotClient *ws.Client
+
+	// RefreshSlots is the number of slots between two slot-triggered refreshes.
+	// Defaults to DefaultBlockhashRefreshSlots.
+	RefreshSlots uint64
+}
+
+// BlockhashCache keeps a recent blockhash refreshed in the background,
+// so that transactions can be assigned a blockhash without
+// a getLatestBlockhash round trip.
+type BlockhashCache struct {
+	client *rpc.Client
+	opts   BlockhashCacheOpts
+
+	lock   sync.RWMutex
+	latest *rpc.LatestBlockhashResult
+	slot   uint64
+
+	refresh chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewBlockhashCache creates a new BlockhashCache; call Start to begin refreshing.
+func NewBlockhashCache(client *rpc.Client, opts *BlockhashCacheOpts) *BlockhashCache {
+	c := &BlockhashCache{
+		client:  client,
+		refresh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	if opts != nil {
+		c.opts = *opts
+	}
+	if c.opts.Commitment == "" {
+		c.opts.Commitment = rpc.CommitmentConfirmed
+	}
+	if c.opts.RefreshInterval <= 0 {
+		c.opts.RefreshInterval = DefaultBlockhashRefreshInterval
+	}
+	if c.opts.RefreshSlots == 0 {
+		c.opts.RefreshSlots = DefaultBlockhashRefreshSlots
+	}
+	return c
+}
+
+// Start fetches the first blockhash and starts refreshing it in the background
+// until ctx is done or Close is called.
+func (c *BlockhashCache) Start(ctx context.Context) error {
+	if err := c.update(ctx); err != nil {
+		return fmt.Errorf("blockhash cache: initial fetch: %w", err)
+	}
+
+	var slotSub *ws.SlotSubscription
+	if c.opts.SlotClient != nil {
+		var err error
+		slotSub, err = c.opts.SlotClient.SlotSubscribe()
+		if err != nil {
+			return fmt.Errorf("blockhash cache: slot subscribe: %w", err)
+		}
+	}
+
+	ctx, c.cancel = context.WithCancel(ctx)
+	if slotSub != nil {
+		go c.watchSlots(ctx, slotSub)
+	}
+	go c.run(ctx)
+	return nil
+}
+
+// Close stops refreshing the blockhash, and waits for the refresh
+// goroutine to exit; an in-flight refresh is canceled.
+func (c *BlockhashCache) Close() {
+	c.CloseWithContext(context.Background())
+}
+
+// CloseWithContext stops refreshing the blockhash, and waits for the
+// refresh goroutine to exit until ctx is done, in which case ctx.Err()
+// is returned.
+func (c *BlockhashCache) CloseWithContext(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get returns the most recent cached blockhash.
+func (c *BlockhashCache) Get() (*rpc.LatestBlockhashResult, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.latest == nil {
+		return nil, ErrBlockhashUnavailable
+	}
+	return c.latest, nil
+}
+
+// Slot returns the context slot at which the cached blockhash was fetched.
+func (c *BlockhashCache) Slot() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.slot
+}
+
+// Refresh requests an immediate refresh of the blockhash.
+func (c *BlockhashCache) Refresh() {
+	select {
+	case c.refresh <- struct{}{}:
+	default:
+	}
+}
+
+func (c *BlockhashCache) run(ctx context.Context) {
+	defer close(c.done)
+	ticker := time.NewTicker(c.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.refresh:
+		}
+		if err := c.update(ctx); err != nil && ctx.Err() == nil {
+			zlog.Warn("unable to refresh blockhash", zap.Error(err))
+		}
+	}
+}
+
+func (c *BlockhashCache) watchSlots(ctx context.Context, sub *ws.SlotSubscription) {
+	defer sub.Unsubscribe()
+	var last uint64
+	for {
+		got, err := sub.RecvWithContext(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				zlog.Warn("blockhash cache slot subscription ended", zap.Error(err))
+			}
+			return
+		}
+		if got.Slot >= last+c.opts.RefreshSlots {
+			last = got.Slot
+			c.Refresh()
+		}
+	}
+}
+
+func (c *BlockhashCache) update(ctx context.Context) error {
+	out, err := c.client.GetLatestBlockhash(ctx, c.opts.Commitment)
+	if err != nil {
+		return err
+	}
+	if out == nil || out.Value == nil {
+		return ErrBlockhashUnavailable
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	// Never go back to an older blockhash.
+	if c.latest != nil && out.Context.Slot < c.slot {
+		return nil
+	}
+	c.latest = out.Value
+	c.slot = out.Context.Slot
+	return nil
+}
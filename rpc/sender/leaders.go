@@ -0,0 +1,365 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.uber.org/zap"
+)
+
+var DefaultLeaderRefreshInterval = 5 * time.Minute
+
+// maxLeaderLookahead is the number of slots, from the current one,
+// within which LeaderTracker.Leaders looks for upcoming leaders.
+const maxLeaderLookahead = 4096
+
+type LeaderTrackerOpts struct {
+	// Commitment used to fetch the initial slot and the leader schedules.
+	// Defaults to rpc.CommitmentProcessed.
+	Commitment rpc.CommitmentType
+
+	// RefreshInterval is the period at which the cluster nodes
+	// and the leader schedules are fetched again.
+	// Defaults to DefaultLeaderRefreshInterval.
+	RefreshInterval time.Duration
+
+	// SlotClient, if set, is used to subscribe to slot notifications
+	// that advance the current slot of the tracker; otherwise,
+	// the slots must be provided with Update.
+	//
+	// This parameter is optional.
+	SlotClient *ws.Client
+}
+
+// Leader is an upcoming leader.
+type Leader struct {
+	// Identity of the validator.
+	Identity solana.PublicKey
+
+	// Slot is the first upcoming slot of the leader, and Slots the number
+	// of consecutive slots it is the leader of from Slot on.
+	Slot  uint64
+	Slots uint64
+
+	// TPU and TPUQUIC are the addresses of the validator reported by
+	// getClusterNodes; they are empty if the validator doesn't advertise them.
+	TPU     string
+	TPUQUIC string
+}
+
+// LeaderTracker follows the current slot, and predicts the upcoming leaders
+// and their TPU addresses from the leader schedules and the cluster nodes,
+// so that transactions can be sent directly to them.
+type LeaderTracker struct {
+	client *rpc.Client
+	opts   LeaderTrackerOpts
+	epochs *rpc.GetEpochScheduleResult
+
+	lock      sync.RWMutex
+	slot      uint64
+	schedules map[uint64]*rpc.LeaderSchedule // by epoch
+	nodes     map[solana.PublicKey]*rpc.GetClusterNodesResult
+
+	refresh chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewLeaderTracker creates a new LeaderTracker; call Start to begin tracking.
+func NewLeaderTracker(client *rpc.Client, opts *LeaderTrackerOpts) *LeaderTracker {
+	t := &LeaderTracker{
+		client:    client,
+		schedules: map[uint64]*rpc.LeaderSchedule{},
+		nodes:     map[solana.PublicKey]*rpc.GetClusterNodesResult{},
+		refresh:   make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	if opts != nil {
+		t.opts = *opts
+	}
+	if t.opts.Commitment == "" {
+		t.opts.Commitment = rpc.CommitmentProcessed
+	}
+	if t.opts.RefreshInterval <= 0 {
+		t.opts.RefreshInterval = DefaultLeaderRefreshInterval
+	}
+	return t
+}
+
+// Start fetches the epoch schedule, the current slot, its leader schedule
+// and the cluster nodes, and starts refreshing them in the background
+// until ctx is done or Close is called.
+func (t *LeaderTracker) Start(ctx context.Context) error {
+	epochs, err := t.client.GetEpochSchedule(ctx)
+	if err != nil {
+		return fmt.Errorf("leader tracker: get epoch schedule: %w", err)
+	}
+	t.epochs = epochs
+	slot, err := t.client.GetSlot(ctx, t.opts.Commitment)
+	if err != nil {
+		return fmt.Errorf("leader tracker: get slot: %w", err)
+	}
+	t.lock.Lock()
+	t.slot = slot
+	t.lock.Unlock()
+	if err := t.updateSchedules(ctx); err != nil {
+		return fmt.Errorf("leader tracker: initial fetch: %w", err)
+	}
+	if err := t.updateNodes(ctx); err != nil {
+		return fmt.Errorf("leader tracker: initial fetch: %w", err)
+	}
+
+	var slotSub *ws.SlotSubscription
+	if t.opts.SlotClient != nil {
+		slotSub, err = t.opts.SlotClient.SlotSubscribe()
+		if err != nil {
+			return fmt.Errorf("leader tracker: slot subscribe: %w", err)
+		}
+	}
+
+	ctx, t.cancel = context.WithCancel(ctx)
+	if slotSub != nil {
+		go t.watchSlots(ctx, slotSub)
+	}
+	go t.run(ctx)
+	return nil
+}
+
+// Close stops tracking, and waits for the refresh goroutine to exit;
+// an in-flight refresh is canceled.
+func (t *LeaderTracker) Close() {
+	t.CloseWithContext(context.Background())
+}
+
+// CloseWithContext stops tracking, and waits for the refresh goroutine
+// to exit until ctx is done, in which case ctx.Err() is returned.
+func (t *LeaderTracker) CloseWithContext(ctx context.Context) error {
+	if t.cancel == nil {
+		return nil
+	}
+	t.cancel()
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Update advances the current slot; older slots are ignored.
+// Reaching an epoch whose leader schedule is unknown triggers a refresh.
+func (t *LeaderTracker) Update(slot uint64) {
+	t.lock.Lock()
+	if slot <= t.slot {
+		t.lock.Unlock()
+		return
+	}
+	t.slot = slot
+	var missing bool
+	if t.epochs != nil {
+		epoch, _ := t.epochs.GetEpoch(slot)
+		_, known := t.schedules[epoch]
+		missing = !known
+	}
+	t.lock.Unlock()
+	if missing {
+		t.Refresh()
+	}
+}
+
+// Slot returns the current slot.
+func (t *LeaderTracker) Slot() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.slot
+}
+
+// Refresh requests an immediate refresh of the leader schedules.
+func (t *LeaderTracker) Refresh() {
+	select {
+	case t.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Leader returns the leader of the slot, and false if its leader schedule
+// is unknown.
+func (t *LeaderTracker) Leader(slot uint64) (Leader, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	identity, ok := t.leaderOf(slot)
+	if !ok {
+		return Leader{}, false
+	}
+	leader := t.leader(identity, slot)
+	for next := slot + 1; ; next++ {
+		if id, ok := t.leaderOf(next); !ok || id != identity {
+			break
+		}
+		leader.Slots++
+	}
+	return leader, true
+}
+
+// Leaders returns up to n distinct upcoming leaders, from the current slot
+// on, in the order of their first upcoming slot. Fewer leaders are returned
+// if the leader schedule of the following slots is unknown.
+func (t *LeaderTracker) Leaders(n int) []Leader {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	var leaders []Leader
+	seen := map[solana.PublicKey]int{} // index in leaders
+	for slot := t.slot; slot < t.slot+maxLeaderLookahead; slot++ {
+		identity, ok := t.leaderOf(slot)
+		if !ok {
+			break
+		}
+		if i, ok := seen[identity]; ok {
+			// Only the first window of a leader is reported.
+			if leaders[i].Slot+leaders[i].Slots == slot {
+				leaders[i].Slots++
+			}
+			continue
+		}
+		if len(leaders) == n {
+			break
+		}
+		seen[identity] = len(leaders)
+		leaders = append(leaders, t.leader(identity, slot))
+	}
+	return leaders
+}
+
+// leaderOf returns the leader of the slot. The lock must be held.
+func (t *LeaderTracker) leaderOf(slot uint64) (solana.PublicKey, bool) {
+	if t.epochs == nil {
+		return solana.PublicKey{}, false
+	}
+	epoch, _ := t.epochs.GetEpoch(slot)
+	schedule, ok := t.schedules[epoch]
+	if !ok {
+		return solana.PublicKey{}, false
+	}
+	return schedule.Leader(slot)
+}
+
+// leader returns the Leader of the identity, starting at slot,
+// with the addresses of its node. The lock must be held.
+func (t *LeaderTracker) leader(identity solana.PublicKey, slot uint64) Leader {
+	leader := Leader{Identity: identity, Slot: slot, Slots: 1}
+	if node, ok := t.nodes[identity]; ok {
+		if node.TPU != nil {
+			leader.TPU = *node.TPU
+		}
+		if node.TPUQUIC != nil {
+			leader.TPUQUIC = *node.TPUQUIC
+		}
+	}
+	return leader
+}
+
+func (t *LeaderTracker) run(ctx context.Context) {
+	defer close(t.done)
+	ticker := time.NewTicker(t.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.updateNodes(ctx); err != nil && ctx.Err() == nil {
+				zlog.Warn("unable to refresh cluster nodes", zap.Error(err))
+			}
+		case <-t.refresh:
+		}
+		if err := t.updateSchedules(ctx); err != nil && ctx.Err() == nil {
+			zlog.Warn("unable to refresh leader schedule", zap.Error(err))
+		}
+	}
+}
+
+func (t *LeaderTracker) watchSlots(ctx context.Context, sub *ws.SlotSubscription) {
+	defer sub.Unsubscribe()
+	for {
+		got, err := sub.RecvWithContext(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				zlog.Warn("leader tracker slot subscription ended", zap.Error(err))
+			}
+			return
+		}
+		t.Update(got.Slot)
+	}
+}
+
+// updateSchedules fetches the missing leader schedules of the current
+// and the next epoch, and forgets those of the past epochs.
+// The schedule of the next epoch may not be available yet:
+// only a failure to fetch the current one is returned.
+func (t *LeaderTracker) updateSchedules(ctx context.Context) error {
+	epoch, _ := t.epochs.GetEpoch(t.Slot())
+	for _, e := range []uint64{epoch, epoch + 1} {
+		t.lock.RLock()
+		_, known := t.schedules[e]
+		t.lock.RUnlock()
+		if known {
+			continue
+		}
+		schedule, err := t.client.GetEpochLeaderSchedule(ctx, t.epochs, e, t.opts.Commitment)
+		if err != nil {
+			if e == epoch {
+				return err
+			}
+			zlog.Debug("leader schedule of next epoch unavailable", zap.Uint64("epoch", e), zap.Error(err))
+			continue
+		}
+		t.lock.Lock()
+		t.schedules[e] = schedule
+		t.lock.Unlock()
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for e := range t.schedules {
+		if e < epoch {
+			delete(t.schedules, e)
+		}
+	}
+	return nil
+}
+
+func (t *LeaderTracker) updateNodes(ctx context.Context) error {
+	nodes, err := t.client.GetClusterNodes(ctx)
+	if err != nil {
+		return err
+	}
+	byIdentity := make(map[solana.PublicKey]*rpc.GetClusterNodesResult, len(nodes))
+	for _, node := range nodes {
+		byIdentity[node.Pubkey] = node
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.nodes = byIdentity
+	return nil
+}
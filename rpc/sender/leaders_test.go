@@ -0,0 +1,79 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderTracker(t *testing.T) {
+	a, b, c := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	tpu, quic := "10.0.0.1:8003", "10.0.0.1:8009"
+
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getEpochSchedule", map[string]interface{}{
+		"slotsPerEpoch": 8, "leaderScheduleSlotOffset": 8, "warmup": false, "firstNormalEpoch": 0, "firstNormalSlot": 0,
+	})
+	server.Handle("getSlot", 13)
+	server.HandleFunc("getLeaderSchedule", func(params stdjson.RawMessage) (interface{}, error) {
+		var in []stdjson.RawMessage
+		require.NoError(t, stdjson.Unmarshal(params, &in))
+		switch string(in[0]) {
+		case "8": // epoch 1
+			return rpc.GetLeaderScheduleResult{a: {0, 1, 2, 3}, b: {4, 5, 6, 7}}, nil
+		case "16": // epoch 2
+			return rpc.GetLeaderScheduleResult{a: {0, 1}, c: {2, 3, 4, 5, 6, 7}}, nil
+		}
+		return nil, nil
+	})
+	server.Handle("getClusterNodes", []*rpc.GetClusterNodesResult{
+		{Pubkey: a},
+		{Pubkey: b, TPU: &tpu, TPUQUIC: &quic},
+	})
+
+	tracker := NewLeaderTracker(rpc.New(server.URL()), nil)
+	require.NoError(t, tracker.Start(context.Background()))
+	defer tracker.Close()
+	require.Equal(t, uint64(13), tracker.Slot())
+
+	// From slot 13: b until 15, then a and c in epoch 2.
+	require.Equal(t, []Leader{
+		{Identity: b, Slot: 13, Slots: 3, TPU: tpu, TPUQUIC: quic},
+		{Identity: a, Slot: 16, Slots: 2},
+	}, tracker.Leaders(2))
+	leaders := tracker.Leaders(10)
+	require.Len(t, leaders, 3)
+	require.Equal(t, Leader{Identity: c, Slot: 18, Slots: 6}, leaders[2])
+
+	leader, ok := tracker.Leader(8)
+	require.True(t, ok)
+	require.Equal(t, Leader{Identity: a, Slot: 8, Slots: 4}, leader)
+
+	// Older slots are ignored; the schedule of epoch 3 is unknown.
+	tracker.Update(10)
+	require.Equal(t, uint64(13), tracker.Slot())
+	tracker.Update(23)
+	require.Equal(t, []Leader{{Identity: c, Slot: 23, Slots: 1}}, tracker.Leaders(5))
+	_, ok = tracker.Leader(24)
+	require.False(t, ok)
+}
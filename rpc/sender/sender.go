@@ -0,0 +1,332 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/priorityfee"
+)
+
+var ErrBlockhashExpired = errors.New("transaction blockhash expired before confirmation")
+
+var DefaultStatusPollInterval = 1 * time.Second
+
+// DefaultTrackingTimeout is the default of Opts.TrackingTimeout.
+var DefaultTrackingTimeout = 2 * time.Minute
+
+type Opts struct {
+	// TransactionOpts are used for the initial send and for every rebroadcast.
+	TransactionOpts rpc.TransactionOpts
+
+	// Endpoints to which every transaction is also sent, simultaneously
+	// with the client of the Sender. The send succeeds as soon as one
+	// endpoint accepted the transaction.
+	//
+	// This parameter is optional.
+	Endpoints []Endpoint
+
+	// RebroadcastInterval is the period at which pending transactions
+	// are sent again until confirmed or expired.
+	// Zero disables rebroadcasting.
+	RebroadcastInterval time.Duration
+
+	// StatusPollInterval is the period at which the status of pending
+	// transactions is checked when rebroadcasting is disabled.
+	// Defaults to DefaultStatusPollInterval.
+	StatusPollInterval time.Duration
+
+	// Commitment a transaction must reach to be considered confirmed.
+	// Defaults to rpc.CommitmentConfirmed.
+	Commitment rpc.CommitmentType
+
+	// TrackingTimeout bounds the tracking of each sent transaction,
+	// which then finishes with context.DeadlineExceeded.
+	// Defaults to DefaultTrackingTimeout.
+	TrackingTimeout time.Duration
+}
+
+// Priority configures the compute budget instructions
+// prepended to the transaction (see priorityfee.Priority).
+type Priority = priorityfee.Priority
+
+// PriceEstimator recommends a compute unit price, in micro-lamports
+// (see priorityfee.PriceEstimator).
+type PriceEstimator = priorityfee.PriceEstimator
+
+// Sender assigns cached blockhashes to transactions at send time
+// and tracks them until they are confirmed or expired,
+// optionally rebroadcasting them.
+//
+// Sending a transaction that is already being tracked doesn't send it
+// again, and returns the existing PendingTransaction.
+type Sender struct {
+	client      *rpc.Client
+	blockhashes *BlockhashCache
+	opts        Opts
+
+	lock    sync.Mutex
+	pending map[solana.Signature]*PendingTransaction
+
+	// ctx bounds the tracking of the transactions (see Close).
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New creates a new Sender; the provided BlockhashCache must be started.
+func New(client *rpc.Client, blockhashes *BlockhashCache, opts *Opts) *Sender {
+	s := &Sender{
+		client:      client,
+		blockhashes: blockhashes,
+		pending:     make(map[solana.Signature]*PendingTransaction),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.StatusPollInterval <= 0 {
+		s.opts.StatusPollInterval = DefaultStatusPollInterval
+	}
+	if s.opts.Commitment == "" {
+		s.opts.Commitment = rpc.CommitmentConfirmed
+	}
+	if s.opts.TrackingTimeout <= 0 {
+		s.opts.TrackingTimeout = DefaultTrackingTimeout
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+// Close stops tracking the pending transactions,
+// which finish with context.Canceled.
+func (s *Sender) Close() {
+	s.cancel()
+}
+
+// Send builds a transaction from the provided instructions, prepending
+// the compute budget instructions required by the priority (if any)
+// in place of the ones among instructions,
+// assigns it the cached blockhash, signs it with the signers and sends it.
+//
+// The ctx bounds the estimation of the priority and the initial send;
+// the returned PendingTransaction is tracked until Opts.TrackingTimeout
+// or Close.
+func (s *Sender) Send(
+	ctx context.Context,
+	instructions []solana.Instruction,
+	payer solana.PublicKey,
+	signers []solana.Signer,
+	priority *Priority, // optional
+) (*PendingTransaction, error) {
+	if priority != nil {
+		var err error
+		instructions, err = priority.Apply(ctx, instructions)
+		if err != nil {
+			return nil, fmt.Errorf("send: %w", err)
+		}
+	}
+
+	latest, err := s.blockhashes.Get()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := solana.NewTransaction(instructions, latest.Blockhash, solana.TransactionPayer(payer))
+	if err != nil {
+		return nil, fmt.Errorf("send: build transaction: %w", err)
+	}
+	return s.send(ctx, tx, latest, signers)
+}
+
+// SendTransaction assigns the cached blockhash to the provided transaction,
+// signs it with the signers and sends it. Any existing signatures are replaced.
+//
+// The ctx bounds the initial send; the returned PendingTransaction
+// is tracked until Opts.TrackingTimeout or Close.
+func (s *Sender) SendTransaction(
+	ctx context.Context,
+	tx *solana.Transaction,
+	signers []solana.Signer,
+) (*PendingTransaction, error) {
+	latest, err := s.blockhashes.Get()
+	if err != nil {
+		return nil, err
+	}
+	tx.Message.RecentBlockhash = latest.Blockhash
+	tx.Signatures = nil
+	return s.send(ctx, tx, latest, signers)
+}
+
+func (s *Sender) send(
+	ctx context.Context,
+	tx *solana.Transaction,
+	latest *rpc.LatestBlockhashResult,
+	signers []solana.Signer,
+) (*PendingTransaction, error) {
+	if _, err := tx.SignWith(signers...); err != nil {
+		return nil, fmt.Errorf("send: sign transaction: %w", err)
+	}
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("send: encode transaction: %w", err)
+	}
+
+	pending, existing := s.inflight(&PendingTransaction{
+		Signature:            tx.Signatures[0],
+		LastValidBlockHeight: latest.LastValidBlockHeight,
+		done:                 make(chan struct{}),
+	})
+	if existing {
+		return pending, nil
+	}
+	if err := s.broadcast(ctx, pending, rawTx); err != nil {
+		pending.finish(nil, err)
+		s.forget(pending.Signature)
+		return nil, err
+	}
+	// The tracking outlives the ctx of the caller.
+	trackCtx, cancel := context.WithTimeout(s.ctx, s.opts.TrackingTimeout)
+	go func() {
+		defer cancel()
+		s.track(trackCtx, pending, rawTx)
+		s.forget(pending.Signature)
+	}()
+	return pending, nil
+}
+
+func (s *Sender) track(ctx context.Context, pending *PendingTransaction, rawTx []byte) {
+	interval := s.opts.StatusPollInterval
+	if s.opts.RebroadcastInterval > 0 {
+		interval = s.opts.RebroadcastInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			pending.finish(nil, ctx.Err())
+			return
+		case <-ticker.C:
+		}
+
+		statuses, err := s.client.GetSignatureStatuses(ctx, false, pending.Signature)
+		if err == nil && len(statuses.Value) > 0 && statuses.Value[0] != nil {
+			status := statuses.Value[0]
+			if status.Err != nil {
+				pending.finish(status, fmt.Errorf("transaction %s failed: %v", pending.Signature, status.Err))
+				return
+			}
+			if reachedCommitment(status.ConfirmationStatus, s.opts.Commitment) {
+				pending.finish(status, nil)
+				return
+			}
+		}
+
+		height, err := s.client.GetBlockHeight(ctx, s.opts.Commitment)
+		if err == nil && height > pending.LastValidBlockHeight {
+			pending.finish(nil, ErrBlockhashExpired)
+			return
+		}
+
+		if s.opts.RebroadcastInterval > 0 {
+			s.rebroadcast(ctx, pending, rawTx)
+		}
+	}
+}
+
+// reachedCommitment reports whether the provided confirmation status
+// satisfies the commitment.
+func reachedCommitment(status rpc.ConfirmationStatusType, commitment rpc.CommitmentType) bool {
+	switch status {
+	case rpc.ConfirmationStatusFinalized:
+		return true
+	case rpc.ConfirmationStatusConfirmed:
+		return commitment != rpc.CommitmentFinalized
+	case rpc.ConfirmationStatusProcessed:
+		return commitment == rpc.CommitmentProcessed
+	}
+	return false
+}
+
+// PendingTransaction is a transaction that was sent and is being
+// tracked until it is confirmed or its blockhash expires.
+type PendingTransaction struct {
+	Signature            solana.Signature
+	LastValidBlockHeight uint64
+
+	done   chan struct{}
+	status *rpc.SignatureStatusesResult
+	err    error
+
+	lock          sync.Mutex
+	firstAccepted string
+	broadcasts    []EndpointResult
+}
+
+func (p *PendingTransaction) record(res EndpointResult) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if res.Err == nil && p.firstAccepted == "" {
+		p.firstAccepted = res.Endpoint
+	}
+	p.broadcasts = append(p.broadcasts, res)
+}
+
+// FirstAccepted returns the name of the endpoint that accepted the transaction
+// first (PrimaryEndpoint for the client of the Sender).
+// Which endpoint the transaction actually landed through cannot be observed;
+// the fastest acceptance is the closest proxy.
+func (p *PendingTransaction) FirstAccepted() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.firstAccepted
+}
+
+// Broadcasts returns the results of the initial send to each endpoint,
+// in the order they were received. The results of the slower endpoints
+// may still be missing when the transaction is returned by Send.
+func (p *PendingTransaction) Broadcasts() []EndpointResult {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]EndpointResult(nil), p.broadcasts...)
+}
+
+func (p *PendingTransaction) finish(status *rpc.SignatureStatusesResult, err error) {
+	p.status = status
+	p.err = err
+	close(p.done)
+}
+
+// Done returns a channel that is closed once the transaction
+// is confirmed, failed, or expired.
+func (p *PendingTransaction) Done() <-chan struct{} {
+	return p.done
+}
+
+// Wait waits for the transaction to be confirmed at the configured commitment.
+// It returns ErrBlockhashExpired if the transaction expired before confirmation.
+func (p *PendingTransaction) Wait(ctx context.Context) (*rpc.SignatureStatusesResult, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-p.done:
+		return p.status, p.err
+	}
+}
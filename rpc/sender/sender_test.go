@@ -0,0 +1,346 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+type mockNode struct {
+	lock      sync.Mutex
+	blockhash solana.Hash
+	sent      []*solana.Transaction
+	statusAt  int // number of status checks before the transaction is confirmed
+	checks    int
+}
+
+func (m *mockNode) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var in struct {
+		ID     any                  `json:"id"`
+		Method string               `json:"method"`
+		Params []stdjson.RawMessage `json:"params"`
+	}
+	if err := stdjson.NewDecoder(req.Body).Decode(&in); err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var result string
+	switch in.Method {
+	case "getLatestBlockhash":
+		result = fmt.Sprintf(`{"context":{"slot":10},"value":{"blockhash":%q,"lastValidBlockHeight":200}}`, m.blockhash)
+	case "sendTransaction":
+		var encoded string
+		stdjson.Unmarshal(in.Params[0], &encoded)
+		raw, _ := base64.StdEncoding.DecodeString(encoded)
+		tx, err := solana.TransactionFromBytes(raw)
+		if err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.sent = append(m.sent, tx)
+		result = fmt.Sprintf("%q", tx.Signatures[0])
+	case "getSignatureStatuses":
+		m.checks++
+		if m.checks < m.statusAt {
+			result = `{"context":{"slot":11},"value":[null]}`
+		} else {
+			result = `{"context":{"slot":12},"value":[{"slot":12,"confirmations":1,"err":null,"confirmationStatus":"confirmed"}]}`
+		}
+	case "getBlockHeight":
+		result = `100`
+	default:
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+	fmt.Fprintf(rw, `{"jsonrpc":"2.0","result":%s,"id":%q}`, result, fmt.Sprint(in.ID))
+}
+
+func TestSender_Send(t *testing.T) {
+	node := &mockNode{
+		blockhash: solana.MustHashFromBase58("EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N"),
+		statusAt:  3,
+	}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := rpc.New(server.URL)
+
+	cache := NewBlockhashCache(client, &BlockhashCacheOpts{RefreshInterval: time.Hour})
+	require.NoError(t, cache.Start(ctx))
+	defer cache.Close()
+
+	payer := solana.NewWallet().PrivateKey
+	sender := New(client, cache, &Opts{
+		RebroadcastInterval: 10 * time.Millisecond,
+	})
+
+	pending, err := sender.Send(
+		ctx,
+		[]solana.Instruction{
+			system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+		},
+		payer.PublicKey(),
+		[]solana.Signer{payer},
+		&Priority{ComputeUnitPrice: 1000},
+	)
+	require.NoError(t, err)
+	require.Equal(t, uint64(200), pending.LastValidBlockHeight)
+
+	status, err := pending.Wait(ctx)
+	require.NoError(t, err)
+	require.Equal(t, rpc.ConfirmationStatusConfirmed, status.ConfirmationStatus)
+
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	// Initial send plus rebroadcasts until confirmed.
+	require.Len(t, node.sent, 3)
+	for _, tx := range node.sent {
+		require.Equal(t, pending.Signature, tx.Signatures[0])
+		require.Equal(t, node.blockhash, tx.Message.RecentBlockhash)
+	}
+	programID, err := node.sent[0].ResolveProgramIDIndex(node.sent[0].Message.Instructions[0].ProgramIDIndex)
+	require.NoError(t, err)
+	require.Equal(t, computebudget.ProgramID, programID)
+}
+
+type estimatorFunc func(ctx context.Context, writable solana.PublicKeySlice) (uint64, error)
+
+func (f estimatorFunc) RecommendedPrice(ctx context.Context, writable solana.PublicKeySlice) (uint64, error) {
+	return f(ctx, writable)
+}
+
+func TestSender_Send_estimatedPrice(t *testing.T) {
+	node := &mockNode{
+		blockhash: solana.MustHashFromBase58("EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N"),
+	}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := rpc.New(server.URL)
+
+	cache := NewBlockhashCache(client, &BlockhashCacheOpts{RefreshInterval: time.Hour})
+	require.NoError(t, cache.Start(ctx))
+	defer cache.Close()
+
+	payer := solana.NewWallet().PrivateKey
+	recipient := solana.NewWallet().PublicKey()
+	var writable solana.PublicKeySlice
+	pending, err := New(client, cache, nil).Send(
+		ctx,
+		[]solana.Instruction{
+			system.NewTransferInstruction(1, payer.PublicKey(), recipient).Build(),
+		},
+		payer.PublicKey(),
+		[]solana.Signer{payer},
+		&Priority{
+			Estimator: estimatorFunc(func(ctx context.Context, accounts solana.PublicKeySlice) (uint64, error) {
+				writable = accounts
+				return 4242, nil
+			}),
+		},
+	)
+	require.NoError(t, err)
+	_, err = pending.Wait(ctx)
+	require.NoError(t, err)
+
+	require.Equal(t, solana.PublicKeySlice{payer.PublicKey(), recipient}, writable)
+
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	require.Len(t, node.sent, 1)
+	data := node.sent[0].Message.Instructions[0].Data
+	// SetComputeUnitPrice: discriminator then the price.
+	require.Equal(t, byte(computebudget.Instruction_SetComputeUnitPrice), data[0])
+	require.Equal(t, uint64(4242), binary.LittleEndian.Uint64(data[1:]))
+}
+
+func TestSender_Send_tracking(t *testing.T) {
+	node := &mockNode{
+		blockhash: solana.MustHashFromBase58("EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N"),
+		statusAt:  3,
+	}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	client := rpc.New(server.URL)
+	cache := NewBlockhashCache(client, &BlockhashCacheOpts{RefreshInterval: time.Hour})
+	require.NoError(t, cache.Start(context.Background()))
+	defer cache.Close()
+
+	payer := solana.NewWallet().PrivateKey
+	send := func(sender *Sender) *PendingTransaction {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		pending, err := sender.Send(
+			ctx,
+			[]solana.Instruction{
+				system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+			},
+			payer.PublicKey(),
+			[]solana.Signer{payer},
+			nil,
+		)
+		require.NoError(t, err)
+		return pending
+	}
+
+	// The tracking outlives the ctx of Send.
+	sender := New(client, cache, &Opts{StatusPollInterval: 10 * time.Millisecond})
+	defer sender.Close()
+	status, err := send(sender).Wait(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, rpc.ConfirmationStatusConfirmed, status.ConfirmationStatus)
+
+	// It is bounded by the tracking timeout.
+	node.lock.Lock()
+	node.statusAt = 1 << 30
+	node.lock.Unlock()
+	sender = New(client, cache, &Opts{StatusPollInterval: 10 * time.Millisecond, TrackingTimeout: 50 * time.Millisecond})
+	_, err = send(sender).Wait(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// And by Close.
+	pending := send(sender)
+	sender.Close()
+	_, err = pending.Wait(context.Background())
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReachedCommitment(t *testing.T) {
+	require.True(t, reachedCommitment(rpc.ConfirmationStatusFinalized, rpc.CommitmentFinalized))
+	require.True(t, reachedCommitment(rpc.ConfirmationStatusConfirmed, rpc.CommitmentConfirmed))
+	require.False(t, reachedCommitment(rpc.ConfirmationStatusConfirmed, rpc.CommitmentFinalized))
+	require.True(t, reachedCommitment(rpc.ConfirmationStatusProcessed, rpc.CommitmentProcessed))
+	require.False(t, reachedCommitment(rpc.ConfirmationStatusProcessed, rpc.CommitmentConfirmed))
+	require.False(t, reachedCommitment("", rpc.CommitmentProcessed))
+}
+
+func TestSender_Send_endpoints(t *testing.T) {
+	blockhash := solana.MustHashFromBase58("EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N")
+	primary := &mockNode{blockhash: blockhash, statusAt: 2}
+	primaryServer := httptest.NewServer(primary)
+	defer primaryServer.Close()
+	relay := &mockNode{blockhash: blockhash}
+	relayServer := httptest.NewServer(relay)
+	defer relayServer.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	ctx := context.Background()
+	client := rpc.New(primaryServer.URL)
+
+	cache := NewBlockhashCache(client, &BlockhashCacheOpts{RefreshInterval: time.Hour})
+	require.NoError(t, cache.Start(ctx))
+	defer cache.Close()
+
+	sender := New(client, cache, &Opts{
+		Endpoints: []Endpoint{
+			{Name: "relay", Client: rpc.New(relayServer.URL), TransactionOpts: &rpc.TransactionOpts{SkipPreflight: true}},
+			{Name: "failing", Client: rpc.New(failing.URL)},
+		},
+	})
+
+	payer := solana.NewWallet().PrivateKey
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+		},
+		blockhash,
+		solana.TransactionPayer(payer.PublicKey()),
+	)
+	require.NoError(t, err)
+
+	pending, err := sender.SendTransaction(ctx, tx, []solana.Signer{payer})
+	require.NoError(t, err)
+	require.Contains(t, []string{PrimaryEndpoint, "relay"}, pending.FirstAccepted())
+
+	// The same transaction is not sent again while it is tracked.
+	again, err := sender.SendTransaction(ctx, tx, []solana.Signer{payer})
+	require.NoError(t, err)
+	require.Same(t, pending, again)
+
+	_, err = pending.Wait(ctx)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(pending.Broadcasts()) == 3 }, time.Second, 10*time.Millisecond)
+	for _, res := range pending.Broadcasts() {
+		if res.Endpoint == "failing" {
+			require.Error(t, res.Err)
+		} else {
+			require.NoError(t, res.Err)
+		}
+	}
+
+	for _, node := range []*mockNode{primary, relay} {
+		node.lock.Lock()
+		require.Len(t, node.sent, 1)
+		require.Equal(t, pending.Signature, node.sent[0].Signatures[0])
+		node.lock.Unlock()
+	}
+}
+
+func TestSender_Send_allEndpointsFail(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	node := &mockNode{blockhash: solana.MustHashFromBase58("EkSnNWid2cvwEVnVx9aBqawnmiCNiDgp3gUdkDPTKN1N")}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	ctx := context.Background()
+	cache := NewBlockhashCache(rpc.New(server.URL), &BlockhashCacheOpts{RefreshInterval: time.Hour})
+	require.NoError(t, cache.Start(ctx))
+	defer cache.Close()
+
+	sender := New(rpc.New(failing.URL), cache, &Opts{
+		Endpoints: []Endpoint{{Name: "relay", Client: rpc.New(failing.URL)}},
+	})
+	payer := solana.NewWallet().PrivateKey
+	_, err := sender.Send(
+		ctx,
+		[]solana.Instruction{
+			system.NewTransferInstruction(1, payer.PublicKey(), solana.NewWallet().PublicKey()).Build(),
+		},
+		payer.PublicKey(),
+		[]solana.Signer{payer},
+		nil,
+	)
+	require.Error(t, err)
+	require.Empty(t, sender.pending)
+}
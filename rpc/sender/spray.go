@@ -0,0 +1,171 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sender
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"go.uber.org/zap"
+)
+
+// HeliusSenderURL is the endpoint of the Helius Sender service, which
+// forwards transactions both to the leaders and to the Jito relayers.
+// It requires skipPreflight, and maxRetries set to zero.
+const HeliusSenderURL = "https://sender.helius-rpc.com/fast"
+
+// PrimaryEndpoint is the name under which the results of
+// the client the Sender was created with are reported.
+const PrimaryEndpoint = "primary"
+
+// Endpoint is an additional endpoint to which every transaction is sent,
+// simultaneously with the client of the Sender
+// (e.g. the Helius Sender, a Jito block engine, or a second RPC region).
+type Endpoint struct {
+	// Name identifies the endpoint in the results of the broadcasts.
+	Name string
+
+	Client *rpc.Client
+
+	// TransactionOpts replace the TransactionOpts of the Sender
+	// for this endpoint, e.g. to skip preflight on the Helius Sender.
+	//
+	// This parameter is optional.
+	TransactionOpts *rpc.TransactionOpts
+}
+
+// EndpointResult is the outcome of the initial send of a transaction
+// to one endpoint.
+type EndpointResult struct {
+	Endpoint string
+	// Latency is the time the endpoint took to answer.
+	Latency time.Duration
+	Err     error
+}
+
+// endpoints returns the client of the Sender followed by the additional endpoints.
+func (s *Sender) endpoints() []Endpoint {
+	return append([]Endpoint{{Name: PrimaryEndpoint, Client: s.client}}, s.opts.Endpoints...)
+}
+
+// sendTo sends rawTx to the endpoint, and checks that the endpoint
+// answered with the signature of the transaction.
+// Rebroadcasts always skip preflight.
+func (s *Sender) sendTo(ctx context.Context, endpoint Endpoint, rawTx []byte, sig solana.Signature, rebroadcast bool) error {
+	opts := s.opts.TransactionOpts
+	if endpoint.TransactionOpts != nil {
+		opts = *endpoint.TransactionOpts
+	}
+	if rebroadcast {
+		opts.SkipPreflight = true
+	}
+	got, err := endpoint.Client.SendRawTransactionWithOpts(ctx, rawTx, opts)
+	if err != nil {
+		return err
+	}
+	if !got.Equals(sig) {
+		return fmt.Errorf("endpoint %s returned signature %s, expected %s", endpoint.Name, got, sig)
+	}
+	return nil
+}
+
+// broadcast sends rawTx to all the endpoints simultaneously, and returns
+// once one of them accepted it, or all of them failed. The results
+// of the other endpoints are recorded on pending as they arrive.
+//
+// If all the endpoints failed, the error of the primary endpoint is returned.
+func (s *Sender) broadcast(ctx context.Context, pending *PendingTransaction, rawTx []byte) error {
+	endpoints := s.endpoints()
+	results := make(chan EndpointResult, len(endpoints))
+	for _, endpoint := range endpoints {
+		go func(endpoint Endpoint) {
+			start := time.Now()
+			err := s.sendTo(ctx, endpoint, rawTx, pending.Signature, false)
+			results <- EndpointResult{
+				Endpoint: endpoint.Name,
+				Latency:  time.Since(start),
+				Err:      err,
+			}
+		}(endpoint)
+	}
+
+	var primaryErr error
+	for i := range endpoints {
+		res := <-results
+		pending.record(res)
+		if res.Err == nil {
+			go func(remaining int) {
+				for ; remaining > 0; remaining-- {
+					pending.record(<-results)
+				}
+			}(len(endpoints) - i - 1)
+			return nil
+		}
+		if res.Endpoint == PrimaryEndpoint {
+			primaryErr = res.Err
+		}
+		zlog.Debug("endpoint rejected transaction",
+			zap.String("endpoint", res.Endpoint),
+			zap.Stringer("signature", pending.Signature),
+			zap.Error(res.Err),
+		)
+	}
+	if len(endpoints) == 1 {
+		return primaryErr
+	}
+	return fmt.Errorf("send: all %d endpoints failed, primary: %w", len(endpoints), primaryErr)
+}
+
+// rebroadcast sends rawTx again to all the endpoints simultaneously,
+// and waits for all of them to answer.
+func (s *Sender) rebroadcast(ctx context.Context, pending *PendingTransaction, rawTx []byte) {
+	var wg sync.WaitGroup
+	for _, endpoint := range s.endpoints() {
+		wg.Add(1)
+		go func(endpoint Endpoint) {
+			defer wg.Done()
+			if err := s.sendTo(ctx, endpoint, rawTx, pending.Signature, true); err != nil {
+				zlog.Debug("unable to rebroadcast transaction",
+					zap.String("endpoint", endpoint.Name),
+					zap.Stringer("signature", pending.Signature),
+					zap.Error(err),
+				)
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+// inflight returns the pending transaction with the provided signature,
+// or registers pending under it.
+func (s *Sender) inflight(pending *PendingTransaction) (existing *PendingTransaction, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if existing, ok := s.pending[pending.Signature]; ok {
+		return existing, true
+	}
+	s.pending[pending.Signature] = pending
+	return pending, false
+}
+
+func (s *Sender) forget(sig solana.Signature) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.pending, sig)
+}
@@ -43,6 +43,17 @@ type SimulateTransactionResult struct {
 
 	// The number of compute budget units consumed during the processing of this transaction.
 	UnitsConsumed *uint64 `json:"unitsConsumed,omitempty"`
+
+	// The most recent return data generated by an instruction in the transaction.
+	ReturnData *ReturnData `json:"returnData,omitempty"`
+
+	// Inner instructions of the transaction, if requested
+	// with SimulateTransactionOpts.InnerInstructions.
+	InnerInstructions []InnerInstruction `json:"innerInstructions,omitempty"`
+
+	// The blockhash used to simulate the transaction, if replaced
+	// with SimulateTransactionOpts.ReplaceRecentBlockhash.
+	ReplacementBlockhash *LatestBlockhashResult `json:"replacementBlockhash,omitempty"`
 }
 
 // SimulateTransaction simulates sending a transaction.
@@ -71,6 +82,12 @@ type SimulateTransactionOpts struct {
 	ReplaceRecentBlockhash bool
 
 	Accounts *SimulateTransactionAccountsOpts
+
+	// If true the response includes the inner instructions of the transaction.
+	InnerInstructions bool
+
+	// The minimum slot that the request can be evaluated at.
+	MinContextSlot *uint64
 }
 
 type SimulateTransactionAccountsOpts struct {
@@ -109,6 +126,9 @@ func (cl *Client) SimulateRawTransactionWithOpts(
 		"encoding": "base64",
 	}
 	if opts != nil {
+		if opts.SigVerify && opts.ReplaceRecentBlockhash {
+			return nil, fmt.Errorf("simulate transaction: SigVerify conflicts with ReplaceRecentBlockhash")
+		}
 		if opts.SigVerify {
 			obj["sigVerify"] = opts.SigVerify
 		}
@@ -124,6 +144,12 @@ func (cl *Client) SimulateRawTransactionWithOpts(
 				"addresses": opts.Accounts.Addresses,
 			}
 		}
+		if opts.InnerInstructions {
+			obj["innerInstructions"] = opts.InnerInstructions
+		}
+		if opts.MinContextSlot != nil {
+			obj["minContextSlot"] = *opts.MinContextSlot
+		}
 	}
 
 	b64Data := base64.StdEncoding.EncodeToString(txData)
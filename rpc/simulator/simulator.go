@@ -0,0 +1,233 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package simulator simulates unsigned instructions against the state
+// of the cluster, and reports the changes of the accounts they write,
+// e.g. to check the outcome of a trade before signing it.
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type Opts struct {
+	// Commitment of the state the instructions are simulated against.
+	// Defaults to the commitment of the node (finalized).
+	Commitment rpc.CommitmentType
+
+	// Decoder decodes the data of the changed accounts
+	// (see AccountChange.DecodedBefore and AccountChange.DecodedAfter).
+	//
+	// This parameter is optional.
+	Decoder AccountDecoder
+
+	// InnerInstructions includes the inner instructions in the result.
+	InnerInstructions bool
+}
+
+// AccountDecoder decodes the data of an account owned by owner.
+// It returns a nil value for the accounts it does not decode.
+type AccountDecoder func(address solana.PublicKey, owner solana.PublicKey, data []byte) (interface{}, error)
+
+// Simulator simulates unsigned instructions from a payer.
+type Simulator struct {
+	client *rpc.Client
+	opts   Opts
+}
+
+// New creates a new Simulator.
+func New(client *rpc.Client, opts *Opts) *Simulator {
+	s := &Simulator{client: client}
+	if opts != nil {
+		s.opts = *opts
+	}
+	return s
+}
+
+type Result struct {
+	// Slot at which the transaction was simulated.
+	Slot uint64
+
+	// Error if the transaction failed, nil if it succeeded.
+	Err interface{}
+
+	Logs          []string
+	UnitsConsumed uint64
+	ReturnData    *rpc.ReturnData
+	// Inner instructions of the transaction, if requested
+	// with Opts.InnerInstructions.
+	InnerInstructions []rpc.InnerInstruction
+
+	// Changes of the accounts written by the transaction (the payer included),
+	// in the order of the accounts of the message.
+	// Unchanged accounts are reported too (see AccountChange.Changed).
+	Changes []AccountChange
+}
+
+// Change returns the change of the provided account, if it is writable.
+func (r *Result) Change(address solana.PublicKey) (*AccountChange, bool) {
+	for i := range r.Changes {
+		if r.Changes[i].Address.Equals(address) {
+			return &r.Changes[i], true
+		}
+	}
+	return nil, false
+}
+
+// AccountChange is the state of an account before and after the transaction.
+type AccountChange struct {
+	Address solana.PublicKey
+	// Before and After are nil if the account doesn't exist.
+	Before *rpc.Account
+	After  *rpc.Account
+
+	LamportsDelta int64
+	DataChanged   bool
+	OwnerChanged  bool
+
+	// Data decoded with Opts.Decoder, if any.
+	DecodedBefore interface{}
+	DecodedAfter  interface{}
+}
+
+// Changed reports whether the account was modified by the transaction.
+func (c *AccountChange) Changed() bool {
+	return c.LamportsDelta != 0 || c.DataChanged || c.OwnerChanged || (c.Before == nil) != (c.After == nil)
+}
+
+// Simulate simulates a transaction with the provided instructions, paid by payer.
+// The transaction doesn't need to be signed: signatures are not verified,
+// and the recent blockhash is replaced by the node.
+func (s *Simulator) Simulate(
+	ctx context.Context,
+	payer solana.PublicKey,
+	instructions ...solana.Instruction,
+) (*Result, error) {
+	tx, err := solana.NewTransaction(instructions, solana.Hash{}, solana.TransactionPayer(payer))
+	if err != nil {
+		return nil, fmt.Errorf("simulate: build transaction: %w", err)
+	}
+	tx.Signatures = make([]solana.Signature, tx.Message.Header.NumRequiredSignatures)
+
+	writable, err := tx.Message.Writable()
+	if err != nil {
+		return nil, fmt.Errorf("simulate: %w", err)
+	}
+
+	before, err := s.client.GetMultipleAccountsWithOpts(ctx, writable, &rpc.GetMultipleAccountsOpts{
+		Encoding:   solana.EncodingBase64,
+		Commitment: s.opts.Commitment,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("simulate: get accounts: %w", err)
+	}
+	if len(before.Value) != len(writable) {
+		return nil, fmt.Errorf("simulate: got %d accounts, expected %d", len(before.Value), len(writable))
+	}
+
+	// The simulation must not see an older state than the accounts before.
+	minContextSlot := before.Context.Slot
+	simulated, err := s.client.SimulateTransactionWithOpts(ctx, tx, &rpc.SimulateTransactionOpts{
+		Commitment:             s.opts.Commitment,
+		ReplaceRecentBlockhash: true,
+		Accounts: &rpc.SimulateTransactionAccountsOpts{
+			Encoding:  solana.EncodingBase64,
+			Addresses: writable,
+		},
+		InnerInstructions: s.opts.InnerInstructions,
+		MinContextSlot:    &minContextSlot,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("simulate: %w", err)
+	}
+	if simulated.Value == nil {
+		return nil, errors.New("simulate: empty result")
+	}
+	value := simulated.Value
+
+	out := &Result{
+		Slot:              simulated.Context.Slot,
+		Err:               value.Err,
+		Logs:              value.Logs,
+		ReturnData:        value.ReturnData,
+		InnerInstructions: value.InnerInstructions,
+	}
+	if value.UnitsConsumed != nil {
+		out.UnitsConsumed = *value.UnitsConsumed
+	}
+	// The accounts are not returned when the transaction fails.
+	if value.Err != nil || len(value.Accounts) == 0 {
+		return out, nil
+	}
+	if len(value.Accounts) != len(writable) {
+		return nil, fmt.Errorf("simulate: got %d simulated accounts, expected %d", len(value.Accounts), len(writable))
+	}
+
+	out.Changes = make([]AccountChange, len(writable))
+	for i, address := range writable {
+		change, err := s.newAccountChange(address, before.Value[i], value.Accounts[i])
+		if err != nil {
+			return nil, fmt.Errorf("simulate: %s: %w", address, err)
+		}
+		out.Changes[i] = *change
+	}
+	return out, nil
+}
+
+func (s *Simulator) newAccountChange(address solana.PublicKey, before, after *rpc.Account) (*AccountChange, error) {
+	change := &AccountChange{
+		Address: address,
+		Before:  before,
+		After:   after,
+	}
+	beforeData, err := before.Binary()
+	if err != nil {
+		return nil, err
+	}
+	afterData, err := after.Binary()
+	if err != nil {
+		return nil, err
+	}
+	var beforeLamports, afterLamports uint64
+	var beforeOwner, afterOwner solana.PublicKey
+	if before != nil {
+		beforeLamports, beforeOwner = before.Lamports, before.Owner
+	}
+	if after != nil {
+		afterLamports, afterOwner = after.Lamports, after.Owner
+	}
+	change.LamportsDelta = int64(afterLamports) - int64(beforeLamports)
+	change.DataChanged = string(beforeData) != string(afterData)
+	change.OwnerChanged = before != nil && after != nil && !beforeOwner.Equals(afterOwner)
+
+	if s.opts.Decoder == nil {
+		return change, nil
+	}
+	if before != nil {
+		if change.DecodedBefore, err = s.opts.Decoder(address, beforeOwner, beforeData); err != nil {
+			return nil, fmt.Errorf("decode state before: %w", err)
+		}
+	}
+	if after != nil {
+		if change.DecodedAfter, err = s.opts.Decoder(address, afterOwner, afterData); err != nil {
+			return nil, fmt.Errorf("decode state after: %w", err)
+		}
+	}
+	return change, nil
+}
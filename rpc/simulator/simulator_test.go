@@ -0,0 +1,145 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func account(lamports uint64, owner solana.PublicKey, data []byte) rpc.M {
+	return rpc.M{
+		"lamports":   lamports,
+		"owner":      owner.String(),
+		"data":       []string{base64.StdEncoding.EncodeToString(data), "base64"},
+		"executable": false,
+		"rentEpoch":  0,
+	}
+}
+
+func TestSimulator_Simulate(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	recipient := solana.NewWallet().PublicKey()
+	counterProgram := solana.NewWallet().PublicKey()
+
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getMultipleAccounts", rpc.M{
+		"context": rpc.M{"slot": 100},
+		"value": []interface{}{
+			account(10_000, solana.SystemProgramID, nil),
+			nil,
+		},
+	})
+	server.Handle("simulateTransaction", rpc.M{
+		"context": rpc.M{"slot": 101},
+		"value": rpc.M{
+			"err":           nil,
+			"logs":          []string{"Program 11111111111111111111111111111111 invoke [1]"},
+			"unitsConsumed": 150,
+			"accounts": []interface{}{
+				account(8_995, solana.SystemProgramID, nil),
+				account(1_000, counterProgram, []byte{7, 0, 0, 0}),
+			},
+		},
+	})
+
+	sim := New(rpc.New(server.URL()), &Opts{
+		Commitment: rpc.CommitmentConfirmed,
+		Decoder: func(address, owner solana.PublicKey, data []byte) (interface{}, error) {
+			if !owner.Equals(counterProgram) {
+				return nil, nil
+			}
+			return binary.LittleEndian.Uint32(data), nil
+		},
+	})
+	out, err := sim.Simulate(context.Background(), payer, system.NewTransferInstruction(1_000, payer, recipient).Build())
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(101), out.Slot)
+	require.Nil(t, out.Err)
+	require.Equal(t, uint64(150), out.UnitsConsumed)
+	require.Len(t, out.Changes, 2)
+
+	change, ok := out.Change(payer)
+	require.True(t, ok)
+	require.Equal(t, int64(-1_005), change.LamportsDelta)
+	require.False(t, change.DataChanged)
+	require.Nil(t, change.DecodedAfter)
+	require.True(t, change.Changed())
+
+	change, ok = out.Change(recipient)
+	require.True(t, ok)
+	require.Nil(t, change.Before)
+	require.Equal(t, int64(1_000), change.LamportsDelta)
+	require.True(t, change.DataChanged)
+	require.Equal(t, uint32(7), change.DecodedAfter)
+
+	_, ok = out.Change(counterProgram)
+	require.False(t, ok)
+
+	requests := server.Requests("simulateTransaction")
+	require.Len(t, requests, 1)
+	var params []stdjson.RawMessage
+	require.NoError(t, stdjson.Unmarshal(requests[0].Params, &params))
+	var config map[string]interface{}
+	require.NoError(t, stdjson.Unmarshal(params[1], &config))
+	require.Equal(t, true, config["replaceRecentBlockhash"])
+	require.Equal(t, "confirmed", config["commitment"])
+	require.Equal(t, float64(100), config["minContextSlot"])
+	require.Equal(t, []interface{}{payer.String(), recipient.String()}, config["accounts"].(map[string]interface{})["addresses"])
+
+	var encoded string
+	require.NoError(t, stdjson.Unmarshal(params[0], &encoded))
+	tx, err := solana.TransactionFromBase64(encoded)
+	require.NoError(t, err)
+	require.Len(t, tx.Signatures, 1)
+}
+
+func TestSimulator_Simulate_failed(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getMultipleAccounts", rpc.M{
+		"context": rpc.M{"slot": 100},
+		"value":   []interface{}{nil, nil},
+	})
+	server.Handle("simulateTransaction", rpc.M{
+		"context": rpc.M{"slot": 101},
+		"value": rpc.M{
+			"err":      "AccountNotFound",
+			"accounts": nil,
+		},
+	})
+
+	out, err := New(rpc.New(server.URL()), nil).Simulate(
+		context.Background(),
+		payer,
+		system.NewTransferInstruction(1, payer, solana.NewWallet().PublicKey()).Build(),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "AccountNotFound", out.Err)
+	require.Empty(t, out.Changes)
+}
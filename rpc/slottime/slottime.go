@@ -0,0 +1,299 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slottime estimates the progression of slots in wall-clock time,
+// to convert between slots and approximate timestamps, and to compute
+// the wall-clock deadline of a blockhash.
+package slottime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.uber.org/zap"
+)
+
+var (
+	// DefaultSlotDuration is the target slot duration of the cluster,
+	// used until the first estimate is available.
+	DefaultSlotDuration = 400 * time.Millisecond
+
+	DefaultRefreshInterval = 1 * time.Minute
+	DefaultSamples         = uint(10)
+
+	// DefaultSlotWeight is the weight of a new slot notification
+	// in the moving average of the slot duration.
+	DefaultSlotWeight = 0.05
+)
+
+// ErrNoSamples is returned when getRecentPerformanceSamples
+// returns no usable sample.
+var ErrNoSamples = errors.New("no performance samples")
+
+type Opts struct {
+	// Commitment used to fetch the reference slot and block height.
+	// Defaults to rpc.CommitmentConfirmed.
+	Commitment rpc.CommitmentType
+
+	// RefreshInterval is the period at which the performance samples,
+	// the slot and the block height are fetched again.
+	// Defaults to DefaultRefreshInterval.
+	RefreshInterval time.Duration
+
+	// Samples is the number of performance samples
+	// (of 60 seconds each) averaged.
+	// Defaults to DefaultSamples.
+	Samples uint
+
+	// SlotClient, if set, is used to subscribe to slot notifications,
+	// which move the reference slot forward, and refine the slot duration
+	// from their cadence between two refreshes.
+	//
+	// This parameter is optional.
+	SlotClient *ws.Client
+}
+
+// Estimator maintains an estimate of the slot duration, and a reference
+// slot observed at a known time, from which the time of any slot is extrapolated.
+//
+// Skipped slots don't produce blocks, so block heights advance slower
+// than slots; deadlines computed from block heights are thus early,
+// which is the safe side for expiry logic.
+type Estimator struct {
+	client *rpc.Client
+	opts   Opts
+
+	lock         sync.RWMutex
+	slotDuration time.Duration
+	refSlot      uint64
+	refTime      time.Time
+	// heightLag is the difference between the slot and
+	// the block height at the last refresh.
+	heightLag uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewEstimator creates a new Estimator; call Start to fetch
+// the first estimate and begin refreshing it.
+func NewEstimator(client *rpc.Client, opts *Opts) *Estimator {
+	e := &Estimator{
+		client:       client,
+		slotDuration: DefaultSlotDuration,
+		done:         make(chan struct{}),
+	}
+	if opts != nil {
+		e.opts = *opts
+	}
+	if e.opts.Commitment == "" {
+		e.opts.Commitment = rpc.CommitmentConfirmed
+	}
+	if e.opts.RefreshInterval <= 0 {
+		e.opts.RefreshInterval = DefaultRefreshInterval
+	}
+	if e.opts.Samples == 0 {
+		e.opts.Samples = DefaultSamples
+	}
+	return e
+}
+
+// Start fetches the first estimate and starts refreshing it in the background
+// until ctx is done or Close is called.
+func (e *Estimator) Start(ctx context.Context) error {
+	if err := e.update(ctx); err != nil {
+		return fmt.Errorf("slot time estimator: initial fetch: %w", err)
+	}
+
+	var slotSub *ws.SlotSubscription
+	if e.opts.SlotClient != nil {
+		var err error
+		slotSub, err = e.opts.SlotClient.SlotSubscribe()
+		if err != nil {
+			return fmt.Errorf("slot time estimator: slot subscribe: %w", err)
+		}
+	}
+
+	ctx, e.cancel = context.WithCancel(ctx)
+	if slotSub != nil {
+		go e.watchSlots(ctx, slotSub)
+	}
+	go e.run(ctx)
+	return nil
+}
+
+// Close stops refreshing the estimate, and waits for the
+// refresh goroutine to exit.
+func (e *Estimator) Close() {
+	if e.cancel == nil {
+		return
+	}
+	e.cancel()
+	<-e.done
+}
+
+func (e *Estimator) run(ctx context.Context) {
+	defer close(e.done)
+	ticker := time.NewTicker(e.opts.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := e.update(ctx); err != nil && ctx.Err() == nil {
+			zlog.Warn("unable to refresh slot time estimate", zap.Error(err))
+		}
+	}
+}
+
+func (e *Estimator) update(ctx context.Context) error {
+	limit := e.opts.Samples
+	samples, err := e.client.GetRecentPerformanceSamples(ctx, &limit)
+	if err != nil {
+		return fmt.Errorf("get performance samples: %w", err)
+	}
+	duration, err := SlotDurationFromSamples(samples)
+	if err != nil {
+		return err
+	}
+	slot, err := e.client.GetSlot(ctx, e.opts.Commitment)
+	if err != nil {
+		return fmt.Errorf("get slot: %w", err)
+	}
+	at := time.Now()
+	height, err := e.client.GetBlockHeight(ctx, e.opts.Commitment)
+	if err != nil {
+		return fmt.Errorf("get block height: %w", err)
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.slotDuration = duration
+	if slot >= e.refSlot {
+		e.refSlot = slot
+		e.refTime = at
+	}
+	if slot >= height {
+		e.heightLag = slot - height
+	}
+	return nil
+}
+
+func (e *Estimator) watchSlots(ctx context.Context, sub *ws.SlotSubscription) {
+	defer sub.Unsubscribe()
+	for {
+		got, err := sub.RecvWithContext(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				zlog.Warn("slot time estimator slot subscription ended", zap.Error(err))
+			}
+			return
+		}
+		e.Observe(got.Slot, time.Now())
+	}
+}
+
+// Observe records that the slot was observed at the provided time,
+// moving the reference slot forward, and refining the slot duration
+// from the time elapsed since the previous reference.
+func (e *Estimator) Observe(slot uint64, at time.Time) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if slot <= e.refSlot {
+		return
+	}
+	if !e.refTime.IsZero() && at.After(e.refTime) {
+		observed := at.Sub(e.refTime) / time.Duration(slot-e.refSlot)
+		e.slotDuration += time.Duration(DefaultSlotWeight * float64(observed-e.slotDuration))
+	}
+	e.refSlot = slot
+	e.refTime = at
+}
+
+// SlotDuration returns the estimated duration of a slot.
+func (e *Estimator) SlotDuration() time.Duration {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return e.slotDuration
+}
+
+// Reference returns the last observed slot, and the time it was observed at.
+func (e *Estimator) Reference() (slot uint64, at time.Time) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return e.refSlot, e.refTime
+}
+
+// CurrentSlot returns the estimated current slot.
+func (e *Estimator) CurrentSlot() uint64 {
+	return e.SlotAt(time.Now())
+}
+
+// SlotTime returns the approximate time of the slot,
+// in the past or in the future.
+func (e *Estimator) SlotTime(slot uint64) time.Time {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return e.refTime.Add(time.Duration(int64(slot)-int64(e.refSlot)) * e.slotDuration)
+}
+
+// SlotAt returns the approximate slot at the provided time.
+func (e *Estimator) SlotAt(t time.Time) uint64 {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	slots := int64(t.Sub(e.refTime) / e.slotDuration)
+	if slots < 0 && uint64(-slots) > e.refSlot {
+		return 0
+	}
+	return uint64(int64(e.refSlot) + slots)
+}
+
+// BlockHeightTime returns the approximate time at which
+// the block height will be (or was) reached.
+func (e *Estimator) BlockHeightTime(height uint64) time.Time {
+	e.lock.RLock()
+	lag := e.heightLag
+	e.lock.RUnlock()
+	return e.SlotTime(height + lag)
+}
+
+// BlockhashDeadline returns the approximate time after which a transaction
+// using the blockhash cannot be processed anymore.
+func (e *Estimator) BlockhashDeadline(latest *rpc.LatestBlockhashResult) time.Time {
+	return e.BlockHeightTime(latest.LastValidBlockHeight + 1)
+}
+
+// SlotDurationFromSamples returns the average slot duration
+// over the provided performance samples.
+func SlotDurationFromSamples(samples []*rpc.GetRecentPerformanceSamplesResult) (time.Duration, error) {
+	var slots, secs uint64
+	for _, sample := range samples {
+		if sample == nil || sample.NumSlots == 0 {
+			continue
+		}
+		slots += sample.NumSlots
+		secs += uint64(sample.SamplePeriodSecs)
+	}
+	if slots == 0 || secs == 0 {
+		return 0, ErrNoSamples
+	}
+	return time.Duration(secs) * time.Second / time.Duration(slots), nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slottime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotDurationFromSamples(t *testing.T) {
+	duration, err := SlotDurationFromSamples([]*rpc.GetRecentPerformanceSamplesResult{
+		{Slot: 300, NumSlots: 150, SamplePeriodSecs: 60},
+		{Slot: 150, NumSlots: 150, SamplePeriodSecs: 60},
+		{Slot: 0, NumSlots: 0, SamplePeriodSecs: 60},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 400*time.Millisecond, duration)
+
+	_, err = SlotDurationFromSamples(nil)
+	require.ErrorIs(t, err, ErrNoSamples)
+}
+
+func TestEstimator(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getRecentPerformanceSamples", []rpc.M{
+		{"slot": 1000, "numSlots": 120, "numTransactions": 1, "samplePeriodSecs": 60},
+	})
+	server.Handle("getSlot", 1000)
+	server.Handle("getBlockHeight", 900)
+
+	e := NewEstimator(rpc.New(server.URL()), &Opts{RefreshInterval: time.Hour})
+	require.NoError(t, e.Start(context.Background()))
+	defer e.Close()
+
+	require.Equal(t, 500*time.Millisecond, e.SlotDuration())
+	slot, at := e.Reference()
+	require.Equal(t, uint64(1000), slot)
+
+	require.Equal(t, at.Add(5*time.Second), e.SlotTime(1010))
+	require.Equal(t, at.Add(-5*time.Second), e.SlotTime(990))
+	require.Equal(t, uint64(1010), e.SlotAt(at.Add(5*time.Second)))
+	require.Equal(t, uint64(0), e.SlotAt(at.Add(-time.Hour)))
+
+	// Block height 950 is expected 50 blocks after the reference.
+	require.Equal(t, at.Add(25*time.Second), e.BlockHeightTime(950))
+	require.Equal(t, at.Add(25*time.Second+500*time.Millisecond),
+		e.BlockhashDeadline(&rpc.LatestBlockhashResult{LastValidBlockHeight: 950}))
+
+	// Slots observed faster than the estimate pull the duration down.
+	e.Observe(1010, at.Add(3*time.Second))
+	slot, _ = e.Reference()
+	require.Equal(t, uint64(1010), slot)
+	require.Less(t, e.SlotDuration(), 500*time.Millisecond)
+	require.Greater(t, e.SlotDuration(), 300*time.Millisecond)
+
+	// Older slots are ignored.
+	e.Observe(1005, at.Add(4*time.Second))
+	slot, _ = e.Reference()
+	require.Equal(t, uint64(1010), slot)
+}
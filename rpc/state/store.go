@@ -0,0 +1,299 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+)
+
+var ErrStoreClosed = errors.New("state store closed")
+
+// Update is an account change delivered to a reducer.
+type Update struct {
+	Pubkey  solana.PublicKey
+	Slot    uint64
+	Account *rpc.Account
+}
+
+// Reducer computes the new state from the previous state and an account update.
+// The previous state must not be modified in place: snapshots and change
+// notifications share it with other goroutines.
+//
+// If the reducer returns an error, the update is discarded,
+// the state is left unchanged and the error is reported on Store.Err.
+type Reducer[S any] func(prev S, update Update) (S, error)
+
+// Change is delivered to the watchers of a store every time a reducer
+// produces a new state.
+type Change[S any] struct {
+	Prev   S
+	Next   S
+	Update Update
+}
+
+// Store maintains a state materialized from account and program subscriptions
+// through user-provided reducers. Updates are applied one at a time,
+// in the order they are received.
+type Store[S any] struct {
+	client *ws.Client
+
+	lock     sync.RWMutex
+	state    S
+	slot     uint64
+	closed   bool
+	watchers map[int]chan Change[S]
+	nextID   int
+
+	errs   chan error
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewStore creates a new store with the provided initial state.
+// The client is used by WatchAccount and WatchProgram, and may be nil
+// if updates are only fed through Apply.
+func NewStore[S any](client *ws.Client, initial S) *Store[S] {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Store[S]{
+		client:   client,
+		state:    initial,
+		watchers: map[int]chan Change[S]{},
+		errs:     make(chan error, 100),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// WatchAccount subscribes to an account and reduces every notification
+// into the state with the provided reducer.
+func (s *Store[S]) WatchAccount(
+	account solana.PublicKey,
+	commitment rpc.CommitmentType,
+	reducer Reducer[S],
+) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	sub, err := s.client.AccountSubscribe(account, commitment)
+	if err != nil {
+		return fmt.Errorf("watch account %s: %w", account, err)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer sub.Unsubscribe()
+		for {
+			got, err := sub.RecvWithContext(s.ctx)
+			if err != nil {
+				s.stopped(err)
+				return
+			}
+			s.Apply(Update{
+				Pubkey:  account,
+				Slot:    got.Context.Slot,
+				Account: &got.Value.Account,
+			}, reducer)
+		}
+	}()
+	return nil
+}
+
+// WatchProgram subscribes to the accounts owned by a program (optionally
+// matching the provided filters) and reduces every notification
+// into the state with the provided reducer.
+func (s *Store[S]) WatchProgram(
+	programID solana.PublicKey,
+	commitment rpc.CommitmentType,
+	filters []rpc.RPCFilter,
+	reducer Reducer[S],
+) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	sub, err := s.client.ProgramSubscribeWithOpts(programID, commitment, "", filters)
+	if err != nil {
+		return fmt.Errorf("watch program %s: %w", programID, err)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer sub.Unsubscribe()
+		for {
+			got, err := sub.RecvWithContext(s.ctx)
+			if err != nil {
+				s.stopped(err)
+				return
+			}
+			s.Apply(Update{
+				Pubkey:  got.Value.Pubkey,
+				Slot:    got.Context.Slot,
+				Account: got.Value.Account,
+			}, reducer)
+		}
+	}()
+	return nil
+}
+
+// Apply reduces an update into the state, and notifies the watchers.
+// It can be used to seed the state, e.g. from getMultipleAccounts.
+func (s *Store[S]) Apply(update Update, reducer Reducer[S]) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	next, err := reducer(s.state, update)
+	if err != nil {
+		err = fmt.Errorf("reduce update of %s at slot %d: %w", update.Pubkey, update.Slot, err)
+		s.report(err)
+		return err
+	}
+
+	change := Change[S]{
+		Prev:   s.state,
+		Next:   next,
+		Update: update,
+	}
+	s.state = next
+	if update.Slot > s.slot {
+		s.slot = update.Slot
+	}
+	for _, ch := range s.watchers {
+		// Never block the update loop on a slow watcher.
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+	return nil
+}
+
+// Snapshot returns the current state, and the highest slot
+// of the updates applied to it.
+func (s *Store[S]) Snapshot() (S, uint64) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.state, s.slot
+}
+
+// Changes returns a channel on which every state change is delivered,
+// and a function to stop the delivery. Changes are dropped for
+// a watcher whose buffer is full.
+func (s *Store[S]) Changes(buffer int) (<-chan Change[S], func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	ch := make(chan Change[S], buffer)
+	if s.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	id := s.nextID
+	s.nextID++
+	s.watchers[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			if _, ok := s.watchers[id]; ok {
+				delete(s.watchers, id)
+				close(ch)
+			}
+		})
+	}
+}
+
+// Err returns a channel on which reducer and subscription errors are reported.
+// Errors are dropped if the channel is not consumed.
+func (s *Store[S]) Err() <-chan error {
+	return s.errs
+}
+
+// Close stops all the subscriptions of the store and closes
+// the channels returned by Changes.
+func (s *Store[S]) Close() {
+	s.cancel()
+	s.wg.Wait()
+	s.closeWatchers()
+}
+
+// CloseWithContext stops all the subscriptions of the store and closes
+// the channels returned by Changes, waiting for the subscriptions to stop
+// until ctx is done. If ctx is done first, ctx.Err() is returned and
+// the channels are closed in the background once the subscriptions stop.
+func (s *Store[S]) CloseWithContext(ctx context.Context) error {
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		s.closeWatchers()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Store[S]) closeWatchers() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for id, ch := range s.watchers {
+		delete(s.watchers, id)
+		close(ch)
+	}
+}
+
+func (s *Store[S]) checkOpen() error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		return ErrStoreClosed
+	}
+	return nil
+}
+
+func (s *Store[S]) stopped(err error) {
+	if s.ctx.Err() != nil {
+		return
+	}
+	s.report(fmt.Errorf("subscription ended: %w", err))
+}
+
+func (s *Store[S]) report(err error) {
+	select {
+	case s.errs <- err:
+	default:
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package state
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+type balances map[solana.PublicKey]uint64
+
+// reduceBalances copies the previous state, as reducers must not modify it.
+func reduceBalances(prev balances, update Update) (balances, error) {
+	if update.Account == nil {
+		return nil, errors.New("missing account")
+	}
+	next := make(balances, len(prev)+1)
+	for k, v := range prev {
+		next[k] = v
+	}
+	next[update.Pubkey] = update.Account.Lamports
+	return next, nil
+}
+
+func TestStore_Apply(t *testing.T) {
+	store := NewStore[balances](nil, balances{})
+	defer store.Close()
+
+	changes, stop := store.Changes(10)
+	defer stop()
+
+	a, b := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	require.NoError(t, store.Apply(Update{Pubkey: a, Slot: 5, Account: &rpc.Account{Lamports: 10}}, reduceBalances))
+	require.NoError(t, store.Apply(Update{Pubkey: b, Slot: 4, Account: &rpc.Account{Lamports: 20}}, reduceBalances))
+	require.Error(t, store.Apply(Update{Pubkey: b, Slot: 6}, reduceBalances))
+
+	state, slot := store.Snapshot()
+	require.Equal(t, balances{a: 10, b: 20}, state)
+	require.Equal(t, uint64(5), slot)
+
+	first := <-changes
+	require.Empty(t, first.Prev)
+	require.Equal(t, balances{a: 10}, first.Next)
+	second := <-changes
+	require.Equal(t, balances{a: 10}, second.Prev)
+	require.Equal(t, b, second.Update.Pubkey)
+
+	select {
+	case err := <-store.Err():
+		require.Contains(t, err.Error(), "missing account")
+	default:
+		t.Fatal("expected reducer error")
+	}
+
+	require.NoError(t, store.CloseWithContext(context.Background()))
+	_, ok := <-changes
+	require.False(t, ok)
+	require.ErrorIs(t, store.Apply(Update{Pubkey: a}, reduceBalances), ErrStoreClosed)
+}
+
+func TestStore_WatchAccount(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var in struct {
+				ID     uint64 `json:"id"`
+				Method string `json:"method"`
+			}
+			_, msg, err := conn.ReadMessage()
+			if err != nil || stdjson.Unmarshal(msg, &in) != nil {
+				return
+			}
+			if in.Method != "accountSubscribe" {
+				continue
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":7,"id":%d}`, in.ID)))
+			for lamports := 1; lamports <= 3; lamports++ {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+					`{"jsonrpc":"2.0","method":"accountNotification","params":{"result":{"context":{"slot":%d},"value":{"data":["","base64"],"executable":false,"lamports":%d,"owner":"11111111111111111111111111111111","rentEpoch":0}},"subscription":7}}`,
+					100+lamports, lamports,
+				)))
+			}
+		}
+	}))
+	defer server.Close()
+
+	client, err := ws.Connect(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	defer client.Close()
+
+	store := NewStore[balances](client, balances{})
+	defer store.Close()
+	changes, stop := store.Changes(10)
+	defer stop()
+
+	account := solana.NewWallet().PublicKey()
+	require.NoError(t, store.WatchAccount(account, rpc.CommitmentConfirmed, reduceBalances))
+
+	for i := 0; i < 3; i++ {
+		<-changes
+	}
+	state, slot := store.Snapshot()
+	require.Equal(t, balances{account: 3}, state)
+	require.Equal(t, uint64(103), slot)
+}
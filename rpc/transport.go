@@ -0,0 +1,212 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// rateLimitTransport waits on the configured RateLimiter before each request
+// and retries requests rejected with HTTP 429, honoring Retry-After.
+type rateLimitTransport struct {
+	base       http.RoundTripper
+	limiter    RateLimiter
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	logger     *zap.Logger
+}
+
+func newRateLimitTransport(base http.RoundTripper, opts *Options) *rateLimitTransport {
+	tr := &rateLimitTransport{
+		base:       base,
+		limiter:    opts.RateLimiter,
+		maxRetries: opts.RateLimitRetries,
+		minBackoff: opts.RateLimitMinBackoff,
+		maxBackoff: opts.RateLimitMaxBackoff,
+		logger:     opts.Logger,
+	}
+	if tr.maxRetries == 0 {
+		tr.maxRetries = DefaultRateLimitRetries
+	}
+	if tr.maxRetries < 0 {
+		tr.maxRetries = 0
+	}
+	if tr.minBackoff <= 0 {
+		tr.minBackoff = DefaultRateLimitMinBackoff
+	}
+	if tr.maxBackoff <= 0 {
+		tr.maxBackoff = DefaultRateLimitMaxBackoff
+	}
+	return tr
+}
+
+func (tr *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if tr.limiter != nil {
+			if err := tr.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			attemptReq, err = rewindRequest(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := tr.base.RoundTrip(attemptReq)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= tr.maxRetries {
+			return resp, err
+		}
+
+		wait, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if !ok {
+			wait = tr.backoff(attempt)
+		}
+		if wait > tr.maxBackoff {
+			wait = tr.maxBackoff
+		}
+		// Drain the body so that the connection can be reused.
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+
+		logger(tr.logger).Debug("rpc request rate limited, retrying",
+			zap.String("http_method", req.Method),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("wait", wait),
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns an exponential backoff with full jitter for the provided attempt.
+func (tr *rateLimitTransport) backoff(attempt int) time.Duration {
+	d := tr.minBackoff << uint(attempt)
+	if d <= 0 || d > tr.maxBackoff {
+		d = tr.maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// CloseIdleConnections closes the idle connections of the underlying transport.
+func (tr *rateLimitTransport) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+	if c, ok := tr.base.(closeIdler); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// gzipRequestTransport gzip-compresses the body of each request.
+type gzipRequestTransport struct {
+	base http.RoundTripper
+}
+
+func (tr *gzipRequestTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody || req.Header.Get("Content-Encoding") != "" {
+		return tr.base.RoundTrip(req)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := io.Copy(zw, req.Body)
+	req.Body.Close()
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+
+	compressed := buf.Bytes()
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Content-Encoding", "gzip")
+	clone.ContentLength = int64(len(compressed))
+	clone.Body = io.NopCloser(bytes.NewReader(compressed))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	return tr.base.RoundTrip(clone)
+}
+
+// CloseIdleConnections closes the idle connections of the underlying transport.
+func (tr *gzipRequestTransport) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+	if c, ok := tr.base.(closeIdler); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// rewindRequest returns a copy of req with a fresh body, so it can be sent again.
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("cannot retry %s request to %s: body is not rewindable", req.Method, req.URL)
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// parseRetryAfter parses the value of a Retry-After header,
+// which is either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		wait := at.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
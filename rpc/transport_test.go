@@ -0,0 +1,285 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/jsonrpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingLimiter struct {
+	calls int32
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	atomic.AddInt32(&l.calls, 1)
+	return nil
+}
+
+func TestClient_RateLimitRetry(t *testing.T) {
+	var requests int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+
+		if atomic.AddInt32(&requests, 1) < 3 {
+			rw.Header().Set("Retry-After", "0")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		rw.Write([]byte(wrapIntoRPC(`{"context":{"slot":1},"value":42}`)))
+	}))
+	defer server.Close()
+
+	limiter := &countingLimiter{}
+	client := NewWithOptions(server.URL, &Options{
+		RateLimiter: limiter,
+	})
+
+	out, err := client.GetBalance(context.Background(), solana.PublicKey{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), out.Value)
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&limiter.calls))
+	require.Len(t, bodies, 3)
+	assert.Equal(t, bodies[0], bodies[2])
+}
+
+func TestClient_RateLimitRetryDisabled(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		rw.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := NewWithOptions(server.URL, &Options{
+		RateLimitRetries: -1,
+	})
+
+	_, err := client.GetBalance(context.Background(), solana.PublicKey{}, "")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	wait, ok := parseRetryAfter("3", now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, wait)
+
+	wait, ok = parseRetryAfter(now.Add(5*time.Second).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, wait)
+
+	_, ok = parseRetryAfter("", now)
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
+
+func TestClient_APIKeyRotation(t *testing.T) {
+	var keys []string
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		keys = append(keys, req.URL.Query().Get("api-key"))
+		if atomic.AddInt32(&requests, 1) == 1 {
+			rw.Header().Set("Retry-After", "0")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		rw.Write([]byte(wrapIntoRPC(`{"context":{"slot":1},"value":42}`)))
+	}))
+	defer server.Close()
+
+	client := NewHeliusWithOptions(server.URL+"/?foo=bar", &Options{
+		APIKeys: []string{"first", "second"},
+	})
+	for i := 0; i < 2; i++ {
+		_, err := client.GetBalance(context.Background(), solana.PublicKey{}, "")
+		require.NoError(t, err)
+	}
+
+	// The rate limited request is retried with the next key.
+	assert.Equal(t, []string{"first", "second", "first"}, keys)
+	assert.Equal(t, []APIKeyUsage{
+		{Key: "first", Requests: 2, RateLimited: 1},
+		{Key: "second", Requests: 1},
+	}, client.APIKeyUsage())
+}
+
+func TestClient_APIKeyHeader(t *testing.T) {
+	var header, query string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		header = req.Header.Get("X-Api-Key")
+		query = req.URL.RawQuery
+		rw.Write([]byte(wrapIntoRPC(`{"context":{"slot":1},"value":42}`)))
+	}))
+	defer server.Close()
+
+	client := NewWithOptions(server.URL, &Options{
+		APIKeys:      []string{"secret"},
+		APIKeyHeader: "X-Api-Key",
+	})
+	_, err := client.GetBalance(context.Background(), solana.PublicKey{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", header)
+	assert.Empty(t, query)
+
+	assert.Nil(t, New(server.URL).APIKeyUsage())
+}
+
+func TestClient_CompressRequests(t *testing.T) {
+	var encoding, body string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		encoding = req.Header.Get("Content-Encoding")
+		zr, err := gzip.NewReader(req.Body)
+		require.NoError(t, err)
+		raw, err := ioutil.ReadAll(zr)
+		require.NoError(t, err)
+		body = string(raw)
+		rw.Write([]byte(wrapIntoRPC(`{"context":{"slot":1},"value":42}`)))
+	}))
+	defer server.Close()
+
+	client := NewWithOptions(server.URL, &Options{CompressRequests: true})
+	out, err := client.GetBalance(context.Background(), solana.PublicKey{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), out.Value)
+	assert.Equal(t, "gzip", encoding)
+	assert.Contains(t, body, `"method":"getBalance"`)
+}
+
+type countingTransport struct {
+	calls int32
+	base  http.RoundTripper
+}
+
+func (tr *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&tr.calls, 1)
+	return tr.base.RoundTrip(req)
+}
+
+func TestClient_CustomTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(wrapIntoRPC(`{"context":{"slot":1},"value":42}`)))
+	}))
+	defer server.Close()
+
+	tr := &countingTransport{base: http.DefaultTransport}
+	client := NewWithOptions(server.URL, &Options{Transport: tr})
+	_, err := client.GetBalance(context.Background(), solana.PublicKey{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tr.calls))
+
+	tr = &countingTransport{base: http.DefaultTransport}
+	client = NewWithOptions(server.URL, &Options{HTTPClient: &http.Client{Transport: tr}})
+	_, err = client.GetBalance(context.Background(), solana.PublicKey{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&tr.calls))
+}
+
+func TestClient_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client := NewWithOptions(server.URL, &Options{Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	_, err := client.GetBalance(context.Background(), solana.PublicKey{}, "")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestNewHTTPTransportWithOptions(t *testing.T) {
+	proxyURL, err := url.Parse("http://proxy.local:8080")
+	require.NoError(t, err)
+
+	tr, ok := newHTTPTransportWithOptions(&Options{
+		MaxIdleConnsPerHost: 64,
+		IdleConnTimeout:     time.Minute,
+		TLSClientConfig:     &tls.Config{ServerName: "rpc.local"},
+		DisableHTTP2:        true,
+		Proxy:               http.ProxyURL(proxyURL),
+	}).(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 64, tr.MaxIdleConnsPerHost)
+	assert.Equal(t, 64, tr.MaxConnsPerHost)
+	assert.Equal(t, time.Minute, tr.IdleConnTimeout)
+	assert.Equal(t, "rpc.local", tr.TLSClientConfig.ServerName)
+	assert.False(t, tr.ForceAttemptHTTP2)
+	assert.NotNil(t, tr.TLSNextProto)
+
+	got, err := tr.Proxy(httptest.NewRequest(http.MethodPost, "http://rpc.local", nil))
+	require.NoError(t, err)
+	assert.Equal(t, proxyURL, got)
+
+	tr = newHTTPTransportWithOptions(&Options{MaxConnsPerHost: -1}).(*http.Transport)
+	assert.Equal(t, 0, tr.MaxConnsPerHost)
+	assert.True(t, tr.ForceAttemptHTTP2)
+}
+
+func TestClient_Debug(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getSlot", 100)
+
+	var exchanges []*jsonrpc.Exchange
+	client := NewWithOptions(server.URL()+"?api-key=endpoint-key", &Options{
+		APIKeys:            []string{"rotated-key"},
+		Headers:            map[string]string{"Authorization": "Bearer token"},
+		DebugRedactAPIKeys: true,
+		Debug: func(exchange *jsonrpc.Exchange) {
+			exchanges = append(exchanges, exchange)
+		},
+	})
+
+	ctx, capture := jsonrpc.WithDebugCapture(context.Background())
+	slot, err := client.GetSlot(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(100), slot)
+
+	require.Len(t, exchanges, 1)
+	exchange := exchanges[0]
+	assert.Same(t, exchange, capture.Last())
+	assert.Equal(t, "getSlot", exchange.Method)
+	assert.Equal(t, server.URL()+"?api-key=REDACTED", exchange.URL)
+	assert.Equal(t, "REDACTED", exchange.RequestHeader.Get("Authorization"))
+	assert.Contains(t, string(exchange.Request), `"method":"getSlot"`)
+	assert.Contains(t, string(exchange.Response), `"result":100`)
+}
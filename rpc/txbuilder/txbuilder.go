@@ -0,0 +1,213 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package txbuilder builds transactions with a chained API, fetching
+// the blockhash, the address lookup tables and the compute unit price
+// from an RPC node, and validating the transaction before returning it.
+package txbuilder
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/priorityfee"
+)
+
+// Priority configures the compute budget instructions
+// prepended to the transaction (see priorityfee.Priority).
+type Priority = priorityfee.Priority
+
+// PriceEstimator recommends a compute unit price, in micro-lamports
+// (see priorityfee.PriceEstimator).
+type PriceEstimator = priorityfee.PriceEstimator
+
+// TxBuilder builds a transaction with chained calls; the RPC calls
+// (SetBlockhashFromClient, UseALTs, Priority.Estimator) are made by Build,
+// which validates the transaction before signing it.
+type TxBuilder struct {
+	instructions []solana.Instruction
+	feePayer     solana.PublicKey
+	signers      []solana.Signer
+	priority     *Priority
+
+	blockhash            solana.Hash
+	lastValidBlockHeight uint64
+	blockhashClient      *rpc.Client
+	blockhashCommitment  rpc.CommitmentType
+
+	tables       map[solana.PublicKey]solana.PublicKeySlice
+	tablesClient *rpc.Client
+	tableKeys    solana.PublicKeySlice
+}
+
+// New creates a new, empty TxBuilder.
+func New() *TxBuilder {
+	return &TxBuilder{}
+}
+
+// AddInstruction appends the provided instructions to the transaction.
+func (b *TxBuilder) AddInstruction(instructions ...solana.Instruction) *TxBuilder {
+	b.instructions = append(b.instructions, instructions...)
+	return b
+}
+
+// SetFeePayer sets the account paying the fees of the transaction.
+// If not set, it defaults to the first signer of the first instruction.
+func (b *TxBuilder) SetFeePayer(feePayer solana.PublicKey) *TxBuilder {
+	b.feePayer = feePayer
+	return b
+}
+
+// SetBlockhash sets the recent blockhash of the transaction.
+func (b *TxBuilder) SetBlockhash(blockhash solana.Hash) *TxBuilder {
+	b.blockhash = blockhash
+	b.blockhashClient = nil
+	return b
+}
+
+// SetBlockhashFromClient makes Build fetch the latest blockhash
+// from the client, at the provided commitment (optional).
+func (b *TxBuilder) SetBlockhashFromClient(client *rpc.Client, commitment rpc.CommitmentType) *TxBuilder {
+	b.blockhashClient = client
+	b.blockhashCommitment = commitment
+	return b
+}
+
+// AddAddressTable adds an address lookup table, with the addresses it holds;
+// a transaction using address tables is built as a versioned (v0) transaction.
+func (b *TxBuilder) AddAddressTable(table solana.PublicKey, addresses solana.PublicKeySlice) *TxBuilder {
+	if b.tables == nil {
+		b.tables = make(map[solana.PublicKey]solana.PublicKeySlice)
+	}
+	b.tables[table] = addresses
+	return b
+}
+
+// UseALTs makes Build fetch the provided address lookup tables from the client,
+// and load from them the accounts of the transaction they hold,
+// except for the signers and the invoked programs (see AddAddressTable).
+func (b *TxBuilder) UseALTs(client *rpc.Client, tables ...solana.PublicKey) *TxBuilder {
+	b.tablesClient = client
+	b.tableKeys = append(b.tableKeys, tables...)
+	return b
+}
+
+// AddSigner adds signers of the transaction; Build signs the transaction
+// with them, and requires a signer for every account that must sign it.
+// If no signer is added, Build returns the transaction unsigned.
+func (b *TxBuilder) AddSigner(signers ...solana.Signer) *TxBuilder {
+	b.signers = append(b.signers, signers...)
+	return b
+}
+
+// PrioritizeWith prepends the compute budget instructions
+// required by the priority to the transaction, replacing the ones
+// added with AddInstruction (see priorityfee.Priority.Apply).
+func (b *TxBuilder) PrioritizeWith(priority Priority) *TxBuilder {
+	b.priority = &priority
+	return b
+}
+
+// LastValidBlockHeight returns the last block height at which the blockhash
+// fetched by Build is valid, or zero if it was set with SetBlockhash.
+func (b *TxBuilder) LastValidBlockHeight() uint64 {
+	return b.lastValidBlockHeight
+}
+
+// Build makes the RPC calls configured on the builder, validates
+// and signs the transaction. It returns a *ValidationError listing
+// all the problems found if the transaction is invalid.
+func (b *TxBuilder) Build(ctx context.Context) (*solana.Transaction, error) {
+	if err := b.fetch(ctx); err != nil {
+		return nil, err
+	}
+	instructions, err := b.prioritized(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &validator{}
+	feePayer := v.checkInstructions(b.instructions, b.feePayer)
+	if b.blockhash.IsZero() {
+		v.add(Problem{Err: ErrNoBlockhash, Instruction: -1})
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	opts := []solana.TransactionOption{solana.TransactionPayer(feePayer)}
+	if len(b.tables) > 0 {
+		opts = append(opts, solana.TransactionAddressTables(b.tables), solana.TransactionV0())
+	}
+	tx, err := solana.NewTransaction(instructions, b.blockhash, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("build transaction: %w", err)
+	}
+
+	if len(b.signers) > 0 {
+		v.checkSigners(tx, b.signers)
+	}
+	if err := tx.CheckSize(); err != nil {
+		v.add(Problem{Err: err, Instruction: -1})
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	if len(b.signers) > 0 {
+		if _, err := tx.SignWith(b.signers...); err != nil {
+			return nil, fmt.Errorf("build transaction: sign: %w", err)
+		}
+	}
+	return tx, nil
+}
+
+// fetch makes the RPC calls configured on the builder.
+func (b *TxBuilder) fetch(ctx context.Context) error {
+	if b.blockhashClient != nil {
+		latest, err := b.blockhashClient.GetLatestBlockhash(ctx, b.blockhashCommitment)
+		if err != nil {
+			return fmt.Errorf("build transaction: get latest blockhash: %w", err)
+		}
+		b.blockhash = latest.Value.Blockhash
+		b.lastValidBlockHeight = latest.Value.LastValidBlockHeight
+	}
+	for _, table := range b.tableKeys {
+		if _, ok := b.tables[table]; ok {
+			continue
+		}
+		state, err := addresslookuptable.GetAddressLookupTable(ctx, b.tablesClient, table)
+		if err != nil {
+			return fmt.Errorf("build transaction: get address lookup table %s: %w", table, err)
+		}
+		b.AddAddressTable(table, state.Addresses)
+	}
+	return nil
+}
+
+// prioritized returns the instructions of the transaction,
+// with the compute budget instructions of the priority, if any.
+func (b *TxBuilder) prioritized(ctx context.Context) ([]solana.Instruction, error) {
+	if b.priority == nil {
+		return b.instructions, nil
+	}
+	instructions, err := b.priority.Apply(ctx, b.instructions)
+	if err != nil {
+		return nil, fmt.Errorf("build transaction: %w", err)
+	}
+	return instructions, nil
+}
@@ -0,0 +1,207 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txbuilder
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	addresslookuptable "github.com/gagliardetto/solana-go/programs/address-lookup-table"
+	computebudget "github.com/gagliardetto/solana-go/programs/compute-budget"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedEstimator uint64
+
+func (e fixedEstimator) RecommendedPrice(ctx context.Context, writable solana.PublicKeySlice) (uint64, error) {
+	return uint64(e), nil
+}
+
+func TestBuild(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	blockhash := solana.Hash{1, 2, 3}
+	server.Handle("getLatestBlockhash", rpc.M{
+		"context": rpc.M{"slot": 10},
+		"value":   rpc.M{"blockhash": blockhash.String(), "lastValidBlockHeight": 200},
+	})
+
+	payer := solana.NewWallet().PrivateKey
+	program := solana.NewWallet().PublicKey()
+	account := solana.NewWallet().PublicKey()
+
+	builder := New().
+		AddInstruction(solana.NewInstruction(program, solana.AccountMetaSlice{
+			solana.Meta(payer.PublicKey()).SIGNER().WRITE(),
+			solana.Meta(account).WRITE(),
+		}, []byte{1})).
+		SetBlockhashFromClient(rpc.New(server.URL()), rpc.CommitmentConfirmed).
+		PrioritizeWith(Priority{ComputeUnitLimit: 100_000, Estimator: fixedEstimator(5000)}).
+		AddSigner(payer)
+	tx, err := builder.Build(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, blockhash, tx.Message.RecentBlockhash)
+	require.EqualValues(t, 200, builder.LastValidBlockHeight())
+	require.Equal(t, payer.PublicKey(), tx.Message.AccountKeys[0])
+	require.Len(t, tx.Signatures, 1)
+	require.NoError(t, tx.VerifySignatures())
+
+	require.Len(t, tx.Message.Instructions, 3)
+	for _, inst := range tx.Message.Instructions[:2] {
+		programID, err := tx.ResolveProgramIDIndex(inst.ProgramIDIndex)
+		require.NoError(t, err)
+		require.Equal(t, computebudget.ProgramID, programID)
+	}
+}
+
+func TestBuild_replacesComputeBudget(t *testing.T) {
+	payer := solana.NewWallet().PrivateKey
+	program := solana.NewWallet().PublicKey()
+
+	tx, err := New().
+		AddInstruction(
+			computebudget.NewSetComputeUnitLimitInstruction(50_000).Build(),
+			computebudget.NewSetComputeUnitPriceInstruction(1).Build(),
+			solana.NewInstruction(program, solana.AccountMetaSlice{
+				solana.Meta(payer.PublicKey()).SIGNER().WRITE(),
+			}, []byte{1}),
+		).
+		SetBlockhash(solana.Hash{1}).
+		SetFeePayer(payer.PublicKey()).
+		PrioritizeWith(Priority{ComputeUnitPrice: 5000}).
+		Build(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, tx.Message.Instructions, 3)
+	budget, err := computebudget.ParseTransaction(tx)
+	require.NoError(t, err)
+	require.EqualValues(t, 50_000, *budget.ComputeUnitLimit)
+	require.EqualValues(t, 5000, *budget.ComputeUnitPrice)
+}
+
+func TestBuild_validation(t *testing.T) {
+	payer := solana.NewWallet().PrivateKey
+	other := solana.NewWallet().PrivateKey
+	program := solana.NewWallet().PublicKey()
+	account := solana.NewWallet().PublicKey()
+
+	_, err := New().Build(context.Background())
+	require.ErrorIs(t, err, ErrNoInstructions)
+	require.ErrorIs(t, err, ErrNoBlockhash)
+
+	_, err = New().
+		AddInstruction(solana.NewInstruction(program, solana.AccountMetaSlice{
+			solana.Meta(payer.PublicKey()).SIGNER().WRITE(),
+			solana.Meta(account).WRITE(),
+			solana.Meta(account),
+			solana.Meta(program).WRITE(),
+		}, nil)).
+		SetBlockhash(solana.Hash{1}).
+		Build(context.Background())
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Problems, 2)
+	require.ErrorIs(t, err, ErrConflictingFlags)
+	require.ErrorIs(t, err, ErrInvokedProgramFlags)
+	require.Equal(t, 0, validationErr.Problems[0].Instruction)
+	require.Equal(t, account, *validationErr.Problems[0].Account)
+
+	_, err = New().
+		AddInstruction(solana.NewInstruction(program, solana.AccountMetaSlice{
+			solana.Meta(account).WRITE(),
+		}, nil)).
+		SetBlockhash(solana.Hash{1}).
+		Build(context.Background())
+	require.ErrorIs(t, err, ErrNoFeePayer)
+
+	_, err = New().
+		AddInstruction(solana.NewInstruction(program, solana.AccountMetaSlice{
+			solana.Meta(payer.PublicKey()).SIGNER().WRITE(),
+			solana.Meta(account).SIGNER(),
+		}, nil)).
+		SetBlockhash(solana.Hash{1}).
+		AddSigner(payer, other).
+		Build(context.Background())
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Problems, 2)
+	require.ErrorIs(t, err, ErrMissingSigner)
+	require.Equal(t, account, *validationErr.Problems[0].Account)
+	require.ErrorIs(t, err, ErrUnexpectedSigner)
+	require.Equal(t, other.PublicKey(), *validationErr.Problems[1].Account)
+
+	_, err = New().
+		AddInstruction(solana.NewInstruction(program, solana.AccountMetaSlice{
+			solana.Meta(payer.PublicKey()).SIGNER().WRITE(),
+		}, make([]byte, solana.MaxTransactionSize))).
+		SetBlockhash(solana.Hash{1}).
+		Build(context.Background())
+	require.ErrorIs(t, err, solana.ErrTransactionTooLarge)
+	var sizeErr *solana.TransactionSizeError
+	require.True(t, errors.As(err.(*ValidationError).Problems[0].Err, &sizeErr))
+}
+
+func TestBuild_useALTs(t *testing.T) {
+	payer := solana.NewWallet().PrivateKey
+	program := solana.NewWallet().PublicKey()
+	account := solana.NewWallet().PublicKey()
+	table := solana.NewWallet().PublicKey()
+
+	buf := new(bytes.Buffer)
+	state := addresslookuptable.AddressLookupTableState{
+		TypeIndex:        1,
+		DeactivationSlot: math.MaxUint64,
+		Addresses:        solana.PublicKeySlice{account},
+	}
+	require.NoError(t, state.MarshalWithEncoder(bin.NewBinEncoder(buf)))
+
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.HandleFunc("getAccountInfo", func(params stdjson.RawMessage) (interface{}, error) {
+		return rpc.M{
+			"context": rpc.M{"slot": 10},
+			"value": rpc.M{
+				"data":       []string{base64.StdEncoding.EncodeToString(buf.Bytes()), "base64"},
+				"executable": false,
+				"lamports":   1,
+				"owner":      "AddressLookupTab1e1111111111111111111111111",
+				"rentEpoch":  0,
+			},
+		}, nil
+	})
+
+	tx, err := New().
+		AddInstruction(solana.NewInstruction(program, solana.AccountMetaSlice{
+			solana.Meta(payer.PublicKey()).SIGNER().WRITE(),
+			solana.Meta(account).WRITE(),
+		}, nil)).
+		SetBlockhash(solana.Hash{1}).
+		UseALTs(rpc.New(server.URL()), table).
+		AddSigner(payer).
+		Build(context.Background())
+	require.NoError(t, err)
+	require.True(t, tx.Message.IsVersioned())
+	require.Len(t, tx.Message.AddressTableLookups, 1)
+	require.Equal(t, table, tx.Message.AddressTableLookups[0].AccountKey)
+	require.Equal(t, solana.PublicKeySlice{payer.PublicKey(), program}, solana.PublicKeySlice(tx.Message.AccountKeys))
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+var (
+	ErrNoInstructions = errors.New("no instructions")
+	ErrNoFeePayer     = errors.New("no fee payer: set one, or make an account of the first instruction a signer")
+	ErrNoBlockhash    = errors.New("no recent blockhash")
+	// ErrInstructionData is the problem of an instruction whose data cannot be encoded.
+	ErrInstructionData = errors.New("invalid instruction data")
+	// ErrConflictingFlags is the problem of an account listed more than once
+	// by an instruction with different signer or writable flags.
+	ErrConflictingFlags = errors.New("account listed more than once with different flags")
+	// ErrInvokedProgramFlags is the problem of an invoked program
+	// that an instruction lists as a signer or writable account.
+	ErrInvokedProgramFlags = errors.New("invoked program listed as signer or writable")
+	// ErrMissingSigner is the problem of an account that must sign
+	// the transaction, but has no signer (see TxBuilder.AddSigner).
+	ErrMissingSigner = errors.New("missing signer")
+	// ErrUnexpectedSigner is the problem of a signer whose account
+	// doesn't have to sign the transaction.
+	ErrUnexpectedSigner = errors.New("unexpected signer")
+)
+
+// Problem is an issue found while validating a transaction.
+// Err is one of the Err* problems of the package, or a
+// *solana.TransactionSizeError for a transaction too large.
+type Problem struct {
+	Err error
+	// Instruction is the index of the instruction at fault,
+	// in the order of TxBuilder.AddInstruction, or -1.
+	Instruction int
+	// Account is the account at fault, if any.
+	Account *solana.PublicKey
+}
+
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.Instruction >= 0 {
+		fmt.Fprintf(&b, "instruction %d: ", p.Instruction)
+	}
+	if p.Account != nil {
+		fmt.Fprintf(&b, "account %s: ", p.Account)
+	}
+	b.WriteString(p.Err.Error())
+	return b.String()
+}
+
+// ValidationError lists the problems found while validating a transaction;
+// errors.Is matches the Err of any of them.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.String()
+	}
+	return fmt.Sprintf("invalid transaction: %s", strings.Join(problems, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	for _, p := range e.Problems {
+		if errors.Is(p.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+type validator struct {
+	problems []Problem
+}
+
+func (v *validator) add(p Problem) {
+	v.problems = append(v.problems, p)
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+// checkInstructions validates the instructions and their accounts,
+// and returns the fee payer, defaulting to the first signer
+// of the first instruction.
+func (v *validator) checkInstructions(instructions []solana.Instruction, feePayer solana.PublicKey) solana.PublicKey {
+	if len(instructions) == 0 {
+		v.add(Problem{Err: ErrNoInstructions, Instruction: -1})
+		return feePayer
+	}
+
+	programs := make(map[solana.PublicKey]bool, len(instructions))
+	for _, inst := range instructions {
+		programs[inst.ProgramID()] = true
+	}
+	for i, inst := range instructions {
+		if _, err := inst.Data(); err != nil {
+			v.add(Problem{Err: fmt.Errorf("%w: %v", ErrInstructionData, err), Instruction: i})
+		}
+		seen := make(map[solana.PublicKey]*solana.AccountMeta)
+		for _, account := range inst.Accounts() {
+			key := account.PublicKey
+			if prev, ok := seen[key]; ok {
+				if prev.IsSigner != account.IsSigner || prev.IsWritable != account.IsWritable {
+					v.add(Problem{Err: ErrConflictingFlags, Instruction: i, Account: &key})
+				}
+				continue
+			}
+			seen[key] = account
+			if programs[key] && (account.IsSigner || account.IsWritable) {
+				v.add(Problem{Err: ErrInvokedProgramFlags, Instruction: i, Account: &key})
+			}
+		}
+	}
+
+	if feePayer.IsZero() {
+		for _, account := range instructions[0].Accounts() {
+			if account.IsSigner {
+				return account.PublicKey
+			}
+		}
+		v.add(Problem{Err: ErrNoFeePayer, Instruction: -1})
+	}
+	return feePayer
+}
+
+// checkSigners checks that the signers are exactly
+// the accounts required to sign the transaction.
+func (v *validator) checkSigners(tx *solana.Transaction, signers []solana.Signer) {
+	provided := make(map[solana.PublicKey]bool, len(signers))
+	for _, signer := range signers {
+		provided[signer.PublicKey()] = true
+	}
+	required := make(map[solana.PublicKey]bool)
+	for _, key := range tx.Message.AccountKeys[:tx.Message.Header.NumRequiredSignatures] {
+		key := key
+		required[key] = true
+		if !provided[key] {
+			v.add(Problem{Err: ErrMissingSigner, Instruction: -1, Account: &key})
+		}
+	}
+	for _, signer := range signers {
+		key := signer.PublicKey()
+		if !required[key] {
+			v.add(Problem{Err: ErrUnexpectedSigner, Instruction: -1, Account: &key})
+			required[key] = true // report once
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	stdjson "encoding/json"
 	"fmt"
 	"math/big"
+	"sync"
 
 	bin "github.com/gagliardetto/binary"
 
@@ -228,6 +229,14 @@ type InnerInstruction struct {
 	Instructions []solana.CompiledInstruction `json:"instructions"`
 }
 
+// ReturnData is the data returned by a program with sol_set_return_data.
+type ReturnData struct {
+	// The program that generated the return data.
+	ProgramId solana.PublicKey `json:"programId"`
+	// The return data, encoded in base64 in JSON.
+	Data solana.Data `json:"data"`
+}
+
 // Ok  interface{} `json:"Ok"`  // <null> Transaction was successful
 // Err interface{} `json:"Err"` // Transaction failed with TransactionError
 type DeprecatedTransactionMetaStatus M
@@ -298,10 +307,52 @@ type Account struct {
 	RentEpoch *big.Int `json:"rentEpoch"`
 }
 
+// GetBinary returns the decoded account data regardless of whether it
+// was received as "base58", "base64", or "base64+zstd".
+// It returns nil if the account has no binary data.
+func (a *Account) GetBinary() []byte {
+	if a == nil || a.Data == nil {
+		return nil
+	}
+	return a.Data.GetBinary()
+}
+
+// Binary is like GetBinary but also returns any error
+// encountered while decompressing "base64+zstd" data.
+func (a *Account) Binary() ([]byte, error) {
+	if a == nil || a.Data == nil {
+		return nil, nil
+	}
+	return a.Data.Binary()
+}
+
 type DataBytesOrJSON struct {
 	rawDataEncoding solana.EncodingType
 	asDecodedBinary solana.Data
 	asJSON          stdjson.RawMessage
+	// compressed holds the still-compressed payload of "base64+zstd" data
+	// until it is accessed for the first time.
+	compressed *compressedData
+}
+
+// compressedData lazily decompresses a "base64+zstd" payload
+// and caches the result.
+type compressedData struct {
+	once    sync.Once
+	content []byte
+	decoded []byte
+	err     error
+}
+
+func (c *compressedData) decode() ([]byte, error) {
+	c.once.Do(func() {
+		if len(c.content) == 0 {
+			c.decoded = []byte{}
+			return
+		}
+		c.decoded, c.err = solana.DecompressZstd(c.content)
+	})
+	return c.decoded, c.err
 }
 
 func DataBytesOrJSONFromBase64(stringBase64 string) (*DataBytesOrJSON, error) {
@@ -327,6 +378,14 @@ func (dt DataBytesOrJSON) MarshalJSON() ([]byte, error) {
 	if dt.rawDataEncoding == solana.EncodingJSONParsed || dt.rawDataEncoding == solana.EncodingJSON {
 		return json.Marshal(dt.asJSON)
 	}
+	if dt.compressed != nil {
+		// Re-use the payload as received instead of compressing it again.
+		return json.Marshal(
+			[]interface{}{
+				base64.StdEncoding.EncodeToString(dt.compressed.content),
+				solana.EncodingBase64Zstd,
+			})
+	}
 	return json.Marshal(dt.asDecodedBinary)
 }
 
@@ -343,6 +402,18 @@ func (wrap *DataBytesOrJSON) UnmarshalJSON(data []byte) error {
 	case '[':
 		// It's base64 (or similar)
 		{
+			var in []string
+			if err := json.Unmarshal(data, &in); err == nil && len(in) == 2 && solana.EncodingType(in[1]) == solana.EncodingBase64Zstd {
+				// Defer decompression until the data is actually accessed.
+				compressed, err := base64.StdEncoding.DecodeString(in[0])
+				if err != nil {
+					return err
+				}
+				wrap.compressed = &compressedData{content: compressed}
+				wrap.asDecodedBinary.Encoding = solana.EncodingBase64Zstd
+				wrap.rawDataEncoding = solana.EncodingBase64Zstd
+				return nil
+			}
 			err := wrap.asDecodedBinary.UnmarshalJSON(data)
 			if err != nil {
 				return err
@@ -366,8 +437,42 @@ func (wrap *DataBytesOrJSON) UnmarshalJSON(data []byte) error {
 
 // GetBinary returns the decoded bytes if the encoding is
 // "base58", "base64", or "base64+zstd".
+// It returns nil if "base64+zstd" data cannot be decompressed;
+// use Binary to get the error.
 func (dt *DataBytesOrJSON) GetBinary() []byte {
-	return dt.asDecodedBinary.Content
+	content, _ := dt.Binary()
+	return content
+}
+
+// Binary returns the decoded bytes if the encoding is
+// "base58", "base64", or "base64+zstd".
+// "base64+zstd" data is decompressed on the first call
+// and the result is cached for subsequent calls.
+func (dt *DataBytesOrJSON) Binary() ([]byte, error) {
+	if dt == nil {
+		return nil, nil
+	}
+	if dt.compressed != nil {
+		return dt.compressed.decode()
+	}
+	return dt.asDecodedBinary.Content, nil
+}
+
+// Encoding returns the encoding in which the data was received.
+func (dt *DataBytesOrJSON) Encoding() solana.EncodingType {
+	if dt == nil {
+		return ""
+	}
+	return dt.rawDataEncoding
+}
+
+// IsJSON returns true if the data was received as JSON
+// (i.e. "jsonParsed" encoding with an available parser).
+func (dt *DataBytesOrJSON) IsJSON() bool {
+	if dt == nil {
+		return false
+	}
+	return dt.rawDataEncoding == solana.EncodingJSONParsed || dt.rawDataEncoding == solana.EncodingJSON
 }
 
 // GetRawJSON returns a stdjson.RawMessage when the data
@@ -392,6 +497,10 @@ type GetProgramAccountsOpts struct {
 	// Filter results using various filter objects;
 	// account must meet all filter criteria to be included in results.
 	Filters []RPCFilter `json:"filters,omitempty"`
+
+	// The minimum slot that the request can be evaluated at.
+	// This parameter is optional.
+	MinContextSlot *uint64 `json:"minContextSlot,omitempty"`
 }
 
 type GetProgramAccountsResult []*KeyedAccount
@@ -418,6 +527,10 @@ type RPCFilter struct {
 type RPCFilterMemcmp struct {
 	Offset uint64        `json:"offset"`
 	Bytes  solana.Base58 `json:"bytes"`
+
+	// Encoding of Bytes in the request: "base58" (default) or "base64".
+	// Nodes only accept base58 for up to MaxBase58MemcmpBytes bytes.
+	Encoding solana.EncodingType `json:"encoding,omitempty"`
 }
 
 type CommitmentType string
@@ -113,3 +113,110 @@ func TestData_DataBytesOrJSONFromBytes(t *testing.T) {
 	out := dataBytesOrJSON.GetBinary()
 	assert.Equal(t, in, out)
 }
+
+func TestData_base64_zstd_lazy(t *testing.T) {
+	val := "KLUv/QQAWQAAaGVsbG8td29ybGTcLcaB"
+	in := `["` + val + `", "base64+zstd"]`
+
+	var data DataBytesOrJSON
+	err := data.UnmarshalJSON([]byte(in))
+	assert.NoError(t, err)
+	assert.Equal(t, solana.EncodingBase64Zstd, data.Encoding())
+	assert.False(t, data.IsJSON())
+
+	// Nothing is decompressed until the data is accessed.
+	assert.Nil(t, data.asDecodedBinary.Content)
+
+	first, err := data.Binary()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello-world"), first)
+
+	second, err := data.Binary()
+	assert.NoError(t, err)
+	assert.Equal(t, &first[0], &second[0])
+
+	acc := &Account{Data: &data}
+	assert.Equal(t, []byte("hello-world"), acc.GetBinary())
+}
+
+func TestData_base64_zstd_invalid(t *testing.T) {
+	in := `["aGVsbG8td29ybGQ=", "base64+zstd"]`
+
+	var data DataBytesOrJSON
+	err := data.UnmarshalJSON([]byte(in))
+	assert.NoError(t, err)
+
+	_, err = data.Binary()
+	assert.Error(t, err)
+	assert.Nil(t, data.GetBinary())
+}
+
+func TestGetEpochScheduleResult(t *testing.T) {
+	// Mainnet-beta schedule, with warmup.
+	schedule := &GetEpochScheduleResult{
+		SlotsPerEpoch:    432000,
+		Warmup:           true,
+		FirstNormalEpoch: 14,
+		FirstNormalSlot:  524256,
+	}
+
+	epoch, index := schedule.GetEpoch(0)
+	assert.Equal(t, uint64(0), epoch)
+	assert.Equal(t, uint64(0), index)
+
+	epoch, index = schedule.GetEpoch(31)
+	assert.Equal(t, uint64(0), epoch)
+	assert.Equal(t, uint64(31), index)
+
+	epoch, index = schedule.GetEpoch(32)
+	assert.Equal(t, uint64(1), epoch)
+	assert.Equal(t, uint64(0), index)
+	assert.Equal(t, uint64(64), schedule.GetSlotsInEpoch(1))
+	assert.Equal(t, uint64(32), schedule.GetFirstSlotInEpoch(1))
+
+	epoch, index = schedule.GetEpoch(524255)
+	assert.Equal(t, uint64(13), epoch)
+	assert.Equal(t, schedule.GetSlotsInEpoch(13)-1, index)
+
+	epoch, index = schedule.GetEpoch(524256 + 432000*2 + 7)
+	assert.Equal(t, uint64(16), epoch)
+	assert.Equal(t, uint64(7), index)
+	assert.Equal(t, uint64(524256+432000*2), schedule.GetFirstSlotInEpoch(16))
+	assert.Equal(t, uint64(432000), schedule.GetSlotsInEpoch(16))
+	assert.Equal(t, uint64(524256), schedule.GetFirstSlotInEpoch(14))
+}
+
+func TestLeaderSchedule(t *testing.T) {
+	a, b := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	schedule := NewLeaderSchedule(GetLeaderScheduleResult{
+		a: {0, 1, 2, 3},
+		b: {4, 5, 6, 7, 99}, // out of the epoch
+	}, 1, 32, 8)
+	assert.Equal(t, uint64(39), schedule.LastSlot())
+
+	leader, ok := schedule.Leader(32)
+	assert.True(t, ok)
+	assert.Equal(t, a, leader)
+	leader, ok = schedule.Leader(39)
+	assert.True(t, ok)
+	assert.Equal(t, b, leader)
+	_, ok = schedule.Leader(31)
+	assert.False(t, ok)
+	_, ok = schedule.Leader(40)
+	assert.False(t, ok)
+}
+
+func TestIdentityToSlotsBlocks_Get(t *testing.T) {
+	identity := solana.NewWallet().PublicKey()
+	production := IdentityToSlotsBlocks{identity: {8, 6}}
+
+	got, ok := production.Get(identity)
+	assert.True(t, ok)
+	assert.Equal(t, BlockProduction{LeaderSlots: 8, BlocksProduced: 6}, got)
+	assert.Equal(t, int64(2), got.SkippedSlots())
+	assert.Equal(t, 0.25, got.SkipRate())
+
+	_, ok = production.Get(solana.NewWallet().PublicKey())
+	assert.False(t, ok)
+	assert.Equal(t, 0.0, BlockProduction{}.SkipRate())
+}
@@ -0,0 +1,294 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validators joins the vote accounts, the gossip nodes and the
+// published validator info of a cluster into a Directory of validators,
+// with lookups by identity and vote account, and stake weights.
+package validators
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"go.uber.org/zap"
+)
+
+// ValidatorInfoKey is the first key of the Config program accounts
+// holding validator info.
+var ValidatorInfoKey = solana.MustPublicKeyFromBase58("Va1idator1nfo111111111111111111111111111111")
+
+var ErrNotValidatorInfo = errors.New("account is not a validator info account")
+
+type Opts struct {
+	// Commitment of the vote accounts and validator info.
+	// Defaults to the commitment of the node (finalized).
+	Commitment rpc.CommitmentType
+
+	// SkipNodes does not query the gossip nodes (getClusterNodes).
+	SkipNodes bool
+
+	// SkipInfo does not query the validator info accounts.
+	SkipInfo bool
+}
+
+// Info is the information a validator published
+// with `solana validator-info publish`.
+type Info struct {
+	Name            string `json:"name,omitempty"`
+	Website         string `json:"website,omitempty"`
+	Details         string `json:"details,omitempty"`
+	KeybaseUsername string `json:"keybaseUsername,omitempty"`
+	IconURL         string `json:"iconUrl,omitempty"`
+}
+
+// Validator is a vote account, joined with the gossip node
+// and the published info of its identity.
+type Validator struct {
+	Identity    solana.PublicKey
+	VoteAccount solana.PublicKey
+
+	// Delinquent is true if the validator is not voting
+	// (it is in the delinquent list of getVoteAccounts).
+	Delinquent bool
+
+	// Vote is the vote account as returned by getVoteAccounts.
+	Vote rpc.VoteAccountsResult
+
+	// Node is the gossip node of the identity,
+	// or nil if the validator is not in gossip.
+	Node *rpc.GetClusterNodesResult
+
+	// Info is the published info of the identity,
+	// or nil if none was published.
+	Info *Info
+
+	// StakeWeight is the fraction (0-1) of the total activated stake
+	// delegated to the vote account.
+	StakeWeight float64
+}
+
+// Directory is a snapshot of the validators of a cluster.
+type Directory struct {
+	validators      []*Validator
+	byIdentity      map[solana.PublicKey]*Validator
+	byVoteAccount   map[solana.PublicKey]*Validator
+	totalStake      uint64
+	delinquentStake uint64
+}
+
+// Load queries the vote accounts, the gossip nodes and the validator info
+// accounts, and joins them into a Directory.
+func Load(ctx context.Context, client *rpc.Client, opts *Opts) (*Directory, error) {
+	if opts == nil {
+		opts = &Opts{}
+	}
+	votes, err := client.GetVoteAccounts(ctx, &rpc.GetVoteAccountsOpts{Commitment: opts.Commitment})
+	if err != nil {
+		return nil, fmt.Errorf("validators: get vote accounts: %w", err)
+	}
+	var nodes []*rpc.GetClusterNodesResult
+	if !opts.SkipNodes {
+		nodes, err = client.GetClusterNodes(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("validators: get cluster nodes: %w", err)
+		}
+	}
+	var infos map[solana.PublicKey]*Info
+	if !opts.SkipInfo {
+		infos, err = GetInfos(ctx, client, opts.Commitment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewDirectory(votes, nodes, infos), nil
+}
+
+// GetInfos returns the published validator info, by identity.
+func GetInfos(ctx context.Context, client *rpc.Client, commitment rpc.CommitmentType) (map[solana.PublicKey]*Info, error) {
+	accounts, err := client.GetProgramAccountsWithOpts(ctx, solana.ConfigProgramID, &rpc.GetProgramAccountsOpts{
+		Commitment: commitment,
+		Encoding:   solana.EncodingBase64,
+		Filters: []rpc.RPCFilter{
+			// The keys are prefixed by their compact-u16 count.
+			{Memcmp: &rpc.RPCFilterMemcmp{Offset: 1, Bytes: solana.Base58(ValidatorInfoKey[:])}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("validators: get validator info accounts: %w", err)
+	}
+	infos := make(map[solana.PublicKey]*Info, len(accounts))
+	for _, account := range accounts {
+		if account.Account == nil {
+			continue
+		}
+		identity, info, err := ParseInfo(account.Account.Data.GetBinary())
+		if err != nil {
+			// Malformed info is skipped, as validators publish arbitrary data.
+			zlog.Debug("skipping validator info account",
+				zap.Stringer("account", account.Pubkey),
+				zap.Error(err),
+			)
+			continue
+		}
+		infos[identity] = info
+	}
+	return infos, nil
+}
+
+// ParseInfo parses the data of a validator info account
+// of the Config program, and returns the identity that signed it.
+func ParseInfo(data []byte) (identity solana.PublicKey, info *Info, err error) {
+	dec := bin.NewBinDecoder(data)
+	count, err := dec.ReadCompactU16()
+	if err != nil {
+		return identity, nil, fmt.Errorf("read config keys: %w", err)
+	}
+	if count < 2 {
+		return identity, nil, ErrNotValidatorInfo
+	}
+	keys := make([]solana.PublicKey, count)
+	signers := make([]bool, count)
+	for i := range keys {
+		raw, err := dec.ReadNBytes(solana.PublicKeyLength)
+		if err != nil {
+			return identity, nil, fmt.Errorf("read config key %d: %w", i, err)
+		}
+		keys[i] = solana.PublicKeyFromBytes(raw)
+		if signers[i], err = dec.ReadBool(); err != nil {
+			return identity, nil, fmt.Errorf("read config key %d: %w", i, err)
+		}
+	}
+	if !keys[0].Equals(ValidatorInfoKey) || !signers[1] {
+		return identity, nil, ErrNotValidatorInfo
+	}
+	raw, err := dec.ReadRustString()
+	if err != nil {
+		return identity, nil, fmt.Errorf("read validator info: %w", err)
+	}
+	info = &Info{}
+	if err := json.Unmarshal([]byte(raw), info); err != nil {
+		return identity, nil, fmt.Errorf("decode validator info: %w", err)
+	}
+	return keys[1], info, nil
+}
+
+// NewDirectory joins the provided vote accounts, gossip nodes and validator info
+// (by identity) into a Directory. nodes and infos may be nil.
+func NewDirectory(
+	votes *rpc.GetVoteAccountsResult,
+	nodes []*rpc.GetClusterNodesResult,
+	infos map[solana.PublicKey]*Info,
+) *Directory {
+	d := &Directory{
+		byIdentity:    map[solana.PublicKey]*Validator{},
+		byVoteAccount: map[solana.PublicKey]*Validator{},
+	}
+	nodeByIdentity := make(map[solana.PublicKey]*rpc.GetClusterNodesResult, len(nodes))
+	for _, node := range nodes {
+		nodeByIdentity[node.Pubkey] = node
+	}
+	add := func(vote rpc.VoteAccountsResult, delinquent bool) {
+		v := &Validator{
+			Identity:    vote.NodePubkey,
+			VoteAccount: vote.VotePubkey,
+			Delinquent:  delinquent,
+			Vote:        vote,
+			Node:        nodeByIdentity[vote.NodePubkey],
+			Info:        infos[vote.NodePubkey],
+		}
+		d.validators = append(d.validators, v)
+		d.byVoteAccount[v.VoteAccount] = v
+		// An identity with several vote accounts is looked up
+		// by the one with the most stake.
+		if prev, ok := d.byIdentity[v.Identity]; !ok || prev.Vote.ActivatedStake < vote.ActivatedStake {
+			d.byIdentity[v.Identity] = v
+		}
+		d.totalStake += vote.ActivatedStake
+		if delinquent {
+			d.delinquentStake += vote.ActivatedStake
+		}
+	}
+	if votes != nil {
+		for _, vote := range votes.Current {
+			add(vote, false)
+		}
+		for _, vote := range votes.Delinquent {
+			add(vote, true)
+		}
+	}
+	for _, v := range d.validators {
+		if d.totalStake > 0 {
+			v.StakeWeight = float64(v.Vote.ActivatedStake) / float64(d.totalStake)
+		}
+	}
+	sort.SliceStable(d.validators, func(i, j int) bool {
+		return d.validators[i].Vote.ActivatedStake > d.validators[j].Vote.ActivatedStake
+	})
+	return d
+}
+
+// Validators returns the validators, by descending activated stake.
+func (d *Directory) Validators() []*Validator {
+	return d.validators
+}
+
+// ByIdentity returns the validator with the provided identity, or nil.
+func (d *Directory) ByIdentity(identity solana.PublicKey) *Validator {
+	return d.byIdentity[identity]
+}
+
+// ByVoteAccount returns the validator with the provided vote account, or nil.
+func (d *Directory) ByVoteAccount(voteAccount solana.PublicKey) *Validator {
+	return d.byVoteAccount[voteAccount]
+}
+
+// Delinquent returns the delinquent validators, by descending activated stake.
+func (d *Directory) Delinquent() []*Validator {
+	var out []*Validator
+	for _, v := range d.validators {
+		if v.Delinquent {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// TotalStake returns the activated stake of all the validators, in lamports.
+func (d *Directory) TotalStake() uint64 {
+	return d.totalStake
+}
+
+// DelinquentStake returns the activated stake of the delinquent validators, in lamports.
+func (d *Directory) DelinquentStake() uint64 {
+	return d.delinquentStake
+}
+
+// Superminority returns the smallest number of validators that together
+// hold more than a third of the total activated stake (the Nakamoto coefficient).
+func (d *Directory) Superminority() []*Validator {
+	var stake uint64
+	for i, v := range d.validators {
+		stake += v.Vote.ActivatedStake
+		if stake*3 > d.totalStake {
+			return d.validators[:i+1]
+		}
+	}
+	return d.validators
+}
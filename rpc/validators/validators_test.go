@@ -0,0 +1,121 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validators
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func infoAccountData(identity solana.PublicKey, info string) []byte {
+	data := []byte{2}
+	data = append(data, ValidatorInfoKey[:]...)
+	data = append(data, 0)
+	data = append(data, identity[:]...)
+	data = append(data, 1)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(info)))
+	return append(data, info...)
+}
+
+func TestLoad(t *testing.T) {
+	alice := solana.NewWallet().PublicKey()
+	aliceVote := solana.NewWallet().PublicKey()
+	bob := solana.NewWallet().PublicKey()
+	bobVote := solana.NewWallet().PublicKey()
+	carol := solana.NewWallet().PublicKey()
+	carolVote := solana.NewWallet().PublicKey()
+
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getVoteAccounts", rpc.M{
+		"current": []rpc.M{
+			{"votePubkey": aliceVote, "nodePubkey": alice, "activatedStake": 100, "commission": 5},
+			{"votePubkey": bobVote, "nodePubkey": bob, "activatedStake": 300},
+		},
+		"delinquent": []rpc.M{
+			{"votePubkey": carolVote, "nodePubkey": carol, "activatedStake": 600},
+		},
+	})
+	server.Handle("getClusterNodes", []rpc.M{
+		{"pubkey": alice, "gossip": "10.0.0.1:8001", "version": "1.18.0"},
+	})
+	server.Handle("getProgramAccounts", []rpc.M{
+		{
+			"pubkey": solana.NewWallet().PublicKey(),
+			"account": rpc.M{
+				"owner": solana.ConfigProgramID,
+				"data":  []string{base64.StdEncoding.EncodeToString(infoAccountData(alice, `{"name":"Alice","website":"https://alice.example"}`)), "base64"},
+			},
+		},
+		{
+			"pubkey": solana.NewWallet().PublicKey(),
+			"account": rpc.M{
+				"owner": solana.ConfigProgramID,
+				"data":  []string{base64.StdEncoding.EncodeToString([]byte{2, 1, 2}), "base64"},
+			},
+		},
+	})
+
+	dir, err := Load(context.Background(), rpc.New(server.URL()), nil)
+	require.NoError(t, err)
+
+	require.Len(t, dir.Validators(), 3)
+	assert.Equal(t, carol, dir.Validators()[0].Identity)
+	assert.Equal(t, uint64(1000), dir.TotalStake())
+	assert.Equal(t, uint64(600), dir.DelinquentStake())
+
+	a := dir.ByIdentity(alice)
+	require.NotNil(t, a)
+	assert.Same(t, a, dir.ByVoteAccount(aliceVote))
+	assert.False(t, a.Delinquent)
+	assert.InDelta(t, 0.1, a.StakeWeight, 1e-9)
+	assert.Equal(t, uint8(5), a.Vote.Commission)
+	require.NotNil(t, a.Node)
+	assert.Equal(t, "10.0.0.1:8001", *a.Node.Gossip)
+	require.NotNil(t, a.Info)
+	assert.Equal(t, "Alice", a.Info.Name)
+	assert.Equal(t, "https://alice.example", a.Info.Website)
+
+	b := dir.ByIdentity(bob)
+	require.NotNil(t, b)
+	assert.Nil(t, b.Node)
+	assert.Nil(t, b.Info)
+
+	require.Len(t, dir.Delinquent(), 1)
+	assert.Equal(t, carol, dir.Delinquent()[0].Identity)
+	assert.Len(t, dir.Superminority(), 1)
+	assert.Nil(t, dir.ByIdentity(solana.NewWallet().PublicKey()))
+}
+
+func TestParseInfo(t *testing.T) {
+	identity := solana.NewWallet().PublicKey()
+	got, info, err := ParseInfo(infoAccountData(identity, `{"name":"Alice","keybaseUsername":"alice","iconUrl":"https://alice.example/icon.png"}`))
+	require.NoError(t, err)
+	assert.Equal(t, identity, got)
+	assert.Equal(t, &Info{Name: "Alice", KeybaseUsername: "alice", IconURL: "https://alice.example/icon.png"}, info)
+
+	other := infoAccountData(identity, `{}`)
+	other[1] = 0 // Not the validator info key.
+	_, _, err = ParseInfo(other)
+	assert.ErrorIs(t, err, ErrNotValidatorInfo)
+}
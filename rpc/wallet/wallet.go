@@ -0,0 +1,366 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wallet takes snapshots of the portfolio of a wallet:
+// its SOL balance, its token balances with their prices,
+// and its NFT holdings.
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// DefaultAssetsPageSize is the number of assets
+// requested per page from the DAS API.
+var DefaultAssetsPageSize = 1000
+
+type Opts struct {
+	// Commitment of the balances.
+	// Defaults to the commitment of the node (finalized).
+	Commitment rpc.CommitmentType
+
+	// Helius, if not nil, is queried (DAS getAssetsByOwner) for the prices
+	// of SOL and of the tokens, and for the NFT holdings.
+	//
+	// This parameter is optional.
+	Helius *rpc.HeliusClient
+
+	// ShowZeroBalance keeps the token accounts without tokens in the snapshot.
+	ShowZeroBalance bool
+}
+
+// Wallet is a public key, with the clients to query its holdings.
+type Wallet struct {
+	PublicKey solana.PublicKey
+
+	client *rpc.Client
+	opts   Opts
+}
+
+// New creates a new Wallet for the owner.
+func New(owner solana.PublicKey, client *rpc.Client, opts *Opts) *Wallet {
+	w := &Wallet{
+		PublicKey: owner,
+		client:    client,
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	return w
+}
+
+// Price is the price of a unit of a token (or of one SOL).
+type Price struct {
+	PerToken float64
+	// Currency of the price, e.g. "USDC".
+	Currency string
+}
+
+// Token is the balance of a token account of the wallet.
+type Token struct {
+	Account solana.PublicKey
+	Mint    solana.PublicKey
+	// Program is solana.TokenProgramID or solana.Token2022ProgramID.
+	Program solana.PublicKey
+
+	// Raw amount of tokens, ignoring decimals.
+	Amount   uint64
+	Decimals uint8
+	// Amount of tokens as a string, accounting for decimals.
+	UiAmountString string
+
+	// Symbol of the token, if known by the DAS API.
+	Symbol string
+	// Price of the token, or nil if not available.
+	Price *Price
+}
+
+// UiAmount returns the amount of tokens, accounting for decimals.
+func (t *Token) UiAmount() float64 {
+	f, _ := new(big.Float).Quo(
+		new(big.Float).SetUint64(t.Amount),
+		new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.Decimals)), nil)),
+	).Float64()
+	return f
+}
+
+// Value returns the value of the balance in the currency of its price,
+// and false if the price is not available.
+func (t *Token) Value() (float64, bool) {
+	if t.Price == nil {
+		return 0, false
+	}
+	return t.UiAmount() * t.Price.PerToken, true
+}
+
+// NFT is a non-fungible asset held by the wallet.
+type NFT struct {
+	ID        string
+	Interface rpc.AssetInterface
+	Name      string
+	// Collection of the NFT, if any.
+	Collection string
+	// Compressed is true for compressed NFTs, which have no token account.
+	Compressed bool
+}
+
+// Portfolio is a snapshot of the holdings of a wallet.
+type Portfolio struct {
+	Owner solana.PublicKey
+	// Slot at which the SOL balance was read.
+	Slot uint64
+
+	Lamports uint64
+	// SolPrice is the price of one SOL, or nil if not available.
+	SolPrice *Price
+
+	// Tokens are the balances of the token accounts of both token programs,
+	// NFTs included, by decreasing value then by mint.
+	Tokens []Token
+	// NFTs are the NFT holdings, including compressed NFTs;
+	// they are only available when Opts.Helius is set.
+	NFTs []NFT
+}
+
+// TotalValue returns the value of the SOL and of the tokens with a price,
+// assuming that all prices share the same currency.
+func (p *Portfolio) TotalValue() float64 {
+	var total float64
+	if p.SolPrice != nil {
+		total += float64(p.Lamports) / float64(solana.LAMPORTS_PER_SOL) * p.SolPrice.PerToken
+	}
+	for i := range p.Tokens {
+		if value, ok := p.Tokens[i].Value(); ok {
+			total += value
+		}
+	}
+	return total
+}
+
+// Snapshot fetches, concurrently, the SOL balance, the token accounts
+// of both token programs and, if Opts.Helius is set, the assets of the
+// wallet, and joins them into a Portfolio.
+func (w *Wallet) Snapshot(ctx context.Context) (*Portfolio, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		lock     sync.Mutex
+		firstErr error
+	)
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				lock.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				lock.Unlock()
+			}
+		}()
+	}
+
+	out := &Portfolio{Owner: w.PublicKey}
+	run(func() error {
+		balance, err := w.client.GetBalance(ctx, w.PublicKey, w.opts.Commitment)
+		if err != nil {
+			return fmt.Errorf("wallet: get balance: %w", err)
+		}
+		out.Slot, out.Lamports = balance.Context.Slot, balance.Value
+		return nil
+	})
+	programs := []solana.PublicKey{solana.TokenProgramID, solana.Token2022ProgramID}
+	tokens := make([][]Token, len(programs))
+	for i, program := range programs {
+		i, program := i, program
+		run(func() (err error) {
+			tokens[i], err = w.tokenAccounts(ctx, program)
+			return err
+		})
+	}
+	var assets *holdings
+	if w.opts.Helius != nil {
+		run(func() (err error) {
+			assets, err = w.assets(ctx)
+			return err
+		})
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	for _, programTokens := range tokens {
+		out.Tokens = append(out.Tokens, programTokens...)
+	}
+	if assets != nil {
+		out.SolPrice = assets.solPrice
+		out.NFTs = assets.nfts
+		for i := range out.Tokens {
+			token := &out.Tokens[i]
+			if fungible, ok := assets.fungibles[token.Mint.String()]; ok {
+				token.Symbol, token.Price = fungible.symbol, fungible.price
+			}
+		}
+	}
+	sort.SliceStable(out.Tokens, func(i, j int) bool {
+		vi, _ := out.Tokens[i].Value()
+		vj, _ := out.Tokens[j].Value()
+		if vi != vj {
+			return vi > vj
+		}
+		return out.Tokens[i].Mint.String() < out.Tokens[j].Mint.String()
+	})
+	return out, nil
+}
+
+// parsedTokenAccount is the jsonParsed data of a token account.
+type parsedTokenAccount struct {
+	Parsed struct {
+		Info struct {
+			Mint        solana.PublicKey  `json:"mint"`
+			TokenAmount rpc.UiTokenAmount `json:"tokenAmount"`
+		} `json:"info"`
+	} `json:"parsed"`
+}
+
+// tokenAccounts returns the balances of the token accounts of the program.
+func (w *Wallet) tokenAccounts(ctx context.Context, program solana.PublicKey) ([]Token, error) {
+	resp, err := w.client.GetTokenAccountsByOwner(
+		ctx,
+		w.PublicKey,
+		&rpc.GetTokenAccountsConfig{ProgramId: &program},
+		&rpc.GetTokenAccountsOpts{
+			Commitment: w.opts.Commitment,
+			Encoding:   solana.EncodingJSONParsed,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("wallet: get token accounts of %s: %w", program, err)
+	}
+	var out []Token
+	for _, account := range resp.Value {
+		var parsed parsedTokenAccount
+		if err := json.Unmarshal(account.Account.Data.GetRawJSON(), &parsed); err != nil {
+			return nil, fmt.Errorf("wallet: decode token account %s: %w", account.Pubkey, err)
+		}
+		info := parsed.Parsed.Info
+		amount, err := strconv.ParseUint(info.TokenAmount.Amount, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("wallet: decode amount of token account %s: %w", account.Pubkey, err)
+		}
+		if amount == 0 && !w.opts.ShowZeroBalance {
+			continue
+		}
+		out = append(out, Token{
+			Account:        account.Pubkey,
+			Mint:           info.Mint,
+			Program:        program,
+			Amount:         amount,
+			Decimals:       info.TokenAmount.Decimals,
+			UiAmountString: info.TokenAmount.UiAmountString,
+		})
+	}
+	return out, nil
+}
+
+type fungible struct {
+	symbol string
+	price  *Price
+}
+
+// holdings are the prices and NFTs returned by the DAS API.
+type holdings struct {
+	solPrice  *Price
+	fungibles map[string]fungible // by mint
+	nfts      []NFT
+}
+
+// assets fetches all the assets of the wallet from the DAS API.
+func (w *Wallet) assets(ctx context.Context) (*holdings, error) {
+	out := &holdings{fungibles: map[string]fungible{}}
+	limit := DefaultAssetsPageSize
+	for page := 1; ; page++ {
+		page := page
+		resp, err := w.opts.Helius.GetAssetsByOwner(ctx, rpc.GetAssetsByOwnerOpts{
+			OwnerAddress: w.PublicKey.String(),
+			Page:         &page,
+			Limit:        &limit,
+			Options: &rpc.GetAssetsByOwnerOptions{
+				ShowFungible:      true,
+				ShowNativeBalance: true,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("wallet: get assets: %w", err)
+		}
+		if native := resp.NativeBalance; native != nil && native.PricePerSol > 0 {
+			out.solPrice = &Price{PerToken: native.PricePerSol, Currency: "USDC"}
+		}
+		for i := range resp.Items {
+			item := &resp.Items[i]
+			switch {
+			case item.Interface.IsFungible():
+				var f fungible
+				if item.Content != nil && item.Content.Metadata != nil {
+					f.symbol = item.Content.Metadata.Symbol
+				}
+				if item.TokenInfo != nil && item.TokenInfo.PriceInfo != nil {
+					f.price = &Price{
+						PerToken: item.TokenInfo.PriceInfo.PricePerToken,
+						Currency: item.TokenInfo.PriceInfo.Currency,
+					}
+				}
+				out.fungibles[item.Id] = f
+			case item.Interface.IsNFT():
+				out.nfts = append(out.nfts, newNFT(item))
+			}
+		}
+		if len(resp.Items) < limit {
+			return out, nil
+		}
+	}
+}
+
+func newNFT(item *rpc.GetAssetsByOwnerItem) NFT {
+	nft := NFT{
+		ID:        item.Id,
+		Interface: item.Interface,
+	}
+	if item.Content != nil && item.Content.Metadata != nil {
+		nft.Name = item.Content.Metadata.Name
+	}
+	for _, group := range item.Grouping {
+		if group.GroupKey == "collection" {
+			nft.Collection = group.GroupValue
+		}
+	}
+	if item.Compression != nil {
+		nft.Compressed = item.Compression.Compressed
+	}
+	return nft
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wallet
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tokenAccount(pubkey, mint solana.PublicKey, program string, amount string, decimals uint8, ui string) rpc.M {
+	return rpc.M{
+		"pubkey": pubkey,
+		"account": rpc.M{
+			"lamports": 2039280,
+			"owner":    program,
+			"data": rpc.M{
+				"program": program,
+				"parsed": rpc.M{
+					"type": "account",
+					"info": rpc.M{
+						"mint":        mint,
+						"tokenAmount": rpc.M{"amount": amount, "decimals": decimals, "uiAmountString": ui},
+					},
+				},
+				"space": 165,
+			},
+		},
+	}
+}
+
+func TestWallet_Snapshot(t *testing.T) {
+	owner := solana.NewWallet().PublicKey()
+	usdc, usdcAccount := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	pyusd, pyusdAccount := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	empty, emptyAccount := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getBalance", rpc.M{"context": rpc.M{"slot": 42}, "value": 2_500_000_000})
+	server.HandleFunc("getTokenAccountsByOwner", func(params stdjson.RawMessage) (interface{}, error) {
+		var in []stdjson.RawMessage
+		require.NoError(t, stdjson.Unmarshal(params, &in))
+		var conf struct{ ProgramId solana.PublicKey }
+		require.NoError(t, stdjson.Unmarshal(in[1], &conf))
+		var value []rpc.M
+		if conf.ProgramId.Equals(solana.TokenProgramID) {
+			value = []rpc.M{
+				tokenAccount(usdcAccount, usdc, solana.TokenProgramID.String(), "12500000", 6, "12.5"),
+				tokenAccount(emptyAccount, empty, solana.TokenProgramID.String(), "0", 9, "0"),
+			}
+		} else {
+			value = []rpc.M{
+				tokenAccount(pyusdAccount, pyusd, solana.Token2022ProgramID.String(), "3000000", 6, "3"),
+			}
+		}
+		return rpc.M{"context": rpc.M{"slot": 42}, "value": value}, nil
+	})
+	server.Handle("getAssetsByOwner", rpc.M{
+		"total": 3,
+		"limit": 1000,
+		"page":  1,
+		"items": []rpc.M{
+			{
+				"interface":  "FungibleToken",
+				"id":         usdc.String(),
+				"content":    rpc.M{"metadata": rpc.M{"symbol": "USDC"}},
+				"token_info": rpc.M{"price_info": rpc.M{"price_per_token": 1.0, "currency": "USDC"}},
+			},
+			{
+				"interface":   "V1_NFT",
+				"id":          "nft",
+				"content":     rpc.M{"metadata": rpc.M{"name": "Mad Lad #1"}},
+				"grouping":    []rpc.M{{"group_key": "collection", "group_value": "lads"}},
+				"compression": rpc.M{"compressed": true},
+			},
+		},
+		"native_balance": rpc.M{"lamports": 2_500_000_000, "price_per_sol": 150.0},
+	})
+
+	client := rpc.New(server.URL())
+	w := New(owner, client, &Opts{Helius: &rpc.HeliusClient{Client: client}})
+	out, err := w.Snapshot(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, owner, out.Owner)
+	assert.Equal(t, uint64(42), out.Slot)
+	assert.Equal(t, uint64(2_500_000_000), out.Lamports)
+	assert.Equal(t, &Price{PerToken: 150, Currency: "USDC"}, out.SolPrice)
+
+	require.Len(t, out.Tokens, 2)
+	assert.Equal(t, usdc, out.Tokens[0].Mint)
+	assert.Equal(t, "USDC", out.Tokens[0].Symbol)
+	assert.Equal(t, uint64(12_500_000), out.Tokens[0].Amount)
+	assert.Equal(t, uint8(6), out.Tokens[0].Decimals)
+	value, ok := out.Tokens[0].Value()
+	assert.True(t, ok)
+	assert.Equal(t, 12.5, value)
+
+	assert.Equal(t, pyusd, out.Tokens[1].Mint)
+	assert.Equal(t, solana.Token2022ProgramID, out.Tokens[1].Program)
+	assert.Nil(t, out.Tokens[1].Price)
+
+	assert.Equal(t, []NFT{{ID: "nft", Interface: rpc.AssetInterfaceV1NFT, Name: "Mad Lad #1", Collection: "lads", Compressed: true}}, out.NFTs)
+	assert.Equal(t, 2.5*150+12.5, out.TotalValue())
+}
+
+func TestWallet_Snapshot_error(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getBalance", rpc.M{"context": rpc.M{"slot": 42}, "value": 1})
+	server.HandleError("getTokenAccountsByOwner", -32602, "invalid params")
+
+	w := New(solana.NewWallet().PublicKey(), rpc.New(server.URL()), nil)
+	_, err := w.Snapshot(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "get token accounts")
+}
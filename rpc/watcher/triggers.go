@@ -0,0 +1,193 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watcher
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// Source is a set of streams a trigger is evaluated over.
+type Source uint8
+
+const (
+	// Account notifications of the watched address.
+	SourceAccount Source = 1 << iota
+	// Logs of the transactions mentioning the watched address.
+	SourceLogs
+	// Details of the transactions mentioning the watched address,
+	// fetched with getTransaction for every logs notification.
+	SourceTransaction
+)
+
+type EventKind int
+
+const (
+	EventAccount EventKind = iota
+	EventTransaction
+)
+
+// Event is an activity of a watched address, evaluated against its triggers.
+type Event struct {
+	Kind    EventKind
+	Address solana.PublicKey
+	Slot    uint64
+
+	// Set on EventAccount:
+
+	Account  *rpc.Account
+	Lamports uint64
+	// Lamports before this update; nil on the first update.
+	PrevLamports *uint64
+
+	// Set on EventTransaction:
+
+	Signature solana.Signature
+	Failed    bool
+	Logs      []string
+	// Programs invoked by the transaction, as reported by its logs.
+	Programs []solana.PublicKey
+
+	// Set on EventTransaction when a trigger requires SourceTransaction:
+
+	Transaction *rpc.GetTransactionResult
+	// Change of the address balance in the transaction,
+	// not counting the fee if the address paid it.
+	LamportsDelta int64
+	// Mints of the token accounts owned by the address
+	// whose balance decreased in the transaction.
+	OutgoingTokens []solana.PublicKey
+}
+
+// Trigger is a condition evaluated on every event of a watched address.
+type Trigger interface {
+	// Name identifies the trigger in alerts.
+	Name() string
+	// Sources returns the streams the trigger must be evaluated over.
+	Sources() Source
+	// Match reports whether the event satisfies the condition.
+	Match(ev *Event) bool
+}
+
+type funcTrigger struct {
+	name    string
+	sources Source
+	match   func(ev *Event) bool
+}
+
+func (t *funcTrigger) Name() string         { return t.name }
+func (t *funcTrigger) Sources() Source      { return t.sources }
+func (t *funcTrigger) Match(ev *Event) bool { return t.match(ev) }
+
+// NewTrigger creates a trigger from a function.
+func NewTrigger(name string, sources Source, match func(ev *Event) bool) Trigger {
+	return &funcTrigger{name: name, sources: sources, match: match}
+}
+
+// BalanceBelow fires when the balance of the address drops below
+// the provided amount of lamports. It fires again only after
+// the balance went back above the threshold.
+func BalanceBelow(lamports uint64) Trigger {
+	return NewTrigger(
+		fmt.Sprintf("balance_below(%d)", lamports),
+		SourceAccount,
+		func(ev *Event) bool {
+			if ev.Kind != EventAccount || ev.Lamports >= lamports {
+				return false
+			}
+			return ev.PrevLamports == nil || *ev.PrevLamports >= lamports
+		},
+	)
+}
+
+// OutgoingTransfer fires on every successful transaction that moves
+// lamports or tokens out of the address.
+func OutgoingTransfer() Trigger {
+	return NewTrigger(
+		"outgoing_transfer",
+		SourceTransaction,
+		func(ev *Event) bool {
+			if ev.Kind != EventTransaction || ev.Failed {
+				return false
+			}
+			return ev.LamportsDelta < 0 || len(ev.OutgoingTokens) > 0
+		},
+	)
+}
+
+// ProgramInteraction fires on every transaction mentioning the address
+// that invokes the provided program.
+func ProgramInteraction(programID solana.PublicKey) Trigger {
+	return NewTrigger(
+		fmt.Sprintf("program_interaction(%s)", programID),
+		SourceLogs,
+		func(ev *Event) bool {
+			if ev.Kind != EventTransaction {
+				return false
+			}
+			return solana.PublicKeySlice(ev.Programs).Has(programID)
+		},
+	)
+}
+
+// AllOf fires when all the provided triggers match the same event.
+func AllOf(triggers ...Trigger) Trigger {
+	return NewTrigger(
+		compositeName("all", triggers),
+		compositeSources(triggers),
+		func(ev *Event) bool {
+			for _, t := range triggers {
+				if !t.Match(ev) {
+					return false
+				}
+			}
+			return len(triggers) > 0
+		},
+	)
+}
+
+// AnyOf fires when any of the provided triggers matches an event.
+func AnyOf(triggers ...Trigger) Trigger {
+	return NewTrigger(
+		compositeName("any", triggers),
+		compositeSources(triggers),
+		func(ev *Event) bool {
+			for _, t := range triggers {
+				if t.Match(ev) {
+					return true
+				}
+			}
+			return false
+		},
+	)
+}
+
+func compositeName(op string, triggers []Trigger) string {
+	names := make([]string, len(triggers))
+	for i, t := range triggers {
+		names[i] = t.Name()
+	}
+	return op + "(" + strings.Join(names, ",") + ")"
+}
+
+func compositeSources(triggers []Trigger) (out Source) {
+	for _, t := range triggers {
+		out |= t.Sources()
+	}
+	return out
+}
@@ -0,0 +1,469 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"go.uber.org/zap"
+)
+
+var ErrAlreadyWatched = errors.New("address is already watched")
+
+// ErrFetchQueueFull is reported when a transaction is not fetched because
+// the fetch queue is full; its event is evaluated without the transaction.
+var ErrFetchQueueFull = errors.New("transaction fetch queue is full")
+
+var (
+	DefaultAlertBuffer = 1024
+
+	// Attempts and delay between attempts to fetch a transaction
+	// that is not yet available after its logs notification.
+	DefaultTransactionFetchAttempts = 5
+	DefaultTransactionFetchDelay    = 400 * time.Millisecond
+
+	// Number of goroutines fetching transactions, and capacity
+	// of the queue of transactions waiting to be fetched.
+	DefaultTransactionFetchWorkers = 8
+	DefaultTransactionFetchQueue   = 1024
+)
+
+type Opts struct {
+	// Commitment of the subscriptions.
+	// Defaults to rpc.CommitmentConfirmed.
+	Commitment rpc.CommitmentType
+
+	// AlertBuffer is the capacity of the channel returned by Alerts.
+	// Defaults to DefaultAlertBuffer.
+	AlertBuffer int
+
+	// FetchWorkers is the number of goroutines fetching the transactions
+	// of the logs notifications, for the triggers requiring SourceTransaction.
+	// Defaults to DefaultTransactionFetchWorkers.
+	FetchWorkers int
+
+	// FetchQueue is the capacity of the queue of the transactions waiting
+	// to be fetched; when it is full, ErrFetchQueueFull is reported
+	// and the events are evaluated without their transaction.
+	// Defaults to DefaultTransactionFetchQueue.
+	FetchQueue int
+}
+
+// Alert is emitted when a trigger of a watched address matches an event.
+type Alert struct {
+	Address solana.PublicKey
+	Trigger string
+	Event   *Event
+}
+
+// Watcher evaluates the triggers registered for addresses over their
+// account, logs and transaction streams, and emits alerts.
+type Watcher struct {
+	wsClient  *ws.Client
+	rpcClient *rpc.Client
+	opts      Opts
+
+	lock    sync.Mutex
+	watches map[solana.PublicKey]*watch
+
+	alerts  chan Alert
+	errs    chan error
+	fetches chan fetchJob
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+type watch struct {
+	address  solana.PublicKey
+	triggers []Trigger
+	sources  Source
+	cancel   context.CancelFunc
+
+	// Only accessed by the account stream goroutine.
+	prevLamports *uint64
+}
+
+// fetchJob is an event waiting for its transaction to be fetched,
+// before being evaluated.
+type fetchJob struct {
+	ctx context.Context
+	wt  *watch
+	ev  *Event
+}
+
+// New creates a new Watcher. The rpc client is only used by triggers
+// requiring SourceTransaction, and may be nil otherwise.
+//
+// The transactions are fetched by a pool of goroutines, so that
+// the events requiring them may be evaluated out of order.
+func New(wsClient *ws.Client, rpcClient *rpc.Client, opts *Opts) *Watcher {
+	w := &Watcher{
+		wsClient:  wsClient,
+		rpcClient: rpcClient,
+		watches:   map[solana.PublicKey]*watch{},
+	}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.Commitment == "" {
+		w.opts.Commitment = rpc.CommitmentConfirmed
+	}
+	if w.opts.AlertBuffer <= 0 {
+		w.opts.AlertBuffer = DefaultAlertBuffer
+	}
+	if w.opts.FetchWorkers <= 0 {
+		w.opts.FetchWorkers = DefaultTransactionFetchWorkers
+	}
+	if w.opts.FetchQueue <= 0 {
+		w.opts.FetchQueue = DefaultTransactionFetchQueue
+	}
+	w.alerts = make(chan Alert, w.opts.AlertBuffer)
+	w.errs = make(chan error, 100)
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	if rpcClient != nil {
+		w.fetches = make(chan fetchJob, w.opts.FetchQueue)
+		for i := 0; i < w.opts.FetchWorkers; i++ {
+			w.wg.Add(1)
+			go w.fetchTransactions()
+		}
+	}
+	return w
+}
+
+// Watch starts evaluating the provided triggers over the activity of the address.
+func (w *Watcher) Watch(address solana.PublicKey, triggers ...Trigger) error {
+	if len(triggers) == 0 {
+		return errors.New("watch: no triggers provided")
+	}
+	wt := &watch{
+		address:  address,
+		triggers: triggers,
+	}
+	for _, t := range triggers {
+		wt.sources |= t.Sources()
+	}
+	if wt.sources&SourceTransaction != 0 && w.rpcClient == nil {
+		return errors.New("watch: an rpc client is required by transaction triggers")
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.ctx.Err() != nil {
+		return w.ctx.Err()
+	}
+	if _, ok := w.watches[address]; ok {
+		return ErrAlreadyWatched
+	}
+
+	var ctx context.Context
+	ctx, wt.cancel = context.WithCancel(w.ctx)
+
+	if wt.sources&SourceAccount != 0 {
+		sub, err := w.wsClient.AccountSubscribe(address, w.opts.Commitment)
+		if err != nil {
+			wt.cancel()
+			return fmt.Errorf("watch: account subscribe: %w", err)
+		}
+		w.wg.Add(1)
+		go w.watchAccount(ctx, wt, sub)
+	}
+	if wt.sources&(SourceLogs|SourceTransaction) != 0 {
+		sub, err := w.wsClient.LogsSubscribeMentions(address, w.opts.Commitment)
+		if err != nil {
+			wt.cancel()
+			return fmt.Errorf("watch: logs subscribe: %w", err)
+		}
+		w.wg.Add(1)
+		go w.watchLogs(ctx, wt, sub)
+	}
+
+	w.watches[address] = wt
+	return nil
+}
+
+// Unwatch stops watching the address.
+func (w *Watcher) Unwatch(address solana.PublicKey) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if wt, ok := w.watches[address]; ok {
+		wt.cancel()
+		delete(w.watches, address)
+	}
+}
+
+// Alerts returns the channel on which alerts are delivered.
+func (w *Watcher) Alerts() <-chan Alert {
+	return w.alerts
+}
+
+// Err returns a channel on which stream and fetch errors are reported.
+// Errors are dropped if the channel is not consumed.
+func (w *Watcher) Err() <-chan error {
+	return w.errs
+}
+
+// Close stops watching all the addresses, and waits for
+// the running triggers to return.
+func (w *Watcher) Close() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// CloseWithContext stops watching all the addresses, and waits for
+// the running triggers to return until ctx is done, in which case
+// ctx.Err() is returned.
+func (w *Watcher) CloseWithContext(ctx context.Context) error {
+	w.cancel()
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Watcher) watchAccount(ctx context.Context, wt *watch, sub *ws.AccountSubscription) {
+	defer w.wg.Done()
+	defer sub.Unsubscribe()
+	for {
+		got, err := sub.RecvWithContext(ctx)
+		if err != nil {
+			w.stopped(ctx, wt, err)
+			return
+		}
+		ev := &Event{
+			Kind:         EventAccount,
+			Address:      wt.address,
+			Slot:         got.Context.Slot,
+			Account:      &got.Value.Account,
+			Lamports:     got.Value.Lamports,
+			PrevLamports: wt.prevLamports,
+		}
+		lamports := got.Value.Lamports
+		wt.prevLamports = &lamports
+		w.evaluate(ctx, wt, ev)
+	}
+}
+
+func (w *Watcher) watchLogs(ctx context.Context, wt *watch, sub *ws.LogSubscription) {
+	defer w.wg.Done()
+	defer sub.Unsubscribe()
+	for {
+		got, err := sub.RecvWithContext(ctx)
+		if err != nil {
+			w.stopped(ctx, wt, err)
+			return
+		}
+		ev := &Event{
+			Kind:      EventTransaction,
+			Address:   wt.address,
+			Slot:      got.Context.Slot,
+			Signature: got.Value.Signature,
+			Failed:    got.Value.Err != nil,
+			Logs:      got.Value.Logs,
+			Programs:  invokedPrograms(got.Value.Logs),
+		}
+		if wt.sources&SourceTransaction != 0 && !ev.Failed {
+			// Never block the notifications on the fetch.
+			select {
+			case w.fetches <- fetchJob{ctx: ctx, wt: wt, ev: ev}:
+				continue
+			default:
+				w.report(fmt.Errorf("watch %s: fetch transaction %s: %w", wt.address, ev.Signature, ErrFetchQueueFull))
+			}
+		}
+		w.evaluate(ctx, wt, ev)
+	}
+}
+
+// fetchTransactions fetches the transactions of the queued events,
+// and evaluates them, until the watcher is closed.
+func (w *Watcher) fetchTransactions() {
+	defer w.wg.Done()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case job := <-w.fetches:
+			if job.ctx.Err() != nil {
+				// Unwatched meanwhile.
+				continue
+			}
+			if err := w.loadTransaction(job.ctx, job.ev); err != nil {
+				if job.ctx.Err() != nil {
+					continue
+				}
+				w.report(fmt.Errorf("watch %s: fetch transaction %s: %w", job.wt.address, job.ev.Signature, err))
+			}
+			w.evaluate(job.ctx, job.wt, job.ev)
+		}
+	}
+}
+
+func (w *Watcher) evaluate(ctx context.Context, wt *watch, ev *Event) {
+	for _, t := range wt.triggers {
+		if !t.Match(ev) {
+			continue
+		}
+		select {
+		case w.alerts <- Alert{Address: wt.address, Trigger: t.Name(), Event: ev}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *Watcher) stopped(ctx context.Context, wt *watch, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	w.report(fmt.Errorf("watch %s: stream ended: %w", wt.address, err))
+}
+
+func (w *Watcher) report(err error) {
+	zlog.Debug("watcher error", zap.Error(err))
+	select {
+	case w.errs <- err:
+	default:
+	}
+}
+
+// loadTransaction fetches the details of the transaction of the event,
+// and computes the balance changes of the address.
+func (w *Watcher) loadTransaction(ctx context.Context, ev *Event) error {
+	commitment := w.opts.Commitment
+	if commitment == rpc.CommitmentProcessed {
+		// "processed" is not supported by getTransaction.
+		commitment = rpc.CommitmentConfirmed
+	}
+	maxVersion := uint64(0)
+	opts := &rpc.GetTransactionOpts{
+		Encoding:                       solana.EncodingBase64,
+		Commitment:                     commitment,
+		MaxSupportedTransactionVersion: &maxVersion,
+	}
+
+	var out *rpc.GetTransactionResult
+	var err error
+	for attempt := 0; attempt < DefaultTransactionFetchAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(DefaultTransactionFetchDelay):
+			}
+		}
+		out, err = w.rpcClient.GetTransaction(ctx, ev.Signature, opts)
+		if !errors.Is(err, rpc.ErrNotFound) {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if out.Meta == nil || out.Transaction == nil {
+		return errors.New("transaction has no meta")
+	}
+	tx, err := out.Transaction.GetTransaction()
+	if err != nil {
+		return err
+	}
+
+	ev.Transaction = out
+	ev.LamportsDelta, ev.OutgoingTokens = balanceChanges(tx, out.Meta, ev.Address)
+	return nil
+}
+
+// balanceChanges returns the change of the lamports of the address (not counting
+// the fee if the address paid it), and the mints of the token accounts owned
+// by the address whose balance decreased.
+func balanceChanges(tx *solana.Transaction, meta *rpc.TransactionMeta, address solana.PublicKey) (int64, []solana.PublicKey) {
+	keys := append(solana.PublicKeySlice{}, tx.Message.AccountKeys...)
+	keys = append(keys, meta.LoadedAddresses.Writable...)
+	keys = append(keys, meta.LoadedAddresses.ReadOnly...)
+
+	var lamportsDelta int64
+	for i, key := range keys {
+		if !key.Equals(address) || i >= len(meta.PreBalances) || i >= len(meta.PostBalances) {
+			continue
+		}
+		lamportsDelta = int64(meta.PostBalances[i]) - int64(meta.PreBalances[i])
+		if i == 0 {
+			lamportsDelta += int64(meta.Fee)
+		}
+		break
+	}
+
+	post := make(map[uint16]uint64, len(meta.PostTokenBalances))
+	for _, b := range meta.PostTokenBalances {
+		post[b.AccountIndex] = tokenAmount(b)
+	}
+	var outgoing []solana.PublicKey
+	for _, b := range meta.PreTokenBalances {
+		if b.Owner == nil || !b.Owner.Equals(address) {
+			continue
+		}
+		// A missing post balance means the token account was closed.
+		if post[b.AccountIndex] < tokenAmount(b) {
+			outgoing = append(outgoing, b.Mint)
+		}
+	}
+	return lamportsDelta, outgoing
+}
+
+func tokenAmount(b rpc.TokenBalance) uint64 {
+	if b.UiTokenAmount == nil {
+		return 0
+	}
+	v, _ := strconv.ParseUint(b.UiTokenAmount.Amount, 10, 64)
+	return v
+}
+
+// invokedPrograms returns the programs invoked according to the logs,
+// in order of first invocation.
+func invokedPrograms(logs []string) []solana.PublicKey {
+	var out solana.PublicKeySlice
+	for _, line := range logs {
+		// e.g. "Program 11111111111111111111111111111111 invoke [1]"
+		if !strings.HasPrefix(line, "Program ") {
+			continue
+		}
+		id, after, ok := strings.Cut(strings.TrimPrefix(line, "Program "), " ")
+		if !ok || !strings.HasPrefix(after, "invoke [") {
+			continue
+		}
+		programID, err := solana.PublicKeyFromBase58(id)
+		if err != nil || out.Has(programID) {
+			continue
+		}
+		out = append(out, programID)
+	}
+	return out
+}
@@ -0,0 +1,227 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watcher
+
+import (
+	"context"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/programs/system"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func newStreamServer(t *testing.T, sig solana.Signature) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var in struct {
+				ID     uint64 `json:"id"`
+				Method string `json:"method"`
+			}
+			_, msg, err := conn.ReadMessage()
+			if err != nil || stdjson.Unmarshal(msg, &in) != nil {
+				return
+			}
+			switch in.Method {
+			case "accountSubscribe":
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":1,"id":%d}`, in.ID)))
+				for _, lamports := range []uint64{100, 40, 30} {
+					conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+						`{"jsonrpc":"2.0","method":"accountNotification","params":{"result":{"context":{"slot":5},"value":{"data":["","base64"],"executable":false,"lamports":%d,"owner":"11111111111111111111111111111111","rentEpoch":0}},"subscription":1}}`,
+						lamports,
+					)))
+				}
+			case "logsSubscribe":
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":2,"id":%d}`, in.ID)))
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+					`{"jsonrpc":"2.0","method":"logsNotification","params":{"result":{"context":{"slot":6},"value":{"signature":"%s","err":null,"logs":["Program 11111111111111111111111111111111 invoke [1]","Program 11111111111111111111111111111111 success"]}},"subscription":2}}`,
+					sig,
+				)))
+			}
+		}
+	}))
+}
+
+func TestWatcher(t *testing.T) {
+	payer := solana.NewWallet().PrivateKey
+	address := payer.PublicKey()
+
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{
+			system.NewTransferInstruction(100, address, solana.NewWallet().PublicKey()).Build(),
+		},
+		solana.Hash{1},
+		solana.TransactionPayer(address),
+	)
+	require.NoError(t, err)
+	_, err = tx.Sign(func(key solana.PublicKey) *solana.PrivateKey { return &payer })
+	require.NoError(t, err)
+	rawTx, err := tx.MarshalBinary()
+	require.NoError(t, err)
+
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var in struct {
+			ID any `json:"id"`
+		}
+		stdjson.NewDecoder(req.Body).Decode(&in)
+		fmt.Fprintf(rw,
+			`{"jsonrpc":"2.0","result":{"slot":6,"blockTime":null,"version":"legacy","transaction":[%q,"base64"],"meta":{"err":null,"fee":5000,"preBalances":[1000000,0,1],"postBalances":[994900,100,1],"preTokenBalances":[],"postTokenBalances":[]}},"id":%q}`,
+			base64.StdEncoding.EncodeToString(rawTx), fmt.Sprint(in.ID),
+		)
+	}))
+	defer rpcServer.Close()
+
+	wsServer := newStreamServer(t, tx.Signatures[0])
+	defer wsServer.Close()
+	wsClient, err := ws.Connect(context.Background(), "ws"+strings.TrimPrefix(wsServer.URL, "http"))
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	w := New(wsClient, rpc.New(rpcServer.URL), nil)
+	defer w.Close()
+
+	require.NoError(t, w.Watch(
+		address,
+		BalanceBelow(50),
+		AllOf(ProgramInteraction(solana.SystemProgramID), OutgoingTransfer()),
+		ProgramInteraction(solana.TokenProgramID),
+	))
+	require.ErrorIs(t, w.Watch(address, OutgoingTransfer()), ErrAlreadyWatched)
+
+	got := map[string]*Event{}
+	for i := 0; i < 2; i++ {
+		select {
+		case alert := <-w.Alerts():
+			require.Equal(t, address, alert.Address)
+			got[alert.Trigger] = alert.Event
+		case err := <-w.Err():
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for alerts")
+		}
+	}
+	require.Contains(t, got, "balance_below(50)")
+	require.Equal(t, uint64(40), got["balance_below(50)"].Lamports)
+
+	transfer := got["all(program_interaction(11111111111111111111111111111111),outgoing_transfer)"]
+	require.NotNil(t, transfer)
+	require.Equal(t, tx.Signatures[0], transfer.Signature)
+	require.Equal(t, int64(-100), transfer.LamportsDelta)
+
+	select {
+	case alert := <-w.Alerts():
+		t.Fatalf("unexpected alert %s", alert.Trigger)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWatcher_fetchQueue(t *testing.T) {
+	address := solana.NewWallet().PublicKey()
+	sigs := []solana.Signature{{1}, {2}, {3}}
+
+	upgrader := websocket.Upgrader{}
+	wsServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var in struct {
+				ID uint64 `json:"id"`
+			}
+			_, msg, err := conn.ReadMessage()
+			if err != nil || stdjson.Unmarshal(msg, &in) != nil {
+				return
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":1,"id":%d}`, in.ID)))
+			for _, sig := range sigs {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+					`{"jsonrpc":"2.0","method":"logsNotification","params":{"result":{"context":{"slot":6},"value":{"signature":"%s","err":null,"logs":["Program 11111111111111111111111111111111 invoke [1]"]}},"subscription":1}}`,
+					sig,
+				)))
+			}
+		}
+	}))
+	defer wsServer.Close()
+
+	// The transactions are never found before the end of the test.
+	release := make(chan struct{})
+	rpcServer := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var in struct {
+			ID any `json:"id"`
+		}
+		stdjson.NewDecoder(req.Body).Decode(&in)
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+		fmt.Fprintf(rw, `{"jsonrpc":"2.0","result":null,"id":%q}`, fmt.Sprint(in.ID))
+	}))
+	defer rpcServer.Close()
+	defer close(release)
+
+	wsClient, err := ws.Connect(context.Background(), "ws"+strings.TrimPrefix(wsServer.URL, "http"))
+	require.NoError(t, err)
+	defer wsClient.Close()
+
+	w := New(wsClient, rpc.New(rpcServer.URL), &Opts{FetchWorkers: 1, FetchQueue: 1})
+	defer w.Close()
+	require.NoError(t, w.Watch(address, ProgramInteraction(solana.SystemProgramID), OutgoingTransfer()))
+
+	// The first transaction is being fetched and the second is queued:
+	// the third is evaluated without waiting for them.
+	select {
+	case err := <-w.Err():
+		require.ErrorIs(t, err, ErrFetchQueueFull)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the fetch queue to fill")
+	}
+	select {
+	case alert := <-w.Alerts():
+		require.Equal(t, sigs[2], alert.Event.Signature)
+		require.Nil(t, alert.Event.Transaction)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notifications blocked by the transaction fetch")
+	}
+}
+
+func TestInvokedPrograms(t *testing.T) {
+	programs := invokedPrograms([]string{
+		"Program ComputeBudget111111111111111111111111111111 invoke [1]",
+		"Program ComputeBudget111111111111111111111111111111 success",
+		"Program TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA invoke [1]",
+		"Program log: Instruction: Transfer",
+		"Program TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA invoke [2]",
+		"Program TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA consumed 4645 of 200000 compute units",
+	})
+	require.Equal(t, []solana.PublicKey{solana.ComputeBudget, solana.TokenProgramID}, programs)
+}
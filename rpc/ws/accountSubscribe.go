@@ -16,6 +16,7 @@ package ws
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
@@ -43,13 +44,43 @@ func (cl *Client) AccountSubscribe(
 	)
 }
 
-// AccountSubscribe subscribes to an account to receive notifications
+// AccountSubscribeWithContext is like AccountSubscribe, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (cl *Client) AccountSubscribeWithContext(
+	ctx context.Context,
+	account solana.PublicKey,
+	commitment rpc.CommitmentType,
+) (*AccountSubscription, error) {
+	return cl.WithContext(ctx).AccountSubscribe(account, commitment)
+}
+
+// AccountSubscribeWithOpts subscribes to an account to receive notifications
 // when the lamports or data for a given account public key changes.
+//
+// Use solana.EncodingBase64Zstd to reduce bandwidth for large accounts;
+// the data is decompressed on first access (see rpc.DataBytesOrJSON.Binary).
 func (cl *Client) AccountSubscribeWithOpts(
 	account solana.PublicKey,
 	commitment rpc.CommitmentType,
 	encoding solana.EncodingType,
 ) (*AccountSubscription, error) {
+	genSub, err := cl.accountSubscribeWithConvert(account, commitment, encoding, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountSubscription{
+		sub: genSub,
+	}, nil
+}
+
+// accountSubscribeWithConvert subscribes to the account, delivering the
+// notifications converted by convert, or as *AccountResult if convert is nil.
+func (cl *Client) accountSubscribeWithConvert(
+	account solana.PublicKey,
+	commitment rpc.CommitmentType,
+	encoding solana.EncodingType,
+	convert func(res *AccountResult) (interface{}, error),
+) (*Subscription, error) {
 
 	params := []interface{}{account.String()}
 	conf := map[string]interface{}{
@@ -59,10 +90,13 @@ func (cl *Client) AccountSubscribeWithOpts(
 		conf["commitment"] = commitment
 	}
 	if encoding != "" {
+		if !isSupportedAccountEncoding(encoding) {
+			return nil, fmt.Errorf("provided encoding is not supported: %s", encoding)
+		}
 		conf["encoding"] = encoding
 	}
 
-	genSub, err := cl.subscribe(
+	return cl.subscribe(
 		params,
 		conf,
 		"accountSubscribe",
@@ -70,58 +104,25 @@ func (cl *Client) AccountSubscribeWithOpts(
 		func(msg []byte) (interface{}, error) {
 			var res AccountResult
 			err := decodeResponseFromMessage(msg, &res)
-			return &res, err
+			if err != nil || convert == nil {
+				return &res, err
+			}
+			return convert(&res)
 		},
 	)
-	if err != nil {
-		return nil, err
-	}
-	return &AccountSubscription{
-		sub: genSub,
-	}, nil
-}
-
-type AccountSubscription struct {
-	sub *Subscription
-}
-
-func (sw *AccountSubscription) Recv() (*AccountResult, error) {
-	select {
-	case d := <-sw.sub.stream:
-		return d.(*AccountResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *AccountSubscription) RecvWithContext(ctx context.Context) (*AccountResult, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case d := <-sw.sub.stream:
-		return d.(*AccountResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
 }
 
-func (sw *AccountSubscription) Err() <-chan error {
-	return sw.sub.err
-}
+// AccountSubscription is the subscription returned by AccountSubscribe.
+type AccountSubscription = TypedSubscription[AccountResult]
 
-func (sw *AccountSubscription) Response() <-chan *AccountResult {
-	typedChan := make(chan *AccountResult, 1)
-	go func(ch chan *AccountResult) {
-		// TODO: will this subscription yield more than one result?
-		d, ok := <-sw.sub.stream
-		if !ok {
-			return
-		}
-		ch <- d.(*AccountResult)
-	}(typedChan)
-	return typedChan
-}
-
-func (sw *AccountSubscription) Unsubscribe() {
-	sw.sub.Unsubscribe()
+// isSupportedAccountEncoding checks whether the provided encoding
+// can be used for account data in subscriptions.
+func isSupportedAccountEncoding(encoding solana.EncodingType) bool {
+	return solana.IsAnyOfEncodingType(
+		encoding,
+		solana.EncodingBase58,
+		solana.EncodingBase64,
+		solana.EncodingBase64Zstd,
+		solana.EncodingJSONParsed,
+	)
 }
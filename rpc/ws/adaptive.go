@@ -0,0 +1,355 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	DefaultAdaptiveCheckInterval  = 1 * time.Second
+	DefaultAdaptiveDowngradeAfter = 3
+	DefaultAdaptiveUpgradeAfter   = 10
+)
+
+// AdaptiveDetailsOpts configures an adaptive transaction subscription
+// (see HeliusClient.AdaptiveTransactionSubscribe).
+type AdaptiveDetailsOpts struct {
+	// Levels lists the transactionDetails to switch between,
+	// from the richest to the lightest.
+	// Defaults to full, accounts, signatures.
+	Levels []TransactionDetails
+
+	// CheckInterval is the period at which the backpressure
+	// of the subscription is evaluated.
+	// Defaults to DefaultAdaptiveCheckInterval.
+	CheckInterval time.Duration
+
+	// DowngradeAfter is the number of consecutive checks at the critical
+	// (or full) backpressure level after which the subscription switches
+	// to the next lighter level.
+	// Defaults to DefaultAdaptiveDowngradeAfter.
+	DowngradeAfter int
+
+	// UpgradeAfter is the number of consecutive checks without backpressure
+	// after which the subscription switches back to the previous richer level.
+	// Defaults to DefaultAdaptiveUpgradeAfter.
+	UpgradeAfter int
+
+	// Buffer is the number of notifications buffered for the subscriber.
+	// Defaults to the subscription buffer of the client (see Options.SubscriptionBuffer).
+	Buffer int
+
+	// OnChange is called after the subscription switched level.
+	//
+	// This parameter is optional.
+	OnChange func(from, to TransactionDetails)
+}
+
+// AdaptiveTransactionSubscription is a transaction subscription that
+// resubscribes with lighter transactionDetails under sustained backpressure,
+// and with richer ones once the load subsides.
+//
+// While switching, the previous subscription is drained before being
+// dropped, so a few notifications may be delivered twice, at different levels.
+type AdaptiveTransactionSubscription struct {
+	client *HeliusClient
+	filter TransactionSubscribeFilterType
+	opts   TransactionSubscribeOptionsType
+	cfg    AdaptiveDetailsOpts
+
+	lock    sync.Mutex
+	sub     *TransactionSubscription
+	level   int
+	retired []*TransactionSubscription
+
+	swapped   chan struct{}
+	stream    chan *TransactionResult
+	err       chan error
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// AdaptiveTransactionSubscribe subscribes to transactions like TransactionSubscribe,
+// switching between the transactionDetails levels of cfg depending on how fast
+// the notifications are consumed. The subscription starts at opts.TransactionDetails
+// if it is one of the levels, and at the richest level otherwise.
+func (c *HeliusClient) AdaptiveTransactionSubscribe(
+	filter TransactionSubscribeFilterType,
+	opts TransactionSubscribeOptionsType,
+	cfg *AdaptiveDetailsOpts, // optional
+) (*AdaptiveTransactionSubscription, error) {
+	a := &AdaptiveTransactionSubscription{
+		client:  c,
+		filter:  filter,
+		opts:    opts,
+		swapped: make(chan struct{}, 1),
+		err:     make(chan error, 1),
+		done:    make(chan struct{}),
+	}
+	if cfg != nil {
+		a.cfg = *cfg
+	}
+	if len(a.cfg.Levels) == 0 {
+		a.cfg.Levels = []TransactionDetails{
+			TransactionDetailsFull,
+			TransactionDetailsAccounts,
+			TransactionDetailsSignatures,
+		}
+	}
+	if a.cfg.CheckInterval <= 0 {
+		a.cfg.CheckInterval = DefaultAdaptiveCheckInterval
+	}
+	if a.cfg.DowngradeAfter <= 0 {
+		a.cfg.DowngradeAfter = DefaultAdaptiveDowngradeAfter
+	}
+	if a.cfg.UpgradeAfter <= 0 {
+		a.cfg.UpgradeAfter = DefaultAdaptiveUpgradeAfter
+	}
+	if a.cfg.Buffer <= 0 {
+		a.cfg.Buffer = c.subscriptionBuffer
+	}
+	a.stream = make(chan *TransactionResult, a.cfg.Buffer)
+
+	for i, level := range a.cfg.Levels {
+		if level == opts.TransactionDetails {
+			a.level = i
+		}
+	}
+	sub, err := a.subscribe(a.level)
+	if err != nil {
+		return nil, err
+	}
+	a.sub = sub
+
+	go a.forward()
+	go a.adapt()
+	return a, nil
+}
+
+func (a *AdaptiveTransactionSubscription) subscribe(level int) (*TransactionSubscription, error) {
+	opts := a.opts
+	opts.TransactionDetails = a.cfg.Levels[level]
+	return a.client.transactionSubscribe(context.Background(), a.filter, opts)
+}
+
+// forward moves the notifications of the current subscription
+// to the subscriber.
+func (a *AdaptiveTransactionSubscription) forward() {
+	for {
+		a.lock.Lock()
+		sub := a.sub
+		a.lock.Unlock()
+
+		select {
+		case <-a.done:
+			return
+		case <-a.swapped:
+			if !a.drainRetired() {
+				return
+			}
+		case d := <-sub.sub.stream:
+			if !a.deliver(d.value) {
+				return
+			}
+		case err := <-sub.sub.err:
+			a.lock.Lock()
+			current := a.sub == sub
+			a.lock.Unlock()
+			if current {
+				a.fail(err)
+				return
+			}
+		}
+	}
+}
+
+// drainRetired unsubscribes from the subscriptions replaced by a switch,
+// and forwards the notifications they still buffer.
+func (a *AdaptiveTransactionSubscription) drainRetired() bool {
+	a.lock.Lock()
+	retired := a.retired
+	a.retired = nil
+	a.lock.Unlock()
+
+	for _, sub := range retired {
+		sub.Unsubscribe()
+		for drained := false; !drained; {
+			select {
+			case d := <-sub.sub.stream:
+				if !a.deliver(d.value) {
+					return false
+				}
+			default:
+				drained = true
+			}
+		}
+	}
+	return true
+}
+
+// deliver sends a notification to the subscriber. A notification that was
+// not decoded (e.g. through a raw view of the client) fails the subscription
+// with ErrRawNotification.
+func (a *AdaptiveTransactionSubscription) deliver(value interface{}) bool {
+	res, err := typedNotification[TransactionResult](value)
+	if err != nil {
+		a.fail(err)
+		return false
+	}
+	return a.send(res)
+}
+
+// fail delivers err to the subscriber, and unsubscribes.
+// It is only called by the forward goroutine.
+func (a *AdaptiveTransactionSubscription) fail(err error) {
+	a.err <- err
+	a.Unsubscribe()
+}
+
+func (a *AdaptiveTransactionSubscription) send(res *TransactionResult) bool {
+	select {
+	case <-a.done:
+		return false
+	case a.stream <- res:
+		return true
+	}
+}
+
+// adapt evaluates the backpressure of the subscription and switches levels.
+func (a *AdaptiveTransactionSubscription) adapt() {
+	ticker := time.NewTicker(a.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	var pressured, relaxed int
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+		}
+
+		switch a.client.backpressureLevel(len(a.stream), cap(a.stream)) {
+		case BackpressureCritical, BackpressureFull:
+			pressured++
+			relaxed = 0
+		case BackpressureNone:
+			relaxed++
+			pressured = 0
+		default:
+			pressured, relaxed = 0, 0
+		}
+
+		a.lock.Lock()
+		level := a.level
+		a.lock.Unlock()
+		switch {
+		case pressured >= a.cfg.DowngradeAfter && level+1 < len(a.cfg.Levels):
+			a.switchLevel(level, level+1)
+			pressured, relaxed = 0, 0
+		case relaxed >= a.cfg.UpgradeAfter && level > 0:
+			a.switchLevel(level, level-1)
+			pressured, relaxed = 0, 0
+		}
+	}
+}
+
+func (a *AdaptiveTransactionSubscription) switchLevel(from, to int) {
+	sub, err := a.subscribe(to)
+	if err != nil {
+		a.client.log().Warn("unable to switch adaptive transaction subscription",
+			zap.String("from", string(a.cfg.Levels[from])),
+			zap.String("to", string(a.cfg.Levels[to])),
+			zap.Error(err),
+		)
+		return
+	}
+
+	a.lock.Lock()
+	select {
+	case <-a.done:
+		// Closed while subscribing.
+		a.lock.Unlock()
+		sub.Unsubscribe()
+		return
+	default:
+	}
+	a.retired = append(a.retired, a.sub)
+	a.sub = sub
+	a.level = to
+	a.lock.Unlock()
+
+	select {
+	case a.swapped <- struct{}{}:
+	default:
+	}
+
+	a.client.log().Info("switched adaptive transaction subscription",
+		zap.String("from", string(a.cfg.Levels[from])),
+		zap.String("to", string(a.cfg.Levels[to])),
+	)
+	if a.cfg.OnChange != nil {
+		a.cfg.OnChange(a.cfg.Levels[from], a.cfg.Levels[to])
+	}
+}
+
+// Details returns the transactionDetails level currently subscribed to.
+func (a *AdaptiveTransactionSubscription) Details() TransactionDetails {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.cfg.Levels[a.level]
+}
+
+func (a *AdaptiveTransactionSubscription) Recv() (*TransactionResult, error) {
+	return a.RecvWithContext(context.Background())
+}
+
+func (a *AdaptiveTransactionSubscription) RecvWithContext(ctx context.Context) (*TransactionResult, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case d := <-a.stream:
+		return d, nil
+	case err := <-a.err:
+		return nil, err
+	}
+}
+
+func (a *AdaptiveTransactionSubscription) Err() <-chan error {
+	return a.err
+}
+
+func (a *AdaptiveTransactionSubscription) Unsubscribe() {
+	a.closeOnce.Do(func() {
+		a.lock.Lock()
+		close(a.done)
+		subs := append(a.retired, a.sub)
+		a.retired = nil
+		a.lock.Unlock()
+		for _, sub := range subs {
+			sub.Unsubscribe()
+		}
+	})
+}
+
+// UnsubscribeWithContext unsubscribes, waiting until ctx is done
+// (see Subscription.UnsubscribeWithContext).
+func (a *AdaptiveTransactionSubscription) UnsubscribeWithContext(ctx context.Context) error {
+	return runWithContext(ctx, a.Unsubscribe)
+}
@@ -0,0 +1,173 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AdaptiveTransactionSubscribe(t *testing.T) {
+	var lock sync.Mutex
+	var requested []TransactionDetails
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var subID uint64
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in struct {
+				ID     uint64        `json:"id"`
+				Method string        `json:"method"`
+				Params []interface{} `json:"params"`
+			}
+			if err := json.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			if in.Method != "transactionSubscribe" {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":true,"id":%d}`, in.ID)))
+				continue
+			}
+			details := TransactionDetails(in.Params[1].(map[string]interface{})["transactionDetails"].(string))
+			lock.Lock()
+			requested = append(requested, details)
+			lock.Unlock()
+
+			subID++
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":%d,"id":%d}`, subID, in.ID)))
+			// Only the first subscription receives a burst.
+			if subID == 1 {
+				for i := 0; i < 8; i++ {
+					conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"transactionNotification","params":{"result":{"signature":"sig%d","transaction":{"transaction":[],"meta":{"fee":5000}}},"subscription":%d}}`, i, subID)))
+				}
+			}
+		}
+	}))
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	var changes []string
+	helius := &HeliusClient{Client: c}
+	sub, err := helius.AdaptiveTransactionSubscribe(
+		TransactionSubscribeFilterType{},
+		TransactionSubscribeOptionsType{TransactionDetails: TransactionDetailsFull},
+		&AdaptiveDetailsOpts{
+			CheckInterval:  10 * time.Millisecond,
+			DowngradeAfter: 2,
+			UpgradeAfter:   3,
+			Buffer:         4,
+			OnChange: func(from, to TransactionDetails) {
+				lock.Lock()
+				defer lock.Unlock()
+				changes = append(changes, fmt.Sprintf("%s->%s", from, to))
+			},
+		},
+	)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	require.Equal(t, TransactionDetailsFull, sub.Details())
+
+	// Not consuming: the subscription is downgraded.
+	require.Eventually(t, func() bool {
+		return sub.Details() == TransactionDetailsAccounts
+	}, 5*time.Second, 5*time.Millisecond)
+
+	// Consuming: all notifications of the burst are delivered,
+	// and the subscription is upgraded back.
+	for i := 0; i < 8; i++ {
+		got, err := sub.Recv()
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("sig%d", i), got.Signature)
+	}
+	require.Eventually(t, func() bool {
+		return sub.Details() == TransactionDetailsFull
+	}, 5*time.Second, 5*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(requested) == 3
+	}, 5*time.Second, 5*time.Millisecond)
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, []TransactionDetails{TransactionDetailsFull, TransactionDetailsAccounts, TransactionDetailsFull}, requested)
+	require.Equal(t, []string{"full->accounts", "accounts->full"}, changes)
+}
+
+func Test_AdaptiveTransactionSubscribe_rawView(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in struct {
+				ID     uint64 `json:"id"`
+				Method string `json:"method"`
+			}
+			if err := json.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			if in.Method != "transactionSubscribe" {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":true,"id":%d}`, in.ID)))
+				continue
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":1,"id":%d}`, in.ID)))
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"transactionNotification","params":{"result":{"signature":"sig","transaction":{"transaction":[],"meta":{"fee":5000}}},"subscription":1}}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// The notifications of a raw view fail the subscription instead of panicking.
+	helius := (&HeliusClient{Client: c}).WithRawNotifications(RawCopy)
+	sub, err := helius.AdaptiveTransactionSubscribe(TransactionSubscribeFilterType{}, TransactionSubscribeOptionsType{}, nil)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = sub.RecvWithContext(ctx)
+	require.ErrorIs(t, err, ErrRawNotification)
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import "fmt"
+
+var (
+	// DefaultSubscriptionBuffer is the number of notifications
+	// buffered for each subscription.
+	DefaultSubscriptionBuffer = 200_000
+
+	DefaultBackpressureWarning  = 0.5
+	DefaultBackpressureCritical = 0.8
+)
+
+// BackpressureLevel describes how full the buffer of a subscription is.
+type BackpressureLevel int32
+
+const (
+	// BackpressureNone means the buffer is below the warning threshold.
+	BackpressureNone BackpressureLevel = iota
+	// BackpressureWarning means the buffer reached Options.BackpressureWarning.
+	BackpressureWarning
+	// BackpressureCritical means the buffer reached Options.BackpressureCritical.
+	BackpressureCritical
+	// BackpressureFull means the buffer is full: the notification
+	// is dropped (see Options.DropWhenFull) or the subscription is closed.
+	BackpressureFull
+)
+
+func (l BackpressureLevel) String() string {
+	switch l {
+	case BackpressureNone:
+		return "none"
+	case BackpressureWarning:
+		return "warning"
+	case BackpressureCritical:
+		return "critical"
+	case BackpressureFull:
+		return "full"
+	}
+	return fmt.Sprintf("BackpressureLevel(%d)", int32(l))
+}
+
+// BackpressureState is the backpressure state of a subscription.
+type BackpressureState struct {
+	Subscription SubscriptionInfo
+	Level        BackpressureLevel
+	// Number of notifications waiting to be received, and buffer capacity.
+	Buffered int
+	Capacity int
+	// Fill is Buffered/Capacity, between 0 and 1.
+	Fill float64
+}
+
+// BackpressureFunc is called when the backpressure level of a subscription changes.
+// It runs on the goroutine that reads the websocket connection, so it must not block;
+// long reactions (e.g. resubscribing with narrower filters) must run in a separate goroutine.
+type BackpressureFunc func(state BackpressureState)
+
+// backpressureLevel returns the level of a buffer holding buffered
+// notifications out of capacity.
+func (c *connection) backpressureLevel(buffered, capacity int) BackpressureLevel {
+	fill := float64(buffered) / float64(capacity)
+	switch {
+	case buffered >= capacity:
+		return BackpressureFull
+	case fill >= c.backpressureCritical:
+		return BackpressureCritical
+	case fill >= c.backpressureWarning:
+		return BackpressureWarning
+	}
+	return BackpressureNone
+}
+
+// updateBackpressure records the backpressure level of the subscription
+// and calls the backpressure callback if it changed.
+func (c *connection) updateBackpressure(sub *Subscription) BackpressureLevel {
+	buffered, capacity := len(sub.stream), cap(sub.stream)
+	level := c.backpressureLevel(buffered, capacity)
+	prev := BackpressureLevel(sub.backpressure.Swap(int32(level)))
+	if level != prev && c.onBackpressure != nil {
+		c.onBackpressure(BackpressureState{
+			Subscription: sub.info(),
+			Level:        level,
+			Buffered:     buffered,
+			Capacity:     capacity,
+			Fill:         float64(buffered) / float64(capacity),
+		})
+	}
+	return level
+}
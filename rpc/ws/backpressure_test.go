@@ -0,0 +1,97 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Backpressure(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var in request
+		if err := json.Unmarshal(msg, &in); err != nil {
+			return
+		}
+		conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":1,"id":%d}`, in.ID)))
+		for slot := 1; slot <= 6; slot++ {
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{"parent":0,"root":0,"slot":%d},"subscription":1}}`, slot)))
+		}
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	var lock sync.Mutex
+	var states []BackpressureState
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{
+		SubscriptionBuffer: 4,
+		DropWhenFull:       true,
+		OnBackpressure: func(state BackpressureState) {
+			lock.Lock()
+			defer lock.Unlock()
+			states = append(states, state)
+		},
+	}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		subs := c.Subscriptions()
+		return len(subs) == 1 && subs[0].Drops == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	lock.Lock()
+	require.Len(t, states, 2)
+	require.Equal(t, BackpressureWarning, states[0].Level)
+	require.Equal(t, 2, states[0].Buffered)
+	require.Equal(t, 0.5, states[0].Fill)
+	require.Equal(t, "slotSubscribe", states[0].Subscription.Method)
+	require.Equal(t, BackpressureFull, states[1].Level)
+	require.Equal(t, 4, states[1].Capacity)
+	lock.Unlock()
+
+	info := c.Subscriptions()[0]
+	require.Equal(t, 4, info.Buffered)
+	require.Equal(t, uint64(4), info.Notifications)
+
+	// The buffered notifications are still delivered.
+	for slot := uint64(1); slot <= 4; slot++ {
+		got, err := sub.Recv()
+		require.NoError(t, err)
+		require.Equal(t, slot, got.Slot)
+	}
+}
@@ -145,47 +145,15 @@ func (cl *Client) BlockSubscribe(
 	}, nil
 }
 
-type BlockSubscription struct {
-	sub *Subscription
-}
-
-func (sw *BlockSubscription) Recv() (*BlockResult, error) {
-	select {
-	case d := <-sw.sub.stream:
-		return d.(*BlockResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *BlockSubscription) RecvWithContext(ctx context.Context) (*BlockResult, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case d := <-sw.sub.stream:
-		return d.(*BlockResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *BlockSubscription) Err() <-chan error {
-	return sw.sub.err
-}
-
-func (sw *BlockSubscription) Response() <-chan *BlockResult {
-	typedChan := make(chan *BlockResult, 1)
-	go func(ch chan *BlockResult) {
-		// TODO: will this subscription yield more than one result?
-		d, ok := <-sw.sub.stream
-		if !ok {
-			return
-		}
-		ch <- d.(*BlockResult)
-	}(typedChan)
-	return typedChan
+// BlockSubscribeWithContext is like BlockSubscribe, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (cl *Client) BlockSubscribeWithContext(
+	ctx context.Context,
+	filter BlockSubscribeFilter,
+	opts *BlockSubscribeOpts,
+) (*BlockSubscription, error) {
+	return cl.WithContext(ctx).BlockSubscribe(filter, opts)
 }
 
-func (sw *BlockSubscription) Unsubscribe() {
-	sw.sub.Unsubscribe()
-}
+// BlockSubscription is the subscription returned by BlockSubscribe.
+type BlockSubscription = TypedSubscription[BlockResult]
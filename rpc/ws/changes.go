@@ -0,0 +1,239 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"reflect"
+	"sync"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// ChangeOpts configures the change detection of AccountSubscribeChanges
+// and ProgramSubscribeChanges.
+type ChangeOpts struct {
+	// Ranges computes the byte ranges of the data that changed since the
+	// previous notification of the account (see AccountChange.Ranges).
+	// The last data of every account is then kept in memory,
+	// instead of its hash only.
+	Ranges bool
+}
+
+// ByteRange is a range of bytes of account data.
+type ByteRange struct {
+	Offset int
+	Length int
+}
+
+// AccountChange is the notification of an account whose data changed.
+type AccountChange struct {
+	Slot    uint64
+	Pubkey  solana.PublicKey
+	Account *rpc.Account
+
+	// First is set for the first notification of the account;
+	// it has no previous data to compare with.
+	First bool
+	// Ranges are the byte ranges of the data that changed, set when
+	// ChangeOpts.Ranges is set and First is not. See DiffRanges.
+	Ranges []ByteRange
+}
+
+// changeDetector remembers a hash (and optionally the data) of the
+// last notification of every account, to drop the notifications
+// whose data did not change.
+type changeDetector struct {
+	ranges bool
+	seed   maphash.Seed
+
+	lock   sync.Mutex
+	hashes map[solana.PublicKey]uint64
+	data   map[solana.PublicKey][]byte
+}
+
+func newChangeDetector(opts *ChangeOpts) *changeDetector {
+	d := &changeDetector{
+		seed:   maphash.MakeSeed(),
+		hashes: make(map[solana.PublicKey]uint64),
+	}
+	if opts != nil && opts.Ranges {
+		d.ranges = true
+		d.data = make(map[solana.PublicKey][]byte)
+	}
+	return d
+}
+
+// observe records the data of the account, and reports whether it changed
+// since the previous call; ranges are computed if configured.
+func (d *changeDetector) observe(pubkey solana.PublicKey, data []byte) (changed, first bool, ranges []ByteRange) {
+	sum := maphash.Bytes(d.seed, data)
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	prev, ok := d.hashes[pubkey]
+	if ok && prev == sum {
+		return false, false, nil
+	}
+	d.hashes[pubkey] = sum
+	if d.ranges {
+		if ok {
+			ranges = DiffRanges(d.data[pubkey], data)
+		}
+		d.data[pubkey] = append([]byte(nil), data...)
+	}
+	return true, !ok, ranges
+}
+
+// change converts a notification to an AccountChange,
+// or returns errDiscardNotification if the data did not change.
+func (d *changeDetector) change(slot uint64, pubkey solana.PublicKey, account *rpc.Account) (interface{}, error) {
+	if account == nil {
+		return nil, errors.New("notification has no account")
+	}
+	data, err := account.Binary()
+	if err != nil {
+		return nil, err
+	}
+	changed, first, ranges := d.observe(pubkey, data)
+	if !changed {
+		return nil, errDiscardNotification
+	}
+	return &AccountChange{
+		Slot:    slot,
+		Pubkey:  pubkey,
+		Account: account,
+		First:   first,
+		Ranges:  ranges,
+	}, nil
+}
+
+// AccountSubscribeChanges is like AccountSubscribeWithOpts, but it only
+// delivers the notifications whose account data differs from the
+// previous notification; changes of the lamports alone are dropped.
+//
+// The encoding must be a binary one ("base58", "base64" or "base64+zstd").
+func (cl *Client) AccountSubscribeChanges(
+	account solana.PublicKey,
+	commitment rpc.CommitmentType,
+	encoding solana.EncodingType,
+	opts *ChangeOpts, // optional
+) (*ChangeSubscription, error) {
+	if encoding == solana.EncodingJSONParsed {
+		return nil, fmt.Errorf("change detection cannot be used with %s encoding", encoding)
+	}
+	detector := newChangeDetector(opts)
+	sub, err := cl.accountSubscribeWithConvert(account, commitment, encoding, func(res *AccountResult) (interface{}, error) {
+		return detector.change(res.Context.Slot, account, &res.Value.Account)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeSubscription{sub: sub}, nil
+}
+
+// ProgramSubscribeChanges is like ProgramSubscribeWithOpts, but it only
+// delivers the notifications whose account data differs from the
+// previous notification of the same account; changes of the lamports
+// alone are dropped. The hash of the data of every notified account
+// is kept for the lifetime of the subscription.
+//
+// The encoding must be a binary one ("base58", "base64" or "base64+zstd").
+func (cl *Client) ProgramSubscribeChanges(
+	programID solana.PublicKey,
+	commitment rpc.CommitmentType,
+	encoding solana.EncodingType,
+	filters []rpc.RPCFilter,
+	opts *ChangeOpts, // optional
+) (*ChangeSubscription, error) {
+	if encoding == solana.EncodingJSONParsed {
+		return nil, fmt.Errorf("change detection cannot be used with %s encoding", encoding)
+	}
+	detector := newChangeDetector(opts)
+	sub, err := cl.programSubscribeWithConvert(programID, commitment, encoding, filters, nil, func(res *ProgramResult) (interface{}, error) {
+		return detector.change(res.Context.Slot, res.Value.Pubkey, res.Value.Account)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeSubscription{sub: sub}, nil
+}
+
+// ChangeSubscription is an account or program subscription
+// delivering only the changes of the account data.
+type ChangeSubscription = TypedSubscription[AccountChange]
+
+// DiffRanges returns the byte ranges that differ between old and new,
+// in increasing order, merging adjacent changed bytes into a single range.
+// If the lengths differ, the bytes past the shorter one form the last range;
+// it extends past new if the data shrunk.
+func DiffRanges(old, new []byte) []ByteRange {
+	var ranges []ByteRange
+	n := len(old)
+	if len(new) < n {
+		n = len(new)
+	}
+	start := -1
+	for i := 0; i < n; i++ {
+		if old[i] != new[i] {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		if start != -1 {
+			ranges = append(ranges, ByteRange{Offset: start, Length: i - start})
+			start = -1
+		}
+	}
+	end := len(old)
+	if len(new) > end {
+		end = len(new)
+	}
+	if end > n {
+		if start == -1 {
+			start = n
+		}
+		n = end
+	}
+	if start != -1 {
+		ranges = append(ranges, ByteRange{Offset: start, Length: n - start})
+	}
+	return ranges
+}
+
+// ChangedFields returns the names of the exported fields of the structs
+// old and new (of the same type, or pointers to it) whose values differ.
+// It returns nil if they are not structs.
+func ChangedFields(old, new interface{}) []string {
+	a, b := reflect.Indirect(reflect.ValueOf(old)), reflect.Indirect(reflect.ValueOf(new))
+	if a.Kind() != reflect.Struct || a.Type() != b.Type() {
+		return nil
+	}
+	var changed []string
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			changed = append(changed, field.Name)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRanges(t *testing.T) {
+	require.Nil(t, DiffRanges([]byte{1, 2, 3}, []byte{1, 2, 3}))
+	require.Equal(t,
+		[]ByteRange{{Offset: 1, Length: 2}, {Offset: 4, Length: 1}},
+		DiffRanges([]byte{1, 2, 3, 4, 5}, []byte{1, 9, 9, 4, 9}),
+	)
+	// Grown and shrunk data.
+	require.Equal(t, []ByteRange{{Offset: 2, Length: 2}}, DiffRanges([]byte{1, 2}, []byte{1, 2, 3, 4}))
+	require.Equal(t, []ByteRange{{Offset: 1, Length: 3}}, DiffRanges([]byte{1, 2, 3, 4}, []byte{1, 9}))
+}
+
+func TestChangedFields(t *testing.T) {
+	a := testPool{Reserve: 1}
+	b := testPool{Reserve: 2, Mint: solana.TokenProgramID}
+	require.Equal(t, []string{"Mint", "Reserve"}, ChangedFields(&a, &b))
+	require.Nil(t, ChangedFields(a, a))
+	require.Nil(t, ChangedFields(1, 2))
+}
+
+func TestProgramSubscribeChanges(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := c.ProgramSubscribeChanges(solana.SystemProgramID, rpc.CommitmentConfirmed, "", nil, &ChangeOpts{Ranges: true})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	_, err = server.WaitForSubscription(ctx, "programSubscribe")
+	require.NoError(t, err)
+
+	accountA, accountB := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	notify := func(slot uint64, pubkey solana.PublicKey, data []byte) {
+		_, err := server.Notify("programSubscribe", rpc.M{
+			"context": rpc.M{"slot": slot},
+			"value": rpc.M{
+				"pubkey":  pubkey.String(),
+				"account": rpc.M{"lamports": slot, "owner": solana.SystemProgramID.String(), "data": []string{base64.StdEncoding.EncodeToString(data), "base64"}},
+			},
+		})
+		require.NoError(t, err)
+	}
+	notify(1, accountA, []byte{1, 2, 3})
+	notify(2, accountA, []byte{1, 2, 3})
+	notify(3, accountB, []byte{1, 2, 3})
+	notify(4, accountA, []byte{1, 5, 3})
+
+	got, err := sub.RecvWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, accountA, got.Pubkey)
+	require.True(t, got.First)
+	require.EqualValues(t, 1, got.Slot)
+
+	// The unchanged data of slot 2 is dropped.
+	got, err = sub.RecvWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, accountB, got.Pubkey)
+	require.True(t, got.First)
+
+	got, err = sub.RecvWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, accountA, got.Pubkey)
+	require.False(t, got.First)
+	require.EqualValues(t, 4, got.Slot)
+	require.Equal(t, []ByteRange{{Offset: 1, Length: 1}}, got.Ranges)
+}
+
+func Test_ProgramSubscribeTyped_onlyChanges(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := ProgramSubscribeTyped[testPool](c, solana.SystemProgramID, &ProgramSubscribeOpts{OnlyChanges: true}, nil)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	_, err = server.WaitForSubscription(ctx, "programSubscribe")
+	require.NoError(t, err)
+
+	account := solana.NewWallet().PublicKey()
+	for _, reserve := range []uint64{1, 1, 2} {
+		data, err := bin.MarshalBin(testPool{Reserve: reserve})
+		require.NoError(t, err)
+		_, err = server.Notify("programSubscribe", rpc.M{
+			"context": rpc.M{"slot": reserve},
+			"value": rpc.M{
+				"pubkey":  account.String(),
+				"account": rpc.M{"lamports": 1, "owner": solana.SystemProgramID.String(), "data": []string{base64.StdEncoding.EncodeToString(data), "base64"}},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	got, err := sub.RecvWithContext(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, got.Value.Reserve)
+	require.Nil(t, got.ChangedFields)
+
+	got, err = sub.RecvWithContext(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, got.Value.Reserve)
+	require.Equal(t, []string{"Reserve"}, got.ChangedFields)
+}
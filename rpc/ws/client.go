@@ -20,11 +20,13 @@ package ws
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/buger/jsonparser"
@@ -35,27 +37,89 @@ import (
 	"go.uber.org/zap"
 )
 
-type result interface{}
+// result is a decoded notification queued for delivery.
+type result struct {
+	value      interface{}
+	receivedAt time.Time
+	decoding   time.Duration
+	queuedAt   time.Time
+	slot       uint64
+}
 
 type Client struct {
+	*connection
+
+	// tenant labels the subscriptions created through this client.
+	tenant string
+	// ctx bounds the subscriptions created through this client, if not nil.
+	ctx context.Context
+	// raw, if set, makes the subscriptions created through this client
+	// deliver undecoded notifications (see WithRawNotifications).
+	raw RawMode
+	// subscribeTimeout, if not zero, overrides Options.SubscribeTimeout
+	// for the subscriptions created through this client (see WithSubscribeTimeout).
+	subscribeTimeout time.Duration
+}
+
+// connection holds the state shared by a client and its tenant views.
+type connection struct {
 	rpcURL                  string
+	label                   string
+	dialer                  *websocket.Dialer
+	httpHeader              http.Header
+	connLock                sync.Mutex // protects conn
 	conn                    *websocket.Conn
+	closing                 atomic.Bool
 	connCtx                 context.Context
 	connCtxCancel           context.CancelFunc
 	lock                    sync.RWMutex
 	subscriptionByRequestID map[uint64]*Subscription
 	subscriptionByWSSubID   map[uint64]*Subscription
+	unsubscribeAcks         map[uint64]*pendingUnsubscribe // by unsubscribe request ID
+	unsubscribing           map[uint64]struct{}            // subscription IDs awaiting an unsubscribe ack
+	expiredSubscribes       map[uint64]string              // unsubscribe methods by request ID, see expireHandshake
+	subscribeTimeout        time.Duration
 	reconnectOnErr          bool
 	pongWait                time.Duration
 	pingPeriod              time.Duration
-	subIDRetrievals         map[string]subIDRetrievalFunc
-	txDiscarders            map[string]txDiscarderFunc
-	sigRetrievals           map[string]signatureRetrievalFunc
+	useSubIDRetrievals      bool
+	useSigRetrievals        bool
+	fastPaths               atomic.Pointer[fastPathTable] // see registerFastPath
+	txDiscarders            map[string]TxDiscarder
 	sigCache                LogsSignatureCache
+	quotas                  map[string]*tenantQuota
+	subscriptionBuffer      int
+	dropWhenFull            bool
+	onBackpressure          BackpressureFunc
+	onQuotaExceeded         QuotaExceededFunc
+	backpressureWarning     float64
+	backpressureCritical    float64
+	reuseReadBuffer         bool
+	readerDone              chan struct{}
+	writes                  chan *outboundMessage
+	logger                  *zap.Logger
+	health                  *connectionHealth
+	reconnectOpts           *ReconnectOptions
+	onStateChange           ConnectionStateFunc
+	state                   atomic.Int32
+	tap                     chan<- Frame
+	tapDrops                atomic.Uint64
+	compressionLevel        int
+	readLimit               int64
+	maxNotificationSize     int64
+	compressed              atomic.Bool
+	confirmSubscriptions    bool
+	resubscribe             ResubscribePolicy
+	lastRequestID           atomic.Uint64
+	parentCtx               context.Context
+	routines                sync.WaitGroup // internal goroutines, see Client.Done
+	done                    chan struct{}
+	endLock                 sync.Mutex
+	ended                   bool
+	endErr                  error
 }
 
 type subIDRetrievalFunc func([]byte) (uint64, bool)
-type txDiscarderFunc func([]byte) bool
 type signatureRetrievalFunc func([]byte) solana.Signature
 
 type LogsSignatureCache interface {
@@ -63,6 +127,14 @@ type LogsSignatureCache interface {
 	Set(sig solana.Signature)
 }
 
+// signatureCacheAdder is implemented by caches that can check and record
+// a signature atomically, so that connections sharing the cache
+// never both deliver the same notification.
+type signatureCacheAdder interface {
+	// Add records the signature, and reports whether it was not already recorded.
+	Add(sig solana.Signature) bool
+}
+
 const (
 	// Time allowed to write a message to the peer.
 	writeWait = 10 * time.Second
@@ -82,24 +154,115 @@ func Connect(ctx context.Context, rpcEndpoint string) (c *Client, err error) {
 // pass basic authentication params as prescribed
 // ref https://github.com/gorilla/websocket/issues/209
 func ConnectWithOptions(ctx context.Context, rpcEndpoint string, opt *Options, cache LogsSignatureCache) (c *Client, err error) {
-	c = &Client{
+	c = newClient(rpcEndpoint, opt, cache)
+	c.conn, err = c.dial(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("new ws client: %w", err)
+	}
+
+	c.connCtx, c.connCtxCancel = context.WithCancel(c.parentCtx)
+	c.setupConn(c.conn)
+	c.setConnectionState(ConnectionEvent{State: ConnectionConnected})
+	c.routines.Add(5)
+	go func() {
+		defer c.routines.Done()
+		ticker := time.NewTicker(c.pingPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.connCtx.Done():
+				return
+			case <-ticker.C:
+				c.sendPing()
+			}
+		}
+	}()
+	go func() {
+		defer c.routines.Done()
+		c.monitorHealth()
+	}()
+	go func() {
+		defer c.routines.Done()
+		c.writeMessages()
+	}()
+	go func() {
+		defer c.routines.Done()
+		c.closeOnParentDone()
+	}()
+	go func() {
+		defer c.routines.Done()
+		c.receiveMessages()
+	}()
+	go func() {
+		c.routines.Wait()
+		close(c.done)
+	}()
+	return c, nil
+}
+
+// newClient creates a client configured with the provided options,
+// without connecting it.
+func newClient(rpcEndpoint string, opt *Options, cache LogsSignatureCache) (c *Client) {
+	c = &Client{connection: &connection{
 		rpcURL:                  rpcEndpoint,
 		subscriptionByRequestID: map[uint64]*Subscription{},
 		subscriptionByWSSubID:   map[uint64]*Subscription{},
-		subIDRetrievals:         make(map[string]subIDRetrievalFunc),
-		txDiscarders:            make(map[string]txDiscarderFunc),
-		sigRetrievals:           make(map[string]signatureRetrievalFunc),
+		unsubscribeAcks:         map[uint64]*pendingUnsubscribe{},
+		unsubscribing:           map[uint64]struct{}{},
+		expiredSubscribes:       map[uint64]string{},
+		txDiscarders:            make(map[string]TxDiscarder),
 		sigCache:                &defaultLogsSignatureCache{},
+		quotas:                  map[string]*tenantQuota{},
+		subscriptionBuffer:      DefaultSubscriptionBuffer,
+		backpressureWarning:     DefaultBackpressureWarning,
+		backpressureCritical:    DefaultBackpressureCritical,
+		readerDone:              make(chan struct{}),
+		done:                    make(chan struct{}),
+		parentCtx:               context.Background(),
+		writes:                  make(chan *outboundMessage, writeQueueSize),
+	}}
+
+	if opt != nil {
+		c.label = opt.Label
+		for tenant, quota := range opt.TenantQuotas {
+			c.quotas[tenant] = newTenantQuota(quota)
+		}
+		if opt.SubscriptionBuffer > 0 {
+			c.subscriptionBuffer = opt.SubscriptionBuffer
+		}
+		if opt.BackpressureWarning > 0 {
+			c.backpressureWarning = opt.BackpressureWarning
+		}
+		if opt.BackpressureCritical > 0 {
+			c.backpressureCritical = opt.BackpressureCritical
+		}
+		c.dropWhenFull = opt.DropWhenFull
+		c.reuseReadBuffer = opt.ReuseReadBuffer
+		c.onBackpressure = opt.OnBackpressure
+		c.onQuotaExceeded = opt.OnQuotaExceeded
+		c.logger = opt.Logger
+		c.reconnectOpts = opt.Reconnect
+		c.onStateChange = opt.OnConnectionStateChange
+		c.tap = opt.Tap
+		c.compressionLevel = opt.CompressionLevel
+		c.readLimit = opt.ReadLimit
+		c.maxNotificationSize = opt.MaxNotificationSize
+		c.confirmSubscriptions = opt.ConfirmSubscriptions
+		c.resubscribe = opt.Resubscribe
+		c.subscribeTimeout = opt.SubscribeTimeout
+		if opt.Context != nil {
+			c.parentCtx = opt.Context
+		}
 	}
 
 	dialer := &websocket.Dialer{
 		Proxy:             http.ProxyFromEnvironment,
 		HandshakeTimeout:  DefaultHandshakeTimeout,
-		EnableCompression: true,
+		EnableCompression: opt == nil || !opt.DisableCompression,
 	}
 
 	if cache != nil {
-		c.sigRetrievals = defaultSigRetrievals
+		c.useSigRetrievals = true
 		c.sigCache = cache
 	}
 
@@ -121,81 +284,140 @@ func ConnectWithOptions(ctx context.Context, rpcEndpoint string, opt *Options, c
 	}
 
 	if opt != nil && opt.UseSubIDRetrievals {
-		c.subIDRetrievals = defaultSubIDRetrievals
+		c.useSubIDRetrievals = true
 	}
 
-	if opt != nil && opt.DiscardFailedTxs {
-		c.txDiscarders = defaultTxDiscarders
+	if opt != nil {
+		c.txDiscarders = newTxDiscarders(opt)
 	}
 
-	var httpHeader http.Header = nil
 	if opt != nil && opt.HttpHeader != nil && len(opt.HttpHeader) > 0 {
-		httpHeader = opt.HttpHeader
-	}
-	var resp *http.Response
-	c.conn, resp, err = dialer.DialContext(ctx, rpcEndpoint, httpHeader)
-	if err != nil {
-		if resp != nil {
-			body, _ := io.ReadAll(resp.Body)
-			err = fmt.Errorf("new ws client: dial: %w, status: %s, body: %q", err, resp.Status, string(body))
-		} else {
-			err = fmt.Errorf("new ws client: dial: %w", err)
-		}
-		return nil, err
+		c.httpHeader = opt.HttpHeader
 	}
+	c.dialer = dialer
 
-	c.connCtx, c.connCtxCancel = context.WithCancel(context.Background())
-	go func() {
-		c.conn.SetReadDeadline(time.Now().Add(c.pongWait))
-		c.conn.SetPongHandler(func(string) error { c.conn.SetReadDeadline(time.Now().Add(c.pongWait)); return nil })
-		ticker := time.NewTicker(c.pingPeriod)
-		for {
-			select {
-			case <-c.connCtx.Done():
-				return
-			case <-ticker.C:
-				c.sendPing()
-			}
+	healthWindow := c.pongWait
+	var onDegraded HealthFunc
+	if opt != nil {
+		if opt.HealthWindow > 0 {
+			healthWindow = opt.HealthWindow
 		}
-	}()
-	go c.receiveMessages()
-	return c, nil
-}
-
-func (c *Client) sendPing() {
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-	if err := c.conn.WriteMessage(websocket.PingMessage, []byte{}); err != nil {
-		zlog.Debug("unable to send ping message", zap.Error(err))
-		return
+		onDegraded = opt.OnDegraded
 	}
+	c.health = newConnectionHealth(healthWindow, onDegraded)
+	return c
 }
 
+// Close closes the connection immediately, without waiting for
+// in-flight writes; the subscriptions receive the resulting read error.
 func (c *Client) Close() {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	c.end(nil)
+	c.closing.Store(true)
 	c.connCtxCancel()
-	c.conn.Close()
+	c.currentConn().Close()
+}
+
+// CloseWithContext closes the connection gracefully: it sends a close message
+// and waits for the server to close the connection, until ctx is done.
+// The connection is closed when it returns in any case, and ctx.Err()
+// is returned if the server did not close the connection in time.
+func (c *Client) CloseWithContext(ctx context.Context) error {
+	defer c.Close()
+	c.closing.Store(true)
+
+	deadline := time.Now().Add(writeWait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	err := c.currentConn().WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("close: unable to send close message: %w", err)
+	}
+
+	select {
+	case <-c.readerDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (c *Client) receiveMessages() {
+	defer close(c.readerDone)
+	var buf bytes.Buffer
+	conn := c.currentConn()
 	for {
 		select {
 		case <-c.connCtx.Done():
+			c.closeAllSubscription(c.closeError(ErrConnectionClosed))
+			c.setConnectionState(ConnectionEvent{State: ConnectionDisconnected})
 			return
 		default:
-			_, message, err := c.conn.ReadMessage()
+			message, err := c.readMessage(conn, &buf)
+			if errors.Is(err, websocket.ErrReadLimit) {
+				err = &MessageTooLargeError{Limit: c.readLimit, err: err}
+			}
 			if err != nil {
+				if c.closing.Load() {
+					c.closeAllSubscription(c.closeError(err))
+					c.setConnectionState(ConnectionEvent{State: ConnectionDisconnected})
+					return
+				}
 				c.closeAllSubscription(err)
+				c.setConnectionState(ConnectionEvent{State: ConnectionDisconnected, Err: err})
+				if c.reconnectOpts == nil {
+					// The connection is lost for good: stop the other goroutines.
+					c.end(err)
+					c.connCtxCancel()
+					return
+				}
+				var redialErr error
+				if conn, redialErr = c.redial(); conn != nil {
+					continue
+				}
+				// Close the subscriptions made while reconnecting;
+				// register refuses new ones once the conn context is done.
+				if c.closing.Load() || c.connCtx.Err() != nil {
+					c.closeAllSubscription(c.closeError(ErrConnectionClosed))
+					c.setConnectionState(ConnectionEvent{State: ConnectionDisconnected})
+					return
+				}
+				// The connection is lost for good: stop the other goroutines.
+				c.end(err)
+				c.connCtxCancel()
+				c.closeAllSubscription(redialErr)
+				c.setConnectionState(ConnectionEvent{State: ConnectionDisconnected, Err: redialErr})
 				return
 			}
-			c.handleMessage(message)
+			receivedAt := time.Now()
+			c.health.recordMessage(len(message))
+			c.tapFrame(message, receivedAt)
+			c.handleMessage(message, receivedAt)
 		}
 	}
 }
 
+// readMessage reads the next message from the connection, into buf
+// when the read buffer is reused (see Options.ReuseReadBuffer).
+func (c *Client) readMessage(conn *websocket.Conn, buf *bytes.Buffer) ([]byte, error) {
+	if !c.reuseReadBuffer {
+		_, message, err := conn.ReadMessage()
+		return message, err
+	}
+	_, r, err := conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+	buf.Reset()
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // GetUint64 returns the value retrieved by `Get`, cast to a uint64 if possible.
 // If key data type do not match, it will return an error.
 func getUint64(data []byte, keys ...string) (val uint64, err error) {
@@ -217,14 +439,21 @@ func getUint64WithOk(data []byte, path ...string) (uint64, bool) {
 	return 0, false
 }
 
-func (c *Client) handleMessage(message []byte) {
+func (c *Client) handleMessage(message []byte, receivedAt time.Time) {
 	// when receiving message with id. the result will be a subscription number.
 	// that number will be associated to all future message destine to this request
-	// such message should be no longer than 128 bytes
-	if len(message) < 128 {
+	// such message should be no longer than 128 bytes;
+	// longer messages without a method are responses too,
+	// e.g. errors with a long message.
+	var method string
+	var methodErr error
+	if len(message) >= 128 {
+		method, methodErr = jsonparser.GetString(message, "method")
+	}
+	if len(message) < 128 || methodErr != nil {
 		var result struct {
-			ID     uint64 `json:"id"`
-			Result uint64 `json:"result"`
+			ID     uint64              `json:"id"`
+			Result jsoniter.RawMessage `json:"result"`
 			Error  *struct {
 				Code    int    `json:"code"`
 				Message string `json:"message"`
@@ -232,33 +461,61 @@ func (c *Client) handleMessage(message []byte) {
 		}
 
 		if err := jsoniter.Unmarshal(message, &result); err != nil {
-			zlog.Error("unable to parse ws message", zap.Error(err))
+			c.log().Error("unable to parse ws message", zap.Error(err))
 			return
 		}
 
 		if result.Error != nil {
-			zlog.Warn("received error message from ws server",
+			c.log().Warn("received error message from ws server",
 				zap.Uint64("id", result.ID),
-				zap.Uint64("result", result.Result),
+				zap.ByteString("result", result.Result),
 				zap.Int("code", result.Error.Code),
 				zap.String("message", result.Error.Message),
 			)
+			if result.ID == 0 {
+				// An error notification of a subscription, sent at the top level.
+				if subID, ok := getUint64WithOk(message, "params", "subscription"); ok {
+					c.handleServerError(&ServerCanceledError{
+						SubscriptionID: subID,
+						Code:           result.Error.Code,
+						Message:        result.Error.Message,
+					})
+				}
+				return
+			}
+			err := &json2.Error{
+				Code:    json2.ErrorCode(result.Error.Code),
+				Message: result.Error.Message,
+			}
+			if !c.handleUnsubscribeAck(result.ID, fmt.Errorf("unsubscribe: %w", err)) {
+				c.handleSubscribeError(result.ID, err)
+			}
 			return
 		}
 
-		if result.ID != 0 && result.Result != 0 {
-			c.handleNewSubscriptionMessage(result.ID, result.Result)
-			return
+		if result.ID != 0 {
+			if subID, err := strconv.ParseUint(string(result.Result), 10, 64); err == nil && subID != 0 {
+				c.handleNewSubscriptionMessage(result.ID, subID)
+				return
+			}
+			var acked bool
+			if err := jsoniter.Unmarshal(result.Result, &acked); err == nil {
+				ackErr := error(nil)
+				if !acked {
+					ackErr = ErrUnsubscribeRejected
+				}
+				if c.handleUnsubscribeAck(result.ID, ackErr) {
+					return
+				}
+			}
 		}
 
+		if result.ID != 0 && len(message) >= 128 {
+			c.log().Warn("unable to correlate ws response with a request", zap.Uint64("id", result.ID))
+			return
+		}
 		subID, _ := getUint64WithOk(message, "params", "subscription")
-		c.handleSubscriptionMessage(subID, message)
-		return
-	}
-
-	method, err := jsonparser.GetString(message, "method")
-	if err != nil {
-		zlog.Warn("unable to parse ws message method", zap.Error(err))
+		c.handleSubscriptionMessage(subID, message, receivedAt)
 		return
 	}
 
@@ -267,34 +524,49 @@ func (c *Client) handleMessage(message []byte) {
 		return
 	}
 
-	sigRetrieval, sigRetrievalOk := c.sigRetrievals[method]
+	fastPaths := c.fastPaths.Load()
+	sigRetrieval, sigRetrievalOk := fastPaths.signature(method)
 	if sigRetrievalOk {
 		sig := sigRetrieval(message)
-		if c.sigCache.Has(sig) {
-			return
+		if adder, ok := c.sigCache.(signatureCacheAdder); ok {
+			if !adder.Add(sig) {
+				return
+			}
+		} else {
+			if c.sigCache.Has(sig) {
+				return
+			}
+			c.sigCache.Set(sig)
 		}
-		c.sigCache.Set(sig)
 	}
 
-	subIDRetrieval, retrievalOk := c.subIDRetrievals[method]
+	subIDRetrieval, retrievalOk := fastPaths.subID(method)
 	if retrievalOk {
 		subID, idOk := subIDRetrieval(message)
 		if idOk {
-			c.handleSubscriptionMessage(subID, message)
+			c.handleSubscriptionMessage(subID, message, receivedAt)
 			return
 		}
 	}
 
 	subID, _ := getUint64WithOk(message, "params", "subscription")
-	c.handleSubscriptionMessage(subID, message)
+	c.handleSubscriptionMessage(subID, message, receivedAt)
 }
 
 func (c *Client) handleNewSubscriptionMessage(requestID, subID uint64) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	hooks := c.registerSubscription(requestID, subID)
+	c.lock.Unlock()
+	hooks()
+}
+
+// registerSubscription associates the subscription of a request with its
+// subscription ID, and returns the OnSubscribed hooks to call once the lock
+// is released. The lock must be held.
+func (c *Client) registerSubscription(requestID, subID uint64) (hooks func()) {
 
 	if traceEnabled {
-		zlog.Debug("received new subscription message",
+		c.log().Debug("received new subscription message",
 			zap.Uint64("message_id", requestID),
 			zap.Uint64("subscription_id", subID),
 		)
@@ -302,109 +574,264 @@ func (c *Client) handleNewSubscriptionMessage(requestID, subID uint64) {
 
 	callBack, found := c.subscriptionByRequestID[requestID]
 	if !found {
-		zlog.Error("cannot find websocket message handler for a new stream.... this should not happen",
+		if hooks := c.cancelExpiredSubscribe(requestID, subID); hooks != nil {
+			return hooks
+		}
+		c.log().Error("cannot find websocket message handler for a new stream.... this should not happen",
 			zap.Uint64("request_id", requestID),
 			zap.Uint64("subscription_id", subID),
 		)
-		return
+		return func() {}
 	}
-	callBack.subID = subID
+	hooks = callBack.setActive(subID)
 	c.subscriptionByWSSubID[subID] = callBack
 
-	zlog.Debug("registered ws subscription",
+	c.log().Debug("registered ws subscription",
 		zap.Uint64("subscription_id", subID),
 		zap.Uint64("request_id", requestID),
 		zap.Int("subscription_count", len(c.subscriptionByWSSubID)),
+		zap.String("label", c.label),
+		zap.String("tenant", callBack.tenant),
 	)
-	return
+	return hooks
+}
+
+// handleSubscribeError closes the pending subscription of a subscribe request
+// rejected by the server.
+func (c *Client) handleSubscribeError(requestID uint64, err error) {
+	c.lock.Lock()
+	sub, found := c.subscriptionByRequestID[requestID]
+	if !found || sub.subID != 0 {
+		delete(c.expiredSubscribes, requestID)
+		c.lock.Unlock()
+		return
+	}
+	delete(c.subscriptionByRequestID, requestID)
+	err = fmt.Errorf("subscribe: %w", err)
+	sub.err <- err
+	c.lock.Unlock()
+
+	sub.setClosed(err)
 }
 
-func (c *Client) handleSubscriptionMessage(subID uint64, message []byte) {
+func (c *Client) handleSubscriptionMessage(subID uint64, message []byte, receivedAt time.Time) {
 	if traceEnabled {
-		zlog.Debug("received subscription message",
+		c.log().Debug("received subscription message",
 			zap.Uint64("subscription_id", subID),
 		)
 	}
 
 	c.lock.RLock()
 	sub, found := c.subscriptionByWSSubID[subID]
+	_, unsubscribing := c.unsubscribing[subID]
 	c.lock.RUnlock()
+	if unsubscribing {
+		// A notification sent before the server processed the unsubscribe request.
+		return
+	}
 	if !found {
-		zlog.Warn("unable to find subscription for ws message", zap.Uint64("subscription_id", subID))
+		c.log().Warn("unable to find subscription for ws message", zap.Uint64("subscription_id", subID))
+		return
+	}
+
+	sub.lastMessage.Store(receivedAt.UnixNano())
+
+	if limit := sub.maxMessageSize(c.maxNotificationSize); limit > 0 && int64(len(message)) > limit {
+		c.log().Warn("closing ws client subscription... notification too large",
+			zap.Uint64("request_id", sub.req.ID),
+			zap.String("label", c.label),
+			zap.Int("size", len(message)),
+			zap.Int64("limit", limit),
+		)
+		c.closeSubscription(sub.req.ID, &MessageTooLargeError{Size: int64(len(message)), Limit: limit})
+		return
+	}
+
+	if serverErr, ok := parseServerError(subID, message); ok {
+		c.handleServerError(serverErr)
 		return
 	}
 
 	// Decode the message using the subscription-provided decoderFunc.
-	result, err := sub.decoderFunc(message)
+	decodeStart := time.Now()
+	value, err := sub.decoderFunc(message)
+	decoding := time.Since(decodeStart)
+	if errors.Is(err, errDiscardNotification) {
+		return
+	}
 	if err != nil {
-		fmt.Println("*****************************")
 		c.closeSubscription(sub.req.ID, fmt.Errorf("unable to decode client response: %w", err))
 		return
 	}
 
+	if allowed, exceeded := c.checkMessageQuota(sub); !allowed {
+		sub.drops.Add(1)
+		if exceeded != nil {
+			c.log().Warn("dropping ws notifications... tenant quota exceeded",
+				zap.Uint64("request_id", sub.req.ID),
+				zap.String("label", c.label),
+				zap.String("tenant", sub.tenant),
+			)
+			if c.onQuotaExceeded != nil {
+				c.onQuotaExceeded(exceeded)
+			}
+		}
+		return
+	}
+
 	// this cannot be blocking or else
 	// we  will no read any other message
 	if len(sub.stream) >= cap(sub.stream) {
-		zlog.Warn("closing ws client subscription... not consuming fast en ought",
+		c.updateBackpressure(sub)
+		if c.dropWhenFull {
+			sub.drops.Add(1)
+			return
+		}
+		c.log().Warn("closing ws client subscription... not consuming fast en ought",
 			zap.Uint64("request_id", sub.req.ID),
+			zap.String("label", c.label),
+			zap.String("tenant", sub.tenant),
 		)
 		c.closeSubscription(sub.req.ID, fmt.Errorf("reached channel max capacity %d", len(sub.stream)))
 		return
 	}
 
-	sub.stream <- result
+	sub.stream <- result{
+		value:      value,
+		receivedAt: receivedAt,
+		decoding:   decoding,
+		queuedAt:   time.Now(),
+		slot:       notificationSlot(message),
+	}
+	sub.notifications.Add(1)
+	sub.bytes.Add(uint64(len(message)))
+	c.updateBackpressure(sub)
 	return
 }
 
 func (c *Client) closeAllSubscription(err error) {
 	c.lock.Lock()
-	defer c.lock.Unlock()
-
-	for _, sub := range c.subscriptionByRequestID {
+	subs := c.subscriptionByRequestID
+	for _, sub := range subs {
 		sub.err <- err
 	}
 
 	c.subscriptionByRequestID = map[uint64]*Subscription{}
 	c.subscriptionByWSSubID = map[uint64]*Subscription{}
+	c.expiredSubscribes = map[uint64]string{}
+	c.lock.Unlock()
+
+	for _, sub := range subs {
+		sub.setClosed(err)
+	}
+	c.failUnsubscribeAcks(err)
 }
 
-func (c *Client) closeSubscription(reqID uint64, err error) {
+// closeSubscription closes the subscription of the request with err,
+// and sends the unsubscribe request. It returns a channel receiving
+// the acknowledgement of the server, or nil if the subscription
+// was already closed or not confirmed by the server.
+func (c *Client) closeSubscription(reqID uint64, err error) <-chan error {
 	c.lock.Lock()
-	defer c.lock.Unlock()
-
 	sub, found := c.subscriptionByRequestID[reqID]
 	if !found {
-		return
+		c.lock.Unlock()
+		return nil
 	}
 
 	sub.err <- err
 
-	err = c.unsubscribe(sub.subID, sub.unsubscribeMethod)
+	delete(c.subscriptionByRequestID, sub.req.ID)
+	delete(c.subscriptionByWSSubID, sub.subID)
+	c.lock.Unlock()
+
+	sub.setClosed(err)
+
+	ack, err := c.unsubscribe(sub.subID, sub.unsubscribeMethod)
 	if err != nil {
-		zlog.Warn("unable to send rpc unsubscribe call",
+		c.log().Warn("unable to send rpc unsubscribe call",
 			zap.Error(err),
+			zap.String("label", c.label),
+			zap.String("tenant", sub.tenant),
 		)
+		failed := make(chan error, 1)
+		failed <- err
+		return failed
 	}
+	return ack
+}
 
-	delete(c.subscriptionByRequestID, sub.req.ID)
-	delete(c.subscriptionByWSSubID, sub.subID)
+// pendingUnsubscribe is an unsubscribe request waiting for
+// the acknowledgement of the server.
+type pendingUnsubscribe struct {
+	subID uint64
+	ack   chan error
 }
 
-func (c *Client) unsubscribe(subID uint64, method string) error {
-	req := newRequest([]interface{}{subID}, method, nil)
+// unsubscribe sends the unsubscribe request of the subscription ID,
+// and returns a channel receiving the acknowledgement of the server
+// (nil if the subscription was not confirmed by the server).
+func (c *Client) unsubscribe(subID uint64, method string) (<-chan error, error) {
+	req := newRequest(c.nextRequestID(), []interface{}{subID}, method, nil)
 	data, err := req.encode()
 	if err != nil {
-		return fmt.Errorf("unable to encode unsubscription message for subID %d and method %s", subID, method)
+		return nil, fmt.Errorf("unable to encode unsubscription message for subID %d and method %s", subID, method)
+	}
+
+	var ack chan error
+	if subID != 0 {
+		ack = make(chan error, 1)
+		c.lock.Lock()
+		c.unsubscribeAcks[req.ID] = &pendingUnsubscribe{subID: subID, ack: ack}
+		c.unsubscribing[subID] = struct{}{}
+		c.lock.Unlock()
 	}
 
-	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
+	err = c.send(websocket.TextMessage, data)
 	if err != nil {
-		return fmt.Errorf("unable to send unsubscription message for subID %d and method %s", subID, method)
+		if ack != nil {
+			c.lock.Lock()
+			delete(c.unsubscribeAcks, req.ID)
+			delete(c.unsubscribing, subID)
+			c.lock.Unlock()
+		}
+		return nil, fmt.Errorf("unable to send unsubscription message for subID %d and method %s: %w", subID, method, err)
+	}
+	return ack, nil
+}
+
+// handleUnsubscribeAck delivers the response of an unsubscribe request,
+// and reports whether requestID is the ID of a pending unsubscribe request.
+func (c *Client) handleUnsubscribeAck(requestID uint64, err error) bool {
+	c.lock.Lock()
+	pending, found := c.unsubscribeAcks[requestID]
+	if found {
+		delete(c.unsubscribeAcks, requestID)
+		delete(c.unsubscribing, pending.subID)
+	}
+	c.lock.Unlock()
+	if !found {
+		return false
+	}
+	pending.ack <- err
+	return true
+}
+
+// failUnsubscribeAcks fails the pending unsubscribe requests with err,
+// as their response will never be received.
+func (c *Client) failUnsubscribeAcks(err error) {
+	c.lock.Lock()
+	pending := c.unsubscribeAcks
+	c.unsubscribeAcks = map[uint64]*pendingUnsubscribe{}
+	c.unsubscribing = map[uint64]struct{}{}
+	c.lock.Unlock()
+	for _, p := range pending {
+		p.ack <- fmt.Errorf("unsubscribe: %w", err)
 	}
-	return nil
 }
 
+// subscribe subscribes with the context of the client, if any
+// (see WithContext).
 func (c *Client) subscribe(
 	params []interface{},
 	conf map[string]interface{},
@@ -412,33 +839,158 @@ func (c *Client) subscribe(
 	unsubscribeMethod string,
 	decoderFunc decoderFunc,
 ) (*Subscription, error) {
-	c.lock.Lock()
-	defer c.lock.Unlock()
+	return c.subscribeWithContext(c.subscribeContext(), params, conf, subscriptionMethod, unsubscribeMethod, decoderFunc)
+}
+
+// subscribeWithContext subscribes, and binds the lifetime of the subscription
+// to ctx: if ctx is done before the subscription request is sent,
+// ctx.Err() is returned and the subscription, if eventually made, is canceled;
+// if ctx is done afterwards, the subscription is unsubscribed
+// and its receivers get an error matching both ErrCanceled and ctx.Err().
+//
+// With Options.ConfirmSubscriptions, it then waits for the server to confirm
+// the subscription (see confirmSubscription).
+func (c *Client) subscribeWithContext(
+	ctx context.Context,
+	params []interface{},
+	conf map[string]interface{},
+	subscriptionMethod string,
+	unsubscribeMethod string,
+	decoderFunc decoderFunc,
+) (*Subscription, error) {
+	sub, err := c.bindSubscription(ctx, params, conf, subscriptionMethod, unsubscribeMethod, decoderFunc)
+	if err != nil || !c.confirmSubscriptions {
+		return sub, err
+	}
+	if err := c.confirmSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// confirmSubscription waits for the server to confirm the subscription,
+// until DefaultSubscribeConfirmTimeout or ctx is done, in which case
+// the subscription is canceled. It returns the error of the server
+// if it rejected the subscription.
+func (c *Client) confirmSubscription(ctx context.Context, sub *Subscription) error {
+	ctx, cancel := context.WithTimeout(ctx, DefaultSubscribeConfirmTimeout)
+	defer cancel()
+	err := waitSubscribed(ctx, sub)
+	if err == nil || sub.State() == SubscriptionClosed {
+		return err
+	}
+	sub.Unsubscribe()
+	return fmt.Errorf("subscribe: no confirmation from server: %w", err)
+}
+
+// bindSubscription registers the subscription, and binds its lifetime to ctx
+// (see subscribeWithContext).
+func (c *Client) bindSubscription(
+	ctx context.Context,
+	params []interface{},
+	conf map[string]interface{},
+	subscriptionMethod string,
+	unsubscribeMethod string,
+	decoderFunc decoderFunc,
+) (*Subscription, error) {
+	if ctx.Done() == nil {
+		return c.register(params, conf, subscriptionMethod, unsubscribeMethod, decoderFunc)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	type subscribed struct {
+		sub *Subscription
+		err error
+	}
+	done := make(chan subscribed, 1)
+	go func() {
+		sub, err := c.register(params, conf, subscriptionMethod, unsubscribeMethod, decoderFunc)
+		done <- subscribed{sub, err}
+	}()
+	select {
+	case res := <-done:
+		if res.sub != nil {
+			go res.sub.unsubscribeOnDone(ctx)
+		}
+		return res.sub, res.err
+	case <-ctx.Done():
+		go func() {
+			if res := <-done; res.sub != nil {
+				res.sub.Unsubscribe()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// nextRequestID allocates the ID of a request sent on the connection;
+// IDs are unique per connection, and never zero.
+func (c *connection) nextRequestID() uint64 {
+	return c.lastRequestID.Add(1)
+}
 
-	req := newRequest(params, subscriptionMethod, conf)
+// register sends the subscription request, and registers the subscription.
+func (c *Client) register(
+	params []interface{},
+	conf map[string]interface{},
+	subscriptionMethod string,
+	unsubscribeMethod string,
+	decoderFunc decoderFunc,
+) (*Subscription, error) {
+	c.lock.Lock()
+	if c.closing.Load() || c.connCtx.Err() != nil {
+		// The subscriptions are already closed (see receiveMessages).
+		c.lock.Unlock()
+		return nil, fmt.Errorf("subscribe: %w", ErrConnectionClosed)
+	}
+	req := newRequest(c.nextRequestID(), params, subscriptionMethod, conf)
 	data, err := req.encode()
 	if err != nil {
+		c.lock.Unlock()
 		return nil, fmt.Errorf("subscribe: unable to encode subsciption request: %w", err)
 	}
+	if err := c.checkSubscribeQuota(); err != nil {
+		c.lock.Unlock()
+		return nil, fmt.Errorf("subscribe: %w", err)
+	}
 
+	if c.raw != RawDecoded {
+		decoderFunc = c.raw.decoder()
+	}
 	sub := newSubscription(
 		req,
-		func(err error) {
-			c.closeSubscription(req.ID, err)
+		func(err error) <-chan error {
+			return c.closeSubscription(req.ID, err)
 		},
 		unsubscribeMethod,
 		decoderFunc,
+		c.subscriptionBuffer,
 	)
+	sub.method = subscriptionMethod
+	sub.tenant = c.tenant
+	sub.handshakeTimeout = c.handshakeTimeout()
 
 	c.subscriptionByRequestID[req.ID] = sub
-	zlog.Info("added new subscription to websocket client", zap.Int("count", len(c.subscriptionByRequestID)))
+	c.registerFastPath(subscriptionMethod)
+	c.log().Info("added new subscription to websocket client",
+		zap.Int("count", len(c.subscriptionByRequestID)),
+		zap.String("method", subscriptionMethod),
+		zap.String("label", c.label),
+		zap.String("tenant", c.tenant),
+	)
+
+	c.lock.Unlock()
 
-	zlog.Debug("writing data to conn", zap.String("data", string(data)))
-	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-	err = c.conn.WriteMessage(websocket.TextMessage, data)
+	c.log().Debug("writing data to conn", zap.String("data", string(data)))
+	err = c.write(context.Background(), websocket.TextMessage, data)
 	if err != nil {
+		c.lock.Lock()
+		delete(c.subscriptionByRequestID, req.ID)
+		c.lock.Unlock()
 		return nil, fmt.Errorf("unable to write request: %w", err)
 	}
+	c.armHandshake(sub)
 
 	return sub, nil
 }
@@ -505,138 +1057,26 @@ func decodeResponseFromMessage(r []byte, reply interface{}) (err error) {
 	return json.Unmarshal(*c.Params.Result, &reply)
 }
 
-var defaultSubIDRetrievals = map[string]subIDRetrievalFunc{
-	"transactionNotification": func(b []byte) (uint64, bool) {
-		// Subscription ID occurs only once and in the current Helius RPC implementation it is always at the beginning of message.
-		// Use this fact to not necessarily search the whole response for the subscription ID.
-		chunkEnd := 128
-		if len(b) < chunkEnd {
-			return 0, false
-		}
-
-		chunk := b[60:chunkEnd]
-		_, after, ok := bytes.Cut(chunk, []byte(`"subscription":`))
-		if !ok {
-			return 0, false
-		}
-
-		idx := bytes.IndexAny(after, " ,]}")
-		if idx == -1 {
-			return 0, false
-		}
-
-		id := bytes.TrimSpace(after[:idx])
-		subID, err := strconv.ParseUint(string(id), 10, 64)
-		if err != nil {
-			return 0, false
-		}
-
-		return subID, true
-	},
-	"logsNotification": func(b []byte) (uint64, bool) {
-		// Subscription ID occurs only once and in the current Solana RPC implementation it is always at the end of message.
-		// Use this fact to not necessarily search the whole response for the subscription ID.
-		chunkSize := 64
-		if len(b) < chunkSize {
-			chunkSize = len(b)
-		}
-
-		chunk := b[len(b)-chunkSize:]
-		_, after, ok := bytes.Cut(chunk, []byte(`"subscription":`))
-		if !ok {
-			return 0, false
-		}
-
-		// The subscription ID is a number, so we can use the `bytes.ParseUint` function.
-		idx := bytes.IndexAny(after, " ,]}")
-		if idx == -1 {
-			return 0, false
-		}
-
-		id := bytes.TrimSpace(after[:idx])
-		subID, err := strconv.ParseUint(string(id), 10, 64)
-		if err != nil {
-			return 0, false
-		}
-
-		return subID, true
-	},
-}
-
-var defaultTxDiscarders = map[string]txDiscarderFunc{
-	"logsNotification": func(b []byte) bool {
-		chunkStart := 192
-		chunkSize := 64
-
-		if len(b) < chunkStart+chunkSize {
-			return false
-		}
-
-		chunk := b[chunkStart : chunkStart+chunkSize]
-		_, after, ok := bytes.Cut(chunk, []byte(`"err":`))
-		if !ok {
-			return false
-		}
-
-		idx := bytes.IndexAny(after, " ,]}")
-		if idx == -1 {
-			return false
-		}
-
-		value := bytes.TrimSpace(after[:idx])
-		if bytes.Equal(value, []byte("null")) {
-			return false
-		}
+// The keys located by the notification fast paths. The Solana RPC writes
+// the subscription ID of the notifications at their end, and Helius
+// at their start for transactionNotification; the signature of
+// a logsNotification is at its start, and of a transactionNotification
+// (by Helius) at its end.
+var (
+	subscriptionAtStart  = newJSONKey("subscription", 2, false)
+	subscriptionAtEnd    = newJSONKey("subscription", 2, true)
+	logsSignature        = newJSONKey("signature", 4, false)
+	transactionSignature = newJSONKey("signature", 3, true)
+)
 
-		return true
-	},
+var defaultSubIDRetrievals = map[string]subIDRetrievalFunc{
+	"transactionNotification": subscriptionAtStart.uint64,
+	"logsNotification":        subscriptionAtEnd.uint64,
 }
 
 var defaultSigRetrievals = map[string]signatureRetrievalFunc{
-	"logsNotification": func(b []byte) solana.Signature {
-		chunkStart := 96
-		chunkSize := 128
-
-		if len(b) < chunkStart+chunkSize {
-			return solana.Signature{}
-		}
-
-		chunk := b[chunkStart : chunkStart+chunkSize]
-		_, after, ok := bytes.Cut(chunk, []byte(`"signature":"`))
-		if !ok {
-			return solana.Signature{}
-		}
-
-		idx := bytes.IndexAny(after, `"`)
-		if idx == -1 {
-			return solana.Signature{}
-		}
-
-		sig58 := bytes.TrimSpace(after[:idx])
-		sig, _ := solana.SignatureFromBase58(string(sig58))
-		return sig
-	},
-	"transactionNotification": func(b []byte) solana.Signature {
-		chunkStart := len(b) - 128
-		if chunkStart < 0 {
-			return solana.Signature{}
-		}
-
-		chunk := b[chunkStart:]
-		_, after, ok := bytes.Cut(chunk, []byte(`"signature":"`))
-		if !ok {
-			return solana.Signature{}
-		}
-
-		idx := bytes.IndexAny(after, `"`)
-		if idx == -1 {
-			return solana.Signature{}
-		}
-
-		sig58 := bytes.TrimSpace(after[:idx])
-		sig, _ := solana.SignatureFromBase58(string(sig58))
-		return sig
-	},
+	"logsNotification":        logsSignature.signature,
+	"transactionNotification": transactionSignature.signature,
 }
 
 type defaultLogsSignatureCache struct{}
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
 	"github.com/gagliardetto/solana-go/text"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -156,3 +157,38 @@ func Test_SlotSubscribe(t *testing.T) {
 	fmt.Println("data received: ", data.Parent)
 	return
 }
+
+func Test_ProgramNotification_base64_zstd(t *testing.T) {
+	msg := []byte(`{"jsonrpc":"2.0","method":"programNotification","params":{"result":{"context":{"slot":5208469},"value":{"pubkey":"H4vnBqifaSACnKa7acsxstsY1iV1bvJNxsCY7enrd1hq","account":{"data":["KLUv/QQAWQAAaGVsbG8td29ybGTcLcaB","base64+zstd"],"executable":false,"lamports":33594,"owner":"11111111111111111111111111111111","rentEpoch":636}}},"subscription":24040}}`)
+
+	var res ProgramResult
+	require.NoError(t, decodeResponseFromMessage(msg, &res))
+	require.Equal(t, solana.EncodingBase64Zstd, res.Value.Account.Data.Encoding())
+
+	data, err := res.Value.Account.Binary()
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello-world"), data)
+}
+
+func Test_matchAccountData(t *testing.T) {
+	msg := []byte(`{"jsonrpc":"2.0","method":"programNotification","params":{"result":{"context":{"slot":5208469},"value":{"pubkey":"H4vnBqifaSACnKa7acsxstsY1iV1bvJNxsCY7enrd1hq","account":{"data":["KLUv/QQAWQAAaGVsbG8td29ybGTcLcaB","base64+zstd"],"executable":false,"lamports":33594,"owner":"11111111111111111111111111111111","rentEpoch":636}}},"subscription":24040}}`)
+
+	matched, err := matchAccountData(msg, []rpc.RPCFilter{rpc.NewDataSizeFilter(11), rpc.NewMemcmpFilter(6, []byte("world"))})
+	require.NoError(t, err)
+	require.True(t, matched)
+
+	matched, err = matchAccountData(msg, []rpc.RPCFilter{rpc.NewDataSizeFilter(12)})
+	require.NoError(t, err)
+	require.False(t, matched)
+
+	// Messages without account data are left to the decoder.
+	matched, err = matchAccountData([]byte(`{"jsonrpc":"2.0","method":"programNotification","params":{"error":"boom"}}`), []rpc.RPCFilter{rpc.NewDataSizeFilter(12)})
+	require.NoError(t, err)
+	require.True(t, matched)
+}
+
+func Test_isSupportedAccountEncoding(t *testing.T) {
+	require.True(t, isSupportedAccountEncoding(solana.EncodingBase64Zstd))
+	require.True(t, isSupportedAccountEncoding(solana.EncodingJSONParsed))
+	require.False(t, isSupportedAccountEncoding(solana.EncodingJSON))
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CloseWithContext(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil, nil)
+	require.NoError(t, err)
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	_, err = sub.Recv()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, c.CloseWithContext(ctx))
+
+	_, err = sub.RecvWithContext(ctx)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func Test_ContextCanceled(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = c.SubscribeRawWithContext(canceled, nil, nil, "slotSubscribe", "slotUnsubscribe", nil)
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = (&HeliusClient{Client: c}).TransactionSubscribeWithContext(canceled, TransactionSubscribeFilterType{}, TransactionSubscribeOptionsType{})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, c.Subscriptions())
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	require.ErrorIs(t, sub.UnsubscribeWithContext(canceled), context.Canceled)
+	require.NoError(t, sub.UnsubscribeWithContext(context.Background()))
+	require.Empty(t, c.Subscriptions())
+}
+
+// Test_ContextCanceled_connectionLost cancels blocking operations while
+// the server drops the connection, and is meant to be run with -race.
+func Test_ContextCanceled_connectionLost(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		// Drop the connection after the first subscribe requests.
+		time.Sleep(20 * time.Millisecond)
+		conn.Close()
+	}))
+	defer server.Close()
+
+	for i := 0; i < 10; i++ {
+		c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil, nil)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for j := 0; j < 5; j++ {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(j*10)*time.Millisecond)
+				defer cancel()
+				sub, err := c.SubscribeRawWithContext(ctx, nil, nil, "slotSubscribe", "slotUnsubscribe", nil)
+				if err != nil {
+					return
+				}
+				sub.RecvWithContext(ctx)
+				sub.UnsubscribeWithContext(ctx)
+			}(j)
+		}
+		wg.Wait()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		c.CloseWithContext(ctx)
+		cancel()
+	}
+}
+
+func Test_WithContext_unsubscribesOnCancel(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := c.WithContext(ctx).ForTenant("alice").SlotSubscribe()
+	require.NoError(t, err)
+	raw, err := c.SubscribeRawWithContext(ctx, nil, nil, "slotSubscribe", "slotUnsubscribe", nil)
+	require.NoError(t, err)
+	other, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	defer other.Unsubscribe()
+
+	_, err = sub.Recv()
+	require.NoError(t, err)
+	var hookErr error
+	raw.OnError(func(err error) { hookErr = err })
+
+	cancel()
+	for {
+		_, err = sub.Recv()
+		if err != nil {
+			break
+		}
+	}
+	require.ErrorIs(t, err, ErrCanceled)
+	require.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case <-raw.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("raw subscription not closed")
+	}
+	require.Equal(t, SubscriptionClosed, raw.State())
+	require.NoError(t, hookErr)
+
+	subs := c.Subscriptions()
+	require.Len(t, subs, 1)
+	require.Equal(t, other.sub.req.ID, subs[0].RequestID)
+}
+
+func Test_ParentContext(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	parent, cancel := context.WithCancel(context.Background())
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{Context: parent}, nil)
+	require.NoError(t, err)
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	_, err = sub.Recv()
+	require.NoError(t, err)
+
+	cancel()
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("client not shut down")
+	}
+	require.ErrorIs(t, c.Wait(), context.Canceled)
+	require.Equal(t, SubscriptionClosed, sub.sub.State())
+	for {
+		_, err = sub.Recv()
+		if err != nil {
+			break
+		}
+	}
+	require.ErrorIs(t, err, ErrCanceled)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_Wait(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil, nil)
+	require.NoError(t, err)
+	c.Close()
+	require.NoError(t, c.Wait())
+
+	// Without reconnection, a lost connection shuts the client down.
+	upgrader := websocket.Upgrader{}
+	dropping := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}))
+	defer dropping.Close()
+
+	c, err = ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(dropping.URL, "http"), nil, nil)
+	require.NoError(t, err)
+	select {
+	case <-c.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("client not shut down")
+	}
+	require.Error(t, c.Wait())
+	c.Close()
+	require.Error(t, c.Wait())
+}
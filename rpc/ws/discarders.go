@@ -0,0 +1,171 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"bytes"
+
+	"github.com/buger/jsonparser"
+	"github.com/gagliardetto/solana-go"
+)
+
+// TxDiscarder reports whether a raw notification message must be dropped
+// before being decoded. It is called from the read loop of the client,
+// so it must be fast and must not retain the message.
+type TxDiscarder func(message []byte) bool
+
+// AnyTxDiscarder returns a TxDiscarder dropping the notifications
+// dropped by any of the provided discarders.
+func AnyTxDiscarder(discarders ...TxDiscarder) TxDiscarder {
+	if len(discarders) == 1 {
+		return discarders[0]
+	}
+	return func(message []byte) bool {
+		for _, discard := range discarders {
+			if discard(message) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// DiscardFailedLogs drops the logsNotification of failed transactions.
+func DiscardFailedLogs(message []byte) bool {
+	null, ok := logsErr.isNull(message)
+	return ok && !null
+}
+
+// DiscardFailedTransactions drops the transactionNotification of failed transactions.
+func DiscardFailedTransactions(message []byte) bool {
+	return isSet(message, "params", "result", "transaction", "meta", "err")
+}
+
+// DiscardVotes drops the notifications of transactions invoking the vote program.
+// See DiscardMentioning for its limitations.
+var DiscardVotes = DiscardMentioning(solana.VoteProgramID)
+
+// DiscardMentioning returns a TxDiscarder dropping the notifications that
+// mention any of the provided programs (or accounts), e.g. in the logs of
+// a logsNotification, or in the account keys of a json-encoded transaction.
+// Accounts only present in binary-encoded data (e.g. a base64 transaction)
+// are not found.
+func DiscardMentioning(programs ...solana.PublicKey) TxDiscarder {
+	needles := encodeNeedles(programs)
+	return func(message []byte) bool {
+		return containsAny(message, needles)
+	}
+}
+
+// DiscardNotMentioning returns a TxDiscarder dropping the notifications that
+// don't mention any of the provided programs (or accounts).
+// See DiscardMentioning for its limitations.
+func DiscardNotMentioning(programs ...solana.PublicKey) TxDiscarder {
+	needles := encodeNeedles(programs)
+	return func(message []byte) bool {
+		return !containsAny(message, needles)
+	}
+}
+
+// DiscardVoteTransactions drops the transactionNotification of transactions
+// invoking the vote program, including binary-encoded ones
+// (see DiscardTransactionsInvoking).
+var DiscardVoteTransactions = DiscardTransactionsInvoking(solana.VoteProgramID)
+
+// DiscardTransactionsInvoking returns a TxDiscarder dropping the
+// transactionNotification of transactions with an instruction invoking
+// any of the provided programs. Unlike DiscardMentioning, it finds the programs
+// of binary-encoded (base58, base64) transactions, by decoding their message;
+// the other notifications are matched as with DiscardMentioning.
+func DiscardTransactionsInvoking(programs ...solana.PublicKey) TxDiscarder {
+	needles := encodeNeedles(programs)
+	return func(message []byte) bool {
+		data, _ := jsonparser.GetString(message, "params", "result", "transaction", "transaction", "[0]")
+		encoding, _ := jsonparser.GetString(message, "params", "result", "transaction", "transaction", "[1]")
+		if tx, ok := decodeTransaction(data, encoding); ok {
+			return invokesAny(tx, programs)
+		}
+		return containsAny(message, needles)
+	}
+}
+
+func encodeNeedles(programs []solana.PublicKey) [][]byte {
+	needles := make([][]byte, len(programs))
+	for i, program := range programs {
+		needles[i] = []byte(program.String())
+	}
+	return needles
+}
+
+// containsAny reports whether any of the base58 needles is found in message
+// as a whole word, e.g. the system program is not found in the vote program ID.
+func containsAny(message []byte, needles [][]byte) bool {
+	for _, needle := range needles {
+		for offset := 0; ; {
+			idx := bytes.Index(message[offset:], needle)
+			if idx == -1 {
+				break
+			}
+			start := offset + idx
+			end := start + len(needle)
+			if (start == 0 || !isBase58(message[start-1])) && (end == len(message) || !isBase58(message[end])) {
+				return true
+			}
+			offset = start + 1
+		}
+	}
+	return false
+}
+
+func isBase58(c byte) bool {
+	return bytes.IndexByte(base58Alphabet, c) != -1
+}
+
+var base58Alphabet = []byte("123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz")
+
+// logsErr locates the error of a logsNotification; it is usually found
+// right after the signature, so it is looked for from the start,
+// to avoid scanning the logs.
+var logsErr = newJSONKey("err", 4, false)
+
+// isSet reports whether the value at path is present and not null.
+func isSet(message []byte, path ...string) bool {
+	_, dataType, _, err := jsonparser.Get(message, path...)
+	return err == nil && dataType != jsonparser.Null
+}
+
+// newTxDiscarders combines the discarders configured by opt.
+func newTxDiscarders(opt *Options) map[string]TxDiscarder {
+	byMethod := map[string][]TxDiscarder{}
+	if opt.DiscardFailedTxs {
+		byMethod["logsNotification"] = append(byMethod["logsNotification"], DiscardFailedLogs)
+		byMethod["transactionNotification"] = append(byMethod["transactionNotification"], DiscardFailedTransactions)
+	}
+	for method, discarders := range opt.TxDiscarders {
+		for _, discarder := range discarders {
+			if discarder != nil {
+				byMethod[method] = append(byMethod[method], discarder)
+			}
+		}
+	}
+
+	out := make(map[string]TxDiscarder, len(byMethod))
+	for method, discarders := range byMethod {
+		if len(discarders) > 0 {
+			out[method] = AnyTxDiscarder(discarders...)
+		}
+	}
+	return out
+}
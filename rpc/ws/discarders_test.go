@@ -0,0 +1,97 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+func logsNotification(err string, logs ...string) []byte {
+	return []byte(fmt.Sprintf(
+		`{"jsonrpc":"2.0","method":"logsNotification","params":{"result":{"context":{"slot":5208469},"value":{"signature":"5h6xBEauJ3PK6SWCZ1PGjBvj8vDdWG3KpwATGy1ARAXFSDwt8GFXM7W5Ncn16wmqokgpiKRLuS83KUxyZyv2sUYv","err":%s,"logs":["%s"]}},"subscription":24040}}`,
+		err,
+		strings.Join(logs, `","`),
+	))
+}
+
+func TestDiscardFailedLogs(t *testing.T) {
+	logs := []string{"Program 11111111111111111111111111111111 invoke [1]", "Program 11111111111111111111111111111111 success"}
+	require.False(t, DiscardFailedLogs(logsNotification("null", logs...)))
+	require.True(t, DiscardFailedLogs(logsNotification(`{"InstructionError":[0,{"Custom":1}]}`, logs...)))
+
+	{
+		// The error is not at the usual offset.
+		shifted := []byte(strings.Replace(string(logsNotification(`{"InstructionError":[0,{"Custom":1}]}`, logs...)), `"context":{"slot":5208469}`, `"context":{"apiVersion":"2.0.15","slot":5208469}`, 1))
+		require.True(t, DiscardFailedLogs(shifted))
+		shifted = []byte(strings.Replace(string(logsNotification("null", logs...)), `"context":{"slot":5208469}`, `"context":{"apiVersion":"2.0.15","slot":5208469}`, 1))
+		require.False(t, DiscardFailedLogs(shifted))
+	}
+}
+
+func TestDiscardFailedTransactions(t *testing.T) {
+	notification := `{"jsonrpc":"2.0","method":"transactionNotification","params":{"subscription":1,"result":{"signature":"sig","slot":1,"transaction":{"transaction":["","base64"],"meta":{"err":%s,"fee":5000}}}}}`
+	require.False(t, DiscardFailedTransactions([]byte(fmt.Sprintf(notification, "null"))))
+	require.True(t, DiscardFailedTransactions([]byte(fmt.Sprintf(notification, `{"InstructionError":[0,"InvalidArgument"]}`))))
+}
+
+func TestDiscardMentioning(t *testing.T) {
+	vote := logsNotification("null", "Program Vote111111111111111111111111111111111111111 invoke [1]", "Program Vote111111111111111111111111111111111111111 success")
+	transfer := logsNotification("null", "Program 11111111111111111111111111111111 invoke [1]", "Program 11111111111111111111111111111111 success")
+
+	require.True(t, DiscardVotes(vote))
+	require.False(t, DiscardVotes(transfer))
+
+	onlySystem := DiscardNotMentioning(solana.SystemProgramID)
+	require.True(t, onlySystem(vote))
+	require.False(t, onlySystem(transfer))
+}
+
+func TestNewTxDiscarders(t *testing.T) {
+	vote := logsNotification("null", "Program Vote111111111111111111111111111111111111111 invoke [1]")
+	failed := logsNotification(`{"InstructionError":[0,{"Custom":1}]}`, "Program 11111111111111111111111111111111 invoke [1]")
+	ok := logsNotification("null", "Program 11111111111111111111111111111111 invoke [1]")
+
+	discarders := newTxDiscarders(&Options{
+		DiscardFailedTxs: true,
+		TxDiscarders: map[string][]TxDiscarder{
+			"logsNotification": {DiscardVotes},
+		},
+	})
+	discard := discarders["logsNotification"]
+	require.True(t, discard(vote))
+	require.True(t, discard(failed))
+	require.False(t, discard(ok))
+	require.NotNil(t, discarders["transactionNotification"])
+
+	require.Empty(t, newTxDiscarders(&Options{}))
+}
+
+func TestDiscardVoteTransactions(t *testing.T) {
+	vote := transactionNotification(t, newTestTransaction(t, solana.VoteProgramID))
+	transfer := transactionNotification(t, newTestTransaction(t, solana.SystemProgramID))
+
+	// The vote program is not found in a base64 transaction by DiscardVotes.
+	require.False(t, DiscardVotes(vote))
+	require.True(t, DiscardVoteTransactions(vote))
+	require.False(t, DiscardVoteTransactions(transfer))
+
+	// Other notifications are matched as with DiscardMentioning.
+	require.True(t, DiscardVoteTransactions(logsNotification("null", "Program Vote111111111111111111111111111111111111111 invoke [1]")))
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+// notificationFastPath locates the subscription ID and the signature
+// in the raw notifications of a subscribe method, without parsing them
+// (see Options.UseSubIDRetrievals and the cache of ConnectWithOptions).
+type notificationFastPath struct {
+	notification string
+	subID        subIDRetrievalFunc
+	signature    signatureRetrievalFunc
+}
+
+// notificationFastPaths are the fast paths by subscribe method.
+// They rely on the layout of the notifications of the server implementing
+// the method, e.g. Helius for transactionSubscribe, so they are registered
+// on a connection only once a subscription of the method is made.
+var notificationFastPaths = map[string]notificationFastPath{
+	"logsSubscribe": {
+		notification: "logsNotification",
+		subID:        defaultSubIDRetrievals["logsNotification"],
+		signature:    defaultSigRetrievals["logsNotification"],
+	},
+	"transactionSubscribe": {
+		notification: "transactionNotification",
+		subID:        defaultSubIDRetrievals["transactionNotification"],
+		signature:    defaultSigRetrievals["transactionNotification"],
+	},
+}
+
+// fastPathTable holds the fast paths registered on a connection,
+// by notification method. It is never modified once published.
+type fastPathTable struct {
+	subIDs     map[string]subIDRetrievalFunc
+	signatures map[string]signatureRetrievalFunc
+}
+
+func (t *fastPathTable) subID(method string) (subIDRetrievalFunc, bool) {
+	if t == nil {
+		return nil, false
+	}
+	fn, ok := t.subIDs[method]
+	return fn, ok
+}
+
+func (t *fastPathTable) signature(method string) (signatureRetrievalFunc, bool) {
+	if t == nil {
+		return nil, false
+	}
+	fn, ok := t.signatures[method]
+	return fn, ok
+}
+
+// registerFastPath enables the fast paths of the notifications
+// of the subscribe method, if any and if they are enabled by the options.
+// The read loop sees the new table from the next message on.
+// The lock must be held.
+func (c *connection) registerFastPath(subscribeMethod string) {
+	path, ok := notificationFastPaths[subscribeMethod]
+	if !ok {
+		return
+	}
+	current := c.fastPaths.Load()
+	_, hasSubID := current.subID(path.notification)
+	_, hasSignature := current.signature(path.notification)
+	addSubID := c.useSubIDRetrievals && !hasSubID
+	addSignature := c.useSigRetrievals && !hasSignature
+	if !addSubID && !addSignature {
+		return
+	}
+
+	next := &fastPathTable{
+		subIDs:     make(map[string]subIDRetrievalFunc),
+		signatures: make(map[string]signatureRetrievalFunc),
+	}
+	if current != nil {
+		for method, fn := range current.subIDs {
+			next.subIDs[method] = fn
+		}
+		for method, fn := range current.signatures {
+			next.signatures[method] = fn
+		}
+	}
+	if addSubID {
+		next.subIDs[path.notification] = path.subID
+	}
+	if addSignature {
+		next.signatures[path.notification] = path.signature
+	}
+	c.fastPaths.Store(next)
+}
@@ -0,0 +1,204 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrGroupClosed is returned by the methods of a closed SubscriptionGroup.
+var ErrGroupClosed = errors.New("subscription group closed")
+
+// Subscriber is implemented by the typed subscriptions
+// (e.g. *AccountSubscription, *LogSubscription).
+type Subscriber interface {
+	Subscription() *Subscription
+}
+
+// GroupMessage is a notification of a member of a SubscriptionGroup,
+// or the error that ended the member.
+type GroupMessage struct {
+	// Name of the member, as provided to SubscriptionGroup.Add.
+	Name string
+	// Method of the subscription, e.g. "accountSubscribe".
+	Method string
+
+	// Value is the notification, of the type returned by the Recv method
+	// of the member (e.g. *AccountResult for an AccountSubscription).
+	Value interface{}
+	Meta  NotificationMeta
+
+	// Err is the error that ended the member, which delivers
+	// no notifications afterwards; Value is nil.
+	Err error
+}
+
+// SubscriptionGroup multiplexes the notifications of several subscriptions
+// into a single stream, and closes them all at once:
+//
+//	group := ws.NewSubscriptionGroup()
+//	defer group.Close()
+//	group.Add("vault", accountSub)
+//	group.Add("logs", logsSub)
+//	group.Add("slots", slotSub)
+//	for {
+//		msg, err := group.Recv(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		switch v := msg.Value.(type) {
+//		case *ws.AccountResult:
+//		case *ws.LogResult:
+//		case *ws.SlotResult:
+//		}
+//	}
+type SubscriptionGroup struct {
+	stream chan GroupMessage
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	lock    sync.Mutex
+	closed  bool
+	members map[string]*Subscription
+}
+
+// NewSubscriptionGroup creates a new empty SubscriptionGroup.
+func NewSubscriptionGroup() *SubscriptionGroup {
+	return &SubscriptionGroup{
+		stream:  make(chan GroupMessage, DefaultSubscriptionBuffer),
+		done:    make(chan struct{}),
+		members: map[string]*Subscription{},
+	}
+}
+
+// Add adds the subscription to the group under the provided name,
+// which must be unique in the group. Once added, the subscription
+// must only be received from through the group.
+func (g *SubscriptionGroup) Add(name string, sub Subscriber) error {
+	return g.AddSubscription(name, sub.Subscription())
+}
+
+// AddSubscription is like Add, for a subscription created with SubscribeRaw.
+func (g *SubscriptionGroup) AddSubscription(name string, sub *Subscription) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.closed {
+		return ErrGroupClosed
+	}
+	if _, ok := g.members[name]; ok {
+		return fmt.Errorf("subscription group: duplicate member %q", name)
+	}
+	g.members[name] = sub
+	g.wg.Add(1)
+	go g.forward(name, sub)
+	return nil
+}
+
+// Remove unsubscribes the member with the provided name, if any.
+func (g *SubscriptionGroup) Remove(name string) {
+	g.lock.Lock()
+	sub, ok := g.members[name]
+	delete(g.members, name)
+	g.lock.Unlock()
+	if ok {
+		sub.Unsubscribe()
+	}
+}
+
+// Names returns the names of the members of the group
+// that have not ended, in no particular order.
+func (g *SubscriptionGroup) Names() []string {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	names := make([]string, 0, len(g.members))
+	for name := range g.members {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (g *SubscriptionGroup) forward(name string, sub *Subscription) {
+	defer g.wg.Done()
+	for {
+		var msg GroupMessage
+		select {
+		case <-g.done:
+			return
+		case d := <-sub.stream:
+			msg = GroupMessage{Name: name, Method: sub.method, Value: d.value, Meta: d.meta(time.Now())}
+		case err := <-sub.err:
+			g.lock.Lock()
+			if g.members[name] == sub {
+				delete(g.members, name)
+			}
+			g.lock.Unlock()
+			if errors.Is(err, ErrCanceled) {
+				// Removed from the group, or unsubscribed by the caller.
+				return
+			}
+			msg = GroupMessage{Name: name, Method: sub.method, Err: err}
+		}
+		select {
+		case g.stream <- msg:
+		case <-g.done:
+			return
+		}
+		if msg.Err != nil {
+			return
+		}
+	}
+}
+
+// Recv waits for the next message of the group, or for ctx to be done.
+// It returns ErrGroupClosed once the group is closed.
+func (g *SubscriptionGroup) Recv(ctx context.Context) (GroupMessage, error) {
+	select {
+	case <-g.done:
+		return GroupMessage{}, ErrGroupClosed
+	default:
+	}
+	select {
+	case <-ctx.Done():
+		return GroupMessage{}, ctx.Err()
+	case <-g.done:
+		return GroupMessage{}, ErrGroupClosed
+	case msg := <-g.stream:
+		return msg, nil
+	}
+}
+
+// Close unsubscribes all the members of the group; it is safe
+// to call it several times.
+func (g *SubscriptionGroup) Close() {
+	g.lock.Lock()
+	if g.closed {
+		g.lock.Unlock()
+		return
+	}
+	g.closed = true
+	members := g.members
+	g.members = map[string]*Subscription{}
+	close(g.done)
+	g.lock.Unlock()
+
+	for _, sub := range members {
+		sub.Unsubscribe()
+	}
+	g.wg.Wait()
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptionGroup(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	group := NewSubscriptionGroup()
+	defer group.Close()
+
+	slots, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	require.NoError(t, group.Add("slots", slots))
+	account, err := c.AccountSubscribe(solana.SystemProgramID, rpc.CommitmentConfirmed)
+	require.NoError(t, err)
+	require.NoError(t, group.Add("account", account))
+	require.Error(t, group.Add("slots", account))
+	require.ElementsMatch(t, []string{"slots", "account"}, group.Names())
+
+	_, err = server.WaitForSubscription(ctx, "slotSubscribe")
+	require.NoError(t, err)
+	_, err = server.WaitForSubscription(ctx, "accountSubscribe")
+	require.NoError(t, err)
+
+	_, err = server.Notify("slotSubscribe", rpc.M{"parent": 1, "root": 0, "slot": 2})
+	require.NoError(t, err)
+	msg, err := group.Recv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "slots", msg.Name)
+	require.Equal(t, "slotSubscribe", msg.Method)
+	require.EqualValues(t, 2, msg.Value.(*SlotResult).Slot)
+	require.EqualValues(t, 2, msg.Meta.Slot)
+
+	_, err = server.Notify("accountSubscribe", rpc.M{
+		"context": rpc.M{"slot": 3},
+		"value":   rpc.M{"lamports": 42, "owner": solana.SystemProgramID.String(), "data": []string{"", "base64"}},
+	})
+	require.NoError(t, err)
+	msg, err = group.Recv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "account", msg.Name)
+	require.EqualValues(t, 42, msg.Value.(*AccountResult).Value.Lamports)
+
+	group.Remove("slots")
+	require.Equal(t, []string{"account"}, group.Names())
+
+	group.Close()
+	_, err = group.Recv(ctx)
+	require.ErrorIs(t, err, ErrGroupClosed)
+	require.ErrorIs(t, <-account.Err(), ErrCanceled)
+	require.ErrorIs(t, group.Add("slots", slots), ErrGroupClosed)
+}
+
+func TestSubscriptionGroup_MemberError(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in request
+			if err := json.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":%d}`, in.ID)))
+		}
+	}))
+	defer server.Close()
+
+	c, err := Connect(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	group := NewSubscriptionGroup()
+	defer group.Close()
+	slots, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	require.NoError(t, group.Add("slots", slots))
+
+	msg, err := group.Recv(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "slots", msg.Name)
+	require.Error(t, msg.Err)
+	require.Nil(t, msg.Value)
+	require.Empty(t, group.Names())
+}
@@ -0,0 +1,218 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// DefaultHealthCheckInterval is the maximum interval at which the
+// health of a connection is evaluated.
+var DefaultHealthCheckInterval = time.Second
+
+// Health describes the liveness of a websocket connection.
+type Health struct {
+	// Time at which the connection was established.
+	ConnectedAt time.Time
+	// Time at which the last message was received, zero if none was received.
+	LastMessage time.Time
+	// Time at which the last pong was received, zero if none was received.
+	LastPong time.Time
+	// Round-trip time of the last ping answered by the server, zero if none was answered.
+	PingRTT time.Duration
+
+	// Number of messages and bytes received since the connection was established.
+	Messages uint64
+	Bytes    uint64
+	// Messages received per second, measured over the last health check interval.
+	MessagesPerSecond float64
+
+	// Degraded reports that neither a message nor a pong was received within
+	// the health window (see Options.HealthWindow).
+	Degraded bool
+}
+
+// HealthFunc is called when a connection becomes degraded.
+type HealthFunc func(health Health)
+
+// connectionHealth tracks the liveness of a connection.
+type connectionHealth struct {
+	window      time.Duration
+	onDegraded  HealthFunc
+	connectedAt atomic.Int64 // unix nano
+
+	lastMessage atomic.Int64 // unix nano
+	lastPong    atomic.Int64 // unix nano
+	rtt         atomic.Int64
+	messages    atomic.Uint64
+	bytes       atomic.Uint64
+	rate        atomic.Uint64 // math.Float64bits of the messages per second
+	degraded    atomic.Bool
+
+	lock sync.Mutex
+	// degradedCh is closed when the connection becomes degraded,
+	// and replaced when it recovers.
+	degradedCh chan struct{}
+}
+
+func newConnectionHealth(window time.Duration, onDegraded HealthFunc) *connectionHealth {
+	h := &connectionHealth{
+		window:     window,
+		onDegraded: onDegraded,
+		degradedCh: make(chan struct{}),
+	}
+	h.connectedAt.Store(time.Now().UnixNano())
+	return h
+}
+
+// reset records a new connection, whose liveness is tracked from now on.
+func (h *connectionHealth) reset() {
+	h.connectedAt.Store(time.Now().UnixNano())
+}
+
+// Health returns the liveness of the connection.
+func (c *connection) Health() Health {
+	h := c.health
+	out := Health{
+		ConnectedAt:       time.Unix(0, h.connectedAt.Load()),
+		PingRTT:           time.Duration(h.rtt.Load()),
+		Messages:          h.messages.Load(),
+		Bytes:             h.bytes.Load(),
+		MessagesPerSecond: math.Float64frombits(h.rate.Load()),
+		Degraded:          h.degraded.Load(),
+	}
+	if v := h.lastMessage.Load(); v > 0 {
+		out.LastMessage = time.Unix(0, v)
+	}
+	if v := h.lastPong.Load(); v > 0 {
+		out.LastPong = time.Unix(0, v)
+	}
+	return out
+}
+
+// Degraded returns a channel that is closed when the connection becomes degraded,
+// i.e. when neither a message nor a pong was received within the health window.
+// A new channel is returned once the connection recovers.
+func (c *connection) Degraded() <-chan struct{} {
+	c.health.lock.Lock()
+	defer c.health.lock.Unlock()
+	return c.health.degradedCh
+}
+
+// recordMessage records a message of size bytes received from the server.
+func (h *connectionHealth) recordMessage(size int) {
+	h.lastMessage.Store(time.Now().UnixNano())
+	h.messages.Add(1)
+	h.bytes.Add(uint64(size))
+}
+
+// pingPayload returns the payload of a ping, the send time echoed back in the pong.
+func pingPayload(now time.Time) []byte {
+	return strconv.AppendInt(nil, now.UnixNano(), 10)
+}
+
+// recordPong records a pong with the payload of the ping it answers.
+func (h *connectionHealth) recordPong(payload string) {
+	now := time.Now()
+	h.lastPong.Store(now.UnixNano())
+	if sent, err := strconv.ParseInt(payload, 10, 64); err == nil && sent > 0 {
+		if rtt := now.Sub(time.Unix(0, sent)); rtt >= 0 {
+			h.rtt.Store(int64(rtt))
+		}
+	}
+}
+
+// lastActivity returns the time of the last message or pong, or the connection time.
+func (h *connectionHealth) lastActivity() time.Time {
+	last := h.connectedAt.Load()
+	if v := h.lastMessage.Load(); v > last {
+		last = v
+	}
+	if v := h.lastPong.Load(); v > last {
+		last = v
+	}
+	return time.Unix(0, last)
+}
+
+// monitorHealth evaluates the health of the connection periodically
+// until the connection is closed.
+func (c *connection) monitorHealth() {
+	interval := c.health.window / 4
+	if interval <= 0 || interval > DefaultHealthCheckInterval {
+		interval = DefaultHealthCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prevMessages, prevTime := uint64(0), time.Now()
+	for {
+		select {
+		case <-c.connCtx.Done():
+			return
+		case now := <-ticker.C:
+			messages := c.health.messages.Load()
+			if elapsed := now.Sub(prevTime).Seconds(); elapsed > 0 {
+				c.health.rate.Store(math.Float64bits(float64(messages-prevMessages) / elapsed))
+			}
+			prevMessages, prevTime = messages, now
+
+			c.checkHealth(now)
+		}
+	}
+}
+
+// checkHealth updates the degraded state of the connection at now.
+func (c *connection) checkHealth(now time.Time) {
+	h := c.health
+	degraded := now.Sub(h.lastActivity()) > h.window
+	if h.degraded.Load() == degraded {
+		return
+	}
+
+	h.lock.Lock()
+	h.degraded.Store(degraded)
+	if degraded {
+		close(h.degradedCh)
+	} else {
+		h.degradedCh = make(chan struct{})
+	}
+	h.lock.Unlock()
+
+	if !degraded {
+		c.log().Info("ws connection recovered", zap.String("label", c.label))
+		return
+	}
+	c.log().Warn("ws connection degraded",
+		zap.String("label", c.label),
+		zap.Duration("window", h.window),
+		zap.Time("last_activity", h.lastActivity()),
+	)
+	if h.onDegraded != nil {
+		h.onDegraded(c.Health())
+	}
+}
+
+func (c *Client) sendPing() {
+	if err := c.send(websocket.PingMessage, pingPayload(time.Now())); err != nil {
+		c.log().Debug("unable to send ping message", zap.Error(err))
+	}
+}
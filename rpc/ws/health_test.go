@@ -0,0 +1,113 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Health(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{
+		PongWait:   10 * time.Second,
+		PingPeriod: 20 * time.Millisecond,
+	}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	_, err = sub.Recv()
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return !c.Health().LastPong.IsZero()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	health := c.Health()
+	require.False(t, health.Degraded)
+	require.Greater(t, health.PingRTT, time.Duration(0))
+	require.Less(t, health.PingRTT, 5*time.Second)
+	require.GreaterOrEqual(t, health.Messages, uint64(2))
+	require.Greater(t, health.Bytes, uint64(0))
+	require.False(t, health.LastMessage.IsZero())
+	require.False(t, health.ConnectedAt.After(health.LastMessage))
+
+	select {
+	case <-c.Degraded():
+		t.Fatal("connection should not be degraded")
+	default:
+	}
+}
+
+func Test_HealthDegraded(t *testing.T) {
+	send := make(chan struct{})
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Never read, so that pings are not answered.
+		for range send {
+			conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{},"subscription":1}}`))
+		}
+	}))
+	defer server.Close()
+	defer close(send)
+
+	degraded := make(chan Health, 1)
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{
+		PongWait:     10 * time.Second,
+		PingPeriod:   20 * time.Millisecond,
+		HealthWindow: 100 * time.Millisecond,
+		OnDegraded: func(health Health) {
+			degraded <- health
+		},
+	}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ch := c.Degraded()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection should be degraded")
+	}
+	health := <-degraded
+	require.True(t, health.Degraded)
+	require.True(t, health.LastPong.IsZero())
+	require.True(t, c.Health().Degraded)
+
+	// A message makes the connection healthy again.
+	send <- struct{}{}
+	require.Eventually(t, func() bool {
+		return !c.Health().Degraded
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NotEqual(t, ch, c.Degraded())
+	require.Equal(t, uint64(1), c.Health().Messages)
+}
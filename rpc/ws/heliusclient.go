@@ -1,9 +1,40 @@
 package ws
 
+import (
+	"context"
+	"fmt"
+)
+
+// HeliusClient is a Client supporting the Helius-specific subscriptions
+// (e.g. transactionSubscribe) in addition to the standard ones,
+// all sharing the same connection.
 type HeliusClient struct {
 	*Client
 }
 
+// ConnectHelius creates a new websocket client connecting to the provided
+// Helius endpoint (see ConnectWithOptions).
+func ConnectHelius(ctx context.Context, rpcEndpoint string, opt *Options) (*HeliusClient, error) {
+	c, err := ConnectWithOptions(ctx, rpcEndpoint, opt, nil)
+	if err != nil {
+		return nil, fmt.Errorf("new helius ws client: %w", err)
+	}
+	return c.Helius(), nil
+}
+
+// Helius returns a view of the client making the Helius-specific
+// subscriptions available on its connection, e.g. for a client
+// created with ConnectWithOptions to share a signature cache.
+func (c *Client) Helius() *HeliusClient {
+	return &HeliusClient{Client: c}
+}
+
 func (c *HeliusClient) TransactionSubscribe(filter TransactionSubscribeFilterType, opts TransactionSubscribeOptionsType) (*TransactionSubscription, error) {
-	return c.transactionSubscribe(filter, opts)
+	return c.transactionSubscribe(c.subscribeContext(), filter, opts)
+}
+
+// TransactionSubscribeWithContext is like TransactionSubscribe, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (c *HeliusClient) TransactionSubscribeWithContext(ctx context.Context, filter TransactionSubscribeFilterType, opts TransactionSubscribeOptionsType) (*TransactionSubscription, error) {
+	return c.transactionSubscribe(ctx, filter, opts)
 }
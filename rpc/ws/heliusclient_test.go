@@ -0,0 +1,120 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// newHeliusServer answers logsSubscribe and transactionSubscribe requests,
+// and sends a notification right after confirming each subscription,
+// in the layout of the respective server implementation.
+func newHeliusServer(t *testing.T, sig solana.Signature) string {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in request
+			if err := stdjson.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			subID := in.ID + 100
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":%d,"id":%d}`, subID, in.ID)))
+			switch in.Method {
+			case "logsSubscribe":
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+					`{"jsonrpc":"2.0","method":"logsNotification","params":{"result":{"context":{"slot":5},"value":{"signature":"%s","err":null,"logs":["Program 11111111111111111111111111111111 invoke [1]"]}},"subscription":%d}}`,
+					sig, subID,
+				)))
+			case "transactionSubscribe":
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+					`{"jsonrpc":"2.0","method":"transactionNotification","params":{"subscription":%d,"result":{"transaction":{"transaction":[],"meta":{"fee":5000}},"signature":"%s","slot":5}}}`,
+					subID, sig,
+				)))
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestConnectHelius(t *testing.T) {
+	sig := solana.Signature{1, 2, 3}
+	c, err := ConnectHelius(context.Background(), newHeliusServer(t, sig), &Options{UseSubIDRetrievals: true})
+	require.NoError(t, err)
+	defer c.Close()
+
+	logs, err := c.LogsSubscribe(LogsSubscribeFilterAll, "")
+	require.NoError(t, err)
+	defer logs.Unsubscribe()
+	gotLog, err := logs.Recv()
+	require.NoError(t, err)
+	require.Equal(t, sig, gotLog.Value.Signature)
+
+	// Only the fast path of the subscribed method is registered.
+	_, ok := c.fastPaths.Load().subID("logsNotification")
+	require.True(t, ok)
+	_, ok = c.fastPaths.Load().subID("transactionNotification")
+	require.False(t, ok)
+
+	txs, err := c.TransactionSubscribe(TransactionSubscribeFilterType{}, TransactionSubscribeOptionsType{})
+	require.NoError(t, err)
+	defer txs.Unsubscribe()
+	gotTx, err := txs.Recv()
+	require.NoError(t, err)
+	require.Equal(t, sig.String(), gotTx.Signature)
+
+	_, ok = c.fastPaths.Load().subID("transactionNotification")
+	require.True(t, ok)
+	_, ok = c.fastPaths.Load().signature("transactionNotification")
+	require.False(t, ok, "no signature cache was provided")
+}
+
+func TestClient_Helius(t *testing.T) {
+	sig := solana.Signature{4, 5, 6}
+	c, err := ConnectWithOptions(context.Background(), newHeliusServer(t, sig), nil, NewSignatureCache(10))
+	require.NoError(t, err)
+	defer c.Close()
+
+	txs, err := c.Helius().TransactionSubscribe(TransactionSubscribeFilterType{}, TransactionSubscribeOptionsType{})
+	require.NoError(t, err)
+	defer txs.Unsubscribe()
+	got, err := txs.Recv()
+	require.NoError(t, err)
+	require.Equal(t, sig.String(), got.Signature)
+
+	_, ok := c.fastPaths.Load().signature("transactionNotification")
+	require.True(t, ok)
+	_, ok = c.fastPaths.Load().subID("transactionNotification")
+	require.False(t, ok, "subscription ID retrievals are disabled")
+}
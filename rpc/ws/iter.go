@@ -0,0 +1,52 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package ws
+
+import (
+	"context"
+	"iter"
+)
+
+// recvAll iterates over the notifications returned by recv,
+// until recv returns an error (which is yielded) or ctx is done.
+func recvAll[T any](ctx context.Context, recv func(context.Context) (T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			got, err := recv(ctx)
+			if !yield(got, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// All iterates over the notifications of the subscription until it
+// is closed or ctx is done; the error that ended it is yielded last.
+// Breaking out of the loop does not unsubscribe.
+func (sw *TypedSubscription[T]) All(ctx context.Context) iter.Seq2[*T, error] {
+	return recvAll(ctx, sw.RecvWithContext)
+}
+
+// All iterates over the notifications of the subscription (see TypedSubscription.All).
+func (a *AdaptiveTransactionSubscription) All(ctx context.Context) iter.Seq2[*TransactionResult, error] {
+	return recvAll(ctx, a.RecvWithContext)
+}
+
+// All iterates over the notifications of the subscription (see TypedSubscription.All).
+func (s *Subscription) All(ctx context.Context) iter.Seq2[interface{}, error] {
+	return recvAll(ctx, s.RecvWithContext)
+}
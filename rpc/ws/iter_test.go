@@ -0,0 +1,56 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package ws
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SubscriptionAll(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := Connect(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var slots []uint64
+	var lastErr error
+	for got, err := range sub.All(ctx) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		slots = append(slots, got.Slot)
+		// The server sends a single notification.
+		cancel()
+	}
+	require.Equal(t, []uint64{2}, slots)
+	require.ErrorIs(t, lastErr, context.Canceled)
+}
@@ -0,0 +1,247 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// jsonKey locates the value of a key in the raw JSON of a notification
+// without parsing it, wherever the server writes the key.
+//
+// The key is matched by name and by depth (the number of objects and arrays
+// enclosing it; the keys of the root object are at depth 1), so it must not
+// appear at the same depth elsewhere in the message. Occurrences of the name
+// inside strings are never matched, since every quote of a JSON string
+// is escaped.
+//
+// The occurrences of the key are searched from the end of the message
+// that it is usually the closest to, so that the position of the key
+// only matters for performance: finding the depth of a key requires
+// scanning the message from that end up to it.
+type jsonKey struct {
+	quoted  []byte // the name of the key, quoted
+	depth   int
+	fromEnd bool
+}
+
+func newJSONKey(name string, depth int, fromEnd bool) *jsonKey {
+	return &jsonKey{
+		quoted:  []byte(strconv.Quote(name)),
+		depth:   depth,
+		fromEnd: fromEnd,
+	}
+}
+
+// value returns the raw value of the key, and false if the key is not found
+// or the message is malformed; the quotes of a string value are removed,
+// and an object or array value is returned up to the end of the message.
+func (k *jsonKey) value(message []byte) ([]byte, bool) {
+	if k.fromEnd {
+		for end := len(message); ; {
+			at := bytes.LastIndex(message[:end], k.quoted)
+			if at == -1 {
+				return nil, false
+			}
+			if value, ok := k.valueAt(message, at); ok {
+				return value, true
+			}
+			end = at
+		}
+	}
+	for start := 0; ; {
+		at := bytes.Index(message[start:], k.quoted)
+		if at == -1 {
+			return nil, false
+		}
+		at += start
+		if value, ok := k.valueAt(message, at); ok {
+			return value, true
+		}
+		start = at + 1
+	}
+}
+
+// valueAt returns the value of the key if the occurrence of its name at
+// the provided offset is a key at the expected depth.
+func (k *jsonKey) valueAt(message []byte, at int) ([]byte, bool) {
+	if isEscaped(message, at) {
+		return nil, false
+	}
+	rest := skipSpaces(message[at+len(k.quoted):])
+	if len(rest) == 0 || rest[0] != ':' {
+		return nil, false
+	}
+	var depth int
+	var ok bool
+	if at < len(message)/2 {
+		depth, ok = depthForward(message, at)
+	} else {
+		depth, ok = depthBackward(message, at+len(k.quoted))
+	}
+	if !ok || depth != k.depth {
+		return nil, false
+	}
+	return scalarValue(skipSpaces(rest[1:]))
+}
+
+// uint64 returns the value of the key as an unsigned integer.
+func (k *jsonKey) uint64(message []byte) (uint64, bool) {
+	value, ok := k.value(message)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(value), 10, 64)
+	return n, err == nil
+}
+
+// signature returns the value of the key as a signature,
+// or the zero signature if it is not found.
+func (k *jsonKey) signature(message []byte) solana.Signature {
+	value, ok := k.value(message)
+	if !ok {
+		return solana.Signature{}
+	}
+	sig, _ := solana.SignatureFromBase58(string(value))
+	return sig
+}
+
+// isNull reports whether the value of the key is null;
+// ok is false if the key is not found.
+func (k *jsonKey) isNull(message []byte) (null bool, ok bool) {
+	value, ok := k.value(message)
+	if !ok {
+		return false, false
+	}
+	return bytes.Equal(value, []byte("null")), true
+}
+
+// scalarValue returns the value at the start of b,
+// without the quotes if it is a string.
+func scalarValue(b []byte) ([]byte, bool) {
+	if len(b) == 0 {
+		return nil, false
+	}
+	switch b[0] {
+	case '"':
+		end := closingQuote(b, 1)
+		if end == -1 {
+			return nil, false
+		}
+		return b[1:end], true
+	case '{', '[':
+		return b, true
+	}
+	end := bytes.IndexAny(b, " \t\r\n,]}")
+	if end == -1 {
+		return nil, false
+	}
+	return b[:end], true
+}
+
+// depthForward returns the number of objects and arrays
+// opened before the offset, scanning from the start of the message.
+func depthForward(message []byte, offset int) (int, bool) {
+	depth := 0
+	for i := 0; i < offset; i++ {
+		switch message[i] {
+		case '"':
+			end := closingQuote(message, i+1)
+			if end == -1 || end >= offset {
+				return 0, false
+			}
+			i = end
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		}
+	}
+	return depth, true
+}
+
+// depthBackward returns the number of objects and arrays
+// closed after the offset, scanning from the end of the message.
+func depthBackward(message []byte, offset int) (int, bool) {
+	depth := 0
+	for i := len(message) - 1; i >= offset; i-- {
+		switch message[i] {
+		case '"':
+			start := openingQuote(message, i)
+			if start < offset {
+				return 0, false
+			}
+			i = start
+		case '}', ']':
+			depth++
+		case '{', '[':
+			depth--
+		}
+	}
+	return depth, true
+}
+
+// closingQuote returns the offset of the first unescaped quote
+// from the provided offset, or -1.
+func closingQuote(b []byte, from int) int {
+	for {
+		i := bytes.IndexByte(b[from:], '"')
+		if i == -1 {
+			return -1
+		}
+		from += i
+		if !isEscaped(b, from) {
+			return from
+		}
+		from++
+	}
+}
+
+// openingQuote returns the offset of the unescaped quote opening
+// the string closed by the quote at the provided offset, or -1.
+func openingQuote(b []byte, closing int) int {
+	for end := closing; ; {
+		i := bytes.LastIndexByte(b[:end], '"')
+		if i == -1 || !isEscaped(b, i) {
+			return i
+		}
+		end = i
+	}
+}
+
+// isEscaped reports whether the byte at the offset
+// is preceded by an odd number of backslashes.
+func isEscaped(b []byte, offset int) bool {
+	backslashes := 0
+	for i := offset - 1; i >= 0 && b[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 1
+}
+
+func skipSpaces(b []byte) []byte {
+	for len(b) > 0 {
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			b = b[1:]
+		default:
+			return b
+		}
+	}
+	return b
+}
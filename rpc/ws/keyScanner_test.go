@@ -0,0 +1,117 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/stretchr/testify/require"
+)
+
+// notificationCorpus are notifications in the layout written by the Solana RPC
+// and by Helius, and in a different field order (and pretty-printed),
+// so that the fast paths don't depend on where the servers write the keys.
+var notificationCorpus = []struct {
+	file      string
+	method    string
+	subID     uint64
+	signature string
+	failed    bool
+}{
+	{"solana-logs.json", "logsNotification", 24040, "4Jgs8aGj66iQCt88mNPXyjoJpPaCpYwurgym6AtJ2dBf9HZBqwV8ioNXwUw9Sq4iCHjVFPCDthZ6FbPoeeWjXZwh", false},
+	{"solana-logs-failed.json", "logsNotification", 24041, "nxF7K6UtyD1pfmf8FjaXZ8C5zEZ8samJ5cKPpcgUS6v4j3Yb8GTLD3edQX9xZt3GNpehYVK8AqdbbHkgzoGnbj8", true},
+	{"solana-logs-api-version.json", "logsNotification", 24042, "56UKTXRiXUmTAm57tg7qbFH1rHayGvHMPzzQ52mjLwXJyUxfTpUf5LKi1xujubHWK87hiBNkgcAmrY5vBLJrPDeV", false},
+	{"reordered-logs.json", "logsNotification", 24043, "4Jgs8aGj66iQCt88mNPXyjoJpPaCpYwurgym6AtJ2dBf9HZBqwV8ioNXwUw9Sq4iCHjVFPCDthZ6FbPoeeWjXZwh", false},
+	{"helius-transaction-base64.json", "transactionNotification", 4743323479349712, "HtRCLEAvzGSzK4fBrrwC9BoNEznAyNTaQ5f8pMqLhHmKu7VWbje6UsagQt3cc7Df1B1jFfNP5QVyo31EhwCvRBF", false},
+	{"helius-transaction-json-failed.json", "transactionNotification", 4743323479349713, "5Jchm6T1PJaKhBWNjuHSVedzgVXupmGXCRVmeQsZ6fSYeM1GM2ejGmWXpNyDJh2j38Ht5aKRP9b8BzTu9AuhJahJ", true},
+	{"reordered-transaction.json", "transactionNotification", 4743323479349714, "5Jmk8tJa3uUAkv354cGjR5UfFYZVdRwb27h533Vn3YqDdd75XsVrNMUhXYGFyJPSXNwmPpoUUEfVoFiRYaZH9VRu", false},
+}
+
+func readNotification(t testing.TB, file string) []byte {
+	message, err := os.ReadFile(filepath.Join("testdata", "notifications", file))
+	require.NoError(t, err)
+	return bytes.TrimSpace(message)
+}
+
+func TestNotificationFastPaths(t *testing.T) {
+	for _, entry := range notificationCorpus {
+		t.Run(entry.file, func(t *testing.T) {
+			message := readNotification(t, entry.file)
+
+			subID, ok := defaultSubIDRetrievals[entry.method](message)
+			require.True(t, ok)
+			require.Equal(t, entry.subID, subID)
+
+			require.Equal(t, solana.MustSignatureFromBase58(entry.signature), defaultSigRetrievals[entry.method](message))
+
+			if entry.method == "logsNotification" {
+				require.Equal(t, entry.failed, DiscardFailedLogs(message))
+			} else {
+				require.Equal(t, entry.failed, DiscardFailedTransactions(message))
+			}
+		})
+	}
+}
+
+func TestJSONKey(t *testing.T) {
+	subscription := newJSONKey("subscription", 2, true)
+	tests := []struct {
+		name    string
+		message string
+		value   string
+		ok      bool
+	}{
+		{"at end", `{"params":{"result":{},"subscription":7}}`, "7", true},
+		{"at start", `{"params":{"subscription":7,"result":{}}}`, "7", true},
+		{"spaces", `{ "params" : { "subscription" : 7 } }`, "7", true},
+		{"nested deeper", `{"params":{"result":{"subscription":1}}}`, "", false},
+		{"nested deeper, then at depth", `{"params":{"subscription":2,"result":{"subscription":1}}}`, "2", true},
+		{"in a string", `{"params":{"result":"\"subscription\":1"}}`, "", false},
+		{"as a value", `{"params":{"result":"subscription","x":1}}`, "", false},
+		{"after an escaped backslash", `{"params":{"result":"\\","subscription":3}}`, "3", true},
+		{"string value", `{"params":{"subscription":"a\"b"}}`, `a\"b`, true},
+		{"missing", `{"params":{"result":{}}}`, "", false},
+		{"truncated", `{"params":{"result":"x`, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, fromEnd := range []bool{true, false} {
+				subscription.fromEnd = fromEnd
+				value, ok := subscription.value([]byte(test.message))
+				require.Equal(t, test.ok, ok, "fromEnd: %v", fromEnd)
+				require.Equal(t, test.value, string(value), "fromEnd: %v", fromEnd)
+			}
+		})
+	}
+}
+
+func Benchmark_NotificationFastPaths(b *testing.B) {
+	for _, entry := range notificationCorpus {
+		message := readNotification(b, entry.file)
+		subIDRetrieval := defaultSubIDRetrievals[entry.method]
+		sigRetrieval := defaultSigRetrievals[entry.method]
+		b.Run(entry.file, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				subIDRetrieval(message)
+				sigRetrieval(message)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+// closeOnParentDone closes the client once the parent context
+// (see Options.Context) is done, unless the client is closed first.
+func (c *Client) closeOnParentDone() {
+	select {
+	case <-c.parentCtx.Done():
+		c.end(c.parentCtx.Err())
+		c.Close()
+	case <-c.connCtx.Done():
+	}
+}
+
+// end records the reason why the client stopped; only the first one is kept.
+func (c *connection) end(err error) {
+	c.endLock.Lock()
+	defer c.endLock.Unlock()
+	if !c.ended {
+		c.ended, c.endErr = true, err
+	}
+}
+
+// closeError returns the error closing the subscriptions when the client
+// is closed: err, or an error matching both ErrCanceled and the error
+// of the parent context if the client was closed by it.
+func (c *connection) closeError(err error) error {
+	c.endLock.Lock()
+	defer c.endLock.Unlock()
+	if c.endErr != nil {
+		return &contextCanceledError{err: c.endErr}
+	}
+	return err
+}
+
+// Done returns a channel that is closed once the client is fully shut down:
+// the connection is closed, all the subscriptions are closed, and all
+// the internal goroutines (read loop, writes, pings, health monitoring)
+// have returned.
+//
+// It happens after Close, once the parent context is done (see Options.Context),
+// or once the connection fails and is not reestablished.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait blocks until the client is fully shut down (see Done), and returns
+// the reason: nil after Close, the error of the parent context if it is done,
+// or the error of the connection if it failed.
+func (c *Client) Wait() error {
+	<-c.done
+	c.endLock.Lock()
+	defer c.endLock.Unlock()
+	return c.endErr
+}
@@ -0,0 +1,77 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMessageTooLarge matches the *MessageTooLargeError errors.
+var ErrMessageTooLarge = errors.New("message too large")
+
+// MessageTooLargeError is the error of a subscription closed because of a
+// notification larger than its maximum size, or of all the subscriptions
+// of a connection that read a message larger than Options.ReadLimit.
+type MessageTooLargeError struct {
+	// Size of the message, or zero if it exceeded the read limit
+	// of the connection and was not read entirely.
+	Size  int64
+	Limit int64
+
+	err error
+}
+
+func (e *MessageTooLargeError) Error() string {
+	if e.Size == 0 {
+		return fmt.Sprintf("message too large: exceeds read limit of %d bytes", e.Limit)
+	}
+	return fmt.Sprintf("message too large: %d bytes exceeds limit of %d bytes", e.Size, e.Limit)
+}
+
+func (e *MessageTooLargeError) Is(target error) bool {
+	return target == ErrMessageTooLarge
+}
+
+func (e *MessageTooLargeError) Unwrap() error {
+	return e.err
+}
+
+// SetMaxMessageSize sets the maximum size in bytes of the notifications of the
+// subscription, overriding Options.MaxNotificationSize; a larger notification
+// closes the subscription with a *MessageTooLargeError.
+// A negative size removes the limit; zero restores the default.
+func (s *Subscription) SetMaxMessageSize(size int64) *Subscription {
+	s.maxSize.Store(size)
+	return s
+}
+
+// maxMessageSize returns the maximum notification size of the subscription,
+// or zero if unlimited.
+func (s *Subscription) maxMessageSize(defaultSize int64) int64 {
+	switch size := s.maxSize.Load(); {
+	case size < 0:
+		return 0
+	case size > 0:
+		return size
+	}
+	return defaultSize
+}
+
+// CompressionNegotiated reports whether the permessage-deflate extension
+// was negotiated on the current connection.
+func (c *connection) CompressionNegotiated() bool {
+	return c.compressed.Load()
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxNotificationSize(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), server.WSURL(), &Options{MaxNotificationSize: 200}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	account, err := c.AccountSubscribe(solana.SystemProgramID, "")
+	require.NoError(t, err)
+	slots, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	slots.sub.SetMaxMessageSize(-1)
+	_, err = server.WaitForSubscription(ctx, "accountSubscribe")
+	require.NoError(t, err)
+	_, err = server.WaitForSubscription(ctx, "slotSubscribe")
+	require.NoError(t, err)
+
+	_, err = server.Notify("accountSubscribe", rpc.M{
+		"context": rpc.M{"slot": 3},
+		"value":   rpc.M{"lamports": 42, "owner": solana.SystemProgramID.String(), "data": []string{strings.Repeat("A", 400), "base64"}},
+	})
+	require.NoError(t, err)
+	_, err = account.RecvWithContext(ctx)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+	var tooLarge *MessageTooLargeError
+	require.ErrorAs(t, err, &tooLarge)
+	require.EqualValues(t, 200, tooLarge.Limit)
+	require.Greater(t, tooLarge.Size, int64(400))
+
+	// The other subscriptions of the connection are not affected.
+	_, err = server.Notify("slotSubscribe", rpc.M{"parent": 1, "root": 0, "slot": 2, "padding": strings.Repeat("A", 400)})
+	require.NoError(t, err)
+	got, err := slots.RecvWithContext(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, got.Slot)
+}
+
+func TestReadLimit(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), server.WSURL(), &Options{ReadLimit: 200}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	slots, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	_, err = server.WaitForSubscription(ctx, "slotSubscribe")
+	require.NoError(t, err)
+
+	_, err = server.Notify("slotSubscribe", rpc.M{"parent": 1, "root": 0, "slot": 2, "padding": strings.Repeat("A", 400)})
+	require.NoError(t, err)
+	_, err = slots.RecvWithContext(ctx)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+	require.ErrorIs(t, err, websocket.ErrReadLimit)
+}
+
+func TestCompressionNegotiated(t *testing.T) {
+	upgrader := websocket.Upgrader{EnableCompression: true}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	c, err := ConnectWithOptions(context.Background(), url, &Options{CompressionLevel: 1}, nil)
+	require.NoError(t, err)
+	require.True(t, c.CompressionNegotiated())
+	c.Close()
+
+	c, err = ConnectWithOptions(context.Background(), url, &Options{DisableCompression: true}, nil)
+	require.NoError(t, err)
+	require.False(t, c.CompressionNegotiated())
+	c.Close()
+}
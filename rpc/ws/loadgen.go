@@ -0,0 +1,361 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/buger/jsonparser"
+)
+
+// LoadOptions configures a LoadGenerator.
+type LoadOptions struct {
+	// Options of the client the corpus is replayed into, e.g. with the
+	// subscription ID retrievals, the discarders and the buffering
+	// to evaluate. The connection options are ignored.
+	Options *Options
+	// Cache is the LogsSignatureCache of the client (see ConnectWithOptions).
+	//
+	// This parameter is optional.
+	Cache LogsSignatureCache
+
+	// Rate is the number of messages replayed per second;
+	// zero replays them as fast as possible.
+	Rate float64
+	// Repeat is the number of times the corpus is replayed by Run.
+	// Defaults to 1.
+	Repeat int
+	// ConsumerDelay is the time the consumer of each subscription spends
+	// on every notification, e.g. to evaluate the drops of a slow consumer.
+	ConsumerDelay time.Duration
+}
+
+// LoadReport is the result of a LoadGenerator run.
+type LoadReport struct {
+	// Messages is the number of messages replayed, and Bytes their size.
+	Messages uint64
+	Bytes    uint64
+	// Elapsed is the duration of the replay.
+	Elapsed time.Duration
+
+	// Delivered is the number of notifications delivered to the subscriptions.
+	Delivered uint64
+	// Dropped is the number of notifications dropped because the buffer
+	// of their subscription was full (see Options.DropWhenFull).
+	Dropped uint64
+	// Filtered is the number of messages neither delivered nor dropped:
+	// discarded by a TxDiscarder, the signature cache or the decoder,
+	// or for a subscription that is unknown or closed.
+	Filtered uint64
+	// Closed is the number of subscriptions closed during the run, e.g.
+	// because their buffer was full or a notification failed to decode.
+	Closed int
+
+	// Allocs and AllocatedBytes are the number and size of the heap
+	// allocations of the process during the run, consumers included.
+	Allocs         uint64
+	AllocatedBytes uint64
+}
+
+// MessagesPerSecond returns the replay throughput, in messages per second.
+func (r *LoadReport) MessagesPerSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Messages) / r.Elapsed.Seconds()
+}
+
+// BytesPerSecond returns the replay throughput, in bytes per second.
+func (r *LoadReport) BytesPerSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Elapsed.Seconds()
+}
+
+// AllocsPerMessage returns the average number of heap allocations per message.
+func (r *LoadReport) AllocsPerMessage() float64 {
+	if r.Messages == 0 {
+		return 0
+	}
+	return float64(r.Allocs) / float64(r.Messages)
+}
+
+// DropRate returns the ratio of the notifications dropped to
+// the notifications delivered or dropped.
+func (r *LoadReport) DropRate() float64 {
+	if total := r.Delivered + r.Dropped; total > 0 {
+		return float64(r.Dropped) / float64(total)
+	}
+	return 0
+}
+
+func (r *LoadReport) String() string {
+	return fmt.Sprintf(
+		"%d messages in %s (%.0f msg/s, %.1f MB/s), %d delivered, %d dropped (%.2f%%), %d filtered, %d subscriptions closed, %.1f allocs/msg",
+		r.Messages,
+		r.Elapsed,
+		r.MessagesPerSecond(),
+		r.BytesPerSecond()/1e6,
+		r.Delivered,
+		r.Dropped,
+		r.DropRate()*100,
+		r.Filtered,
+		r.Closed,
+		r.AllocsPerMessage(),
+	)
+}
+
+// LoadGenerator replays recorded messages (see Options.Tap and ReadCorpus)
+// into the message handling path of a client that is not connected,
+// to measure the decoding throughput and the drops of a configuration
+// without a live feed.
+//
+// The subscribe and unsubscribe requests of the client are answered
+// in-process, instead of by a server.
+type LoadGenerator struct {
+	client *Client
+	opts   LoadOptions
+
+	nextSubID atomic.Uint64 // subscription ID confirming the next subscribe request
+	lock      sync.Mutex    // serializes Subscribe
+	subs      []*Subscription
+	stop      chan struct{}
+	consumers sync.WaitGroup
+}
+
+// NewLoadGenerator creates a new LoadGenerator; it must be closed after use.
+func NewLoadGenerator(opts *LoadOptions) *LoadGenerator {
+	g := &LoadGenerator{stop: make(chan struct{})}
+	if opts != nil {
+		g.opts = *opts
+	}
+	if g.opts.Repeat <= 0 {
+		g.opts.Repeat = 1
+	}
+	g.client = newClient("", g.opts.Options, g.opts.Cache)
+	g.client.connCtx, g.client.connCtxCancel = context.WithCancel(context.Background())
+	g.client.routines.Add(1)
+	go func() {
+		defer g.client.routines.Done()
+		g.loopback()
+	}()
+	go func() {
+		g.client.routines.Wait()
+		close(g.client.done)
+	}()
+	return g
+}
+
+// Client returns the client the corpus is replayed into.
+func (g *LoadGenerator) Client() *Client {
+	return g.client
+}
+
+// Subscribe calls subscribe, which must make exactly one subscription
+// with the provided client; the subscription is confirmed with
+// subscriptionID, so that it receives the messages of the corpus
+// recorded with that subscription ID (see Frame.SubscriptionID).
+//
+// The notifications of the subscription are consumed by the generator.
+func (g *LoadGenerator) Subscribe(subscriptionID uint64, subscribe func(c *Client) error) error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.nextSubID.Store(subscriptionID)
+	if err := subscribe(g.client); err != nil {
+		return err
+	}
+	g.client.lock.RLock()
+	sub, found := g.client.subscriptionByWSSubID[subscriptionID]
+	g.client.lock.RUnlock()
+	if !found {
+		return fmt.Errorf("load generator: no subscription made for subscription ID %d", subscriptionID)
+	}
+	g.subs = append(g.subs, sub)
+	g.consumers.Add(1)
+	go g.consume(sub)
+	return nil
+}
+
+// loopback answers the requests written by the client.
+func (g *LoadGenerator) loopback() {
+	c := g.client
+	for {
+		select {
+		case <-c.connCtx.Done():
+			return
+		case msg := <-c.writes:
+			id, _ := jsonparser.GetInt(msg.data, "id")
+			method, _ := jsonparser.GetString(msg.data, "method")
+			if msg.done != nil {
+				if !strings.HasSuffix(method, "Unsubscribe") {
+					c.handleNewSubscriptionMessage(uint64(id), g.nextSubID.Load())
+				}
+				msg.done <- nil
+				continue
+			}
+			c.handleUnsubscribeAck(uint64(id), nil)
+		}
+	}
+}
+
+// consume receives the notifications of the subscription until it is closed.
+func (g *LoadGenerator) consume(sub *Subscription) {
+	defer g.consumers.Done()
+	for {
+		select {
+		case <-sub.stream:
+			if g.opts.ConsumerDelay > 0 {
+				time.Sleep(g.opts.ConsumerDelay)
+			}
+		case <-sub.done:
+			return
+		case <-g.stop:
+			return
+		}
+	}
+}
+
+// Run replays the corpus LoadOptions.Repeat times, at LoadOptions.Rate,
+// and returns the report of the run. If ctx is done before the end,
+// it returns the report of the messages replayed so far, and ctx.Err().
+//
+// The counters of the subscriptions are cumulative, and reports of
+// consecutive runs on the same generator only cover their own run.
+func (g *LoadGenerator) Run(ctx context.Context, corpus []Frame) (*LoadReport, error) {
+	var interval time.Duration
+	if g.opts.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / g.opts.Rate)
+	}
+	delivered, dropped := g.counters()
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	allocs, allocated := mem.Mallocs, mem.TotalAlloc
+
+	report := &LoadReport{}
+	start := time.Now()
+	var err error
+replay:
+	for i := 0; i < g.opts.Repeat; i++ {
+		for _, frame := range corpus {
+			if interval > 0 {
+				if wait := time.Until(start.Add(time.Duration(report.Messages) * interval)); wait > 0 {
+					timer := time.NewTimer(wait)
+					select {
+					case <-ctx.Done():
+						timer.Stop()
+					case <-timer.C:
+					}
+				}
+			}
+			if report.Messages%256 == 0 || interval > 0 {
+				if err = ctx.Err(); err != nil {
+					break replay
+				}
+			}
+			g.client.handleMessage(frame.Data, time.Now())
+			report.Messages++
+			report.Bytes += uint64(len(frame.Data))
+		}
+	}
+	report.Elapsed = time.Since(start)
+
+	runtime.ReadMemStats(&mem)
+	report.Allocs, report.AllocatedBytes = mem.Mallocs-allocs, mem.TotalAlloc-allocated
+
+	endDelivered, endDropped := g.counters()
+	report.Delivered, report.Dropped = endDelivered-delivered, endDropped-dropped
+	if handled := report.Delivered + report.Dropped; handled < report.Messages {
+		report.Filtered = report.Messages - handled
+	}
+	g.lock.Lock()
+	for _, sub := range g.subs {
+		if sub.State() == SubscriptionClosed {
+			report.Closed++
+		}
+	}
+	g.lock.Unlock()
+	return report, err
+}
+
+// counters returns the notifications delivered and dropped by the subscriptions.
+func (g *LoadGenerator) counters() (delivered, dropped uint64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for _, sub := range g.subs {
+		delivered += sub.notifications.Load()
+		dropped += sub.drops.Load()
+	}
+	return delivered, dropped
+}
+
+// Close closes the subscriptions and stops the consumers.
+func (g *LoadGenerator) Close() {
+	if g.client.closing.Swap(true) {
+		return
+	}
+	g.client.closeAllSubscription(ErrConnectionClosed)
+	g.client.connCtxCancel()
+	close(g.stop)
+	g.consumers.Wait()
+}
+
+// ReadCorpus reads a corpus of messages written by WriteCorpus:
+// one message per line, the empty lines being skipped.
+func ReadCorpus(r io.Reader) ([]Frame, error) {
+	var frames []Frame
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		frames = append(frames, newFrame(append([]byte(nil), line...), time.Time{}))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read corpus: %w", err)
+	}
+	return frames, nil
+}
+
+// WriteCorpus writes the messages of the frames, one per line,
+// e.g. to record the frames received through Options.Tap.
+func WriteCorpus(w io.Writer, frames []Frame) error {
+	bw := bufio.NewWriter(w)
+	for _, frame := range frames {
+		if bytes.ContainsAny(frame.Data, "\r\n") {
+			return errors.New("write corpus: message contains a line break")
+		}
+		bw.Write(frame.Data)
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("write corpus: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func slotCorpus(t testing.TB, subID uint64, n int) []Frame {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&buf, `{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{"parent":%d,"root":0,"slot":%d},"subscription":%d}}`+"\n", i, i+1, subID)
+	}
+	frames, err := ReadCorpus(&buf)
+	require.NoError(t, err)
+	require.Len(t, frames, n)
+	return frames
+}
+
+func Test_LoadGenerator(t *testing.T) {
+	g := NewLoadGenerator(&LoadOptions{Repeat: 2})
+	defer g.Close()
+
+	var sub *SlotSubscription
+	require.NoError(t, g.Subscribe(7, func(c *Client) (err error) {
+		sub, err = c.SlotSubscribe()
+		return err
+	}))
+	require.Equal(t, uint64(7), sub.sub.SubscriptionID())
+
+	corpus := append(slotCorpus(t, 7, 100), slotCorpus(t, 9, 10)...)
+	require.Equal(t, "slotNotification", corpus[0].Method)
+	require.Equal(t, uint64(7), corpus[0].SubscriptionID)
+
+	report, err := g.Run(context.Background(), corpus)
+	require.NoError(t, err)
+	require.Equal(t, uint64(220), report.Messages)
+	require.Equal(t, uint64(200), report.Delivered)
+	require.Equal(t, uint64(20), report.Filtered)
+	require.Zero(t, report.Dropped)
+	require.Zero(t, report.Closed)
+	require.Greater(t, report.MessagesPerSecond(), float64(0))
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCorpus(&buf, corpus))
+	read, err := ReadCorpus(&buf)
+	require.NoError(t, err)
+	require.Equal(t, corpus, read)
+}
+
+func Test_LoadGenerator_drops(t *testing.T) {
+	g := NewLoadGenerator(&LoadOptions{
+		Options: &Options{
+			SubscriptionBuffer: 1,
+			DropWhenFull:       true,
+		},
+		ConsumerDelay: time.Second,
+	})
+	defer g.Close()
+
+	require.NoError(t, g.Subscribe(1, func(c *Client) error {
+		_, err := c.SlotSubscribe()
+		return err
+	}))
+	report, err := g.Run(context.Background(), slotCorpus(t, 1, 50))
+	require.NoError(t, err)
+	require.Equal(t, uint64(50), report.Delivered+report.Dropped)
+	require.Greater(t, report.Dropped, uint64(40))
+	require.Greater(t, report.DropRate(), 0.8)
+}
+
+func Benchmark_LoadGenerator_slotNotifications(b *testing.B) {
+	g := NewLoadGenerator(nil)
+	defer g.Close()
+	require.NoError(b, g.Subscribe(1, func(c *Client) error {
+		_, err := c.SlotSubscribe()
+		return err
+	}))
+	corpus := slotCorpus(b, 1, b.N)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	report, err := g.Run(context.Background(), corpus)
+	require.NoError(b, err)
+	b.ReportMetric(report.AllocsPerMessage(), "allocs/msg")
+	b.ReportMetric(report.DropRate(), "drops")
+}
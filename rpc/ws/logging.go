@@ -28,3 +28,12 @@ var traceEnabled = logging.IsTraceEnabled("solana-go", "github.com/gagliardetto/
 func init() {
 	logging.Register("github.com/gagliardetto/solana-go/rpc/ws", &zlog)
 }
+
+// log returns the logger of the connection (see Options.Logger),
+// or the package logger if none was provided.
+func (c *connection) log() *zap.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return zlog
+}
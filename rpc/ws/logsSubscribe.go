@@ -16,7 +16,6 @@ package ws
 
 import (
 	"context"
-
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
@@ -59,6 +58,16 @@ func (cl *Client) LogsSubscribe(
 	)
 }
 
+// LogsSubscribeWithContext is like LogsSubscribe, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (cl *Client) LogsSubscribeWithContext(
+	ctx context.Context,
+	filter LogsSubscribeFilterType,
+	commitment rpc.CommitmentType, // (optional)
+) (*LogSubscription, error) {
+	return cl.WithContext(ctx).LogsSubscribe(filter, commitment)
+}
+
 // LogsSubscribe subscribes to all transactions that mention the provided Pubkey.
 func (cl *Client) LogsSubscribeMentions(
 	// Subscribe to all transactions that mention the provided Pubkey.
@@ -74,6 +83,16 @@ func (cl *Client) LogsSubscribeMentions(
 	)
 }
 
+// LogsSubscribeMentionsWithContext is like LogsSubscribeMentions, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (cl *Client) LogsSubscribeMentionsWithContext(
+	ctx context.Context,
+	mentions solana.PublicKey,
+	commitment rpc.CommitmentType, // (optional)
+) (*LogSubscription, error) {
+	return cl.WithContext(ctx).LogsSubscribeMentions(mentions, commitment)
+}
+
 // LogsSubscribe subscribes to transaction logging.
 func (cl *Client) logsSubscribe(
 	filter interface{},
@@ -105,47 +124,5 @@ func (cl *Client) logsSubscribe(
 	}, nil
 }
 
-type LogSubscription struct {
-	sub *Subscription
-}
-
-func (sw *LogSubscription) Recv() (*LogResult, error) {
-	select {
-	case d := <-sw.sub.stream:
-		return d.(*LogResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *LogSubscription) RecvWithContext(ctx context.Context) (*LogResult, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case d := <-sw.sub.stream:
-		return d.(*LogResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *LogSubscription) Err() <-chan error {
-	return sw.sub.err
-}
-
-func (sw *LogSubscription) Response() <-chan *LogResult {
-	typedChan := make(chan *LogResult, 1)
-	go func(ch chan *LogResult) {
-		// TODO: will this subscription yield more than one result?
-		d, ok := <-sw.sub.stream
-		if !ok {
-			return
-		}
-		ch <- d.(*LogResult)
-	}(typedChan)
-	return typedChan
-}
-
-func (sw *LogSubscription) Unsubscribe() {
-	sw.sub.Unsubscribe()
-}
+// LogSubscription is the subscription returned by LogsSubscribe.
+type LogSubscription = TypedSubscription[LogResult]
@@ -0,0 +1,77 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"time"
+
+	"github.com/buger/jsonparser"
+)
+
+// NotificationMeta describes where the latency of a notification was spent
+// between its arrival on the connection and its delivery to the subscriber.
+type NotificationMeta struct {
+	// ReceivedAt is the local time the message was read from the connection.
+	ReceivedAt time.Time
+	// DecodeDuration is the time spent decoding the message.
+	DecodeDuration time.Duration
+	// QueueWait is the time the decoded notification waited in the buffer
+	// of the subscription before being received.
+	QueueWait time.Duration
+	// Slot is the slot of the notification (its context slot, or the slot
+	// reported by slot, root, vote and transaction notifications),
+	// or zero if the payload has none.
+	Slot uint64
+}
+
+func (r result) meta(receivedAt time.Time) NotificationMeta {
+	return NotificationMeta{
+		ReceivedAt:     r.receivedAt,
+		DecodeDuration: r.decoding,
+		QueueWait:      receivedAt.Sub(r.queuedAt),
+		Slot:           r.slot,
+	}
+}
+
+// RecvWithMeta is like RecvWithContext, and also returns
+// the latency metadata of the notification.
+func (s *Subscription) RecvWithMeta(ctx context.Context) (interface{}, NotificationMeta, error) {
+	select {
+	case <-ctx.Done():
+		return nil, NotificationMeta{}, ctx.Err()
+	case d := <-s.stream:
+		return d.value, d.meta(time.Now()), nil
+	case err := <-s.err:
+		return nil, NotificationMeta{}, err
+	}
+}
+
+// notificationSlot returns the slot of a notification message,
+// or zero if it has none.
+func notificationSlot(message []byte) uint64 {
+	if slot, ok := getUint64WithOk(message, "params", "result", "context", "slot"); ok {
+		return slot
+	}
+	if slot, ok := getUint64WithOk(message, "params", "result", "slot"); ok {
+		return slot
+	}
+	// rootNotification: the result is the root slot itself.
+	if method, err := jsonparser.GetString(message, "method"); err == nil && method == "rootNotification" {
+		slot, _ := getUint64WithOk(message, "params", "result")
+		return slot
+	}
+	return 0
+}
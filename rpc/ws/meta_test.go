@@ -0,0 +1,65 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RecvWithMeta(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := Connect(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	before := time.Now()
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	require.Eventually(t, func() bool { return len(sub.sub.stream) == 1 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	got, meta, err := sub.RecvWithMeta(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), got.Slot)
+	require.Equal(t, uint64(2), meta.Slot)
+	require.False(t, meta.ReceivedAt.Before(before))
+	require.GreaterOrEqual(t, meta.QueueWait, 20*time.Millisecond)
+	require.GreaterOrEqual(t, meta.DecodeDuration, time.Duration(0))
+}
+
+func Test_notificationSlot(t *testing.T) {
+	tests := []struct {
+		message string
+		slot    uint64
+	}{
+		{`{"method":"accountNotification","params":{"result":{"context":{"slot":5},"value":{}},"subscription":1}}`, 5},
+		{`{"method":"slotNotification","params":{"result":{"parent":6,"root":4,"slot":7},"subscription":1}}`, 7},
+		{`{"method":"rootNotification","params":{"result":42,"subscription":1}}`, 42},
+		{`{"method":"customNotification","params":{"result":42,"subscription":1}}`, 0},
+		{`{"method":"customNotification","params":{"result":{},"subscription":1}}`, 0},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.slot, notificationSlot([]byte(test.message)), test.message)
+	}
+}
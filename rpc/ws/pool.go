@@ -0,0 +1,294 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"go.uber.org/zap"
+)
+
+var DefaultSignatureCacheSize = 100_000
+
+// SignatureCache is a bounded, concurrency-safe LogsSignatureCache
+// that forgets the oldest signatures first.
+type SignatureCache struct {
+	lock sync.Mutex
+	seen map[solana.Signature]struct{}
+	ring []solana.Signature
+	next int
+}
+
+// NewSignatureCache creates a new SignatureCache remembering
+// up to size signatures.
+func NewSignatureCache(size int) *SignatureCache {
+	if size <= 0 {
+		size = DefaultSignatureCacheSize
+	}
+	return &SignatureCache{
+		seen: make(map[solana.Signature]struct{}, size),
+		ring: make([]solana.Signature, size),
+	}
+}
+
+func (c *SignatureCache) Has(sig solana.Signature) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.seen[sig]
+	return ok
+}
+
+func (c *SignatureCache) Set(sig solana.Signature) {
+	c.Add(sig)
+}
+
+// Add records the signature, and reports whether it was not already recorded.
+// The zero signature (returned when a signature cannot be extracted
+// from a notification) is never recorded.
+func (c *SignatureCache) Add(sig solana.Signature) bool {
+	if sig.IsZero() {
+		return true
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if _, ok := c.seen[sig]; ok {
+		return false
+	}
+	if old := c.ring[c.next]; !old.IsZero() {
+		delete(c.seen, old)
+	}
+	c.ring[c.next] = sig
+	c.next = (c.next + 1) % len(c.ring)
+	c.seen[sig] = struct{}{}
+	return true
+}
+
+// Pool issues the same subscription on connections to several endpoints,
+// and delivers a single merged stream.
+//
+// The connections share a LogsSignatureCache, so that logs and transaction
+// notifications are delivered once, by whichever endpoint is the fastest.
+// Notifications of other subscriptions are not deduplicated.
+type Pool struct {
+	clients []*Client
+}
+
+// NewPool connects to all the provided endpoints with the same options.
+// If cache is nil, a SignatureCache of DefaultSignatureCacheSize is used.
+func NewPool(ctx context.Context, endpoints []string, opt *Options, cache LogsSignatureCache) (*Pool, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("new ws pool: no endpoints provided")
+	}
+	if cache == nil {
+		cache = NewSignatureCache(DefaultSignatureCacheSize)
+	}
+
+	p := &Pool{}
+	for _, endpoint := range endpoints {
+		client, err := ConnectWithOptions(ctx, endpoint, opt, cache)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("new ws pool: %s: %w", endpoint, err)
+		}
+		p.clients = append(p.clients, client)
+	}
+	return p, nil
+}
+
+// Clients returns the clients of the pool, in the order of the endpoints.
+func (p *Pool) Clients() []*Client {
+	return p.clients
+}
+
+// Close closes all the connections of the pool.
+func (p *Pool) Close() {
+	for _, client := range p.clients {
+		client.Close()
+	}
+}
+
+// LogsSubscribe subscribes to transaction logging on all the endpoints.
+func (p *Pool) LogsSubscribe(
+	filter LogsSubscribeFilterType,
+	commitment rpc.CommitmentType, // (optional)
+) (*LogSubscription, error) {
+	sub, err := p.subscribe(func(c *Client) (*Subscription, error) {
+		s, err := c.LogsSubscribe(filter, commitment)
+		if err != nil {
+			return nil, err
+		}
+		return s.sub, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LogSubscription{sub: sub}, nil
+}
+
+// LogsSubscribeMentions subscribes, on all the endpoints, to all transactions
+// that mention the provided Pubkey.
+func (p *Pool) LogsSubscribeMentions(
+	mentions solana.PublicKey,
+	commitment rpc.CommitmentType, // (optional)
+) (*LogSubscription, error) {
+	sub, err := p.subscribe(func(c *Client) (*Subscription, error) {
+		s, err := c.LogsSubscribeMentions(mentions, commitment)
+		if err != nil {
+			return nil, err
+		}
+		return s.sub, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LogSubscription{sub: sub}, nil
+}
+
+// TransactionSubscribe subscribes to transactions on all the endpoints,
+// which must support the Helius transactionSubscribe method.
+func (p *Pool) TransactionSubscribe(
+	filter TransactionSubscribeFilterType,
+	opts TransactionSubscribeOptionsType,
+) (*TransactionSubscription, error) {
+	sub, err := p.subscribe(func(c *Client) (*Subscription, error) {
+		s, err := c.Helius().TransactionSubscribe(filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		return s.sub, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TransactionSubscription{sub: sub}, nil
+}
+
+// subscribe issues a subscription on every client and merges them.
+// It fails only if the subscription fails on all the clients.
+func (p *Pool) subscribe(subscribe func(c *Client) (*Subscription, error)) (*Subscription, error) {
+	var subs []*Subscription
+	var lastErr error
+	for _, client := range p.clients {
+		sub, err := subscribe(client)
+		if err != nil {
+			client.log().Warn("unable to subscribe on pool endpoint",
+				zap.String("endpoint", client.rpcURL),
+				zap.String("label", client.label),
+				zap.Error(err),
+			)
+			lastErr = err
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	if len(subs) == 0 {
+		return nil, fmt.Errorf("pool subscribe: all endpoints failed: %w", lastErr)
+	}
+	return mergeSubscriptions(subs), nil
+}
+
+// mergeSubscriptions forwards the notifications of the provided subscriptions
+// to a single subscription. The merged subscription is active once one of
+// the underlying subscriptions is confirmed, and errors once all of them
+// have errored; unsubscribing from it unsubscribes from all of them.
+func mergeSubscriptions(subs []*Subscription) *Subscription {
+	// The merged subscription is not sent to any server: its request
+	// only carries the method, for Info and the logs.
+	var merged *Subscription
+	var once sync.Once
+	closeFunc := func(err error) <-chan error {
+		var acks []<-chan error
+		once.Do(func() {
+			merged.err <- err
+			merged.setClosed(err)
+			for _, sub := range subs {
+				if ack := sub.unsubscribe(err); ack != nil {
+					acks = append(acks, ack)
+				}
+			}
+		})
+		return mergeAcks(acks)
+	}
+	merged = newSubscription(
+		newRequest(0, nil, subs[0].req.Method, nil),
+		closeFunc,
+		subs[0].unsubscribeMethod,
+		nil,
+		DefaultSubscriptionBuffer,
+	)
+	merged.err = make(chan error, len(subs)+1)
+	merged.method = subs[0].method
+	merged.tenant = subs[0].tenant
+
+	done := merged.done
+	remaining := int32(len(subs))
+	for _, sub := range subs {
+		sub.OnSubscribed(func(subID uint64) {
+			merged.setActive(subID)()
+		})
+		go func(sub *Subscription) {
+			for {
+				select {
+				case <-done:
+					return
+				case d := <-sub.stream:
+					select {
+					case merged.stream <- d:
+						merged.notifications.Add(1)
+						merged.lastMessage.Store(d.receivedAt.UnixNano())
+					default:
+						merged.closeFunc(fmt.Errorf("reached channel max capacity %d", len(merged.stream)))
+						return
+					}
+				case err := <-sub.err:
+					if atomic.AddInt32(&remaining, -1) == 0 {
+						once.Do(func() {
+							merged.err <- err
+							merged.setClosed(err)
+						})
+					}
+					return
+				}
+			}
+		}(sub)
+	}
+	return merged
+}
+
+// mergeAcks returns a channel receiving the first error of the
+// unsubscribe acknowledgements once all of them are received,
+// or nil if there are none.
+func mergeAcks(acks []<-chan error) <-chan error {
+	if len(acks) == 0 {
+		return nil
+	}
+	merged := make(chan error, 1)
+	go func() {
+		var first error
+		for _, ack := range acks {
+			if err := <-ack; err != nil && first == nil {
+				first = err
+			}
+		}
+		merged <- first
+	}()
+	return merged
+}
@@ -0,0 +1,221 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// newLogsServer starts a websocket server that confirms every subscribe
+// request and then sends a logs notification for each of the signatures.
+func newLogsServer(t *testing.T, sigs ...solana.Signature) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in request
+			if err := json.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			if strings.HasSuffix(in.Method, "Unsubscribe") {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":true,"id":%d}`, in.ID)))
+				continue
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":1,"id":%d}`, in.ID)))
+			for _, sig := range sigs {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+					`{"jsonrpc":"2.0","method":"logsNotification","params":{"result":{"context":{"slot":312345678},"value":{"signature":"%s","err":null,"logs":[]}},"subscription":1}}`,
+					sig,
+				)))
+			}
+		}
+	}))
+}
+
+func Test_Pool_LogsSubscribe(t *testing.T) {
+	a, b, c := solana.Signature{1}, solana.Signature{2}, solana.Signature{3}
+	first := newLogsServer(t, a, b)
+	defer first.Close()
+	second := newLogsServer(t, b, c)
+	defer second.Close()
+
+	pool, err := NewPool(
+		context.Background(),
+		[]string{
+			"ws" + strings.TrimPrefix(first.URL, "http"),
+			"ws" + strings.TrimPrefix(second.URL, "http"),
+		},
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+	defer pool.Close()
+	require.Len(t, pool.Clients(), 2)
+
+	sub, err := pool.LogsSubscribe(LogsSubscribeFilterAll, "")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	got := map[solana.Signature]int{}
+	for i := 0; i < 3; i++ {
+		res, err := sub.Recv()
+		require.NoError(t, err)
+		got[res.Value.Signature]++
+	}
+	require.Equal(t, map[solana.Signature]int{a: 1, b: 1, c: 1}, got)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = sub.RecvWithContext(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func Test_SignatureCache(t *testing.T) {
+	cache := NewSignatureCache(2)
+	a, b, c := solana.Signature{1}, solana.Signature{2}, solana.Signature{3}
+
+	require.True(t, cache.Add(a))
+	require.False(t, cache.Add(a))
+	require.True(t, cache.Add(b))
+	require.True(t, cache.Add(c))
+	// a was evicted.
+	require.False(t, cache.Has(a))
+	require.True(t, cache.Has(b))
+	require.True(t, cache.Has(c))
+	// The zero signature is never recorded.
+	require.True(t, cache.Add(solana.Signature{}))
+	require.True(t, cache.Add(solana.Signature{}))
+}
+
+func Test_Pool_SubscriptionLifecycle(t *testing.T) {
+	first := newLogsServer(t)
+	defer first.Close()
+	second := newLogsServer(t)
+	defer second.Close()
+
+	pool, err := NewPool(
+		context.Background(),
+		[]string{
+			"ws" + strings.TrimPrefix(first.URL, "http"),
+			"ws" + strings.TrimPrefix(second.URL, "http"),
+		},
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	logsSub, err := pool.LogsSubscribe(LogsSubscribeFilterAll, "")
+	require.NoError(t, err)
+	sub := logsSub.Subscription()
+
+	subscribed := make(chan uint64, 1)
+	closed := make(chan error, 1)
+	sub.OnSubscribed(func(subID uint64) { subscribed <- subID }).
+		OnError(func(err error) { t.Errorf("unexpected error: %v", err) }).
+		OnClosed(func(err error) { closed <- err })
+
+	select {
+	case got := <-subscribed:
+		require.Equal(t, uint64(1), got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnSubscribed was not called")
+	}
+
+	info := sub.Info()
+	require.Equal(t, SubscriptionActive, info.State)
+	require.Equal(t, "logsSubscribe", info.Method)
+	require.Equal(t, uint64(1), info.SubscriptionID)
+
+	sub.Unsubscribe()
+	require.ErrorIs(t, <-closed, ErrCanceled)
+	require.Equal(t, SubscriptionClosed, sub.State())
+	_, err = logsSub.Recv()
+	require.ErrorIs(t, err, ErrCanceled)
+}
+
+func Test_Pool_SubscriptionLifecycle_rejected(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	reject := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in request
+			if err := json.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":%d}`, in.ID)))
+		}
+	})
+	first := httptest.NewServer(reject)
+	defer first.Close()
+	second := httptest.NewServer(reject)
+	defer second.Close()
+
+	pool, err := NewPool(
+		context.Background(),
+		[]string{
+			"ws" + strings.TrimPrefix(first.URL, "http"),
+			"ws" + strings.TrimPrefix(second.URL, "http"),
+		},
+		nil,
+		nil,
+	)
+	require.NoError(t, err)
+	defer pool.Close()
+
+	logsSub, err := pool.LogsSubscribe(LogsSubscribeFilterAll, "")
+	require.NoError(t, err)
+	sub := logsSub.Subscription()
+
+	failed := make(chan error, 1)
+	sub.OnError(func(err error) { failed <- err })
+	select {
+	case err := <-failed:
+		require.Contains(t, err.Error(), "Invalid params")
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnError was not called")
+	}
+	require.Equal(t, SubscriptionClosed, sub.State())
+	require.Zero(t, sub.Info().SubscriptionID)
+
+	_, err = logsSub.Recv()
+	require.Contains(t, err.Error(), "Invalid params")
+}
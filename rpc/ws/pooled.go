@@ -0,0 +1,154 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/buger/jsonparser"
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+// decodeResultInto decodes the result of a notification message into reply,
+// like decodeResponseFromMessage, but extracts the fields in place instead
+// of decoding the message into intermediate structs.
+func decodeResultInto(msg []byte, reply interface{}) error {
+	if value, typ, _, err := jsonparser.Get(msg, "error"); err == nil && typ != jsonparser.Null {
+		jsonErr := &json2.Error{}
+		if err := json.Unmarshal(value, jsonErr); err != nil {
+			return &json2.Error{
+				Code:    json2.E_SERVER,
+				Message: string(value),
+			}
+		}
+		return jsonErr
+	}
+
+	params, typ, _, err := jsonparser.Get(msg, "params")
+	if err != nil || typ == jsonparser.Null {
+		return json2.ErrNullResult
+	}
+	if value, typ, _, err := jsonparser.Get(params, "error"); err == nil && typ != jsonparser.Null {
+		errMessage, _ := jsonparser.ParseString(value)
+		return fmt.Errorf("rpc error: %s", errMessage)
+	}
+	result, typ, _, err := jsonparser.Get(params, "result")
+	if err != nil || typ == jsonparser.Null {
+		return json2.ErrNullResult
+	}
+	return json.Unmarshal(result, reply)
+}
+
+// PooledSubscription is a subscription whose notifications are decoded
+// into values taken from a pool, to reduce allocations and GC pressure
+// on high-throughput feeds.
+//
+// Each value returned by Recv must be handed back with Release
+// once the caller is done with it, and must not be used afterwards.
+type PooledSubscription[T any] struct {
+	sub  *Subscription
+	pool *sync.Pool
+}
+
+// SubscribePooled subscribes like SubscribeRaw, decoding the result of each
+// notification into a *T taken from a pool. It is selectable per subscription,
+// e.g. for the logs of a busy program:
+//
+//	sub, err := ws.SubscribePooled[ws.LogResult](client,
+//		[]interface{}{map[string]interface{}{"mentions": []string{program.String()}}},
+//		map[string]interface{}{"commitment": "confirmed"},
+//		"logsSubscribe", "logsUnsubscribe",
+//	)
+func SubscribePooled[T any](
+	cl *Client,
+	params []interface{},
+	conf map[string]interface{}, // optional
+	subscribeMethod string,
+	unsubscribeMethod string,
+) (*PooledSubscription[T], error) {
+	s := &PooledSubscription[T]{
+		pool: newPool[T](),
+	}
+	sub, err := cl.SubscribeRaw(params, conf, subscribeMethod, unsubscribeMethod, func(msg []byte) (interface{}, error) {
+		v := s.pool.Get().(*T)
+		if err := decodeResultInto(msg, v); err != nil {
+			s.Release(v)
+			return nil, err
+		}
+		return v, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.sub = sub
+	return s, nil
+}
+
+func newPool[T any]() *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} { return new(T) },
+	}
+}
+
+func (s *PooledSubscription[T]) Recv() (*T, error) {
+	return s.RecvWithContext(context.Background())
+}
+
+func (s *PooledSubscription[T]) RecvWithContext(ctx context.Context) (*T, error) {
+	d, err := s.sub.RecvWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return typedNotification[T](d)
+}
+
+// RecvWithMeta is like RecvWithContext, and also returns
+// the latency metadata of the notification.
+func (s *PooledSubscription[T]) RecvWithMeta(ctx context.Context) (*T, NotificationMeta, error) {
+	d, meta, err := s.sub.RecvWithMeta(ctx)
+	if err != nil {
+		return nil, meta, err
+	}
+	v, err := typedNotification[T](d)
+	return v, meta, err
+}
+
+// Release resets v and returns it to the pool.
+func (s *PooledSubscription[T]) Release(v *T) {
+	var zero T
+	*v = zero
+	s.pool.Put(v)
+}
+
+// Subscription returns the underlying subscription.
+func (s *PooledSubscription[T]) Subscription() *Subscription {
+	return s.sub
+}
+
+func (s *PooledSubscription[T]) Err() <-chan error {
+	return s.sub.Err()
+}
+
+func (s *PooledSubscription[T]) Unsubscribe() {
+	s.sub.Unsubscribe()
+}
+
+// UnsubscribeWithContext unsubscribes, waiting until ctx is done
+// (see Subscription.UnsubscribeWithContext).
+func (s *PooledSubscription[T]) UnsubscribeWithContext(ctx context.Context) error {
+	return s.sub.UnsubscribeWithContext(ctx)
+}
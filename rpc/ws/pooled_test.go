@@ -0,0 +1,89 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const benchLogsNotification = `{"jsonrpc":"2.0","method":"logsNotification","params":{"result":{"context":{"slot":312345678},"value":{"signature":"5h6xBEauJ3PK6SWCZ1PGjBvj8vDdWG3KpwATGy1ARAXFSDwt8GFXM7W5Ncn16wmqokgpiKRLuS83KUxyZyv2sUYv","err":null,"logs":["Program 11111111111111111111111111111111 invoke [1]","Program 11111111111111111111111111111111 success"]}},"subscription":7}}`
+
+func Test_decodeResultInto(t *testing.T) {
+	for _, msg := range []string{
+		benchLogsNotification,
+		`{"jsonrpc":"2.0","method":"logsNotification","params":{"result":null,"subscription":7}}`,
+		`{"jsonrpc":"2.0","method":"logsNotification","params":{"error":"boom","subscription":7}}`,
+		`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":1}`,
+		`{"jsonrpc":"2.0","method":"logsNotification"}`,
+	} {
+		var expected, got LogResult
+		expectedErr := decodeResponseFromMessage([]byte(msg), &expected)
+		gotErr := decodeResultInto([]byte(msg), &got)
+		require.Equal(t, expected, got, msg)
+		if expectedErr == nil {
+			require.NoError(t, gotErr, msg)
+		} else {
+			require.EqualError(t, gotErr, expectedErr.Error(), msg)
+		}
+	}
+}
+
+func Test_SubscribePooled(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{ReuseReadBuffer: true}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := SubscribePooled[SlotResult](c, nil, nil, "slotSubscribe", "slotUnsubscribe")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	got, err := sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), got.Slot)
+	sub.Release(got)
+	require.Zero(t, got.Slot)
+}
+
+func Benchmark_decodeResponseFromMessage(b *testing.B) {
+	msg := []byte(benchLogsNotification)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var res LogResult
+		if err := decodeResponseFromMessage(msg, &res); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_decodeResultInto_pooled(b *testing.B) {
+	msg := []byte(benchLogsNotification)
+	s := &PooledSubscription[LogResult]{}
+	s.pool = newPool[LogResult]()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		res := s.pool.Get().(*LogResult)
+		if err := decodeResultInto(msg, res); err != nil {
+			b.Fatal(err)
+		}
+		s.Release(res)
+	}
+}
@@ -16,7 +16,9 @@ package ws
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/buger/jsonparser"
 	"github.com/gagliardetto/solana-go"
 	"github.com/gagliardetto/solana-go/rpc"
 )
@@ -42,14 +44,76 @@ func (cl *Client) ProgramSubscribe(
 	)
 }
 
-// ProgramSubscribe subscribes to a program to receive notifications
+// ProgramSubscribeWithContext is like ProgramSubscribe, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (cl *Client) ProgramSubscribeWithContext(
+	ctx context.Context,
+	programID solana.PublicKey,
+	commitment rpc.CommitmentType,
+) (*ProgramSubscription, error) {
+	return cl.WithContext(ctx).ProgramSubscribe(programID, commitment)
+}
+
+// ProgramSubscribeWithOpts subscribes to a program to receive notifications
 // when the lamports or data for a given account owned by the program changes.
+//
+// Use solana.EncodingBase64Zstd to reduce bandwidth for large accounts
+// (e.g. orderbooks); the data is decompressed on first access
+// (see rpc.DataBytesOrJSON.Binary).
 func (cl *Client) ProgramSubscribeWithOpts(
 	programID solana.PublicKey,
 	commitment rpc.CommitmentType,
 	encoding solana.EncodingType,
 	filters []rpc.RPCFilter,
 ) (*ProgramSubscription, error) {
+	return cl.programSubscribe(programID, commitment, encoding, filters, nil)
+}
+
+// ProgramSubscribeWithClientFilters is like ProgramSubscribeWithOpts,
+// but it subscribes without filters and evaluates the provided
+// memcmp/dataSize filters client-side, discarding notifications
+// for accounts that don't match before the rest of them is decoded.
+// Use it with endpoints that don't support filters on programSubscribe.
+//
+// The encoding must be a binary one ("base58", "base64" or "base64+zstd").
+func (cl *Client) ProgramSubscribeWithClientFilters(
+	programID solana.PublicKey,
+	commitment rpc.CommitmentType,
+	encoding solana.EncodingType,
+	filters []rpc.RPCFilter,
+) (*ProgramSubscription, error) {
+	if encoding == solana.EncodingJSONParsed {
+		return nil, fmt.Errorf("client-side filters cannot be used with %s encoding", encoding)
+	}
+	return cl.programSubscribe(programID, commitment, encoding, nil, filters)
+}
+
+func (cl *Client) programSubscribe(
+	programID solana.PublicKey,
+	commitment rpc.CommitmentType,
+	encoding solana.EncodingType,
+	filters []rpc.RPCFilter,
+	clientFilters []rpc.RPCFilter,
+) (*ProgramSubscription, error) {
+	genSub, err := cl.programSubscribeWithConvert(programID, commitment, encoding, filters, clientFilters, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ProgramSubscription{
+		sub: genSub,
+	}, nil
+}
+
+// programSubscribeWithConvert subscribes to the program, delivering the
+// notifications converted by convert, or as *ProgramResult if convert is nil.
+func (cl *Client) programSubscribeWithConvert(
+	programID solana.PublicKey,
+	commitment rpc.CommitmentType,
+	encoding solana.EncodingType,
+	filters []rpc.RPCFilter,
+	clientFilters []rpc.RPCFilter,
+	convert func(res *ProgramResult) (interface{}, error),
+) (*Subscription, error) {
 
 	params := []interface{}{programID.String()}
 	conf := map[string]interface{}{
@@ -59,72 +123,61 @@ func (cl *Client) ProgramSubscribeWithOpts(
 		conf["commitment"] = commitment
 	}
 	if encoding != "" {
+		if !isSupportedAccountEncoding(encoding) {
+			return nil, fmt.Errorf("provided encoding is not supported: %s", encoding)
+		}
 		conf["encoding"] = encoding
 	}
 	if filters != nil && len(filters) > 0 {
 		conf["filters"] = filters
 	}
 
-	genSub, err := cl.subscribe(
+	return cl.subscribe(
 		params,
 		conf,
 		"programSubscribe",
 		"programUnsubscribe",
 		func(msg []byte) (interface{}, error) {
+			if len(clientFilters) > 0 {
+				matched, err := matchAccountData(msg, clientFilters)
+				if err != nil {
+					return nil, err
+				}
+				if !matched {
+					return nil, errDiscardNotification
+				}
+			}
 			var res ProgramResult
 			err := decodeResponseFromMessage(msg, &res)
-			return &res, err
+			if err != nil {
+				return &res, err
+			}
+			if convert != nil {
+				return convert(&res)
+			}
+			return &res, nil
 		},
 	)
-	if err != nil {
-		return nil, err
-	}
-	return &ProgramSubscription{
-		sub: genSub,
-	}, nil
 }
 
-type ProgramSubscription struct {
-	sub *Subscription
-}
-
-func (sw *ProgramSubscription) Recv() (*ProgramResult, error) {
-	select {
-	case d := <-sw.sub.stream:
-		return d.(*ProgramResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
+// matchAccountData evaluates the client-side filters on the account data
+// of a program notification, before the rest of the message is decoded.
+// Messages without account data (e.g. errors) are left to the decoder.
+func matchAccountData(msg []byte, filters []rpc.RPCFilter) (bool, error) {
+	value, typ, _, err := jsonparser.Get(msg, "params", "result", "value", "account", "data")
+	if err != nil || typ != jsonparser.Array {
+		return true, nil
 	}
-}
-
-func (sw *ProgramSubscription) RecvWithContext(ctx context.Context) (*ProgramResult, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case d := <-sw.sub.stream:
-		return d.(*ProgramResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
+	var data rpc.DataBytesOrJSON
+	if err := data.UnmarshalJSON(value); err != nil {
+		return false, err
 	}
+	content, err := data.Binary()
+	if err != nil {
+		return false, err
+	}
+	return rpc.MatchFilters(filters, content), nil
 }
 
-func (sw *ProgramSubscription) Err() <-chan error {
-	return sw.sub.err
-}
-
-func (sw *ProgramSubscription) Response() <-chan *ProgramResult {
-	typedChan := make(chan *ProgramResult, 1)
-	go func(ch chan *ProgramResult) {
-		// TODO: will this subscription yield more than one result?
-		d, ok := <-sw.sub.stream
-		if !ok {
-			return
-		}
-		ch <- d.(*ProgramResult)
-	}(typedChan)
-	return typedChan
-}
-
-func (sw *ProgramSubscription) Unsubscribe() {
-	sw.sub.Unsubscribe()
-}
+// ProgramSubscription is the subscription returned by ProgramSubscribe.
+type ProgramSubscription = TypedSubscription[ProgramResult]
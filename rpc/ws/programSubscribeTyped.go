@@ -0,0 +1,270 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gorilla/rpc/v2/json2"
+)
+
+// DefaultSubscribeConfirmTimeout bounds the wait for the server to confirm
+// a subscription, when Options.ConfirmSubscriptions
+// or ProgramSubscribeOpts.Compress is set.
+var DefaultSubscribeConfirmTimeout = 10 * time.Second
+
+type ProgramSubscribeOpts struct {
+	Commitment rpc.CommitmentType
+
+	// Filters of the accounts, e.g. built with rpc.NewFilterBuilder().MemcmpField(...).
+	//
+	// This parameter is optional.
+	Filters *rpc.FilterBuilder
+
+	// ClientFilters evaluates the filters client-side instead of
+	// sending them to the server (see ProgramSubscribeWithClientFilters).
+	ClientFilters bool
+
+	// Compress requests the account data with the base64+zstd encoding,
+	// and falls back to base64 if the endpoint rejects it.
+	// The subscribe call then waits for the server to confirm the subscription,
+	// up to DefaultSubscribeConfirmTimeout.
+	Compress bool
+
+	// OnlyChanges drops the notifications whose account data did not change
+	// since the previous notification of the same account, before decoding
+	// them (see ProgramSubscribeChanges), and sets the ChangedFields
+	// of the results.
+	OnlyChanges bool
+}
+
+// TypedProgramResult is a program notification whose account data
+// is decoded into a T.
+type TypedProgramResult[T any] struct {
+	Slot    uint64
+	Pubkey  solana.PublicKey
+	Account *rpc.Account
+
+	// Value is the decoded account data, or nil if it could not be decoded.
+	Value *T
+	// ChangedFields are the names of the fields of Value that differ from
+	// the previous notification of the account (see ChangedFields), when
+	// ProgramSubscribeOpts.OnlyChanges is set. It is nil for the first
+	// notification of the account, or if T is not a struct.
+	ChangedFields []string
+	// Err is the decoding error, if any.
+	// Notifications of accounts that cannot be decoded are still delivered,
+	// so that a single unexpected account does not end the subscription.
+	Err error
+}
+
+// TypedProgramSubscription is a program subscription whose notifications
+// are decoded into a T.
+type TypedProgramSubscription[T any] struct {
+	sub *Subscription
+}
+
+// ProgramSubscribeTyped subscribes to the accounts of a program, decoding
+// their data into a T with decode, or with the binary decoder if decode is nil:
+//
+//	type Pool struct {
+//		Authority solana.PublicKey
+//		Mint      solana.PublicKey
+//		Reserve   uint64
+//	}
+//
+//	filters := rpc.NewFilterBuilder().
+//		DataSize(72).
+//		MemcmpField(Pool{}, "Mint", mint)
+//	sub, err := ws.ProgramSubscribeTyped[Pool](client, programID,
+//		&ws.ProgramSubscribeOpts{Filters: filters, Compress: true}, nil)
+func ProgramSubscribeTyped[T any](
+	cl *Client,
+	programID solana.PublicKey,
+	opts *ProgramSubscribeOpts, // optional
+	decode func(data []byte, v *T) error, // optional
+) (*TypedProgramSubscription[T], error) {
+	if opts == nil {
+		opts = &ProgramSubscribeOpts{}
+	}
+	if decode == nil {
+		decode = func(data []byte, v *T) error {
+			return bin.NewBinDecoder(data).Decode(v)
+		}
+	}
+	var filters, clientFilters []rpc.RPCFilter
+	if opts.Filters != nil {
+		built, err := opts.Filters.Build()
+		if err != nil {
+			return nil, fmt.Errorf("program subscribe: %w", err)
+		}
+		if opts.ClientFilters {
+			clientFilters = built
+		} else {
+			filters = built
+		}
+	}
+
+	var detector *changeDetector
+	var previousLock sync.Mutex
+	previous := make(map[solana.PublicKey]*T)
+	if opts.OnlyChanges {
+		detector = newChangeDetector(nil)
+	}
+
+	convert := func(res *ProgramResult) (interface{}, error) {
+		out := &TypedProgramResult[T]{
+			Slot:    res.Context.Slot,
+			Pubkey:  res.Value.Pubkey,
+			Account: res.Value.Account,
+		}
+		if res.Value.Account == nil {
+			out.Err = errors.New("notification has no account")
+			return out, nil
+		}
+		data, err := res.Value.Account.Binary()
+		if err != nil {
+			out.Err = err
+			return out, nil
+		}
+		if detector != nil {
+			if changed, _, _ := detector.observe(res.Value.Pubkey, data); !changed {
+				return nil, errDiscardNotification
+			}
+		}
+		v := new(T)
+		if err := decode(data, v); err != nil {
+			out.Err = fmt.Errorf("decode account %s: %w", res.Value.Pubkey, err)
+			return out, nil
+		}
+		out.Value = v
+		if detector != nil {
+			previousLock.Lock()
+			if prev, ok := previous[res.Value.Pubkey]; ok {
+				out.ChangedFields = ChangedFields(prev, v)
+			}
+			previous[res.Value.Pubkey] = v
+			previousLock.Unlock()
+		}
+		return out, nil
+	}
+	subscribe := func(encoding solana.EncodingType) (*Subscription, error) {
+		return cl.programSubscribeWithConvert(programID, opts.Commitment, encoding, filters, clientFilters, convert)
+	}
+
+	if !opts.Compress {
+		sub, err := subscribe(solana.EncodingBase64)
+		if err != nil {
+			return nil, err
+		}
+		return &TypedProgramSubscription[T]{sub: sub}, nil
+	}
+
+	sub, err := subscribe(solana.EncodingBase64Zstd)
+	if err == nil {
+		err = cl.confirmSubscription(cl.subscribeContext(), sub)
+	}
+	var rpcErr *json2.Error
+	switch {
+	case err == nil:
+		return &TypedProgramSubscription[T]{sub: sub}, nil
+	case errors.As(err, &rpcErr):
+		cl.log().Debug("base64+zstd encoding rejected, subscribing with base64")
+		sub, err = subscribe(solana.EncodingBase64)
+		if err != nil {
+			return nil, err
+		}
+		return &TypedProgramSubscription[T]{sub: sub}, nil
+	default:
+		return nil, fmt.Errorf("program subscribe: %w", err)
+	}
+}
+
+// waitSubscribed waits until the subscription is confirmed by the server,
+// and returns the error that ended it if it fails first.
+func waitSubscribed(ctx context.Context, sub *Subscription) error {
+	confirmed := make(chan struct{}, 1)
+	failed := make(chan error, 1)
+	sub.OnSubscribed(func(uint64) {
+		select {
+		case confirmed <- struct{}{}:
+		default:
+		}
+	})
+	sub.OnError(func(err error) {
+		select {
+		case failed <- err:
+		default:
+		}
+	})
+	select {
+	case <-confirmed:
+		return nil
+	case err := <-failed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *TypedProgramSubscription[T]) Recv() (*TypedProgramResult[T], error) {
+	return s.RecvWithContext(context.Background())
+}
+
+func (s *TypedProgramSubscription[T]) RecvWithContext(ctx context.Context) (*TypedProgramResult[T], error) {
+	d, err := s.sub.RecvWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return typedNotification[TypedProgramResult[T]](d)
+}
+
+// RecvWithMeta is like RecvWithContext, and also returns
+// the latency metadata of the notification.
+func (s *TypedProgramSubscription[T]) RecvWithMeta(ctx context.Context) (*TypedProgramResult[T], NotificationMeta, error) {
+	d, meta, err := s.sub.RecvWithMeta(ctx)
+	if err != nil {
+		return nil, meta, err
+	}
+	v, err := typedNotification[TypedProgramResult[T]](d)
+	return v, meta, err
+}
+
+func (s *TypedProgramSubscription[T]) Err() <-chan error {
+	return s.sub.Err()
+}
+
+func (s *TypedProgramSubscription[T]) Unsubscribe() {
+	s.sub.Unsubscribe()
+}
+
+// UnsubscribeWithContext unsubscribes, waiting until ctx is done
+// (see Subscription.UnsubscribeWithContext).
+func (s *TypedProgramSubscription[T]) UnsubscribeWithContext(ctx context.Context) error {
+	return s.sub.UnsubscribeWithContext(ctx)
+}
+
+// Subscription returns the underlying subscription, e.g. to register
+// lifecycle hooks (see Subscription.OnSubscribed) or to inspect its state.
+func (s *TypedProgramSubscription[T]) Subscription() *Subscription {
+	return s.sub
+}
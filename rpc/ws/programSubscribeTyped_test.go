@@ -0,0 +1,157 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"encoding/base64"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+type testPool struct {
+	Authority solana.PublicKey
+	Mint      solana.PublicKey
+	Reserve   uint64
+}
+
+func Test_ProgramSubscribeTyped(t *testing.T) {
+	pool := testPool{
+		Authority: solana.NewWallet().PublicKey(),
+		Mint:      solana.NewWallet().PublicKey(),
+		Reserve:   42,
+	}
+	data, err := bin.MarshalBin(pool)
+	require.NoError(t, err)
+	account := solana.NewWallet().PublicKey()
+
+	var lock sync.Mutex
+	var requests []request
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in request
+			if err := stdjson.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			lock.Lock()
+			requests = append(requests, in)
+			lock.Unlock()
+			if strings.HasSuffix(in.Method, "Unsubscribe") {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":true,"id":%d}`, in.ID)))
+				continue
+			}
+			if strings.Contains(string(msg), "base64+zstd") {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params: unsupported encoding"},"id":%d}`, in.ID)))
+				continue
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":5,"id":%d}`, in.ID)))
+			for _, payload := range [][]byte{data, {1, 2}} {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+					`{"jsonrpc":"2.0","method":"programNotification","params":{"result":{"context":{"slot":9},"value":{"pubkey":"%s","account":{"data":["%s","base64"],"executable":false,"lamports":1,"owner":"11111111111111111111111111111111","rentEpoch":0}}},"subscription":5}}`,
+					account, base64.StdEncoding.EncodeToString(payload),
+				)))
+			}
+		}
+	}))
+	defer server.Close()
+
+	c, err := Connect(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	filters := rpc.NewFilterBuilder().DataSize(72).MemcmpField(testPool{}, "Mint", pool.Mint)
+	sub, err := ProgramSubscribeTyped[testPool](c, solana.SystemProgramID, &ProgramSubscribeOpts{
+		Commitment: rpc.CommitmentConfirmed,
+		Filters:    filters,
+		Compress:   true,
+	}, nil)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	got, err := sub.Recv()
+	require.NoError(t, err)
+	require.NoError(t, got.Err)
+	require.Equal(t, uint64(9), got.Slot)
+	require.Equal(t, account, got.Pubkey)
+	require.Equal(t, &pool, got.Value)
+
+	// Data that cannot be decoded is reported without ending the subscription.
+	got, err = sub.Recv()
+	require.NoError(t, err)
+	require.Error(t, got.Err)
+	require.Nil(t, got.Value)
+
+	lock.Lock()
+	defer lock.Unlock()
+	require.Len(t, requests, 2)
+	conf := requests[1].Params.([]interface{})[1].(map[string]interface{})
+	require.Equal(t, "base64", conf["encoding"])
+	require.Equal(t, "confirmed", conf["commitment"])
+	memcmp := conf["filters"].([]interface{})[1].(map[string]interface{})["memcmp"].(map[string]interface{})
+	require.Equal(t, float64(32), memcmp["offset"])
+}
+
+func Test_ProgramSubscribeTyped_rawView(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := ProgramSubscribeTyped[testPool](c.WithRawNotifications(RawCopy), solana.SystemProgramID, nil, nil)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = server.WaitForSubscription(ctx, "programSubscribe")
+	require.NoError(t, err)
+	_, err = server.Notify("programSubscribe", rpc.M{
+		"context": rpc.M{"slot": 9},
+		"value": rpc.M{
+			"pubkey":  solana.SystemProgramID.String(),
+			"account": rpc.M{"lamports": 1, "owner": solana.SystemProgramID.String(), "data": []string{"", "base64"}},
+		},
+	})
+	require.NoError(t, err)
+
+	// The notifications of a raw view are not decoded: Recv fails instead of panicking.
+	_, err = sub.RecvWithContext(ctx)
+	require.ErrorIs(t, err, ErrRawNotification)
+}
@@ -0,0 +1,160 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"golang.org/x/time/rate"
+)
+
+// TenantQuota configures the limits enforced on a tenant (see Client.ForTenant).
+// A zero value for any of the fields means no limit.
+type TenantQuota struct {
+	// MaxSubscriptions is the maximum number of active subscriptions.
+	MaxSubscriptions int
+
+	// MaxMessagesPerSecond is the maximum rate at which notifications are
+	// delivered to the tenant, across all of its subscriptions.
+	// The notifications above the rate are dropped, and counted in the
+	// Drops of their subscription (see Options.OnQuotaExceeded).
+	MaxMessagesPerSecond float64
+
+	// MaxCallsPerMinute is the maximum number of RPC calls
+	// (i.e. subscribe requests) sent on behalf of the tenant per minute.
+	MaxCallsPerMinute int
+}
+
+// QuotaKind identifies the limit of a TenantQuota.
+type QuotaKind string
+
+const (
+	QuotaSubscriptions QuotaKind = "subscriptions"
+	QuotaMessages      QuotaKind = "messages_per_second"
+	QuotaCalls         QuotaKind = "calls_per_minute"
+)
+
+// ErrQuotaExceeded matches any *QuotaExceededError with errors.Is.
+var ErrQuotaExceeded = errors.New("tenant quota exceeded")
+
+// QuotaExceededError is returned when an operation would exceed a tenant quota.
+type QuotaExceededError struct {
+	Tenant string
+	Kind   QuotaKind
+	Limit  float64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("tenant %q exceeded %s quota (limit %v)", e.Tenant, e.Kind, e.Limit)
+}
+
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// QuotaExceededFunc is called when a tenant starts exceeding its
+// MaxMessagesPerSecond (see Options.OnQuotaExceeded). It runs on the goroutine
+// that reads the websocket connection, so it must not block.
+type QuotaExceededFunc func(err *QuotaExceededError)
+
+// tenantQuota holds the state of a quota, shared by all the views of a tenant.
+type tenantQuota struct {
+	quota    TenantQuota
+	messages *rate.Limiter
+	calls    *rate.Limiter
+	// throttled is true while the notifications of the tenant are dropped.
+	throttled atomic.Bool
+}
+
+func newTenantQuota(quota TenantQuota) *tenantQuota {
+	q := &tenantQuota{quota: quota}
+	if quota.MaxMessagesPerSecond > 0 {
+		burst := int(math.Ceil(quota.MaxMessagesPerSecond))
+		q.messages = rate.NewLimiter(rate.Limit(quota.MaxMessagesPerSecond), burst)
+	}
+	if quota.MaxCallsPerMinute > 0 {
+		q.calls = rate.NewLimiter(rate.Limit(float64(quota.MaxCallsPerMinute)/60), quota.MaxCallsPerMinute)
+	}
+	return q
+}
+
+// SetTenantQuota sets the quota of a tenant, replacing any previous one
+// and resetting its rate counters. The quota applies to all the views
+// of the tenant on this connection.
+func (c *Client) SetTenantQuota(tenant string, quota TenantQuota) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.quotas[tenant] = newTenantQuota(quota)
+}
+
+// TenantQuota returns the quota of the provided tenant, if any.
+func (c *Client) TenantQuota(tenant string) (TenantQuota, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	q, ok := c.quotas[tenant]
+	if !ok {
+		return TenantQuota{}, false
+	}
+	return q.quota, true
+}
+
+// checkSubscribeQuota verifies that the tenant of the client may create
+// a new subscription, and counts the call. A subscription rejected by
+// MaxSubscriptions is not counted. Must be called with the lock held.
+func (c *Client) checkSubscribeQuota() error {
+	q, ok := c.quotas[c.tenant]
+	if !ok {
+		return nil
+	}
+	if max := q.quota.MaxSubscriptions; max > 0 {
+		count := 0
+		for _, sub := range c.subscriptionByRequestID {
+			if sub.tenant == c.tenant {
+				count++
+			}
+		}
+		if count >= max {
+			return &QuotaExceededError{Tenant: c.tenant, Kind: QuotaSubscriptions, Limit: float64(max)}
+		}
+	}
+	if q.calls != nil && !q.calls.Allow() {
+		return &QuotaExceededError{Tenant: c.tenant, Kind: QuotaCalls, Limit: float64(q.quota.MaxCallsPerMinute)}
+	}
+	return nil
+}
+
+// checkMessageQuota reports whether a notification may be delivered
+// to the tenant of the subscription. The error is set when the tenant
+// starts exceeding its quota, i.e. for the first notification dropped
+// after one was delivered.
+func (c *Client) checkMessageQuota(sub *Subscription) (bool, *QuotaExceededError) {
+	c.lock.RLock()
+	q, ok := c.quotas[sub.tenant]
+	c.lock.RUnlock()
+	if !ok || q.messages == nil {
+		return true, nil
+	}
+	if q.messages.Allow() {
+		q.throttled.Store(false)
+		return true, nil
+	}
+	if q.throttled.Swap(true) {
+		return false, nil
+	}
+	return false, &QuotaExceededError{Tenant: sub.tenant, Kind: QuotaMessages, Limit: q.quota.MaxMessagesPerSecond}
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"time"
+)
+
+// ErrDiscardNotification can be returned by the decoder passed to SubscribeRaw
+// to silently skip a notification.
+var ErrDiscardNotification = errDiscardNotification
+
+// DecodeNotification decodes the result of a notification message into reply.
+// It is meant to be used by the decoders passed to SubscribeRaw.
+func DecodeNotification(msg []byte, reply interface{}) error {
+	return decodeResponseFromMessage(msg, reply)
+}
+
+// SubscribeRaw subscribes with an arbitrary subscription method, e.g. a
+// provider-specific method or one exposed by a custom Geyser plugin.
+//
+// The conf object, if not nil, is appended to params. Each notification
+// message is passed to decoder, whose result is returned by Subscription.Recv;
+// when decoder is nil, Recv returns the result of the notification
+// as a json.RawMessage.
+func (cl *Client) SubscribeRaw(
+	params []interface{},
+	conf map[string]interface{}, // optional
+	subscribeMethod string,
+	unsubscribeMethod string,
+	decoder func(msg []byte) (interface{}, error), // optional
+) (*Subscription, error) {
+	return cl.SubscribeRawWithContext(cl.subscribeContext(), params, conf, subscribeMethod, unsubscribeMethod, decoder)
+}
+
+// SubscribeRawWithContext is like SubscribeRaw, but binds the subscription
+// to ctx: if ctx is done before the subscription request is sent,
+// ctx.Err() is returned; once ctx is done, the subscription is unsubscribed
+// and Recv returns an error matching both ErrCanceled and ctx.Err().
+func (cl *Client) SubscribeRawWithContext(
+	ctx context.Context,
+	params []interface{},
+	conf map[string]interface{}, // optional
+	subscribeMethod string,
+	unsubscribeMethod string,
+	decoder func(msg []byte) (interface{}, error), // optional
+) (*Subscription, error) {
+	if params == nil && conf != nil {
+		params = []interface{}{}
+	}
+	if decoder == nil {
+		decoder = func(msg []byte) (interface{}, error) {
+			var res stdjson.RawMessage
+			err := decodeResponseFromMessage(msg, &res)
+			return res, err
+		}
+	}
+	return cl.subscribeWithContext(
+		ctx,
+		params,
+		conf,
+		subscribeMethod,
+		unsubscribeMethod,
+		decoder,
+	)
+}
+
+// RecvWithContext waits for the next notification of the subscription,
+// or for ctx to be done.
+func (s *Subscription) RecvWithContext(ctx context.Context) (interface{}, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case d := <-s.stream:
+		return d.value, nil
+	case err := <-s.err:
+		return nil, err
+	}
+}
+
+// RecvTimeout waits for the next notification of the subscription
+// for up to timeout; it returns ErrRecvTimeout if none is received in time.
+func (s *Subscription) RecvTimeout(timeout time.Duration) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	d, err := s.RecvWithContext(ctx)
+	if err != nil && err == ctx.Err() {
+		return nil, ErrRecvTimeout
+	}
+	return d, err
+}
+
+// Err returns a channel that receives the error that closed the subscription.
+func (s *Subscription) Err() <-chan error {
+	return s.err
+}
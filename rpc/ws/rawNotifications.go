@@ -0,0 +1,179 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"sync"
+)
+
+// RawMode selects how the notifications of the subscriptions
+// are delivered (see Client.WithRawNotifications).
+type RawMode int
+
+const (
+	// RawDecoded delivers the notifications decoded on the read loop
+	// of the client, as the Recv methods of the typed subscriptions return them.
+	RawDecoded RawMode = iota
+	// RawCopy delivers every notification as a copy of the message.
+	RawCopy
+	// RawPooled delivers every notification in a buffer taken from a pool,
+	// which must be handed back with RawNotification.Release.
+	RawPooled
+)
+
+// maxPooledNotificationSize is the capacity above which
+// the buffers of released notifications are not pooled,
+// so that a burst of large messages is not retained.
+const maxPooledNotificationSize = 1 << 20
+
+var rawNotificationBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 4096)
+		return &buf
+	},
+}
+
+// decoder returns the decoder delivering the messages as *RawNotification.
+func (m RawMode) decoder() decoderFunc {
+	if m == RawPooled {
+		return func(msg []byte) (interface{}, error) {
+			buf := rawNotificationBuffers.Get().(*[]byte)
+			*buf = append((*buf)[:0], msg...)
+			return &RawNotification{Data: *buf, buf: buf}, nil
+		}
+	}
+	return func(msg []byte) (interface{}, error) {
+		return &RawNotification{Data: append([]byte(nil), msg...)}, nil
+	}
+}
+
+// WithRawNotifications returns a view of the client that shares its connection,
+// and whose subscriptions deliver the notification messages undecoded,
+// as *RawNotification, so that they can be decoded by the consumer
+// (e.g. on a pool of goroutines) instead of on the read loop of the client.
+//
+// Any subscription can be made through the view, and must then be received
+// with NewRawSubscription: the Recv methods of the typed subscriptions
+// return ErrRawNotification. The notifications the typed subscriptions filter
+// while decoding (e.g. ProgramSubscribeOpts.OnlyChanges) are delivered too.
+//
+//	sub, err := client.WithRawNotifications(ws.RawPooled).AccountSubscribe(account, "")
+//	raw := ws.NewRawSubscription(sub)
+//	n, err := raw.Recv()
+//	var res ws.AccountResult
+//	err = n.Decode(&res)
+//	n.Release()
+//
+// Closing the returned client closes the shared connection.
+func (c *Client) WithRawNotifications(mode RawMode) *Client {
+	return &Client{
+		connection:       c.connection,
+		tenant:           c.tenant,
+		ctx:              c.ctx,
+		raw:              mode,
+		subscribeTimeout: c.subscribeTimeout,
+	}
+}
+
+// WithRawNotifications returns a view of the client that shares its connection,
+// and whose subscriptions deliver undecoded notifications
+// (see Client.WithRawNotifications).
+func (c *HeliusClient) WithRawNotifications(mode RawMode) *HeliusClient {
+	return &HeliusClient{
+		Client: c.Client.WithRawNotifications(mode),
+	}
+}
+
+// RawNotification is an undecoded notification message.
+type RawNotification struct {
+	// Data is the whole notification message; with RawPooled,
+	// it must not be used after Release.
+	Data []byte
+
+	buf *[]byte // pooled buffer of Data, if any
+}
+
+// Decode decodes the result of the notification into reply,
+// e.g. a *AccountResult for an account subscription.
+func (n *RawNotification) Decode(reply interface{}) error {
+	return decodeResponseFromMessage(n.Data, reply)
+}
+
+// Release hands the buffer of a notification delivered with RawPooled
+// back to the pool; it does nothing with RawCopy.
+func (n *RawNotification) Release() {
+	if n.buf == nil {
+		return
+	}
+	if cap(n.Data) <= maxPooledNotificationSize {
+		*n.buf = n.Data[:0]
+		rawNotificationBuffers.Put(n.buf)
+	}
+	n.Data, n.buf = nil, nil
+}
+
+// RawSubscription receives the notifications of a subscription
+// made through Client.WithRawNotifications.
+type RawSubscription struct {
+	sub *Subscription
+}
+
+// NewRawSubscription returns the raw view of a subscription
+// made through Client.WithRawNotifications, e.g. an *AccountSubscription.
+func NewRawSubscription(sub Subscriber) *RawSubscription {
+	return &RawSubscription{sub: sub.Subscription()}
+}
+
+func (s *RawSubscription) Recv() (*RawNotification, error) {
+	return s.RecvWithContext(context.Background())
+}
+
+func (s *RawSubscription) RecvWithContext(ctx context.Context) (*RawNotification, error) {
+	d, err := s.sub.RecvWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return d.(*RawNotification), nil
+}
+
+// RecvWithMeta is like RecvWithContext, and also returns
+// the latency metadata of the notification.
+func (s *RawSubscription) RecvWithMeta(ctx context.Context) (*RawNotification, NotificationMeta, error) {
+	d, meta, err := s.sub.RecvWithMeta(ctx)
+	if err != nil {
+		return nil, meta, err
+	}
+	return d.(*RawNotification), meta, nil
+}
+
+// Subscription returns the underlying subscription.
+func (s *RawSubscription) Subscription() *Subscription {
+	return s.sub
+}
+
+func (s *RawSubscription) Err() <-chan error {
+	return s.sub.Err()
+}
+
+func (s *RawSubscription) Unsubscribe() {
+	s.sub.Unsubscribe()
+}
+
+// UnsubscribeWithContext unsubscribes, waiting until ctx is done
+// (see Subscription.UnsubscribeWithContext).
+func (s *RawSubscription) UnsubscribeWithContext(ctx context.Context) error {
+	return s.sub.UnsubscribeWithContext(ctx)
+}
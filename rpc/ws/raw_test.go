@@ -0,0 +1,95 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SubscribeRaw(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := Connect(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	raw, err := c.SubscribeRaw(nil, map[string]interface{}{"commitment": "confirmed"}, "customSubscribe", "customUnsubscribe", nil)
+	require.NoError(t, err)
+	got, err := raw.RecvWithContext(context.Background())
+	require.NoError(t, err)
+	require.JSONEq(t, `{"parent":1,"root":0,"slot":2}`, string(got.(stdjson.RawMessage)))
+	require.Equal(t, "customSubscribe", c.Subscriptions()[0].Method)
+	raw.Unsubscribe()
+	require.ErrorIs(t, <-raw.Err(), ErrCanceled)
+
+	typed, err := c.SubscribeRaw([]interface{}{"param"}, nil, "customSubscribe", "customUnsubscribe", func(msg []byte) (interface{}, error) {
+		var res SlotResult
+		err := DecodeNotification(msg, &res)
+		return &res, err
+	})
+	require.NoError(t, err)
+	defer typed.Unsubscribe()
+	got, err = typed.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), got.(*SlotResult).Slot)
+}
+
+func Test_WithRawNotifications(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{ReuseReadBuffer: true}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	for _, mode := range []RawMode{RawCopy, RawPooled} {
+		sub, err := c.WithRawNotifications(mode).ForTenant("raw").SlotSubscribe()
+		require.NoError(t, err)
+		raw := NewRawSubscription(sub)
+
+		n, err := raw.Recv()
+		require.NoError(t, err)
+		require.Contains(t, string(n.Data), `"method":"slotNotification"`)
+		var res SlotResult
+		require.NoError(t, n.Decode(&res))
+		require.Equal(t, uint64(2), res.Slot)
+		n.Release()
+		if mode == RawPooled {
+			require.Nil(t, n.Data)
+		}
+		require.NoError(t, raw.UnsubscribeWithContext(context.Background()))
+	}
+
+	// Subscriptions made through the client itself are still decoded.
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	res, err := sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), res.Slot)
+
+	// The typed view of a subscription made through a raw view
+	// returns an error instead of a notification of another type.
+	typed, err := c.WithRawNotifications(RawPooled).SlotSubscribe()
+	require.NoError(t, err)
+	_, err = typed.Recv()
+	require.ErrorIs(t, err, ErrRawNotification)
+	require.NoError(t, typed.UnsubscribeWithContext(context.Background()))
+}
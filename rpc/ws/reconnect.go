@@ -0,0 +1,217 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+var (
+	// DefaultReconnectMinBackoff is the delay before the first reconnection
+	// attempt (see ReconnectOptions); it doubles after each failed attempt.
+	DefaultReconnectMinBackoff = 500 * time.Millisecond
+	// DefaultReconnectMaxBackoff is the maximum delay between
+	// two reconnection attempts.
+	DefaultReconnectMaxBackoff = 30 * time.Second
+)
+
+// ConnectionState is the state of the connection of a client.
+type ConnectionState int32
+
+const (
+	// ConnectionConnected is the state of an open connection.
+	ConnectionConnected ConnectionState = iota
+	// ConnectionDisconnected is the state of a closed connection.
+	ConnectionDisconnected
+	// ConnectionReconnecting is the state of a connection being
+	// reestablished (see Options.Reconnect).
+	ConnectionReconnecting
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case ConnectionConnected:
+		return "connected"
+	case ConnectionDisconnected:
+		return "disconnected"
+	case ConnectionReconnecting:
+		return "reconnecting"
+	default:
+		return fmt.Sprintf("ConnectionState(%d)", int32(s))
+	}
+}
+
+// ConnectionEvent is a change of the state of the connection.
+type ConnectionEvent struct {
+	State ConnectionState
+	// Err is the error that closed the connection, or that failed
+	// the previous reconnection attempt. It is nil when the connection
+	// is closed with Close or CloseWithContext.
+	Err error
+	// Attempt is the number of the reconnection attempt, starting at 1.
+	Attempt int
+}
+
+// ConnectionStateFunc is called when the state of the connection changes
+// (see Options.OnConnectionStateChange).
+type ConnectionStateFunc func(event ConnectionEvent)
+
+// ReconnectOptions configures the reconnection of a client whose
+// connection fails (see Options.Reconnect).
+type ReconnectOptions struct {
+	// MaxAttempts is the number of consecutive failed attempts
+	// after which the client gives up. Zero means no limit.
+	MaxAttempts int
+	// Delay before the first attempt, doubled after each failed attempt
+	// up to MaxBackoff. Default to DefaultReconnectMinBackoff
+	// and DefaultReconnectMaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// ConnectionState returns the current state of the connection.
+func (c *connection) ConnectionState() ConnectionState {
+	return ConnectionState(c.state.Load())
+}
+
+// setConnectionState records the state of the connection,
+// and calls the OnConnectionStateChange hook.
+func (c *connection) setConnectionState(event ConnectionEvent) {
+	c.state.Store(int32(event.State))
+	c.log().Debug("ws connection state changed",
+		zap.String("label", c.label),
+		zap.Stringer("state", event.State),
+		zap.Int("attempt", event.Attempt),
+		zap.Error(event.Err),
+	)
+	if c.onStateChange != nil {
+		c.onStateChange(event)
+	}
+}
+
+// currentConn returns the connection in use, which changes on reconnection.
+func (c *connection) currentConn() *websocket.Conn {
+	c.connLock.Lock()
+	defer c.connLock.Unlock()
+	return c.conn
+}
+
+// dial opens a new connection to the endpoint of the client.
+func (c *connection) dial(ctx context.Context) (*websocket.Conn, error) {
+	conn, resp, err := c.dialer.DialContext(ctx, c.rpcURL, c.httpHeader)
+	if err != nil {
+		if resp != nil {
+			body, _ := io.ReadAll(resp.Body)
+			return nil, fmt.Errorf("dial: %w, status: %s, body: %q", err, resp.Status, string(body))
+		}
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+	c.compressed.Store(strings.Contains(resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate"))
+	return conn, nil
+}
+
+// setupConn applies the read limit and the compression level to conn,
+// and sets its read deadline, extended on each pong.
+func (c *connection) setupConn(conn *websocket.Conn) {
+	if c.readLimit > 0 {
+		conn.SetReadLimit(c.readLimit)
+	}
+	if c.compressionLevel != 0 {
+		if err := conn.SetCompressionLevel(c.compressionLevel); err != nil {
+			c.log().Warn("invalid ws compression level", zap.Int("level", c.compressionLevel), zap.Error(err))
+		}
+	}
+	conn.SetReadDeadline(time.Now().Add(c.pongWait))
+	conn.SetPongHandler(func(payload string) error {
+		c.health.recordPong(payload)
+		conn.SetReadDeadline(time.Now().Add(c.pongWait))
+		return nil
+	})
+}
+
+// redial replaces the failed connection, retrying with backoff.
+// It returns the new connection, or nil if the client was closed
+// or the attempts are exhausted, along with the error of the last
+// attempt in the latter case. The caller closes the subscriptions
+// registered in the meantime, and reports the disconnection.
+func (c *connection) redial() (*websocket.Conn, error) {
+	opts := c.reconnectOpts
+	backoff, maxBackoff := opts.MinBackoff, opts.MaxBackoff
+	if backoff <= 0 {
+		backoff = DefaultReconnectMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultReconnectMaxBackoff
+	}
+
+	var lastErr error
+	for attempt := 1; opts.MaxAttempts <= 0 || attempt <= opts.MaxAttempts; attempt++ {
+		c.setConnectionState(ConnectionEvent{State: ConnectionReconnecting, Err: lastErr, Attempt: attempt})
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-c.connCtx.Done():
+			timer.Stop()
+			return nil, nil
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+
+		conn, err := c.dial(c.connCtx)
+		if err != nil {
+			lastErr = err
+			c.log().Warn("unable to reconnect ws connection",
+				zap.String("label", c.label),
+				zap.Int("attempt", attempt),
+				zap.Error(err),
+			)
+			continue
+		}
+		c.setupConn(conn)
+
+		c.connLock.Lock()
+		if c.closing.Load() {
+			c.connLock.Unlock()
+			conn.Close()
+			return nil, nil
+		}
+		c.conn = conn
+		c.connLock.Unlock()
+
+		c.health.reset()
+		c.log().Info("ws connection reestablished",
+			zap.String("label", c.label),
+			zap.Int("attempt", attempt),
+		)
+		c.setConnectionState(ConnectionEvent{State: ConnectionConnected})
+		return conn, nil
+	}
+
+	c.log().Warn("giving up reconnecting ws connection",
+		zap.String("label", c.label),
+		zap.Int("attempts", opts.MaxAttempts),
+		zap.Error(lastErr),
+	)
+	return nil, lastErr
+}
@@ -0,0 +1,343 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// connectionEvents records the connection events of a client.
+type connectionEvents struct {
+	lock   sync.Mutex
+	events []ConnectionEvent
+}
+
+func (e *connectionEvents) record(event ConnectionEvent) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *connectionEvents) states() []ConnectionState {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	out := make([]ConnectionState, len(e.events))
+	for i, event := range e.events {
+		out[i] = event.State
+	}
+	return out
+}
+
+func (e *connectionEvents) last() ConnectionEvent {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.events[len(e.events)-1]
+}
+
+func Test_Reconnect(t *testing.T) {
+	echo := newSubscribeEchoServer(t)
+	defer echo.Close()
+
+	// The first connection is dropped on the first message.
+	upgrader := websocket.Upgrader{}
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&connections, 1) == 1 {
+			conn, err := upgrader.Upgrade(rw, req, nil)
+			if err != nil {
+				return
+			}
+			conn.ReadMessage()
+			conn.Close()
+			return
+		}
+		echo.Config.Handler.ServeHTTP(rw, req)
+	}))
+	defer server.Close()
+
+	events := &connectionEvents{}
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{
+		Reconnect:               &ReconnectOptions{MinBackoff: time.Millisecond},
+		OnConnectionStateChange: events.record,
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, ConnectionConnected, c.ConnectionState())
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	_, err = sub.Recv()
+	require.Error(t, err)
+
+	require.Eventually(t, func() bool {
+		return c.ConnectionState() == ConnectionConnected && len(events.states()) == 4
+	}, 5*time.Second, time.Millisecond)
+	require.Equal(t, []ConnectionState{
+		ConnectionConnected,
+		ConnectionDisconnected,
+		ConnectionReconnecting,
+		ConnectionConnected,
+	}, events.states())
+	require.Error(t, events.events[1].Err)
+	require.Equal(t, 1, events.events[2].Attempt)
+
+	sub, err = c.SlotSubscribe()
+	require.NoError(t, err)
+	got, err := sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), got.Slot)
+
+	c.Close()
+	require.Eventually(t, func() bool {
+		return c.ConnectionState() == ConnectionDisconnected
+	}, 5*time.Second, time.Millisecond)
+	require.NoError(t, events.last().Err)
+}
+
+func Test_Reconnect_maxAttempts(t *testing.T) {
+	// The first connection is dropped, and the next dials fail.
+	upgrader := websocket.Upgrader{}
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&connections, 1) > 1 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+		conn.Close()
+	}))
+	defer server.Close()
+
+	events := &connectionEvents{}
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{
+		Reconnect:               &ReconnectOptions{MaxAttempts: 2, MinBackoff: time.Millisecond},
+		OnConnectionStateChange: events.record,
+	}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	select {
+	case <-c.readerDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client still reconnecting")
+	}
+	require.Equal(t, []ConnectionState{
+		ConnectionConnected,
+		ConnectionDisconnected,
+		ConnectionReconnecting,
+		ConnectionReconnecting,
+		ConnectionDisconnected,
+	}, events.states())
+	require.Error(t, events.last().Err)
+	require.Contains(t, events.last().Err.Error(), "503")
+	require.Equal(t, ConnectionDisconnected, c.ConnectionState())
+
+	// Once the client gave up, subscribing fails instead of
+	// registering a subscription that is never closed.
+	_, err = c.SlotSubscribe()
+	require.ErrorIs(t, err, ErrConnectionClosed)
+	require.Empty(t, c.Subscriptions())
+}
+
+// newDroppingEchoServer is like newSubscribeEchoServer, but drops
+// its first connection when drop is closed.
+func newDroppingEchoServer(t *testing.T, drop <-chan struct{}) *httptest.Server {
+	echo := newSubscribeEchoServer(t)
+	t.Cleanup(echo.Close)
+
+	var connections int32
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&connections, 1) > 1 {
+			echo.Config.Handler.ServeHTTP(rw, req)
+			return
+		}
+		hijacked := &dropResponseWriter{ResponseWriter: rw, drop: drop}
+		echo.Config.Handler.ServeHTTP(hijacked, req)
+	}))
+}
+
+// dropResponseWriter closes the hijacked connection when drop is closed.
+type dropResponseWriter struct {
+	http.ResponseWriter
+	drop <-chan struct{}
+}
+
+func (w *dropResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		go func() {
+			<-w.drop
+			conn.Close()
+		}()
+	}
+	return conn, rw, err
+}
+
+func Test_Reconnect_contextCanceled(t *testing.T) {
+	drop := make(chan struct{})
+	server := newDroppingEchoServer(t, drop)
+	defer server.Close()
+
+	events := &connectionEvents{}
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{
+		Reconnect:               &ReconnectOptions{MinBackoff: 100 * time.Millisecond},
+		OnConnectionStateChange: events.record,
+	}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	var subs []*SlotSubscription
+	var cancels []context.CancelFunc
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sub, err := c.SlotSubscribeWithContext(ctx)
+		require.NoError(t, err)
+		_, err = sub.Recv()
+		require.NoError(t, err)
+		subs = append(subs, sub)
+		cancels = append(cancels, cancel)
+	}
+
+	close(drop)
+	require.Eventually(t, func() bool {
+		return c.ConnectionState() == ConnectionReconnecting
+	}, 5*time.Second, time.Millisecond)
+
+	// Cancel the subscriptions, and subscribe again, while reconnecting.
+	var wg sync.WaitGroup
+	for i := range subs {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			cancels[i]()
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i*10)*time.Millisecond)
+			defer cancel()
+			sub, err := c.SlotSubscribeWithContext(ctx)
+			if err != nil {
+				return
+			}
+			sub.RecvWithContext(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, sub := range subs {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		var err error
+		for err == nil {
+			_, err = sub.RecvWithContext(ctx)
+		}
+		cancel()
+		require.NotErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, SubscriptionClosed, sub.Subscription().State())
+	}
+
+	require.Eventually(t, func() bool {
+		return c.ConnectionState() == ConnectionConnected && len(c.Subscriptions()) == 0
+	}, 5*time.Second, time.Millisecond)
+
+	// The subscriptions made after the reconnection are bound
+	// to their context too.
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := c.SlotSubscribeWithContext(ctx)
+	require.NoError(t, err)
+	_, err = sub.Recv()
+	require.NoError(t, err)
+	cancel()
+	for err == nil {
+		_, err = sub.Recv()
+	}
+	require.ErrorIs(t, err, ErrCanceled)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Eventually(t, func() bool {
+		return len(c.Subscriptions()) == 0
+	}, 5*time.Second, time.Millisecond)
+}
+
+// Test_Reconnect_contextCanceled_race cancels subscriptions while the
+// client repeatedly reconnects, and is meant to be run with -race.
+func Test_Reconnect_contextCanceled_race(t *testing.T) {
+	// Each connection is dropped shortly after it is opened.
+	echo := newSubscribeEchoServer(t)
+	defer echo.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		drop := make(chan struct{})
+		time.AfterFunc(20*time.Millisecond, func() { close(drop) })
+		echo.Config.Handler.ServeHTTP(&dropResponseWriter{ResponseWriter: rw, drop: drop}, req)
+	}))
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{
+		Reconnect: &ReconnectOptions{MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond},
+	}, nil)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			time.Sleep(time.Duration(i*3) * time.Millisecond)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(i%5*10)*time.Millisecond)
+			defer cancel()
+			var sub *SlotSubscription
+			var err error
+			if i%2 == 0 {
+				sub, err = c.SlotSubscribeWithContext(ctx)
+			} else {
+				sub, err = c.WithContext(ctx).SlotSubscribe()
+			}
+			if err != nil {
+				return
+			}
+			sub.RecvWithContext(ctx)
+			<-ctx.Done()
+			sub.UnsubscribeWithContext(context.Background())
+		}(i)
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	c.CloseWithContext(ctx)
+	cancel()
+	require.Eventually(t, func() bool {
+		return c.ConnectionState() == ConnectionDisconnected
+	}, 5*time.Second, time.Millisecond)
+	require.Empty(t, c.Subscriptions())
+
+	// Subscribing to the closed client fails instead of leaking a subscription.
+	_, err = c.SlotSubscribeWithContext(context.Background())
+	require.ErrorIs(t, err, ErrConnectionClosed)
+	require.Empty(t, c.Subscriptions())
+}
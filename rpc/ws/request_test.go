@@ -0,0 +1,112 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gorilla/rpc/v2/json2"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// newRejectingServer confirms the slot subscriptions, and rejects
+// the others with an error longer than a subscription confirmation.
+func newRejectingServer(t *testing.T) (url string, ids func() []uint64) {
+	var lock sync.Mutex
+	var seen []uint64
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in request
+			if err := stdjson.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			lock.Lock()
+			seen = append(seen, in.ID)
+			lock.Unlock()
+			if in.Method == "slotSubscribe" {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":%d,"id":%d}`, in.ID+100, in.ID)))
+				continue
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(
+				`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params: %s"},"id":%d}`,
+				strings.Repeat("x", 200), in.ID,
+			)))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), func() []uint64 {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]uint64(nil), seen...)
+	}
+}
+
+func TestConfirmSubscriptions(t *testing.T) {
+	url, ids := newRejectingServer(t)
+	c, err := ConnectWithOptions(context.Background(), url, &Options{ConfirmSubscriptions: true}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	slots, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	require.Equal(t, SubscriptionActive, slots.sub.State())
+	require.EqualValues(t, 101, slots.sub.SubscriptionID())
+
+	_, err = c.AccountSubscribe(solana.SystemProgramID, "")
+	var rpcErr *json2.Error
+	require.True(t, errors.As(err, &rpcErr), err)
+	require.EqualValues(t, -32602, rpcErr.Code)
+
+	// Request IDs are allocated in sequence on the connection.
+	require.Equal(t, []uint64{1, 2}, ids())
+}
+
+func TestSubscribeError_longMessage(t *testing.T) {
+	url, _ := newRejectingServer(t)
+	c, err := Connect(context.Background(), url)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.AccountSubscribe(solana.SystemProgramID, "")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = sub.RecvWithContext(ctx)
+	var rpcErr *json2.Error
+	require.True(t, errors.As(err, &rpcErr), err)
+	require.Contains(t, rpcErr.Message, "Invalid params")
+}
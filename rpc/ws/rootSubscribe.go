@@ -40,47 +40,11 @@ func (cl *Client) RootSubscribe() (*RootSubscription, error) {
 	}, nil
 }
 
-type RootSubscription struct {
-	sub *Subscription
+// RootSubscribeWithContext is like RootSubscribe, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (cl *Client) RootSubscribeWithContext(ctx context.Context) (*RootSubscription, error) {
+	return cl.WithContext(ctx).RootSubscribe()
 }
 
-func (sw *RootSubscription) Recv() (*RootResult, error) {
-	select {
-	case d := <-sw.sub.stream:
-		return d.(*RootResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *RootSubscription) RecvWithContext(ctx context.Context) (*RootResult, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case d := <-sw.sub.stream:
-		return d.(*RootResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *RootSubscription) Err() <-chan error {
-	return sw.sub.err
-}
-
-func (sw *RootSubscription) Response() <-chan *RootResult {
-	typedChan := make(chan *RootResult, 1)
-	go func(ch chan *RootResult) {
-		// TODO: will this subscription yield more than one result?
-		d, ok := <-sw.sub.stream
-		if !ok {
-			return
-		}
-		ch <- d.(*RootResult)
-	}(typedChan)
-	return typedChan
-}
-
-func (sw *RootSubscription) Unsubscribe() {
-	sw.sub.Unsubscribe()
-}
+// RootSubscription is the subscription returned by RootSubscribe.
+type RootSubscription = TypedSubscription[RootResult]
@@ -0,0 +1,159 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// ErrCanceledByServer matches the *ServerCanceledError errors.
+var ErrCanceledByServer = errors.New("subscription canceled by server")
+
+// ServerCanceledError is the error of a subscription canceled by the server
+// with an error notification, e.g. "subscription was cancelled: node restarted".
+type ServerCanceledError struct {
+	SubscriptionID uint64
+	Code           int
+	Message        string
+}
+
+func (e *ServerCanceledError) Error() string {
+	return fmt.Sprintf("subscription %d canceled by server: %s (code %d)", e.SubscriptionID, e.Message, e.Code)
+}
+
+func (e *ServerCanceledError) Is(target error) bool {
+	return target == ErrCanceledByServer
+}
+
+// ResubscribePolicy decides whether a subscription canceled by the server
+// is made again, from the error of the server and the number of times
+// the subscription was already made again (see Options.Resubscribe).
+// It is called from the read loop of the client and must not block.
+type ResubscribePolicy func(sub *Subscription, err *ServerCanceledError, attempt int) bool
+
+// ResubscribeUpTo returns a ResubscribePolicy making a subscription
+// canceled by the server again, up to max times.
+func ResubscribeUpTo(max int) ResubscribePolicy {
+	return func(_ *Subscription, _ *ServerCanceledError, attempt int) bool {
+		return attempt < max
+	}
+}
+
+// parseServerError returns the error of a notification
+// whose params carry an error object instead of a result.
+func parseServerError(subID uint64, message []byte) (*ServerCanceledError, bool) {
+	value, dataType, _, err := jsonparser.Get(message, "params", "error")
+	if err != nil || dataType != jsonparser.Object {
+		return nil, false
+	}
+	return newServerCanceledError(subID, value), true
+}
+
+// newServerCanceledError decodes the error object sent by the server
+// for the subscription ID.
+func newServerCanceledError(subID uint64, errObject []byte) *ServerCanceledError {
+	code, _ := jsonparser.GetInt(errObject, "code")
+	message, _ := jsonparser.GetString(errObject, "message")
+	return &ServerCanceledError{
+		SubscriptionID: subID,
+		Code:           int(code),
+		Message:        message,
+	}
+}
+
+// handleServerError makes again, or fails, the subscription canceled
+// by the server (see Options.Resubscribe).
+func (c *Client) handleServerError(serverErr *ServerCanceledError) {
+	c.lock.RLock()
+	sub, found := c.subscriptionByWSSubID[serverErr.SubscriptionID]
+	c.lock.RUnlock()
+	if !found {
+		c.log().Warn("unable to find subscription for ws error notification",
+			zap.Uint64("subscription_id", serverErr.SubscriptionID),
+			zap.Error(serverErr),
+		)
+		return
+	}
+
+	attempt := int(sub.resubscribes.Load())
+	c.log().Warn("ws subscription canceled by server",
+		zap.Uint64("request_id", sub.req.ID),
+		zap.String("label", c.label),
+		zap.String("tenant", sub.tenant),
+		zap.Int("resubscribes", attempt),
+		zap.Error(serverErr),
+	)
+	var err error = serverErr
+	if c.resubscribe != nil && c.resubscribe(sub, serverErr, attempt) {
+		resubErr := c.resubscribeCanceled(sub, serverErr.SubscriptionID)
+		if resubErr == nil {
+			return
+		}
+		err = fmt.Errorf("%v: resubscribe: %w", serverErr, resubErr)
+	}
+	c.failSubscription(sub, err)
+}
+
+// resubscribeCanceled sends again the subscribe request of a subscription
+// canceled by the server, keeping its request ID, so that the response
+// of the server confirms or fails the same Subscription.
+func (c *Client) resubscribeCanceled(sub *Subscription, subID uint64) error {
+	c.lock.Lock()
+	if c.subscriptionByRequestID[sub.req.ID] != sub || !sub.setPending() {
+		// Closed meanwhile.
+		c.lock.Unlock()
+		return nil
+	}
+	delete(c.subscriptionByWSSubID, subID)
+	sub.resubscribes.Add(1)
+	c.lock.Unlock()
+
+	data, err := sub.req.encode()
+	if err != nil {
+		return err
+	}
+	if err := c.send(websocket.TextMessage, data); err != nil {
+		return err
+	}
+	c.armHandshake(sub)
+	return nil
+}
+
+// failSubscription closes the subscription with err, without sending
+// an unsubscribe request, as the server already canceled it.
+func (c *Client) failSubscription(sub *Subscription, err error) {
+	c.lock.Lock()
+	if c.subscriptionByRequestID[sub.req.ID] != sub {
+		c.lock.Unlock()
+		return
+	}
+	sub.err <- err
+	delete(c.subscriptionByRequestID, sub.req.ID)
+	delete(c.subscriptionByWSSubID, sub.subID)
+	c.lock.Unlock()
+
+	sub.setClosed(err)
+}
+
+// Resubscribes returns the number of times the subscription was made again
+// after being canceled by the server (see Options.Resubscribe).
+func (s *Subscription) Resubscribes() int {
+	return int(s.resubscribes.Load())
+}
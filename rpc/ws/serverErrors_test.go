@@ -0,0 +1,97 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerCanceledSubscription(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	_, err = server.WaitForSubscription(ctx, "slotSubscribe")
+	require.NoError(t, err)
+
+	n, err := server.NotifyError("slotSubscribe", -32000, "subscription was cancelled: node restarted")
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+
+	_, err = sub.RecvWithContext(ctx)
+	require.ErrorIs(t, err, ErrCanceledByServer)
+	var serverErr *ServerCanceledError
+	require.ErrorAs(t, err, &serverErr)
+	require.Equal(t, -32000, serverErr.Code)
+	require.Equal(t, "subscription was cancelled: node restarted", serverErr.Message)
+	require.NotZero(t, serverErr.SubscriptionID)
+	require.Equal(t, SubscriptionClosed, sub.Subscription().State())
+}
+
+func TestServerCanceledSubscription_resubscribe(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), server.WSURL(), &Options{Resubscribe: ResubscribeUpTo(1)}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	firstID, err := server.WaitForSubscription(ctx, "slotSubscribe")
+	require.NoError(t, err)
+	require.NoError(t, waitSubscribed(ctx, sub.Subscription()))
+
+	_, err = server.NotifyError("slotSubscribe", -32000, "node restarted")
+	require.NoError(t, err)
+
+	// The subscription is made again, and keeps receiving notifications.
+	secondID, err := server.WaitForSubscription(ctx, "slotSubscribe")
+	require.NoError(t, err)
+	require.NotEqual(t, firstID, secondID)
+	require.Eventually(t, func() bool {
+		return sub.Subscription().SubscriptionID() == secondID
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 1, sub.Subscription().Resubscribes())
+
+	_, err = server.Notify("slotSubscribe", rpc.M{"parent": 1, "root": 0, "slot": 2})
+	require.NoError(t, err)
+	got, err := sub.RecvWithContext(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, got.Slot)
+
+	// The policy allows a single resubscription.
+	_, err = server.NotifyError("slotSubscribe", -32000, "node restarted")
+	require.NoError(t, err)
+	_, err = sub.RecvWithContext(ctx)
+	require.ErrorIs(t, err, ErrCanceledByServer)
+}
@@ -63,49 +63,17 @@ func (cl *Client) SignatureSubscribe(
 	}, nil
 }
 
-type SignatureSubscription struct {
-	sub *Subscription
-}
-
-func (sw *SignatureSubscription) Recv() (*SignatureResult, error) {
-	select {
-	case d := <-sw.sub.stream:
-		return d.(*SignatureResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
+// SignatureSubscribeWithContext is like SignatureSubscribe, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (cl *Client) SignatureSubscribeWithContext(
+	ctx context.Context,
+	signature solana.Signature,
+	commitment rpc.CommitmentType, // (optional)
+) (*SignatureSubscription, error) {
+	return cl.WithContext(ctx).SignatureSubscribe(signature, commitment)
 }
 
-func (sw *SignatureSubscription) Err() <-chan error {
-	return sw.sub.err
-}
-
-func (sw *SignatureSubscription) Response() <-chan *SignatureResult {
-	typedChan := make(chan *SignatureResult, 1)
-	go func(ch chan *SignatureResult) {
-		// TODO: will this subscription yield more than one result?
-		d, ok := <-sw.sub.stream
-		if !ok {
-			return
-		}
-		ch <- d.(*SignatureResult)
-	}(typedChan)
-	return typedChan
-}
+// SignatureSubscription is the subscription returned by SignatureSubscribe.
+type SignatureSubscription = TypedSubscription[SignatureResult]
 
 var ErrTimeout = fmt.Errorf("timeout waiting for confirmation")
-
-func (sw *SignatureSubscription) RecvWithContext(ctx context.Context) (*SignatureResult, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case d := <-sw.sub.stream:
-		return d.(*SignatureResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *SignatureSubscription) Unsubscribe() {
-	sw.sub.Unsubscribe()
-}
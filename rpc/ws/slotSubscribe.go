@@ -43,47 +43,11 @@ func (cl *Client) SlotSubscribe() (*SlotSubscription, error) {
 	}, nil
 }
 
-type SlotSubscription struct {
-	sub *Subscription
+// SlotSubscribeWithContext is like SlotSubscribe, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (cl *Client) SlotSubscribeWithContext(ctx context.Context) (*SlotSubscription, error) {
+	return cl.WithContext(ctx).SlotSubscribe()
 }
 
-func (sw *SlotSubscription) Recv() (*SlotResult, error) {
-	select {
-	case d := <-sw.sub.stream:
-		return d.(*SlotResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *SlotSubscription) RecvWithContext(ctx context.Context) (*SlotResult, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case d := <-sw.sub.stream:
-		return d.(*SlotResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *SlotSubscription) Err() <-chan error {
-	return sw.sub.err
-}
-
-func (sw *SlotSubscription) Response() <-chan *SlotResult {
-	typedChan := make(chan *SlotResult, 1)
-	go func(ch chan *SlotResult) {
-		// TODO: will this subscription yield more than one result?
-		d, ok := <-sw.sub.stream
-		if !ok {
-			return
-		}
-		ch <- d.(*SlotResult)
-	}(typedChan)
-	return typedChan
-}
-
-func (sw *SlotSubscription) Unsubscribe() {
-	sw.sub.Unsubscribe()
-}
+// SlotSubscription is the subscription returned by SlotSubscribe.
+type SlotSubscription = TypedSubscription[SlotResult]
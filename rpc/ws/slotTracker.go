@@ -0,0 +1,161 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// DefaultSlotTrackerHistory is the number of slots, behind the latest one,
+// for which a SlotTracker remembers first shred times and dead slots.
+var DefaultSlotTrackerHistory = uint64(1024)
+
+// SlotTracker maintains the latest processed, confirmed and finalized slots,
+// and the time at which the first shred of each slot was received,
+// from the updates of a slotsUpdatesSubscribe subscription.
+//
+// A slot is considered processed once its bank is frozen, confirmed once
+// it is optimistically confirmed, and finalized once it is rooted.
+type SlotTracker struct {
+	history uint64
+
+	lock        sync.RWMutex
+	firstShred  uint64
+	processed   uint64
+	confirmed   uint64
+	finalized   uint64
+	firstShreds map[uint64]time.Time
+	dead        map[uint64]string
+}
+
+// NewSlotTracker creates a new SlotTracker remembering first shred times
+// and dead slots for history slots; zero means DefaultSlotTrackerHistory.
+func NewSlotTracker(history uint64) *SlotTracker {
+	if history == 0 {
+		history = DefaultSlotTrackerHistory
+	}
+	return &SlotTracker{
+		history:     history,
+		firstShreds: map[uint64]time.Time{},
+		dead:        map[uint64]string{},
+	}
+}
+
+// Run applies the updates of the subscription until ctx is done
+// or the subscription fails, and returns the corresponding error.
+func (t *SlotTracker) Run(ctx context.Context, sub *SlotsUpdatesSubscription) error {
+	for {
+		update, err := sub.RecvWithContext(ctx)
+		if err != nil {
+			return err
+		}
+		t.Update(update)
+	}
+}
+
+// Update applies a slots update.
+func (t *SlotTracker) Update(update *SlotsUpdatesResult) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch update.Type {
+	case SlotsUpdatesFirstShredReceived:
+		if _, ok := t.firstShreds[update.Slot]; !ok {
+			received := time.Now()
+			if update.Timestamp != nil {
+				received = update.Timestamp.Time()
+			}
+			t.firstShreds[update.Slot] = received
+		}
+		if update.Slot > t.firstShred {
+			t.firstShred = update.Slot
+			t.prune()
+		}
+	case SlotsUpdatesFrozen:
+		if update.Slot > t.processed {
+			t.processed = update.Slot
+		}
+	case SlotsUpdatesOptimisticConfirmation:
+		if update.Slot > t.confirmed {
+			t.confirmed = update.Slot
+		}
+	case SlotsUpdatesRoot:
+		if update.Slot > t.finalized {
+			t.finalized = update.Slot
+		}
+	case SlotsUpdatesDead:
+		t.dead[update.Slot] = update.Err
+	}
+}
+
+// prune forgets the slots older than the history; the lock must be held.
+func (t *SlotTracker) prune() {
+	if t.firstShred < t.history {
+		return
+	}
+	oldest := t.firstShred - t.history
+	for slot := range t.firstShreds {
+		if slot < oldest {
+			delete(t.firstShreds, slot)
+		}
+	}
+	for slot := range t.dead {
+		if slot < oldest {
+			delete(t.dead, slot)
+		}
+	}
+}
+
+// FirstShred returns the latest slot of which a shred was received.
+func (t *SlotTracker) FirstShred() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.firstShred
+}
+
+// Slot returns the latest slot reaching the provided commitment.
+func (t *SlotTracker) Slot(commitment rpc.CommitmentType) uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	switch commitment {
+	case rpc.CommitmentProcessed, rpc.CommitmentRecent:
+		return t.processed
+	case rpc.CommitmentFinalized, rpc.CommitmentMax, rpc.CommitmentRoot:
+		return t.finalized
+	default:
+		return t.confirmed
+	}
+}
+
+// FirstShredReceived returns the time at which the first shred
+// of the slot was received, if it is known.
+func (t *SlotTracker) FirstShredReceived(slot uint64) (time.Time, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	received, ok := t.firstShreds[slot]
+	return received, ok
+}
+
+// Dead reports whether the slot was marked dead, and the reason.
+func (t *SlotTracker) Dead(slot uint64) (string, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	reason, ok := t.dead[slot]
+	return reason, ok
+}
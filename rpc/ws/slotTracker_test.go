@@ -0,0 +1,91 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlotTracker(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.SlotsUpdatesSubscribe()
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = server.WaitForSubscription(ctx, "slotsUpdatesSubscribe")
+	require.NoError(t, err)
+
+	for _, update := range []string{
+		`{"slot":100,"timestamp":1700000000000,"type":"firstShredReceived"}`,
+		`{"slot":101,"timestamp":1700000000400,"type":"firstShredReceived"}`,
+		`{"parent":99,"slot":100,"timestamp":1700000000300,"type":"createdBank"}`,
+		`{"slot":100,"timestamp":1700000000500,"type":"frozen","stats":{"numTransactionEntries":1,"numSuccessfulTransactions":2,"numFailedTransactions":0,"maxTransactionsPerEntry":2}}`,
+		`{"slot":98,"timestamp":1700000000600,"type":"optimisticConfirmation"}`,
+		`{"slot":60,"timestamp":1700000000700,"type":"root"}`,
+		`{"slot":97,"timestamp":1700000000800,"type":"dead","err":"shred insert error"}`,
+		`{"slot":95,"timestamp":1700000000900,"type":"optimisticConfirmation"}`,
+	} {
+		_, err := server.Notify("slotsUpdatesSubscribe", stdjson.RawMessage(update))
+		require.NoError(t, err)
+	}
+
+	tracker := NewSlotTracker(0)
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- tracker.Run(runCtx, sub) }()
+	require.Eventually(t, func() bool {
+		_, dead := tracker.Dead(97)
+		return dead && tracker.Slot(rpc.CommitmentConfirmed) == 98
+	}, 5*time.Second, 5*time.Millisecond)
+	stop()
+	require.ErrorIs(t, <-done, context.Canceled)
+
+	require.Equal(t, uint64(101), tracker.FirstShred())
+	require.Equal(t, uint64(100), tracker.Slot(rpc.CommitmentProcessed))
+	require.Equal(t, uint64(98), tracker.Slot(rpc.CommitmentConfirmed))
+	require.Equal(t, uint64(60), tracker.Slot(rpc.CommitmentFinalized))
+
+	received, ok := tracker.FirstShredReceived(100)
+	require.True(t, ok)
+	require.Equal(t, int64(1700000000000), received.UnixMilli())
+	reason, _ := tracker.Dead(97)
+	require.Equal(t, "shred insert error", reason)
+}
+
+func TestSlotTracker_history(t *testing.T) {
+	tracker := NewSlotTracker(10)
+	for slot := uint64(1); slot <= 30; slot++ {
+		tracker.Update(&SlotsUpdatesResult{Slot: slot, Type: SlotsUpdatesFirstShredReceived})
+	}
+	_, ok := tracker.FirstShredReceived(19)
+	require.False(t, ok)
+	_, ok = tracker.FirstShredReceived(20)
+	require.True(t, ok)
+}
@@ -31,6 +31,8 @@ type SlotsUpdatesResult struct {
 	Type SlotsUpdatesType `json:"type"`
 	// Extra stats provided when a bank is frozen.
 	Stats *BankStats `json:"stats"`
+	// The error that made the slot dead.
+	Err string `json:"err,omitempty"`
 }
 
 type BankStats struct {
@@ -77,47 +79,11 @@ func (cl *Client) SlotsUpdatesSubscribe() (*SlotsUpdatesSubscription, error) {
 	}, nil
 }
 
-type SlotsUpdatesSubscription struct {
-	sub *Subscription
+// SlotsUpdatesSubscribeWithContext is like SlotsUpdatesSubscribe, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (cl *Client) SlotsUpdatesSubscribeWithContext(ctx context.Context) (*SlotsUpdatesSubscription, error) {
+	return cl.WithContext(ctx).SlotsUpdatesSubscribe()
 }
 
-func (sw *SlotsUpdatesSubscription) Recv() (*SlotsUpdatesResult, error) {
-	select {
-	case d := <-sw.sub.stream:
-		return d.(*SlotsUpdatesResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *SlotsUpdatesSubscription) RecvWithContext(ctx context.Context) (*SlotsUpdatesResult, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case d := <-sw.sub.stream:
-		return d.(*SlotsUpdatesResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *SlotsUpdatesSubscription) Err() <-chan error {
-	return sw.sub.err
-}
-
-func (sw *SlotsUpdatesSubscription) Response() <-chan *SlotsUpdatesResult {
-	typedChan := make(chan *SlotsUpdatesResult, 1)
-	go func(ch chan *SlotsUpdatesResult) {
-		// TODO: will this subscription yield more than one result?
-		d, ok := <-sw.sub.stream
-		if !ok {
-			return
-		}
-		ch <- d.(*SlotsUpdatesResult)
-	}(typedChan)
-	return typedChan
-}
-
-func (sw *SlotsUpdatesSubscription) Unsubscribe() {
-	sw.sub.Unsubscribe()
-}
+// SlotsUpdatesSubscription is the subscription returned by SlotsUpdatesSubscribe.
+type SlotsUpdatesSubscription = TypedSubscription[SlotsUpdatesResult]
@@ -0,0 +1,236 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+// AccountUpdate is the state of an account at a slot.
+type AccountUpdate struct {
+	Slot uint64
+	// Account is nil if the account doesn't exist.
+	Account *rpc.Account
+	// Snapshot is true for a state fetched with getMultipleAccounts,
+	// and false for a notification.
+	Snapshot bool
+}
+
+// ProgramUpdate is the state of accounts of a program at a slot:
+// all the accounts of the program for a snapshot,
+// or the account changed for a notification.
+type ProgramUpdate struct {
+	Slot     uint64
+	Accounts []*rpc.KeyedAccount
+	// Snapshot is true for a state fetched with getProgramAccounts,
+	// and false for a notification.
+	Snapshot bool
+}
+
+// GetAccountInfoAndSubscribe subscribes to the account and, once the
+// subscription is confirmed, fetches its current state with rpcClient.
+// The returned subscription delivers that snapshot first, then the
+// notifications of later slots, so that no change is missed or applied
+// out of order (see SnapshotSubscription).
+func (cl *Client) GetAccountInfoAndSubscribe(
+	ctx context.Context,
+	rpcClient *rpc.Client,
+	account solana.PublicKey,
+	commitment rpc.CommitmentType, // (optional)
+	encoding solana.EncodingType, // (optional)
+) (*SnapshotSubscription[*AccountUpdate], error) {
+	sub, err := cl.AccountSubscribeWithOpts(account, commitment, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if encoding == "" {
+		encoding = solana.EncodingBase64
+	}
+	return newSnapshotSubscription(ctx, sub.sub,
+		func(ctx context.Context, minSlot uint64) (*AccountUpdate, uint64, error) {
+			opts := &rpc.GetMultipleAccountsOpts{
+				Commitment: commitment,
+				Encoding:   encoding,
+			}
+			if minSlot > 0 {
+				opts.MinContextSlot = &minSlot
+			}
+			out, err := rpcClient.GetMultipleAccountsWithOpts(ctx, []solana.PublicKey{account}, opts)
+			if err != nil {
+				return nil, 0, err
+			}
+			update := &AccountUpdate{Slot: out.Context.Slot, Snapshot: true}
+			if len(out.Value) > 0 {
+				update.Account = out.Value[0]
+			}
+			return update, update.Slot, nil
+		},
+		func(value interface{}) (*AccountUpdate, uint64, error) {
+			res, err := typedNotification[AccountResult](value)
+			if err != nil {
+				return nil, 0, err
+			}
+			return &AccountUpdate{Slot: res.Context.Slot, Account: &res.Value.Account}, res.Context.Slot, nil
+		},
+	)
+}
+
+// GetProgramAccountsAndSubscribe subscribes to the accounts of the program
+// matching the filters and, once the subscription is confirmed, fetches
+// their current state with rpcClient. The returned subscription delivers
+// that snapshot first, then the notifications of later slots
+// (see SnapshotSubscription).
+func (cl *Client) GetProgramAccountsAndSubscribe(
+	ctx context.Context,
+	rpcClient *rpc.Client,
+	programID solana.PublicKey,
+	commitment rpc.CommitmentType, // (optional)
+	encoding solana.EncodingType, // (optional)
+	filters []rpc.RPCFilter, // (optional)
+) (*SnapshotSubscription[*ProgramUpdate], error) {
+	sub, err := cl.ProgramSubscribeWithOpts(programID, commitment, encoding, filters)
+	if err != nil {
+		return nil, err
+	}
+	return newSnapshotSubscription(ctx, sub.sub,
+		func(ctx context.Context, minSlot uint64) (*ProgramUpdate, uint64, error) {
+			opts := &rpc.GetProgramAccountsOpts{
+				Commitment: commitment,
+				Encoding:   encoding,
+				Filters:    filters,
+			}
+			if minSlot > 0 {
+				opts.MinContextSlot = &minSlot
+			}
+			out, err := rpcClient.GetProgramAccountsWithContext(ctx, programID, opts)
+			if err != nil {
+				return nil, 0, err
+			}
+			return &ProgramUpdate{Slot: out.Context.Slot, Accounts: out.Value, Snapshot: true}, out.Context.Slot, nil
+		},
+		func(value interface{}) (*ProgramUpdate, uint64, error) {
+			res, err := typedNotification[ProgramResult](value)
+			if err != nil {
+				return nil, 0, err
+			}
+			return &ProgramUpdate{Slot: res.Context.Slot, Accounts: []*rpc.KeyedAccount{&res.Value}}, res.Context.Slot, nil
+		},
+	)
+}
+
+// SnapshotSubscription delivers the state fetched when subscribing,
+// then the notifications of the slots after it; notifications of
+// the slot of the snapshot or before are dropped, as the snapshot
+// already includes them.
+//
+// When the subscription is made again after being canceled by the server
+// (see Options.Resubscribe), the changes made in between are not notified:
+// the state is fetched again on the next notification, and delivered
+// in its place.
+type SnapshotSubscription[T any] struct {
+	sub     *Subscription
+	fetch   func(ctx context.Context, minSlot uint64) (T, uint64, error)
+	convert func(value interface{}) (T, uint64, error)
+
+	pending      []T
+	lastSlot     uint64
+	resubscribes int
+}
+
+func newSnapshotSubscription[T any](
+	ctx context.Context,
+	sub *Subscription,
+	fetch func(ctx context.Context, minSlot uint64) (T, uint64, error),
+	convert func(value interface{}) (T, uint64, error),
+) (*SnapshotSubscription[T], error) {
+	if err := waitSubscribed(ctx, sub); err != nil {
+		sub.Unsubscribe()
+		return nil, fmt.Errorf("subscribe: %w", err)
+	}
+	s := &SnapshotSubscription[T]{
+		sub:          sub,
+		fetch:        fetch,
+		convert:      convert,
+		resubscribes: sub.Resubscribes(),
+	}
+	if err := s.snapshot(ctx, 0); err != nil {
+		sub.Unsubscribe()
+		return nil, err
+	}
+	return s, nil
+}
+
+// snapshot fetches the state, at minSlot or later, and queues it for delivery.
+func (s *SnapshotSubscription[T]) snapshot(ctx context.Context, minSlot uint64) error {
+	state, slot, err := s.fetch(ctx, minSlot)
+	if err != nil {
+		return fmt.Errorf("fetch snapshot: %w", err)
+	}
+	if slot > s.lastSlot {
+		s.lastSlot = slot
+	}
+	s.pending = append(s.pending, state)
+	return nil
+}
+
+// Recv waits for the next update. It must not be called concurrently.
+// It returns ErrRawNotification for the notifications of a subscription
+// made through a raw view of the client (see Client.WithRawNotifications).
+func (s *SnapshotSubscription[T]) Recv(ctx context.Context) (T, error) {
+	var zero T
+	for {
+		if len(s.pending) > 0 {
+			update := s.pending[0]
+			s.pending = s.pending[1:]
+			return update, nil
+		}
+		value, err := s.sub.RecvWithContext(ctx)
+		if err != nil {
+			return zero, err
+		}
+		update, slot, err := s.convert(value)
+		if err != nil {
+			return zero, err
+		}
+		if n := s.sub.Resubscribes(); n != s.resubscribes {
+			// The changes made before the subscription was made again
+			// were not notified: fetch the state including them.
+			if err := s.snapshot(ctx, slot); err != nil {
+				return zero, err
+			}
+			s.resubscribes = n
+			continue
+		}
+		if slot <= s.lastSlot {
+			continue
+		}
+		s.lastSlot = slot
+		return update, nil
+	}
+}
+
+// Unsubscribe unsubscribes from the notifications.
+func (s *SnapshotSubscription[T]) Unsubscribe() {
+	s.sub.Unsubscribe()
+}
+
+// Subscription returns the underlying subscription.
+func (s *SnapshotSubscription[T]) Subscription() *Subscription {
+	return s.sub
+}
@@ -0,0 +1,181 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func accountNotification(slot, lamports uint64) rpc.M {
+	return rpc.M{
+		"context": rpc.M{"slot": slot},
+		"value":   rpc.M{"lamports": lamports, "owner": solana.SystemProgramID.String(), "data": []string{"", "base64"}},
+	}
+}
+
+func TestGetAccountInfoAndSubscribe(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	var snapshotSlot atomic.Uint64
+	snapshotSlot.Store(10)
+	server.HandleFunc("getMultipleAccounts", func(params stdjson.RawMessage) (interface{}, error) {
+		slot := snapshotSlot.Load()
+		return rpc.M{
+			"context": rpc.M{"slot": slot},
+			"value":   []interface{}{accountNotification(slot, slot)["value"]},
+		}, nil
+	})
+
+	c, err := ConnectWithOptions(context.Background(), server.WSURL(), &Options{Resubscribe: ResubscribeUpTo(1)}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := c.GetAccountInfoAndSubscribe(ctx, rpc.New(server.URL()), solana.SystemProgramID, rpc.CommitmentConfirmed, "")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	// Notifications up to the slot of the snapshot are dropped.
+	_, err = server.Notify("accountSubscribe", accountNotification(9, 9))
+	require.NoError(t, err)
+	_, err = server.Notify("accountSubscribe", accountNotification(10, 10))
+	require.NoError(t, err)
+	_, err = server.Notify("accountSubscribe", accountNotification(11, 11))
+	require.NoError(t, err)
+
+	update, err := sub.Recv(ctx)
+	require.NoError(t, err)
+	require.True(t, update.Snapshot)
+	require.EqualValues(t, 10, update.Slot)
+	require.EqualValues(t, 10, update.Account.Lamports)
+
+	update, err = sub.Recv(ctx)
+	require.NoError(t, err)
+	require.False(t, update.Snapshot)
+	require.EqualValues(t, 11, update.Slot)
+	require.EqualValues(t, 11, update.Account.Lamports)
+
+	// Once made again, the subscription fetches the state it may have missed.
+	_, err = server.NotifyError("accountSubscribe", -32000, "node restarted")
+	require.NoError(t, err)
+	_, err = server.WaitForSubscription(ctx, "accountSubscribe")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return sub.Subscription().State() == SubscriptionActive
+	}, 5*time.Second, 10*time.Millisecond)
+	snapshotSlot.Store(20)
+	_, err = server.Notify("accountSubscribe", accountNotification(15, 15))
+	require.NoError(t, err)
+	_, err = server.Notify("accountSubscribe", accountNotification(21, 21))
+	require.NoError(t, err)
+
+	update, err = sub.Recv(ctx)
+	require.NoError(t, err)
+	require.True(t, update.Snapshot)
+	require.EqualValues(t, 20, update.Slot)
+	requests := server.Requests("getMultipleAccounts")
+	require.Len(t, requests, 2)
+	require.Contains(t, string(requests[1].Params), `"minContextSlot":15`)
+
+	update, err = sub.Recv(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 21, update.Slot)
+}
+
+func TestGetProgramAccountsAndSubscribe(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	account := solana.NewWallet().PublicKey()
+	server.Handle("getProgramAccounts", rpc.M{
+		"context": rpc.M{"slot": 10},
+		"value": []rpc.M{{
+			"pubkey":  account.String(),
+			"account": accountNotification(10, 10)["value"],
+		}},
+	})
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := c.GetProgramAccountsAndSubscribe(ctx, rpc.New(server.URL()), solana.SystemProgramID, "", "", nil)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	require.Contains(t, string(server.Requests("getProgramAccounts")[0].Params), `"withContext":true`)
+
+	for _, slot := range []uint64{10, 12} {
+		_, err = server.Notify("programSubscribe", rpc.M{
+			"context": rpc.M{"slot": slot},
+			"value":   rpc.M{"pubkey": account.String(), "account": accountNotification(slot, slot)["value"]},
+		})
+		require.NoError(t, err)
+	}
+
+	update, err := sub.Recv(ctx)
+	require.NoError(t, err)
+	require.True(t, update.Snapshot)
+	require.Len(t, update.Accounts, 1)
+	require.Equal(t, account, update.Accounts[0].Pubkey)
+
+	update, err = sub.Recv(ctx)
+	require.NoError(t, err)
+	require.False(t, update.Snapshot)
+	require.EqualValues(t, 12, update.Slot)
+	require.EqualValues(t, 12, update.Accounts[0].Account.Lamports)
+}
+
+func TestGetAccountInfoAndSubscribe_rawView(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+	server.Handle("getMultipleAccounts", rpc.M{
+		"context": rpc.M{"slot": 10},
+		"value":   []interface{}{accountNotification(10, 10)["value"]},
+	})
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := c.WithRawNotifications(RawCopy).GetAccountInfoAndSubscribe(ctx, rpc.New(server.URL()), solana.SystemProgramID, "", "")
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	update, err := sub.Recv(ctx)
+	require.NoError(t, err)
+	require.True(t, update.Snapshot)
+
+	// The notifications of a raw view are not decoded: Recv fails instead of panicking.
+	_, err = server.Notify("accountSubscribe", accountNotification(11, 11))
+	require.NoError(t, err)
+	_, err = sub.Recv(ctx)
+	require.ErrorIs(t, err, ErrRawNotification)
+}
@@ -0,0 +1,140 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrSubscribeTimeout is matched by the error of a subscription whose
+// subscribe request was not acknowledged by the server in time
+// (see Options.SubscribeTimeout).
+var ErrSubscribeTimeout = errors.New("subscribe: no acknowledgement from server")
+
+// SubscribeTimeoutError fails a subscription whose subscribe request
+// was not acknowledged by the server within the subscribe timeout.
+type SubscribeTimeoutError struct {
+	// Subscribe method, e.g. "accountSubscribe".
+	Method string
+	// ID of the subscribe request.
+	RequestID uint64
+	Timeout   time.Duration
+}
+
+func (e *SubscribeTimeoutError) Error() string {
+	return fmt.Sprintf("subscribe: %s request %d not acknowledged by server within %s", e.Method, e.RequestID, e.Timeout)
+}
+
+func (e *SubscribeTimeoutError) Is(target error) bool {
+	return target == ErrSubscribeTimeout
+}
+
+// WithSubscribeTimeout returns a view of the client that shares its connection,
+// and whose subscriptions fail with a *SubscribeTimeoutError if the server
+// doesn't acknowledge their subscribe request within timeout,
+// overriding Options.SubscribeTimeout; a negative timeout disables it.
+//
+// Closing the returned client closes the shared connection.
+func (c *Client) WithSubscribeTimeout(timeout time.Duration) *Client {
+	return &Client{
+		connection:       c.connection,
+		tenant:           c.tenant,
+		ctx:              c.ctx,
+		raw:              c.raw,
+		subscribeTimeout: timeout,
+	}
+}
+
+// handshakeTimeout returns the subscribe timeout of the subscriptions
+// created through the client, or zero if there is none.
+func (c *Client) handshakeTimeout() time.Duration {
+	switch {
+	case c.subscribeTimeout > 0:
+		return c.subscribeTimeout
+	case c.subscribeTimeout < 0:
+		return 0
+	}
+	return c.connection.subscribeTimeout
+}
+
+// armHandshake starts the subscribe timeout of the subscription,
+// if it has one and is still waiting for the confirmation of the server.
+// The timer is stopped when the subscription is confirmed or closed.
+func (c *Client) armHandshake(sub *Subscription) {
+	if sub.handshakeTimeout <= 0 {
+		return
+	}
+	sub.lifecycle.Lock()
+	defer sub.lifecycle.Unlock()
+	if sub.state != SubscriptionPending {
+		return
+	}
+	sub.handshakeTimer = time.AfterFunc(sub.handshakeTimeout, func() {
+		c.expireHandshake(sub)
+	})
+}
+
+// expireHandshake fails the subscription if it is still waiting
+// for the confirmation of the server, and removes its pending entry.
+// A confirmation received afterwards is answered with an unsubscribe request.
+func (c *Client) expireHandshake(sub *Subscription) {
+	c.lock.Lock()
+	if c.subscriptionByRequestID[sub.req.ID] != sub || sub.State() != SubscriptionPending {
+		c.lock.Unlock()
+		return
+	}
+	err := &SubscribeTimeoutError{
+		Method:    sub.method,
+		RequestID: sub.req.ID,
+		Timeout:   sub.handshakeTimeout,
+	}
+	delete(c.subscriptionByRequestID, sub.req.ID)
+	c.expiredSubscribes[sub.req.ID] = sub.unsubscribeMethod
+	sub.err <- err
+	c.lock.Unlock()
+
+	c.log().Warn("subscribe request not acknowledged in time",
+		zap.String("method", sub.method),
+		zap.Uint64("request_id", sub.req.ID),
+		zap.Duration("timeout", sub.handshakeTimeout),
+		zap.String("label", c.label),
+		zap.String("tenant", sub.tenant),
+	)
+	sub.setClosed(err)
+}
+
+// cancelExpiredSubscribe returns the hook unsubscribing from the subscription
+// confirmed by the server after its subscribe timeout, or nil if the request
+// did not expire. The lock must be held.
+func (c *Client) cancelExpiredSubscribe(requestID, subID uint64) (hooks func()) {
+	method, found := c.expiredSubscribes[requestID]
+	if !found {
+		return nil
+	}
+	delete(c.expiredSubscribes, requestID)
+	return func() {
+		if _, err := c.unsubscribe(subID, method); err != nil {
+			c.log().Warn("unable to unsubscribe from late confirmed subscription",
+				zap.Uint64("request_id", requestID),
+				zap.Uint64("subscription_id", subID),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// newSlowConfirmServer confirms the subscribe requests once release is closed,
+// and forwards the unsubscribe requests it receives to unsubscribes.
+func newSlowConfirmServer(t *testing.T, release <-chan struct{}, unsubscribes chan<- request) string {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var writeLock sync.Mutex
+		write := func(msg string) {
+			writeLock.Lock()
+			defer writeLock.Unlock()
+			conn.WriteMessage(websocket.TextMessage, []byte(msg))
+		}
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in request
+			if err := stdjson.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			if strings.HasSuffix(in.Method, "Unsubscribe") {
+				unsubscribes <- in
+				write(fmt.Sprintf(`{"jsonrpc":"2.0","result":true,"id":%d}`, in.ID))
+				continue
+			}
+			go func(id uint64) {
+				<-release
+				write(fmt.Sprintf(`{"jsonrpc":"2.0","result":%d,"id":%d}`, id+100, id))
+			}(in.ID)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func TestSubscribeTimeout(t *testing.T) {
+	release := make(chan struct{})
+	unsubscribes := make(chan request, 1)
+	url := newSlowConfirmServer(t, release, unsubscribes)
+	c, err := ConnectWithOptions(context.Background(), url, &Options{SubscribeTimeout: 50 * time.Millisecond}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	// The timeout is disabled for the subscriptions of the view.
+	untimed, err := c.WithSubscribeTimeout(-1).RootSubscribe()
+	require.NoError(t, err)
+
+	_, err = sub.RecvWithContext(ctx)
+	require.ErrorIs(t, err, ErrSubscribeTimeout)
+	var timeoutErr *SubscribeTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	require.Equal(t, "slotSubscribe", timeoutErr.Method)
+	require.EqualValues(t, 1, timeoutErr.RequestID)
+	require.Equal(t, 50*time.Millisecond, timeoutErr.Timeout)
+	require.Equal(t, SubscriptionClosed, sub.Subscription().State())
+
+	// Only the pending entry of the timed out subscription was removed.
+	infos := c.Subscriptions()
+	require.Len(t, infos, 1)
+	require.Equal(t, "rootSubscribe", infos[0].Method)
+	require.Equal(t, SubscriptionPending, untimed.Subscription().State())
+
+	// The late confirmation is answered with an unsubscribe request.
+	close(release)
+	select {
+	case unsub := <-unsubscribes:
+		require.Equal(t, "slotUnsubscribe", unsub.Method)
+		require.Equal(t, []interface{}{float64(101)}, unsub.Params)
+	case <-ctx.Done():
+		t.Fatal("no unsubscribe request received")
+	}
+	require.NoError(t, waitSubscribed(ctx, untimed.Subscription()))
+}
@@ -17,32 +17,97 @@
 
 package ws
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SubscriptionState is the state of a Subscription.
+type SubscriptionState int32
+
+const (
+	// SubscriptionPending is the state of a subscription
+	// waiting for the confirmation of the server.
+	SubscriptionPending SubscriptionState = iota
+	// SubscriptionActive is the state of a subscription
+	// confirmed by the server.
+	SubscriptionActive
+	// SubscriptionClosed is the state of a subscription that was
+	// unsubscribed or that failed; it doesn't receive notifications anymore.
+	SubscriptionClosed
+)
+
+func (s SubscriptionState) String() string {
+	switch s {
+	case SubscriptionPending:
+		return "pending"
+	case SubscriptionActive:
+		return "active"
+	case SubscriptionClosed:
+		return "closed"
+	default:
+		return fmt.Sprintf("SubscriptionState(%d)", int32(s))
+	}
+}
 
 type Subscription struct {
 	req               *request
 	subID             uint64
 	stream            chan result
 	err               chan error
-	closeFunc         func(err error)
+	closeFunc         func(err error) (ack <-chan error)
+	done              chan struct{}
 	unsubscribeMethod string
 	decoderFunc       decoderFunc
+
+	method        string
+	tenant        string
+	notifications atomic.Uint64
+	bytes         atomic.Uint64
+	drops         atomic.Uint64
+	backpressure  atomic.Int32
+	lastMessage   atomic.Int64 // unix nanoseconds
+	maxSize       atomic.Int64 // overrides Options.MaxNotificationSize if not zero
+	resubscribes  atomic.Int32
+
+	// lifecycle protects the state, the hooks and the reads of subID
+	// outside of the client lock.
+	lifecycle    sync.Mutex
+	state        SubscriptionState
+	closeErr     error
+	onSubscribed []func(subID uint64)
+	onError      []func(err error)
+	onClosed     []func(err error)
+
+	handshakeTimeout time.Duration
+	handshakeTimer   *time.Timer // protected by lifecycle
 }
 
+// decoderFunc decodes a notification message. It returns errDiscardNotification
+// if the notification must not be delivered to the subscriber.
 type decoderFunc func([]byte) (interface{}, error)
 
+// errDiscardNotification is returned by a decoderFunc to silently drop a notification.
+var errDiscardNotification = errors.New("discard notification")
+
 func newSubscription(
 	req *request,
-	closeFunc func(err error),
+	closeFunc func(err error) (ack <-chan error),
 	unsubscribeMethod string,
 	decoderFunc decoderFunc,
+	bufferSize int,
 ) *Subscription {
 	return &Subscription{
 		req:               req,
 		subID:             0,
-		stream:            make(chan result, 200_000),
+		stream:            make(chan result, bufferSize),
 		err:               make(chan error, 100_000),
 		closeFunc:         closeFunc,
+		done:              make(chan struct{}),
 		unsubscribeMethod: unsubscribeMethod,
 		decoderFunc:       decoderFunc,
 	}
@@ -51,7 +116,7 @@ func newSubscription(
 func (s *Subscription) Recv() (interface{}, error) {
 	select {
 	case d := <-s.stream:
-		return d, nil
+		return d.value, nil
 	case err := <-s.err:
 		return nil, err
 	}
@@ -63,8 +128,275 @@ func (s *Subscription) Unsubscribe() {
 	s.unsubscribe(ErrCanceled)
 }
 
-func (s *Subscription) unsubscribe(err error) {
-	s.closeFunc(err)
+// contextCanceledError closes a subscription whose context is done;
+// it matches both ErrCanceled and the error of the context.
+type contextCanceledError struct {
+	err error
+}
+
+func (e *contextCanceledError) Error() string {
+	return fmt.Sprintf("subscription canceled by context: %s", e.err)
+}
+
+func (e *contextCanceledError) Is(target error) bool {
+	return target == ErrCanceled
+}
+
+func (e *contextCanceledError) Unwrap() error {
+	return e.err
+}
+
+// unsubscribeOnDone unsubscribes when ctx is done,
+// unless the subscription is closed first.
+func (s *Subscription) unsubscribeOnDone(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+		s.unsubscribe(&contextCanceledError{err: ctx.Err()})
+	case <-s.done:
+	}
+}
+
+// Done returns a channel that is closed when the subscription is closed.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// ErrUnsubscribeRejected is returned by UnsubscribeWithContext
+// when the server answers the unsubscribe request with false.
+var ErrUnsubscribeRejected = errors.New("unsubscribe rejected by server")
+
+// UnsubscribeWithContext unsubscribes, and waits until ctx is done
+// for the server to acknowledge the unsubscribe request.
+//
+// Notifications received before the acknowledgement are discarded,
+// and those queued but not yet received are dropped before waiting,
+// so that the next Recv returns ErrCanceled (unless another goroutine
+// is receiving concurrently).
+//
+// It returns the error of the server if the request is rejected,
+// or an error wrapping ctx.Err() if ctx is done before the acknowledgement;
+// the subscription is closed in any case.
+// It returns nil if the subscription was already closed,
+// or was not confirmed by the server yet.
+func (s *Subscription) UnsubscribeWithContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	acks := make(chan (<-chan error), 1)
+	go func() {
+		acks <- s.unsubscribe(ErrCanceled)
+	}()
+	var ack <-chan error
+	select {
+	case ack = <-acks:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	s.discardQueued()
+	if ack == nil {
+		return nil
+	}
+	select {
+	case err := <-ack:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("unsubscribe: no acknowledgement from server: %w", ctx.Err())
+	}
+}
+
+// discardQueued drops the notifications queued in the stream.
+func (s *Subscription) discardQueued() {
+	for {
+		select {
+		case <-s.stream:
+		default:
+			return
+		}
+	}
+}
+
+// OnSubscribed registers fn to be called with the subscription ID
+// when the server confirms the subscription; fn is called immediately
+// if the subscription is already confirmed.
+// The hooks are called from the read loop of the client and must not block.
+func (s *Subscription) OnSubscribed(fn func(subID uint64)) *Subscription {
+	s.lifecycle.Lock()
+	if s.state == SubscriptionPending {
+		s.onSubscribed = append(s.onSubscribed, fn)
+		s.lifecycle.Unlock()
+		return s
+	}
+	subID := s.subID
+	s.lifecycle.Unlock()
+	if subID != 0 {
+		fn(subID)
+	}
+	return s
+}
+
+// OnError registers fn to be called with the error that ended the subscription,
+// e.g. a connection failure or a subscribe request rejected by the server;
+// it is not called when the subscription is canceled with Unsubscribe.
+// fn is called immediately if the subscription already failed.
+// The hooks are called from the read loop of the client and must not block.
+func (s *Subscription) OnError(fn func(err error)) *Subscription {
+	s.lifecycle.Lock()
+	if s.state != SubscriptionClosed {
+		s.onError = append(s.onError, fn)
+		s.lifecycle.Unlock()
+		return s
+	}
+	err := s.closeErr
+	s.lifecycle.Unlock()
+	if !errors.Is(err, ErrCanceled) {
+		fn(err)
+	}
+	return s
+}
+
+// OnClosed registers fn to be called with the reason of the closure
+// (ErrCanceled after Unsubscribe) when the subscription is closed;
+// fn is called immediately if the subscription is already closed.
+// The hooks are called from the read loop of the client and must not block.
+func (s *Subscription) OnClosed(fn func(err error)) *Subscription {
+	s.lifecycle.Lock()
+	if s.state != SubscriptionClosed {
+		s.onClosed = append(s.onClosed, fn)
+		s.lifecycle.Unlock()
+		return s
+	}
+	err := s.closeErr
+	s.lifecycle.Unlock()
+	fn(err)
+	return s
+}
+
+// State returns the state of the subscription.
+func (s *Subscription) State() SubscriptionState {
+	s.lifecycle.Lock()
+	defer s.lifecycle.Unlock()
+	return s.state
+}
+
+// SubscriptionID returns the ID assigned by the server to the subscription,
+// or zero if the subscription is not confirmed yet.
+func (s *Subscription) SubscriptionID() uint64 {
+	s.lifecycle.Lock()
+	defer s.lifecycle.Unlock()
+	return s.subID
+}
+
+// Notifications returns the number of notifications delivered to the subscriber.
+func (s *Subscription) Notifications() uint64 {
+	return s.notifications.Load()
+}
+
+// LastMessageTime returns the time of the last notification received
+// for the subscription, or the zero time if none was received.
+func (s *Subscription) LastMessageTime() time.Time {
+	nanos := s.lastMessage.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// Info returns a snapshot of the state and counters of the subscription.
+func (s *Subscription) Info() SubscriptionInfo {
+	return s.info()
+}
+
+// setActive records the subscription ID assigned by the server,
+// and calls the OnSubscribed hooks. The client lock must be held.
+func (s *Subscription) setActive(subID uint64) (hooks func()) {
+	s.lifecycle.Lock()
+	defer s.lifecycle.Unlock()
+	if s.state != SubscriptionPending {
+		return func() {}
+	}
+	s.subID = subID
+	s.stopHandshakeTimer()
+	s.state = SubscriptionActive
+	onSubscribed := s.onSubscribed
+	s.onSubscribed = nil
+	return func() {
+		for _, fn := range onSubscribed {
+			fn(subID)
+		}
+	}
+}
+
+// setPending marks an active subscription canceled by the server as waiting
+// for the confirmation of its new subscribe request, and reports whether
+// it was active. The client lock must be held.
+func (s *Subscription) setPending() bool {
+	s.lifecycle.Lock()
+	defer s.lifecycle.Unlock()
+	if s.state != SubscriptionActive {
+		return false
+	}
+	s.state = SubscriptionPending
+	s.subID = 0
+	return true
+}
+
+// setClosed marks the subscription as closed by err, and calls
+// the OnError and OnClosed hooks the first time it is called.
+// The client lock must not be held.
+func (s *Subscription) setClosed(err error) {
+	s.lifecycle.Lock()
+	if s.state == SubscriptionClosed {
+		s.lifecycle.Unlock()
+		return
+	}
+	s.stopHandshakeTimer()
+	s.state = SubscriptionClosed
+	s.closeErr = err
+	close(s.done)
+	onError, onClosed := s.onError, s.onClosed
+	s.onSubscribed, s.onError, s.onClosed = nil, nil, nil
+	s.lifecycle.Unlock()
+
+	if !errors.Is(err, ErrCanceled) {
+		for _, fn := range onError {
+			fn(err)
+		}
+	}
+	for _, fn := range onClosed {
+		fn(err)
+	}
+}
+
+// stopHandshakeTimer stops the subscribe timeout, if any.
+// The lifecycle lock must be held.
+func (s *Subscription) stopHandshakeTimer() {
+	if s.handshakeTimer != nil {
+		s.handshakeTimer.Stop()
+		s.handshakeTimer = nil
+	}
+}
+
+func (s *Subscription) unsubscribe(err error) (ack <-chan error) {
+	return s.closeFunc(err)
 	//close(s.stream)
 	//close(s.err)
 }
+
+// runWithContext runs fn, and returns ctx.Err() without waiting
+// for fn to complete if ctx is done first.
+func runWithContext(ctx context.Context, fn func()) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/gorilla/rpc/v2/json2"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_SubscriptionLifecycle(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	subscribed := make(chan uint64, 1)
+	closed := make(chan error, 1)
+	slotSub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	sub := slotSub.Subscription()
+	sub.OnSubscribed(func(subID uint64) { subscribed <- subID }).
+		OnError(func(err error) { t.Errorf("unexpected error: %v", err) }).
+		OnClosed(func(err error) { closed <- err })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	subID, err := server.WaitForSubscription(ctx, "slotSubscribe")
+	require.NoError(t, err)
+	select {
+	case got := <-subscribed:
+		require.Equal(t, subID, got)
+	case <-ctx.Done():
+		t.Fatal("OnSubscribed was not called")
+	}
+	require.Equal(t, SubscriptionActive, sub.State())
+	require.Equal(t, subID, sub.SubscriptionID())
+	require.True(t, sub.LastMessageTime().IsZero())
+
+	{
+		// Hooks registered late are called immediately.
+		var got uint64
+		sub.OnSubscribed(func(subID uint64) { got = subID })
+		require.Equal(t, subID, got)
+	}
+
+	_, err = server.Notify("slotSubscribe", stdjson.RawMessage(`{"parent":1,"root":0,"slot":2}`))
+	require.NoError(t, err)
+	_, err = sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), sub.Notifications())
+	require.False(t, sub.LastMessageTime().IsZero())
+
+	info := sub.Info()
+	require.Equal(t, SubscriptionActive, info.State)
+	require.Equal(t, uint64(1), info.Notifications)
+	require.Equal(t, sub.LastMessageTime(), info.LastMessage)
+
+	sub.Unsubscribe()
+	require.ErrorIs(t, <-closed, ErrCanceled)
+	require.Equal(t, SubscriptionClosed, sub.State())
+}
+
+func Test_Subscription_setActive_once(t *testing.T) {
+	// e.g. a merged pool subscription, confirmed by each endpoint.
+	sub := newSubscription(newRequest(0, nil, "slotSubscribe", nil), nil, "slotUnsubscribe", nil, 1)
+	var subscribed []uint64
+	sub.OnSubscribed(func(subID uint64) { subscribed = append(subscribed, subID) })
+
+	sub.setActive(7)()
+	sub.setActive(9)()
+	require.Equal(t, uint64(7), sub.SubscriptionID())
+	require.Equal(t, []uint64{7}, subscribed)
+}
+
+func Test_SubscriptionLifecycle_rejected(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in request
+			if err := stdjson.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":%d}`, in.ID)))
+		}
+	}))
+	defer server.Close()
+
+	c, err := Connect(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	failed := make(chan error, 1)
+	slotSub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	sub := slotSub.Subscription()
+	sub.OnError(func(err error) { failed <- err })
+
+	var rpcErr *json2.Error
+	select {
+	case err := <-failed:
+		require.ErrorAs(t, err, &rpcErr)
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnError was not called")
+	}
+	require.Equal(t, json2.ErrorCode(-32602), rpcErr.Code)
+	require.Equal(t, SubscriptionClosed, sub.State())
+	require.Zero(t, sub.SubscriptionID())
+
+	_, err = sub.Recv()
+	require.ErrorAs(t, err, &rpcErr)
+
+	{
+		// Hooks registered late are called immediately.
+		var got error
+		sub.OnClosed(func(err error) { got = err })
+		require.ErrorAs(t, got, &rpcErr)
+	}
+}
+
+func Test_OptionsLogger(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// A notification for an unknown subscription:
+		conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{"parent":1,"root":0,"slot":2},"subscription":42}}`))
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{Logger: zap.New(core)}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	require.Eventually(t, func() bool {
+		return logs.FilterMessage("unable to find subscription for ws message").Len() == 1
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func Test_Subscription_UnsubscribeWithContext(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	slotSub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	sub := slotSub.Subscription()
+	_, err = server.WaitForSubscription(ctx, "slotSubscribe")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return sub.State() == SubscriptionActive }, 5*time.Second, 5*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		_, err = server.Notify("slotSubscribe", stdjson.RawMessage(`{"parent":1,"root":0,"slot":2}`))
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool { return sub.Notifications() == 3 }, 5*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, sub.UnsubscribeWithContext(ctx))
+	// The queued notifications are discarded.
+	_, err = sub.Recv()
+	require.ErrorIs(t, err, ErrCanceled)
+	// Already closed.
+	require.NoError(t, sub.UnsubscribeWithContext(ctx))
+}
+
+func Test_Subscription_UnsubscribeWithContext_notAcknowledged(t *testing.T) {
+	const subID = 7
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		unsubscribes := 0
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in request
+			if err := stdjson.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			if !strings.HasSuffix(in.Method, "Unsubscribe") {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":%d,"id":%d}`, subID, in.ID)))
+				continue
+			}
+			unsubscribes++
+			// A notification racing with the unsubscribe request.
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{"parent":1,"root":0,"slot":2},"subscription":%d}}`, subID)))
+			if unsubscribes == 1 {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":false,"id":%d}`, in.ID)))
+			}
+			// The second unsubscribe request is never answered.
+		}
+	}))
+	defer server.Close()
+
+	c, err := Connect(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	subscribe := func() *Subscription {
+		slotSub, err := c.SlotSubscribe()
+		require.NoError(t, err)
+		sub := slotSub.Subscription()
+		require.Eventually(t, func() bool { return sub.State() == SubscriptionActive }, 5*time.Second, 5*time.Millisecond)
+		return sub
+	}
+
+	sub := subscribe()
+	require.ErrorIs(t, sub.UnsubscribeWithContext(context.Background()), ErrUnsubscribeRejected)
+	require.Equal(t, SubscriptionClosed, sub.State())
+
+	sub = subscribe()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = sub.UnsubscribeWithContext(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Contains(t, err.Error(), "no acknowledgement")
+	require.Equal(t, SubscriptionClosed, sub.State())
+	require.Zero(t, sub.Notifications())
+}
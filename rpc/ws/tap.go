@@ -0,0 +1,64 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"time"
+
+	"github.com/buger/jsonparser"
+)
+
+// Frame is a raw message received from the server (see Options.Tap).
+type Frame struct {
+	// Method of the notification, e.g. "accountNotification";
+	// empty for the responses to requests.
+	Method string
+	// SubscriptionID of the notification, or zero.
+	SubscriptionID uint64
+	// Data is the raw message. It is not shared with the client,
+	// and may be retained.
+	Data []byte
+	// ReceivedAt is the time the message was read from the connection.
+	ReceivedAt time.Time
+}
+
+// TapDrops returns the number of frames dropped because
+// the tap channel was full (see Options.Tap).
+func (c *connection) TapDrops() uint64 {
+	return c.tapDrops.Load()
+}
+
+// tapFrame sends the message to the tap channel, if any, without blocking.
+func (c *connection) tapFrame(message []byte, receivedAt time.Time) {
+	if c.tap == nil {
+		return
+	}
+	select {
+	case c.tap <- newFrame(append([]byte(nil), message...), receivedAt):
+	default:
+		c.tapDrops.Add(1)
+	}
+}
+
+// newFrame returns the frame of the message.
+func newFrame(message []byte, receivedAt time.Time) Frame {
+	frame := Frame{
+		Data:       message,
+		ReceivedAt: receivedAt,
+	}
+	frame.Method, _ = jsonparser.GetString(message, "method")
+	frame.SubscriptionID, _ = getUint64WithOk(message, "params", "subscription")
+	return frame
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Tap(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	tap := make(chan Frame, 10)
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{Tap: tap, ReuseReadBuffer: true}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	start := time.Now()
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	got, err := sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), got.Slot)
+
+	response := <-tap
+	require.Empty(t, response.Method)
+	require.Zero(t, response.SubscriptionID)
+	require.JSONEq(t, fmt.Sprintf(`{"jsonrpc":"2.0","result":1,"id":%d}`, sub.sub.req.ID), string(response.Data))
+
+	notification := <-tap
+	require.Equal(t, "slotNotification", notification.Method)
+	require.Equal(t, uint64(1), notification.SubscriptionID)
+	require.Contains(t, string(notification.Data), `"slot":2`)
+	require.False(t, notification.ReceivedAt.Before(start))
+	require.Zero(t, c.TapDrops())
+}
+
+func Test_Tap_drops(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{Tap: make(chan Frame)}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// The tap is never read, but the notifications are still delivered.
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+	_, err = sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), c.TapDrops())
+}
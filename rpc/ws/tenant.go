@@ -0,0 +1,154 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ForTenant returns a view of the client that shares its connection,
+// and whose subscriptions are tagged with the provided tenant label.
+// The tenant flows into logs and into the introspection API (see Subscriptions).
+//
+// Closing the returned client closes the shared connection.
+func (c *Client) ForTenant(tenant string) *Client {
+	return &Client{
+		connection:       c.connection,
+		tenant:           tenant,
+		ctx:              c.ctx,
+		raw:              c.raw,
+		subscribeTimeout: c.subscribeTimeout,
+	}
+}
+
+// WithContext returns a view of the client that shares its connection,
+// and whose subscriptions are bound to ctx: once ctx is done,
+// they are unsubscribed and their Recv methods return an error
+// matching both ErrCanceled and ctx.Err().
+//
+// Closing the returned client closes the shared connection.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	return &Client{
+		connection:       c.connection,
+		tenant:           c.tenant,
+		ctx:              ctx,
+		raw:              c.raw,
+		subscribeTimeout: c.subscribeTimeout,
+	}
+}
+
+// WithContext returns a view of the client that shares its connection,
+// and whose subscriptions are bound to ctx (see Client.WithContext).
+func (c *HeliusClient) WithContext(ctx context.Context) *HeliusClient {
+	return &HeliusClient{
+		Client: c.Client.WithContext(ctx),
+	}
+}
+
+// subscribeContext returns the context bounding the subscriptions
+// of the client.
+func (c *Client) subscribeContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// ForTenant returns a view of the client that shares its connection,
+// and whose subscriptions are tagged with the provided tenant label.
+func (c *HeliusClient) ForTenant(tenant string) *HeliusClient {
+	return &HeliusClient{
+		Client: c.Client.ForTenant(tenant),
+	}
+}
+
+// Tenant returns the tenant label of the client,
+// or an empty string if the client is not a tenant view.
+func (c *Client) Tenant() string {
+	return c.tenant
+}
+
+// Label returns the label of the connection (see Options.Label).
+func (c *Client) Label() string {
+	return c.label
+}
+
+// SubscriptionInfo describes a subscription.
+type SubscriptionInfo struct {
+	// ID of the subscribe request.
+	RequestID uint64
+	// ID assigned by the server; zero until the subscription is confirmed.
+	SubscriptionID uint64
+	// State of the subscription.
+	State SubscriptionState
+	// Subscribe method, e.g. "accountSubscribe".
+	Method string
+	// Tenant label of the client that created the subscription.
+	Tenant string
+	// Number of notifications delivered to the subscriber.
+	Notifications uint64
+	// Total size in bytes of the notifications delivered to the subscriber.
+	Bytes uint64
+	// Number of notifications waiting to be received, and buffer capacity.
+	Buffered int
+	Capacity int
+	// Number of notifications dropped because the buffer was full
+	// (see Options.DropWhenFull), or above the MaxMessagesPerSecond
+	// of the tenant (see TenantQuota).
+	Drops uint64
+	// Time of the last notification received, or zero if none.
+	LastMessage time.Time
+}
+
+// Subscriptions returns the active subscriptions on the connection,
+// sorted by request ID. On a tenant view, only the subscriptions
+// of that tenant are returned.
+func (c *Client) Subscriptions() []SubscriptionInfo {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	out := make([]SubscriptionInfo, 0, len(c.subscriptionByRequestID))
+	for _, sub := range c.subscriptionByRequestID {
+		if c.tenant != "" && sub.tenant != c.tenant {
+			continue
+		}
+		out = append(out, sub.info())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].RequestID < out[j].RequestID
+	})
+	return out
+}
+
+func (s *Subscription) info() SubscriptionInfo {
+	s.lifecycle.Lock()
+	subID, state := s.subID, s.state
+	s.lifecycle.Unlock()
+	return SubscriptionInfo{
+		RequestID:      s.req.ID,
+		SubscriptionID: subID,
+		State:          state,
+		Method:         s.method,
+		Tenant:         s.tenant,
+		Notifications:  s.notifications.Load(),
+		Bytes:          s.bytes.Load(),
+		Buffered:       len(s.stream),
+		Capacity:       cap(s.stream),
+		Drops:          s.drops.Load(),
+		LastMessage:    s.LastMessageTime(),
+	}
+}
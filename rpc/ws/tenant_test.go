@@ -0,0 +1,186 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// newSubscribeEchoServer starts a websocket server that confirms every
+// subscribe request and then sends one notification for it.
+func newSubscribeEchoServer(t *testing.T) *httptest.Server {
+	var nextSubID uint64
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var in request
+			if err := json.Unmarshal(msg, &in); err != nil {
+				return
+			}
+			if strings.HasSuffix(in.Method, "Unsubscribe") {
+				conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":true,"id":%d}`, in.ID)))
+				continue
+			}
+			subID := atomic.AddUint64(&nextSubID, 1)
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","result":%d,"id":%d}`, subID, in.ID)))
+			conn.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{"parent":1,"root":0,"slot":2},"subscription":%d}}`, subID)))
+		}
+	}))
+}
+
+func Test_ForTenant(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{Label: "shared"}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	alice := c.ForTenant("alice")
+	bob := c.ForTenant("bob")
+	require.Equal(t, "alice", alice.Tenant())
+	require.Equal(t, "shared", alice.Label())
+
+	aliceSub, err := alice.SlotSubscribe()
+	require.NoError(t, err)
+	_, err = aliceSub.Recv()
+	require.NoError(t, err)
+
+	bobSub, err := bob.SlotSubscribe()
+	require.NoError(t, err)
+	_, err = bobSub.Recv()
+	require.NoError(t, err)
+
+	all := c.Subscriptions()
+	require.Len(t, all, 2)
+
+	aliceSubs := alice.Subscriptions()
+	require.Len(t, aliceSubs, 1)
+	require.Equal(t, "alice", aliceSubs[0].Tenant)
+	require.Equal(t, "slotSubscribe", aliceSubs[0].Method)
+	require.Equal(t, uint64(1), aliceSubs[0].Notifications)
+	require.NotZero(t, aliceSubs[0].Bytes)
+	require.NotZero(t, aliceSubs[0].SubscriptionID)
+
+	aliceSub.Unsubscribe()
+	require.Len(t, alice.Subscriptions(), 0)
+	require.Len(t, bob.Subscriptions(), 1)
+}
+
+func Test_TenantQuota(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{
+		TenantQuotas: map[string]TenantQuota{
+			"alice": {MaxSubscriptions: 1, MaxCallsPerMinute: 2},
+		},
+	}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	alice := c.ForTenant("alice")
+	sub, err := alice.SlotSubscribe()
+	require.NoError(t, err)
+
+	// The rejected subscribes don't use up the calls quota.
+	var quotaErr *QuotaExceededError
+	for i := 0; i < 3; i++ {
+		_, err = alice.SlotSubscribe()
+		require.ErrorIs(t, err, ErrQuotaExceeded)
+		require.True(t, errors.As(err, &quotaErr))
+		require.Equal(t, "alice", quotaErr.Tenant)
+		require.Equal(t, QuotaSubscriptions, quotaErr.Kind)
+	}
+
+	// Other tenants are not affected.
+	_, err = c.ForTenant("bob").SlotSubscribe()
+	require.NoError(t, err)
+
+	sub.Unsubscribe()
+	_, err = alice.SlotSubscribe()
+	require.NoError(t, err)
+
+	c.SetTenantQuota("alice", TenantQuota{MaxCallsPerMinute: 1})
+	_, err = alice.SlotSubscribe()
+	require.NoError(t, err)
+	_, err = alice.SlotSubscribe()
+	require.True(t, errors.As(err, &quotaErr))
+	require.Equal(t, QuotaCalls, quotaErr.Kind)
+
+	quota, ok := c.TenantQuota("alice")
+	require.True(t, ok)
+	require.Equal(t, 1, quota.MaxCallsPerMinute)
+}
+
+func Test_TenantQuota_messages(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	quotaExceeded := make(chan *QuotaExceededError, 10)
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{
+		OnQuotaExceeded: func(err *QuotaExceededError) { quotaExceeded <- err },
+	}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	c.SetTenantQuota("alice", TenantQuota{MaxMessagesPerSecond: 0.001})
+	alice := c.ForTenant("alice")
+
+	first, err := alice.SlotSubscribe()
+	require.NoError(t, err)
+	_, err = first.Recv()
+	require.NoError(t, err)
+
+	// The notifications above the rate are dropped, without closing the subscription.
+	second, err := alice.SlotSubscribe()
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return second.Subscription().Info().Drops == 1
+	}, 5*time.Second, time.Millisecond)
+	require.Equal(t, SubscriptionActive, second.Subscription().State())
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = second.RecvWithContext(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	select {
+	case exceeded := <-quotaExceeded:
+		require.Equal(t, "alice", exceeded.Tenant)
+		require.Equal(t, QuotaMessages, exceeded.Kind)
+	default:
+		t.Fatal("OnQuotaExceeded was not called")
+	}
+}
@@ -25,7 +25,7 @@ type TransactionResult struct {
 			Rewards           interface{}   `json:"rewards"`
 			LoadedAddresses   struct {
 				Writable []string `json:"writable"`
-				Readable []string `json:"readable"`
+				Readable []string `json:"readonly"`
 			} `json:"loadedAddresses"`
 			ComputeUnitsConsumed uint64 `json:"computeUnitsConsumed"`
 		} `json:"meta"`
@@ -61,7 +61,7 @@ type TransactionSubscribeOptionsType struct {
 	MaxSupportedTransactionVersion *uint8              `json:"maxSupportedTransactionVersion,omitempty"`
 }
 
-func (c *HeliusClient) transactionSubscribe(filter TransactionSubscribeFilterType, opts TransactionSubscribeOptionsType) (*TransactionSubscription, error) {
+func (c *HeliusClient) transactionSubscribe(ctx context.Context, filter TransactionSubscribeFilterType, opts TransactionSubscribeOptionsType) (*TransactionSubscription, error) {
 	params := rpc.M{}
 	if filter.Vote != nil {
 		params["vote"] = *filter.Vote
@@ -99,7 +99,8 @@ func (c *HeliusClient) transactionSubscribe(filter TransactionSubscribeFilterTyp
 		conf["maxSupportedTransactionVersion"] = *opts.MaxSupportedTransactionVersion
 	}
 
-	genSub, err := c.subscribe(
+	genSub, err := c.subscribeWithContext(
+		ctx,
 		[]interface{}{params},
 		conf,
 		"transactionSubscribe",
@@ -118,30 +119,5 @@ func (c *HeliusClient) transactionSubscribe(filter TransactionSubscribeFilterTyp
 	}, nil
 }
 
-type TransactionSubscription struct {
-	sub *Subscription
-}
-
-func (sw *TransactionSubscription) Recv() (*TransactionResult, error) {
-	select {
-	case d := <-sw.sub.stream:
-		return d.(*TransactionResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *TransactionSubscription) RecvWithContext(ctx context.Context) (*TransactionResult, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case d := <-sw.sub.stream:
-		return d.(*TransactionResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *TransactionSubscription) Unsubscribe() {
-	sw.sub.Unsubscribe()
-}
+// TransactionSubscription is the subscription returned by TransactionSubscribe.
+type TransactionSubscription = TypedSubscription[TransactionResult]
@@ -0,0 +1,128 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRecvTimeout is returned by RecvTimeout when no notification
+// is received before the timeout expires.
+var ErrRecvTimeout = errors.New("timeout waiting for notification")
+
+// ErrRawNotification is returned by the Recv methods of a typed subscription
+// made through a raw view of the client (see Client.WithRawNotifications),
+// whose notifications must be received with NewRawSubscription.
+var ErrRawNotification = errors.New("raw notification on a typed subscription")
+
+// typedNotification returns the notification d decoded as *T, or
+// ErrRawNotification if it was not decoded. The buffer of a pooled
+// raw notification is released, as the caller cannot reach it.
+func typedNotification[T any](d interface{}) (*T, error) {
+	if v, ok := d.(*T); ok {
+		return v, nil
+	}
+	if raw, ok := d.(*RawNotification); ok {
+		raw.Release()
+	}
+	return nil, fmt.Errorf("%w: got %T, expected %T", ErrRawNotification, d, (*T)(nil))
+}
+
+// TypedSubscription is a subscription whose notifications are decoded into *T.
+// The subscriptions returned by the *Subscribe methods of Client
+// (e.g. LogSubscription, AccountSubscription) are instances of it.
+type TypedSubscription[T any] struct {
+	sub *Subscription
+}
+
+// Recv waits for the next notification of the subscription.
+func (sw *TypedSubscription[T]) Recv() (*T, error) {
+	return sw.RecvWithContext(context.Background())
+}
+
+// RecvWithContext waits for the next notification of the subscription,
+// or for ctx to be done, in which case ctx.Err() is returned.
+func (sw *TypedSubscription[T]) RecvWithContext(ctx context.Context) (*T, error) {
+	d, err := sw.sub.RecvWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return typedNotification[T](d)
+}
+
+// RecvTimeout waits for the next notification of the subscription
+// for up to timeout; it returns ErrRecvTimeout if none is received in time.
+// The subscription is left open on timeout.
+func (sw *TypedSubscription[T]) RecvTimeout(timeout time.Duration) (*T, error) {
+	d, err := sw.sub.RecvTimeout(timeout)
+	if err != nil {
+		return nil, err
+	}
+	return typedNotification[T](d)
+}
+
+// RecvWithMeta is like RecvWithContext, and also returns
+// the latency metadata of the notification.
+func (sw *TypedSubscription[T]) RecvWithMeta(ctx context.Context) (*T, NotificationMeta, error) {
+	d, meta, err := sw.sub.RecvWithMeta(ctx)
+	if err != nil {
+		return nil, meta, err
+	}
+	v, err := typedNotification[T](d)
+	return v, meta, err
+}
+
+// Err returns a channel that receives the error that closed the subscription.
+func (sw *TypedSubscription[T]) Err() <-chan error {
+	return sw.sub.err
+}
+
+// Response returns a channel that receives the next notification
+// of the subscription.
+func (sw *TypedSubscription[T]) Response() <-chan *T {
+	typedChan := make(chan *T, 1)
+	go func(ch chan *T) {
+		// TODO: will this subscription yield more than one result?
+		d, ok := <-sw.sub.stream
+		if !ok {
+			return
+		}
+		v, err := typedNotification[T](d.value)
+		if err != nil {
+			return
+		}
+		ch <- v
+	}(typedChan)
+	return typedChan
+}
+
+func (sw *TypedSubscription[T]) Unsubscribe() {
+	sw.sub.Unsubscribe()
+}
+
+// UnsubscribeWithContext unsubscribes, waiting until ctx is done
+// (see Subscription.UnsubscribeWithContext).
+func (sw *TypedSubscription[T]) UnsubscribeWithContext(ctx context.Context) error {
+	return sw.sub.UnsubscribeWithContext(ctx)
+}
+
+// Subscription returns the underlying subscription, e.g. to register
+// lifecycle hooks (see Subscription.OnSubscribed) or to inspect its state.
+func (sw *TypedSubscription[T]) Subscription() *Subscription {
+	return sw.sub
+}
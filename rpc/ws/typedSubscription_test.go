@@ -0,0 +1,53 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TypedSubscription_RecvTimeout(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	sub, err := c.SlotSubscribe()
+	require.NoError(t, err)
+
+	got, err := sub.RecvTimeout(5 * time.Second)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), got.Slot)
+
+	_, err = sub.RecvTimeout(20 * time.Millisecond)
+	require.ErrorIs(t, err, ErrRecvTimeout)
+	require.Equal(t, SubscriptionActive, sub.Subscription().State())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = sub.RecvWithContext(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, sub.UnsubscribeWithContext(context.Background()))
+	_, err = sub.RecvTimeout(5 * time.Second)
+	require.ErrorIs(t, err, ErrCanceled)
+}
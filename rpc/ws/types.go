@@ -18,11 +18,13 @@
 package ws
 
 import (
+	"context"
 	stdjson "encoding/json"
 	"fmt"
-	"math/rand"
 	"net/http"
 	"time"
+
+	"go.uber.org/zap"
 )
 
 type request struct {
@@ -32,7 +34,7 @@ type request struct {
 	ID      uint64      `json:"id"`
 }
 
-func newRequest(params []interface{}, method string, configuration map[string]interface{}) *request {
+func newRequest(id uint64, params []interface{}, method string, configuration map[string]interface{}) *request {
 	if params != nil && configuration != nil {
 		params = append(params, configuration)
 	}
@@ -40,7 +42,7 @@ func newRequest(params []interface{}, method string, configuration map[string]in
 		Version: "2.0",
 		Method:  method,
 		Params:  params,
-		ID:      uint64(rand.Int63()),
+		ID:      id,
 	}
 }
 
@@ -65,12 +67,124 @@ type params struct {
 }
 
 type Options struct {
-	HttpHeader         http.Header
-	HandshakeTimeout   time.Duration
-	PongWait           time.Duration
-	PingPeriod         time.Duration
+	// Label identifies the connection in logs and in the introspection API.
+	Label string
+	// TenantQuotas sets the initial quota of each tenant (see Client.SetTenantQuota).
+	TenantQuotas map[string]TenantQuota
+	// OnQuotaExceeded is called when a tenant starts exceeding its
+	// MaxMessagesPerSecond, i.e. when its notifications start being dropped.
+	OnQuotaExceeded  QuotaExceededFunc
+	HttpHeader       http.Header
+	HandshakeTimeout time.Duration
+	PongWait         time.Duration
+	PingPeriod       time.Duration
+	// UseSubIDRetrievals locates the subscription ID of the logs and
+	// transaction notifications from their expected layout, without parsing them.
 	UseSubIDRetrievals bool
-	DiscardFailedTxs   bool
+	// DiscardFailedTxs drops the logs and transaction notifications
+	// of failed transactions (see DiscardFailedLogs and DiscardFailedTransactions).
+	DiscardFailedTxs bool
+	// TxDiscarders drop notifications before they are decoded, by notification
+	// method (e.g. "logsNotification"); a notification is dropped if any of
+	// the discarders of its method reports it. They are combined with the
+	// discarders enabled by DiscardFailedTxs.
+	TxDiscarders map[string][]TxDiscarder
+
+	// SubscriptionBuffer is the number of notifications buffered for each
+	// subscription. Defaults to DefaultSubscriptionBuffer.
+	SubscriptionBuffer int
+	// DropWhenFull drops the notifications of a subscription whose buffer
+	// is full, instead of closing the subscription.
+	DropWhenFull bool
+	// OnBackpressure is called when the backpressure level of a subscription
+	// changes, so that the application can react (e.g. shed load or narrow
+	// its filters) before notifications are dropped or the subscription closed.
+	OnBackpressure BackpressureFunc
+	// Buffer fill ratios (between 0 and 1) at which a subscription enters
+	// the warning and critical backpressure levels. Default to
+	// DefaultBackpressureWarning and DefaultBackpressureCritical.
+	BackpressureWarning  float64
+	BackpressureCritical float64
+
+	// ReuseReadBuffer reads all messages into the same buffer instead of
+	// allocating one per message. The decoders passed to SubscribeRaw
+	// must then not retain the message they are given.
+	ReuseReadBuffer bool
+
+	// Logger receives the logs of the client, e.g. to scope them
+	// with fields or to set their level per client.
+	// Defaults to the package logger (see github.com/streamingfast/logging).
+	Logger *zap.Logger
+
+	// HealthWindow is the time without any message or pong after which
+	// the connection is considered degraded (see Client.Health).
+	// Defaults to PongWait.
+	HealthWindow time.Duration
+	// OnDegraded is called when the connection becomes degraded, e.g. to fail
+	// over to another endpoint before the connection fails with a read error.
+	OnDegraded HealthFunc
+
+	// Reconnect, if not nil, reestablishes the connection when it fails.
+	// The subscriptions still fail with the error of the connection,
+	// and must be made again once the connection is reestablished
+	// (see OnConnectionStateChange).
+	Reconnect *ReconnectOptions
+	// OnConnectionStateChange is called when the connection is established,
+	// closed, or being reestablished, e.g. to wire alerting and metrics.
+	// It is called from the read loop of the client and must not block.
+	OnConnectionStateChange ConnectionStateFunc
+
+	// ConfirmSubscriptions makes the subscribe methods wait for the server
+	// to confirm the subscription, up to DefaultSubscribeConfirmTimeout,
+	// and return its error (e.g. invalid params) if it rejects it.
+	// Otherwise, the subscribe methods return as soon as the request is sent,
+	// and a rejection is received as the error of the subscription.
+	ConfirmSubscriptions bool
+
+	// Resubscribe, if not nil, decides whether a subscription canceled by
+	// the server with an error notification is made again with the same
+	// parameters (see ResubscribeUpTo); the subscription then keeps
+	// its stream and hooks, and gets a new subscription ID.
+	// Otherwise, the subscription fails with a *ServerCanceledError.
+	Resubscribe ResubscribePolicy
+
+	// SubscribeTimeout is the time the server has to acknowledge a subscribe
+	// request (including a resubscribe, see Resubscribe); past it,
+	// the subscription fails with a *SubscribeTimeoutError, and a late
+	// confirmation is answered with an unsubscribe request.
+	// Zero means no timeout; it can be overridden per subscription
+	// with Client.WithSubscribeTimeout.
+	SubscribeTimeout time.Duration
+
+	// Context bounds the lifetime of the client: once it is done, the client
+	// is closed, and its subscriptions fail with an error matching both
+	// ErrCanceled and the error of the context (see Client.Wait).
+	// The context passed to ConnectWithOptions only bounds the dial.
+	// Defaults to context.Background().
+	Context context.Context
+
+	// DisableCompression disables the negotiation of the permessage-deflate
+	// extension, e.g. to save CPU on a low-latency link
+	// (see Client.CompressionNegotiated).
+	DisableCompression bool
+	// CompressionLevel is the flate level of the messages written when
+	// compression is negotiated; zero keeps the default level.
+	CompressionLevel int
+	// ReadLimit is the maximum size in bytes of a message read from the
+	// connection. A larger message fails the connection, and all its
+	// subscriptions, with a *MessageTooLargeError. Zero means no limit.
+	ReadLimit int64
+	// MaxNotificationSize is the maximum size in bytes of a notification
+	// delivered to a subscription; a larger notification closes the
+	// subscription with a *MessageTooLargeError, without affecting the
+	// others (see Subscription.SetMaxMessageSize). Zero means no limit.
+	MaxNotificationSize int64
+
+	// Tap receives a copy of every message read from the connection,
+	// before it is decoded and routed to its subscription, e.g. to archive
+	// the raw feed or to measure the notification latency.
+	// Frames are dropped when the channel is full (see Client.TapDrops).
+	Tap chan<- Frame
 }
 
 var DefaultHandshakeTimeout = 45 * time.Second
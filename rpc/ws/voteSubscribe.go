@@ -21,12 +21,16 @@ import (
 )
 
 type VoteResult struct {
+	// The vote account, as base58 encoded string.
+	VotePubkey solana.PublicKey `json:"votePubkey"`
 	// The vote hash.
 	Hash solana.Hash `json:"hash"`
 	// The slots covered by the vote.
 	Slots []uint64 `json:"slots"`
 	// The timestamp of the vote.
 	Timestamp *solana.UnixTimeSeconds `json:"timestamp,omitempty"`
+	// The signature of the transaction that contained this vote.
+	Signature solana.Signature `json:"signature"`
 }
 
 // VoteSubscribe (UNSTABLE, disabled by default) subscribes
@@ -57,47 +61,11 @@ func (cl *Client) VoteSubscribe() (*VoteSubscription, error) {
 	}, nil
 }
 
-type VoteSubscription struct {
-	sub *Subscription
+// VoteSubscribeWithContext is like VoteSubscribe, but binds
+// the subscription to ctx (see SubscribeRawWithContext).
+func (cl *Client) VoteSubscribeWithContext(ctx context.Context) (*VoteSubscription, error) {
+	return cl.WithContext(ctx).VoteSubscribe()
 }
 
-func (sw *VoteSubscription) Recv() (*VoteResult, error) {
-	select {
-	case d := <-sw.sub.stream:
-		return d.(*VoteResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *VoteSubscription) RecvWithContext(ctx context.Context) (*VoteResult, error) {
-	select {
-	case <-ctx.Done():
-		return nil, ctx.Err()
-	case d := <-sw.sub.stream:
-		return d.(*VoteResult), nil
-	case err := <-sw.sub.err:
-		return nil, err
-	}
-}
-
-func (sw *VoteSubscription) Err() <-chan error {
-	return sw.sub.err
-}
-
-func (sw *VoteSubscription) Response() <-chan *VoteResult {
-	typedChan := make(chan *VoteResult, 1)
-	go func(ch chan *VoteResult) {
-		// TODO: will this subscription yield more than one result?
-		d, ok := <-sw.sub.stream
-		if !ok {
-			return
-		}
-		ch <- d.(*VoteResult)
-	}(typedChan)
-	return typedChan
-}
-
-func (sw *VoteSubscription) Unsubscribe() {
-	sw.sub.Unsubscribe()
-}
+// VoteSubscription is the subscription returned by VoteSubscribe.
+type VoteSubscription = TypedSubscription[VoteResult]
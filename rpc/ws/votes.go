@@ -0,0 +1,104 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"strings"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+// Invokes reports whether the logs of the transaction show that it invoked
+// any of the provided programs, directly or through a cross-program invocation.
+func (r *LogResult) Invokes(programs ...solana.PublicKey) bool {
+	return logsInvoke(r.Value.Logs, programs)
+}
+
+// IsVote reports whether the transaction invoked the vote program.
+func (r *LogResult) IsVote() bool {
+	return r.Invokes(solana.VoteProgramID)
+}
+
+// Invokes reports whether the transaction has an instruction invoking
+// any of the provided programs. Binary-encoded (base58, base64) transactions
+// are decoded; otherwise the log messages of the transaction are used,
+// which also show the cross-program invocations.
+func (r *TransactionResult) Invokes(programs ...solana.PublicKey) bool {
+	if len(r.Transaction.Transaction) == 2 {
+		if tx, ok := decodeTransaction(r.Transaction.Transaction[0], r.Transaction.Transaction[1]); ok {
+			return invokesAny(tx, programs)
+		}
+	}
+	return logsInvoke(r.Transaction.Meta.LogMessages, programs)
+}
+
+// IsVote reports whether the transaction invoked the vote program.
+func (r *TransactionResult) IsVote() bool {
+	return r.Invokes(solana.VoteProgramID)
+}
+
+// IsVoteTransaction reports whether the transaction has an instruction
+// invoking the vote program.
+func IsVoteTransaction(tx *solana.Transaction) bool {
+	return invokesAny(tx, []solana.PublicKey{solana.VoteProgramID})
+}
+
+// invokesAny reports whether an instruction of tx invokes any of the programs.
+// The program of an instruction is always one of the static account keys.
+func invokesAny(tx *solana.Transaction, programs []solana.PublicKey) bool {
+	for _, inst := range tx.Message.Instructions {
+		if int(inst.ProgramIDIndex) >= len(tx.Message.AccountKeys) {
+			continue
+		}
+		programID := tx.Message.AccountKeys[inst.ProgramIDIndex]
+		for _, program := range programs {
+			if programID == program {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// logsInvoke reports whether the logs show an invocation of any of the programs.
+func logsInvoke(logs []string, programs []solana.PublicKey) bool {
+	prefixes := make([]string, len(programs))
+	for i, program := range programs {
+		prefixes[i] = "Program " + program.String() + " invoke ["
+	}
+	for _, log := range logs {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(log, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeTransaction decodes a binary-encoded transaction;
+// ok is false if the encoding is not supported or the data is invalid.
+func decodeTransaction(data string, encoding string) (tx *solana.Transaction, ok bool) {
+	var err error
+	switch solana.EncodingType(encoding) {
+	case solana.EncodingBase64:
+		tx, err = solana.TransactionFromBase64(data)
+	case solana.EncodingBase58:
+		tx, err = solana.TransactionFromBase58(data)
+	default:
+		return nil, false
+	}
+	return tx, err == nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+	"github.com/gagliardetto/solana-go/rpc/rpctest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoteSubscribe(t *testing.T) {
+	server := rpctest.NewServer()
+	defer server.Close()
+
+	c, err := Connect(context.Background(), server.WSURL())
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := c.VoteSubscribe()
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	_, err = server.WaitForSubscription(ctx, "voteSubscribe")
+	require.NoError(t, err)
+
+	_, err = server.Notify("voteSubscribe", rpc.M{
+		"votePubkey": "Vote111111111111111111111111111111111111111",
+		"slots":      []uint64{1, 2},
+		"hash":       "8Rshv2oMkPu5E4opXTRyuyBeZBqQ4S477VG26wUTFxUM",
+		"timestamp":  1700000000,
+		"signature":  "5h6xBEauJ3PK6SWCZ1PGjBvj8vDdWG3KpwATGy1ARAXFSDwt8GFXM7W5Ncn16wmqokgpiKRLuS83KUxyZyv2sUYv",
+	})
+	require.NoError(t, err)
+
+	vote, err := sub.RecvWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, solana.VoteProgramID, vote.VotePubkey)
+	require.Equal(t, []uint64{1, 2}, vote.Slots)
+	require.Equal(t, solana.MustHashFromBase58("8Rshv2oMkPu5E4opXTRyuyBeZBqQ4S477VG26wUTFxUM"), vote.Hash)
+	require.Equal(t, solana.UnixTimeSeconds(1700000000), *vote.Timestamp)
+	require.Equal(t, solana.MustSignatureFromBase58("5h6xBEauJ3PK6SWCZ1PGjBvj8vDdWG3KpwATGy1ARAXFSDwt8GFXM7W5Ncn16wmqokgpiKRLuS83KUxyZyv2sUYv"), vote.Signature)
+}
+
+func newTestTransaction(t *testing.T, programID solana.PublicKey) *solana.Transaction {
+	payer := solana.NewWallet().PublicKey()
+	tx, err := solana.NewTransaction(
+		[]solana.Instruction{solana.NewInstruction(programID, solana.AccountMetaSlice{solana.Meta(payer).WRITE().SIGNER()}, []byte{1})},
+		solana.Hash{},
+		solana.TransactionPayer(payer),
+	)
+	require.NoError(t, err)
+	tx.Signatures = make([]solana.Signature, 1)
+	return tx
+}
+
+func transactionNotification(t *testing.T, tx *solana.Transaction) []byte {
+	return []byte(fmt.Sprintf(
+		`{"jsonrpc":"2.0","method":"transactionNotification","params":{"subscription":1,"result":{"signature":"sig","slot":1,"transaction":{"transaction":["%s","base64"],"meta":{"err":null,"fee":5000}}}}}`,
+		tx.MustToBase64(),
+	))
+}
+
+func TestIsVote(t *testing.T) {
+	vote := newTestTransaction(t, solana.VoteProgramID)
+	transfer := newTestTransaction(t, solana.SystemProgramID)
+	require.True(t, IsVoteTransaction(vote))
+	require.False(t, IsVoteTransaction(transfer))
+
+	var res TransactionResult
+	res.Transaction.Transaction = []string{vote.MustToBase64(), "base64"}
+	require.True(t, res.IsVote())
+	res.Transaction.Transaction = []string{transfer.MustToBase64(), "base64"}
+	require.False(t, res.IsVote())
+	require.True(t, res.Invokes(solana.SystemProgramID))
+
+	// Without a binary transaction, the logs are used.
+	res.Transaction.Transaction = nil
+	res.Transaction.Meta.LogMessages = []string{"Program Vote111111111111111111111111111111111111111 invoke [1]"}
+	require.True(t, res.IsVote())
+
+	var logs LogResult
+	logs.Value.Logs = []string{"Program 11111111111111111111111111111111 invoke [1]", "Program 11111111111111111111111111111111 success"}
+	require.False(t, logs.IsVote())
+	logs.Value.Logs = append(logs.Value.Logs, "Program Vote111111111111111111111111111111111111111 invoke [2]")
+	require.True(t, logs.IsVote())
+}
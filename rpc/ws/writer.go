@@ -0,0 +1,118 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// ErrConnectionClosed is returned when writing to a closed connection.
+var ErrConnectionClosed = errors.New("connection closed")
+
+// writeQueueSize is the number of outbound messages that can be queued
+// before writers block.
+const writeQueueSize = 64
+
+// outboundMessage is a message queued for the writer goroutine.
+type outboundMessage struct {
+	messageType int
+	data        []byte
+	// done receives the result of the write;
+	// it is nil when the caller does not wait for it.
+	done chan error
+}
+
+// writeMessages writes the queued messages to the connection, one at a time,
+// so that callers never hold a lock while waiting on the network.
+// After a failed write the connection is closed, so that the reader
+// fails the subscriptions, and the remaining messages fail with the same error
+// until the connection is replaced.
+func (c *connection) writeMessages() {
+	// failed is the connection on which a write failed, if any;
+	// the writes resume once it is replaced (see Options.Reconnect).
+	var failed *websocket.Conn
+	var writeErr error
+	for {
+		select {
+		case <-c.connCtx.Done():
+			return
+		case msg := <-c.writes:
+			conn := c.currentConn()
+			err := writeErr
+			if conn != failed {
+				conn.SetWriteDeadline(time.Now().Add(writeWait))
+				err = conn.WriteMessage(msg.messageType, msg.data)
+				if err != nil {
+					failed, writeErr = conn, err
+					c.log().Warn("unable to write to ws connection, closing it",
+						zap.String("label", c.label),
+						zap.Error(err),
+					)
+					conn.Close()
+				}
+			}
+			if msg.done != nil {
+				msg.done <- err
+			} else if err != nil && msg.messageType != websocket.PingMessage {
+				c.log().Debug("unable to write queued message", zap.Error(err))
+			}
+		}
+	}
+}
+
+// enqueue queues the message for the writer goroutine.
+func (c *connection) enqueue(ctx context.Context, msg *outboundMessage) error {
+	select {
+	case c.writes <- msg:
+		return nil
+	case <-c.connCtx.Done():
+		return ErrConnectionClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// write queues the message and waits until it is written.
+func (c *connection) write(ctx context.Context, messageType int, data []byte) error {
+	msg := &outboundMessage{
+		messageType: messageType,
+		data:        data,
+		done:        make(chan error, 1),
+	}
+	if err := c.enqueue(ctx, msg); err != nil {
+		return err
+	}
+	select {
+	case err := <-msg.done:
+		return err
+	case <-c.connCtx.Done():
+		return ErrConnectionClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// send queues the message without waiting for it to be written.
+func (c *connection) send(messageType int, data []byte) error {
+	return c.enqueue(context.Background(), &outboundMessage{
+		messageType: messageType,
+		data:        data,
+	})
+}
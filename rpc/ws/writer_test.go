@@ -0,0 +1,68 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ws
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteQueue_concurrentWrites(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), &Options{
+		PongWait:   time.Second,
+		PingPeriod: time.Millisecond,
+	}, nil)
+	require.NoError(t, err)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sub, err := c.SlotSubscribe()
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = sub.Recv()
+			assert.NoError(t, err)
+			sub.Unsubscribe()
+		}()
+	}
+	wg.Wait()
+	require.Len(t, c.Subscriptions(), 0)
+}
+
+func Test_WriteQueue_closed(t *testing.T) {
+	server := newSubscribeEchoServer(t)
+	defer server.Close()
+
+	c, err := ConnectWithOptions(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http"), nil, nil)
+	require.NoError(t, err)
+	c.Close()
+
+	_, err = c.SlotSubscribe()
+	require.ErrorIs(t, err, ErrConnectionClosed)
+	require.Len(t, c.Subscriptions(), 0)
+}
@@ -0,0 +1,28 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+// Signer signs messages on behalf of an account.
+//
+// PrivateKey implements Signer; other implementations can keep the key
+// outside of the process, e.g. in a KMS, an HSM, a vault or a Ledger.
+type Signer interface {
+	// PublicKey returns the public key of the account.
+	PublicKey() PublicKey
+	// Sign returns the ed25519 signature of the message.
+	Sign(message []byte) (Signature, error)
+}
+
+var _ Signer = PrivateKey(nil)
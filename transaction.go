@@ -26,7 +26,6 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	bin "github.com/gagliardetto/binary"
 	"github.com/gagliardetto/treeout"
-	"github.com/mr-tron/base58"
 	"go.uber.org/zap"
 
 	"github.com/gagliardetto/solana-go/text"
@@ -102,7 +101,7 @@ func TransactionFromBase64(b64 string) (*Transaction, error) {
 }
 
 func TransactionFromBase58(b58 string) (*Transaction, error) {
-	data, err := base58.Decode(b58)
+	data, err := decodeBase58(b58)
 	if err != nil {
 		return nil, err
 	}
@@ -160,6 +159,7 @@ type TransactionOption interface {
 type transactionOptions struct {
 	payer         PublicKey
 	addressTables map[PublicKey]PublicKeySlice // [tablePubkey]addresses
+	v0            bool
 }
 
 type transactionOptionFunc func(opts *transactionOptions)
@@ -182,6 +182,7 @@ type TransactionBuilder struct {
 	instructions    []Instruction
 	recentBlockHash Hash
 	opts            []TransactionOption
+	addressTables   map[PublicKey]PublicKeySlice
 }
 
 // NewTransactionBuilder creates a new instruction builder.
@@ -253,8 +254,17 @@ func NewTransaction(instructions []Instruction, recentBlockHash Hash, opts ...Tr
 		}
 	}
 
+	// Iterate the tables in a fixed order, so that an address present
+	// in several tables is always looked up in the same one.
+	addressTablePubKeys := make(PublicKeySlice, 0, len(options.addressTables))
+	for addressTablePubKey := range options.addressTables {
+		addressTablePubKeys = append(addressTablePubKeys, addressTablePubKey)
+	}
+	addressTablePubKeys.Sort()
+
 	addressLookupKeysMap := make(map[PublicKey]addressTablePubkeyWithIndex) // all accounts from tables as map
-	for addressTablePubKey, addressTable := range options.addressTables {
+	for _, addressTablePubKey := range addressTablePubKeys {
+		addressTable := options.addressTables[addressTablePubKey]
 		if len(addressTable) > 256 {
 			return nil, fmt.Errorf("max lookup table index exceeded for %s table", addressTablePubKey)
 		}
@@ -407,7 +417,11 @@ func NewTransaction(instructions []Instruction, recentBlockHash Hash, opts ...Tr
 	if len(lookupsMap) > 0 {
 		lookups := make([]MessageAddressTableLookup, 0, len(lookupsMap))
 
-		for tablePubKey, l := range lookupsMap {
+		for _, tablePubKey := range addressTablePubKeys {
+			l, ok := lookupsMap[tablePubKey]
+			if !ok {
+				continue
+			}
 			lookupsWritableKeys = append(lookupsWritableKeys, l.Writable...)
 			lookupsReadOnlyKeys = append(lookupsReadOnlyKeys, l.Readonly...)
 
@@ -466,6 +480,10 @@ func NewTransaction(instructions []Instruction, recentBlockHash Hash, opts ...Tr
 		})
 	}
 
+	if options.v0 {
+		message.SetVersion(MessageVersionV0)
+	}
+
 	return &Transaction{
 		Message: message,
 	}, nil
@@ -529,36 +547,145 @@ func (tx *Transaction) UnmarshalWithDecoder(decoder *bin.Decoder) (err error) {
 	return nil
 }
 
+// PartialSign signs the transaction with the private keys returned by getter
+// for the signers of the message; getter returns nil for the keys held
+// by other parties. See PartialSignWith.
 func (tx *Transaction) PartialSign(getter privateKeyGetter) (out []Signature, err error) {
+	var signers []Signer
+	for _, key := range tx.Message.signerKeys() {
+		if privateKey := getter(key); privateKey != nil {
+			signers = append(signers, *privateKey)
+		}
+	}
+	return tx.PartialSignWith(signers...)
+}
+
+func (tx *Transaction) Sign(getter privateKeyGetter) (out []Signature, err error) {
+	signerKeys := tx.Message.signerKeys()
+	for _, key := range signerKeys {
+		if getter(key) == nil {
+			return nil, fmt.Errorf("signer key %q not found. Ensure all the signer keys are in the vault", key.String())
+		}
+	}
+	return tx.PartialSign(getter)
+}
+
+// SignWith signs the transaction with the provided signers,
+// which must include all the signers of the message.
+// See PartialSignWith.
+func (tx *Transaction) SignWith(signers ...Signer) (out []Signature, err error) {
+	for _, key := range tx.Message.signerKeys() {
+		if findSigner(signers, key) == nil {
+			return nil, fmt.Errorf("signer key %q not found. Ensure all the signers are provided", key.String())
+		}
+	}
+	return tx.PartialSignWith(signers...)
+}
+
+// PartialSignWith signs the transaction with the provided signers that are
+// signers of the message. Each signature is placed at the index of its signer,
+// so that several parties can sign the transaction in turn;
+// the signatures of the other signers are left untouched.
+//
+// The signatures are verified, so that a faulty remote signer
+// cannot produce an invalid transaction.
+func (tx *Transaction) PartialSignWith(signers ...Signer) (out []Signature, err error) {
 	messageContent, err := tx.Message.MarshalBinary()
 	if err != nil {
 		return nil, fmt.Errorf("unable to encode message for signing: %w", err)
 	}
 	signerKeys := tx.Message.signerKeys()
+	tx.padSignatures(len(signerKeys))
 
-	signedSignatures := []Signature{}
-	for _, key := range signerKeys {
-		privateKey := getter(key)
-		if privateKey != nil {
-			s, err := privateKey.Sign(messageContent)
-			if err != nil {
-				return nil, fmt.Errorf("failed to signed with key %q: %w", key.String(), err)
-			}
-			signedSignatures = append(signedSignatures, s)
+	for i, key := range signerKeys {
+		signer := findSigner(signers, key)
+		if signer == nil {
+			continue
 		}
+		s, err := signer.Sign(messageContent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to signed with key %q: %w", key.String(), err)
+		}
+		if !s.Verify(key, messageContent) {
+			return nil, fmt.Errorf("invalid signature returned by signer %q", key.String())
+		}
+		tx.Signatures[i] = s
 	}
-	tx.Signatures = append(tx.Signatures, signedSignatures...)
 	return tx.Signatures, nil
 }
 
-func (tx *Transaction) Sign(getter privateKeyGetter) (out []Signature, err error) {
+// MessageToSign returns the serialized message, which each signer
+// of the transaction signs, e.g. with an external signer.
+func (tx *Transaction) MessageToSign() ([]byte, error) {
+	return tx.Message.MarshalBinary()
+}
+
+// AddSignature sets the signature of the provided signer of the message,
+// e.g. returned by an external signer or a co-signing service.
+// The signature is verified against the message before being added.
+func (tx *Transaction) AddSignature(signer PublicKey, signature Signature) error {
+	messageContent, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("unable to encode message: %w", err)
+	}
 	signerKeys := tx.Message.signerKeys()
-	for _, key := range signerKeys {
-		if getter(key) == nil {
-			return nil, fmt.Errorf("signer key %q not found. Ensure all the signer keys are in the vault", key.String())
+	index := -1
+	for i, key := range signerKeys {
+		if key.Equals(signer) {
+			index = i
+			break
 		}
 	}
-	return tx.PartialSign(getter)
+	if index == -1 {
+		return fmt.Errorf("%s is not a signer of the transaction", signer)
+	}
+	if !signature.Verify(signer, messageContent) {
+		return fmt.Errorf("invalid signature by %s", signer)
+	}
+	tx.padSignatures(len(signerKeys))
+	tx.Signatures[index] = signature
+	return nil
+}
+
+// MissingSigners returns the signers of the message
+// whose signature is missing, in the order of the signatures.
+func (tx *Transaction) MissingSigners() PublicKeySlice {
+	var missing PublicKeySlice
+	for i, key := range tx.Message.signerKeys() {
+		if i >= len(tx.Signatures) || tx.Signatures[i].IsZero() {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// ToBase64ForSigning returns the wire format of the (partially signed)
+// transaction encoded in base64, with a zero signature in place of each
+// missing one, as expected by wallets and multisig services (e.g. Squads)
+// signing serialized transactions. Decode the signed transaction
+// with TransactionFromBase64.
+func (tx *Transaction) ToBase64ForSigning() (string, error) {
+	tx.padSignatures(len(tx.Message.signerKeys()))
+	return tx.ToBase64()
+}
+
+// padSignatures resizes the signatures to the number of signers,
+// keeping the existing ones.
+func (tx *Transaction) padSignatures(signers int) {
+	if len(tx.Signatures) != signers {
+		signatures := make([]Signature, signers)
+		copy(signatures, tx.Signatures)
+		tx.Signatures = signatures
+	}
+}
+
+func findSigner(signers []Signer, key PublicKey) Signer {
+	for _, signer := range signers {
+		if signer.PublicKey().Equals(key) {
+			return signer
+		}
+	}
+	return nil
 }
 
 func (tx *Transaction) EncodeTree(encoder *text.TreeEncoder) (int, error) {
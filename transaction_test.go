@@ -19,6 +19,7 @@ package solana
 
 import (
 	"encoding/base64"
+	"fmt"
 	"testing"
 
 	bin "github.com/gagliardetto/binary"
@@ -138,7 +139,11 @@ func TestPartialSignTransaction(t *testing.T) {
 		return nil
 	})
 	require.NoError(t, err)
-	assert.Equal(t, len(signatures), 1)
+	// The signature of the other signer is left empty, at its index.
+	require.Len(t, signatures, 2)
+	assert.False(t, signatures[0].IsZero())
+	assert.True(t, signatures[1].IsZero())
+	assert.Equal(t, PublicKeySlice{signers[1].PublicKey()}, trx.MissingSigners())
 }
 
 func TestSignTransaction(t *testing.T) {
@@ -189,6 +194,107 @@ func TestSignTransaction(t *testing.T) {
 	})
 }
 
+type badSigner struct {
+	PrivateKey
+}
+
+func (s badSigner) Sign(message []byte) (Signature, error) {
+	return s.PrivateKey.Sign(append(message, 0))
+}
+
+func TestSignTransactionWithSigners(t *testing.T) {
+	signers := []PrivateKey{
+		NewWallet().PrivateKey,
+		NewWallet().PrivateKey,
+	}
+	instructions := []Instruction{
+		&testTransactionInstructions{
+			accounts: []*AccountMeta{
+				{PublicKey: signers[0].PublicKey(), IsSigner: true, IsWritable: false},
+				{PublicKey: signers[1].PublicKey(), IsSigner: true, IsWritable: true},
+			},
+			data:      []byte{0xaa, 0xbb},
+			programID: MustPublicKeyFromBase58("11111111111111111111111111111111"),
+		},
+	}
+
+	blockhash, err := HashFromBase58("A9QnpgfhCkmiBSjgBuWk76Wo3HxzxvDopUq9x6UUMmjn")
+	require.NoError(t, err)
+
+	trx, err := NewTransaction(instructions, blockhash)
+	require.NoError(t, err)
+
+	_, err = trx.SignWith(signers[1])
+	require.Error(t, err)
+
+	_, err = trx.PartialSignWith(badSigner{signers[1]})
+	require.Error(t, err)
+
+	// Sign in turn, in the reverse order of the signers.
+	signatures, err := trx.PartialSignWith(signers[1])
+	require.NoError(t, err)
+	require.Len(t, signatures, 2)
+	require.True(t, signatures[0].IsZero())
+	require.Error(t, trx.VerifySignatures())
+
+	signatures, err = trx.PartialSignWith(signers[0])
+	require.NoError(t, err)
+	require.Len(t, signatures, 2)
+	require.NoError(t, trx.VerifySignatures())
+
+	signatures, err = trx.SignWith(signers[0], signers[1])
+	require.NoError(t, err)
+	require.Len(t, signatures, 2)
+	require.NoError(t, trx.VerifySignatures())
+}
+
+func TestMultiPartySigning(t *testing.T) {
+	feePayer := NewWallet().PrivateKey
+	owner := NewWallet().PrivateKey
+	instructions := []Instruction{
+		&testTransactionInstructions{
+			accounts: []*AccountMeta{
+				{PublicKey: owner.PublicKey(), IsSigner: true, IsWritable: true},
+			},
+			data:      []byte{0xaa, 0xbb},
+			programID: MustPublicKeyFromBase58("11111111111111111111111111111111"),
+		},
+	}
+	blockhash, err := HashFromBase58("A9QnpgfhCkmiBSjgBuWk76Wo3HxzxvDopUq9x6UUMmjn")
+	require.NoError(t, err)
+	trx, err := NewTransaction(instructions, blockhash, TransactionPayer(feePayer.PublicKey()))
+	require.NoError(t, err)
+
+	// The owner signs first, and exports the transaction for the fee payer service.
+	_, err = trx.PartialSign(func(key PublicKey) *PrivateKey {
+		if key.Equals(owner.PublicKey()) {
+			return &owner
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	exported, err := trx.ToBase64ForSigning()
+	require.NoError(t, err)
+
+	received, err := TransactionFromBase64(exported)
+	require.NoError(t, err)
+	require.Equal(t, PublicKeySlice{feePayer.PublicKey()}, received.MissingSigners())
+
+	message, err := received.MessageToSign()
+	require.NoError(t, err)
+	signature, err := feePayer.Sign(message)
+	require.NoError(t, err)
+
+	require.EqualError(t, received.AddSignature(owner.PublicKey(), signature), fmt.Sprintf("invalid signature by %s", owner.PublicKey()))
+	stranger := NewWallet().PublicKey()
+	require.EqualError(t, received.AddSignature(stranger, signature), fmt.Sprintf("%s is not a signer of the transaction", stranger))
+
+	require.NoError(t, received.AddSignature(feePayer.PublicKey(), signature))
+	require.Empty(t, received.MissingSigners())
+	require.NoError(t, received.VerifySignatures())
+	require.Equal(t, signature, received.Signatures[0])
+}
+
 func FuzzTransaction(f *testing.F) {
 	encoded := "AfjEs3XhTc3hrxEvlnMPkm/cocvAUbFNbCl00qKnrFue6J53AhEqIFmcJJlJW3EDP5RmcMz+cNTTcZHW/WJYwAcBAAEDO8hh4VddzfcO5jbCt95jryl6y8ff65UcgukHNLWH+UQGgxCGGpgyfQVQV02EQYqm4QwzUt2qf9f1gVLM7rI4hwAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA6ANIF55zOZWROWRkeh+lExxZBnKFqbvIxZDLE7EijjoBAgIAAQwCAAAAOTAAAAAAAAA="
 	data, err := base64.StdEncoding.DecodeString(encoded)
@@ -0,0 +1,154 @@
+// Copyright 2021 github.com/gagliardetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package solana
+
+import (
+	"errors"
+	"fmt"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+// MaxTransactionSize is the maximum size of a serialized transaction,
+// signatures included (the PACKET_DATA_SIZE of the Solana runtime).
+const MaxTransactionSize = 1232
+
+var ErrTransactionTooLarge = errors.New("transaction too large")
+
+// TransactionV0 builds a versioned (v0) message, even if none of the
+// accounts are loaded from address tables (see TransactionAddressTables).
+func TransactionV0() TransactionOption {
+	return transactionOptionFunc(func(opts *transactionOptions) { opts.v0 = true })
+}
+
+// AddAddressTable adds an address lookup table, with the addresses it holds,
+// to the tables used by BuildV0.
+func (builder *TransactionBuilder) AddAddressTable(table PublicKey, addresses PublicKeySlice) *TransactionBuilder {
+	if builder.addressTables == nil {
+		builder.addressTables = make(map[PublicKey]PublicKeySlice)
+	}
+	builder.addressTables[table] = addresses
+	return builder
+}
+
+// BuildV0 builds a versioned (v0) transaction. The accounts found in the
+// address tables added with AddAddressTable are loaded from the tables,
+// except for the signers and the invoked programs, which must be static.
+//
+// It returns a *TransactionSizeError if the transaction, once signed,
+// would exceed MaxTransactionSize.
+func (builder *TransactionBuilder) BuildV0() (*Transaction, error) {
+	opts := append([]TransactionOption{}, builder.opts...)
+	if builder.addressTables != nil {
+		opts = append(opts, TransactionAddressTables(builder.addressTables))
+	}
+	opts = append(opts, TransactionV0())
+
+	tx, err := NewTransaction(builder.instructions, builder.recentBlockHash, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.CheckSize(); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Size returns the size of the serialized transaction, counting
+// the signatures required by the message even if they are not set yet.
+func (tx *Transaction) Size() (int, error) {
+	message, err := tx.Message.MarshalBinary()
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode tx.Message to binary: %w", err)
+	}
+	numSignatures := len(tx.Signatures)
+	if required := int(tx.Message.Header.NumRequiredSignatures); required > numSignatures {
+		numSignatures = required
+	}
+	var signatureCount []byte
+	bin.EncodeCompactU16Length(&signatureCount, numSignatures)
+	return len(signatureCount) + numSignatures*SignatureLength + len(message), nil
+}
+
+// CheckSize returns a *TransactionSizeError if the transaction,
+// once signed, exceeds MaxTransactionSize.
+func (tx *Transaction) CheckSize() error {
+	size, err := tx.Size()
+	if err != nil {
+		return err
+	}
+	if size <= MaxTransactionSize {
+		return nil
+	}
+
+	mx := &tx.Message
+	staticKeys := mx.getStaticKeys()
+	report := &TransactionSizeError{
+		Size:              size,
+		Limit:             MaxTransactionSize,
+		Signatures:        int(mx.Header.NumRequiredSignatures),
+		StaticAccountKeys: len(staticKeys),
+		LookupTables:      len(mx.AddressTableLookups),
+		LookupAccountKeys: mx.AddressTableLookups.NumLookups(),
+		Instructions:      len(mx.Instructions),
+	}
+	invoked := make(map[uint16]struct{}, len(mx.Instructions))
+	for _, inst := range mx.Instructions {
+		report.InstructionDataBytes += len(inst.Data)
+		invoked[inst.ProgramIDIndex] = struct{}{}
+	}
+	for i := int(mx.Header.NumRequiredSignatures); i < len(staticKeys); i++ {
+		if _, ok := invoked[uint16(i)]; !ok {
+			report.UncompressedKeys = append(report.UncompressedKeys, staticKeys[i])
+		}
+	}
+	return report
+}
+
+// TransactionSizeError reports what makes up a transaction
+// that exceeds MaxTransactionSize.
+type TransactionSizeError struct {
+	// Size of the signed transaction, in bytes.
+	Size  int
+	Limit int
+
+	Signatures        int
+	StaticAccountKeys int
+	LookupTables      int
+	LookupAccountKeys int
+
+	Instructions         int
+	InstructionDataBytes int
+
+	// UncompressedKeys are the static account keys that are neither signers
+	// nor invoked programs: adding them to an address table would save
+	// 31 bytes each.
+	UncompressedKeys PublicKeySlice
+}
+
+func (e *TransactionSizeError) Error() string {
+	return fmt.Sprintf(
+		"transaction too large: %d bytes (max %d): %d signatures, %d static account keys (%d could be loaded from address tables), %d keys loaded from %d address tables, %d instructions with %d bytes of data",
+		e.Size, e.Limit,
+		e.Signatures,
+		e.StaticAccountKeys, len(e.UncompressedKeys),
+		e.LookupAccountKeys, e.LookupTables,
+		e.Instructions, e.InstructionDataBytes,
+	)
+}
+
+func (e *TransactionSizeError) Unwrap() error {
+	return ErrTransactionTooLarge
+}
@@ -1,6 +1,7 @@
 package solana
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
@@ -118,3 +119,83 @@ func TestTransactionV0(t *testing.T) {
 		require.Equal(t, txB64, encoded)
 	}
 }
+
+func TestTransactionBuilder_BuildV0(t *testing.T) {
+	payer := NewWallet().PublicKey()
+	program := NewWallet().PublicKey()
+	table1 := MPK("9WWfC3y4uCNofr2qEFHSVUXkCxW99JiYkMWmSZvVt8j3")
+	table2 := MPK("2m4eNwBVqu6SgFk23HgE3W5MW89yT5z1vspz2WsiFBHF")
+
+	accounts := make(AccountMetaSlice, 40)
+	addresses := make(PublicKeySlice, len(accounts))
+	for i := range accounts {
+		addresses[i] = NewWallet().PublicKey()
+		accounts[i] = Meta(addresses[i])
+		if i%2 == 0 {
+			accounts[i].WRITE()
+		}
+	}
+	instruction := NewInstruction(program, append(AccountMetaSlice{Meta(payer).SIGNER().WRITE()}, accounts...), []byte{1, 2, 3})
+
+	// Without tables, the 40 static keys overflow the packet size.
+	_, err := NewTransactionBuilder().
+		AddInstruction(instruction).
+		SetFeePayer(payer).
+		BuildV0()
+	require.ErrorIs(t, err, ErrTransactionTooLarge)
+	var sizeErr *TransactionSizeError
+	require.True(t, errors.As(err, &sizeErr))
+	require.Greater(t, sizeErr.Size, MaxTransactionSize)
+	require.Equal(t, 1, sizeErr.Signatures)
+	require.Equal(t, 42, sizeErr.StaticAccountKeys)
+	require.Len(t, sizeErr.UncompressedKeys, 40)
+	require.Contains(t, err.Error(), "40 could be loaded from address tables")
+
+	// The payer and the program are kept static even if they are in a table;
+	// addresses present in both tables are loaded from the same table every time.
+	tx, err := NewTransactionBuilder().
+		AddInstruction(instruction).
+		SetFeePayer(payer).
+		AddAddressTable(table1, append(PublicKeySlice{payer, program}, addresses[:30]...)).
+		AddAddressTable(table2, addresses[20:]).
+		BuildV0()
+	require.NoError(t, err)
+	require.True(t, tx.Message.IsVersioned())
+	require.Equal(t, PublicKeySlice{payer, program}, tx.Message.AccountKeys)
+	require.Equal(t, PublicKeySlice{table2, table1}, tx.Message.GetAddressTableLookups().GetTableIDs())
+	require.Equal(t, 40, tx.Message.AddressTableLookups.NumLookups())
+	require.NoError(t, tx.CheckSize())
+
+	size, err := tx.Size()
+	require.NoError(t, err)
+	require.Less(t, size, MaxTransactionSize)
+
+	decoded, err := TransactionFromBytes(mustMarshal(t, tx))
+	require.NoError(t, err)
+	require.NoError(t, decoded.Message.SetAddressTables(map[PublicKey]PublicKeySlice{
+		table1: append(PublicKeySlice{payer, program}, addresses[:30]...),
+		table2: addresses[20:],
+	}))
+	require.NoError(t, decoded.Message.ResolveLookups())
+	instructions := decoded.Message.Instructions
+	require.Len(t, instructions, 1)
+	resolved, err := instructions[0].ResolveInstructionAccounts(&decoded.Message)
+	require.NoError(t, err)
+	for i, account := range accounts {
+		require.Equal(t, account.PublicKey, resolved[i+1].PublicKey)
+		require.Equal(t, account.IsWritable, resolved[i+1].IsWritable)
+	}
+
+	// Without tables, BuildV0 still produces a v0 message.
+	tx, err = NewTransactionBuilder().
+		AddInstruction(NewInstruction(program, AccountMetaSlice{Meta(payer).SIGNER().WRITE()}, nil)).
+		BuildV0()
+	require.NoError(t, err)
+	require.True(t, tx.Message.IsVersioned())
+}
+
+func mustMarshal(t *testing.T, tx *Transaction) []byte {
+	out, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	return out
+}